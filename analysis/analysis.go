@@ -0,0 +1,289 @@
+// Package analysis computes waveform peaks, tempo and loudness for show
+// audio, so the timeline can draw waveforms and snap to beats without every
+// track being decoded in the webview.
+//
+// Only WAV input is decoded here; compressed formats are left to the
+// frontend's Web Audio decoder.
+package analysis
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+)
+
+// ErrUnsupported is returned for audio this package cannot decode.
+var ErrUnsupported = errors.New("unsupported audio format (only WAV can be analyzed)")
+
+// Audio is decoded PCM mixed down to mono.
+type Audio struct {
+	SampleRate int
+	Mono       []float32 // -1..1
+}
+
+// DurationMs is the length of the audio.
+func (a *Audio) DurationMs() int64 {
+	if a.SampleRate == 0 {
+		return 0
+	}
+	return int64(len(a.Mono)) * 1000 / int64(a.SampleRate)
+}
+
+const (
+	wavFormatPCM        = 1
+	wavFormatFloat      = 3
+	wavFormatExtensible = 0xFFFE
+)
+
+// DecodeWAV reads integer (8/16/24/32-bit) or 32-bit float WAV data.
+func DecodeWAV(r io.Reader) (*Audio, error) {
+	var riff [12]byte
+	if _, err := io.ReadFull(r, riff[:]); err != nil {
+		return nil, ErrUnsupported
+	}
+	if string(riff[0:4]) != "RIFF" || string(riff[8:12]) != "WAVE" {
+		return nil, ErrUnsupported
+	}
+
+	var (
+		format, channels, bits uint16
+		rate                   uint32
+		haveFmt                bool
+	)
+	for {
+		var hdr [8]byte
+		if _, err := io.ReadFull(r, hdr[:]); err != nil {
+			return nil, fmt.Errorf("invalid WAV: no data chunk")
+		}
+		id := string(hdr[0:4])
+		size := binary.LittleEndian.Uint32(hdr[4:8])
+
+		switch id {
+		case "fmt ":
+			if size < 16 {
+				return nil, fmt.Errorf("invalid WAV: short fmt chunk")
+			}
+			chunk := make([]byte, size)
+			if _, err := io.ReadFull(r, chunk); err != nil {
+				return nil, fmt.Errorf("invalid WAV: %w", err)
+			}
+			format = binary.LittleEndian.Uint16(chunk[0:2])
+			channels = binary.LittleEndian.Uint16(chunk[2:4])
+			rate = binary.LittleEndian.Uint32(chunk[4:8])
+			bits = binary.LittleEndian.Uint16(chunk[14:16])
+			if format == wavFormatExtensible && size >= 26 {
+				format = binary.LittleEndian.Uint16(chunk[24:26])
+			}
+			haveFmt = true
+			if size%2 == 1 {
+				io.CopyN(io.Discard, r, 1)
+			}
+
+		case "data":
+			if !haveFmt {
+				return nil, fmt.Errorf("invalid WAV: data before fmt")
+			}
+			return decodeSamples(io.LimitReader(r, int64(size)), format, int(channels), int(bits), int(rate))
+
+		default:
+			if _, err := io.CopyN(io.Discard, r, int64(size)+int64(size%2)); err != nil {
+				return nil, fmt.Errorf("invalid WAV: %w", err)
+			}
+		}
+	}
+}
+
+func decodeSamples(r io.Reader, format uint16, channels, bits, rate int) (*Audio, error) {
+	if channels < 1 || rate <= 0 {
+		return nil, fmt.Errorf("invalid WAV: %d channels at %d Hz", channels, rate)
+	}
+	var sample func(b []byte) float32
+	switch {
+	case format == wavFormatPCM && bits == 8:
+		sample = func(b []byte) float32 { return (float32(b[0]) - 128) / 128 }
+	case format == wavFormatPCM && bits == 16:
+		sample = func(b []byte) float32 { return float32(int16(binary.LittleEndian.Uint16(b))) / 32768 }
+	case format == wavFormatPCM && bits == 24:
+		sample = func(b []byte) float32 {
+			v := int32(b[0]) | int32(b[1])<<8 | int32(int8(b[2]))<<16
+			return float32(v) / 8388608
+		}
+	case format == wavFormatPCM && bits == 32:
+		sample = func(b []byte) float32 { return float32(int32(binary.LittleEndian.Uint32(b))) / 2147483648 }
+	case format == wavFormatFloat && bits == 32:
+		sample = func(b []byte) float32 { return math.Float32frombits(binary.LittleEndian.Uint32(b)) }
+	default:
+		return nil, fmt.Errorf("%w: format %d, %d-bit", ErrUnsupported, format, bits)
+	}
+
+	frameSize := channels * bits / 8
+	buf := make([]byte, frameSize*4096)
+	a := &Audio{SampleRate: rate}
+	for {
+		n, err := io.ReadFull(r, buf)
+		for off := 0; off+frameSize <= n; off += frameSize {
+			var sum float32
+			for c := 0; c < channels; c++ {
+				sum += sample(buf[off+c*bits/8:])
+			}
+			a.Mono = append(a.Mono, sum/float32(channels))
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return a, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// Peaks returns the maximum absolute amplitude in each of buckets equal
+// slices, for drawing a waveform.
+func Peaks(mono []float32, buckets int) []float32 {
+	if buckets <= 0 || len(mono) == 0 {
+		return []float32{}
+	}
+	peaks := make([]float32, buckets)
+	for i, s := range mono {
+		b := i * buckets / len(mono)
+		if s < 0 {
+			s = -s
+		}
+		if s > peaks[b] {
+			peaks[b] = s
+		}
+	}
+	return peaks
+}
+
+// Loudness returns the RMS level and the sample peak in dBFS. Silence is
+// floored at -120 dB because -Inf cannot be encoded as JSON.
+func Loudness(mono []float32) (rmsDB, peakDB float64) {
+	var sum, peak float64
+	for _, s := range mono {
+		v := float64(s)
+		sum += v * v
+		peak = math.Max(peak, math.Abs(v))
+	}
+	rms := 0.0
+	if len(mono) > 0 {
+		rms = math.Sqrt(sum / float64(len(mono)))
+	}
+	return toDB(rms), toDB(peak)
+}
+
+func toDB(v float64) float64 {
+	if v <= 1e-6 {
+		return -120
+	}
+	return 20 * math.Log10(v)
+}
+
+// Tempo range searched by Tempo.
+const (
+	MinBPM = 60
+	MaxBPM = 200
+)
+
+// envelopeRate is the onset envelope resolution in frames per second.
+const envelopeRate = 100
+
+// Tempo estimates the beats per minute from the autocorrelation of the
+// onset (rising energy) envelope. It returns 0 when no steady pulse is
+// found, e.g. for ambient beds or audio shorter than a few beats.
+func Tempo(mono []float32, sampleRate int) float64 {
+	hop := sampleRate / envelopeRate
+	if hop <= 0 || len(mono) < hop*envelopeRate*4 {
+		return 0
+	}
+
+	frames := len(mono) / hop
+	env := make([]float64, frames)
+	var prev float64
+	for f := 0; f < frames; f++ {
+		var e float64
+		for _, s := range mono[f*hop : (f+1)*hop] {
+			e += float64(s) * float64(s)
+		}
+		if d := e - prev; d > 0 {
+			env[f] = d
+		}
+		prev = e
+	}
+
+	minLag := envelopeRate * 60 / MaxBPM
+	maxLag := envelopeRate * 60 / MinBPM
+	bestLag, best := 0, 0.0
+	var zero float64
+	for i := range env {
+		zero += env[i] * env[i]
+	}
+	if zero == 0 {
+		return 0
+	}
+	for lag := minLag; lag <= maxLag && lag < frames; lag++ {
+		var c float64
+		for i := lag; i < frames; i++ {
+			c += env[i] * env[i-lag]
+		}
+		if c > best {
+			best, bestLag = c, lag
+		}
+	}
+	// Require a meaningful periodic component.
+	if bestLag == 0 || best < zero*0.1 {
+		return 0
+	}
+	bpm := 60 * float64(envelopeRate) / float64(bestLag)
+	return math.Round(bpm*10) / 10
+}
+
+// Kinds of analysis Analyze can run.
+const (
+	KindPeaks    = "peaks"
+	KindTempo    = "tempo"
+	KindLoudness = "loudness"
+)
+
+// Result holds whichever analyses were requested.
+type Result struct {
+	DurationMs int64     `json:"durationMs"`
+	Peaks      []float32 `json:"peaks,omitempty"`
+	BPM        float64   `json:"bpm,omitempty"`
+	RMSDB      float64   `json:"rmsDb,omitempty"`
+	PeakDB     float64   `json:"peakDb,omitempty"`
+}
+
+// PeakBuckets is the waveform resolution returned by Analyze.
+const PeakBuckets = 2000
+
+// Analyze runs the requested kinds (all if none), reporting progress in 0..1
+// between steps and stopping early if ctx is cancelled.
+func Analyze(ctx context.Context, a *Audio, kinds []string, progress func(float64)) (*Result, error) {
+	if len(kinds) == 0 {
+		kinds = []string{KindPeaks, KindTempo, KindLoudness}
+	}
+	res := &Result{DurationMs: a.DurationMs()}
+	for i, kind := range kinds {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		switch kind {
+		case KindPeaks:
+			res.Peaks = Peaks(a.Mono, PeakBuckets)
+		case KindTempo:
+			res.BPM = Tempo(a.Mono, a.SampleRate)
+		case KindLoudness:
+			res.RMSDB, res.PeakDB = Loudness(a.Mono)
+		default:
+			return nil, fmt.Errorf("unknown analysis %q", kind)
+		}
+		if progress != nil {
+			progress(float64(i+1) / float64(len(kinds)))
+		}
+	}
+	return res, nil
+}
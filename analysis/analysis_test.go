@@ -0,0 +1,89 @@
+package analysis
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"testing"
+)
+
+// wav16 builds a 16-bit PCM WAV with the same sample on every channel.
+func wav16(samples []int16, rate, channels int) []byte {
+	var buf bytes.Buffer
+	dataLen := uint32(len(samples) * 2 * channels)
+	buf.WriteString("RIFF")
+	binary.Write(&buf, binary.LittleEndian, 36+dataLen)
+	buf.WriteString("WAVEfmt ")
+	binary.Write(&buf, binary.LittleEndian, uint32(16))
+	binary.Write(&buf, binary.LittleEndian, uint16(1))
+	binary.Write(&buf, binary.LittleEndian, uint16(channels))
+	binary.Write(&buf, binary.LittleEndian, uint32(rate))
+	binary.Write(&buf, binary.LittleEndian, uint32(rate*2*channels))
+	binary.Write(&buf, binary.LittleEndian, uint16(2*channels))
+	binary.Write(&buf, binary.LittleEndian, uint16(16))
+	buf.WriteString("data")
+	binary.Write(&buf, binary.LittleEndian, dataLen)
+	for _, s := range samples {
+		for c := 0; c < channels; c++ {
+			binary.Write(&buf, binary.LittleEndian, s)
+		}
+	}
+	return buf.Bytes()
+}
+
+// clickTrack returns short bursts at the given tempo.
+func clickTrack(bpm float64, seconds, rate int) []int16 {
+	out := make([]int16, seconds*rate)
+	period := int(float64(rate) * 60 / bpm)
+	for start := 0; start < len(out); start += period {
+		for i := 0; i < rate/100 && start+i < len(out); i++ {
+			out[start+i] = int16(16000 * math.Sin(float64(i)*0.3))
+		}
+	}
+	return out
+}
+
+func TestDecodeWAVMixesToMono(t *testing.T) {
+	a, err := DecodeWAV(bytes.NewReader(wav16([]int16{16384, -16384, 0}, 44100, 2)))
+	if err != nil {
+		t.Fatalf("DecodeWAV() error = %v", err)
+	}
+	if a.SampleRate != 44100 || len(a.Mono) != 3 {
+		t.Fatalf("got %d Hz, %d samples", a.SampleRate, len(a.Mono))
+	}
+	if a.Mono[0] != 0.5 || a.Mono[1] != -0.5 {
+		t.Errorf("Mono = %v, want [0.5 -0.5 0]", a.Mono)
+	}
+
+	if _, err := DecodeWAV(bytes.NewReader([]byte("ID3\x04 not a wav file"))); err != ErrUnsupported {
+		t.Errorf("mp3 input: err = %v, want ErrUnsupported", err)
+	}
+}
+
+func TestTempoFindsClickTrack(t *testing.T) {
+	a, err := DecodeWAV(bytes.NewReader(wav16(clickTrack(120, 10, 22050), 22050, 1)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bpm := Tempo(a.Mono, a.SampleRate); math.Abs(bpm-120) > 2 {
+		t.Errorf("Tempo() = %v, want ~120", bpm)
+	}
+	if bpm := Tempo(make([]float32, 22050*10), 22050); bpm != 0 {
+		t.Errorf("Tempo(silence) = %v, want 0", bpm)
+	}
+}
+
+func TestPeaksAndLoudness(t *testing.T) {
+	mono := []float32{0.1, -0.8, 0.2, 0.4}
+	peaks := Peaks(mono, 2)
+	if len(peaks) != 2 || peaks[0] != 0.8 || peaks[1] != 0.4 {
+		t.Errorf("Peaks() = %v, want [0.8 0.4]", peaks)
+	}
+	_, peakDB := Loudness([]float32{1, -1})
+	if math.Abs(peakDB) > 1e-9 {
+		t.Errorf("peak of full scale = %v dB, want 0", peakDB)
+	}
+	if rms, _ := Loudness(make([]float32, 10)); rms != -120 {
+		t.Errorf("silence RMS = %v, want -120", rms)
+	}
+}
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	goruntime "runtime"
+	"sync"
+
+	"PicoLume/analysis"
+	"PicoLume/i18n"
+	"PicoLume/logger"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// ==========================================================
+// AUDIO ANALYSIS JOBS
+// ==========================================================
+
+// maxQueuedAnalyses bounds jobs waiting for a worker.
+const maxQueuedAnalyses = 256
+
+// analysisPool runs audio analysis on a few workers so importing many
+// tracks neither pegs every core nor blocks bound calls.
+type analysisPool struct {
+	once   sync.Once
+	queue  chan *analysisJob
+	mu     sync.Mutex
+	nextID uint64
+	active map[string]context.CancelFunc // queued or running, by job ID
+}
+
+type analysisJob struct {
+	id       string
+	bufferID string
+	kinds    []string
+	ctx      context.Context
+}
+
+// AnalysisQueued is returned by AnalyzeAudio.
+type AnalysisQueued struct {
+	JobIDs []string `json:"jobIds"` // one per buffer, in request order
+	Error  string   `json:"error"`
+}
+
+// AnalysisProgress is the payload of "analysis:progress" events.
+type AnalysisProgress struct {
+	JobID    string  `json:"jobId"`
+	BufferID string  `json:"bufferId"`
+	Progress float64 `json:"progress"` // 0..1
+}
+
+// AnalysisDone is the payload of "analysis:done" events.
+type AnalysisDone struct {
+	JobID    string           `json:"jobId"`
+	BufferID string           `json:"bufferId"`
+	Result   *analysis.Result `json:"result"`
+	Error    string           `json:"error"`
+}
+
+func analysisWorkers() int {
+	return max(1, goruntime.NumCPU()/2)
+}
+
+// AnalyzeAudio queues waveform peaks, tempo and/or loudness analysis
+// (kinds: "peaks", "tempo", "loudness"; empty for all) for audio buffers of
+// the loaded project. Results arrive as "analysis:done" events.
+func (a *App) AnalyzeAudio(bufferIDs []string, kinds []string) AnalysisQueued {
+	defer a.recoverBinding("AnalyzeAudio")
+
+	p := &a.analysis
+	p.once.Do(func() {
+		p.queue = make(chan *analysisJob, maxQueuedAnalyses)
+		p.active = make(map[string]context.CancelFunc)
+		for i := 0; i < analysisWorkers(); i++ {
+			a.goSafe("analysis worker", a.analysisWorker)
+		}
+	})
+
+	resp := AnalysisQueued{JobIDs: []string{}}
+	for _, bufferID := range bufferIDs {
+		ctx, cancel := context.WithCancel(context.Background())
+		p.mu.Lock()
+		p.nextID++
+		job := &analysisJob{
+			id:       fmt.Sprintf("analysis-%d", p.nextID),
+			bufferID: bufferID,
+			kinds:    kinds,
+			ctx:      ctx,
+		}
+		p.active[job.id] = cancel
+		p.mu.Unlock()
+
+		select {
+		case p.queue <- job:
+			resp.JobIDs = append(resp.JobIDs, job.id)
+		default:
+			a.finishAnalysis(job.id)
+			resp.Error = fmt.Sprintf("Too many analysis jobs queued (max %d)", maxQueuedAnalyses)
+			return resp
+		}
+	}
+	return resp
+}
+
+// CancelAnalysis stops a queued or running job; it still reports
+// "analysis:done" with a cancellation error.
+func (a *App) CancelAnalysis(jobID string) string {
+	p := &a.analysis
+	p.mu.Lock()
+	cancel := p.active[jobID]
+	p.mu.Unlock()
+	if cancel == nil {
+		return i18n.T("Error: Unknown or finished job")
+	}
+	cancel()
+	return "OK"
+}
+
+func (a *App) finishAnalysis(jobID string) {
+	p := &a.analysis
+	p.mu.Lock()
+	if cancel := p.active[jobID]; cancel != nil {
+		cancel()
+		delete(p.active, jobID)
+	}
+	p.mu.Unlock()
+}
+
+func (a *App) analysisWorker() {
+	for job := range a.analysis.queue {
+		done := AnalysisDone{JobID: job.id, BufferID: job.bufferID}
+		result, err := a.runAnalysis(job)
+		if err != nil {
+			if errors.Is(err, context.Canceled) {
+				err = errors.New("cancelled")
+			}
+			logger.Info("Analysis %s (%s): %v", job.id, job.bufferID, err)
+			done.Error = err.Error()
+		}
+		done.Result = result
+		a.finishAnalysis(job.id)
+		if a.ctx != nil {
+			runtime.EventsEmit(a.ctx, "analysis:done", done)
+		}
+	}
+}
+
+func (a *App) runAnalysis(job *analysisJob) (*analysis.Result, error) {
+	if err := job.ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	a.mu.Lock()
+	file := a.audioFiles[job.bufferID]
+	a.mu.Unlock()
+	if file == "" {
+		return nil, fmt.Errorf("no audio loaded for buffer %q", job.bufferID)
+	}
+
+	report := func(progress float64) {
+		if a.ctx != nil {
+			runtime.EventsEmit(a.ctx, "analysis:progress", AnalysisProgress{
+				JobID: job.id, BufferID: job.bufferID, Progress: progress,
+			})
+		}
+	}
+	report(0)
+
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	// Decoded mono float32 is at most 4x the file (8-bit mono WAV).
+	var size int64
+	if info, err := f.Stat(); err == nil {
+		size = info.Size()
+	}
+	release, err := a.reserveMemory("Analyzing "+job.bufferID, 4*size)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	defer release()
+
+	audio, err := analysis.DecodeWAV(bufio.NewReader(f))
+	f.Close()
+	if err != nil {
+		return nil, err
+	}
+	// Decoding is most of the work; analysis steps share the second half.
+	report(0.5)
+
+	return analysis.Analyze(job.ctx, audio, job.kinds, func(p float64) { report(0.5 + p/2) })
+}
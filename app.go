@@ -7,13 +7,25 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 
 	"PicoLume/bingen"
+	"PicoLume/companion"
+	"PicoLume/devices"
+	"PicoLume/dmx"
+	"PicoLume/i18n"
+	"PicoLume/livepreview"
 	"PicoLume/logger"
+	"PicoLume/serialmanager"
+	"PicoLume/settings"
+	"PicoLume/showaudio"
+	"PicoLume/showsync"
 
 	"github.com/wailsapp/wails/v2/pkg/runtime"
 	"go.bug.st/serial"
@@ -35,22 +47,11 @@ var (
 // FILE SIZE LIMITS (Security - DoS Prevention)
 // ==========================================================
 
-const (
-	// MaxZipFileSize is the maximum allowed size for a .lum project file (500MB)
-	MaxZipFileSize = 500 * 1024 * 1024
+// MaxProjectJsonSize is the maximum allowed size for project.json (10MB).
+// The other archive limits are configurable, see settings.Limits.
+const MaxProjectJsonSize = 10 * 1024 * 1024
 
-	// MaxProjectJsonSize is the maximum allowed size for project.json (10MB)
-	MaxProjectJsonSize = 10 * 1024 * 1024
-
-	// MaxAudioFileSize is the maximum allowed size for a single audio file (200MB)
-	MaxAudioFileSize = 200 * 1024 * 1024
-
-	// MaxTotalExtractedSize is the maximum total size of all extracted files (1GB)
-	MaxTotalExtractedSize = 1024 * 1024 * 1024
-
-	// MaxFilesInZip is the maximum number of files allowed in a zip archive
-	MaxFilesInZip = 100
-)
+const megabyte = 1024 * 1024
 
 // validateSavePath validates a file path for safe write operations.
 // It ensures the path is absolute, has the expected extension, and
@@ -92,9 +93,69 @@ func validateSavePath(path string, allowedExtensions []string) (string, error) {
 	return cleanPath, nil
 }
 
+// Subsystem loggers; levels can be tuned via PICOLUME_LOG_LEVELS.
+var (
+	serialLog = logger.Named("serial")
+	driveLog  = logger.Named("drive")
+)
+
 // App struct
 type App struct {
 	ctx context.Context
+
+	mu            sync.Mutex
+	commandServer *companion.Server
+	dmxOutput     *dmx.Output
+	dmxPatch      dmx.Patch
+	syncMaster    *showsync.Master
+	syncFollower  *showsync.Follower
+	previewMaster *showsync.Master
+	previewCmd    *exec.Cmd
+	tray          *trayIcon
+	prefs         *settings.Store
+	registry      *devices.Registry
+
+	// Set when this process is the detached preview window.
+	preview previewOptions
+
+	// Command-line arguments (.lum path or picolume:// link), handled once
+	// the DOM is ready; launchReady is set then. Guarded by mu.
+	launchArgs  []string
+	launchReady bool
+
+	// Unsaved-changes state for the close prompt, see SetDirty.
+	dirty          bool
+	closeConfirmed bool
+
+	// Last device scan result from the connection watcher, served by
+	// GetPicoConnectionStatus and kept for diagnostics bundles.
+	lastConnStatus PicoConnectionStatus
+	lastConnAt     time.Time
+	presence       presenceDebouncer // device events, see watchConnection
+
+	// Last generated show.bin, see generateShow.
+	gen genCache
+
+	// Stops the upload in progress, see CancelUpload.
+	uploadCancel context.CancelFunc
+	uploadID     uint64 // latest upload
+
+	// Large allocations in flight, see reserveMemory.
+	memory memoryBudget
+
+	// Queued and running audio analysis, see AnalyzeAudio.
+	analysis analysisPool
+
+	// Extracted audio of the loaded project, served at /project-audio/{id}.
+	audioDir   string
+	audioFiles map[string]string // buffer ID -> file
+
+	// Owner of the receiver serial ports and the persistent session, see
+	// serialPorts and OpenSerialSession.
+	serial *serialmanager.Manager
+
+	// Playhead stream to the receiver, see StartLivePreview.
+	livePreview *livepreview.Streamer
 }
 
 // NewApp creates a new App application struct
@@ -104,6 +165,19 @@ func NewApp() *App {
 
 func (a *App) startup(ctx context.Context) {
 	a.ctx = ctx
+	if a.preview.Enabled {
+		a.startPreview(ctx)
+		return
+	}
+	runtime.MenuSetApplicationMenu(ctx, a.buildMenu())
+	a.watchSystemTheme()
+	a.watchConnection()
+	if t, err := a.startTray(); err != nil {
+		logger.Info("Tray icon unavailable: %v", err)
+	} else {
+		a.tray = t
+	}
+	a.checkForUpdatesOnStartup()
 }
 
 func (a *App) emitUploadStatus(message string) {
@@ -128,28 +202,15 @@ func (a *App) emitUploadManualEject(drive, reason string) {
 	})
 }
 
-// ==========================================================
-// BINARY GENERATION (uses shared bingen package)
-// ==========================================================
-
-// generateBinaryBytes wraps the shared bingen package for binary generation.
-// This ensures consistency between the Go backend, WASM, and any other consumers.
-func generateBinaryBytes(projectJSON string) ([]byte, int, error) {
-	result, err := bingen.GenerateFromJSON(projectJSON)
-	if err != nil {
-		return nil, 0, err
-	}
-	return result.Bytes, result.EventCount, nil
-}
-
 // ==========================================================
 // EXPOSED FUNCTIONS
 // ==========================================================
 
 func (a *App) RequestSavePath() string {
 	filename, err := runtime.SaveFileDialog(a.ctx, runtime.SaveDialogOptions{
-		DefaultFilename: "myshow.lum",
-		Title:           "Save Project",
+		DefaultDirectory: a.currentSettings().ProjectDir,
+		DefaultFilename:  "myshow.lum",
+		Title:            "Save Project",
 		Filters: []runtime.FileFilter{
 			{DisplayName: "PicoLume Project (*.lum)", Pattern: "*.lum"},
 		},
@@ -162,127 +223,352 @@ func (a *App) RequestSavePath() string {
 }
 
 func (a *App) SaveProjectToPath(path string, projectJson string, audioFiles map[string]string) string {
+	defer a.recoverBinding("SaveProjectToPath")
+
 	// Validate and sanitize path to prevent directory traversal
 	safePath, err := validateSavePath(path, []string{".lum"})
 	if err != nil {
-		return "Error: Invalid path - " + err.Error()
+		return i18n.T("Error: Invalid path - %s", err.Error())
 	}
 
-	outFile, err := os.Create(safePath)
+	// Write next to the target and rename at the end, so a failed save of a
+	// multi-GB project never leaves a truncated .lum behind.
+	tmpPath := safePath + ".tmp"
+	outFile, err := os.Create(tmpPath)
 	if err != nil {
-		return "Error creating file: " + err.Error()
+		return i18n.T("Error creating file: %s", err.Error())
 	}
-	defer outFile.Close()
+	committed := false
+	defer func() {
+		if !committed {
+			outFile.Close()
+			os.Remove(tmpPath)
+		}
+	}()
 
+	// archive/zip switches to Zip64 records by itself once an entry or the
+	// archive passes 4GB.
 	zipWriter := zip.NewWriter(outFile)
-	defer zipWriter.Close()
 
 	f, err := zipWriter.Create("project.json")
 	if err != nil {
-		return "Error writing project.json: " + err.Error()
+		return i18n.T("Error writing project.json: %s", err.Error())
 	}
 	_, err = f.Write([]byte(projectJson))
 	if err != nil {
-		return "Error writing JSON data: " + err.Error()
+		return i18n.T("Error writing JSON data: %s", err.Error())
 	}
 
-	var audioErrors []string
-	for id, dataUrl := range audioFiles {
-		parts := strings.Split(dataUrl, ",")
-		if len(parts) != 2 {
-			logger.Warn("SaveProject: Malformed data URL for audio file %s (expected 2 parts, got %d)", id, len(parts))
-			audioErrors = append(audioErrors, fmt.Sprintf("malformed data URL for %s", id))
-			continue
+	// A project missing its audio must not replace the previous save, so
+	// any audio that can't be written fails the whole save.
+	for id, src := range audioFiles {
+		if err := a.writeAudioEntry(zipWriter, id, src); err != nil {
+			logger.Error("SaveProject: Failed to write audio %s: %v", id, err)
+			return i18n.T("Error writing audio %s: %s", id, err.Error())
 		}
+	}
 
-		// Parse MIME type safely
-		mimeSection := parts[0]
-		colonIdx := strings.Index(mimeSection, ":")
-		if colonIdx == -1 || colonIdx >= len(mimeSection)-1 {
-			logger.Warn("SaveProject: Invalid MIME format for audio file %s: %s", id, mimeSection)
-			audioErrors = append(audioErrors, fmt.Sprintf("invalid MIME format for %s", id))
-			continue
-		}
-		mime := mimeSection[colonIdx+1:]
-		if semiIdx := strings.Index(mime, ";"); semiIdx != -1 {
-			mime = mime[:semiIdx]
-		}
+	if err := zipWriter.Close(); err != nil {
+		return i18n.T("Error creating file: %s", err.Error())
+	}
+	if err := outFile.Close(); err != nil {
+		return i18n.T("Error creating file: %s", err.Error())
+	}
+	if err := os.Rename(tmpPath, safePath); err != nil {
+		return i18n.T("Error creating file: %s", err.Error())
+	}
+	committed = true
 
-		ext := "bin"
-		if strings.Contains(mime, "mpeg") || strings.Contains(mime, "mp3") {
-			ext = "mp3"
-		} else if strings.Contains(mime, "wav") {
-			ext = "wav"
-		} else if strings.Contains(mime, "ogg") {
-			ext = "ogg"
-		}
+	return "Saved"
+}
 
-		decoded, err := base64.StdEncoding.DecodeString(parts[1])
+// writeAudioEntry adds audio/<id>.<ext> to zw from src, which is either a
+// base64 data URL (audio imported this session) or a /project-audio/ URL
+// of audio extracted from the loaded project.
+func (a *App) writeAudioEntry(zw *zip.Writer, id, src string) error {
+	var (
+		ext string
+		r   io.Reader
+	)
+	if strings.HasPrefix(src, projectAudioRoute) {
+		file, ok := a.projectAudioFile(src)
+		if !ok {
+			return fmt.Errorf("%s is not part of the loaded project", src)
+		}
+		in, err := os.Open(file)
 		if err != nil {
-			logger.Warn("SaveProject: Failed to decode base64 for audio file %s: %v", id, err)
-			audioErrors = append(audioErrors, fmt.Sprintf("decode error for %s", id))
-			continue
+			return err
 		}
-
-		zipPath := fmt.Sprintf("audio/%s.%s", id, ext)
-		f, err := zipWriter.Create(zipPath)
+		defer in.Close()
+		ext, r = strings.TrimPrefix(filepath.Ext(file), "."), in
+	} else {
+		mime, data, err := parseDataURL(src)
 		if err != nil {
-			logger.Warn("SaveProject: Failed to create zip entry for %s: %v", zipPath, err)
-			audioErrors = append(audioErrors, fmt.Sprintf("zip error for %s", id))
-			continue
+			return err
 		}
-		if _, err := f.Write(decoded); err != nil {
-			logger.Warn("SaveProject: Failed to write audio data for %s: %v", zipPath, err)
-			audioErrors = append(audioErrors, fmt.Sprintf("write error for %s", id))
+		ext = "bin"
+		if e := audioExt(mime); e != "" {
+			ext = e
 		}
+		// Decode while writing instead of holding a second copy of the audio.
+		r = base64.NewDecoder(base64.StdEncoding, strings.NewReader(data))
 	}
 
-	if len(audioErrors) > 0 {
-		logger.Warn("SaveProject: Completed with %d audio file errors", len(audioErrors))
+	// MP3 and Ogg are already compressed; storing them saves time on
+	// long tracks.
+	method := zip.Deflate
+	if ext == "mp3" || ext == "ogg" {
+		method = zip.Store
 	}
 
-	return "Saved"
+	zipPath := fmt.Sprintf("audio/%s.%s", id, ext)
+	f, err := zw.CreateHeader(&zip.FileHeader{Name: zipPath, Method: method, Modified: time.Now()})
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(f, r)
+	return err
 }
 
 // SaveBinary is deprecated - use SaveBinaryData instead.
 // Kept for backwards compatibility.
 func (a *App) SaveBinary(projectJson string) string {
-	data, count, err := generateBinaryBytes(projectJson)
+	defer a.recoverBinding("SaveBinary")
+
+	filename, err := runtime.SaveFileDialog(a.ctx, runtime.SaveDialogOptions{
+		DefaultDirectory: a.currentSettings().ExportDir,
+		DefaultFilename:  "show.bin",
+		Title:            "Export Show Binary",
+		Filters: []runtime.FileFilter{
+			{DisplayName: "Binary Files (*.bin)", Pattern: "*.bin"},
+		},
+	})
+
+	if err != nil || filename == "" {
+		return i18n.T("Export cancelled")
+	}
+
+	result, err := a.writeShowFile(filename, projectJson, a.showOptions(0))
+	if errors.Is(err, context.Canceled) {
+		return "Cancelled"
+	}
+	var pathErr *fs.PathError
+	if errors.As(err, &pathErr) {
+		return i18n.T("Error saving file: %s", err.Error())
+	}
+	if err != nil {
+		return "Error: " + err.Error()
+	}
+
+	return "Success! " + i18n.T("Exported %d events to %s", result.EventCount, filename)
+}
+
+// writeShowFile streams show.bin for projectJson into filename, through a
+// temporary file so a failed or cancelled export never leaves half a show
+// behind.
+func (a *App) writeShowFile(filename, projectJson string, opts bingen.Options) (*bingen.Result, error) {
+	tmp := filename + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return nil, err
+	}
+	result, err := a.exportShow(f, projectJson, opts)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp, filename)
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return nil, err
+	}
+	return result, nil
+}
+
+// ExportGroupBinaries writes one show.bin per prop group, each holding only
+// the events for that group's props, into <dir>/<group name>/show.bin. It
+// is for shows whose prop sets are programmed by different people.
+func (a *App) ExportGroupBinaries(projectJson string) string {
+	defer a.recoverBinding("ExportGroupBinaries")
+
+	p, err := parseProject(projectJson)
+	if err != nil {
+		return i18n.T("Error: Invalid project - %s", err.Error())
+	}
+	if len(p.PropGroups) == 0 {
+		return "Error: " + i18n.T("The project has no prop groups.")
+	}
+
+	dir, err := runtime.OpenDirectoryDialog(a.ctx, runtime.OpenDialogOptions{
+		DefaultDirectory:     a.currentSettings().ExportDir,
+		Title:                i18n.T("Choose a folder for the group binaries"),
+		CanCreateDirectories: true,
+	})
+	if err != nil || dir == "" {
+		return "Cancelled"
+	}
+
+	used := make(map[string]bool)
+	for _, g := range p.PropGroups {
+		if len(bingen.ParseIDRange(g.IDs)) == 0 {
+			logger.Warn("ExportGroupBinaries: Skipping group %q without props", g.Name)
+			continue
+		}
+		opts := a.showOptions(0)
+		opts.Props, opts.Bank = g.IDs, g.Bank
+
+		name := groupDirName(g.Name, g.ID)
+		for i := 2; used[strings.ToLower(name)]; i++ {
+			name = fmt.Sprintf("%s (%d)", groupDirName(g.Name, g.ID), i)
+		}
+		used[strings.ToLower(name)] = true
+
+		groupDir := filepath.Join(dir, name)
+		if err := os.MkdirAll(groupDir, 0755); err != nil {
+			return i18n.T("Error saving file: %s", err.Error())
+		}
+		_, err := a.writeShowFile(filepath.Join(groupDir, "show.bin"), projectJson, opts)
+		if errors.Is(err, context.Canceled) {
+			return "Cancelled"
+		}
+		var pathErr *fs.PathError
+		if errors.As(err, &pathErr) {
+			return i18n.T("Error saving file: %s", err.Error())
+		}
+		if err != nil {
+			return i18n.T("Error: %s: %s", g.Name, err.Error())
+		}
+	}
+	if len(used) == 0 {
+		return "Error: " + i18n.T("The project has no prop groups.")
+	}
+
+	return "Success! " + i18n.T("Exported %d group binaries to %s", len(used), dir)
+}
+
+// ExportTestPattern saves a diagnostic show.bin (ID blink, color order
+// check, full-white soak) for validating new props without a project.
+func (a *App) ExportTestPattern(opts bingen.TestPatternOptions) string {
+	defer a.recoverBinding("ExportTestPattern")
+
+	if opts.FormatVersion == 0 {
+		opts.FormatVersion = a.currentSettings().ShowFormatVersion
+	}
+	result, err := bingen.GenerateTestPattern(opts)
 	if err != nil {
 		return "Error: " + err.Error()
 	}
 
 	filename, err := runtime.SaveFileDialog(a.ctx, runtime.SaveDialogOptions{
-		DefaultFilename: "show.bin",
-		Title:           "Export Show Binary",
+		DefaultDirectory: a.currentSettings().ExportDir,
+		DefaultFilename:  "show.bin",
+		Title:            i18n.T("Export Test Pattern"),
 		Filters: []runtime.FileFilter{
 			{DisplayName: "Binary Files (*.bin)", Pattern: "*.bin"},
 		},
 	})
-
 	if err != nil || filename == "" {
-		return "Export cancelled"
+		return "Cancelled"
 	}
+	if err := os.WriteFile(filename, result.Bytes, 0644); err != nil {
+		return i18n.T("Error saving file: %s", err.Error())
+	}
+	return "OK"
+}
 
-	err = os.WriteFile(filename, data, 0644)
+// groupDirName turns a group name into a folder name, falling back to the
+// group ID for names with nothing usable.
+func groupDirName(name, id string) string {
+	clean := strings.Map(func(r rune) rune {
+		if r < 32 || strings.ContainsRune(`<>:"/\|?*`, r) {
+			return '_'
+		}
+		return r
+	}, strings.TrimSpace(name))
+	clean = strings.Trim(clean, ". ")
+	if clean == "" {
+		clean = strings.Trim(id, ". ")
+	}
+	if clean == "" {
+		clean = "group"
+	}
+	return clean
+}
+
+// GetEffectSchemas lists the firmware effects and the clip properties each
+// one uses, for building property panels.
+func (a *App) GetEffectSchemas() []bingen.EffectSchema {
+	return bingen.EffectSchemas()
+}
+
+// GetClipTypes lists the clip types added on top of the firmware effects,
+// such as script, which generation encodes into ordinary events.
+func (a *App) GetClipTypes() []string {
+	return bingen.ClipTypes()
+}
+
+// PowerReportResponse is returned by AnalyzePowerBudget.
+type PowerReportResponse struct {
+	Limits []bingen.PowerLimit `json:"limits"`
+	Error  string              `json:"error"`
+}
+
+// AnalyzePowerBudget lists the clips that would draw more than their props'
+// power budget, and by how much the limitPower setting dims them.
+func (a *App) AnalyzePowerBudget(projectJson string) PowerReportResponse {
+	defer a.recoverBinding("AnalyzePowerBudget")
+
+	p, err := parseProject(projectJson)
 	if err != nil {
-		return "Error saving file: " + err.Error()
+		return PowerReportResponse{Error: "Invalid project - " + err.Error()}
+	}
+	limits := bingen.AnalyzePower(p)
+	if limits == nil {
+		limits = []bingen.PowerLimit{}
 	}
+	return PowerReportResponse{Limits: limits}
+}
+
+// CueReportResponse is returned by CueTimingReport.
+type CueReportResponse struct {
+	Segments []bingen.CueSegment `json:"segments"`
+	Error    string              `json:"error"`
+}
+
+// CueTimingReport summarizes each cue segment for the cue sheet: its
+// length, active groups, busiest effects and the event receivers seek to.
+func (a *App) CueTimingReport(projectJson string) CueReportResponse {
+	defer a.recoverBinding("CueTimingReport")
 
-	return fmt.Sprintf("Success! Exported %d events to %s", count, filename)
+	p, err := parseProject(projectJson)
+	if err != nil {
+		return CueReportResponse{Error: "Invalid project - " + err.Error()}
+	}
+	segs, err := bingen.CueReport(context.Background(), p, a.showOptions(0))
+	if err != nil {
+		return CueReportResponse{Error: err.Error()}
+	}
+	if segs == nil {
+		segs = []bingen.CueSegment{}
+	}
+	return CueReportResponse{Segments: segs}
 }
 
 // SaveBinaryData saves pre-generated binary data (base64 encoded) using native file dialog.
 // Binary generation is now handled in JavaScript for consistency.
 func (a *App) SaveBinaryData(base64Data string) string {
+	defer a.recoverBinding("SaveBinaryData")
+
 	data, err := base64.StdEncoding.DecodeString(base64Data)
 	if err != nil {
-		return "Error decoding binary data: " + err.Error()
+		return i18n.T("Error decoding binary data: %s", err.Error())
 	}
 
 	filename, err := runtime.SaveFileDialog(a.ctx, runtime.SaveDialogOptions{
-		DefaultFilename: "show.bin",
-		Title:           "Export Show Binary",
+		DefaultDirectory: a.currentSettings().ExportDir,
+		DefaultFilename:  "show.bin",
+		Title:            "Export Show Binary",
 		Filters: []runtime.FileFilter{
 			{DisplayName: "Binary Files (*.bin)", Pattern: "*.bin"},
 		},
@@ -294,7 +580,7 @@ func (a *App) SaveBinaryData(base64Data string) string {
 
 	err = os.WriteFile(filename, data, 0644)
 	if err != nil {
-		return "Error saving file: " + err.Error()
+		return i18n.T("Error saving file: %s", err.Error())
 	}
 
 	return "OK"
@@ -326,7 +612,7 @@ func isPicoLikeUSBSerialPort(p *enumerator.PortDetails) bool {
 
 // isPortLockedError checks if a serial port error indicates the port is held by another application.
 func isPortLockedError(err error) bool {
-	if err == nil {
+	if err == nil || isPortPermissionError(err) {
 		return false
 	}
 	errStr := strings.ToLower(err.Error())
@@ -341,31 +627,199 @@ func isPortLockedError(err error) bool {
 
 // UploadToPico: Writes file and resets via Native Serial
 func (a *App) UploadToPico(projectJson string) string {
-	a.emitUploadStatus("Generating show.bin...")
-	data, count, err := generateBinaryBytes(projectJson)
+	defer a.recoverBinding("UploadToPico")
+	return a.uploadToPico(projectJson, UploadOptions{})
+}
+
+// UploadToPicoAs uploads in a specific show format version (2 to 5), for a
+// receiver whose firmware differs from the rest of the fleet.
+func (a *App) UploadToPicoAs(projectJson string, formatVersion int) string {
+	defer a.recoverBinding("UploadToPicoAs")
+	return a.uploadToPico(projectJson, UploadOptions{FormatVersion: formatVersion})
+}
+
+// UploadToPicoForDevice uploads show.bin plus a config.json (prop ID, radio
+// channel, brightness) for a device from the registry, so a receiver is
+// configured in the same step.
+func (a *App) UploadToPicoForDevice(projectJson string, deviceID string) string {
+	defer a.recoverBinding("UploadToPicoForDevice")
+	return a.uploadToPico(projectJson, UploadOptions{DeviceID: deviceID})
+}
+
+// UploadOptions selects what UploadToPicoWithOptions writes besides
+// show.bin.
+type UploadOptions struct {
+	FormatVersion int      `json:"formatVersion"` // 0 for the setting
+	DeviceID      string   `json:"deviceId"`      // registry device for config.json
+	AudioIDs      []string `json:"audioIds"`      // project audio copied to audio/
+}
+
+// UploadToPicoWithOptions uploads show.bin plus config and audio files for
+// receivers that need them. All files are written and verified, or none
+// are; progress is reported per file with "upload:file" events and for the
+// whole upload with "upload:progress".
+func (a *App) UploadToPicoWithOptions(projectJson string, opts UploadOptions) string {
+	defer a.recoverBinding("UploadToPicoWithOptions")
+	return a.uploadToPico(projectJson, opts)
+}
+
+// CancelUpload stops the upload in progress: generation, the search for the
+// receiver's drive, the file writes (rolling back to the previous files) or
+// the serial reset. The upload returns "Cancelled".
+func (a *App) CancelUpload() string {
+	defer a.recoverBinding("CancelUpload")
+	a.mu.Lock()
+	cancel := a.uploadCancel
+	a.mu.Unlock()
+	if cancel == nil {
+		return "OK"
+	}
+	cancel()
+	return "Cancelled"
+}
+
+// beginUpload returns the context of a new upload, cancelling any upload
+// still running, and a function to call when it ends.
+func (a *App) beginUpload() (context.Context, func()) {
+	ctx, cancel := context.WithCancel(context.Background())
+	a.mu.Lock()
+	if a.uploadCancel != nil {
+		a.uploadCancel()
+	}
+	a.uploadCancel = cancel
+	a.uploadID++
+	id := a.uploadID
+	a.mu.Unlock()
+
+	// Generation has its own cancel; forward ours to it while this upload
+	// is waiting on it.
+	stop := context.AfterFunc(ctx, func() { a.cancelGeneration(genUpload) })
+	return ctx, func() {
+		stop()
+		a.mu.Lock()
+		if a.uploadID == id {
+			a.uploadCancel = nil
+		}
+		a.mu.Unlock()
+		cancel()
+	}
+}
+
+// sleepContext waits for d, returning early with ctx's error if it is
+// cancelled.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// uploadManifest lists the files an upload writes besides show.bin. A
+// device whose hardware profile plays audio also gets the show audio.
+func (a *App) uploadManifest(projectJson string, opts UploadOptions) ([]deviceFile, error) {
+	var files []deviceFile
+	if opts.DeviceID != "" {
+		d, err := a.registeredDevice(opts.DeviceID)
+		if err != nil {
+			return nil, err
+		}
+		config, err := d.ConfigJSON()
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, deviceFile{Name: devices.ConfigFileName, Data: config})
+		audio, err := a.deviceAudio(projectJson, d)
+		if err != nil {
+			return nil, fmt.Errorf("show audio: %w", err)
+		}
+		if audio != nil {
+			files = append(files, deviceFile{Name: showaudio.DeviceFile, Data: audio})
+		}
+	}
+	for _, id := range opts.AudioIDs {
+		a.mu.Lock()
+		file := a.audioFiles[id]
+		a.mu.Unlock()
+		if file == "" {
+			return nil, fmt.Errorf("audio %q is not part of the loaded project", id)
+		}
+		files = append(files, deviceFile{Name: "audio/" + filepath.Base(file), Source: file})
+	}
+	return files, nil
+}
+
+// uploadToPico writes show.bin and any extra files in opts, then resets
+// the receiver.
+func (a *App) uploadToPico(projectJson string, opts UploadOptions) string {
+	ctx, end := a.beginUpload()
+	defer end()
+
+	extra, err := a.uploadManifest(projectJson, opts)
+	if err != nil {
+		return "Error: " + err.Error()
+	}
+
+	meter := a.newUploadMeter()
+	meter.enter(phaseGenerate)
+	a.emitUploadStatus(i18n.T("Checking the receiver firmware..."))
+	device := a.receiverInfo()
+	if ctx.Err() != nil {
+		return "Cancelled"
+	}
+
+	a.emitUploadStatus(i18n.T("Generating show.bin..."))
+	genOpts := a.showOptions(opts.FormatVersion)
+	genOpts.TargetVersion = device.FormatVersion
+	result, err := a.generateShow(genUpload, projectJson, genOpts)
+	if errors.Is(err, context.Canceled) {
+		return "Cancelled"
+	}
 	if err != nil {
-		return "Error generating binary: " + err.Error()
+		return i18n.T("Error generating binary: %s", err.Error())
+	}
+	data, count := result.Bytes, result.EventCount
+	if device.FormatVersion != 0 {
+		fw := device.Firmware
+		if fw == "" {
+			fw = "without device info"
+		}
+		logger.Info("UploadToPico: Writing show format V%d for firmware %s on %s (reads up to V%d)", result.Version, fw, device.Port, device.FormatVersion)
+	}
+
+	// Refuse a show the receiver's flash cannot hold before the drive is
+	// touched.
+	if err := checkShowCapacity(device, int64(len(data))); err != nil {
+		return i18n.T("Show too large: %s. Shorten the show or turn on show optimization.", err.Error())
 	}
 
-	a.emitUploadStatus("Looking for PicoLume USB drive...")
+	a.emitUploadStatus(i18n.T("Looking for PicoLume USB drive..."))
 	targetDrive := ""
 	possibleDrives := []string{}
 
-	for _, drive := range "DEFGHIJKLMNOPQRSTUVWXYZ" {
-		driveRoot := string(drive) + ":/"
-		if _, err := os.Stat(driveRoot); err == nil {
+	for _, driveRoot := range volumeRoots() {
+		if ctx.Err() != nil {
+			return "Cancelled"
+		}
+		if driveRoot == "C:/" {
+			continue // Windows system drive
+		}
 
-			// Skip Bootloader Mode
-			if _, err := os.Stat(driveRoot + "INFO_UF2.TXT"); err == nil {
-				continue
-			}
+		// Skip Bootloader Mode
+		if _, err := os.Stat(driveRoot + "INFO_UF2.TXT"); err == nil {
+			continue
+		}
 
-			// Look for Pico-specific markers
-			if _, err := os.Stat(driveRoot + "INDEX.HTM"); err == nil {
-				possibleDrives = append(possibleDrives, driveRoot)
-			} else if _, err := os.Stat(driveRoot + "show.bin"); err == nil {
-				possibleDrives = append(possibleDrives, driveRoot)
-			}
+		// Look for Pico-specific markers
+		if _, err := os.Stat(driveRoot + "INDEX.HTM"); err == nil {
+			driveLog.Debug("UploadToPico: Found receiver drive %s (INDEX.HTM)", driveRoot)
+			possibleDrives = append(possibleDrives, driveRoot)
+		} else if _, err := os.Stat(driveRoot + "show.bin"); err == nil {
+			driveLog.Debug("UploadToPico: Found receiver drive %s (show.bin)", driveRoot)
+			possibleDrives = append(possibleDrives, driveRoot)
 		}
 	}
 
@@ -373,41 +827,46 @@ func (a *App) UploadToPico(projectJson string) string {
 		// If the Pico's USB volume is freshly formatted, it may not contain any marker
 		// files yet (e.g., INDEX.HTM/show.bin). Fall back to asking the user to select
 		// the mounted drive manually.
-		a.emitUploadStatus("Select the PicoLume USB drive...")
+		a.emitUploadStatus(i18n.T("Select the PicoLume USB drive..."))
 		dir, derr := runtime.OpenDirectoryDialog(a.ctx, runtime.OpenDialogOptions{
-			Title: "Select PicoLume USB Drive (USB MODE)",
+			Title: i18n.T("Select PicoLume USB Drive (USB MODE)"),
 		})
 		if derr != nil || dir == "" {
-			return "No Pico found. (Hold CONFIG btn while plugging in?)"
+			return i18n.T("No Pico found. (Hold CONFIG btn while plugging in?)")
 		}
 		possibleDrives = append(possibleDrives, dir)
 	}
 
 	targetDrive = possibleDrives[len(possibleDrives)-1]
-
-	// --- UPDATED FILE WRITE LOGIC ---
-	destPath := filepath.Join(targetDrive, "show.bin")
-	a.emitUploadStatus(fmt.Sprintf("Uploading show.bin to %s...", targetDrive))
-
-	// 1. Open with Truncate
-	f, err := os.OpenFile(destPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0666)
-	if err != nil {
-		return fmt.Sprintf("Failed to open %s: %s", targetDrive, err.Error())
+	if ctx.Err() != nil {
+		return "Cancelled"
 	}
 
-	// 2. Write Data
-	_, err = f.Write(data)
-	if err != nil {
-		f.Close()
-		return fmt.Sprintf("Failed to write to %s: %s", targetDrive, err.Error())
+	// --- UPDATED FILE WRITE LOGIC ---
+	a.emitUploadStatus(i18n.T("Uploading show.bin to %s...", targetDrive))
+
+	// show.bin goes last so a receiver never sees the new show without
+	// the files it depends on.
+	files := append(extra, deviceFile{Name: devices.ShowFileName, Data: data})
+	if err := a.writeDeviceFiles(ctx, targetDrive, files, meter); err != nil {
+		var pathErr *fs.PathError
+		switch {
+		case errors.Is(err, context.Canceled):
+			return "Cancelled"
+		case errors.Is(err, errShowTooLarge):
+			return i18n.T("Show too large: %s. Shorten the show or turn on show optimization.", err.Error())
+		case errors.Is(err, errDeviceFull):
+			return i18n.T("Device full: %s. Delete old files from the drive and try again.", err.Error())
+		case errors.Is(err, errWriteStalled):
+			return i18n.T("Device stopped responding: %s. Reconnect the receiver and try again.", err.Error())
+		case errors.As(err, &pathErr) && pathErr.Op == "open":
+			return i18n.T("Failed to open %s: %s", targetDrive, err.Error())
+		}
+		return i18n.T("Failed to write to %s: %s", targetDrive, err.Error())
 	}
-
-	// 3. Force Flush to Disk
-	err = f.Sync()
-	if err != nil {
-		logger.Warn("UploadToPico: Sync to disk failed for %s: %v", destPath, err)
+	if len(extra) > 0 {
+		logger.Info("UploadToPico: Wrote %d files to %s", len(files), targetDrive)
 	}
-	f.Close()
 
 	// --- TRIGGER DEVICE RELOAD ---
 	// Prefer serial reset (works even when Windows refuses to "eject" a non-removable MSC device).
@@ -415,7 +874,7 @@ func (a *App) UploadToPico(projectJson string) string {
 		if driveRoot == "" {
 			return
 		}
-		go func() {
+		a.goSafe("drive disconnect check", func() {
 			deadline := time.Now().Add(grace)
 			for time.Now().Before(deadline) {
 				if _, err := os.Stat(driveRoot); err != nil {
@@ -423,76 +882,107 @@ func (a *App) UploadToPico(projectJson string) string {
 				}
 				time.Sleep(250 * time.Millisecond)
 			}
-			a.emitUploadManualEject(driveRoot, "Device did not disconnect/reload automatically after the reset command.")
-		}()
+			a.emitUploadManualEject(driveRoot, i18n.T("Device did not disconnect/reload automatically after the reset command."))
+		})
 	}
 
 	trySerialReset := func() error {
-		a.emitUploadStatus("Scanning for PicoLume serial port (auto-reset)...")
-		ports, err := enumerator.GetDetailedPortsList()
+		a.emitUploadStatus(i18n.T("Scanning for PicoLume serial port (auto-reset)..."))
+		candidates, err := findSerialCandidates()
 		if err != nil {
 			return err
 		}
-
-		isCandidate := func(p *enumerator.PortDetails) bool {
-			return isPicoLikeUSBSerialPort(p)
-		}
-
-		var candidates []*enumerator.PortDetails
-		for _, p := range ports {
-			if isCandidate(p) {
-				candidates = append(candidates, p)
-			}
-		}
-
 		if len(candidates) == 0 {
 			return fmt.Errorf("no suitable USB serial ports found")
 		}
 
-		driveLetter := filepath.VolumeName(targetDrive)
-		driveRoot := driveLetter + `\`
+		// Watch the drive letter on Windows and the mount point elsewhere.
+		driveRoot := targetDrive
+		if v := filepath.VolumeName(targetDrive); v != "" {
+			driveRoot = v + `\`
+		}
 
-		const resetAttemptsPerPort = 3
-		const resetAttemptDelay = 350 * time.Millisecond
+		serialPrefs := a.currentSettings().Serial
+		resetAttemptsPerPort := serialPrefs.ResetAttempts
+		resetAttemptDelay := time.Duration(serialPrefs.ResetDelayMs) * time.Millisecond
 
-		// Track if we encountered a port lock error for better messaging.
-		var lockedPort string
+		// Track lock and permission errors for better messaging.
+		var lockedPort, deniedPort string
 
-		a.emitUploadStatus("Resetting PicoLume device via serial...")
-		time.Sleep(350 * time.Millisecond)
+		a.emitUploadStatus(i18n.T("Resetting PicoLume device via serial..."))
+		if err := sleepContext(ctx, 350*time.Millisecond); err != nil {
+			return err
+		}
 
 		for _, candidate := range candidates {
-			for attempt := 1; attempt <= resetAttemptsPerPort; attempt++ {
-				a.emitUploadStatus(fmt.Sprintf("Resetting via %s (attempt %d/%d)...", candidate.Name, attempt, resetAttemptsPerPort))
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			// A serial session already holds the port; use it rather than
+			// failing to open the port a second time.
+			if held, err := a.resetViaSession(candidate.Name); held {
+				if err != nil {
+					serialLog.Debug("UploadToPico: Reset via session on %s failed: %v", candidate.Name, err)
+					continue
+				}
+				serialLog.Info("UploadToPico: Sent reset command via session on %s", candidate.Name)
+				confirmDriveDropsAsync(driveRoot, 20*time.Second)
+				return nil
+			}
 
-				mode := &serial.Mode{BaudRate: 115200}
-				s, err := serial.Open(candidate.Name, mode)
+			for attempt := 1; attempt <= resetAttemptsPerPort; attempt++ {
+				a.emitUploadStatus(i18n.T("Resetting via %s (attempt %d/%d)...", candidate.Name, attempt, resetAttemptsPerPort))
+
+				mode := &serial.Mode{BaudRate: serialPrefs.BaudRate}
+				var werr error
+				err := a.serialPorts().Do(ctx, serialmanager.OpReset, candidate.Name, func() error {
+					s, err := openSerial(candidate.Name, mode)
+					if err != nil {
+						return err
+					}
+					// Some USB CDC implementations only deliver data after DTR is asserted.
+					// Ignore errors here (not all backends support toggling modem lines).
+					_ = s.SetDTR(true)
+					_ = s.SetRTS(true)
+					time.Sleep(250 * time.Millisecond)
+
+					_, werr = s.Write([]byte("r"))
+					if werr == nil {
+						_, _ = s.Write([]byte("\n"))
+					}
+					time.Sleep(250 * time.Millisecond)
+					_ = s.Close()
+					return nil
+				})
+				if err := ctx.Err(); err != nil {
+					return err
+				}
 				if err != nil {
+					serialLog.Debug("UploadToPico: Open %s failed (attempt %d): %v", candidate.Name, attempt, err)
+					if isPortPermissionError(err) {
+						// Retrying will not help.
+						deniedPort = candidate.Name
+						break
+					}
 					if isPortLockedError(err) {
 						lockedPort = candidate.Name
 					}
-					time.Sleep(resetAttemptDelay)
+					if err := sleepContext(ctx, resetAttemptDelay); err != nil {
+						return err
+					}
 					continue
 				}
-				// Some USB CDC implementations only deliver data after DTR is asserted.
-				// Ignore errors here (not all backends support toggling modem lines).
-				_ = s.SetDTR(true)
-				_ = s.SetRTS(true)
-				time.Sleep(250 * time.Millisecond)
-
-				_, werr := s.Write([]byte("r"))
-				if werr == nil {
-					_, _ = s.Write([]byte("\n"))
-				}
-				time.Sleep(250 * time.Millisecond)
-				_ = s.Close()
 				if werr != nil {
-					time.Sleep(resetAttemptDelay)
+					serialLog.Debug("UploadToPico: Reset write to %s failed (attempt %d): %v", candidate.Name, attempt, werr)
+					if err := sleepContext(ctx, resetAttemptDelay); err != nil {
+						return err
+					}
 					continue
 				}
 
 				// We successfully sent the reset command. Windows can be slow to drop the USB mount,
 				// so treat the write as success and confirm disconnect asynchronously.
+				serialLog.Info("UploadToPico: Sent reset command via %s", candidate.Name)
 				confirmDriveDropsAsync(driveRoot, 20*time.Second)
 				return nil
 			}
@@ -502,26 +992,46 @@ func (a *App) UploadToPico(projectJson string) string {
 
 		// Provide specific error message if port was locked by another application.
 		if lockedPort != "" {
+			if holder := portHolder(lockedPort); holder != "" {
+				serialLog.Info("UploadToPico: %s is held by %s", lockedPort, holder)
+				return fmt.Errorf("PORT_LOCKED:%s:%s", lockedPort, holder)
+			}
 			return fmt.Errorf("PORT_LOCKED:%s", lockedPort)
 		}
+		if deniedPort != "" {
+			hint := serialPermissionHint(deniedPort)
+			serialLog.Warn("UploadToPico: %s", hint)
+			return fmt.Errorf("PORT_DENIED:%s\n%s", deniedPort, hint)
+		}
 
 		return fmt.Errorf("RESET_FAILED")
 	}
 
+	if !a.currentSettings().AutoResetAfterUpload {
+		a.emitUploadManualEject(targetDrive, "AUTO_RESET_DISABLED")
+		return "Success! " + i18n.T("Uploaded %d events to %s. Eject the drive to reload.", count, targetDrive)
+	}
+
+	meter.enter(phaseReset)
 	serialErr := trySerialReset()
+	if errors.Is(serialErr, context.Canceled) {
+		// The show is written; only the reload was skipped.
+		a.emitUploadManualEject(targetDrive, "RESET_CANCELLED")
+		return "Success! " + i18n.T("Uploaded %d events to %s. Eject the drive to reload.", count, targetDrive)
+	}
 	if serialErr == nil {
-		return fmt.Sprintf("Success! Uploaded %d events. Device is reloading.", count)
+		return "Success! " + i18n.T("Uploaded %d events. Device is reloading.", count)
 	}
 
 	// Pass structured error code to frontend for clean messaging.
 	a.emitUploadManualEject(targetDrive, serialErr.Error())
-	a.emitUploadStatus("Auto-reset failed; please safely eject the drive before unplugging.")
-	return fmt.Sprintf("Success! Uploaded %d events to %s. Manual eject required.", count, targetDrive)
+	a.emitUploadStatus(i18n.T("Auto-reset failed; please safely eject the drive before unplugging."))
+	return "Success! " + i18n.T("Uploaded %d events to %s. Manual eject required.", count, targetDrive)
 }
 
 type LoadResponse struct {
 	ProjectJson string            `json:"projectJson"`
-	AudioFiles  map[string]string `json:"audioFiles"`
+	AudioFiles  map[string]string `json:"audioFiles"` // buffer ID -> fetchable URL
 	FilePath    string            `json:"filePath"`
 	Error       string            `json:"error"`
 }
@@ -532,11 +1042,17 @@ type PicoConnectionStatus struct {
 	USBDrive         string `json:"usbDrive"`         // e.g. "E:/"
 	SerialPort       string `json:"serialPort"`       // e.g. "COM5"
 	SerialPortLocked bool   `json:"serialPortLocked"` // true if port is held by another application
+	SerialPortHolder string `json:"serialPortHolder"` // process holding a locked port, if known
+	SerialPortDenied bool   `json:"serialPortDenied"` // true if the user may not open the port
+	SerialHint       string `json:"serialHint"`       // how to fix SerialPortDenied
 }
 
 func (a *App) LoadProject() LoadResponse {
+	defer a.recoverBinding("LoadProject")
+
 	filename, err := runtime.OpenFileDialog(a.ctx, runtime.OpenDialogOptions{
-		Title: "Open Project",
+		DefaultDirectory: a.currentSettings().ProjectDir,
+		Title:            "Open Project",
 		Filters: []runtime.FileFilter{
 			{DisplayName: "PicoLume Project (*.lum)", Pattern: "*.lum"},
 		},
@@ -546,24 +1062,37 @@ func (a *App) LoadProject() LoadResponse {
 		return LoadResponse{Error: "Cancelled"}
 	}
 
+	return a.LoadProjectFromPath(filename)
+}
+
+// LoadProjectFromPath loads a .lum project without a dialog (command line,
+// file association, recent files).
+func (a *App) LoadProjectFromPath(filename string) LoadResponse {
+	defer a.recoverBinding("LoadProjectFromPath")
+
+	if !strings.EqualFold(filepath.Ext(filename), ".lum") {
+		return LoadResponse{Error: i18n.T("Not a PicoLume project (.lum): %s", filename)}
+	}
+
 	// Security: Check zip file size before opening
 	fileInfo, err := os.Stat(filename)
 	if err != nil {
-		return LoadResponse{Error: "Failed to stat file: " + err.Error()}
+		return LoadResponse{Error: i18n.T("Failed to stat file: %s", err.Error())}
 	}
-	if fileInfo.Size() > MaxZipFileSize {
-		return LoadResponse{Error: fmt.Sprintf("Project file too large (max %dMB)", MaxZipFileSize/(1024*1024))}
+	limits := a.currentSettings().Limits
+	if fileInfo.Size() > int64(limits.ProjectFileMB)*megabyte {
+		return LoadResponse{Error: i18n.T("Project file too large (max %dMB)", limits.ProjectFileMB)}
 	}
 
 	r, err := zip.OpenReader(filename)
 	if err != nil {
-		return LoadResponse{Error: "Failed to open zip: " + err.Error()}
+		return LoadResponse{Error: i18n.T("Failed to open zip: %s", err.Error())}
 	}
 	defer r.Close()
 
 	// Security: Check file count to prevent zip bombs
-	if len(r.File) > MaxFilesInZip {
-		return LoadResponse{Error: fmt.Sprintf("Too many files in archive (max %d)", MaxFilesInZip)}
+	if len(r.File) > limits.FilesInArchive {
+		return LoadResponse{Error: i18n.T("Too many files in archive (max %d)", limits.FilesInArchive)}
 	}
 
 	response := LoadResponse{
@@ -571,93 +1100,65 @@ func (a *App) LoadProject() LoadResponse {
 		FilePath:   filename,
 	}
 
-	var totalExtracted uint64 = 0
-
+	// Check declared sizes up front so an oversized archive fails before
+	// anything is extracted; the readers below enforce the real sizes.
+	var totalDeclared uint64
+	var audioEntries []*zip.File
 	for _, f := range r.File {
 		// Security: Skip directories
 		if f.FileInfo().IsDir() {
 			continue
 		}
 
-		// Security: Check uncompressed size before reading
 		uncompressedSize := f.UncompressedSize64
 		isProjectJson := f.Name == "project.json"
 		isAudioFile := strings.HasPrefix(f.Name, "audio/")
 
 		// Apply appropriate size limits based on file type
 		if isProjectJson && uncompressedSize > MaxProjectJsonSize {
-			return LoadResponse{Error: fmt.Sprintf("project.json too large (max %dMB)", MaxProjectJsonSize/(1024*1024))}
+			return LoadResponse{Error: i18n.T("project.json too large (max %dMB)", MaxProjectJsonSize/(1024*1024))}
 		}
-		if isAudioFile && uncompressedSize > MaxAudioFileSize {
-			return LoadResponse{Error: fmt.Sprintf("Audio file too large (max %dMB)", MaxAudioFileSize/(1024*1024))}
+		if isAudioFile && uncompressedSize > uint64(limits.AudioFileMB)*megabyte {
+			return LoadResponse{Error: i18n.T("Audio file too large (max %dMB)", limits.AudioFileMB)}
 		}
 
 		// Security: Check total extracted size
-		if totalExtracted+uncompressedSize > MaxTotalExtractedSize {
-			return LoadResponse{Error: fmt.Sprintf("Total extracted size exceeds limit (max %dMB)", MaxTotalExtractedSize/(1024*1024))}
-		}
-
-		// Only process known file types
-		if !isProjectJson && !isAudioFile {
-			continue
-		}
-
-		rc, err := f.Open()
-		if err != nil {
-			logger.Warn("LoadProject: Failed to open zip entry %s: %v", f.Name, err)
-			continue
-		}
-
-		// Security: Use LimitReader to enforce size limit during read
-		var maxSize int64
-		if isProjectJson {
-			maxSize = MaxProjectJsonSize
-		} else {
-			maxSize = MaxAudioFileSize
-		}
-		limitedReader := io.LimitReader(rc, maxSize+1) // +1 to detect overflow
-
-		content, err := io.ReadAll(limitedReader)
-		rc.Close()
-
-		if err != nil {
-			logger.Warn("LoadProject: Failed to read zip entry %s: %v", f.Name, err)
-			continue
-		}
-
-		// Security: Verify we didn't exceed the limit
-		if int64(len(content)) > maxSize {
-			return LoadResponse{Error: "File exceeded size limit during extraction"}
+		if totalDeclared+uncompressedSize > uint64(limits.TotalExtractedMB)*megabyte {
+			return LoadResponse{Error: i18n.T("Total extracted size exceeds limit (max %dMB)", limits.TotalExtractedMB)}
 		}
-
-		totalExtracted += uint64(len(content))
+		totalDeclared += uncompressedSize
 
 		if isProjectJson {
-			response.ProjectJson = string(content)
-			logger.Info("LoadProject: Loaded project.json (%d bytes)", len(content))
-		} else if isAudioFile {
-			nameParts := strings.Split(f.Name, "/")
-			fileName := nameParts[len(nameParts)-1]
-			fileParts := strings.Split(fileName, ".")
-			if len(fileParts) < 2 {
-				logger.Warn("LoadProject: Skipping malformed audio filename: %s", f.Name)
-				continue
+			// The JSON is held as bytes, then as a string for the frontend.
+			release, err := a.reserveMemory("Loading project.json", 2*int64(uncompressedSize))
+			if err != nil {
+				return LoadResponse{Error: err.Error()}
 			}
-			id := fileParts[0]
-			ext := fileParts[len(fileParts)-1]
-
-			mime := "audio/mpeg"
-			if ext == "wav" {
-				mime = "audio/wav"
+			content, err := readZipEntry(f, MaxProjectJsonSize)
+			release()
+			if errors.Is(err, errEntryTooLarge) {
+				return LoadResponse{Error: i18n.T("File exceeded size limit during extraction")}
 			}
-			if ext == "ogg" {
-				mime = "audio/ogg"
+			if err != nil {
+				logger.Warn("LoadProject: Failed to read zip entry %s: %v", f.Name, err)
+				continue
 			}
+			response.ProjectJson = string(content)
+			logger.Info("LoadProject: Loaded project.json (%d bytes)", len(content))
+		} else if isAudioFile {
+			audioEntries = append(audioEntries, f)
+		}
+	}
 
-			b64 := base64.StdEncoding.EncodeToString(content)
-			response.AudioFiles[id] = fmt.Sprintf("data:%s;base64,%s", mime, b64)
-			logger.Debug("LoadProject: Loaded audio file %s (%d bytes)", id, len(content))
+	if len(audioEntries) > 0 {
+		audio, err := a.extractAudio(audioEntries, limits)
+		if errors.Is(err, errEntryTooLarge) {
+			return LoadResponse{Error: i18n.T("File exceeded size limit during extraction")}
+		}
+		if err != nil {
+			return LoadResponse{Error: i18n.T("Failed to extract audio: %s", err.Error())}
 		}
+		response.AudioFiles = audio
 	}
 
 	logger.Info("LoadProject: Successfully loaded project with %d audio files from %s", len(response.AudioFiles), filename)
@@ -665,7 +1166,26 @@ func (a *App) LoadProject() LoadResponse {
 }
 
 // GetPicoConnectionStatus provides lightweight device presence info for the status bar.
+// It returns the connection watcher's latest result without touching the
+// hardware; only a call before the first scan waits for one.
 func (a *App) GetPicoConnectionStatus() PicoConnectionStatus {
+	defer a.recoverBinding("GetPicoConnectionStatus")
+
+	a.mu.Lock()
+	status, scanned := a.lastConnStatus, !a.lastConnAt.IsZero()
+	a.mu.Unlock()
+	if scanned {
+		return status
+	}
+	return a.refreshConnectionStatus()
+}
+
+// scanConnectionStatus looks for receiver drives and serial ports. The
+// previous result lets it skip re-probing a serial port already known to be
+// free, since opening it can disturb other programs using the port.
+// Probes run through ports, which skips them while another operation such
+// as a reset uses the ports; the port held by its session is never probed.
+func scanConnectionStatus(previous PicoConnectionStatus, baudRate int, ports *serialmanager.Manager) PicoConnectionStatus {
 	status := PicoConnectionStatus{
 		Connected:  false,
 		Mode:       "NONE",
@@ -673,15 +1193,10 @@ func (a *App) GetPicoConnectionStatus() PicoConnectionStatus {
 		SerialPort: "",
 	}
 
-	// USB drive scan (Windows-only path semantics, but Stat works elsewhere too if mounted).
+	// USB drive scan: drive letters on Windows, mount points elsewhere.
 	usbDrive := ""
 	usbMode := ""
-	for _, drive := range "CDEFGHIJKLMNOPQRSTUVWXYZ" {
-		driveRoot := string(drive) + ":/"
-		if _, err := os.Stat(driveRoot); err != nil {
-			continue
-		}
-
+	for _, driveRoot := range volumeRoots() {
 		// Bootloader mode is exposed as a UF2 volume.
 		if _, err := os.Stat(driveRoot + "INFO_UF2.TXT"); err == nil {
 			usbDrive = driveRoot
@@ -708,12 +1223,14 @@ func (a *App) GetPicoConnectionStatus() PicoConnectionStatus {
 		status.Connected = true
 	}
 
+	var sessionPort string
+	if s := ports.Session(); s != nil {
+		sessionPort = s.Name()
+	}
+
 	// Serial port scan (for reset + normal run mode).
-	if ports, err := enumerator.GetDetailedPortsList(); err == nil {
-		for _, port := range ports {
-			if !isPicoLikeUSBSerialPort(port) {
-				continue
-			}
+	if candidates, err := findSerialCandidates(); err == nil {
+		for _, port := range candidates {
 			status.SerialPort = port.Name
 			status.Connected = true
 			if status.Mode == "NONE" {
@@ -722,16 +1239,40 @@ func (a *App) GetPicoConnectionStatus() PicoConnectionStatus {
 				status.Mode = "USB+SERIAL"
 			}
 
+			if port.Name == sessionPort || port.Name == previous.SerialPort && !previous.SerialPortLocked && !previous.SerialPortDenied {
+				break
+			}
+
 			// Check if the port is locked by another application.
 			// Try a brief open to detect if another app (Arduino IDE, etc.) has the port.
-			mode := &serial.Mode{BaudRate: 115200}
-			s, err := serial.Open(port.Name, mode)
-			if err != nil {
-				if isPortLockedError(err) {
+			mode := &serial.Mode{BaudRate: baudRate}
+			err := ports.TryDo(serialmanager.OpProbe, port.Name, func() error {
+				s, err := openSerial(port.Name, mode)
+				if err == nil {
+					_ = s.Close()
+				}
+				return err
+			})
+			if errors.Is(err, serialmanager.ErrBusy) || errors.Is(err, serialmanager.ErrClosed) {
+				// The port is in use by this app; keep what the last probe found.
+				if previous.SerialPort == port.Name {
+					status.SerialPortLocked, status.SerialPortHolder = previous.SerialPortLocked, previous.SerialPortHolder
+					status.SerialPortDenied, status.SerialHint = previous.SerialPortDenied, previous.SerialHint
+				}
+			} else if err != nil {
+				if isPortPermissionError(err) {
+					status.SerialPortDenied = true
+					status.SerialHint = serialPermissionHint(port.Name)
+				} else if isPortLockedError(err) {
 					status.SerialPortLocked = true
+					// Finding the holder walks every process; do it once
+					// per lock rather than on every poll.
+					if previous.SerialPortLocked && previous.SerialPort == port.Name && previous.SerialPortHolder != "" {
+						status.SerialPortHolder = previous.SerialPortHolder
+					} else {
+						status.SerialPortHolder = portHolder(port.Name)
+					}
 				}
-			} else {
-				_ = s.Close()
 			}
 			break
 		}
@@ -1,8 +1,35 @@
 package main
 
 import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
 	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	goruntime "runtime"
+	"strings"
+	"sync"
+	"syscall"
 	"testing"
+	"time"
+
+	"PicoLume/bingen"
+	"PicoLume/firmware"
+	"PicoLume/serialsession"
+	"PicoLume/settings"
+
+	"go.bug.st/serial"
+	"go.bug.st/serial/enumerator"
 )
 
 func TestValidateSavePath(t *testing.T) {
@@ -187,19 +214,19 @@ func TestParseColorInBinaryGeneration(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			data, count, err := generateBinaryBytes(tt.projectJson)
+			result, err := bingen.GenerateFromJSON(tt.projectJson)
 
 			if (err != nil) != tt.wantErr {
-				t.Errorf("generateBinaryBytes() error = %v, wantErr %v", err, tt.wantErr)
+				t.Errorf("GenerateFromJSON() error = %v, wantErr %v", err, tt.wantErr)
 				return
 			}
 
 			if !tt.wantErr {
-				if len(data) == 0 {
-					t.Error("generateBinaryBytes() returned empty data")
+				if len(result.Bytes) == 0 {
+					t.Error("GenerateFromJSON() returned empty data")
 				}
-				if count != 1 {
-					t.Errorf("generateBinaryBytes() event count = %v, want 1", count)
+				if result.EventCount != 1 {
+					t.Errorf("GenerateFromJSON() event count = %v, want 1", result.EventCount)
 				}
 			}
 		})
@@ -338,20 +365,20 @@ func TestCalculateMaskInBinaryGeneration(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			data, count, err := generateBinaryBytes(tt.projectJson)
+			result, err := bingen.GenerateFromJSON(tt.projectJson)
 
 			if (err != nil) != tt.wantErr {
-				t.Errorf("generateBinaryBytes() error = %v, wantErr %v", err, tt.wantErr)
+				t.Errorf("GenerateFromJSON() error = %v, wantErr %v", err, tt.wantErr)
 				return
 			}
 
-			if count != tt.wantEvents {
-				t.Errorf("generateBinaryBytes() event count = %v, want %v", count, tt.wantEvents)
+			if result.EventCount != tt.wantEvents {
+				t.Errorf("GenerateFromJSON() event count = %v, want %v", result.EventCount, tt.wantEvents)
 			}
 
 			// Even with no events, should still generate header + LUT
-			if len(data) == 0 && !tt.wantErr {
-				t.Error("generateBinaryBytes() returned empty data")
+			if len(result.Bytes) == 0 {
+				t.Error("GenerateFromJSON() returned empty data")
 			}
 		})
 	}
@@ -367,13 +394,14 @@ func TestBinaryGenerationHeader(t *testing.T) {
 		]}]
 	}`
 
-	data, count, err := generateBinaryBytes(projectJson)
+	result, err := bingen.GenerateFromJSON(projectJson)
 	if err != nil {
-		t.Fatalf("generateBinaryBytes() error = %v", err)
+		t.Fatalf("GenerateFromJSON() error = %v", err)
 	}
+	data := result.Bytes
 
-	if count != 1 {
-		t.Errorf("Expected 1 event, got %d", count)
+	if result.EventCount != 1 {
+		t.Errorf("Expected 1 event, got %d", result.EventCount)
 	}
 
 	// Check magic number "PICO" (0x5049434F in little endian)
@@ -431,9 +459,9 @@ func TestInvalidJSON(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			_, _, err := generateBinaryBytes(tt.projectJson)
+			_, err := bingen.GenerateFromJSON(tt.projectJson)
 			if err == nil {
-				t.Error("generateBinaryBytes() expected error for invalid JSON, got nil")
+				t.Error("GenerateFromJSON() expected error for invalid JSON, got nil")
 			}
 		})
 	}
@@ -454,13 +482,94 @@ func TestAudioTracksIgnored(t *testing.T) {
 		]
 	}`
 
-	_, count, err := generateBinaryBytes(projectJson)
+	result, err := bingen.GenerateFromJSON(projectJson)
+	if err != nil {
+		t.Fatalf("GenerateFromJSON() error = %v", err)
+	}
+
+	if result.EventCount != 1 {
+		t.Errorf("Expected 1 event (only LED), got %d", result.EventCount)
+	}
+}
+
+// TestCueBlockWritten verifies that enabled cues reach show.bin on the
+// desktop path
+func TestCueBlockWritten(t *testing.T) {
+	projectJson := `{
+		"settings": {"ledCount": 10, "brightness": 100, "profiles": [], "patch": {}, "showDuration": 1000},
+		"propGroups": [{"id": "g1", "name": "Test", "ids": "1"}],
+		"tracks": [
+			{"type": "led", "groupId": "g1", "clips": [
+				{"startTime": 0, "duration": 1000, "type": "solid", "props": {"color": "#FF0000"}}
+			]}
+		],
+		"cues": [{"id": "A", "timeMs": 500, "enabled": true}]
+	}`
+
+	result, err := bingen.GenerateFromJSON(projectJson)
+	if err != nil {
+		t.Fatalf("GenerateFromJSON() error = %v", err)
+	}
+	if !bytes.Contains(result.Bytes, []byte("CUE1")) {
+		t.Error("Expected CUE1 block for enabled cue")
+	}
+}
+
+func TestInspectBinary(t *testing.T) {
+	projectJson := `{
+		"settings": {"profiles": [{"id": "p", "assignedIds": "1-2", "ledCount": 30, "colorOrder": 1, "brightnessCap": 128}], "patch": {}, "showDuration": 2000},
+		"propGroups": [{"id": "g1", "name": "Test", "ids": "1-2,5"}],
+		"tracks": [
+			{"type": "led", "groupId": "g1", "clips": [
+				{"startTime": 0, "duration": 1000, "type": "strobe", "props": {"color": "#FF0000"}}
+			]}
+		],
+		"cues": [{"id": "A", "timeMs": 500, "enabled": true}]
+	}`
+	result, err := bingen.GenerateFromJSON(projectJson)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "show.bin")
+	if err := os.WriteFile(path, result.Bytes, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := inspectBinary(path)
 	if err != nil {
-		t.Fatalf("generateBinaryBytes() error = %v", err)
+		t.Fatalf("inspectBinary() error = %v", err)
+	}
+	if r.Version != bingen.FormatVersion || r.EventCount != 2 || r.DurationMs != 2000 || r.Cues["A"] != 500 {
+		t.Errorf("report = %+v", r)
+	}
+	if p := r.Props[0]; p.LedCount != 30 || p.ColorOrder != "RGB" || p.BrightnessCap != 128 {
+		t.Errorf("prop 1 = %+v", p)
+	}
+	if e := r.Events[0]; e.Effect != "strobe" || e.Color != "#ff0000" || e.Props != "1-2,5" {
+		t.Errorf("event 0 = %+v", e)
 	}
 
-	if count != 1 {
-		t.Errorf("Expected 1 event (only LED), got %d", count)
+	// An uploaded show.bin, written with the settings, matches ShowHash.
+	a := &App{}
+	uploaded, err := a.generateShow(genUpload, projectJson, a.showOptions(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, uploaded.Bytes, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if r, err = inspectBinary(path); err != nil {
+		t.Fatal(err)
+	}
+	if h := a.ShowHash(projectJson); h.Error != "" || h.Hash != r.Hash {
+		t.Errorf("ShowHash() = %+v, want the file's hash %s", h, r.Hash)
+	}
+
+	if err := os.WriteFile(path, []byte("not a show"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := inspectBinary(path); err == nil {
+		t.Error("inspectBinary(text file) should fail")
 	}
 }
 
@@ -526,6 +635,11 @@ func TestIsPortLockedError(t *testing.T) {
 			err:      errors.New("permission denied"),
 			expected: true, // Contains "denied"
 		},
+		{
+			name:     "EACCES is a permission error, not a lock",
+			err:      &fs.PathError{Op: "open", Path: "/dev/ttyACM0", Err: fs.ErrPermission},
+			expected: false,
+		},
 	}
 
 	for _, tt := range tests {
@@ -537,3 +651,1302 @@ func TestIsPortLockedError(t *testing.T) {
 		})
 	}
 }
+
+func TestIsSerialCandidate(t *testing.T) {
+	tests := []struct {
+		port enumerator.PortDetails
+		want bool
+	}{
+		{enumerator.PortDetails{Name: "COM5", IsUSB: true, VID: "2E8A"}, true},
+		{enumerator.PortDetails{Name: "/dev/ttyACM0", IsUSB: true, VID: "2341"}, false}, // Arduino
+		{enumerator.PortDetails{Name: "/dev/ttyACM0"}, true},                            // no USB details
+		{enumerator.PortDetails{Name: "/dev/cu.usbmodem1101"}, true},
+		{enumerator.PortDetails{Name: "/dev/tty.usbmodem1101", IsUSB: true, VID: "2E8A"}, false},
+		{enumerator.PortDetails{Name: "/dev/ttyS0"}, false},
+	}
+	for _, tt := range tests {
+		if got := isSerialCandidate(&tt.port); got != tt.want {
+			t.Errorf("isSerialCandidate(%+v) = %v, want %v", tt.port, got, tt.want)
+		}
+	}
+}
+
+func TestSerialCandidates(t *testing.T) {
+	arduino := &enumerator.PortDetails{Name: "/dev/ttyACM0", IsUSB: true, VID: "2341"}
+	pico := &enumerator.PortDetails{Name: "/dev/ttyACM1", IsUSB: true, VID: "2E8A"}
+	bare := &enumerator.PortDetails{Name: "/dev/ttyACM2"}
+	tests := []struct {
+		name  string
+		ports []*enumerator.PortDetails
+		nodes []string
+		want  []string
+	}{
+		{"matched by VID", []*enumerator.PortDetails{arduino, pico}, []string{"/dev/ttyACM0", "/dev/ttyACM1"}, []string{"/dev/ttyACM1"}},
+		{"only other devices", []*enumerator.PortDetails{arduino}, []string{"/dev/ttyACM0"}, nil},
+		{"no USB details", []*enumerator.PortDetails{bare}, []string{"/dev/ttyACM2"}, []string{"/dev/ttyACM2"}},
+		{"enumerator found nothing", nil, []string{"/dev/ttyACM3", "/dev/ttyACM0"}, []string{"/dev/ttyACM0", "/dev/ttyACM3"}},
+		{"node next to another device", []*enumerator.PortDetails{arduino}, []string{"/dev/ttyACM0", "/dev/ttyACM4"}, []string{"/dev/ttyACM4"}},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, p := range serialCandidates(tt.ports, tt.nodes) {
+			got = append(got, p.Name)
+		}
+		if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("%s: serialCandidates() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestPortHolderFindsProcess(t *testing.T) {
+	if goruntime.GOOS != "linux" {
+		t.Skip("portHolder reads /proc")
+	}
+	dev := filepath.Join(t.TempDir(), "ttyACM0")
+	f, err := os.Create(dev)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	cmd := exec.Command("sleep", "5")
+	cmd.Stdin = f
+	if err := cmd.Start(); err != nil {
+		t.Skipf("cannot start sleep: %v", err)
+	}
+	defer func() {
+		cmd.Process.Kill()
+		cmd.Wait()
+	}()
+
+	want := fmt.Sprintf("sleep (PID %d)", cmd.Process.Pid)
+	if got := portHolder(dev); got != want {
+		t.Errorf("portHolder() = %q, want %q", got, want)
+	}
+}
+
+func TestWriteDeviceFilesRollsBack(t *testing.T) {
+	root := t.TempDir()
+	show := filepath.Join(root, "show.bin")
+	if err := os.WriteFile(show, []byte("old show"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// The second entry cannot be created because show.bin is a file.
+	a := &App{}
+	err := a.writeDeviceFiles(context.Background(), root, []deviceFile{
+		{Name: "show.bin", Data: []byte("new show")},
+		{Name: "show.bin/config.json", Data: []byte("{}")},
+	}, nil)
+	if err == nil {
+		t.Fatal("writeDeviceFiles() should fail")
+	}
+
+	got, err := os.ReadFile(show)
+	if err != nil || string(got) != "old show" {
+		t.Errorf("show.bin = %q, %v; want the old show restored", got, err)
+	}
+	if _, err := os.Stat(show + backupSuffix); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("backup left behind: %v", err)
+	}
+}
+
+func TestFitDeviceSpace(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "show.bin"), make([]byte, 10*1024), 0644); err != nil {
+		t.Fatal(err)
+	}
+	files := []deviceFile{{Name: "config.json"}, {Name: "show.bin"}}
+
+	tests := []struct {
+		name     string
+		space    volumeSpace
+		sizes    []int64
+		wantKeep bool
+		wantErr  error
+	}{
+		{"room for backups", volumeSpace{Free: 64 * 1024, Total: 1 << 20, Cluster: 4096}, []int64{100, 12 * 1024}, true, nil},
+		{"whole clusters", volumeSpace{Free: 8 * 1024, Total: 1 << 20, Cluster: 4096}, []int64{1, 4097}, false, nil},
+		{"only by overwriting", volumeSpace{Free: 8 * 1024, Total: 1 << 20, Cluster: 4096}, []int64{100, 12 * 1024}, false, nil},
+		{"not enough space", volumeSpace{Free: 8 * 1024, Total: 1 << 20, Cluster: 4096}, []int64{100, 64 * 1024}, false, errDeviceFull},
+		{"larger than the drive", volumeSpace{Free: 8 * 1024, Total: 32 * 1024, Cluster: 4096}, []int64{100, 64 * 1024}, false, errShowTooLarge},
+		{"unknown total", volumeSpace{Free: 8 * 1024}, []int64{100, 64 * 1024}, false, errDeviceFull},
+	}
+	for _, tt := range tests {
+		keep, err := fitDeviceSpace(tt.space, root, files, tt.sizes)
+		if keep != tt.wantKeep || !errors.Is(err, tt.wantErr) || (err == nil) != (tt.wantErr == nil) {
+			t.Errorf("%s: fitDeviceSpace() = %v, %v; want %v, %v", tt.name, keep, err, tt.wantKeep, tt.wantErr)
+		}
+	}
+
+	if _, err := checkDeviceSpace(root, files, []int64{1 << 62, 0}); !errors.Is(err, errShowTooLarge) {
+		t.Errorf("checkDeviceSpace() of an impossible upload = %v, want errShowTooLarge", err)
+	}
+}
+
+// fullDevice is a device file that runs out of space after limit bytes.
+type fullDevice struct {
+	*os.File
+	limit int
+}
+
+func (f *fullDevice) Write(p []byte) (int, error) {
+	if len(p) > f.limit {
+		n, _ := f.File.Write(p[:f.limit])
+		f.limit = 0
+		return n, syscall.ENOSPC
+	}
+	f.limit -= len(p)
+	return f.File.Write(p)
+}
+
+func TestWriteDeviceFilesDiskFull(t *testing.T) {
+	if !isDiskFull(syscall.ENOSPC) {
+		t.Skip("ENOSPC is not this platform's disk-full error")
+	}
+	defer func(create func(string) (deviceFileWriter, error)) { createDeviceFile = create }(createDeviceFile)
+	createDeviceFile = func(path string) (deviceFileWriter, error) {
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0666)
+		if err != nil || filepath.Base(path) == "config.json" {
+			return f, err
+		}
+		return &fullDevice{File: f, limit: writeChunkSize + 10}, nil
+	}
+
+	root := t.TempDir()
+	old := map[string]string{"show.bin": "old show", "config.json": `{"old": true}`}
+	for name, data := range old {
+		if err := os.WriteFile(filepath.Join(root, name), []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	a := &App{}
+	err := a.writeDeviceFiles(context.Background(), root, []deviceFile{
+		{Name: "config.json", Data: []byte("{}")},
+		{Name: "show.bin", Data: make([]byte, 3*writeChunkSize)},
+		{Name: "audio/show.mp3", Data: []byte("never written")},
+	}, nil)
+	if !errors.Is(err, errDeviceFull) {
+		t.Fatalf("writeDeviceFiles() error = %v, want errDeviceFull", err)
+	}
+
+	entries, _ := os.ReadDir(root)
+	if len(entries) != len(old) {
+		t.Errorf("volume holds %d entries after the failed upload, want the %d old files", len(entries), len(old))
+	}
+	for name, want := range old {
+		if got, err := os.ReadFile(filepath.Join(root, name)); err != nil || string(got) != want {
+			t.Errorf("%s = %q, %v; want %q restored", name, got, err, want)
+		}
+	}
+
+	// Without the old files to fall back on, the partial show.bin is removed.
+	root = t.TempDir()
+	if err := a.writeDeviceFiles(context.Background(), root, []deviceFile{{Name: "show.bin", Data: make([]byte, 2*writeChunkSize)}}, nil); !errors.Is(err, errDeviceFull) {
+		t.Fatalf("writeDeviceFiles() error = %v, want errDeviceFull", err)
+	}
+	if _, err := os.Stat(filepath.Join(root, "show.bin")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("partial show.bin left behind: %v", err)
+	}
+}
+
+func TestWriteDeviceFilesReportsProgress(t *testing.T) {
+	var events []UploadProgress
+	meter := &uploadMeter{emit: func(p UploadProgress) { events = append(events, p) }, now: time.Now}
+	a := &App{}
+	files := []deviceFile{
+		{Name: "config.json", Data: []byte("{}")},
+		{Name: "show.bin", Data: make([]byte, 3*writeChunkSize)},
+	}
+	if err := a.writeDeviceFiles(context.Background(), t.TempDir(), files, meter); err != nil {
+		t.Fatal(err)
+	}
+
+	total := int64(2 + 3*writeChunkSize)
+	var last int64
+	phases := make(map[string]bool)
+	for _, e := range events {
+		if e.Total != total || e.Written < last {
+			t.Fatalf("event %+v: want total %d and written never going back", e, total)
+		}
+		last = e.Written
+		phases[e.Phase] = true
+	}
+	if last != total || !phases[phaseCopy] || !phases[phaseSync] {
+		t.Errorf("events = %+v, want copy and sync phases ending at %d bytes", events, total)
+	}
+}
+
+func TestUploadMeterEstimate(t *testing.T) {
+	now := time.Unix(0, 0)
+	var got UploadProgress
+	m := &uploadMeter{emit: func(p UploadProgress) { got = p }, now: func() time.Time { return now }}
+	m.start(1000)
+	if got.Phase != phaseCopy || got.RemainingMs != -1 {
+		t.Errorf("at start = %+v, want copy with no estimate", got)
+	}
+	m.copied(0)
+	now = now.Add(time.Second)
+	m.copied(250)
+	if got.Written != 250 || got.RemainingMs != 3000 {
+		t.Errorf("after 250 bytes in 1s = %+v, want 3000 ms left", got)
+	}
+	now = now.Add(progressInterval / 2)
+	m.copied(260)
+	if got.Written != 250 {
+		t.Errorf("progress sent %v after the last, want it throttled", progressInterval/2)
+	}
+	m.enter(phaseReset)
+	if got.Phase != phaseReset || got.RemainingMs != -1 {
+		t.Errorf("reset = %+v, want no estimate", got)
+	}
+}
+
+func TestWriteDeviceFilesCancelled(t *testing.T) {
+	root := t.TempDir()
+	show := filepath.Join(root, "show.bin")
+	if err := os.WriteFile(show, []byte("old show"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	a := &App{}
+	err := a.writeDeviceFiles(ctx, root, []deviceFile{{Name: "show.bin", Data: []byte("new show")}}, nil)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("writeDeviceFiles() error = %v, want context.Canceled", err)
+	}
+	if got, err := os.ReadFile(show); err != nil || string(got) != "old show" {
+		t.Errorf("show.bin = %q, %v; want the old show restored", got, err)
+	}
+}
+
+// commanderFunc adapts a function to commander.
+type commanderFunc func(cmd string) (string, error)
+
+func (f commanderFunc) Command(cmd string, _ time.Duration) (string, error) { return f(cmd) }
+
+func TestCheckShowCapacity(t *testing.T) {
+	receiver := commanderFunc(func(cmd string) (string, error) {
+		if cmd != infoCommand {
+			return "", fmt.Errorf("unexpected command %q", cmd)
+		}
+		return "fw=1.4.0 flash=1048576", nil
+	})
+	device, err := queryDeviceInfo(receiver)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := checkShowCapacity(device, 1048576); err != nil {
+		t.Errorf("checkShowCapacity() at capacity = %v", err)
+	}
+	if err := checkShowCapacity(device, 1048577); !errors.Is(err, errShowTooLarge) {
+		t.Errorf("checkShowCapacity() above capacity = %v, want errShowTooLarge", err)
+	}
+
+	// Firmware that cannot tell leaves the decision to the drive.
+	for _, device := range []DeviceInfo{{}, {Firmware: "1.2.0"}} {
+		if err := checkShowCapacity(device, 1<<30); err != nil {
+			t.Errorf("checkShowCapacity(%+v) = %v, want nil", device, err)
+		}
+	}
+	old := commanderFunc(func(string) (string, error) { return "", errors.New("device error: unknown command") })
+	if _, err := queryDeviceInfo(old); err == nil {
+		t.Error("queryDeviceInfo() without the command should fail")
+	}
+}
+
+func TestParseDeviceInfo(t *testing.T) {
+	info, err := parseDeviceInfo("fw=1.4.0 format=4 flash=1572864 leds=150 ledtype=ws2812 order=GRB battery=3.92 uptime=812")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := DeviceInfo{Firmware: "1.4.0", FormatVersion: 4, FlashBytes: 1572864, LedCount: 150, LedType: "ws2812", ColorOrder: "GRB", BatteryVolts: 3.92}
+	if info != want {
+		t.Errorf("parseDeviceInfo() = %+v, want %+v", info, want)
+	}
+
+	for _, reply := range []string{"", "format=3 flash=1024", "fw=1.4.0 leds=many"} {
+		if _, err := parseDeviceInfo(reply); err == nil {
+			t.Errorf("parseDeviceInfo(%q) should fail", reply)
+		}
+	}
+}
+
+func TestSleepContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	start := time.Now()
+	if err := sleepContext(ctx, time.Minute); !errors.Is(err, context.Canceled) {
+		t.Errorf("sleepContext() = %v, want context.Canceled", err)
+	}
+	if time.Since(start) > time.Second {
+		t.Error("sleepContext() did not return when cancelled")
+	}
+	if err := sleepContext(context.Background(), time.Millisecond); err != nil {
+		t.Errorf("sleepContext() = %v, want nil", err)
+	}
+}
+
+// recordingWriter counts syncs; blockAfter makes later writes hang.
+type recordingWriter struct {
+	bytes.Buffer
+	syncs      int
+	writes     int
+	blockAfter int
+}
+
+func (w *recordingWriter) Write(p []byte) (int, error) {
+	w.writes++
+	if w.blockAfter > 0 && w.writes > w.blockAfter {
+		select {}
+	}
+	return w.Buffer.Write(p)
+}
+
+func (w *recordingWriter) Sync() error {
+	w.syncs++
+	return nil
+}
+
+func TestChunkedCopy(t *testing.T) {
+	data := bytes.Repeat([]byte{0xA5}, syncInterval+writeChunkSize/2)
+	w := &recordingWriter{}
+	var reports []int64
+	n, err := chunkedCopy(context.Background(), w, bytes.NewReader(data), time.Second, func(n int64) { reports = append(reports, n) })
+	if err != nil || n != int64(len(data)) || !bytes.Equal(w.Bytes(), data) {
+		t.Fatalf("chunkedCopy() = %d, %v; want %d bytes copied", n, err, len(data))
+	}
+	if want := syncInterval/writeChunkSize + 1; len(reports) != want {
+		t.Errorf("got %d progress reports, want one per chunk (%d)", len(reports), want)
+	}
+	if w.syncs != 2 {
+		t.Errorf("got %d syncs, want 2 (at %d bytes and at the end)", w.syncs, syncInterval)
+	}
+}
+
+func TestChunkedCopyDetectsStall(t *testing.T) {
+	w := &recordingWriter{blockAfter: 1}
+	data := make([]byte, 3*writeChunkSize)
+	n, err := chunkedCopy(context.Background(), w, bytes.NewReader(data), 20*time.Millisecond, func(int64) {})
+	if !errors.Is(err, errWriteStalled) {
+		t.Fatalf("chunkedCopy() error = %v, want errWriteStalled", err)
+	}
+	if n != writeChunkSize {
+		t.Errorf("written = %d, want %d before the stall", n, writeChunkSize)
+	}
+}
+
+func TestGroupDirName(t *testing.T) {
+	tests := []struct{ name, id, want string }{
+		{"Left Wing", "g1", "Left Wing"},
+		{"Drums: A/B", "g2", "Drums_ A_B"},
+		{" ... ", "g3", "g3"},
+		{"", "", "group"},
+	}
+	for _, tt := range tests {
+		if got := groupDirName(tt.name, tt.id); got != tt.want {
+			t.Errorf("groupDirName(%q, %q) = %q, want %q", tt.name, tt.id, got, tt.want)
+		}
+	}
+}
+
+func TestValidateProject(t *testing.T) {
+	a := &App{}
+	resp := a.ValidateProject(`{
+		"settings": {"showDuration": 2000},
+		"propGroups": [{"id": "g1", "ids": "1-2"}],
+		"tracks": [{"type": "led", "groupId": "g1", "clips": [
+			{"startTime": 0, "duration": 1000, "type": "solid", "props": {"color": "teal-ish"}}
+		]}]
+	}`)
+	if resp.Error != "" || len(resp.Problems) != 1 {
+		t.Fatalf("ValidateProject() = %+v, want one problem", resp)
+	}
+	if p := resp.Problems[0]; p.Track != 0 || p.Clip != 0 || p.Field != "color" || p.Severity != bingen.SeverityError {
+		t.Errorf("problem = %+v", p)
+	}
+
+	if resp := a.ValidateProject("{"); resp.Error == "" {
+		t.Error("ValidateProject(bad JSON) should fail")
+	}
+}
+
+func TestBuildPlaylist(t *testing.T) {
+	a := &App{}
+	dir := t.TempDir()
+	var paths []string
+	for i, name := range []string{"Opener", "Finale"} {
+		path := filepath.Join(dir, name+".lum")
+		project := fmt.Sprintf(`{"settings": {"showDuration": %d}, "propGroups": [{"id": "g1", "ids": "1"}],
+			"tracks": [{"type": "led", "groupId": "g1", "clips": [{"startTime": 0, "duration": 500, "type": "solid", "props": {"color": "#FF0000"}}]}]}`, 1000*(i+1))
+		if msg := a.SaveProjectToPath(path, project, nil); msg != "Saved" {
+			t.Fatalf("SaveProjectToPath() = %q", msg)
+		}
+		paths = append(paths, path)
+	}
+
+	data, entries, err := a.buildPlaylist(paths)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := bingen.ParsePlaylist(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(parsed) != 2 || parsed[0] != entries[0] || parsed[1].Name != "Finale" || parsed[1].DurationMs != 2000 {
+		t.Errorf("playlist index = %+v", parsed)
+	}
+
+	if _, _, err := a.buildPlaylist([]string{filepath.Join(dir, "missing.lum")}); err == nil {
+		t.Error("buildPlaylist accepted a missing project")
+	}
+}
+
+func TestWriteShowFile(t *testing.T) {
+	a := &App{}
+	projectJson := `{"settings": {"showDuration": 2000}, "propGroups": [{"id": "g1", "ids": "1-4"}],
+		"tracks": [{"type": "led", "groupId": "g1", "clips": [{"startTime": 0, "duration": 1000, "type": "solid", "props": {"color": "#00FF00"}}]}]}`
+	want, err := a.generateShow(genUpload, projectJson, a.showOptions(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "show.bin")
+	for _, opts := range []bingen.Options{a.showOptions(0), a.showOptions(5)} {
+		result, err := a.writeShowFile(path, projectJson, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if int64(len(got)) != result.Size || (opts.FormatVersion == 0 && !bytes.Equal(got, want.Bytes)) {
+			t.Errorf("format %d: wrote %d bytes, Size %d", opts.FormatVersion, len(got), result.Size)
+		}
+	}
+
+	if _, err := a.writeShowFile(path, "{", a.showOptions(0)); err == nil {
+		t.Error("writeShowFile accepted bad JSON")
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("files left behind: %v", entries)
+	}
+}
+
+func TestEstimateShowSize(t *testing.T) {
+	a := &App{}
+	project := `{"settings": {"showDuration": 1000}, "propGroups": [{"id": "g1", "ids": "1-4"}],
+		"tracks": [{"type": "led", "groupId": "g1", "clips": [{"startTime": 100, "duration": 500, "type": "solid", "props": {"color": "#FF0000"}}]}]}`
+	result, err := a.generateShow(genUpload, project, a.showOptions(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp := a.EstimateShowSize(project)
+	if resp.Error != "" || resp.Bytes != int64(len(result.Bytes)) || resp.Events != result.EventCount {
+		t.Errorf("EstimateShowSize() = %+v, want %d bytes and %d events", resp, len(result.Bytes), result.EventCount)
+	}
+	if resp.LimitBytes != 1536*1024 || !resp.Fits {
+		t.Errorf("EstimateShowSize() limit %d, fits %v; want the default 1536 KB and true", resp.LimitBytes, resp.Fits)
+	}
+
+	if resp := a.EstimateShowSize("{"); resp.Error == "" {
+		t.Error("EstimateShowSize accepted invalid JSON")
+	}
+}
+
+func TestFlashFirmwareFileRejectsBadImage(t *testing.T) {
+	a := &App{}
+	path := filepath.Join(t.TempDir(), "rx.uf2")
+	if err := os.WriteFile(path, []byte("not firmware"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if msg := a.FlashFirmwareFile(path); !strings.HasPrefix(msg, "Error: ") {
+		t.Errorf("FlashFirmwareFile(bad image) = %q, want an error before touching the receiver", msg)
+	}
+}
+
+func TestPresenceDebouncer(t *testing.T) {
+	var d presenceDebouncer
+	start := time.Unix(0, 0)
+	at := func(ms int) time.Time { return start.Add(time.Duration(ms) * time.Millisecond) }
+	usb := PicoConnectionStatus{Connected: true, Mode: "USB", USBDrive: "E:/"}
+	both := PicoConnectionStatus{Connected: true, Mode: "USB+SERIAL", USBDrive: "E:/", SerialPort: "COM5"}
+	none := PicoConnectionStatus{Mode: "NONE"}
+
+	steps := []struct {
+		ms     int
+		status PicoConnectionStatus
+		event  string
+	}{
+		{0, usb, ""},
+		{500, usb, ""},
+		{1000, usb, "device:connected"},
+		{1500, usb, ""},
+		// The drive dropping briefly during a reset is not reported.
+		{2000, none, ""},
+		{2500, usb, ""},
+		{4000, none, ""},
+		{5000, none, "device:disconnected"},
+		{6000, usb, ""},
+		{6500, both, ""},
+		{7500, both, "device:connected"},
+		{8000, usb, ""},
+		{9000, usb, "device:mode-changed"},
+	}
+	for _, s := range steps {
+		event, change := d.observe(s.status, at(s.ms))
+		if event != s.event {
+			t.Errorf("at %d ms: event %q, want %q", s.ms, event, s.event)
+		}
+		if event != "" && change.Status != s.status {
+			t.Errorf("at %d ms: status %+v, want %+v", s.ms, change.Status, s.status)
+		}
+	}
+	if event, change := d.observe(usb, at(9000)); event != "" || d.settling() {
+		t.Errorf("repeated status gave %q %+v", event, change)
+	}
+	d.observe(none, at(10000))
+	if !d.settling() {
+		t.Error("settling() = false with a change pending")
+	}
+	if _, change := d.observe(none, at(11000)); change.PreviousMode != "USB" {
+		t.Errorf("PreviousMode = %q, want USB", change.PreviousMode)
+	}
+}
+
+func TestSaveProjectKeepsLoadedAudio(t *testing.T) {
+	a := &App{}
+	defer a.clearProjectAudio()
+	dir := t.TempDir()
+	project := `{"settings": {"showDuration": 1000}}`
+	audio := []byte("ID3 fake mp3 bytes")
+	dataURL := "data:audio/mpeg;base64," + base64.StdEncoding.EncodeToString(audio)
+
+	first := filepath.Join(dir, "first.lum")
+	if msg := a.SaveProjectToPath(first, project, map[string]string{"buf1": dataURL}); msg != "Saved" {
+		t.Fatalf("SaveProjectToPath() = %q", msg)
+	}
+	loaded := a.LoadProjectFromPath(first)
+	if loaded.Error != "" || !strings.HasPrefix(loaded.AudioFiles["buf1"], projectAudioRoute) {
+		t.Fatalf("LoadProjectFromPath() = %+v", loaded)
+	}
+
+	// Saving again passes the loaded project's URLs back.
+	second := filepath.Join(dir, "second.lum")
+	if msg := a.SaveProjectToPath(second, loaded.ProjectJson, loaded.AudioFiles); msg != "Saved" {
+		t.Fatalf("SaveProjectToPath() with project audio URLs = %q", msg)
+	}
+	r, err := zip.OpenReader(second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	var got []byte
+	for _, f := range r.File {
+		if f.Name == "audio/buf1.mp3" {
+			got, err = readZipEntry(f, 1<<20)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if !bytes.Equal(got, audio) {
+		t.Errorf("resaved audio = %q, want %q", got, audio)
+	}
+
+	for _, src := range []string{projectAudioRoute + "unknown", "not a url", "data:audio/mpeg;base64,a,b"} {
+		if msg := a.SaveProjectToPath(first, project, map[string]string{"buf2": src}); !strings.HasPrefix(msg, "Error") {
+			t.Errorf("SaveProjectToPath() with audio %q = %q, want an error", src, msg)
+		}
+	}
+	if resp := a.LoadProjectFromPath(first); len(resp.AudioFiles) != 1 {
+		t.Errorf("a failed save replaced the project: %+v", resp)
+	}
+}
+
+func TestGenerationCancelPerPurpose(t *testing.T) {
+	a := &App{}
+	done := func(ctx context.Context) bool { return ctx.Err() != nil }
+
+	upload, endUpload := a.beginGeneration(genUpload)
+	preview, endPreview := a.beginGeneration(genPreview)
+	defer endPreview()
+	if done(upload) {
+		t.Fatal("a live preview generation cancelled the upload's")
+	}
+
+	upload2, endUpload2 := a.beginGeneration(genUpload)
+	defer endUpload2()
+	if !done(upload) || done(preview) {
+		t.Errorf("second upload: first upload cancelled %v, preview cancelled %v", done(upload), done(preview))
+	}
+	// The replaced generation finishing must not unregister the new one.
+	endUpload()
+
+	if msg := a.CancelGeneration(); msg != "Cancelled" {
+		t.Errorf("CancelGeneration() = %q", msg)
+	}
+	if !done(upload2) || done(preview) {
+		t.Errorf("CancelGeneration(): upload cancelled %v, preview cancelled %v", done(upload2), done(preview))
+	}
+	if !a.cancelGeneration(genPreview) || !done(preview) {
+		t.Error("cancelGeneration(genPreview) did not stop the preview")
+	}
+}
+
+func TestImportAudioIsUploadable(t *testing.T) {
+	a := &App{}
+	defer a.clearProjectAudio()
+	audio := []byte("RIFF fake wav")
+	dataURL := "data:audio/wav;base64," + base64.StdEncoding.EncodeToString(audio)
+
+	if msg := a.ImportAudio("audio_1", dataURL); msg != "OK" {
+		t.Fatalf("ImportAudio() = %q", msg)
+	}
+	files, err := a.uploadManifest(`{}`, UploadOptions{AudioIDs: []string{"audio_1"}})
+	if err != nil {
+		t.Fatalf("uploadManifest() error = %v", err)
+	}
+	if len(files) != 1 || files[0].Name != "audio/audio_1.wav" {
+		t.Fatalf("uploadManifest() = %+v", files)
+	}
+	if got, err := os.ReadFile(files[0].Source); err != nil || !bytes.Equal(got, audio) {
+		t.Errorf("stored audio = %q, %v", got, err)
+	}
+
+	for _, id := range []string{"", "../x", `a\b`, "a.mp3"} {
+		if msg := a.ImportAudio(id, dataURL); !strings.HasPrefix(msg, "Error") {
+			t.Errorf("ImportAudio(%q) = %q, want an error", id, msg)
+		}
+	}
+	if msg := a.ImportAudio("audio_2", "not a data url"); !strings.HasPrefix(msg, "Error") {
+		t.Errorf("ImportAudio() of a bad data URL = %q", msg)
+	}
+}
+
+// fakeReceiver stands in for a receiver's serial port. It reboots into the
+// bootloader, creating the bootloader volume, on the bootloader command or
+// the 1200 baud touch.
+type fakeReceiver struct {
+	volume      string
+	commandFail bool // writes fail, as on a receiver that stopped answering
+
+	mu      sync.Mutex
+	bauds   []int
+	written string
+}
+
+func (r *fakeReceiver) open(name string, mode *serial.Mode) (serial.Port, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.bauds = append(r.bauds, mode.BaudRate)
+	return &fakeReceiverPort{r: r, baud: mode.BaudRate, dtr: true}, nil
+}
+
+func (r *fakeReceiver) reboot() {
+	os.WriteFile(filepath.Join(r.volume, firmware.BootloaderInfoFile), []byte("UF2 Bootloader v3.0\n"), 0644)
+}
+
+type fakeReceiverPort struct {
+	serial.Port // methods the tests do not use panic
+	r           *fakeReceiver
+	baud        int
+	dtr         bool
+}
+
+func (p *fakeReceiverPort) Write(b []byte) (int, error) {
+	if p.r.commandFail {
+		return 0, errors.New("write timeout")
+	}
+	p.r.mu.Lock()
+	p.r.written += string(b)
+	p.r.mu.Unlock()
+	if string(b) == bootloaderCommand+"\n" {
+		p.r.reboot()
+	}
+	return len(b), nil
+}
+
+func (p *fakeReceiverPort) SetDTR(dtr bool) error {
+	p.dtr = dtr
+	return nil
+}
+
+func (p *fakeReceiverPort) Close() error {
+	if p.baud == firmware.BootloaderBaud && !p.dtr {
+		p.r.reboot()
+	}
+	return nil
+}
+
+func TestRebootToBootloader(t *testing.T) {
+	defer func(list func() ([]*enumerator.PortDetails, error), open func(string, *serial.Mode) (serial.Port, error), volumes func() []string) {
+		listSerialPorts, openSerialPort, listVolumes = list, open, volumes
+	}(listSerialPorts, openSerialPort, listVolumes)
+
+	tests := []struct {
+		name        string
+		commandFail bool
+		wantBauds   []int
+		wantWritten string
+	}{
+		{"bootloader command", false, []int{115200}, "b\n"},
+		{"1200 baud touch", true, []int{115200, firmware.BootloaderBaud}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			volume := t.TempDir()
+			rcv := &fakeReceiver{volume: volume, commandFail: tt.commandFail}
+			listSerialPorts = func() ([]*enumerator.PortDetails, error) {
+				return []*enumerator.PortDetails{{Name: "COM7", IsUSB: true, VID: "2E8A"}}, nil
+			}
+			openSerialPort = rcv.open
+			listVolumes = func() []string { return []string{volume + "/"} }
+
+			a := &App{}
+			defer a.serialPorts().Close()
+			root, err := a.rebootToBootloader(context.Background())
+			if err != nil {
+				t.Fatalf("rebootToBootloader() error = %v", err)
+			}
+			if root != volume+"/" {
+				t.Errorf("rebootToBootloader() = %q, want %q", root, volume+"/")
+			}
+			rcv.mu.Lock()
+			defer rcv.mu.Unlock()
+			if fmt.Sprint(rcv.bauds) != fmt.Sprint(tt.wantBauds) || rcv.written != tt.wantWritten {
+				t.Errorf("opened at %v and wrote %q, want %v and %q", rcv.bauds, rcv.written, tt.wantBauds, tt.wantWritten)
+			}
+		})
+	}
+
+	listSerialPorts = func() ([]*enumerator.PortDetails, error) { return nil, nil }
+	a := &App{}
+	defer a.serialPorts().Close()
+	if _, err := a.rebootToBootloader(context.Background()); err == nil {
+		t.Error("rebootToBootloader() without a receiver succeeded")
+	}
+}
+
+// unpluggedReceiver answers commands but fails every other write, like a
+// receiver pulled out right after it entered preview.
+type unpluggedReceiver struct {
+	r *io.PipeReader
+	w *io.PipeWriter
+}
+
+func openUnpluggedReceiver(string) (serialsession.Port, error) {
+	r, w := io.Pipe()
+	return &unpluggedReceiver{r, w}, nil
+}
+
+func (p *unpluggedReceiver) Read(b []byte) (int, error) { return p.r.Read(b) }
+func (p *unpluggedReceiver) Close() error               { return p.r.Close() }
+
+func (p *unpluggedReceiver) Write(b []byte) (int, error) {
+	if !strings.HasPrefix(string(b), "preview ") {
+		return 0, errors.New("device disconnected")
+	}
+	go io.WriteString(p.w, "OK\n")
+	return len(b), nil
+}
+
+func TestStartLivePreviewFailingAtOnce(t *testing.T) {
+	a := &App{}
+	defer a.serialPorts().Close()
+	connected := make(chan struct{}, 1)
+	a.serialPorts().OpenSession("COM7", openUnpluggedReceiver, serialsession.Options{OnState: func(state string, _ error) {
+		if state == serialsession.StateConnected {
+			connected <- struct{}{}
+		}
+	}})
+	select {
+	case <-connected:
+	case <-time.After(2 * time.Second):
+		t.Fatal("session did not connect")
+	}
+
+	if got := a.StartLivePreview(`{"settings": {"showDuration": 1000}}`); got != "OK" && got != "Cancelled" {
+		t.Fatalf("StartLivePreview() = %q", got)
+	}
+	deadline := time.Now().Add(time.Second)
+	for {
+		a.mu.Lock()
+		st := a.livePreview
+		a.mu.Unlock()
+		if st == nil && !a.serialPorts().State().Streaming {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("preview still recorded after its stream failed (streaming %v)", a.serialPorts().State().Streaming)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// answeringReceiver replies to every command line with reply, or not at
+// all when reply is empty.
+type answeringReceiver struct {
+	reply string
+	r     *io.PipeReader
+	w     *io.PipeWriter
+}
+
+func (p *answeringReceiver) Read(b []byte) (int, error) { return p.r.Read(b) }
+func (p *answeringReceiver) Close() error               { return p.r.Close() }
+
+func (p *answeringReceiver) Write(b []byte) (int, error) {
+	if p.reply != "" {
+		go io.WriteString(p.w, p.reply+"\n")
+	}
+	return len(b), nil
+}
+
+func TestUploadFormatForFirmwareWithoutInfo(t *testing.T) {
+	for _, reply := range []string{"ERR unknown command", ""} {
+		t.Run(fmt.Sprintf("reply %q", reply), func(t *testing.T) {
+			a := &App{}
+			defer a.serialPorts().Close()
+			connected := make(chan struct{}, 1)
+			a.serialPorts().OpenSession("COM7", func(string) (serialsession.Port, error) {
+				r, w := io.Pipe()
+				return &answeringReceiver{reply, r, w}, nil
+			}, serialsession.Options{OnState: func(state string, _ error) {
+				if state == serialsession.StateConnected {
+					connected <- struct{}{}
+				}
+			}})
+			select {
+			case <-connected:
+			case <-time.After(2 * time.Second):
+				t.Fatal("session did not connect")
+			}
+
+			device := a.receiverInfo()
+			if device.FormatVersion != bingen.FormatV2 {
+				t.Fatalf("receiverInfo() = %+v, want format V%d", device, bingen.FormatV2)
+			}
+			opts := a.showOptions(bingen.FormatV4)
+			opts.TargetVersion = device.FormatVersion
+			result, err := a.generateShow(genUpload, `{"settings": {"showDuration": 1000}}`, opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.Version != bingen.FormatV2 {
+				t.Errorf("show written as V%d, want V%d", result.Version, bingen.FormatV2)
+			}
+		})
+	}
+}
+
+func TestGenCacheKey(t *testing.T) {
+	const project = `{"settings": {"showDuration": 1000}}`
+	base := genCacheKey(project, bingen.Options{})
+	if genCacheKey(project, bingen.Options{}) != base {
+		t.Fatal("genCacheKey() differs for the same project and options")
+	}
+	if genCacheKey(`{"settings": {"showDuration": 2000}}`, bingen.Options{}) == base {
+		t.Error("genCacheKey() ignores the project")
+	}
+
+	// Every option but the progress callback changes the output, so each
+	// must change the key, and differently from the others.
+	seen := map[[sha256.Size]byte]string{}
+	typ := reflect.TypeOf(bingen.Options{})
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if !field.IsExported() || field.Name == "Progress" {
+			continue
+		}
+		var opts bingen.Options
+		v := reflect.ValueOf(&opts).Elem().Field(i)
+		switch v.Kind() {
+		case reflect.Bool:
+			v.SetBool(true)
+		case reflect.Int:
+			v.SetInt(3)
+		case reflect.String:
+			v.SetString("x")
+		default:
+			t.Fatalf("no test value for option %s of kind %s", field.Name, v.Kind())
+		}
+		key := genCacheKey(project, opts)
+		if key == base {
+			t.Errorf("genCacheKey() ignores %s", field.Name)
+		}
+		if other, ok := seen[key]; ok {
+			t.Errorf("genCacheKey() is the same for %s and %s", field.Name, other)
+		}
+		seen[key] = field.Name
+	}
+}
+
+func TestGenerateShowCache(t *testing.T) {
+	a := &App{}
+	const project = `{"settings": {"showDuration": 1000}}`
+	first, err := a.generateShow(genExport, project, bingen.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, err := a.generateShow(genUpload, project, bingen.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again != first {
+		t.Error("unchanged project was generated again")
+	}
+
+	checksummed, err := a.generateShow(genUpload, project, bingen.Options{Checksum: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if checksummed == first || bytes.Equal(checksummed.Bytes, first.Bytes) {
+		t.Error("changed options reused the cached show")
+	}
+	longer, err := a.generateShow(genUpload, `{"settings": {"showDuration": 2000}}`, bingen.Options{Checksum: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if longer == checksummed {
+		t.Error("changed project reused the cached show")
+	}
+}
+
+func TestReserveMemory(t *testing.T) {
+	store, err := settings.Open(filepath.Join(t.TempDir(), "settings.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Update(func(s *settings.Settings) { s.Limits.MemoryMB = 64 }); err != nil {
+		t.Fatal(err)
+	}
+	a := &App{prefs: store}
+
+	release, err := a.reserveMemory("first", 40*megabyte)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := a.reserveMemory("second", 30*megabyte); err == nil || !strings.Contains(err.Error(), "memory budget") {
+		t.Errorf("reserveMemory() over budget = %v, want a budget error", err)
+	}
+	if used := a.memory.used.Load(); used != 40*megabyte {
+		t.Errorf("used = %d after a refused reservation, want %d", used, 40*megabyte)
+	}
+	release()
+	release()
+	if used := a.memory.used.Load(); used != 0 {
+		t.Errorf("used = %d after releasing twice, want 0", used)
+	}
+
+	// With the budget spent, generating and loading refuse up front.
+	const project = `{"settings": {"showDuration": 1000}}`
+	path := filepath.Join(t.TempDir(), "show.lum")
+	if msg := a.SaveProjectToPath(path, project, nil); msg != "Saved" {
+		t.Fatalf("SaveProjectToPath() = %q", msg)
+	}
+	releaseAll, err := a.reserveMemory("everything", 64*megabyte)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := a.generateShow(genExport, project, bingen.Options{}); err == nil || !strings.Contains(err.Error(), "memory budget") {
+		t.Errorf("generateShow() without budget = %v, want a budget error", err)
+	}
+	if loaded := a.LoadProjectFromPath(path); !strings.Contains(loaded.Error, "memory budget") {
+		t.Errorf("LoadProjectFromPath() without budget = %+v, want a budget error", loaded)
+	}
+	releaseAll()
+	if _, err := a.generateShow(genExport, project, bingen.Options{}); err != nil {
+		t.Errorf("generateShow() after release = %v", err)
+	}
+	if loaded := a.LoadProjectFromPath(path); loaded.Error != "" {
+		t.Errorf("LoadProjectFromPath() after release = %q", loaded.Error)
+	}
+}
+
+// useDataDir points the app data directory, and with it the crash dumps,
+// at a temporary directory for the rest of the test.
+func useDataDir(t *testing.T) string {
+	saved := portableDataDir
+	t.Cleanup(func() { portableDataDir = saved })
+	portableDataDir = t.TempDir()
+	return portableDataDir
+}
+
+func TestIsCrashDumpName(t *testing.T) {
+	tests := map[string]bool{
+		"crash_2026-01-02_03-04-05.000.txt": true,
+		"../crash_x.txt":                    false,
+		"archived/crash_x.txt":              false,
+		`..\crash_x.txt`:                    false,
+		"crash_x.log":                       false,
+		"dump_x.txt":                        false,
+	}
+	for name, want := range tests {
+		if got := isCrashDumpName(name); got != want {
+			t.Errorf("isCrashDumpName(%q) = %v, want %v", name, got, want)
+		}
+	}
+}
+
+func TestSubmitCrashReports(t *testing.T) {
+	useDataDir(t)
+	dumps := map[string]string{
+		"crash_2026-01-02_03-04-05.000.txt": "panic: one",
+		"crash_2026-01-03_03-04-05.000.txt": "panic: two",
+	}
+	if err := os.MkdirAll(crashDir(), 0755); err != nil {
+		t.Fatal(err)
+	}
+	for name, text := range dumps {
+		if err := os.WriteFile(filepath.Join(crashDir(), name), []byte(text), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	pending, err := pendingCrashDumps()
+	if err != nil || len(pending) != 2 || pending[0].Name != "crash_2026-01-03_03-04-05.000.txt" {
+		t.Fatalf("pendingCrashDumps() = %+v, %v; want both, newest first", pending, err)
+	}
+
+	status := http.StatusInternalServerError
+	var fields map[string][]string
+	var files map[string]string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			t.Errorf("not a multipart form: %v", err)
+		}
+		fields, files = r.MultipartForm.Value, map[string]string{}
+		for _, fh := range r.MultipartForm.File["dump"] {
+			f, _ := fh.Open()
+			data, _ := io.ReadAll(f)
+			f.Close()
+			files[fh.Filename] = string(data)
+		}
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+
+	store, err := settings.Open(filepath.Join(t.TempDir(), "settings.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := &App{prefs: store}
+	names := []string{"crash_2026-01-02_03-04-05.000.txt", "crash_2026-01-03_03-04-05.000.txt"}
+	if msg := a.SubmitCrashReports(names, "it crashed"); !strings.HasPrefix(msg, "Error: No crash report endpoint") {
+		t.Errorf("SubmitCrashReports() without an endpoint = %q", msg)
+	}
+	if _, err := store.Update(func(s *settings.Settings) { s.CrashReportURL = srv.URL }); err != nil {
+		t.Fatal(err)
+	}
+	if msg := a.SubmitCrashReports([]string{"../crash_x.txt"}, ""); !strings.HasPrefix(msg, "Error: Invalid report name") {
+		t.Errorf("SubmitCrashReports() of a path = %q", msg)
+	}
+
+	if msg := a.SubmitCrashReports(names, "it crashed"); !strings.HasPrefix(msg, "Error: Crash report rejected") {
+		t.Errorf("SubmitCrashReports() on a server error = %q", msg)
+	}
+	if pending, _ := pendingCrashDumps(); len(pending) != 2 {
+		t.Errorf("rejected dumps were archived: %d left pending", len(pending))
+	}
+
+	status = http.StatusCreated
+	if msg := a.SubmitCrashReports(names, "it crashed"); msg != "OK" {
+		t.Fatalf("SubmitCrashReports() = %q", msg)
+	}
+	if fields["comment"][0] != "it crashed" || fields["appVersion"][0] != AppVersion || fields["os"][0] != goruntime.GOOS+"/"+goruntime.GOARCH {
+		t.Errorf("form fields = %v", fields)
+	}
+	if fmt.Sprint(files) != fmt.Sprint(dumps) {
+		t.Errorf("submitted dumps = %v, want %v", files, dumps)
+	}
+	if pending, _ := pendingCrashDumps(); len(pending) != 0 {
+		t.Errorf("%d dumps still pending after submitting", len(pending))
+	}
+	for name := range dumps {
+		if _, err := os.Stat(filepath.Join(archivedCrashDir(), name)); err != nil {
+			t.Errorf("%s not archived: %v", name, err)
+		}
+	}
+}
+
+func TestDismissCrashReports(t *testing.T) {
+	useDataDir(t)
+	name := "crash_2026-01-02_03-04-05.000.txt"
+	if err := os.MkdirAll(crashDir(), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(crashDir(), name), []byte("panic"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	a := &App{}
+	if msg := a.DismissCrashReports([]string{name, "../crash_x.txt"}); !strings.HasPrefix(msg, "Error: Invalid report name") {
+		t.Errorf("DismissCrashReports() with a path = %q", msg)
+	}
+	if pending, _ := pendingCrashDumps(); len(pending) != 1 {
+		t.Error("a refused dismissal archived the valid name")
+	}
+	if msg := a.DismissCrashReports([]string{name}); msg != "OK" {
+		t.Fatalf("DismissCrashReports() = %q", msg)
+	}
+	if pending, _ := pendingCrashDumps(); len(pending) != 0 {
+		t.Errorf("%d dumps still pending after dismissing", len(pending))
+	}
+	if _, err := os.Stat(filepath.Join(archivedCrashDir(), name)); err != nil {
+		t.Errorf("dump not archived: %v", err)
+	}
+}
+
+// crashDumps returns the text of the crash dumps written so far.
+func crashDumps(t *testing.T) []string {
+	t.Helper()
+	pending, err := pendingCrashDumps()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out []string
+	for _, d := range pending {
+		data, err := os.ReadFile(filepath.Join(crashDir(), d.Name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		out = append(out, string(data))
+	}
+	return out
+}
+
+func TestPanicCapture(t *testing.T) {
+	useDataDir(t)
+	a := &App{}
+
+	// Wails recovers a binding's panic and rejects the frontend promise;
+	// recoverBinding records it and passes it on for that.
+	binding := func() string {
+		defer a.recoverBinding("TestBinding")
+		var m map[string]int
+		m["x"] = 1
+		return "OK"
+	}
+	var rejected interface{}
+	func() {
+		defer func() { rejected = recover() }()
+		binding()
+	}()
+	if rejected == nil {
+		t.Error("recoverBinding() swallowed the panic; the frontend promise would hang")
+	}
+
+	done := make(chan struct{})
+	a.goSafe("test worker", func() {
+		defer close(done)
+		panic("worker failed")
+	})
+	<-done
+	// The dump is written after fn's deferred calls, so wait for it.
+	deadline := time.Now().Add(time.Second)
+	for len(crashDumps(t)) < 2 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+
+	dumps := strings.Join(crashDumps(t), "\n")
+	for _, want := range []string{"Where:    TestBinding", "assignment to entry in nil map", "Where:    test worker", "Panic:    worker failed", "goroutine "} {
+		if !strings.Contains(dumps, want) {
+			t.Errorf("crash dumps lack %q:\n%s", want, dumps)
+		}
+	}
+}
+
+func TestWriteCrashDumpKeepsEarlierDumps(t *testing.T) {
+	dir := t.TempDir()
+	var paths []string
+	for i := 0; i < 3; i++ {
+		path, err := writeCrashDump(dir, "test", fmt.Sprint("panic ", i), []byte("stack"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !isCrashDumpName(filepath.Base(path)) {
+			t.Errorf("dump name %q is not offered for submission", filepath.Base(path))
+		}
+		paths = append(paths, path)
+	}
+	for i, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil || !strings.Contains(string(data), fmt.Sprint("Panic:    panic ", i)) {
+			t.Errorf("dump %d = %q, %v", i, data, err)
+		}
+	}
+}
+
+func TestUploadToFPP(t *testing.T) {
+	dir := t.TempDir()
+	seq, music := filepath.Join(dir, "fixed.fseq"), filepath.Join(dir, "show.mp3")
+	for path, data := range map[string]string{seq: "FSEQ data", music: "ID3 audio"} {
+		if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name       string
+		audio      string
+		failOn     string // path answered with 500
+		wantPrefix string
+		want       []string
+	}{
+		{"sequence and audio", music, "", "OK", []string{
+			"GET /api/fppd/status",
+			"POST /api/file/sequences/fixed.fseq FSEQ data",
+			"POST /api/file/music/show.mp3 ID3 audio",
+		}},
+		{"sequence only", "", "", "OK", []string{
+			"GET /api/fppd/status",
+			"POST /api/file/sequences/fixed.fseq FSEQ data",
+		}},
+		{"FPP not ready", music, "/api/fppd/status", "Error", []string{
+			"GET /api/fppd/status",
+		}},
+		{"sequence rejected", music, "/api/file/sequences/fixed.fseq", "Error", []string{
+			"GET /api/fppd/status",
+			"POST /api/file/sequences/fixed.fseq FSEQ data",
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var mu sync.Mutex
+			var got []string
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := io.ReadAll(r.Body)
+				mu.Lock()
+				got = append(got, strings.TrimSpace(r.Method+" "+r.URL.Path+" "+string(body)))
+				mu.Unlock()
+				if r.URL.Path == tt.failOn {
+					w.WriteHeader(http.StatusInternalServerError)
+					return
+				}
+				if r.URL.Path == "/api/fppd/status" {
+					io.WriteString(w, `{"mode_name": "player", "status_name": "idle", "version": "7.5"}`)
+				}
+			}))
+			defer srv.Close()
+
+			a := &App{}
+			if msg := a.UploadToFPP(srv.URL, seq, tt.audio); !strings.HasPrefix(msg, tt.wantPrefix) {
+				t.Errorf("UploadToFPP() = %q, want %s", msg, tt.wantPrefix)
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("requests:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+
+	a := &App{}
+	if msg := a.UploadToFPP("127.0.0.1:1", filepath.Join(dir, "show.bin"), ""); !strings.HasPrefix(msg, "Error: Invalid sequence path") {
+		t.Errorf("UploadToFPP() of a non-FSEQ file = %q", msg)
+	}
+}
@@ -0,0 +1,233 @@
+package bingen
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// Automation block: keyframe lanes from "automation" tracks, which ramp a
+// group's brightness or effect speed over time without a run of discrete
+// events. Each lane is a target byte, a bank byte, a uint16 keyframe
+// count and the 28-byte prop mask, then count keyframes of a uint32
+// absolute time in ms and a uint8 value. Receivers interpolate linearly
+// between keyframes and apply a lane only from its first keyframe to its
+// last; where lanes of one target overlap on a prop, the later lane in the
+// block wins. Lanes are in start order, split across as many blocks as the
+// uint16 block length needs.
+const (
+	AutomationBlockMagic   = "AUT1"
+	AutomationBlockVersion = 1
+	AutomationLaneSize     = 4 + 4*MaskArraySize
+	AutomationPointSize    = 5
+)
+
+// MaxKeyframes is the most keyframes an automation clip keeps; later ones
+// are dropped.
+const MaxKeyframes = 4096
+
+// Automation targets, named by an automation clip's Type. Brightness
+// values are 0 (black) to 1 (the prop's cap), stored as value*255; speed
+// values multiply the playing effect's speed, 0 to 5.1 stored as value*50
+// (50 leaves it unchanged).
+const (
+	AutomateBrightness = iota
+	AutomateSpeed
+)
+
+var automationTargets = []struct {
+	name  string
+	scale float64
+}{
+	AutomateBrightness: {"brightness", 255},
+	AutomateSpeed:      {"speed", 50},
+}
+
+// ErrBadTarget is reported for an automation clip whose type is not an
+// automation target; the clip is left out.
+var ErrBadTarget = errors.New("unknown automation target")
+
+// Keyframe is one point of an automation clip, in ms from the clip start.
+type Keyframe struct {
+	TimeMs float64 `json:"timeMs"`
+	Value  float64 `json:"value"`
+}
+
+// AutomationPoint is a keyframe as stored in the automation block.
+type AutomationPoint struct {
+	TimeMs uint32 // from the show start
+	Value  uint8
+}
+
+// AutomationLane is one automation clip as stored in the automation block.
+type AutomationLane struct {
+	Target    uint8 // AutomateBrightness or AutomateSpeed
+	Bank      uint8
+	Mask      [MaskArraySize]uint32
+	Keyframes []AutomationPoint
+}
+
+// AutomationTargets lists the clip types an automation track accepts.
+func AutomationTargets() []string {
+	var out []string
+	for _, t := range automationTargets {
+		out = append(out, t.name)
+	}
+	return out
+}
+
+func automationTarget(name string) (uint8, bool) {
+	for i, t := range automationTargets {
+		if t.name == name {
+			return uint8(i), true
+		}
+	}
+	return 0, false
+}
+
+// automationLane compiles clip ci of track ti, already sanitized, into a
+// lane running exactly from the clip's start to its end: keyframes are
+// sorted, those outside the clip give way to values interpolated at its
+// edges, and values are clamped to the target's range. ok is false if the
+// clip has nothing to play.
+func automationLane(ti, ci int, clip Clip) (lane AutomationLane, errs []FieldError, ok bool) {
+	report := func(field string, v any, err error) {
+		errs = append(errs, FieldError{Track: ti, Clip: ci, Field: field, Value: v, Err: err})
+	}
+	target, known := automationTarget(clip.Type)
+	if !known {
+		report("type", clip.Type, ErrBadTarget)
+		return lane, errs, false
+	}
+	scale := automationTargets[target].scale
+	limit := 255 / scale
+
+	var keys []Keyframe
+	for i, k := range clip.Props.Keyframes {
+		field := fmt.Sprintf("keyframes[%d]", i)
+		switch {
+		case math.IsNaN(k.TimeMs) || math.IsInf(k.TimeMs, 0):
+			report(field+".timeMs", k.TimeMs, ErrNotFinite)
+			continue
+		case math.IsNaN(k.Value) || math.IsInf(k.Value, 0):
+			report(field+".value", k.Value, ErrNotFinite)
+			continue
+		case k.Value < 0 || k.Value > limit:
+			report(field+".value", k.Value, fmt.Errorf("outside 0 to %g", limit))
+			k.Value = math.Max(0, math.Min(k.Value, limit))
+		}
+		keys = append(keys, k)
+	}
+	if len(keys) == 0 {
+		report("keyframes", len(clip.Props.Keyframes), errors.New("no usable keyframes"))
+		return lane, errs, false
+	}
+	sort.SliceStable(keys, func(i, j int) bool { return keys[i].TimeMs < keys[j].TimeMs })
+
+	valueAt := func(t float64) float64 {
+		i := sort.Search(len(keys), func(i int) bool { return keys[i].TimeMs > t })
+		switch {
+		case i == 0:
+			return keys[0].Value
+		case i == len(keys):
+			return keys[i-1].Value
+		}
+		a, b := keys[i-1], keys[i]
+		return a.Value + (b.Value-a.Value)*(t-a.TimeMs)/(b.TimeMs-a.TimeMs)
+	}
+	points := []Keyframe{{0, valueAt(0)}}
+	for _, k := range keys {
+		if k.TimeMs > 0 && k.TimeMs < clip.Duration {
+			points = append(points, k)
+		}
+	}
+	points = append(points, Keyframe{clip.Duration, valueAt(clip.Duration)})
+	if len(points) > MaxKeyframes {
+		report("keyframes", len(points), fmt.Errorf("more than %d", MaxKeyframes))
+		points = points[:MaxKeyframes]
+	}
+
+	lane.Target = target
+	for _, k := range points {
+		lane.Keyframes = append(lane.Keyframes, AutomationPoint{
+			TimeMs: uint32(clip.StartTime + k.TimeMs),
+			Value:  uint8(math.Round(k.Value * scale)),
+		})
+	}
+	return lane, errs, true
+}
+
+// sortLanes orders lanes by start time, keeping track order for equal
+// times.
+func sortLanes(lanes []AutomationLane) {
+	sort.SliceStable(lanes, func(i, j int) bool { return lanes[i].Keyframes[0].TimeMs < lanes[j].Keyframes[0].TimeMs })
+}
+
+// writeAutomation writes lanes as automation blocks.
+func writeAutomation(buf *bytes.Buffer, lanes []AutomationLane) {
+	var payload bytes.Buffer
+	for _, lane := range lanes {
+		size := AutomationLaneSize + AutomationPointSize*len(lane.Keyframes)
+		if payload.Len()+size > math.MaxUint16 {
+			writeBlock(buf, AutomationBlockMagic, AutomationBlockVersion, payload.Bytes())
+			payload.Reset()
+		}
+		payload.Write([]byte{lane.Target, lane.Bank})
+		binary.Write(&payload, binary.LittleEndian, uint16(len(lane.Keyframes)))
+		binary.Write(&payload, binary.LittleEndian, lane.Mask)
+		for _, k := range lane.Keyframes {
+			binary.Write(&payload, binary.LittleEndian, k.TimeMs)
+			payload.WriteByte(k.Value)
+		}
+	}
+	if payload.Len() > 0 {
+		writeBlock(buf, AutomationBlockMagic, AutomationBlockVersion, payload.Bytes())
+	}
+}
+
+// decodeAutomation reads the lanes of one automation block payload.
+func decodeAutomation(b []byte) ([]AutomationLane, error) {
+	var lanes []AutomationLane
+	for len(b) > 0 {
+		if len(b) < AutomationLaneSize {
+			return nil, errors.New("truncated automation lane")
+		}
+		lane := AutomationLane{Target: b[0], Bank: b[1]}
+		n := int(binary.LittleEndian.Uint16(b[2:]))
+		for i := range lane.Mask {
+			lane.Mask[i] = binary.LittleEndian.Uint32(b[4+4*i:])
+		}
+		b = b[AutomationLaneSize:]
+		if len(b) < n*AutomationPointSize {
+			return nil, errors.New("truncated automation lane")
+		}
+		for i := 0; i < n; i++ {
+			k := b[i*AutomationPointSize:]
+			lane.Keyframes = append(lane.Keyframes, AutomationPoint{TimeMs: binary.LittleEndian.Uint32(k), Value: k[4]})
+		}
+		b = b[n*AutomationPointSize:]
+		lanes = append(lanes, lane)
+	}
+	return lanes, nil
+}
+
+// laneClip is the inverse of automationLane.
+func laneClip(lane AutomationLane) (Clip, bool) {
+	if int(lane.Target) >= len(automationTargets) || len(lane.Keyframes) == 0 {
+		return Clip{}, false
+	}
+	t := automationTargets[lane.Target]
+	start := lane.Keyframes[0].TimeMs
+	clip := Clip{
+		StartTime: float64(start),
+		Duration:  float64(lane.Keyframes[len(lane.Keyframes)-1].TimeMs - start),
+		Type:      t.name,
+	}
+	for _, k := range lane.Keyframes {
+		clip.Props.Keyframes = append(clip.Props.Keyframes, Keyframe{TimeMs: float64(k.TimeMs - start), Value: float64(k.Value) / t.scale})
+	}
+	return clip, clip.Duration > 0
+}
@@ -0,0 +1,96 @@
+package bingen_test
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"PicoLume/bingen"
+	"PicoLume/bingen/bintest"
+)
+
+func TestGenerateAutomationBlock(t *testing.T) {
+	var p bingen.Project
+	if err := json.Unmarshal([]byte(stemProject), &p); err != nil {
+		t.Fatal(err)
+	}
+	plain, err := bingen.Generate(&p)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Keyframes out of order and past the clip end, which is interpolated.
+	p.Tracks = append(p.Tracks, bingen.Track{Type: "automation", GroupId: "left", Clips: []bingen.Clip{
+		{StartTime: 500, Duration: 1000, Type: "brightness", Props: bingen.ClipProps{Keyframes: []bingen.Keyframe{
+			{TimeMs: 2000, Value: 0}, {TimeMs: 0, Value: 1},
+		}}},
+	}})
+	result, err := bingen.Generate(&p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Warnings) != 0 {
+		t.Errorf("warnings = %v", result.Warnings)
+	}
+	if result.EventCount != plain.EventCount {
+		t.Errorf("automation added %d events", result.EventCount-plain.EventCount)
+	}
+	block := result.Bytes[len(plain.Bytes):]
+	want := "AUT1\x01\x00\x2a\x00" + "\x00\x00\x02\x00" + "\xff\xff\x0f\x00" + strings.Repeat("\x00", 24) +
+		"\xf4\x01\x00\x00\xff" + "\xdc\x05\x00\x00\x80"
+	if string(block) != want {
+		t.Errorf("automation block = % x, want % x", block, want)
+	}
+	if name, _, _ := bintest.Field(result.Bytes, len(plain.Bytes)); name != "AUT1.magic" {
+		t.Errorf("Field() = %q", name)
+	}
+
+	v2, err := bingen.GenerateContext(context.Background(), &p, bingen.Options{FormatVersion: bingen.FormatV2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(v2.Bytes), "AUT1") {
+		t.Error("V2 show.bin has an automation block")
+	}
+}
+
+func TestGenerateAutomationWarnings(t *testing.T) {
+	var p bingen.Project
+	if err := json.Unmarshal([]byte(stemProject), &p); err != nil {
+		t.Fatal(err)
+	}
+	p.Tracks = append(p.Tracks, bingen.Track{Type: "automation", GroupId: "all", Clips: []bingen.Clip{
+		{StartTime: 0, Duration: 1000, Type: "hue"},
+		{StartTime: 0, Duration: 1000, Type: "speed"},
+		{StartTime: 0, Duration: 1000, Type: "speed", Props: bingen.ClipProps{Keyframes: []bingen.Keyframe{{TimeMs: 500, Value: 9}}}},
+	}})
+	result, err := bingen.Generate(&p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fields []string
+	for _, w := range result.Warnings {
+		fields = append(fields, w.Field)
+		if w.Track != 2 {
+			t.Errorf("warning %v on track %d, want 2", w, w.Track)
+		}
+	}
+	if want := []string{"type", "keyframes", "keyframes[0].value"}; !reflect.DeepEqual(fields, want) {
+		t.Errorf("warnings on %v, want %v", fields, want)
+	}
+
+	_, info, err := bingen.Parse(result.Bytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []bingen.AutomationPoint{{TimeMs: 0, Value: 255}, {TimeMs: 500, Value: 255}, {TimeMs: 1000, Value: 255}}
+	if len(info.Automation) != 1 || !reflect.DeepEqual(info.Automation[0].Keyframes, want) {
+		t.Errorf("lanes = %+v, want one clamped to speed 5.1", info.Automation)
+	}
+
+	if _, err := bingen.GenerateContext(context.Background(), &p, bingen.Options{Strict: true}); err == nil {
+		t.Error("strict mode accepted bad automation clips")
+	}
+}
@@ -4,9 +4,16 @@ package bingen
 
 import (
 	"bytes"
+	"context"
+	"crypto/sha256"
 	"encoding/binary"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"hash/crc32"
+	"io"
+	"math"
+	"sort"
 	"strconv"
 	"strings"
 )
@@ -16,6 +23,64 @@ const (
 	MaskArraySize = 7
 )
 
+// Encoded sizes in bytes.
+const (
+	HeaderSize     = 16
+	PropConfigSize = 8
+	EventSize      = 20 + 4*MaskArraySize
+	CueBlockSize   = 8 + 4*4 + 8
+)
+
+// Format versions written into the show.bin header and CUE1 trailer.
+// Cue blocks are version CueBlockV2 when they name a blackout cue.
+const (
+	FormatVersion   = 3
+	CueBlockVersion = 1
+	CueBlockV2      = 2
+)
+
+// BlackoutCueID is the cue reserved by Options.BlackoutCue: triggering it
+// (the serial "cue Z" command) blacks out every prop at once, however the
+// show was authored.
+const BlackoutCueID = "Z"
+
+// Show formats Generate can emit. V2 predates the PropConfig LUT: events
+// follow the header directly and receivers use their built-in LED setup.
+// V4 stores each event's prop mask compactly (see MaskList); V5 adds clip
+// fades (see FadeEventHeaderSize) and gradients (see GradientBlockMagic).
+// Both are opt-in until the fleet's firmware reads them.
+const (
+	FormatV2 = 2
+	FormatV3 = 3
+	FormatV4 = 4
+	FormatV5 = 5
+)
+
+// LED chipset values for HardwareProfile.LedType / PropConfig.LedType.
+// These map directly to the firmware enum.
+const (
+	LedTypeWS2812B = iota
+	LedTypeSK6812
+	LedTypeSK6812RGBW
+	LedTypeWS2811
+	LedTypeWS2813
+	LedTypeWS2815
+)
+
+// Color order values for HardwareProfile.ColorOrder / PropConfig.ColorOrder.
+// These map directly to the firmware enum.
+const (
+	ColorOrderGRB = iota
+	ColorOrderRGB
+	ColorOrderBRG
+	ColorOrderRBG
+	ColorOrderGBR
+	ColorOrderBGR
+)
+
+// ColorOrderNames is indexed by the ColorOrder enum.
+var ColorOrderNames = []string{"GRB", "RGB", "BRG", "RBG", "GBR", "BGR"}
+
 // Project represents the show project data structure.
 type Project struct {
 	Settings   Settings    `json:"settings"`
@@ -24,6 +89,65 @@ type Project struct {
 	Cues       []Cue       `json:"cues"`
 }
 
+// FindGroup returns the prop group with the given ID, or nil if none exists.
+func (p *Project) FindGroup(id string) *PropGroup {
+	for i := range p.PropGroups {
+		if p.PropGroups[i].ID == id {
+			return &p.PropGroups[i]
+		}
+	}
+	return nil
+}
+
+// PropProfiles maps each prop ID with a hardware profile to that profile:
+// the profile's AssignedIds, overridden by the Patch.
+func (p *Project) PropProfiles() map[int]*HardwareProfile {
+	profileMap := make(map[string]*HardwareProfile)
+	for i := range p.Settings.Profiles {
+		prof := &p.Settings.Profiles[i]
+		profileMap[prof.ID] = prof
+	}
+
+	propAssignment := make(map[int]*HardwareProfile)
+
+	// Apply profile's AssignedIds
+	for i := range p.Settings.Profiles {
+		prof := &p.Settings.Profiles[i]
+		if prof.AssignedIds != "" {
+			for _, propID := range ParseIDRange(prof.AssignedIds) {
+				propAssignment[propID] = prof
+			}
+		}
+	}
+
+	// Apply Patch overrides. Keys such as "5" and "05" name the same prop,
+	// so apply them in a fixed order: other spellings by key, then the
+	// plain decimal one, which wins.
+	keys := make([]string, 0, len(p.Settings.Patch))
+	for k := range p.Settings.Patch {
+		keys = append(keys, k)
+	}
+	plain := func(k string) bool {
+		id, err := strconv.Atoi(k)
+		return err == nil && strconv.Itoa(id) == k
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if pi, pj := plain(keys[i]), plain(keys[j]); pi != pj {
+			return pj
+		}
+		return keys[i] < keys[j]
+	})
+	for _, propIDStr := range keys {
+		propID, err := strconv.Atoi(propIDStr)
+		if err == nil && propID >= 1 && propID <= TotalProps {
+			if prof, found := profileMap[p.Settings.Patch[propIDStr]]; found {
+				propAssignment[propID] = prof
+			}
+		}
+	}
+	return propAssignment
+}
+
 // Cue represents a cue point for live resync.
 type Cue struct {
 	ID      string `json:"id"`      // "A", "B", "C", "D"
@@ -38,6 +162,9 @@ type Settings struct {
 	ShowDuration float64           `json:"showDuration"` // Total show length in ms
 	Profiles     []HardwareProfile `json:"profiles"`
 	Patch        map[string]string `json:"patch"`
+	Schedule     *Schedule         `json:"schedule,omitempty"` // automatic start, if any
+	Standby      *Standby          `json:"standby,omitempty"`  // look outside the show, if any
+	Loop         *Loop             `json:"loop,omitempty"`     // repeated region, if any
 }
 
 // HardwareProfile defines LED hardware configuration.
@@ -49,20 +176,38 @@ type HardwareProfile struct {
 	LedType       int    `json:"ledType"`       // 0=WS2812B, 1=SK6812, etc.
 	ColorOrder    int    `json:"colorOrder"`    // 0=GRB, 1=RGB, etc.
 	BrightnessCap int    `json:"brightnessCap"` // 0-255
+	Audio         bool   `json:"audio"`         // plays the show audio locally
+	Zone          int    `json:"zone"`          // radio zone, 0-MaxZone
+
+	// Voltage and PowerBudget (watts per prop, 0 for none) let
+	// Options.LimitPower dim clips a prop's battery or supply cannot feed.
+	Voltage     float64 `json:"voltage"`
+	PowerBudget float64 `json:"powerBudget"`
+
+	// ThermalCap (1-254, 0 for none) is the brightness an enclosed prop
+	// can sustain; above it for ThermalAfter seconds, the receiver derates.
+	ThermalCap   int `json:"thermalCap"`
+	ThermalAfter int `json:"thermalAfter"`
 }
 
-// PropGroup defines a group of prop IDs.
+// PropGroup defines a group of prop IDs. IDs are 1..TotalProps within the
+// group's Bank, so prop 5 of bank 1 is receiver 229 of the rig.
 type PropGroup struct {
 	ID   string `json:"id"`
 	Name string `json:"name"`
 	IDs  string `json:"ids"`
+	Bank int    `json:"bank,omitempty"` // 0-MaxBank
 }
 
 // Track represents a timeline track.
 type Track struct {
-	Type    string `json:"type"`
+	Type    string `json:"type"` // "led", "audio" or "automation"
 	GroupId string `json:"groupId"`
 	Clips   []Clip `json:"clips"`
+
+	// Priority ranks LED tracks under ConflictPriority: where two play
+	// on the same props at once, the higher one wins.
+	Priority int `json:"priority,omitempty"`
 }
 
 // Clip represents an effect clip on a track.
@@ -71,17 +216,94 @@ type Clip struct {
 	Duration  float64   `json:"duration"`
 	Type      string    `json:"type"`
 	Props     ClipProps `json:"props"`
+	BufferId  string    `json:"bufferId,omitempty"` // audio clips only
 }
 
 // ClipProps holds effect-specific properties.
 type ClipProps struct {
-	Color      string  `json:"color"`
-	Color2     string  `json:"color2"`
-	ColorA     string  `json:"colorA"`
-	ColorB     string  `json:"colorB"`
-	ColorStart string  `json:"colorStart"`
-	Speed      float64 `json:"speed"`
-	Width      float64 `json:"width"`
+	Color      string   `json:"color"`
+	Color2     string   `json:"color2"`
+	ColorA     string   `json:"colorA"`
+	ColorB     string   `json:"colorB"`
+	ColorStart string   `json:"colorStart"`
+	Speed      float64  `json:"speed"`
+	Width      float64  `json:"width"`
+	Volume     *float64 `json:"volume,omitempty"` // audio clips; nil plays at full volume
+
+	// ColorStops, when there are two or more, make a chase, wipe or
+	// scanner run across a gradient instead of Color. Formats before V5
+	// play the first and last stop as color and color2.
+	ColorStops []ColorStop `json:"colorStops,omitempty"`
+
+	// Keyframes are the points of an automation clip, whose Type names
+	// the target (see AutomationTargets).
+	Keyframes []Keyframe `json:"keyframes,omitempty"`
+
+	// Fades ramp brightness up from black at the start of the clip and
+	// down to black at its end, in ms along the named easing curve (see
+	// Easings; "" is linear). Formats before V5 play them as hard cuts.
+	FadeIn  float64 `json:"fadeIn,omitempty"`
+	FadeOut float64 `json:"fadeOut,omitempty"`
+	Easing  string  `json:"easing,omitempty"`
+
+	// Script clips only; see ScriptEffect.
+	Script string  `json:"script,omitempty"`
+	Step   float64 `json:"step,omitempty"`   // ms between keyframes
+	Effect string  `json:"effect,omitempty"` // effect each keyframe plays
+
+	// Params holds the settings of clip types added with RegisterClipType.
+	Params map[string]any `json:"params,omitempty"`
+}
+
+// ColorHex resolves the primary and secondary colors of a clip, applying the
+// same fallbacks the firmware encoding uses (a gradient's end stops,
+// colorStart, alternate's colorA/colorB, white primary, black secondary).
+func (c Clip) ColorHex() (string, string) {
+	if stops := clipGradient(c); stops != nil {
+		return hexColor(stops[0].Color), hexColor(stops[len(stops)-1].Color)
+	}
+
+	colorHex := c.Props.Color
+	if colorHex == "" {
+		colorHex = c.Props.ColorStart
+	}
+	if colorHex == "" {
+		colorHex = "#FFFFFF"
+	}
+
+	color2Hex := c.Props.Color2
+	if color2Hex == "" && c.Type == "alternate" {
+		color2Hex = c.Props.ColorB
+		if c.Props.ColorA != "" {
+			colorHex = c.Props.ColorA
+		}
+	}
+	if color2Hex == "" {
+		color2Hex = "#000000"
+	}
+	return colorHex, color2Hex
+}
+
+// clipEvent encodes the effect and parameters of clip; the caller sets the
+// timing and mask.
+func clipEvent(clip Clip) Event {
+	colorHex, color2Hex := clip.ColorHex()
+
+	speedVal := clip.Props.Speed
+	if speedVal <= 0 {
+		speedVal = 1.0
+	}
+	fadeIn, fadeOut := clipFades(clip)
+	return Event{
+		Effect:  getEffectCode(clip.Type),
+		Speed:   uint8(min(255, int(speedVal*50))),
+		Width:   uint8(clip.Props.Width * 255),
+		Color:   ParseColor(colorHex),
+		Color2:  ParseColor(color2Hex),
+		FadeIn:  fadeIn,
+		FadeOut: fadeOut,
+		Easing:  easingCode(clip.Props.Easing),
+	}
 }
 
 // PropConfig represents per-prop configuration in show.bin (8 bytes).
@@ -90,13 +312,38 @@ type PropConfig struct {
 	LedType       uint8
 	ColorOrder    uint8
 	BrightnessCap uint8
-	Reserved      [3]uint8
+
+	// Thermal derating: after DerateAfter seconds above SustainedCap the
+	// receiver dims the prop to SustainedCap until it has cooled for as
+	// long. SustainedCap 0 disables it.
+	SustainedCap uint8
+	DerateAfter  uint16 // seconds
 }
 
 // Result contains the generated binary and metadata.
 type Result struct {
-	Bytes      []byte
+	Bytes      []byte // nil from GenerateTo
+	Size       int64  // bytes of show.bin
 	EventCount int
+	Version    int // format version written; see Options.TargetVersion
+
+	// Warnings lists the values that were clamped or skipped to produce a
+	// valid file. Strict mode returns them as a *ValidationError instead.
+	Warnings []FieldError
+
+	// PowerLimits lists the clips dimmed (or too bright to dim) by
+	// Options.LimitPower.
+	PowerLimits []PowerLimit
+
+	// Conflicts lists the LED tracks playing on the same props at the
+	// same time, settled by Options.Conflicts.
+	Conflicts []TrackConflict
+
+	// Optimized compares the sizes with and without Options.Optimize;
+	// nil without it.
+	Optimized *OptimizeReport
+
+	events []Event // in file order, if Options.keepEvents
 }
 
 // GenerateFromJSON generates show.bin bytes from project JSON string.
@@ -108,54 +355,214 @@ func GenerateFromJSON(projectJSON string) (*Result, error) {
 	return Generate(&p)
 }
 
-// Generate creates show.bin bytes from a Project struct.
-func Generate(p *Project) (*Result, error) {
-	// --- 1. PREPARE PROFILES ---
-	profileMap := make(map[string]*HardwareProfile)
-	if p.Settings.Profiles != nil {
-		for i := range p.Settings.Profiles {
-			prof := &p.Settings.Profiles[i]
-			profileMap[prof.ID] = prof
+// Hash identifies the show.bin Generate writes for p: the hex SHA-256 of
+// its bytes. Output depends only on the project, so the same project
+// always hashes the same, and a show.bin copied off a receiver matches the
+// editor's project when HashBytes of it equals Hash. Files written with
+// other Options hash as GenerateContext's bytes do, through HashBytes.
+func Hash(p *Project) (string, error) {
+	result, err := Generate(p)
+	if err != nil {
+		return "", err
+	}
+	return HashBytes(result.Bytes), nil
+}
+
+// HashBytes is the hex SHA-256 of a show.bin image, as Hash reports it.
+func HashBytes(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// Options controls GenerateContext.
+type Options struct {
+	// FormatVersion selects the layout (FormatV2 to FormatV5); 0 means
+	// the current FormatVersion.
+	FormatVersion int
+
+	// TargetVersion, if set, is the newest format the receiver reads, as
+	// its firmware reports it. A newer FormatVersion is lowered to it, so
+	// the receiver is never sent a show it cannot play; Result.Version is
+	// the version written.
+	TargetVersion int
+
+	// Overlap decides what happens when clips on one track overlap; the
+	// default OverlapAllow writes both events as before.
+	Overlap OverlapPolicy
+
+	// Conflicts decides what happens when LED tracks play on the same
+	// props at the same time; the default ConflictAllow writes both.
+	Conflicts ConflictPolicy
+
+	// Strict rejects projects with invalid times or durations with a
+	// *ValidationError, instead of clamping them and reporting Warnings.
+	Strict bool
+
+	// Props, if set, is a prop ID list such as PropGroup.IDs, in bank
+	// Bank. Events only address these props and tracks for other props are
+	// left out, so each group's props can get their own show.bin.
+	Props string
+	Bank  int
+
+	// BlackoutCue reserves cue BlackoutCueID as an instant blackout. The
+	// cue block is then always written, as version CueBlockV2.
+	BlackoutCue bool
+
+	// LimitPower dims the colors of clips that would draw more than a
+	// prop's HardwareProfile.PowerBudget; Result.PowerLimits lists them.
+	LimitPower bool
+
+	// Checksum appends the CRC1 footer, for firmware that verifies the
+	// file before playing it.
+	Checksum bool
+
+	// Optimize drops zero-duration events and merges neighbouring
+	// identical off and solid events, including the off gaps of tracks
+	// on the same props; the show plays the same. Result.Optimized
+	// reports the savings.
+	Optimize bool
+
+	// Progress, if set, is called as LED tracks are encoded.
+	Progress Progress
+
+	keepEvents bool // for reports that need the encoded events
+}
+
+// version returns the format version opts select.
+func (opts Options) version() (int, error) {
+	version := opts.FormatVersion
+	if version == 0 {
+		version = FormatVersion
+	}
+	if version < FormatV2 || version > FormatV5 {
+		return 0, fmt.Errorf("unsupported show format version %d (use %d to %d)", version, FormatV2, FormatV5)
+	}
+	if opts.TargetVersion != 0 {
+		if opts.TargetVersion < FormatV2 {
+			return 0, fmt.Errorf("receiver reads show format %d, older than the oldest supported (%d)", opts.TargetVersion, FormatV2)
 		}
+		version = min(version, opts.TargetVersion)
 	}
+	return version, nil
+}
 
-	// --- 2. BUILD PROP-TO-PROFILE MAPPING ---
-	propAssignment := make(map[int]*HardwareProfile)
+// OverlapPolicy resolves clips that overlap within one track. Overlapping
+// events target the same props at the same time, and which one a receiver
+// shows is undefined.
+type OverlapPolicy string
 
-	// Apply profile's AssignedIds
-	for i := range p.Settings.Profiles {
-		prof := &p.Settings.Profiles[i]
-		if prof.AssignedIds != "" {
-			for _, propID := range parseIDRange(prof.AssignedIds) {
-				propAssignment[propID] = prof
+const (
+	// OverlapAllow writes overlapping clips unchanged (legacy behavior).
+	OverlapAllow OverlapPolicy = ""
+	// OverlapError fails generation on the first overlap.
+	OverlapError OverlapPolicy = "error"
+	// OverlapTrim shortens the earlier clip to end where the later starts.
+	OverlapTrim OverlapPolicy = "trim"
+	// OverlapPriority lets clips later in the track's clip list win; an
+	// earlier clip is split around them and resumes afterwards.
+	OverlapPriority OverlapPolicy = "priority"
+)
+
+// MaxSize returns an upper bound on the show.bin size for p (a gap event
+// before every clip plus a final one per LED track, each in the largest
+// layout and with a bank table entry, and an automation lane and block per
+// automation clip), so callers can check resources before generating.
+// EstimateSize gives the expected size instead.
+func MaxSize(p *Project) int64 {
+	events, automation := int64(0), int64(0)
+	for _, track := range p.Tracks {
+		switch track.Type {
+		case "led":
+			events += 2*int64(len(track.Clips)) + 1
+		case "automation":
+			for _, clip := range track.Clips {
+				points := min(len(clip.Props.Keyframes)+2, MaxKeyframes)
+				automation += BlockHeaderSize + AutomationLaneSize + int64(points)*AutomationPointSize
 			}
 		}
 	}
+	return HeaderSize + TotalProps*PropConfigSize + events*(FadeEventHeaderSize+4*MaskArraySize+1) + BlockHeaderSize + ZoneBlockSize + ScheduleBlockSize + StandbyBlockSize + LoopBlockSize + automation + CueBlockSize
+}
 
-	// Apply Patch overrides
-	if p.Settings.Patch != nil {
-		for propIDStr, profileID := range p.Settings.Patch {
-			propID, err := strconv.Atoi(propIDStr)
-			if err == nil && propID >= 1 && propID <= TotalProps {
-				if prof, found := profileMap[profileID]; found {
-					propAssignment[propID] = prof
-				}
-			}
+// Generate creates show.bin bytes from a Project struct.
+func Generate(p *Project) (*Result, error) {
+	return GenerateContext(context.Background(), p, Options{})
+}
+
+// Progress is called as LED tracks are encoded.
+type Progress func(done, total int)
+
+// GenerateContext is Generate with options, cancellation and progress
+// reporting, for projects large enough that generation takes noticeable
+// time. It returns ctx.Err() if cancelled.
+func GenerateContext(ctx context.Context, p *Project, opts Options) (*Result, error) {
+	var buf bytes.Buffer
+	result, err := GenerateToContext(ctx, &buf, p, opts)
+	if err != nil {
+		return nil, err
+	}
+	result.Bytes = buf.Bytes()
+	return result, nil
+}
+
+// GenerateTo writes show.bin for p to w as it is encoded, instead of
+// building it in memory: only the events and one chunk of output are held
+// at a time, so exports of very large shows and the WASM build stay within
+// bounded memory. The Result has no Bytes; Size is the number written.
+func GenerateTo(w io.Writer, p *Project) (*Result, error) {
+	return GenerateToContext(context.Background(), w, p, Options{})
+}
+
+// GenerateToContext is GenerateTo with options, cancellation and progress
+// reporting. Nothing is written to w until the project has been checked,
+// so an invalid project or a cancelled run leaves w untouched; a failed
+// write leaves a partial file.
+func GenerateToContext(ctx context.Context, w io.Writer, p *Project, opts Options) (*Result, error) {
+	version, err := opts.version()
+	if err != nil {
+		return nil, err
+	}
+	if opts.Bank < 0 || opts.Bank > MaxBank {
+		return nil, fmt.Errorf("prop bank %d outside 0 to %d", opts.Bank, MaxBank)
+	}
+	progress := opts.Progress
+	switch opts.Overlap {
+	case OverlapAllow, OverlapError, OverlapTrim, OverlapPriority:
+	default:
+		return nil, fmt.Errorf("unknown overlap policy %q", opts.Overlap)
+	}
+	switch opts.Conflicts {
+	case ConflictAllow, ConflictLastTrack, ConflictPriority, ConflictError:
+	default:
+		return nil, fmt.Errorf("unknown track conflict policy %q", opts.Conflicts)
+	}
+
+	var propFilter [MaskArraySize]uint32
+	if opts.Props != "" {
+		propFilter = calculateMask(opts.Props)
+		if isMaskEmpty(propFilter) {
+			return nil, fmt.Errorf("no valid prop IDs in %q", opts.Props)
 		}
 	}
 
+	// --- 1/2. BUILD PROP-TO-PROFILE MAPPING ---
+	propAssignment := p.PropProfiles()
+
 	// --- 3. GENERATE LOOK-UP TABLE (LUT) ---
 	const defaultLedCount = 164
 	const defaultBrightness = 255
 
 	lutBuf := new(bytes.Buffer)
+	var zones [TotalProps]byte
+	var zoned bool
+	var warnings []FieldError
+	checked := make(map[*HardwareProfile]bool)
 	for i := 1; i <= TotalProps; i++ {
 		config := PropConfig{
 			LedCount:      defaultLedCount,
 			LedType:       0,
 			ColorOrder:    0,
 			BrightnessCap: defaultBrightness,
-			Reserved:      [3]uint8{0, 0, 0},
 		}
 
 		if prof, found := propAssignment[i]; found {
@@ -163,95 +570,145 @@ func Generate(p *Project) (*Result, error) {
 			config.LedType = uint8(prof.LedType)
 			config.ColorOrder = uint8(prof.ColorOrder)
 			config.BrightnessCap = uint8(prof.BrightnessCap)
+
+			if !checked[prof] {
+				checked[prof] = true
+				warnings = append(warnings, checkProfile(prof)...)
+			}
+			if prof.Zone > 0 && prof.Zone <= MaxZone {
+				zones[i-1] = byte(prof.Zone)
+				zoned = true
+			}
+			if prof.ThermalCap > 0 && prof.ThermalCap < 255 && prof.ThermalAfter > 0 && prof.ThermalAfter <= math.MaxUint16 {
+				config.SustainedCap = uint8(prof.ThermalCap)
+				config.DerateAfter = uint16(prof.ThermalAfter)
+			}
 		}
 
 		binary.Write(lutBuf, binary.LittleEndian, config.LedCount)
 		binary.Write(lutBuf, binary.LittleEndian, config.LedType)
 		binary.Write(lutBuf, binary.LittleEndian, config.ColorOrder)
 		binary.Write(lutBuf, binary.LittleEndian, config.BrightnessCap)
-		binary.Write(lutBuf, binary.LittleEndian, config.Reserved)
+		binary.Write(lutBuf, binary.LittleEndian, config.SustainedCap)
+		binary.Write(lutBuf, binary.LittleEndian, config.DerateAfter)
 	}
 
 	// --- 4. GENERATE EVENTS ---
-	eventBuf := new(bytes.Buffer)
-	eventCount := 0
+	var events []Event
+	var owners []int // the track of each event
+	var lanes []AutomationLane
+	var gradients gradientTable
 
 	showDuration := p.Settings.ShowDuration
-	if showDuration <= 0 {
+	if err := checkTime(showDuration); err != nil && err != ErrNegative {
+		warnings = append(warnings, FieldError{Track: -1, Clip: -1, Field: "showDuration", Value: showDuration, Err: err})
+		showDuration = MaxTimeMs
+	}
+	if showDuration <= 0 || math.IsNaN(showDuration) {
 		showDuration = 60000
 	}
 
-	for _, track := range p.Tracks {
-		if track.Type != "led" {
+	for ti, track := range p.Tracks {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if progress != nil {
+			progress(ti, len(p.Tracks))
+		}
+		if track.Type != "led" && (track.Type != "automation" || version < FormatV3) {
 			continue
 		}
 
 		var groupIds string
-		for _, g := range p.PropGroups {
-			if g.ID == track.GroupId {
-				groupIds = g.IDs
-				break
-			}
+		var bank int
+		if g := p.FindGroup(track.GroupId); g != nil {
+			groupIds, bank = g.IDs, g.Bank
+		}
+		if bank < 0 || bank > MaxBank {
+			warnings = append(warnings, FieldError{Track: ti, Clip: -1, Field: "group " + track.GroupId + " bank", Value: bank, Err: fmt.Errorf("outside 0 to %d", MaxBank)})
+			continue
+		}
+		if opts.Props != "" && bank != opts.Bank {
+			continue
 		}
 
 		mask := calculateMask(groupIds)
+		if opts.Props != "" {
+			for i := range mask {
+				mask[i] &= propFilter[i]
+			}
+		}
 		if isMaskEmpty(mask) {
 			continue
 		}
 
+		if track.Type == "automation" {
+			for ci, clip := range track.Clips {
+				clips, errs := sanitizeClips(ti, []Clip{clip})
+				if len(clips) == 1 {
+					lane, laneErrs, ok := automationLane(ti, ci, clips[0])
+					errs = append(errs, laneErrs...)
+					if ok {
+						lane.Mask, lane.Bank = mask, uint8(bank)
+						lanes = append(lanes, lane)
+					}
+				}
+				for i := range errs {
+					errs[i].Clip = ci
+				}
+				warnings = append(warnings, errs...)
+			}
+			continue
+		}
+
+		clips, errs := sanitizeClips(ti, track.Clips)
+		warnings = append(warnings, errs...)
+		if opts.Strict && len(errs) > 0 {
+			// Keep collecting so the user sees every bad value at once.
+			continue
+		}
+
 		// Sort clips by start time
-		clips := make([]Clip, len(track.Clips))
-		copy(clips, track.Clips)
-		sortClips(clips)
+		clips, err := resolveOverlaps(clips, opts.Overlap)
+		if err != nil {
+			return nil, fmt.Errorf("track %d: %w", ti+1, err)
+		}
 
 		var lastEndTime float64 = 0
 
-		for _, clip := range clips {
+		for ci, clip := range clips {
+			// Huge imported tracks can hold many thousands of clips.
+			if ci%4096 == 4095 {
+				if err := ctx.Err(); err != nil {
+					return nil, err
+				}
+			}
+
 			// Gap detection
 			if clip.StartTime > lastEndTime {
 				gapDuration := clip.StartTime - lastEndTime
 				if gapDuration > 0 {
-					eventCount++
-					writeEvent(eventBuf, uint32(lastEndTime), uint32(gapDuration), 0, 0, 0, 0, 0, mask)
+					events = append(events, offEvent(lastEndTime, gapDuration, uint8(bank), mask))
 				}
 			}
 
-			// Write clip event
-			eventCount++
-			colorHex := clip.Props.Color
-			if colorHex == "" {
-				colorHex = clip.Props.ColorStart
-			}
-			if colorHex == "" {
-				colorHex = "#FFFFFF"
-			}
-
-			color2Hex := clip.Props.Color2
-			if color2Hex == "" && clip.Type == "alternate" {
-				color2Hex = clip.Props.ColorB
-				if clip.Props.ColorA != "" {
-					colorHex = clip.Props.ColorA
+			var gradient uint8
+			if stops := clipGradient(clip); stops != nil && version >= FormatV5 {
+				if gradient = gradients.add(stops); gradient == 0 {
+					warnings = append(warnings, FieldError{Track: ti, Clip: -1, Field: fmt.Sprintf("track %d colorStops", ti+1), Value: clip.StartTime, Err: fmt.Errorf("more than %d gradients in the show", MaxGradients)})
 				}
 			}
-			if color2Hex == "" {
-				color2Hex = "#000000"
-			}
 
-			speedVal := clip.Props.Speed
-			if speedVal <= 0 {
-				speedVal = 1.0
+			// Write clip events
+			for _, e := range clipEvents(clip) {
+				e.Mask, e.Bank, e.Gradient = mask, uint8(bank), gradient
+				if opts.LimitPower {
+					if scale, _, _ := powerScale(e, maskIDs(mask), propAssignment); scale < 1 {
+						e.Color, e.Color2 = scaleColor(e.Color, scale), scaleColor(e.Color2, scale)
+					}
+				}
+				events = append(events, e)
 			}
-			speedByte := uint8(min(255, int(speedVal*50)))
-			widthByte := uint8(clip.Props.Width * 255)
-
-			writeEvent(eventBuf,
-				uint32(clip.StartTime),
-				uint32(clip.Duration),
-				getEffectCode(clip.Type),
-				speedByte, widthByte,
-				parseColor(colorHex),
-				parseColor(color2Hex),
-				mask)
 
 			clipEnd := clip.StartTime + clip.Duration
 			if clipEnd > lastEndTime {
@@ -263,61 +720,187 @@ func Generate(p *Project) (*Result, error) {
 		if lastEndTime < showDuration {
 			finalGap := showDuration - lastEndTime
 			if finalGap > 0 {
-				eventCount++
-				writeEvent(eventBuf, uint32(lastEndTime), uint32(finalGap), 0, 0, 0, 0, 0, mask)
+				events = append(events, offEvent(lastEndTime, finalGap, uint8(bank), mask))
 			}
 		}
+		for len(owners) < len(events) {
+			owners = append(owners, ti)
+		}
+	}
+
+	if progress != nil {
+		progress(len(p.Tracks), len(p.Tracks))
+	}
+	events, conflicts, err := resolveConflicts(events, owners, p.Tracks, opts.Conflicts)
+	if err != nil {
+		return nil, err
+	}
+	events = sortEvents(events)
+	sortLanes(lanes)
+
+	var optimized *OptimizeReport
+	var saved int64
+	if opts.Optimize {
+		before := len(events)
+		saved = eventsSize(events, version)
+		events = optimizeEvents(events)
+		saved -= eventsSize(events, version)
+		optimized = &OptimizeReport{EventsBefore: before, EventsAfter: len(events)}
+	}
+
+	cueTimes := make(map[string]uint32)
+	for _, cue := range p.Cues {
+		if !cue.Enabled || cue.TimeMs == nil {
+			continue
+		}
+		if _, seen := cueTimes[cue.ID]; seen {
+			continue
+		}
+		if err := checkTime(float64(*cue.TimeMs)); err != nil {
+			warnings = append(warnings, FieldError{Track: -1, Clip: -1, Field: "cue " + cue.ID + " timeMs", Value: float64(*cue.TimeMs), Err: err})
+			continue
+		}
+		cueTimes[cue.ID] = uint32(*cue.TimeMs)
+	}
+
+	var schedule []byte
+	if sch := p.Settings.Schedule; sch != nil && version >= FormatV3 {
+		var err error
+		if schedule, err = sch.encode(); err != nil {
+			warnings = append(warnings, FieldError{Track: -1, Clip: -1, Field: "schedule", Value: sch.Start, Err: err})
+		}
+	}
+
+	var standby []byte
+	if sb := p.Settings.Standby; sb != nil && sb.Type != "" && version >= FormatV3 {
+		var errs []FieldError
+		standby, errs = sb.encode()
+		warnings = append(warnings, errs...)
+	}
+
+	var loop []byte
+	if l := p.Settings.Loop; l != nil && version >= FormatV3 {
+		var errs []FieldError
+		loop, errs = l.encode(showEnd(events))
+		warnings = append(warnings, errs...)
+	}
+
+	if opts.Strict && len(warnings) > 0 {
+		return nil, &ValidationError{Errors: warnings}
+	}
+
+	banks := eventBanks(events)
+	if banks != nil && version < FormatV3 {
+		return nil, fmt.Errorf("prop banks need show format %d or later", FormatV3)
 	}
 
 	// --- 5. WRITE HEADER ---
+	// Sections are encoded into buf and streamed out as it fills.
 	buf := new(bytes.Buffer)
+	out := &showWriter{w: w, crc: crc32.NewIEEE()}
 	binary.Write(buf, binary.LittleEndian, uint32(0x5049434F)) // Magic "PICO"
-	binary.Write(buf, binary.LittleEndian, uint16(3))          // Version 3
-	binary.Write(buf, binary.LittleEndian, uint16(eventCount))
+	binary.Write(buf, binary.LittleEndian, uint16(version))
+	binary.Write(buf, binary.LittleEndian, uint16(len(events)))
 	buf.Write([]byte{0, 0, 0, 0, 0, 0, 0, 0}) // reserved[8]
 
-	// Write LUT and events
-	buf.Write(lutBuf.Bytes())
-	buf.Write(eventBuf.Bytes())
-
-	// --- 6. APPEND CUE BLOCK (if cues exist) ---
-	hasCues := false
-	for _, cue := range p.Cues {
-		if cue.Enabled && cue.TimeMs != nil {
-			hasCues = true
-			break
+	// Write LUT (V3 only) and events
+	if version >= FormatV3 {
+		buf.Write(lutBuf.Bytes())
+	}
+	out.flush(buf)
+	for _, e := range events {
+		if version >= FormatV4 {
+			writeCompactEvent(buf, e, version)
+		} else {
+			writeEvent(buf, e)
+		}
+		if buf.Len() >= streamChunk {
+			out.flush(buf)
 		}
 	}
+	out.flush(buf)
 
-	if hasCues {
+	// --- 6. APPEND EXTENSION BLOCKS (V3 and later) ---
+	if zoned && version >= FormatV3 {
+		writeBlock(buf, ZoneBlockMagic, ZoneBlockVersion, zones[:])
+	}
+	if banks != nil {
+		writeBlock(buf, BankBlockMagic, BankBlockVersion, banks)
+	}
+	if len(gradients.list) > 0 {
+		writeBlock(buf, GradientBlockMagic, GradientBlockVersion, gradients.encode())
+	}
+	if schedule != nil {
+		writeBlock(buf, ScheduleBlockMagic, ScheduleBlockVersion, schedule)
+	}
+	if standby != nil {
+		writeBlock(buf, StandbyBlockMagic, StandbyBlockVersion, standby)
+	}
+	if loop != nil {
+		writeBlock(buf, LoopBlockMagic, LoopBlockVersion, loop)
+	}
+	writeAutomation(buf, lanes)
+	out.flush(buf)
+
+	// --- 7. APPEND CUE BLOCK (if cues exist) ---
+	if len(cueTimes) > 0 || opts.BlackoutCue {
 		// Magic "CUE1"
 		buf.Write([]byte{0x43, 0x55, 0x45, 0x31})
-		binary.Write(buf, binary.LittleEndian, uint16(1)) // Version
+		cueVersion, blackout := CueBlockVersion, byte(0)
+		if opts.BlackoutCue {
+			cueVersion, blackout = CueBlockV2, BlackoutCueID[0]
+		}
+		binary.Write(buf, binary.LittleEndian, uint16(cueVersion))
 		binary.Write(buf, binary.LittleEndian, uint16(4)) // Count
 
 		cueIds := []string{"A", "B", "C", "D"}
 		for _, cueId := range cueIds {
-			timeValue := uint32(0xFFFFFFFF)
-			for _, cue := range p.Cues {
-				if cue.ID == cueId && cue.Enabled && cue.TimeMs != nil {
-					timeValue = uint32(*cue.TimeMs)
-					break
-				}
+			timeValue, ok := cueTimes[cueId]
+			if !ok {
+				timeValue = 0xFFFFFFFF
 			}
 			binary.Write(buf, binary.LittleEndian, timeValue)
 		}
-		buf.Write([]byte{0, 0, 0, 0, 0, 0, 0, 0}) // Reserved
+		buf.Write([]byte{blackout, 0, 0, 0, 0, 0, 0, 0}) // Blackout cue, reserved
+		out.flush(buf)
 	}
 
-	return &Result{
-		Bytes:      buf.Bytes(),
-		EventCount: eventCount,
-	}, nil
+	// --- 8. APPEND CHECKSUM FOOTER (optional) ---
+	if opts.Checksum {
+		buf.WriteString(ChecksumMagic)
+		binary.Write(buf, binary.LittleEndian, out.crc.Sum32())
+		out.flush(buf)
+	}
+	if out.err != nil {
+		return nil, fmt.Errorf("writing show.bin: %w", out.err)
+	}
+
+	if optimized != nil {
+		optimized.BytesBefore, optimized.BytesAfter = out.n+saved, out.n
+	}
+
+	result := &Result{
+		Size:       out.n,
+		EventCount: len(events),
+		Version:    version,
+		Warnings:   warnings,
+		Conflicts:  conflicts,
+		Optimized:  optimized,
+	}
+	if opts.LimitPower {
+		result.PowerLimits = AnalyzePower(p)
+	}
+	if opts.keepEvents {
+		result.events = events
+	}
+	return result, nil
 }
 
 // Helper functions
 
-func parseIDRange(idStr string) []int {
+// ParseIDRange expands a prop ID list such as "1-18" or "1,3,5" into
+// individual IDs, ignoring malformed parts and IDs outside 1..TotalProps.
+func ParseIDRange(idStr string) []int {
 	var ids []int
 	parts := strings.Split(idStr, ",")
 	for _, part := range parts {
@@ -348,6 +931,25 @@ func parseIDRange(idStr string) []int {
 	return ids
 }
 
+// FormatIDRange writes ids, in ascending order, as a ParseIDRange list
+// such as "1-18,20".
+func FormatIDRange(ids []int) string {
+	var parts []string
+	for i := 0; i < len(ids); {
+		j := i
+		for j+1 < len(ids) && ids[j+1] == ids[j]+1 {
+			j++
+		}
+		if j > i {
+			parts = append(parts, fmt.Sprintf("%d-%d", ids[i], ids[j]))
+		} else {
+			parts = append(parts, strconv.Itoa(ids[i]))
+		}
+		i = j + 1
+	}
+	return strings.Join(parts, ",")
+}
+
 func calculateMask(idStr string) [MaskArraySize]uint32 {
 	var masks [MaskArraySize]uint32
 	parts := strings.Split(idStr, ",")
@@ -392,6 +994,17 @@ func calculateMask(idStr string) [MaskArraySize]uint32 {
 	return masks
 }
 
+// maskIDs lists the prop IDs set in mask.
+func maskIDs(mask [MaskArraySize]uint32) []int {
+	var ids []int
+	for id := 1; id <= TotalProps; id++ {
+		if mask[(id-1)/32]&(1<<((id-1)%32)) != 0 {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
 func isMaskEmpty(mask [MaskArraySize]uint32) bool {
 	for _, m := range mask {
 		if m != 0 {
@@ -401,40 +1014,83 @@ func isMaskEmpty(mask [MaskArraySize]uint32) bool {
 	return true
 }
 
-func parseColor(hex string) uint32 {
-	if len(hex) == 0 {
-		return 0
-	}
-	hex = strings.TrimPrefix(hex, "#")
-	val, err := strconv.ParseUint(hex, 16, 32)
-	if err != nil {
-		return 0
+func writeEvent(buf *bytes.Buffer, e Event) {
+	binary.Write(buf, binary.LittleEndian, e.StartTime)
+	binary.Write(buf, binary.LittleEndian, e.Duration)
+	buf.Write([]byte{e.Effect, e.Speed, e.Width, 0})
+	binary.Write(buf, binary.LittleEndian, e.Color)
+	binary.Write(buf, binary.LittleEndian, e.Color2)
+	for _, m := range e.Mask {
+		binary.Write(buf, binary.LittleEndian, m)
 	}
-	return uint32(val)
 }
 
-func getEffectCode(t string) uint8 {
-	codes := map[string]uint8{
-		"solid": 1, "flash": 2, "strobe": 3, "rainbow": 4, "rainbowHold": 5, "chase": 6,
-		"wipe": 9, "scanner": 10, "meteor": 11, "fire": 12, "heartbeat": 13,
-		"glitch": 14, "energy": 15, "sparkle": 16, "breathe": 17, "alternate": 18,
+// resolveOverlaps returns a sorted copy of clips with overlaps handled
+// according to policy.
+func resolveOverlaps(in []Clip, policy OverlapPolicy) ([]Clip, error) {
+	if policy == OverlapPriority {
+		return paintClips(in), nil
+	}
+
+	clips := make([]Clip, len(in))
+	copy(clips, in)
+	sortClips(clips)
+	if policy == OverlapAllow {
+		return clips, nil
 	}
-	if val, ok := codes[t]; ok {
-		return val
+
+	out := clips[:0]
+	for _, clip := range clips {
+		if n := len(out); n > 0 {
+			prev := &out[n-1]
+			if clip.StartTime < prev.StartTime+prev.Duration {
+				if policy == OverlapError {
+					return nil, fmt.Errorf("%s clip at %gms overlaps %s clip at %gms-%gms",
+						clip.Type, clip.StartTime, prev.Type, prev.StartTime, prev.StartTime+prev.Duration)
+				}
+				prev.Duration = clip.StartTime - prev.StartTime
+				if prev.Duration <= 0 {
+					out = out[:n-1]
+				}
+			}
+		}
+		out = append(out, clip)
 	}
-	return 1
+	return out, nil
 }
 
-func writeEvent(buf *bytes.Buffer, startTime, duration uint32, effectType, speedByte, widthByte uint8, color, color2 uint32, mask [MaskArraySize]uint32) {
-	binary.Write(buf, binary.LittleEndian, startTime)
-	binary.Write(buf, binary.LittleEndian, duration)
-	binary.Write(buf, binary.LittleEndian, effectType)
-	buf.Write([]byte{speedByte, widthByte, 0})
-	binary.Write(buf, binary.LittleEndian, color)
-	binary.Write(buf, binary.LittleEndian, color2)
-	for _, m := range mask {
-		binary.Write(buf, binary.LittleEndian, m)
+// paintClips lays clips onto the timeline in list order, each one covering
+// whatever earlier clips occupied its time span.
+func paintClips(in []Clip) []Clip {
+	var out []Clip
+	for _, clip := range in {
+		start, end := clip.StartTime, clip.StartTime+clip.Duration
+		var kept []Clip
+		for _, seg := range out {
+			segEnd := seg.StartTime + seg.Duration
+			if segEnd <= start || seg.StartTime >= end {
+				kept = append(kept, seg)
+				continue
+			}
+			// The pieces of a split clip fade only at its own ends.
+			if seg.StartTime < start {
+				before := seg
+				before.Duration = start - seg.StartTime
+				before.Props.FadeOut = 0
+				kept = append(kept, before)
+			}
+			if segEnd > end {
+				after := seg
+				after.StartTime = end
+				after.Props.FadeIn = 0
+				after.Duration = segEnd - end
+				kept = append(kept, after)
+			}
+		}
+		out = append(kept, clip)
 	}
+	sortClips(out)
+	return out
 }
 
 func sortClips(clips []Clip) {
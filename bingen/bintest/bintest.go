@@ -0,0 +1,252 @@
+// Package bintest compares show.bin images and describes differences in
+// terms of the format (header fields, LUT entries, events, extension and
+// cue blocks) rather than raw offsets, for use in tests.
+package bintest
+
+import (
+	"encoding/binary"
+	"fmt"
+	"sort"
+	"strings"
+
+	"PicoLume/bingen"
+)
+
+// maxDiffs caps how many differences Diff reports.
+const maxDiffs = 20
+
+// Diff returns "" if want and got are identical, otherwise a readable list
+// of the differing fields.
+func Diff(want, got []byte) string {
+	if string(want) == string(got) {
+		return ""
+	}
+
+	var diffs []string
+	add := func(format string, args ...any) bool {
+		if len(diffs) == maxDiffs {
+			diffs = append(diffs, "...")
+			return false
+		}
+		if len(diffs) < maxDiffs {
+			diffs = append(diffs, fmt.Sprintf(format, args...))
+		}
+		return true
+	}
+
+	if len(want) != len(got) {
+		add("length: want %d bytes, got %d", len(want), len(got))
+	}
+
+	for off := 0; off < max(len(want), len(got)); off++ {
+		var w, g int = -1, -1
+		if off < len(want) {
+			w = int(want[off])
+		}
+		if off < len(got) {
+			g = int(got[off])
+		}
+		if w == g {
+			continue
+		}
+		field, start, size := Field(want, off)
+		if !add("%s: want %s, got %s", field, hexRange(want, start, size), hexRange(got, start, size)) {
+			break
+		}
+		off = start + size - 1 // one line per field
+	}
+	return strings.Join(diffs, "\n")
+}
+
+// Field names the part of a show.bin image containing byte off, returning
+// the field's start offset and size. The layout is read from data's own
+// header, so it matches what the firmware would parse.
+func Field(data []byte, off int) (name string, start, size int) {
+	if off < bingen.HeaderSize {
+		switch {
+		case off < 4:
+			return "header.magic", 0, 4
+		case off < 6:
+			return "header.version", 4, 2
+		case off < 8:
+			return "header.eventCount", 6, 2
+		default:
+			return "header.reserved", 8, 8
+		}
+	}
+
+	lutEnd := bingen.HeaderSize
+	if version(data) >= bingen.FormatV3 {
+		lutEnd += bingen.TotalProps * bingen.PropConfigSize
+	}
+	if off < lutEnd {
+		i := (off - bingen.HeaderSize) / bingen.PropConfigSize
+		start := bingen.HeaderSize + i*bingen.PropConfigSize
+		fields := []struct {
+			name string
+			size int
+		}{{"ledCount", 2}, {"ledType", 1}, {"colorOrder", 1}, {"brightnessCap", 1}, {"sustainedCap", 1}, {"derateAfter", 2}}
+		pos := start
+		for _, f := range fields {
+			if off < pos+f.size {
+				return fmt.Sprintf("lut[prop %d].%s", i+1, f.name), pos, f.size
+			}
+			pos += f.size
+		}
+	}
+
+	starts, eventsEnd := eventOffsets(data, lutEnd)
+	if off < eventsEnd {
+		i := sort.Search(len(starts), func(i int) bool { return starts[i] > off }) - 1
+		start := starts[i]
+		next := eventsEnd
+		if i+1 < len(starts) {
+			next = starts[i+1]
+		}
+		type field struct {
+			name string
+			size int
+		}
+		fields := []field{{"startTime", 4}, {"duration", 4}, {"effect", 1}, {"speed", 1}, {"width", 1}, {"reserved", 1}, {"color", 4}, {"color2", 4}}
+		switch v := version(data); {
+		case v >= bingen.FormatV5:
+			fields[5].name = "easingMaskEncoding"
+			fields = append(fields, field{"fadeIn", 2}, field{"fadeOut", 2})
+		case v >= bingen.FormatV4:
+			fields[5].name = "maskEncoding"
+		}
+		fields = append(fields, field{"mask", next - start - eventHeaderSize(data)})
+		pos := start
+		for _, f := range fields {
+			if off < pos+f.size {
+				return fmt.Sprintf("event[%d].%s", i, f.name), pos, f.size
+			}
+			pos += f.size
+		}
+	}
+
+	// Extension blocks, then the cue block and checksum footer.
+	end := len(data)
+	if bingen.HasChecksum(data) {
+		end -= bingen.ChecksumFooterSize
+		switch {
+		case off >= end+4:
+			return "crc.value", end + 4, 4
+		case off >= end:
+			return "crc.magic", end, 4
+		}
+	}
+	pos := eventsEnd
+	for pos+bingen.BlockHeaderSize <= end && string(data[pos:pos+4]) != "CUE1" {
+		magic := string(data[pos : pos+4])
+		size := int(binary.LittleEndian.Uint16(data[pos+6:]))
+		switch {
+		case off < pos+4:
+			return magic + ".magic", pos, 4
+		case off < pos+6:
+			return magic + ".version", pos + 4, 2
+		case off < pos+8:
+			return magic + ".length", pos + 6, 2
+		case off < pos+bingen.BlockHeaderSize+size:
+			i := off - pos - bingen.BlockHeaderSize
+			switch magic {
+			case bingen.ZoneBlockMagic:
+				return fmt.Sprintf("zone[prop %d]", i+1), off, 1
+			case bingen.BankBlockMagic:
+				return fmt.Sprintf("event[%d].bank", i), off, 1
+			}
+			return fmt.Sprintf("%s.payload[%d]", magic, i), off, 1
+		}
+		pos += bingen.BlockHeaderSize + size
+	}
+
+	cueOff := off - pos
+	switch {
+	case cueOff < 4:
+		return "cue.magic", pos, 4
+	case cueOff < 6:
+		return "cue.version", pos + 4, 2
+	case cueOff < 8:
+		return "cue.count", pos + 6, 2
+	case cueOff < 24:
+		i := (cueOff - 8) / 4
+		return fmt.Sprintf("cue[%c].timeMs", 'A'+i), pos + 8 + i*4, 4
+	case cueOff < 25:
+		return "cue.blackout", pos + 24, 1
+	case cueOff < bingen.CueBlockSize:
+		return "cue.reserved", pos + 25, 7
+	}
+	return fmt.Sprintf("trailing byte %d", off), off, 1
+}
+
+func version(data []byte) int {
+	if len(data) < 6 {
+		return 0
+	}
+	return int(binary.LittleEndian.Uint16(data[4:6]))
+}
+
+// eventOffsets returns the start offset of each event and the offset just
+// past the last one. V4 and V5 events vary in length, so they are walked.
+func eventOffsets(data []byte, lutEnd int) (starts []int, end int) {
+	count := 0
+	if len(data) >= 8 {
+		count = int(binary.LittleEndian.Uint16(data[6:8]))
+	}
+	compact := version(data) >= bingen.FormatV4
+	pos := lutEnd
+	for i := 0; i < count; i++ {
+		starts = append(starts, pos)
+		size := bingen.EventSize
+		if compact {
+			size = compactEventSize(data, pos)
+		}
+		pos += size
+	}
+	return starts, pos
+}
+
+// eventHeaderSize is the fixed part of each event in data's format.
+func eventHeaderSize(data []byte) int {
+	if version(data) >= bingen.FormatV5 {
+		return bingen.FadeEventHeaderSize
+	}
+	return bingen.CompactEventHeaderSize
+}
+
+// compactEventSize is the length of the V4 or V5 event at pos, or the
+// bitmap size if data is truncated or the encoding unknown.
+func compactEventSize(data []byte, pos int) int {
+	header := eventHeaderSize(data)
+	bitmap := header + 4*bingen.MaskArraySize
+	if pos+header >= len(data) {
+		return bitmap
+	}
+	n := int(data[pos+header])
+	switch data[pos+11] & 0x0F {
+	case bingen.MaskList:
+		return header + 1 + n
+	case bingen.MaskRuns:
+		return header + 1 + 2*n
+	}
+	return bitmap
+}
+
+// Events decodes the event table of a show.bin image in any format
+// version, expanding compact masks to bitmaps and taking banks from the
+// bank table.
+func Events(data []byte) ([]bingen.Event, error) {
+	_, info, err := bingen.Parse(data)
+	if err != nil {
+		return nil, err
+	}
+	return info.Events, nil
+}
+
+func hexRange(data []byte, start, size int) string {
+	if start >= len(data) {
+		return "(missing)"
+	}
+	end := min(start+size, len(data))
+	return fmt.Sprintf("% x", data[start:end])
+}
@@ -0,0 +1,120 @@
+package bingen
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash"
+	"hash/crc32"
+	"io"
+)
+
+// Extension blocks sit between the events and the CUE1 trailer, which stays
+// last because receivers look for it at the end of the file. Each block is
+// a 4-byte magic, a uint16 version and a uint16 payload length followed by
+// the payload, so firmware skips blocks it does not know.
+const BlockHeaderSize = 8
+
+// Zone table block: one byte per prop with its radio zone.
+const (
+	ZoneBlockMagic   = "ZON1"
+	ZoneBlockVersion = 1
+	ZoneBlockSize    = BlockHeaderSize + TotalProps
+)
+
+// MaxZone is the highest radio zone. Zone 0 is the default transmitter;
+// rigs with more props than one transmitter serves put the rest on zones
+// 1..MaxZone, each with its own RF channel.
+const MaxZone = 15
+
+// Bank table block: one byte per event, in event order, with the prop bank
+// its mask addresses. Receivers in bank b play only the events of bank b;
+// without the block every event is bank 0.
+const (
+	BankBlockMagic   = "BNK1"
+	BankBlockVersion = 1
+)
+
+// MaxBank is the highest prop bank. Bank b holds receivers
+// b*TotalProps+1 to (b+1)*TotalProps; the PropConfig LUT and zone table
+// are shared by all banks.
+const MaxBank = 255
+
+// eventBanks returns the bank table for events, or nil if all are bank 0.
+func eventBanks(events []Event) []byte {
+	var banks []byte
+	for i, e := range events {
+		if e.Bank != 0 && banks == nil {
+			banks = make([]byte, len(events))
+		}
+		if banks != nil {
+			banks[i] = e.Bank
+		}
+	}
+	return banks
+}
+
+func writeBlock(buf *bytes.Buffer, magic string, version uint16, payload []byte) {
+	buf.WriteString(magic)
+	binary.Write(buf, binary.LittleEndian, version)
+	binary.Write(buf, binary.LittleEndian, uint16(len(payload)))
+	buf.Write(payload)
+}
+
+// Checksum footer, written with Options.Checksum: "CRC1" and the CRC-32
+// (IEEE) of every byte before it, after the CUE1 trailer. Firmware that
+// knows the footer checks and strips it before looking for CUE1, and
+// rejects a truncated or corrupt file. Older firmware would not find the
+// cue trailer, so the footer is opt-in.
+const (
+	ChecksumMagic      = "CRC1"
+	ChecksumFooterSize = 8
+)
+
+// Errors from VerifyChecksum.
+var (
+	ErrNoChecksum = errors.New("show.bin has no checksum footer")
+	ErrChecksum   = errors.New("show.bin checksum mismatch")
+)
+
+// HasChecksum reports whether data ends in a checksum footer.
+func HasChecksum(data []byte) bool {
+	n := len(data) - ChecksumFooterSize
+	return n >= HeaderSize && string(data[n:n+4]) == ChecksumMagic
+}
+
+// VerifyChecksum checks data's footer and returns data without it.
+func VerifyChecksum(data []byte) ([]byte, error) {
+	if !HasChecksum(data) {
+		return nil, ErrNoChecksum
+	}
+	body := data[:len(data)-ChecksumFooterSize]
+	if want := binary.LittleEndian.Uint32(data[len(data)-4:]); crc32.ChecksumIEEE(body) != want {
+		return nil, ErrChecksum
+	}
+	return body, nil
+}
+
+// streamChunk is how much encoded show.bin GenerateToContext buffers
+// before writing it out.
+const streamChunk = 32 << 10
+
+// showWriter streams show.bin to w, keeping the count and CRC-32 of the
+// bytes written for Result.Size and the checksum footer.
+type showWriter struct {
+	w   io.Writer
+	crc hash.Hash32
+	n   int64
+	err error // the first write error; later writes are skipped
+}
+
+// flush writes out buf and empties it.
+func (s *showWriter) flush(buf *bytes.Buffer) {
+	if s.err == nil && buf.Len() > 0 {
+		var n int
+		n, s.err = s.w.Write(buf.Bytes())
+		s.crc.Write(buf.Bytes()[:n])
+		s.n += int64(n)
+	}
+	buf.Reset()
+}
@@ -0,0 +1,111 @@
+package bingen
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// ClipEncoder encodes the clips of a type registered with
+// RegisterClipType, so forks and extensions can add clip types without
+// touching the effect table. Clip-type specific settings go in
+// ClipProps.Params.
+type ClipEncoder interface {
+	// Validate returns a FieldError for each unusable property of clip,
+	// with Field and Value set; Generate fills in Track and Clip. In strict
+	// mode any error fails generation, otherwise the clip is still encoded.
+	Validate(clip Clip) []FieldError
+
+	// Encode returns the events clip plays, with StartTime, Duration and the
+	// effect parameters set. Generate sets Mask and Bank, and trims events
+	// to the clip so they cannot overlap its neighbours.
+	Encode(clip Clip) []Event
+}
+
+var clipTypes = struct {
+	sync.RWMutex
+	m map[string]ClipEncoder
+}{m: make(map[string]ClipEncoder)}
+
+// RegisterClipType makes enc encode clips of type name. It is meant to be
+// called from an init function and panics if name is empty, a built-in
+// effect or already registered.
+func RegisterClipType(name string, enc ClipEncoder) {
+	if name == "" || enc == nil {
+		panic("bingen: RegisterClipType with empty name or nil encoder")
+	}
+	for _, e := range effects {
+		if e.Name == name {
+			panic("bingen: RegisterClipType of built-in effect " + name)
+		}
+	}
+	clipTypes.Lock()
+	defer clipTypes.Unlock()
+	if _, dup := clipTypes.m[name]; dup {
+		panic("bingen: RegisterClipType called twice for " + name)
+	}
+	clipTypes.m[name] = enc
+}
+
+// ClipTypes returns the registered clip type names, sorted.
+func ClipTypes() []string {
+	clipTypes.RLock()
+	defer clipTypes.RUnlock()
+	names := make([]string, 0, len(clipTypes.m))
+	for name := range clipTypes.m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func clipEncoder(name string) ClipEncoder {
+	clipTypes.RLock()
+	defer clipTypes.RUnlock()
+	return clipTypes.m[name]
+}
+
+// clipEvents returns the events clip plays, with timing set but no mask:
+// one event for a built-in effect, or what a registered encoder returns.
+func clipEvents(clip Clip) []Event {
+	enc := clipEncoder(clip.Type)
+	if enc == nil {
+		e := clipEvent(clip)
+		e.StartTime, e.Duration = uint32(clip.StartTime), uint32(clip.Duration)
+		return []Event{e}
+	}
+
+	start, end := uint32(clip.StartTime), uint32(clip.StartTime+clip.Duration)
+	var out []Event
+	for _, e := range enc.Encode(clip) {
+		eEnd := uint64(e.StartTime) + uint64(e.Duration)
+		if e.StartTime < start {
+			e.StartTime = start
+		}
+		if eEnd > uint64(end) {
+			eEnd = uint64(end)
+		}
+		if eEnd <= uint64(e.StartTime) {
+			continue
+		}
+		e.Duration = uint32(eEnd) - e.StartTime
+		out = append(out, e)
+	}
+	return out
+}
+
+// validateClip runs the registered encoder's checks on clip ci of track ti.
+func validateClip(ti, ci int, clip Clip) []FieldError {
+	enc := clipEncoder(clip.Type)
+	if enc == nil {
+		return nil
+	}
+	errs := enc.Validate(clip)
+	for i := range errs {
+		errs[i].Track, errs[i].Clip = ti, ci
+		if errs[i].Err == nil {
+			errs[i].Err = fmt.Errorf("invalid %s clip", clip.Type)
+		}
+	}
+	return errs
+}
@@ -0,0 +1,75 @@
+package bingen_test
+
+import (
+	"errors"
+	"testing"
+
+	"PicoLume/bingen"
+	"PicoLume/bingen/bintest"
+)
+
+// pulseEncoder plays props.params.count white flashes, one per equal
+// slot, and spills its last one past the clip end to test trimming.
+type pulseEncoder struct{}
+
+func (pulseEncoder) Validate(clip bingen.Clip) []bingen.FieldError {
+	if n, _ := clip.Props.Params["count"].(float64); n < 1 {
+		return []bingen.FieldError{{Field: "params.count", Value: clip.Props.Params["count"], Err: errors.New("want at least 1")}}
+	}
+	return nil
+}
+
+func (pulseEncoder) Encode(clip bingen.Clip) []bingen.Event {
+	n, _ := clip.Props.Params["count"].(float64)
+	n = max(n, 1)
+	slot := uint32(clip.Duration / n)
+	var out []bingen.Event
+	for i := uint32(0); i < uint32(n); i++ {
+		out = append(out, bingen.Event{StartTime: uint32(clip.StartTime) + i*slot, Duration: slot + 50, Effect: 2, Color: 0xFFFFFF})
+	}
+	return out
+}
+
+func init() { bingen.RegisterClipType("pulse", pulseEncoder{}) }
+
+func TestRegisteredClipType(t *testing.T) {
+	p := &bingen.Project{
+		Settings:   bingen.Settings{ShowDuration: 1000},
+		PropGroups: []bingen.PropGroup{{ID: "g", IDs: "1"}},
+		Tracks: []bingen.Track{{Type: "led", GroupId: "g", Clips: []bingen.Clip{{
+			StartTime: 0, Duration: 1000, Type: "pulse",
+			Props: bingen.ClipProps{Params: map[string]any{"count": 2.0}},
+		}}}},
+	}
+	result, err := bingen.Generate(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	events, err := bintest.Events(result.Bytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 2 || events[0].Effect != 2 || events[1].StartTime != 500 || events[1].Duration != 500 {
+		t.Errorf("events = %+v, want two flashes, the second trimmed to the clip", events)
+	}
+
+	p.Tracks[0].Clips[0].Props.Params = nil
+	result, err = bingen.Generate(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Warnings) != 1 || result.Warnings[0].Field != "params.count" || result.Warnings[0].Clip != 0 {
+		t.Errorf("warnings = %v, want params.count of clip 1", result.Warnings)
+	}
+
+	for _, name := range []string{"pulse", "solid", ""} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("RegisterClipType(%q) should panic", name)
+				}
+			}()
+			bingen.RegisterClipType(name, pulseEncoder{})
+		}()
+	}
+}
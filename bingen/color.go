@@ -0,0 +1,208 @@
+package bingen
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// ErrBadColor is wrapped by color parse errors.
+var ErrBadColor = errors.New("unrecognized color")
+
+// ParseColor converts a color string to a 0xRRGGBB value; see
+// ParseColorValue for the accepted formats. Unparseable input yields 0
+// (black).
+func ParseColor(s string) uint32 {
+	c, _ := ParseColorValue(s)
+	return c
+}
+
+// ParseColorValue converts a CSS-style color to 0xRRGGBB. It accepts
+// "#RGB", "#RGBA", "#RRGGBB" and "#RRGGBBAA" (the "#" is optional),
+// "rgb()"/"rgba()", "hsl()"/"hsla()" and CSS named colors. LEDs cannot be
+// translucent, so alpha dims the color toward off.
+func ParseColorValue(s string) (uint32, error) {
+	in := strings.ToLower(strings.TrimSpace(s))
+	if in == "" {
+		return 0, fmt.Errorf("%w: empty", ErrBadColor)
+	}
+	if c, ok := namedColors[in]; ok {
+		return c, nil
+	}
+
+	var r, g, b, alpha float64
+	var ok bool
+	switch {
+	case strings.HasPrefix(in, "rgb"):
+		r, g, b, alpha, ok = parseRGBFunc(in)
+	case strings.HasPrefix(in, "hsl"):
+		r, g, b, alpha, ok = parseHSLFunc(in)
+	default:
+		r, g, b, alpha, ok = parseHex(strings.TrimPrefix(in, "#"))
+	}
+	if !ok {
+		return 0, fmt.Errorf("%w %q", ErrBadColor, s)
+	}
+
+	channel := func(v float64) uint32 {
+		return uint32(math.Round(math.Max(0, math.Min(255, v*alpha))))
+	}
+	return channel(r)<<16 | channel(g)<<8 | channel(b), nil
+}
+
+func parseHex(h string) (r, g, b, alpha float64, ok bool) {
+	val, err := strconv.ParseUint(h, 16, 32)
+	if err != nil {
+		return 0, 0, 0, 0, false
+	}
+	alpha = 1
+	switch len(h) {
+	case 3, 4:
+		if len(h) == 4 {
+			alpha = float64(val&0xF) / 15
+			val >>= 4
+		}
+		// Each digit is repeated: #F80 is #FF8800.
+		r, g, b = float64((val>>8&0xF)*17), float64((val>>4&0xF)*17), float64((val&0xF)*17)
+	case 6, 8:
+		if len(h) == 8 {
+			alpha = float64(val&0xFF) / 255
+			val >>= 8
+		}
+		r, g, b = float64(val>>16&0xFF), float64(val>>8&0xFF), float64(val&0xFF)
+	default:
+		return 0, 0, 0, 0, false
+	}
+	return r, g, b, alpha, true
+}
+
+// funcArgs splits "name(a, b, c / d)" or "name(a b c)" into its arguments.
+func funcArgs(s string, names ...string) ([]string, bool) {
+	for _, name := range names {
+		if rest, found := strings.CutPrefix(s, name+"("); found && strings.HasSuffix(rest, ")") {
+			rest = strings.TrimSuffix(rest, ")")
+			rest = strings.NewReplacer(",", " ", "/", " ").Replace(rest)
+			return strings.Fields(rest), true
+		}
+	}
+	return nil, false
+}
+
+// number parses a plain number, or a percentage scaled so 100% is full.
+func number(s string, full float64) (float64, bool) {
+	p, percent := strings.CutSuffix(s, "%")
+	v, err := strconv.ParseFloat(p, 64)
+	if err != nil || math.IsNaN(v) || math.IsInf(v, 0) {
+		return 0, false
+	}
+	if percent {
+		return v * full / 100, true
+	}
+	return v, true
+}
+
+// parseAlpha parses an optional fourth argument.
+func parseAlpha(args []string) (float64, bool) {
+	if len(args) == 3 {
+		return 1, true
+	}
+	a, ok := number(args[3], 1)
+	return math.Max(0, math.Min(1, a)), ok
+}
+
+func parseRGBFunc(s string) (r, g, b, alpha float64, ok bool) {
+	args, found := funcArgs(s, "rgba", "rgb")
+	if !found || (len(args) != 3 && len(args) != 4) {
+		return 0, 0, 0, 0, false
+	}
+	var rgb [3]float64
+	for i := range rgb {
+		if rgb[i], ok = number(args[i], 255); !ok {
+			return 0, 0, 0, 0, false
+		}
+	}
+	alpha, ok = parseAlpha(args)
+	return rgb[0], rgb[1], rgb[2], alpha, ok
+}
+
+func parseHSLFunc(s string) (r, g, b, alpha float64, ok bool) {
+	args, found := funcArgs(s, "hsla", "hsl")
+	if !found || (len(args) != 3 && len(args) != 4) {
+		return 0, 0, 0, 0, false
+	}
+	h, okH := number(strings.TrimSuffix(args[0], "deg"), 360)
+	sat, okS := number(args[1], 1)
+	light, okL := number(args[2], 1)
+	if !okH || !okS || !okL || !strings.HasSuffix(args[1], "%") || !strings.HasSuffix(args[2], "%") {
+		return 0, 0, 0, 0, false
+	}
+	if alpha, ok = parseAlpha(args); !ok {
+		return 0, 0, 0, 0, false
+	}
+
+	h = math.Mod(math.Mod(h, 360)+360, 360) / 360
+	sat = math.Max(0, math.Min(1, sat))
+	light = math.Max(0, math.Min(1, light))
+	q := light + sat - light*sat
+	if light < 0.5 {
+		q = light * (1 + sat)
+	}
+	p := 2*light - q
+	hue := func(t float64) float64 {
+		t = math.Mod(t+1, 1)
+		switch {
+		case t < 1.0/6:
+			return p + (q-p)*6*t
+		case t < 1.0/2:
+			return q
+		case t < 2.0/3:
+			return p + (q-p)*(2.0/3-t)*6
+		}
+		return p
+	}
+	return hue(h+1.0/3) * 255, hue(h) * 255, hue(h-1.0/3) * 255, alpha, true
+}
+
+// namedColors holds the CSS Color Module Level 4 named colors.
+var namedColors = map[string]uint32{
+	"aliceblue": 0xF0F8FF, "antiquewhite": 0xFAEBD7, "aqua": 0x00FFFF, "aquamarine": 0x7FFFD4,
+	"azure": 0xF0FFFF, "beige": 0xF5F5DC, "bisque": 0xFFE4C4, "black": 0x000000,
+	"blanchedalmond": 0xFFEBCD, "blue": 0x0000FF, "blueviolet": 0x8A2BE2, "brown": 0xA52A2A,
+	"burlywood": 0xDEB887, "cadetblue": 0x5F9EA0, "chartreuse": 0x7FFF00, "chocolate": 0xD2691E,
+	"coral": 0xFF7F50, "cornflowerblue": 0x6495ED, "cornsilk": 0xFFF8DC, "crimson": 0xDC143C,
+	"cyan": 0x00FFFF, "darkblue": 0x00008B, "darkcyan": 0x008B8B, "darkgoldenrod": 0xB8860B,
+	"darkgray": 0xA9A9A9, "darkgreen": 0x006400, "darkgrey": 0xA9A9A9, "darkkhaki": 0xBDB76B,
+	"darkmagenta": 0x8B008B, "darkolivegreen": 0x556B2F, "darkorange": 0xFF8C00, "darkorchid": 0x9932CC,
+	"darkred": 0x8B0000, "darksalmon": 0xE9967A, "darkseagreen": 0x8FBC8F, "darkslateblue": 0x483D8B,
+	"darkslategray": 0x2F4F4F, "darkslategrey": 0x2F4F4F, "darkturquoise": 0x00CED1, "darkviolet": 0x9400D3,
+	"deeppink": 0xFF1493, "deepskyblue": 0x00BFFF, "dimgray": 0x696969, "dimgrey": 0x696969,
+	"dodgerblue": 0x1E90FF, "firebrick": 0xB22222, "floralwhite": 0xFFFAF0, "forestgreen": 0x228B22,
+	"fuchsia": 0xFF00FF, "gainsboro": 0xDCDCDC, "ghostwhite": 0xF8F8FF, "gold": 0xFFD700,
+	"goldenrod": 0xDAA520, "gray": 0x808080, "green": 0x008000, "greenyellow": 0xADFF2F,
+	"grey": 0x808080, "honeydew": 0xF0FFF0, "hotpink": 0xFF69B4, "indianred": 0xCD5C5C,
+	"indigo": 0x4B0082, "ivory": 0xFFFFF0, "khaki": 0xF0E68C, "lavender": 0xE6E6FA,
+	"lavenderblush": 0xFFF0F5, "lawngreen": 0x7CFC00, "lemonchiffon": 0xFFFACD, "lightblue": 0xADD8E6,
+	"lightcoral": 0xF08080, "lightcyan": 0xE0FFFF, "lightgoldenrodyellow": 0xFAFAD2, "lightgray": 0xD3D3D3,
+	"lightgreen": 0x90EE90, "lightgrey": 0xD3D3D3, "lightpink": 0xFFB6C1, "lightsalmon": 0xFFA07A,
+	"lightseagreen": 0x20B2AA, "lightskyblue": 0x87CEFA, "lightslategray": 0x778899, "lightslategrey": 0x778899,
+	"lightsteelblue": 0xB0C4DE, "lightyellow": 0xFFFFE0, "lime": 0x00FF00, "limegreen": 0x32CD32,
+	"linen": 0xFAF0E6, "magenta": 0xFF00FF, "maroon": 0x800000, "mediumaquamarine": 0x66CDAA,
+	"mediumblue": 0x0000CD, "mediumorchid": 0xBA55D3, "mediumpurple": 0x9370DB, "mediumseagreen": 0x3CB371,
+	"mediumslateblue": 0x7B68EE, "mediumspringgreen": 0x00FA9A, "mediumturquoise": 0x48D1CC, "mediumvioletred": 0xC71585,
+	"midnightblue": 0x191970, "mintcream": 0xF5FFFA, "mistyrose": 0xFFE4E1, "moccasin": 0xFFE4B5,
+	"navajowhite": 0xFFDEAD, "navy": 0x000080, "oldlace": 0xFDF5E6, "olive": 0x808000,
+	"olivedrab": 0x6B8E23, "orange": 0xFFA500, "orangered": 0xFF4500, "orchid": 0xDA70D6,
+	"palegoldenrod": 0xEEE8AA, "palegreen": 0x98FB98, "paleturquoise": 0xAFEEEE, "palevioletred": 0xDB7093,
+	"papayawhip": 0xFFEFD5, "peachpuff": 0xFFDAB9, "peru": 0xCD853F, "pink": 0xFFC0CB,
+	"plum": 0xDDA0DD, "powderblue": 0xB0E0E6, "purple": 0x800080, "rebeccapurple": 0x663399,
+	"red": 0xFF0000, "rosybrown": 0xBC8F8F, "royalblue": 0x4169E1, "saddlebrown": 0x8B4513,
+	"salmon": 0xFA8072, "sandybrown": 0xF4A460, "seagreen": 0x2E8B57, "seashell": 0xFFF5EE,
+	"sienna": 0xA0522D, "silver": 0xC0C0C0, "skyblue": 0x87CEEB, "slateblue": 0x6A5ACD,
+	"slategray": 0x708090, "slategrey": 0x708090, "snow": 0xFFFAFA, "springgreen": 0x00FF7F,
+	"steelblue": 0x4682B4, "tan": 0xD2B48C, "teal": 0x008080, "thistle": 0xD8BFD8,
+	"tomato": 0xFF6347, "turquoise": 0x40E0D0, "violet": 0xEE82EE, "wheat": 0xF5DEB3,
+	"white": 0xFFFFFF, "whitesmoke": 0xF5F5F5, "yellow": 0xFFFF00, "yellowgreen": 0x9ACD32,
+	"transparent": 0x000000,
+}
@@ -0,0 +1,69 @@
+package bingen_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"PicoLume/bingen"
+)
+
+func TestParseColorValue(t *testing.T) {
+	tests := []struct {
+		in   string
+		want uint32
+	}{
+		{"#FF8000", 0xFF8000},
+		{"ff8000", 0xFF8000},
+		{"#F80", 0xFF8800},
+		{"#F80F", 0xFF8800},
+		{"#FF000080", 0x800000},
+		{"rgb(255, 128, 0)", 0xFF8000},
+		{"rgb(255 128 0)", 0xFF8000},
+		{"RGBA(255, 0, 0, 0.5)", 0x800000},
+		{"rgb(100%, 50%, 0% / 50%)", 0x804000},
+		{"hsl(0, 100%, 50%)", 0xFF0000},
+		{"hsl(120deg 100% 25%)", 0x008000},
+		{"hsla(240, 100%, 50%, 1)", 0x0000FF},
+		{"hsl(-120, 100%, 50%)", 0x0000FF},
+		{" Orange ", 0xFFA500},
+		{"rebeccapurple", 0x663399},
+	}
+	for _, tt := range tests {
+		got, err := bingen.ParseColorValue(tt.in)
+		if err != nil || got != tt.want {
+			t.Errorf("ParseColorValue(%q) = %06X, %v; want %06X", tt.in, got, err, tt.want)
+		}
+	}
+
+	for _, bad := range []string{"", "#12345", "#GGGGGG", "rgb(1, 2)", "rgb(a, b, c)", "hsl(0, 1, 0.5)", "notacolor"} {
+		if _, err := bingen.ParseColorValue(bad); !errors.Is(err, bingen.ErrBadColor) {
+			t.Errorf("ParseColorValue(%q) error = %v, want ErrBadColor", bad, err)
+		}
+		if c := bingen.ParseColor(bad); c != 0 {
+			t.Errorf("ParseColor(%q) = %06X, want black", bad, c)
+		}
+	}
+}
+
+func TestBadColorStrict(t *testing.T) {
+	p := &bingen.Project{
+		PropGroups: []bingen.PropGroup{{ID: "g1", IDs: "1"}},
+		Tracks: []bingen.Track{{Type: "led", GroupId: "g1", Clips: []bingen.Clip{
+			{StartTime: 0, Duration: 1000, Type: "solid", Props: bingen.ClipProps{Color: "red", Color2: "blurple"}},
+		}}},
+	}
+
+	result, err := bingen.GenerateContext(context.Background(), p, bingen.Options{})
+	if err != nil {
+		t.Fatalf("GenerateContext() error = %v", err)
+	}
+	if len(result.Warnings) != 1 || result.Warnings[0].Field != "color2" {
+		t.Errorf("Warnings = %v, want one for color2", result.Warnings)
+	}
+
+	_, err = bingen.GenerateContext(context.Background(), p, bingen.Options{Strict: true})
+	if !errors.Is(err, bingen.ErrBadColor) {
+		t.Errorf("GenerateContext(Strict) error = %v, want ErrBadColor", err)
+	}
+}
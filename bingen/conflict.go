@@ -0,0 +1,222 @@
+package bingen
+
+import (
+	"fmt"
+	"sort"
+)
+
+// ConflictPolicy resolves LED tracks whose groups share props and play
+// effects on them at the same time. Like overlapping clips on one track,
+// which of the events a receiver shows is undefined.
+type ConflictPolicy string
+
+const (
+	// ConflictAllow writes every track's events unchanged (legacy
+	// behavior); conflicts are still listed in Result.Conflicts.
+	ConflictAllow ConflictPolicy = ""
+	// ConflictLastTrack lets the track later in the project win.
+	ConflictLastTrack ConflictPolicy = "last"
+	// ConflictPriority lets the track with the higher Track.Priority win,
+	// and the later track on equal priorities.
+	ConflictPriority ConflictPolicy = "priority"
+	// ConflictError fails generation on the first conflict.
+	ConflictError ConflictPolicy = "error"
+)
+
+// TrackConflict reports two LED tracks playing effects on the same props
+// at the same time.
+type TrackConflict struct {
+	Track, Other int // track indexes, Track < Other
+
+	// Winner is the track whose events play on the shared props, or -1
+	// under ConflictAllow.
+	Winner int
+
+	// The first overlap between the two tracks and the props they share
+	// there.
+	StartMs, EndMs uint32
+	Props          string
+
+	Count int // overlapping event pairs between the two tracks
+}
+
+func (c TrackConflict) String() string {
+	return fmt.Sprintf("tracks %d and %d both play props %s at %dms-%dms", c.Track+1, c.Other+1, c.Props, c.StartMs, c.EndMs)
+}
+
+// span is a time range in which a losing event gives up the props in mask.
+type span struct {
+	start, end uint32
+	mask       [MaskArraySize]uint32
+}
+
+// resolveConflicts finds the events of different tracks (owners[i] is the
+// track of events[i]) that overlap in time on props of one bank, and, unless
+// policy is ConflictAllow, removes the losing track's props from its event
+// for the overlap, splitting it where needed. Off gaps always give way to
+// effects, so a track's gaps do not black out another track; two effects
+// are settled by policy. Events keep their order, with split pieces in
+// place of the event.
+func resolveConflicts(events []Event, owners []int, tracks []Track, policy ConflictPolicy) ([]Event, []TrackConflict, error) {
+	wins := func(a, b int) bool {
+		if policy == ConflictPriority && tracks[a].Priority != tracks[b].Priority {
+			return tracks[a].Priority > tracks[b].Priority
+		}
+		return a > b
+	}
+	end := func(e Event) uint32 { return e.StartTime + e.Duration }
+
+	order := make([]int, len(events))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool { return events[order[i]].StartTime < events[order[j]].StartTime })
+
+	var conflicts []TrackConflict
+	found := make(map[[2]int]int) // track pair to index in conflicts
+	cuts := make(map[int][]span)  // event index to the spans it loses
+	var active []int
+	for _, i := range order {
+		e := events[i]
+		kept := active[:0]
+		for _, j := range active {
+			if end(events[j]) > e.StartTime {
+				kept = append(kept, j)
+			}
+		}
+		active = kept
+
+		for _, j := range active {
+			o := events[j]
+			if owners[i] == owners[j] || o.Bank != e.Bank || (o.Effect == 0 && e.Effect == 0) {
+				continue
+			}
+			var shared [MaskArraySize]uint32
+			for k := range shared {
+				shared[k] = o.Mask[k] & e.Mask[k]
+			}
+			overlap := span{start: e.StartTime, end: min32(end(e), end(o)), mask: shared}
+			if isMaskEmpty(shared) || overlap.end <= overlap.start {
+				continue
+			}
+
+			loser := -1
+			switch {
+			case o.Effect == 0:
+				loser = j
+			case e.Effect == 0:
+				loser = i
+			case o == e:
+				// Identical events are written once anyway.
+				continue
+			default:
+				a, b := owners[j], owners[i]
+				if a > b {
+					a, b = b, a
+				}
+				winner := -1
+				if policy != ConflictAllow {
+					winner = b
+					if wins(a, b) {
+						winner = a
+					}
+				}
+				n, seen := found[[2]int{a, b}]
+				if !seen {
+					n = len(conflicts)
+					found[[2]int{a, b}] = n
+					conflicts = append(conflicts, TrackConflict{
+						Track: a, Other: b, Winner: winner,
+						StartMs: overlap.start, EndMs: overlap.end,
+						Props: FormatIDRange(maskIDs(shared)),
+					})
+				}
+				conflicts[n].Count++
+				if policy == ConflictError {
+					return nil, nil, fmt.Errorf("%v", conflicts[n])
+				}
+				if winner >= 0 {
+					loser = i
+					if winner == owners[i] {
+						loser = j
+					}
+				}
+			}
+			if policy != ConflictAllow && loser >= 0 {
+				cuts[loser] = append(cuts[loser], overlap)
+			}
+		}
+		if e.Duration > 0 {
+			active = append(active, i)
+		}
+	}
+
+	if len(cuts) == 0 {
+		return events, conflicts, nil
+	}
+	out := make([]Event, 0, len(events))
+	for i, e := range events {
+		if c, ok := cuts[i]; ok {
+			out = append(out, yieldSpans(e, c)...)
+		} else {
+			out = append(out, e)
+		}
+	}
+	return out, conflicts, nil
+}
+
+// yieldSpans splits e where spans begin and end, leaving each piece the
+// props no span covering it takes. Pieces with no props left are dropped
+// and neighbours with the same props joined. A piece starting after e
+// restarts the effect there, and only pieces at e's own ends keep its
+// fades.
+func yieldSpans(e Event, spans []span) []Event {
+	start, stop := e.StartTime, e.StartTime+e.Duration
+	bounds := []uint32{start, stop}
+	for _, s := range spans {
+		bounds = append(bounds, s.start, s.end)
+	}
+	sort.Slice(bounds, func(i, j int) bool { return bounds[i] < bounds[j] })
+
+	var out []Event
+	for k := 0; k+1 < len(bounds); k++ {
+		from, to := bounds[k], bounds[k+1]
+		if from == to || from < start || to > stop {
+			continue
+		}
+		mask := e.Mask
+		for _, s := range spans {
+			if s.start <= from && s.end >= to {
+				for m := range mask {
+					mask[m] &^= s.mask[m]
+				}
+			}
+		}
+		if isMaskEmpty(mask) {
+			continue
+		}
+		if n := len(out); n > 0 && out[n-1].Mask == mask && out[n-1].StartTime+out[n-1].Duration == from {
+			out[n-1].Duration = to - out[n-1].StartTime
+			continue
+		}
+		piece := e
+		piece.StartTime, piece.Duration, piece.Mask = from, to-from, mask
+		out = append(out, piece)
+	}
+	for k := range out {
+		if out[k].StartTime != start {
+			out[k].FadeIn = 0
+		}
+		if out[k].StartTime+out[k].Duration != stop {
+			out[k].FadeOut = 0
+		}
+	}
+	return out
+}
+
+func min32(a, b uint32) uint32 {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,78 @@
+package bingen_test
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"PicoLume/bingen"
+	"PicoLume/bingen/bintest"
+)
+
+// conflictProject has an "All" track (props 1-40) playing solid until 2000
+// ms and a "Left" track (props 1-20) flashing from 1000 ms.
+func conflictProject() *bingen.Project {
+	return &bingen.Project{
+		Settings:   bingen.Settings{ShowDuration: 3000},
+		PropGroups: []bingen.PropGroup{{ID: "all", IDs: "1-40"}, {ID: "left", IDs: "1-20"}},
+		Tracks: []bingen.Track{
+			{Type: "led", GroupId: "all", Clips: []bingen.Clip{{StartTime: 0, Duration: 2000, Type: "solid", Props: bingen.ClipProps{Color: "#FF0000"}}}},
+			{Type: "led", GroupId: "left", Clips: []bingen.Clip{{StartTime: 1000, Duration: 2000, Type: "flash", Props: bingen.ClipProps{Color: "#0000FF"}}}},
+		},
+	}
+}
+
+func TestGenerateTrackConflicts(t *testing.T) {
+	left := bingen.FormatIDRange(bingen.ParseIDRange("1-20"))
+	tests := []struct {
+		policy   bingen.ConflictPolicy
+		priority int // of the "All" track
+		winner   int
+		events   []string // start-end effect code props
+	}{
+		{bingen.ConflictAllow, 0, -1, []string{"0-2000 1 1-40", "0-1000 0 1-20", "1000-3000 2 1-20", "2000-3000 0 1-40"}},
+		{bingen.ConflictLastTrack, 5, 1, []string{"0-1000 1 1-40", "1000-2000 1 21-40", "1000-3000 2 1-20", "2000-3000 0 21-40"}},
+		{bingen.ConflictPriority, 0, 1, []string{"0-1000 1 1-40", "1000-2000 1 21-40", "1000-3000 2 1-20", "2000-3000 0 21-40"}},
+		{bingen.ConflictPriority, 1, 0, []string{"0-2000 1 1-40", "2000-3000 0 21-40", "2000-3000 2 1-20"}},
+	}
+	for _, tt := range tests {
+		p := conflictProject()
+		p.Tracks[0].Priority = tt.priority
+		result, err := bingen.GenerateContext(context.Background(), p, bingen.Options{Conflicts: tt.policy})
+		if err != nil {
+			t.Fatalf("%q: %v", tt.policy, err)
+		}
+		want := []bingen.TrackConflict{{Track: 0, Other: 1, Winner: tt.winner, StartMs: 1000, EndMs: 2000, Props: left, Count: 1}}
+		if !reflect.DeepEqual(result.Conflicts, want) {
+			t.Errorf("%q: conflicts = %+v, want %+v", tt.policy, result.Conflicts, want)
+		}
+		events, err := bintest.Events(result.Bytes)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, e := range events {
+			got = append(got, fmt.Sprintf("%d-%d %d %s", e.StartTime, e.StartTime+e.Duration, e.Effect, bingen.FormatIDRange(e.PropIDs())))
+		}
+		if !reflect.DeepEqual(got, tt.events) {
+			t.Errorf("%q priority %d: events = %q, want %q", tt.policy, tt.priority, got, tt.events)
+		}
+	}
+
+	if _, err := bingen.GenerateContext(context.Background(), conflictProject(), bingen.Options{Conflicts: bingen.ConflictError}); err == nil {
+		t.Error("ConflictError accepted conflicting tracks")
+	}
+	if _, err := bingen.GenerateContext(context.Background(), conflictProject(), bingen.Options{Conflicts: "first"}); err == nil {
+		t.Error("unknown conflict policy accepted")
+	}
+}
+
+func TestValidateTrackConflicts(t *testing.T) {
+	got := bingen.Validate(conflictProject())
+	want := []bingen.Problem{{Track: 1, Clip: -1, Field: "groupId", Severity: bingen.SeverityWarning,
+		Message: "plays props 1-20 at the same time as track 1, first at 1000ms-2000ms (1 overlaps)"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Validate() = %+v, want %+v", got, want)
+	}
+}
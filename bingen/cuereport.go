@@ -0,0 +1,143 @@
+package bingen
+
+import (
+	"context"
+	"math"
+	"sort"
+)
+
+// busiestEffects is how many effects a CueSegment lists.
+const busiestEffects = 3
+
+// CueSegment summarizes the stretch of show from one cue to the next, for
+// the stage manager's cue sheet.
+type CueSegment struct {
+	Cue        string        `json:"cue"`
+	StartMs    uint32        `json:"startMs"`
+	EndMs      uint32        `json:"endMs"` // next cue or show end
+	DurationMs uint32        `json:"durationMs"`
+	Groups     []string      `json:"groups"`  // names of groups with clips in the segment
+	Effects    []EffectUsage `json:"effects"` // busiest first, at most three
+
+	// SeekEvent is the index of the first show.bin event starting at or
+	// after the cue, where a receiver's binary search lands; -1 if none.
+	// SeekStartMs is its start time, later than StartMs when the cue
+	// falls between events.
+	SeekEvent   int    `json:"seekEvent"`
+	SeekStartMs uint32 `json:"seekStartMs"`
+
+	// InProgress counts events that started before the cue and are still
+	// playing at it; a receiver jumping here picks them up part-way.
+	InProgress int `json:"inProgress"`
+}
+
+// EffectUsage is how much of a segment one effect fills.
+type EffectUsage struct {
+	Effect string  `json:"effect"` // clip type
+	Clips  int     `json:"clips"`
+	Ms     float64 `json:"ms"` // clip time within the segment, summed over tracks
+}
+
+// CueReport generates p with opts and summarizes each enabled cue segment,
+// in time order. Cues Generate would leave out are left out here too.
+func CueReport(ctx context.Context, p *Project, opts Options) ([]CueSegment, error) {
+	opts.keepEvents = true
+	opts.Progress = nil
+	result, err := GenerateContext(ctx, p, opts)
+	if err != nil {
+		return nil, err
+	}
+	events := result.events
+
+	showEnd := p.Settings.ShowDuration
+	if checkTime(showEnd) != nil || showEnd <= 0 {
+		showEnd = 60000
+	}
+
+	var segs []CueSegment
+	seen := make(map[string]bool)
+	for _, cue := range p.Cues {
+		if !cue.Enabled || cue.TimeMs == nil || seen[cue.ID] || checkTime(float64(*cue.TimeMs)) != nil {
+			continue
+		}
+		seen[cue.ID] = true
+		segs = append(segs, CueSegment{Cue: cue.ID, StartMs: uint32(*cue.TimeMs)})
+	}
+	sort.SliceStable(segs, func(i, j int) bool { return segs[i].StartMs < segs[j].StartMs })
+
+	for i := range segs {
+		s := &segs[i]
+		s.EndMs = uint32(math.Max(showEnd, float64(s.StartMs)))
+		if i+1 < len(segs) {
+			s.EndMs = segs[i+1].StartMs
+		}
+		s.DurationMs = s.EndMs - s.StartMs
+
+		s.SeekEvent = sort.Search(len(events), func(k int) bool { return events[k].StartTime >= s.StartMs })
+		if s.SeekEvent < len(events) {
+			s.SeekStartMs = events[s.SeekEvent].StartTime
+		} else {
+			s.SeekEvent = -1
+		}
+		for _, e := range events {
+			if e.StartTime >= s.StartMs {
+				break
+			}
+			if e.Effect != 0 && uint64(e.StartTime)+uint64(e.Duration) > uint64(s.StartMs) {
+				s.InProgress++
+			}
+		}
+
+		s.Groups, s.Effects = segmentUsage(p, float64(s.StartMs), float64(s.EndMs))
+	}
+	return segs, nil
+}
+
+// segmentUsage returns the groups with LED clips in [start, end) and the
+// busiest effects there.
+func segmentUsage(p *Project, start, end float64) ([]string, []EffectUsage) {
+	groups := []string{}
+	seenGroup := make(map[string]bool)
+	usage := make(map[string]*EffectUsage)
+	for _, track := range p.Tracks {
+		if track.Type != "led" {
+			continue
+		}
+		for _, clip := range track.Clips {
+			overlap := math.Min(end, clip.StartTime+clip.Duration) - math.Max(start, clip.StartTime)
+			if !(overlap > 0) {
+				continue
+			}
+			if !seenGroup[track.GroupId] {
+				seenGroup[track.GroupId] = true
+				name := track.GroupId
+				if g := p.FindGroup(track.GroupId); g != nil && g.Name != "" {
+					name = g.Name
+				}
+				groups = append(groups, name)
+			}
+			u := usage[clip.Type]
+			if u == nil {
+				u = &EffectUsage{Effect: clip.Type}
+				usage[clip.Type] = u
+			}
+			u.Clips++
+			u.Ms += overlap
+		}
+	}
+
+	effects := []EffectUsage{}
+	for _, u := range usage {
+		effects = append(effects, *u)
+	}
+	sort.Slice(effects, func(i, j int) bool {
+		if effects[i].Ms != effects[j].Ms {
+			return effects[i].Ms > effects[j].Ms
+		}
+		return effects[i].Effect < effects[j].Effect
+	})
+	if len(effects) > busiestEffects {
+		effects = effects[:busiestEffects]
+	}
+	return groups, effects
+}
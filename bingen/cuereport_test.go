@@ -0,0 +1,60 @@
+package bingen_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"PicoLume/bingen"
+)
+
+func TestCueReport(t *testing.T) {
+	at := func(ms int) *int { return &ms }
+	p := &bingen.Project{
+		Settings:   bingen.Settings{ShowDuration: 4000},
+		PropGroups: []bingen.PropGroup{{ID: "l", Name: "Left", IDs: "1"}, {ID: "r", Name: "Right", IDs: "2"}},
+		Tracks: []bingen.Track{
+			{Type: "led", GroupId: "l", Clips: []bingen.Clip{
+				{StartTime: 0, Duration: 1500, Type: "solid"},
+				{StartTime: 2000, Duration: 2000, Type: "strobe"},
+			}},
+			{Type: "led", GroupId: "r", Clips: []bingen.Clip{
+				{StartTime: 500, Duration: 500, Type: "flash"},
+			}},
+		},
+		Cues: []bingen.Cue{
+			{ID: "B", TimeMs: at(2000), Enabled: true},
+			{ID: "A", TimeMs: at(1000), Enabled: true},
+			{ID: "C", TimeMs: at(3000), Enabled: false},
+		},
+	}
+	segs, err := bingen.CueReport(context.Background(), p, bingen.Options{})
+	if err != nil {
+		t.Fatalf("CueReport() error = %v", err)
+	}
+	if len(segs) != 2 || segs[0].Cue != "A" || segs[1].Cue != "B" {
+		t.Fatalf("segments = %+v, want A then B", segs)
+	}
+
+	a := segs[0]
+	if a.StartMs != 1000 || a.EndMs != 2000 || a.DurationMs != 1000 {
+		t.Errorf("A spans %d-%d (%d ms), want 1000-2000", a.StartMs, a.EndMs, a.DurationMs)
+	}
+	// Right's flash ends as A starts.
+	if !reflect.DeepEqual(a.Groups, []string{"Left"}) {
+		t.Errorf("A groups = %v, want Left only", a.Groups)
+	}
+	if len(a.Effects) != 1 || a.Effects[0].Effect != "solid" || a.Effects[0].Ms != 500 {
+		t.Errorf("A effects = %+v, want 500 ms of solid", a.Effects)
+	}
+	// The left solid (0-1500) is still playing at 1000; the first event
+	// starting at or after 1000 is the right track's off gap at 1000.
+	if a.InProgress != 1 || a.SeekStartMs != 1000 {
+		t.Errorf("A in progress %d, seek to %d ms; want 1 and 1000", a.InProgress, a.SeekStartMs)
+	}
+
+	b := segs[1]
+	if b.EndMs != 4000 || b.SeekEvent < 0 || b.SeekStartMs != 2000 || b.Effects[0].Effect != "strobe" {
+		t.Errorf("B = %+v, want strobe until the show end", b)
+	}
+}
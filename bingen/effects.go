@@ -0,0 +1,89 @@
+package bingen
+
+// Parameter kinds in a ParamSchema.
+const (
+	ParamColor  = "color"
+	ParamNumber = "number"
+)
+
+// ParamSchema describes one clip property an effect reads. Name is the
+// ClipProps JSON key.
+type ParamSchema struct {
+	Name    string  `json:"name"`
+	Kind    string  `json:"kind"`
+	Min     float64 `json:"min,omitempty"` // ParamNumber only; omitted when 0
+	Max     float64 `json:"max,omitempty"`
+	Step    float64 `json:"step,omitempty"`
+	Default any     `json:"default"` // hex string for colors, number otherwise
+}
+
+// EffectSchema describes an effect and the parameters it uses.
+type EffectSchema struct {
+	Code   uint8         `json:"code"`
+	Name   string        `json:"name"` // clip type
+	Params []ParamSchema `json:"params"`
+}
+
+// Encodable parameter ranges: speed is stored as speed*50 in one byte and
+// width as width*255.
+const (
+	maxSpeed  = 255.0 / 50
+	speedStep = 1.0 / 50
+	widthStep = 1.0 / 255
+)
+
+func colorParam(name, def string) ParamSchema {
+	return ParamSchema{Name: name, Kind: ParamColor, Default: def}
+}
+
+func speedParam() ParamSchema {
+	return ParamSchema{Name: "speed", Kind: ParamNumber, Min: speedStep, Max: maxSpeed, Step: speedStep, Default: 1.0}
+}
+
+func widthParam(def float64) ParamSchema {
+	return ParamSchema{Name: "width", Kind: ParamNumber, Min: 0, Max: 1, Step: widthStep, Default: def}
+}
+
+// effects lists the firmware effects in code order. Defaults match the
+// clips the editor creates. Only parameters show.bin carries are listed;
+// preview-only props such as a sparkle's density never reach a receiver.
+var effects = []EffectSchema{
+	{1, "solid", []ParamSchema{colorParam("color", "#ff0000")}},
+	{2, "flash", []ParamSchema{colorParam("color", "#ffffff")}},
+	{3, "strobe", []ParamSchema{colorParam("color", "#ff0000")}},
+	{4, "rainbow", []ParamSchema{speedParam()}},
+	{5, "rainbowHold", []ParamSchema{}},
+	{6, "chase", []ParamSchema{colorParam("color", "#00ff00"), speedParam(), widthParam(0.1)}},
+	{9, "wipe", []ParamSchema{colorParam("color", "#0000ff")}},
+	{10, "scanner", []ParamSchema{colorParam("color", "#ff00ff"), speedParam(), widthParam(0.1)}},
+	{11, "meteor", []ParamSchema{colorParam("color", "#ffaa00"), speedParam()}},
+	{12, "fire", []ParamSchema{}},
+	{13, "heartbeat", []ParamSchema{colorParam("color", "#ff0000"), speedParam()}},
+	{14, "glitch", []ParamSchema{colorParam("color", "#ff0000"), colorParam("color2", "#00ff00")}},
+	{15, "energy", []ParamSchema{colorParam("color", "#ff00ff"), colorParam("color2", "#00ffff"), speedParam()}},
+	{16, "sparkle", []ParamSchema{colorParam("color", "#0000ff")}},
+	{17, "breathe", []ParamSchema{colorParam("color", "#00ffff"), speedParam()}},
+	{18, "alternate", []ParamSchema{colorParam("colorA", "#ff0000"), colorParam("colorB", "#0000ff")}},
+}
+
+// EffectSchemas returns every effect the firmware knows, in code order, so
+// frontends can build property panels from it. The result is a copy.
+func EffectSchemas() []EffectSchema {
+	out := make([]EffectSchema, len(effects))
+	for i, e := range effects {
+		e.Params = append([]ParamSchema{}, e.Params...)
+		out[i] = e
+	}
+	return out
+}
+
+// getEffectCode maps a clip type to its effect code; unknown types play as
+// solid.
+func getEffectCode(t string) uint8 {
+	for _, e := range effects {
+		if e.Name == t {
+			return e.Code
+		}
+	}
+	return 1
+}
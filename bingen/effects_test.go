@@ -0,0 +1,39 @@
+package bingen_test
+
+import (
+	"testing"
+
+	"PicoLume/bingen"
+)
+
+func TestEffectSchemas(t *testing.T) {
+	schemas := bingen.EffectSchemas()
+	seen := make(map[uint8]bool)
+	for _, s := range schemas {
+		if seen[s.Code] || s.Code == 0 {
+			t.Errorf("effect %s has duplicate or reserved code %d", s.Name, s.Code)
+		}
+		seen[s.Code] = true
+
+		for _, p := range s.Params {
+			switch p.Kind {
+			case bingen.ParamColor:
+				if _, err := bingen.ParseColorValue(p.Default.(string)); err != nil {
+					t.Errorf("%s.%s default: %v", s.Name, p.Name, err)
+				}
+			case bingen.ParamNumber:
+				if d := p.Default.(float64); d < p.Min || d > p.Max {
+					t.Errorf("%s.%s default %g outside [%g, %g]", s.Name, p.Name, d, p.Min, p.Max)
+				}
+			default:
+				t.Errorf("%s.%s has unknown kind %q", s.Name, p.Name, p.Kind)
+			}
+		}
+	}
+
+	// Callers get a copy.
+	schemas[0].Params = nil
+	if len(bingen.EffectSchemas()[0].Params) == 0 {
+		t.Error("EffectSchemas() returned shared state")
+	}
+}
@@ -0,0 +1,158 @@
+package bingen
+
+import (
+	"math"
+	"sort"
+)
+
+// SizeEstimate is the show.bin EstimateSize expects.
+type SizeEstimate struct {
+	Bytes  int64 `json:"bytes"`
+	Events int   `json:"events"`
+}
+
+// EventCounter is implemented by a ClipEncoder that can tell how many
+// events a clip will encode to without encoding it, so EstimateSize stays
+// cheap for clip types that bake many events. Other registered types are
+// counted as one event per clip.
+type EventCounter interface {
+	CountEvents(clip Clip) int
+}
+
+// EstimateSize returns the size and event count of the show.bin
+// GenerateContext would write for p with opts, cheaply enough to run on
+// every edit: it walks the clips without encoding events or resolving
+// anything. The estimate is exact for tracks without overlapping clips and
+// errs high otherwise, since overlaps and conflicts resolved by opts,
+// off gaps shared between tracks and Options.Optimize only remove events.
+// Invalid options give a zero estimate.
+func EstimateSize(p *Project, opts Options) SizeEstimate {
+	version, err := opts.version()
+	if err != nil || opts.Bank < 0 || opts.Bank > MaxBank {
+		return SizeEstimate{}
+	}
+	var propFilter [MaskArraySize]uint32
+	if opts.Props != "" {
+		propFilter = calculateMask(opts.Props)
+	}
+
+	showDuration := p.Settings.ShowDuration
+	if showDuration <= 0 || math.IsNaN(showDuration) {
+		showDuration = 60000
+	}
+	showDuration = math.Min(showDuration, MaxTimeMs)
+
+	size := int64(HeaderSize)
+	if version >= FormatV3 {
+		size += TotalProps * PropConfigSize
+	}
+	events, banked := 0, false
+	var gradients gradientTable
+	for _, track := range p.Tracks {
+		if track.Type != "led" && (track.Type != "automation" || version < FormatV3) {
+			continue
+		}
+		g := p.FindGroup(track.GroupId)
+		if g == nil || g.Bank < 0 || g.Bank > MaxBank || (opts.Props != "" && g.Bank != opts.Bank) {
+			continue
+		}
+		mask := calculateMask(g.IDs)
+		if opts.Props != "" {
+			for i := range mask {
+				mask[i] &= propFilter[i]
+			}
+		}
+		if isMaskEmpty(mask) {
+			continue
+		}
+
+		if track.Type == "automation" {
+			for _, clip := range track.Clips {
+				if _, ok := automationTarget(clip.Type); ok && len(clip.Props.Keyframes) > 0 {
+					points := min(len(clip.Props.Keyframes)+2, MaxKeyframes)
+					size += BlockHeaderSize + AutomationLaneSize + int64(points)*AutomationPointSize
+				}
+			}
+			continue
+		}
+
+		eventSize := int64(EventSize)
+		if version >= FormatV4 {
+			_, payload := compactMask(mask)
+			eventSize = int64(CompactEventHeaderSize + len(payload))
+			if version >= FormatV5 {
+				eventSize += FadeEventHeaderSize - CompactEventHeaderSize
+			}
+		}
+
+		clips := make([]Clip, 0, len(track.Clips))
+		for _, clip := range track.Clips {
+			if checkTime(clip.StartTime) == nil && checkTime(clip.Duration) == nil && clip.Duration > 0 {
+				clips = append(clips, clip)
+			}
+		}
+		sort.SliceStable(clips, func(i, j int) bool { return clips[i].StartTime < clips[j].StartTime })
+
+		n, lastEnd := 0, 0.0
+		for _, clip := range clips {
+			if clip.StartTime > lastEnd {
+				n++
+			}
+			n += countClipEvents(clip)
+			lastEnd = math.Max(lastEnd, clip.StartTime+clip.Duration)
+			if stops := clipGradient(clip); stops != nil && version >= FormatV5 {
+				gradients.add(stops)
+			}
+		}
+		if lastEnd < showDuration {
+			n++
+		}
+		events += n
+		size += int64(n) * eventSize
+		banked = banked || g.Bank != 0
+	}
+	if banked {
+		size += BlockHeaderSize + int64(events)
+	}
+	if len(gradients.list) > 0 {
+		size += BlockHeaderSize + int64(len(gradients.encode()))
+	}
+
+	if version >= FormatV3 {
+		for _, prof := range p.PropProfiles() {
+			if prof.Zone > 0 && prof.Zone <= MaxZone {
+				size += ZoneBlockSize
+				break
+			}
+		}
+		if p.Settings.Schedule != nil {
+			size += ScheduleBlockSize
+		}
+		if sb := p.Settings.Standby; sb != nil && sb.Type != "" {
+			size += StandbyBlockSize
+		}
+		if p.Settings.Loop != nil {
+			size += LoopBlockSize
+		}
+	}
+	cues := opts.BlackoutCue
+	for _, cue := range p.Cues {
+		cues = cues || (cue.Enabled && cue.TimeMs != nil && *cue.TimeMs >= 0)
+	}
+	if cues {
+		size += CueBlockSize
+	}
+	if opts.Checksum {
+		size += ChecksumFooterSize
+	}
+	return SizeEstimate{Bytes: size, Events: events}
+}
+
+// countClipEvents returns how many events clip encodes to, or one if its
+// type cannot tell.
+func countClipEvents(clip Clip) int {
+	if c, ok := clipEncoder(clip.Type).(EventCounter); ok {
+		return c.CountEvents(clip)
+	}
+	return 1
+}
@@ -0,0 +1,70 @@
+package bingen_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"PicoLume/bingen"
+	"PicoLume/bingen/fixtures"
+)
+
+func TestEstimateSize(t *testing.T) {
+	all, err := fixtures.All()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, fx := range all {
+		var p bingen.Project
+		if err := json.Unmarshal(fx.Project, &p); err != nil {
+			t.Fatal(err)
+		}
+		for _, opts := range []bingen.Options{
+			{},
+			{FormatVersion: bingen.FormatV2},
+			{FormatVersion: bingen.FormatV4, BlackoutCue: true},
+			{FormatVersion: bingen.FormatV5, Checksum: true},
+		} {
+			result, err := bingen.GenerateContext(context.Background(), &p, opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			est := bingen.EstimateSize(&p, opts)
+			// The duplicates fixture overlaps clips, which the estimate
+			// may only overcount.
+			exact := fx.Name != "duplicates"
+			if exact && (est.Bytes != result.Size || est.Events != result.EventCount) {
+				t.Errorf("%s V%d: estimate %d bytes, %d events; generated %d bytes, %d events", fx.Name, opts.FormatVersion, est.Bytes, est.Events, result.Size, result.EventCount)
+			}
+			if est.Bytes < result.Size || est.Events < result.EventCount {
+				t.Errorf("%s V%d: estimate %d bytes, %d events is below generated %d bytes, %d events", fx.Name, opts.FormatVersion, est.Bytes, est.Events, result.Size, result.EventCount)
+			}
+		}
+	}
+}
+
+func TestEstimateSizeBlocks(t *testing.T) {
+	var p bingen.Project
+	if err := json.Unmarshal([]byte(stemProject), &p); err != nil {
+		t.Fatal(err)
+	}
+	p.Settings.Loop = &bingen.Loop{Count: 2}
+	p.Tracks[0].Clips = append(p.Tracks[0].Clips, bingen.Clip{
+		StartTime: 1200, Duration: 600, Type: bingen.ScriptEffect,
+		Props: bingen.ClipProps{Script: "color = hsv(p * 360, 1, 1)", Step: 100},
+	})
+	for _, opts := range []bingen.Options{{}, {FormatVersion: bingen.FormatV5}, {Props: "1-20"}} {
+		result, err := bingen.GenerateContext(context.Background(), &p, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		est := bingen.EstimateSize(&p, opts)
+		if est.Bytes != result.Size || est.Events != result.EventCount {
+			t.Errorf("%+v: estimate %d bytes, %d events; generated %d bytes, %d events", opts, est.Bytes, est.Events, result.Size, result.EventCount)
+		}
+	}
+
+	if est := bingen.EstimateSize(&p, bingen.Options{FormatVersion: 9}); est != (bingen.SizeEstimate{}) {
+		t.Errorf("invalid version: estimate = %+v, want zero", est)
+	}
+}
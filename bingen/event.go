@@ -0,0 +1,189 @@
+package bingen
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"math"
+	"sort"
+)
+
+// V4 prop mask encodings, stored in the event's reserved byte (its low
+// nibble in V5). Each is followed by its payload; Generate picks the
+// smallest.
+const (
+	// MaskBitmap is the full 28-byte bitmask used by V2 and V3.
+	MaskBitmap = 0
+	// MaskList is a count byte followed by that many prop IDs.
+	MaskList = 1
+	// MaskRuns is a count byte followed by that many first/last prop ID
+	// pairs, for contiguous ranges.
+	MaskRuns = 2
+)
+
+// CompactEventHeaderSize is the fixed part of a V4 event, before the mask
+// payload.
+const CompactEventHeaderSize = 20
+
+// FadeEventHeaderSize is the fixed part of a V5 event: the V4 header with
+// the easing curve in the high nibble of the mask encoding byte, then the
+// fade-in and fade-out times (u16 ms each) before the mask payload.
+const FadeEventHeaderSize = CompactEventHeaderSize + 4
+
+// MaxFadeMs is the longest fade an event can hold; longer ones are cut.
+const MaxFadeMs = math.MaxUint16
+
+// Easing curves for fades, by the code V5 events store.
+const (
+	EaseLinear = iota
+	EaseIn
+	EaseOut
+	EaseInOut
+)
+
+var easings = []string{"linear", "easeIn", "easeOut", "easeInOut"}
+
+// ErrBadEasing is reported for an Easing that is not in Easings; the clip
+// fades linearly.
+var ErrBadEasing = errors.New("unknown easing curve")
+
+// Easings lists the easing curve names ClipProps.Easing accepts, in code
+// order.
+func Easings() []string {
+	return append([]string(nil), easings...)
+}
+
+// easingCode maps a curve name to its code; "" and unknown names are
+// linear.
+func easingCode(name string) uint8 {
+	for i, n := range easings {
+		if n == name {
+			return uint8(i)
+		}
+	}
+	return EaseLinear
+}
+
+// clipFades returns the fade times of clip in whole ms, each within
+// MaxFadeMs, and shrunk in proportion so together they fit the clip.
+func clipFades(clip Clip) (in, out uint16) {
+	usable := func(v float64) float64 {
+		if v > 0 && !math.IsInf(v, 1) {
+			return v
+		}
+		return 0
+	}
+	fadeIn, fadeOut := usable(clip.Props.FadeIn), usable(clip.Props.FadeOut)
+	if total := fadeIn + fadeOut; total > clip.Duration {
+		fadeIn = fadeIn * clip.Duration / total
+		fadeOut = fadeOut * clip.Duration / total
+	}
+	return uint16(math.Min(fadeIn, MaxFadeMs)), uint16(math.Min(fadeOut, MaxFadeMs))
+}
+
+// Event is one show.bin event: an effect played on the props in Mask of
+// prop bank Bank. Effect 0 is off.
+type Event struct {
+	StartTime uint32 // ms
+	Duration  uint32 // ms
+	Effect    uint8
+	Speed     uint8
+	Width     uint8
+	Color     uint32 // 0xRRGGBB
+	Color2    uint32
+	Mask      [MaskArraySize]uint32
+	Bank      uint8
+
+	// V5 only; older formats drop them.
+	FadeIn   uint16 // ms
+	FadeOut  uint16 // ms
+	Easing   uint8  // EaseLinear and so on
+	Gradient uint8  // 1 and up index the gradient table; 0 for none
+}
+
+// PropIDs lists the props e addresses within its bank.
+func (e Event) PropIDs() []int {
+	return maskIDs(e.Mask)
+}
+
+func offEvent(start, duration float64, bank uint8, mask [MaskArraySize]uint32) Event {
+	return Event{StartTime: uint32(start), Duration: uint32(duration), Mask: mask, Bank: bank}
+}
+
+// sortEvents orders events by start time, keeping track order for equal
+// times, and drops exact duplicates (such as the off gaps of two tracks on
+// the same group). Receivers can then seek with a binary search.
+func sortEvents(events []Event) []Event {
+	sort.SliceStable(events, func(i, j int) bool { return events[i].StartTime < events[j].StartTime })
+
+	out := events[:0]
+	for i, e := range events {
+		dup := false
+		// Duplicates share a start time, so only that run needs checking.
+		for j := i - 1; j >= 0 && events[j].StartTime == e.StartTime; j-- {
+			if events[j] == e {
+				dup = true
+				break
+			}
+		}
+		if !dup {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+// compactMask returns the smallest V4 encoding of mask and its payload.
+func compactMask(mask [MaskArraySize]uint32) (mode byte, payload []byte) {
+	var ids, runs []byte
+	for id := 1; id <= TotalProps; id++ {
+		if mask[(id-1)/32]&(1<<((id-1)%32)) == 0 {
+			continue
+		}
+		ids = append(ids, byte(id))
+		if n := len(runs); n > 0 && int(runs[n-1]) == id-1 {
+			runs[n-1] = byte(id)
+		} else {
+			runs = append(runs, byte(id), byte(id))
+		}
+	}
+
+	bitmap := 4 * MaskArraySize
+	list := 1 + len(ids)
+	ranges := 1 + len(runs)
+	switch {
+	case list <= ranges && list < bitmap:
+		return MaskList, append([]byte{byte(len(ids))}, ids...)
+	case ranges < bitmap:
+		return MaskRuns, append([]byte{byte(len(runs) / 2)}, runs...)
+	}
+	payload = make([]byte, bitmap)
+	for i, m := range mask {
+		binary.LittleEndian.PutUint32(payload[4*i:], m)
+	}
+	return MaskBitmap, payload
+}
+
+// writeCompactEvent writes e in the V4 layout: the V3 event with the
+// reserved byte holding the mask encoding, followed by the mask payload.
+// From V5 the easing, fade times and gradient are written too.
+func writeCompactEvent(buf *bytes.Buffer, e Event, version int) {
+	mode, payload := compactMask(e.Mask)
+	if version >= FormatV5 {
+		mode |= e.Easing << 4
+	}
+	binary.Write(buf, binary.LittleEndian, e.StartTime)
+	binary.Write(buf, binary.LittleEndian, e.Duration)
+	buf.Write([]byte{e.Effect, e.Speed, e.Width, mode})
+	color2 := e.Color2
+	if version >= FormatV5 {
+		color2 |= uint32(e.Gradient) << 24
+	}
+	binary.Write(buf, binary.LittleEndian, e.Color)
+	binary.Write(buf, binary.LittleEndian, color2)
+	if version >= FormatV5 {
+		binary.Write(buf, binary.LittleEndian, e.FadeIn)
+		binary.Write(buf, binary.LittleEndian, e.FadeOut)
+	}
+	buf.Write(payload)
+}
@@ -0,0 +1,51 @@
+// Package fixtures holds canonical project JSONs and the show.bin bytes the
+// generator must produce for them. Deployed firmware depends on these bytes,
+// so any change to a golden file is a format change and must be deliberate.
+//
+// Regenerate after an intended change with:
+//
+//	go test ./bingen -run TestGolden -update
+package fixtures
+
+import (
+	"embed"
+	"path"
+	"sort"
+	"strings"
+)
+
+//go:embed testdata
+var files embed.FS
+
+// Dir is the fixture directory, relative to this package.
+const Dir = "testdata"
+
+// Fixture is one project and its expected output.
+type Fixture struct {
+	Name     string
+	Project  []byte // project JSON
+	Expected []byte // golden show.bin; nil if not generated yet
+}
+
+// All returns every fixture, sorted by name.
+func All() ([]Fixture, error) {
+	entries, err := files.ReadDir(Dir)
+	if err != nil {
+		return nil, err
+	}
+	var out []Fixture
+	for _, e := range entries {
+		name, ok := strings.CutSuffix(e.Name(), ".json")
+		if !ok {
+			continue
+		}
+		project, err := files.ReadFile(path.Join(Dir, e.Name()))
+		if err != nil {
+			return nil, err
+		}
+		expected, _ := files.ReadFile(path.Join(Dir, name+".bin"))
+		out = append(out, Fixture{Name: name, Project: project, Expected: expected})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out, nil
+}
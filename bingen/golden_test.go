@@ -0,0 +1,199 @@
+package bingen_test
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"PicoLume/bingen"
+	"PicoLume/bingen/bintest"
+	"PicoLume/bingen/fixtures"
+)
+
+var update = flag.Bool("update", false, "rewrite golden show.bin files in fixtures/testdata")
+
+func TestGolden(t *testing.T) {
+	all, err := fixtures.All()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) == 0 {
+		t.Fatal("no fixtures found")
+	}
+
+	for _, fx := range all {
+		t.Run(fx.Name, func(t *testing.T) {
+			result, err := bingen.GenerateFromJSON(string(fx.Project))
+			if err != nil {
+				t.Fatalf("GenerateFromJSON() error = %v", err)
+			}
+
+			if *update {
+				golden := filepath.Join("fixtures", fixtures.Dir, fx.Name+".bin")
+				if err := os.WriteFile(golden, result.Bytes, 0644); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			if fx.Expected == nil {
+				t.Fatalf("missing golden file; run go test ./bingen -run TestGolden -update")
+			}
+			if diff := bintest.Diff(fx.Expected, result.Bytes); diff != "" {
+				t.Errorf("show.bin differs from golden file:\n%s", diff)
+			}
+		})
+	}
+}
+
+// TestFormatV2 checks that V2 output is the V3 golden file without the
+// PropConfig LUT and with version 2 in the header.
+func TestFormatV2(t *testing.T) {
+	all, err := fixtures.All()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, fx := range all {
+		t.Run(fx.Name, func(t *testing.T) {
+			var p bingen.Project
+			if err := json.Unmarshal(fx.Project, &p); err != nil {
+				t.Fatal(err)
+			}
+			result, err := bingen.GenerateContext(context.Background(), &p, bingen.Options{FormatVersion: bingen.FormatV2})
+			if err != nil {
+				t.Fatalf("GenerateContext(V2) error = %v", err)
+			}
+
+			want := append([]byte{}, fx.Expected[:bingen.HeaderSize]...)
+			want[4] = bingen.FormatV2
+			want = append(want, fx.Expected[bingen.HeaderSize+bingen.TotalProps*bingen.PropConfigSize:]...)
+			if diff := bintest.Diff(want, result.Bytes); diff != "" {
+				t.Errorf("V2 show.bin differs:\n%s", diff)
+			}
+		})
+	}
+
+	if _, err := bingen.GenerateContext(context.Background(), &bingen.Project{}, bingen.Options{FormatVersion: 1}); err == nil {
+		t.Error("GenerateContext() with format 1 should fail")
+	}
+}
+
+// TestFormatV4 checks that compact masks decode to the V3 events and never
+// make the file larger.
+func TestFormatV4(t *testing.T) {
+	all, err := fixtures.All()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, fx := range all {
+		t.Run(fx.Name, func(t *testing.T) {
+			var p bingen.Project
+			if err := json.Unmarshal(fx.Project, &p); err != nil {
+				t.Fatal(err)
+			}
+			result, err := bingen.GenerateContext(context.Background(), &p, bingen.Options{FormatVersion: bingen.FormatV4})
+			if err != nil {
+				t.Fatalf("GenerateContext(V4) error = %v", err)
+			}
+			if len(result.Bytes) > len(fx.Expected) {
+				t.Errorf("V4 is %d bytes, V3 %d", len(result.Bytes), len(fx.Expected))
+			}
+
+			want, err := bintest.Events(fx.Expected)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := bintest.Events(result.Bytes)
+			if err != nil {
+				t.Fatalf("Events(V4) error = %v", err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("V4 events differ:\n got %v\nwant %v", got, want)
+			}
+		})
+	}
+}
+
+// TestTargetVersion checks that a receiver's format caps the version
+// written, and only lowers it.
+func TestTargetVersion(t *testing.T) {
+	all, err := fixtures.All()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var p bingen.Project
+	if err := json.Unmarshal(all[0].Project, &p); err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct{ format, target, want int }{
+		{0, 0, bingen.FormatVersion},
+		{bingen.FormatV5, bingen.FormatV3, bingen.FormatV3},
+		{0, bingen.FormatV2, bingen.FormatV2},
+		{bingen.FormatV2, bingen.FormatV4, bingen.FormatV2},
+		{bingen.FormatV4, 9, bingen.FormatV4}, // firmware newer than Studio
+	} {
+		opts := bingen.Options{FormatVersion: tc.format, TargetVersion: tc.target}
+		result, err := bingen.GenerateContext(context.Background(), &p, opts)
+		if err != nil {
+			t.Fatalf("%+v: %v", opts, err)
+		}
+		if result.Version != tc.want || int(result.Bytes[4]) != tc.want {
+			t.Errorf("%+v: wrote V%d (header %d), want V%d", opts, result.Version, result.Bytes[4], tc.want)
+		}
+	}
+
+	if _, err := bingen.GenerateContext(context.Background(), &p, bingen.Options{TargetVersion: 1}); err == nil {
+		t.Error("GenerateContext() for a format 1 receiver should fail")
+	}
+}
+
+// TestHash checks that Hash identifies each golden file, and that output
+// does not depend on map order: a project decoded again and again hashes
+// the same.
+func TestHash(t *testing.T) {
+	all, err := fixtures.All()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, fx := range all {
+		t.Run(fx.Name, func(t *testing.T) {
+			want := bingen.HashBytes(fx.Expected)
+			for i := 0; i < 20; i++ {
+				var p bingen.Project
+				if err := json.Unmarshal(fx.Project, &p); err != nil {
+					t.Fatal(err)
+				}
+				got, err := bingen.Hash(&p)
+				if err != nil {
+					t.Fatalf("Hash() error = %v", err)
+				}
+				if got != want {
+					t.Fatalf("run %d: Hash() = %s, want %s", i, got, want)
+				}
+			}
+		})
+	}
+
+	if h := bingen.HashBytes(nil); h != "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855" {
+		t.Errorf("HashBytes(nil) = %s, want the SHA-256 of nothing", h)
+	}
+}
+
+// TestPatchAliases checks that of several keys naming one prop, the plain
+// decimal one wins.
+func TestPatchAliases(t *testing.T) {
+	p := bingen.Project{Settings: bingen.Settings{
+		Profiles: []bingen.HardwareProfile{{ID: "a"}, {ID: "b"}},
+		Patch:    map[string]string{"07": "b", "7": "a", "+7": "b", "008": "a", "08": "b"},
+	}}
+	profiles := p.PropProfiles()
+	if got := profiles[7]; got == nil || got.ID != "a" {
+		t.Errorf("prop 7 = %v, want a", got)
+	}
+	if got := profiles[8]; got == nil || got.ID != "b" {
+		t.Errorf("prop 8 = %v, want b, the last key in order", got)
+	}
+}
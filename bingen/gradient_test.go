@@ -0,0 +1,100 @@
+package bingen_test
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"PicoLume/bingen"
+	"PicoLume/bingen/bintest"
+)
+
+func TestGenerateGradient(t *testing.T) {
+	var p bingen.Project
+	if err := json.Unmarshal([]byte(stemProject), &p); err != nil {
+		t.Fatal(err)
+	}
+	stops := []bingen.ColorStop{{Position: 1, Color: "#0000FF"}, {Position: 0, Color: "#FF0000"}, {Position: 0.5, Color: "#00FF00"}}
+	p.Tracks[0].Clips[0].Type = "chase"
+	p.Tracks[0].Clips[0].Props.ColorStops = stops
+	p.Tracks[1].Clips[0].Type = "wipe"
+	p.Tracks[1].Clips[0].Props.ColorStops = stops
+
+	// Older formats play the end stops.
+	v3, err := bingen.Generate(&p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(v3.Warnings) != 0 {
+		t.Errorf("warnings = %v", v3.Warnings)
+	}
+	events, err := bintest.Events(v3.Bytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e := events[0]; e.Color != 0xFF0000 || e.Color2 != 0x0000FF || e.Gradient != 0 {
+		t.Errorf("V3 chase = %06x/%06x gradient %d, want the first and last stop", e.Color, e.Color2, e.Gradient)
+	}
+	if strings.Contains(string(v3.Bytes), bingen.GradientBlockMagic) {
+		t.Error("V3 show.bin has a gradient table")
+	}
+
+	result, err := bingen.GenerateContext(context.Background(), &p, bingen.Options{FormatVersion: bingen.FormatV5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, info, err := bingen.Parse(result.Bytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]bingen.GradientStop{{{Position: 0, Color: 0xFF0000}, {Position: 128, Color: 0x00FF00}, {Position: 255, Color: 0x0000FF}}}
+	if !reflect.DeepEqual(info.Gradients, want) {
+		t.Errorf("gradients = %v, want one shared by both clips: %v", info.Gradients, want)
+	}
+	for _, e := range info.Events {
+		if e.Effect != 0 && (e.Gradient != 1 || e.Color2 != 0x0000FF) {
+			t.Errorf("event %+v, want gradient 1 and color2 0000ff", e)
+		}
+	}
+	var block []byte
+	for _, b := range info.Blocks {
+		if b.Magic == bingen.GradientBlockMagic {
+			block = b.Payload
+		}
+	}
+	if w := "\x01\x03\x00\xff\x00\x00\x80\x00\xff\x00\xff\x00\x00\xff"; string(block) != w {
+		t.Errorf("gradient table = % x, want % x", block, w)
+	}
+}
+
+func TestGenerateGradientWarnings(t *testing.T) {
+	var p bingen.Project
+	if err := json.Unmarshal([]byte(stemProject), &p); err != nil {
+		t.Fatal(err)
+	}
+	p.Tracks[0].Clips[0].Props.ColorStops = []bingen.ColorStop{{Position: 0, Color: "#FF0000"}, {Position: 1, Color: "#0000FF"}}
+	p.Tracks[1].Clips[0].Type = "scanner"
+	p.Tracks[1].Clips[0].Props.ColorStops = []bingen.ColorStop{{Position: 0, Color: "#FF0000"}, {Position: 1.5, Color: "nope"}}
+	result, err := bingen.GenerateContext(context.Background(), &p, bingen.Options{FormatVersion: bingen.FormatV5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fields []string
+	for _, w := range result.Warnings {
+		fields = append(fields, w.Field)
+	}
+	if want := []string{"colorStops", "colorStops[1].color", "colorStops[1].position"}; !reflect.DeepEqual(fields, want) {
+		t.Errorf("warnings on %v, want %v", fields, want)
+	}
+
+	// Neither clip is left with two usable stops.
+	_, info, err := bingen.Parse(result.Bytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(info.Gradients) != 0 {
+		t.Errorf("gradients = %v, want none", info.Gradients)
+	}
+}
@@ -0,0 +1,78 @@
+package bingen
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// Loop block: a region of the show receivers repeat, from the uint32 start
+// to the uint32 end time in ms, for a uint16 number of plays in all (0
+// repeats until stopped), then 2 reserved bytes. After the last play the
+// show carries on past the region's end.
+const (
+	LoopBlockMagic   = "LOP1"
+	LoopBlockVersion = 1
+	LoopBlockSize    = BlockHeaderSize + 12
+)
+
+// MaxLoopCount is the most plays a loop can count; 0 loops forever.
+const MaxLoopCount = math.MaxUint16
+
+// Loop repeats the show, or a region of it, such as a walk-in section
+// that runs until the operator fires a cue.
+type Loop struct {
+	Count   int     `json:"count"`   // plays of the region in all; 0 until stopped
+	StartMs float64 `json:"startMs"` // region start
+	EndMs   float64 `json:"endMs"`   // region end; 0 for the end of the show
+}
+
+// encode returns the loop block payload for a show ending at showEnd ms,
+// with a FieldError for each value it had to fix. The payload is nil if
+// the region is empty.
+func (l *Loop) encode(showEnd uint32) ([]byte, []FieldError) {
+	var errs []FieldError
+	report := func(field string, value any, err error) {
+		errs = append(errs, FieldError{Track: -1, Clip: -1, Field: "loop " + field, Value: value, Err: err})
+	}
+
+	count := l.Count
+	if count < 0 || count > MaxLoopCount {
+		report("count", l.Count, fmt.Errorf("outside 0 to %d", MaxLoopCount))
+		count = max(0, min(count, MaxLoopCount))
+	}
+	start, end := l.StartMs, l.EndMs
+	if err := checkTime(start); err != nil {
+		report("startMs", l.StartMs, err)
+		start = 0
+	}
+	if err := checkTime(end); err != nil {
+		report("endMs", l.EndMs, err)
+		end = 0
+	}
+	if end == 0 || end > float64(showEnd) {
+		if end > float64(showEnd) {
+			report("endMs", l.EndMs, fmt.Errorf("after the show ends at %d ms", showEnd))
+		}
+		end = float64(showEnd)
+	}
+	if start >= end {
+		report("startMs", l.StartMs, errors.New("not before the loop end"))
+		return nil, errs
+	}
+
+	payload := make([]byte, LoopBlockSize-BlockHeaderSize)
+	binary.LittleEndian.PutUint32(payload, uint32(start))
+	binary.LittleEndian.PutUint32(payload[4:], uint32(end))
+	binary.LittleEndian.PutUint16(payload[8:], uint16(count))
+	return payload, errs
+}
+
+func decodeLoop(b []byte) *Loop {
+	return &Loop{
+		Count:   int(binary.LittleEndian.Uint16(b[8:])),
+		StartMs: float64(binary.LittleEndian.Uint32(b)),
+		EndMs:   float64(binary.LittleEndian.Uint32(b[4:])),
+	}
+}
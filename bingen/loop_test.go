@@ -0,0 +1,92 @@
+package bingen_test
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"PicoLume/bingen"
+)
+
+func TestGenerateLoopBlock(t *testing.T) {
+	var p bingen.Project
+	if err := json.Unmarshal([]byte(stemProject), &p); err != nil {
+		t.Fatal(err)
+	}
+	plain, err := bingen.Generate(&p)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The whole show, three times.
+	p.Settings.Loop = &bingen.Loop{Count: 3}
+	result, err := bingen.Generate(&p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	block := result.Bytes[len(plain.Bytes):]
+	want := "LOP1\x01\x00\x0c\x00" + "\x00\x00\x00\x00" + "\xd0\x07\x00\x00" + "\x03\x00\x00\x00"
+	if string(block) != want {
+		t.Errorf("loop block = % x, want % x", block, want)
+	}
+
+	p.Settings.Loop = &bingen.Loop{StartMs: 500, EndMs: 1500}
+	result, err = bingen.Generate(&p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, _, err := bingen.Parse(result.Bytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(parsed.Settings.Loop, p.Settings.Loop) {
+		t.Errorf("parsed loop = %+v, want %+v", parsed.Settings.Loop, p.Settings.Loop)
+	}
+
+	v2, err := bingen.GenerateContext(context.Background(), &p, bingen.Options{FormatVersion: bingen.FormatV2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(v2.Bytes), bingen.LoopBlockMagic) {
+		t.Error("V2 show.bin has a loop block")
+	}
+}
+
+func TestGenerateLoopWarnings(t *testing.T) {
+	var p bingen.Project
+	if err := json.Unmarshal([]byte(stemProject), &p); err != nil {
+		t.Fatal(err)
+	}
+	plain, err := bingen.Generate(&p)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Clamped to the end of the show.
+	p.Settings.Loop = &bingen.Loop{Count: -1, StartMs: 1000, EndMs: 5000}
+	result, err := bingen.Generate(&p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fields []string
+	for _, w := range result.Warnings {
+		fields = append(fields, w.Field)
+	}
+	if want := []string{"loop count", "loop endMs"}; !reflect.DeepEqual(fields, want) {
+		t.Errorf("warnings on %v, want %v", fields, want)
+	}
+	if block := result.Bytes[len(plain.Bytes):]; string(block[8:16]) != "\xe8\x03\x00\x00\xd0\x07\x00\x00" {
+		t.Errorf("loop region = % x, want 1000-2000 ms", block[8:16])
+	}
+
+	p.Settings.Loop = &bingen.Loop{StartMs: 2000}
+	result, err = bingen.Generate(&p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Warnings) != 1 || len(result.Bytes) != len(plain.Bytes) {
+		t.Errorf("an empty region should warn and write no block: %v", result.Warnings)
+	}
+}
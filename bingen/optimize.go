@@ -0,0 +1,72 @@
+package bingen
+
+// OptimizeReport compares show.bin with and without Options.Optimize.
+type OptimizeReport struct {
+	EventsBefore int   `json:"eventsBefore"`
+	EventsAfter  int   `json:"eventsAfter"`
+	BytesBefore  int64 `json:"bytesBefore"`
+	BytesAfter   int64 `json:"bytesAfter"`
+}
+
+// staticEffect reports whether e looks the same all the way through, so
+// two such events in a row play exactly as one longer event: off or solid,
+// without fades. Other effects animate from their event's start, and
+// merging them would change their phase.
+func staticEffect(e Event) bool {
+	return (e.Effect == 0 || e.Effect == 1) && e.FadeIn == 0 && e.FadeOut == 0
+}
+
+// optimizeEvents returns events, sorted by start time, without the events
+// that play nothing: zero-duration events are dropped, and static events
+// that are identical but for their times and that overlap or touch, such
+// as a clip's solid continued by the next clip or the off gaps of two
+// tracks on the same props, become one event over their combined span.
+func optimizeEvents(events []Event) []Event {
+	out := make([]Event, 0, len(events))
+	open := make(map[Event]int) // event without times to its index in out
+	for _, e := range events {
+		if e.Duration == 0 {
+			continue
+		}
+		if !staticEffect(e) {
+			out = append(out, e)
+			continue
+		}
+		key := e
+		key.StartTime, key.Duration = 0, 0
+		if i, ok := open[key]; ok {
+			prev := &out[i]
+			if end := prev.StartTime + prev.Duration; e.StartTime <= end {
+				if e.StartTime+e.Duration > end {
+					prev.Duration = e.StartTime + e.Duration - prev.StartTime
+				}
+				continue
+			}
+		}
+		open[key] = len(out)
+		out = append(out, e)
+	}
+	return out
+}
+
+// eventsSize returns the bytes events take in show.bin format version:
+// the events themselves and their bank table, if they need one.
+func eventsSize(events []Event, version int) int64 {
+	var size int64
+	for _, e := range events {
+		switch {
+		case version >= FormatV5:
+			_, payload := compactMask(e.Mask)
+			size += int64(FadeEventHeaderSize + len(payload))
+		case version >= FormatV4:
+			_, payload := compactMask(e.Mask)
+			size += int64(CompactEventHeaderSize + len(payload))
+		default:
+			size += EventSize
+		}
+	}
+	if eventBanks(events) != nil {
+		size += BlockHeaderSize + int64(len(events))
+	}
+	return size
+}
@@ -0,0 +1,59 @@
+package bingen_test
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"PicoLume/bingen"
+	"PicoLume/bingen/bintest"
+)
+
+func TestGenerateOptimize(t *testing.T) {
+	red := bingen.ClipProps{Color: "#FF0000"}
+	p := &bingen.Project{
+		Settings:   bingen.Settings{ShowDuration: 4000},
+		PropGroups: []bingen.PropGroup{{ID: "g1", IDs: "1-4"}},
+		Tracks: []bingen.Track{
+			{Type: "led", GroupId: "g1", Clips: []bingen.Clip{
+				{StartTime: 0, Duration: 1000, Type: "solid", Props: red},
+				{StartTime: 1000, Duration: 1000, Type: "solid", Props: red},
+				{StartTime: 2000, Duration: 500, Type: "flash", Props: red},
+				{StartTime: 2500, Duration: 500, Type: "flash", Props: red},
+				{StartTime: 3000, Duration: 0.4, Type: "solid", Props: red},
+			}},
+			{Type: "led", GroupId: "g1", Clips: []bingen.Clip{
+				{StartTime: 0, Duration: 2000, Type: "solid", Props: red},
+			}},
+		},
+	}
+	plain, err := bingen.Generate(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := bingen.GenerateContext(context.Background(), p, bingen.Options{Optimize: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plain.Optimized != nil {
+		t.Error("Optimized set without Options.Optimize")
+	}
+	want := &bingen.OptimizeReport{EventsBefore: plain.EventCount, EventsAfter: 4, BytesBefore: plain.Size, BytesAfter: result.Size}
+	if !reflect.DeepEqual(result.Optimized, want) || result.Size != plain.Size-int64(plain.EventCount-4)*bingen.EventSize {
+		t.Errorf("Optimized = %+v, want %+v", result.Optimized, want)
+	}
+
+	events, err := bintest.Events(result.Bytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, e := range events {
+		got = append(got, fmt.Sprintf("%d-%d %d", e.StartTime, e.StartTime+e.Duration, e.Effect))
+	}
+	// The flashes restart at each clip, so they stay apart.
+	if w := []string{"0-2000 1", "2000-2500 2", "2000-4000 0", "2500-3000 2"}; !reflect.DeepEqual(got, w) {
+		t.Errorf("events = %q, want %q", got, w)
+	}
+}
@@ -0,0 +1,80 @@
+package bingen_test
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"PicoLume/bingen"
+)
+
+const overlapProject = `{
+  "settings": {"ledCount": 30, "brightness": 200, "showDuration": 3000, "profiles": [], "patch": {}},
+  "propGroups": [{"id": "g1", "name": "All", "ids": "1-4"}],
+  "tracks": [
+    {"type": "led", "groupId": "g1", "clips": [
+      {"startTime": 0, "duration": 2000, "type": "solid", "props": {"color": "#FF0000"}},
+      {"startTime": 500, "duration": 500, "type": "flash", "props": {"color": "#0000FF"}}
+    ]}
+  ],
+  "cues": []
+}`
+
+// span is the start, end and effect type of one encoded event.
+type span struct {
+	start, end uint32
+	effect     uint8
+}
+
+func overlapSpans(t *testing.T, policy bingen.OverlapPolicy) ([]span, error) {
+	t.Helper()
+	var p bingen.Project
+	if err := json.Unmarshal([]byte(overlapProject), &p); err != nil {
+		t.Fatal(err)
+	}
+	result, err := bingen.GenerateContext(context.Background(), &p, bingen.Options{
+		FormatVersion: bingen.FormatV2,
+		Overlap:       policy,
+	})
+	if err != nil {
+		return nil, err
+	}
+	var spans []span
+	for i := 0; i < result.EventCount; i++ {
+		ev := result.Bytes[bingen.HeaderSize+i*bingen.EventSize:]
+		start := binary.LittleEndian.Uint32(ev)
+		spans = append(spans, span{start, start + binary.LittleEndian.Uint32(ev[4:]), ev[8]})
+	}
+	return spans, nil
+}
+
+func TestOverlapPolicies(t *testing.T) {
+	const off, solid, flash = 0, 1, 2
+	tests := []struct {
+		policy bingen.OverlapPolicy
+		want   []span
+	}{
+		{bingen.OverlapAllow, []span{{0, 2000, solid}, {500, 1000, flash}, {2000, 3000, off}}},
+		{bingen.OverlapTrim, []span{{0, 500, solid}, {500, 1000, flash}, {1000, 3000, off}}},
+		{bingen.OverlapPriority, []span{{0, 500, solid}, {500, 1000, flash}, {1000, 2000, solid}, {2000, 3000, off}}},
+	}
+	for _, tt := range tests {
+		got, err := overlapSpans(t, tt.policy)
+		if err != nil {
+			t.Errorf("policy %q: error = %v", tt.policy, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("policy %q: events = %v, want %v", tt.policy, got, tt.want)
+		}
+	}
+
+	if _, err := overlapSpans(t, bingen.OverlapError); err == nil {
+		t.Error("OverlapError should reject overlapping clips")
+	}
+	if _, err := overlapSpans(t, "merge"); err == nil {
+		t.Error("unknown overlap policy should fail")
+	}
+}
@@ -0,0 +1,414 @@
+package bingen
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// ErrNotShow is wrapped by Parse errors for data that is not a show.bin.
+var ErrNotShow = errors.New("not a show.bin file")
+
+// ShowInfo is a decoded show.bin, as the firmware reads it.
+type ShowInfo struct {
+	Version int
+	Props   [TotalProps]PropConfig // V3 and later; zero for V2
+	Events  []Event                // with banks from the bank table
+	Zones   [TotalProps]uint8      // radio zone per prop, from the zone table
+	Blocks  []Block                // extension blocks in file order, known or not
+
+	Automation []AutomationLane // from the automation blocks, in file order
+	Gradients  [][]GradientStop // the gradient table; event Gradient g is Gradients[g-1]
+
+	Cues        map[string]uint32 // cue ID to ms, for the cues the cue block sets
+	BlackoutCue bool              // cue block version 2 reserves BlackoutCueID
+	Checksum    bool              // the file ends in a valid checksum footer
+}
+
+// Block is one extension block.
+type Block struct {
+	Magic   string
+	Version uint16
+	Payload []byte
+}
+
+// Parse decodes show.bin data in any format version. The Project rebuilds
+// a timeline from it: one prop group and LED track per distinct prop set,
+// a hardware profile per distinct LUT entry, the cues, schedule and
+// standby look. It plays the same show, but clips are as the receiver sees
+// them: script clips come back as their keyframes, and events with effect
+// codes Studio does not know are left out (ShowInfo.Events keeps them).
+func Parse(data []byte) (*Project, *ShowInfo, error) {
+	info, err := parseShow(data)
+	if err != nil {
+		return nil, nil, err
+	}
+	return info.project(), info, nil
+}
+
+func parseShow(data []byte) (*ShowInfo, error) {
+	checksum := HasChecksum(data)
+	if checksum {
+		body, err := VerifyChecksum(data)
+		if err != nil {
+			return nil, err
+		}
+		data = body
+	}
+	if len(data) < HeaderSize {
+		return nil, fmt.Errorf("%w: %d bytes is shorter than the header", ErrNotShow, len(data))
+	}
+	if binary.LittleEndian.Uint32(data) != 0x5049434F {
+		return nil, fmt.Errorf("%w: bad magic % x", ErrNotShow, data[:4])
+	}
+	info := &ShowInfo{
+		Version:  int(binary.LittleEndian.Uint16(data[4:])),
+		Checksum: checksum,
+		Cues:     make(map[string]uint32),
+	}
+	if info.Version < FormatV2 || info.Version > FormatV5 {
+		return nil, fmt.Errorf("unsupported show format version %d", info.Version)
+	}
+	count := int(binary.LittleEndian.Uint16(data[6:]))
+	pos := HeaderSize
+
+	if info.Version >= FormatV3 {
+		if len(data) < pos+TotalProps*PropConfigSize {
+			return nil, fmt.Errorf("show.bin truncated in the prop table (%d bytes)", len(data))
+		}
+		for i := range info.Props {
+			d := data[pos+i*PropConfigSize:]
+			info.Props[i] = PropConfig{
+				LedCount:      binary.LittleEndian.Uint16(d),
+				LedType:       d[2],
+				ColorOrder:    d[3],
+				BrightnessCap: d[4],
+				SustainedCap:  d[5],
+				DerateAfter:   binary.LittleEndian.Uint16(d[6:]),
+			}
+		}
+		pos += TotalProps * PropConfigSize
+	}
+
+	info.Events = make([]Event, count)
+	for i := range info.Events {
+		n, err := readEvent(data[pos:], info.Version, &info.Events[i])
+		if err != nil {
+			return nil, fmt.Errorf("event %d: %w", i, err)
+		}
+		pos += n
+	}
+
+	// Extension blocks, up to the cue block, which is always last.
+	for pos < len(data) && !(len(data)-pos == CueBlockSize && string(data[pos:pos+4]) == "CUE1") {
+		if len(data)-pos < BlockHeaderSize {
+			return nil, fmt.Errorf("%d stray bytes after the events", len(data)-pos)
+		}
+		size := int(binary.LittleEndian.Uint16(data[pos+6:]))
+		if pos+BlockHeaderSize+size > len(data) {
+			return nil, fmt.Errorf("block %q runs past the end of the file", data[pos:pos+4])
+		}
+		b := Block{
+			Magic:   string(data[pos : pos+4]),
+			Version: binary.LittleEndian.Uint16(data[pos+4:]),
+			Payload: data[pos+BlockHeaderSize : pos+BlockHeaderSize+size],
+		}
+		switch b.Magic {
+		case ZoneBlockMagic:
+			copy(info.Zones[:], b.Payload)
+		case BankBlockMagic:
+			if size != count {
+				return nil, fmt.Errorf("bank table has %d entries for %d events", size, count)
+			}
+			for i := range info.Events {
+				info.Events[i].Bank = b.Payload[i]
+			}
+		case GradientBlockMagic:
+			gradients, err := decodeGradients(b.Payload)
+			if err != nil {
+				return nil, err
+			}
+			info.Gradients = gradients
+		case AutomationBlockMagic:
+			lanes, err := decodeAutomation(b.Payload)
+			if err != nil {
+				return nil, err
+			}
+			info.Automation = append(info.Automation, lanes...)
+		}
+		info.Blocks = append(info.Blocks, b)
+		pos += BlockHeaderSize + size
+	}
+
+	if pos < len(data) {
+		cue := data[pos:]
+		version := binary.LittleEndian.Uint16(cue[4:])
+		for i, id := range []string{"A", "B", "C", "D"} {
+			if t := binary.LittleEndian.Uint32(cue[8+4*i:]); t != 0xFFFFFFFF {
+				info.Cues[id] = t
+			}
+		}
+		info.BlackoutCue = version >= CueBlockV2 && cue[24] == BlackoutCueID[0]
+	}
+	return info, nil
+}
+
+// readEvent decodes the event at the start of d into e and returns its
+// length.
+func readEvent(d []byte, version int, e *Event) (int, error) {
+	header := CompactEventHeaderSize
+	if version >= FormatV5 {
+		header = FadeEventHeaderSize
+	}
+	if len(d) < header+1 {
+		return 0, errors.New("truncated")
+	}
+	e.StartTime = binary.LittleEndian.Uint32(d)
+	e.Duration = binary.LittleEndian.Uint32(d[4:])
+	e.Effect, e.Speed, e.Width = d[8], d[9], d[10]
+	e.Color = binary.LittleEndian.Uint32(d[12:])
+	e.Color2 = binary.LittleEndian.Uint32(d[16:])
+
+	mask := d[header:]
+	set := func(id byte) {
+		if id >= 1 && int(id) <= TotalProps {
+			e.Mask[(id-1)/32] |= 1 << ((id - 1) % 32)
+		}
+	}
+	encoding := byte(MaskBitmap)
+	if version >= FormatV4 {
+		encoding = d[11]
+	}
+	if version >= FormatV5 {
+		encoding, e.Easing = d[11]&0x0F, d[11]>>4
+		e.FadeIn = binary.LittleEndian.Uint16(d[20:])
+		e.FadeOut = binary.LittleEndian.Uint16(d[22:])
+		e.Gradient, e.Color2 = byte(e.Color2>>24), e.Color2&0xFFFFFF
+	}
+	switch encoding {
+	case MaskBitmap:
+		if len(mask) < 4*MaskArraySize {
+			return 0, errors.New("truncated")
+		}
+		for j := range e.Mask {
+			e.Mask[j] = binary.LittleEndian.Uint32(mask[4*j:])
+		}
+		return header + 4*MaskArraySize, nil
+	case MaskList:
+		n := int(mask[0])
+		if len(mask) < 1+n {
+			return 0, errors.New("truncated")
+		}
+		for _, id := range mask[1 : 1+n] {
+			set(id)
+		}
+		return header + 1 + n, nil
+	case MaskRuns:
+		n := int(mask[0])
+		if len(mask) < 1+2*n {
+			return 0, errors.New("truncated")
+		}
+		for r := 0; r < n; r++ {
+			for id := int(mask[1+2*r]); id <= int(mask[2+2*r]); id++ {
+				set(byte(id))
+			}
+		}
+		return header + 1 + 2*n, nil
+	}
+	return 0, fmt.Errorf("unknown mask encoding %d", encoding)
+}
+
+// defaultPropConfig is the LUT entry Generate writes for props without a
+// hardware profile.
+var defaultPropConfig = PropConfig{LedCount: 164, BrightnessCap: 255}
+
+// project rebuilds a Project from info.
+func (info *ShowInfo) project() *Project {
+	p := &Project{}
+
+	// One hardware profile per distinct LUT entry and zone.
+	if info.Version >= FormatV3 {
+		type key struct {
+			config PropConfig
+			zone   uint8
+		}
+		var order []key
+		ids := make(map[key][]int)
+		for i, c := range info.Props {
+			k := key{c, info.Zones[i]}
+			if k == (key{config: defaultPropConfig}) {
+				continue
+			}
+			if ids[k] == nil {
+				order = append(order, k)
+			}
+			ids[k] = append(ids[k], i+1)
+		}
+		for n, k := range order {
+			c := k.config
+			p.Settings.Profiles = append(p.Settings.Profiles, HardwareProfile{
+				ID:            "p" + strconv.Itoa(n+1),
+				Name:          fmt.Sprintf("Profile %d", n+1),
+				AssignedIds:   FormatIDRange(ids[k]),
+				LedCount:      int(c.LedCount),
+				LedType:       int(c.LedType),
+				ColorOrder:    int(c.ColorOrder),
+				BrightnessCap: int(c.BrightnessCap),
+				Zone:          int(k.zone),
+				ThermalCap:    int(c.SustainedCap),
+				ThermalAfter:  int(c.DerateAfter),
+			})
+		}
+	}
+
+	// One group per distinct prop set, and one LED or automation track per
+	// group that uses it, in order of first use.
+	type key struct {
+		mask       [MaskArraySize]uint32
+		bank       uint8
+		automation bool
+	}
+	groups := make(map[key]string)
+	tracks := make(map[key]int)
+	track := func(k key) int {
+		ti, ok := tracks[k]
+		if ok {
+			return ti
+		}
+		gk := key{mask: k.mask, bank: k.bank}
+		id, ok := groups[gk]
+		if !ok {
+			ids := FormatIDRange(maskIDs(k.mask))
+			id = "g" + strconv.Itoa(len(groups)+1)
+			groups[gk] = id
+			p.PropGroups = append(p.PropGroups, PropGroup{ID: id, Name: "Props " + ids, IDs: ids, Bank: int(k.bank)})
+		}
+		typ := "led"
+		if k.automation {
+			typ = "automation"
+		}
+		ti = len(p.Tracks)
+		tracks[k] = ti
+		p.Tracks = append(p.Tracks, Track{Type: typ, GroupId: id, Clips: []Clip{}})
+		return ti
+	}
+	var end uint32
+	for _, e := range info.Events {
+		end = max(end, e.StartTime+e.Duration)
+		ti := track(key{mask: e.Mask, bank: e.Bank})
+		if clip, ok := eventClip(e); ok {
+			if g := int(e.Gradient); g > 0 && g <= len(info.Gradients) {
+				clip.Props.ColorStops = gradientStops(info.Gradients[g-1])
+			}
+			p.Tracks[ti].Clips = append(p.Tracks[ti].Clips, clip)
+		}
+	}
+	p.Settings.ShowDuration = float64(end)
+	for _, lane := range info.Automation {
+		if clip, ok := laneClip(lane); ok {
+			ti := track(key{lane.Mask, lane.Bank, true})
+			p.Tracks[ti].Clips = append(p.Tracks[ti].Clips, clip)
+		}
+	}
+
+	for _, id := range []string{"A", "B", "C", "D"} {
+		cue := Cue{ID: id}
+		if t, ok := info.Cues[id]; ok {
+			ms := int(t)
+			cue.TimeMs, cue.Enabled = &ms, true
+		}
+		p.Cues = append(p.Cues, cue)
+	}
+
+	for _, b := range info.Blocks {
+		switch {
+		case b.Magic == ScheduleBlockMagic && len(b.Payload) >= ScheduleBlockSize-BlockHeaderSize:
+			p.Settings.Schedule = decodeSchedule(b.Payload)
+		case b.Magic == StandbyBlockMagic && len(b.Payload) >= StandbyBlockSize-BlockHeaderSize:
+			p.Settings.Standby = decodeStandby(b.Payload)
+		case b.Magic == LoopBlockMagic && len(b.Payload) >= LoopBlockSize-BlockHeaderSize:
+			p.Settings.Loop = decodeLoop(b.Payload)
+		}
+	}
+	return p
+}
+
+// eventClip turns an event back into a clip; false for off events and
+// unknown effects.
+func eventClip(e Event) (Clip, bool) {
+	var name string
+	for _, s := range effects {
+		if s.Code == e.Effect {
+			name = s.Name
+		}
+	}
+	if name == "" {
+		return Clip{}, false
+	}
+	return Clip{
+		StartTime: float64(e.StartTime),
+		Duration:  float64(e.Duration),
+		Type:      name,
+		Props:     eventProps(name, e),
+	}, true
+}
+
+// eventProps is the inverse of clipEvent's encoding: properties that
+// encode back to the same bytes.
+func eventProps(name string, e Event) ClipProps {
+	props := ClipProps{
+		Color:   hexColor(e.Color),
+		Color2:  hexColor(e.Color2),
+		Width:   float64(e.Width) / 255,
+		FadeIn:  float64(e.FadeIn),
+		FadeOut: float64(e.FadeOut),
+	}
+	if e.Easing != EaseLinear && int(e.Easing) < len(easings) {
+		props.Easing = easings[e.Easing]
+	}
+	if name == "alternate" {
+		props.ColorA, props.ColorB = props.Color, props.Color2
+	}
+
+	// Speed is stored truncated as speed*50; 0 only comes from speeds below
+	// one step. Some k/50 multiply back to just under k, so nudge those up.
+	props.Speed = float64(e.Speed) / 50
+	if e.Speed == 0 {
+		props.Speed = speedStep / 2
+	} else if int(props.Speed*50) < int(e.Speed) {
+		props.Speed = math.Nextafter(props.Speed, math.Inf(1))
+	}
+	return props
+}
+
+func hexColor(c uint32) string {
+	return fmt.Sprintf("#%06x", c&0xFFFFFF)
+}
+
+func decodeSchedule(b []byte) *Schedule {
+	s := &Schedule{Start: fmt.Sprintf("%04d-%02d-%02dT%02d:%02d:%02d",
+		binary.LittleEndian.Uint16(b), b[2], b[3], b[4], b[5], b[6])}
+	switch b[7] {
+	case RepeatDaily:
+		s.Repeat = "daily"
+	case RepeatWeekly:
+		s.Repeat = "weekly"
+		for d := 0; d < 7; d++ {
+			if b[8]&(1<<d) != 0 {
+				s.Days = append(s.Days, d)
+			}
+		}
+	}
+	return s
+}
+
+func decodeStandby(b []byte) *Standby {
+	s := &Standby{Brightness: math.Round(float64(b[3])/255*1000) / 1000}
+	clip, ok := eventClip(Event{Effect: b[0], Speed: b[1], Width: b[2], Color: binary.LittleEndian.Uint32(b[4:]), Color2: binary.LittleEndian.Uint32(b[8:])})
+	if ok {
+		s.Type, s.Props = clip.Type, clip.Props
+	}
+	return s
+}
@@ -0,0 +1,122 @@
+package bingen_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"PicoLume/bingen"
+	"PicoLume/bingen/bintest"
+	"PicoLume/bingen/fixtures"
+)
+
+// TestParseRoundTrip checks that the Project Parse rebuilds from each
+// golden file generates the same file again.
+func TestParseRoundTrip(t *testing.T) {
+	all, err := fixtures.All()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, fx := range all {
+		t.Run(fx.Name, func(t *testing.T) {
+			p, info, err := bingen.Parse(fx.Expected)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if info.Version != bingen.FormatVersion {
+				t.Errorf("Version = %d, want %d", info.Version, bingen.FormatVersion)
+			}
+			result, err := bingen.GenerateContext(context.Background(), p, bingen.Options{BlackoutCue: info.BlackoutCue})
+			if err != nil {
+				t.Fatalf("GenerateContext() error = %v", err)
+			}
+			if diff := bintest.Diff(fx.Expected, result.Bytes); diff != "" {
+				t.Errorf("regenerated show.bin differs:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	project := `{
+		"settings": {"showDuration": 3000, "profiles": [
+			{"id": "x", "assignedIds": "1-4", "ledCount": 60, "ledType": 1, "colorOrder": 1, "brightnessCap": 200, "zone": 2, "thermalCap": 120, "thermalAfter": 30}
+		], "patch": {},
+		"schedule": {"start": "2026-12-01T18:00:00", "repeat": "weekly", "days": [1, 3]},
+		"standby": {"type": "breathe", "props": {"color": "#102030", "speed": 0.58}, "brightness": 0.1}},
+		"propGroups": [{"id": "a", "ids": "1-4"}, {"id": "b", "ids": "5,7", "bank": 1}],
+		"tracks": [
+			{"type": "led", "groupId": "a", "clips": [{"startTime": 500, "duration": 1000, "type": "alternate", "props": {"colorA": "#ff0000", "colorB": "#0000ff", "fadeIn": 200, "fadeOut": 300, "easing": "easeOut"}}]},
+			{"type": "led", "groupId": "b", "clips": [{"startTime": 0, "duration": 2000, "type": "chase", "props": {"color": "#00ff00", "speed": 1.14, "width": 0.25, "colorStops": [{"position": 0, "color": "#ff0000"}, {"position": 0.5, "color": "#00ff00"}, {"position": 1, "color": "#0000ff"}]}}]},
+			{"type": "automation", "groupId": "a", "clips": [{"startTime": 0, "duration": 2000, "type": "brightness", "props": {"keyframes": [{"timeMs": 0, "value": 1}, {"timeMs": 2000, "value": 0.2}]}}]}
+		],
+		"cues": [{"id": "B", "timeMs": 1500, "enabled": true}]
+	}`
+	var p bingen.Project
+	if err := json.Unmarshal([]byte(project), &p); err != nil {
+		t.Fatal(err)
+	}
+	for _, version := range []int{bingen.FormatV3, bingen.FormatV4, bingen.FormatV5} {
+		opts := bingen.Options{FormatVersion: version, BlackoutCue: true, Checksum: true}
+		want, err := bingen.GenerateContext(context.Background(), &p, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		got, info, err := bingen.Parse(want.Bytes)
+		if err != nil {
+			t.Fatalf("V%d: Parse() error = %v", version, err)
+		}
+		if !info.Checksum || !info.BlackoutCue || info.Cues["B"] != 1500 || len(info.Cues) != 1 {
+			t.Errorf("V%d: checksum %v, blackout %v, cues %v", version, info.Checksum, info.BlackoutCue, info.Cues)
+		}
+		if info.Zones[0] != 2 || info.Props[0].LedCount != 60 || info.Props[0].DerateAfter != 30 {
+			t.Errorf("V%d: prop 1 = %+v zone %d", version, info.Props[0], info.Zones[0])
+		}
+		if len(got.Settings.Profiles) != 1 || got.Settings.Profiles[0].AssignedIds != "1-4" {
+			t.Errorf("V%d: profiles = %+v", version, got.Settings.Profiles)
+		}
+		if s := got.Settings.Schedule; s == nil || s.Start != "2026-12-01T18:00:00" || s.Repeat != "weekly" || len(s.Days) != 2 {
+			t.Errorf("V%d: schedule = %+v", version, s)
+		}
+		if len(got.Tracks) != 3 || got.Tracks[2].Type != "automation" || got.Tracks[2].GroupId != got.Tracks[0].GroupId {
+			t.Fatalf("V%d: tracks = %+v, want an LED track per group and automation on the first", version, got.Tracks)
+		}
+		if g := got.FindGroup(got.Tracks[1].GroupId); g == nil || g.IDs != "5,7" || g.Bank != 1 {
+			t.Errorf("V%d: second group = %+v, want props 5,7 in bank 1", version, g)
+		}
+
+		again, err := bingen.GenerateContext(context.Background(), got, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := bintest.Diff(want.Bytes, again.Bytes); diff != "" {
+			t.Errorf("V%d: regenerated show.bin differs:\n%s", version, diff)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	result, err := bingen.GenerateFromJSON(stemProject)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := result.Bytes
+	bad := append([]byte{}, data...)
+	bad[0] = 'X'
+	tests := map[string][]byte{
+		"empty":     nil,
+		"magic":     bad,
+		"truncated": data[:len(data)-bingen.EventSize],
+		"version":   append(append([]byte{}, data[:4]...), append([]byte{9, 0}, data[6:]...)...),
+	}
+	for name, d := range tests {
+		if _, _, err := bingen.Parse(d); err == nil {
+			t.Errorf("Parse(%s) should fail", name)
+		}
+	}
+	if _, _, err := bingen.Parse([]byte("nope")); !errors.Is(err, bingen.ErrNotShow) {
+		t.Errorf("Parse(text) = %v, want ErrNotShow", err)
+	}
+}
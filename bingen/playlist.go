@@ -0,0 +1,150 @@
+package bingen
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"unicode/utf8"
+)
+
+// playlist.bin holds a performer's whole set, so receivers can switch
+// shows from the remote without a USB upload. It starts with a 16-byte
+// header (magic "PLST", uint16 version, uint16 show count, 8 reserved
+// bytes), then one index entry per show: a 32-byte UTF-8 name padded with
+// zeros, and the uint32 offset from the start of the file, size and
+// duration in ms of the show. The shows follow as complete show.bin
+// images, each starting on a 4-byte boundary.
+const (
+	PlaylistMagic      = "PLST"
+	PlaylistVersion    = 1
+	PlaylistHeaderSize = 16
+	PlaylistEntrySize  = PlaylistNameSize + 12
+	PlaylistNameSize   = 32
+)
+
+// MaxPlaylistShows is the most shows a playlist holds; the remote selects
+// them by a one-byte index.
+const MaxPlaylistShows = 255
+
+// ErrNotPlaylist is returned by ParsePlaylist for data without the
+// playlist header.
+var ErrNotPlaylist = errors.New("not a playlist.bin")
+
+// PlaylistShow is one show to put in a playlist: its name on the remote
+// and its show.bin.
+type PlaylistShow struct {
+	Name string
+	Data []byte
+}
+
+// PlaylistEntry is a show's entry in the playlist index.
+type PlaylistEntry struct {
+	Name       string
+	Offset     uint32
+	Size       uint32
+	DurationMs uint32 // end of the last event
+}
+
+// BuildPlaylist writes shows, in order, as a playlist.bin. Names longer
+// than PlaylistNameSize bytes are cut at a character boundary, and empty
+// ones become "Show n". Each show must parse as a show.bin.
+func BuildPlaylist(shows []PlaylistShow) ([]byte, []PlaylistEntry, error) {
+	if len(shows) == 0 {
+		return nil, nil, errors.New("a playlist needs at least one show")
+	}
+	if len(shows) > MaxPlaylistShows {
+		return nil, nil, fmt.Errorf("%d shows; a playlist holds at most %d", len(shows), MaxPlaylistShows)
+	}
+
+	entries := make([]PlaylistEntry, len(shows))
+	offset := PlaylistHeaderSize + PlaylistEntrySize*len(shows)
+	for i, s := range shows {
+		info, err := parseShow(s.Data)
+		if err != nil {
+			return nil, nil, fmt.Errorf("show %d (%s): %w", i+1, s.Name, err)
+		}
+		offset = align4(offset)
+		if int64(offset)+int64(len(s.Data)) > 0xFFFFFFFF {
+			return nil, nil, errors.New("playlist larger than 4 GB")
+		}
+		name := playlistName(s.Name)
+		if name == "" {
+			name = fmt.Sprintf("Show %d", i+1)
+		}
+		entries[i] = PlaylistEntry{Name: name, Offset: uint32(offset), Size: uint32(len(s.Data)), DurationMs: showEnd(info.Events)}
+		offset += len(s.Data)
+	}
+
+	buf := new(bytes.Buffer)
+	buf.WriteString(PlaylistMagic)
+	binary.Write(buf, binary.LittleEndian, uint16(PlaylistVersion))
+	binary.Write(buf, binary.LittleEndian, uint16(len(shows)))
+	buf.Write(make([]byte, 8)) // reserved
+	for _, e := range entries {
+		var name [PlaylistNameSize]byte
+		copy(name[:], e.Name)
+		buf.Write(name[:])
+		binary.Write(buf, binary.LittleEndian, [3]uint32{e.Offset, e.Size, e.DurationMs})
+	}
+	for i, s := range shows {
+		buf.Write(make([]byte, int(entries[i].Offset)-buf.Len()))
+		buf.Write(s.Data)
+	}
+	return buf.Bytes(), entries, nil
+}
+
+// ParsePlaylist reads the index of a playlist.bin, checking that every
+// show lies within data. Show i is data[e.Offset : e.Offset+e.Size].
+func ParsePlaylist(data []byte) ([]PlaylistEntry, error) {
+	if len(data) < PlaylistHeaderSize || string(data[:4]) != PlaylistMagic {
+		return nil, ErrNotPlaylist
+	}
+	if v := binary.LittleEndian.Uint16(data[4:]); v != PlaylistVersion {
+		return nil, fmt.Errorf("unsupported playlist version %d", v)
+	}
+	n := int(binary.LittleEndian.Uint16(data[6:]))
+	if len(data) < PlaylistHeaderSize+n*PlaylistEntrySize {
+		return nil, errors.New("truncated playlist index")
+	}
+	entries := make([]PlaylistEntry, n)
+	for i := range entries {
+		d := data[PlaylistHeaderSize+i*PlaylistEntrySize:]
+		e := PlaylistEntry{
+			Name:       string(bytes.TrimRight(d[:PlaylistNameSize], "\x00")),
+			Offset:     binary.LittleEndian.Uint32(d[PlaylistNameSize:]),
+			Size:       binary.LittleEndian.Uint32(d[PlaylistNameSize+4:]),
+			DurationMs: binary.LittleEndian.Uint32(d[PlaylistNameSize+8:]),
+		}
+		if uint64(e.Offset)+uint64(e.Size) > uint64(len(data)) {
+			return nil, fmt.Errorf("show %d (%s) extends past the end of the playlist", i+1, e.Name)
+		}
+		entries[i] = e
+	}
+	return entries, nil
+}
+
+// playlistName cuts name to PlaylistNameSize bytes without splitting a
+// character.
+func playlistName(name string) string {
+	for len(name) > PlaylistNameSize {
+		_, size := utf8.DecodeLastRuneInString(name)
+		name = name[:len(name)-size]
+	}
+	return name
+}
+
+// showEnd returns when the last of events ends.
+func showEnd(events []Event) uint32 {
+	var end uint32
+	for _, e := range events {
+		if t := e.StartTime + e.Duration; t > end {
+			end = t
+		}
+	}
+	return end
+}
+
+func align4(n int) int {
+	return (n + 3) &^ 3
+}
@@ -0,0 +1,66 @@
+package bingen_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"PicoLume/bingen"
+)
+
+func TestBuildPlaylist(t *testing.T) {
+	short, err := bingen.Generate(conflictProject())
+	if err != nil {
+		t.Fatal(err)
+	}
+	long := conflictProject()
+	long.Settings.ShowDuration = 9000
+	v5, err := bingen.GenerateContext(context.Background(), long, bingen.Options{FormatVersion: bingen.FormatV5, Checksum: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	name := strings.Repeat("é", 20) // 40 bytes
+	data, entries, err := bingen.BuildPlaylist([]bingen.PlaylistShow{{Name: name, Data: short.Bytes}, {Data: v5.Bytes}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entries[0].Name != strings.Repeat("é", 16) || entries[1].Name != "Show 2" {
+		t.Errorf("names = %q, %q", entries[0].Name, entries[1].Name)
+	}
+	if entries[0].DurationMs != 3000 || entries[1].DurationMs != 9000 {
+		t.Errorf("durations = %d, %d", entries[0].DurationMs, entries[1].DurationMs)
+	}
+	if first := bingen.PlaylistHeaderSize + 2*bingen.PlaylistEntrySize; entries[0].Offset != uint32(first) {
+		t.Errorf("first show at %d, want %d", entries[0].Offset, first)
+	}
+
+	parsed, err := bingen.ParsePlaylist(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, want := range [][]byte{short.Bytes, v5.Bytes} {
+		e := parsed[i]
+		if e != entries[i] {
+			t.Errorf("entry %d = %+v, want %+v", i, e, entries[i])
+		}
+		if e.Offset%4 != 0 || !bytes.Equal(data[e.Offset:e.Offset+e.Size], want) {
+			t.Errorf("show %d not stored as is on a 4-byte boundary", i)
+		}
+	}
+
+	if _, err := bingen.ParsePlaylist(short.Bytes); !errors.Is(err, bingen.ErrNotPlaylist) {
+		t.Errorf("ParsePlaylist(show.bin) error = %v", err)
+	}
+	if _, err := bingen.ParsePlaylist(data[:len(data)-1]); err == nil {
+		t.Error("ParsePlaylist accepted a truncated playlist")
+	}
+	if _, _, err := bingen.BuildPlaylist([]bingen.PlaylistShow{{Name: "junk", Data: []byte("PICO")}}); err == nil {
+		t.Error("BuildPlaylist accepted a broken show")
+	}
+	if _, _, err := bingen.BuildPlaylist(nil); err == nil {
+		t.Error("BuildPlaylist accepted no shows")
+	}
+}
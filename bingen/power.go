@@ -0,0 +1,134 @@
+package bingen
+
+import "math"
+
+// MilliampsPerChannel is the current one pixel draws per color channel at
+// full brightness; a WS2812B at full white draws three times this.
+const MilliampsPerChannel = 20
+
+// defaultVoltage is assumed for profiles without a voltage.
+const defaultVoltage = 5
+
+// PowerLimit reports a clip whose worst case exceeds the power budget of a
+// prop it plays on.
+type PowerLimit struct {
+	Track       int     `json:"track"` // index into Project.Tracks
+	Clip        int     `json:"clip"`  // index into that track's clips
+	Profile     string  `json:"profile"`
+	PeakWatts   float64 `json:"peakWatts"`   // per prop, unlimited
+	BudgetWatts float64 `json:"budgetWatts"` // per prop
+	// Scale is the brightness factor that keeps the clip within budget.
+	Scale float64 `json:"scale"`
+	// Applied is false for effects with built-in colors (rainbow, fire),
+	// which generation cannot dim; lower the profile's brightness cap.
+	Applied bool `json:"applied"`
+}
+
+// AnalyzePower lists the clips on LED tracks whose worst-case draw exceeds
+// the PowerBudget of a prop's hardware profile. Generating with
+// Options.LimitPower dims exactly these clips.
+func AnalyzePower(p *Project) []PowerLimit {
+	profiles := p.PropProfiles()
+	var limits []PowerLimit
+	for ti, track := range p.Tracks {
+		if track.Type != "led" {
+			continue
+		}
+		g := p.FindGroup(track.GroupId)
+		if g == nil {
+			continue
+		}
+		ids := ParseIDRange(g.IDs)
+		for ci, clip := range track.Clips {
+			// A script clip is limited by its brightest keyframe.
+			var e Event
+			scale, prof, peak := 1.0, (*HardwareProfile)(nil), 0.0
+			for _, ke := range clipEvents(clip) {
+				if s, p, w := powerScale(ke, ids, profiles); s < scale {
+					e, scale, prof, peak = ke, s, p, w
+				}
+			}
+			if scale >= 1 {
+				continue
+			}
+			limits = append(limits, PowerLimit{
+				Track:       ti,
+				Clip:        ci,
+				Profile:     prof.Name,
+				PeakWatts:   round2(peak),
+				BudgetWatts: prof.PowerBudget,
+				Scale:       round2(scale),
+				Applied:     effectColors(e.Effect) > 0,
+			})
+		}
+	}
+	return limits
+}
+
+// powerScale returns the brightness factor that keeps e within the power
+// budget of every prop in ids, and the profile and peak watts that set it.
+func powerScale(e Event, ids []int, profiles map[int]*HardwareProfile) (float64, *HardwareProfile, float64) {
+	if e.Effect == 0 {
+		return 1, nil, 0
+	}
+	channels := 2.0 // hue-cycling effects light at most two channels fully
+	switch effectColors(e.Effect) {
+	case 1:
+		channels = colorChannels(e.Color)
+	case 2:
+		channels = max(colorChannels(e.Color), colorChannels(e.Color2))
+	}
+
+	scale, worst, peak := 1.0, (*HardwareProfile)(nil), 0.0
+	seen := make(map[*HardwareProfile]bool)
+	for _, id := range ids {
+		prof := profiles[id]
+		if prof == nil || prof.PowerBudget <= 0 || seen[prof] {
+			continue
+		}
+		seen[prof] = true
+		volts := prof.Voltage
+		if volts <= 0 {
+			volts = defaultVoltage
+		}
+		watts := float64(prof.LedCount) * MilliampsPerChannel / 1000 * channels * float64(prof.BrightnessCap) / 255 * volts
+		if s := prof.PowerBudget / watts; s < scale {
+			scale, worst, peak = s, prof, watts
+		}
+	}
+	return scale, worst, peak
+}
+
+// effectColors is how many of an event's colors effect code shows.
+func effectColors(code uint8) int {
+	for _, e := range effects {
+		if e.Code != code {
+			continue
+		}
+		n := 0
+		for _, p := range e.Params {
+			if p.Kind == ParamColor {
+				n++
+			}
+		}
+		return n
+	}
+	return 1 // unknown types play as solid
+}
+
+// colorChannels is how many channels' worth of current c draws, 0 to 3.
+func colorChannels(c uint32) float64 {
+	return float64(c>>16&0xFF+c>>8&0xFF+c&0xFF) / 255
+}
+
+// scaleColor dims c by f, rounding down so the result stays in budget.
+func scaleColor(c uint32, f float64) uint32 {
+	ch := func(shift uint) uint32 {
+		return uint32(math.Floor(float64(c>>shift&0xFF)*f)) << shift
+	}
+	return ch(16) | ch(8) | ch(0)
+}
+
+func round2(f float64) float64 {
+	return math.Round(f*100) / 100
+}
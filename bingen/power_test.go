@@ -0,0 +1,58 @@
+package bingen_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"PicoLume/bingen"
+	"PicoLume/bingen/bintest"
+)
+
+const powerProject = `{
+  "settings": {"showDuration": 3000, "patch": {}, "profiles": [
+    {"id": "p", "name": "Staff", "assignedIds": "1-4", "ledCount": 30, "brightnessCap": 255, "voltage": 5, "powerBudget": 3}
+  ]},
+  "propGroups": [{"id": "g", "name": "All", "ids": "1-4"}],
+  "tracks": [{"type": "led", "groupId": "g", "clips": [
+    {"startTime": 0, "duration": 1000, "type": "solid", "props": {"color": "#FF0000"}},
+    {"startTime": 1000, "duration": 1000, "type": "solid", "props": {"color": "#FFFFFF"}},
+    {"startTime": 2000, "duration": 1000, "type": "rainbow", "props": {}}
+  ]}],
+  "cues": []
+}`
+
+func TestLimitPower(t *testing.T) {
+	var p bingen.Project
+	if err := json.Unmarshal([]byte(powerProject), &p); err != nil {
+		t.Fatal(err)
+	}
+
+	// 30 pixels at 20 mA per channel and 5 V: red 3 W, white 9 W,
+	// rainbow 6 W, against a 3 W budget.
+	limits := bingen.AnalyzePower(&p)
+	if len(limits) != 2 {
+		t.Fatalf("AnalyzePower() = %+v, want white and rainbow", limits)
+	}
+	if l := limits[0]; l.Clip != 1 || l.PeakWatts != 9 || l.Scale != 0.33 || !l.Applied {
+		t.Errorf("white clip = %+v, want 9 W dimmed to 0.33", l)
+	}
+	if l := limits[1]; l.Clip != 2 || l.Scale != 0.5 || l.Applied {
+		t.Errorf("rainbow clip = %+v, want 0.5 and not applied", l)
+	}
+
+	result, err := bingen.GenerateContext(context.Background(), &p, bingen.Options{LimitPower: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.PowerLimits) != 2 {
+		t.Errorf("PowerLimits = %+v, want 2", result.PowerLimits)
+	}
+	events, err := bintest.Events(result.Bytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if events[0].Color != 0xFF0000 || events[1].Color != 0x555555 {
+		t.Errorf("colors = %06x, %06x; want red unchanged and white dimmed to 555555", events[0].Color, events[1].Color)
+	}
+}
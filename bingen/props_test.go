@@ -0,0 +1,314 @@
+package bingen_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"PicoLume/bingen"
+	"PicoLume/bingen/bintest"
+)
+
+const stemProject = `{
+  "settings": {"showDuration": 2000, "profiles": [], "patch": {}},
+  "propGroups": [
+    {"id": "all", "name": "All", "ids": "1-40"},
+    {"id": "left", "name": "Left", "ids": "1-20"},
+    {"id": "right", "name": "Right", "ids": "21-40"}
+  ],
+  "tracks": [
+    {"type": "led", "groupId": "all", "clips": [{"startTime": 0, "duration": 1000, "type": "solid", "props": {"color": "#FF0000"}}]},
+    {"type": "led", "groupId": "right", "clips": [{"startTime": 1000, "duration": 1000, "type": "flash", "props": {"color": "#0000FF"}}]}
+  ],
+  "cues": []
+}`
+
+func TestGeneratePropsFilter(t *testing.T) {
+	var p bingen.Project
+	if err := json.Unmarshal([]byte(stemProject), &p); err != nil {
+		t.Fatal(err)
+	}
+	result, err := bingen.GenerateContext(context.Background(), &p, bingen.Options{Props: "1-20"})
+	if err != nil {
+		t.Fatalf("GenerateContext() error = %v", err)
+	}
+	events, err := bintest.Events(result.Bytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Only the "All" track reaches props 1-20: its clip and final gap.
+	if len(events) != 2 {
+		t.Fatalf("got %d events, want 2", len(events))
+	}
+	for _, e := range events {
+		if e.Mask != [bingen.MaskArraySize]uint32{0x000FFFFF} {
+			t.Errorf("event at %d ms: mask = %08x, want props 1-20 only", e.StartTime, e.Mask)
+		}
+	}
+
+	if _, err := bingen.GenerateContext(context.Background(), &p, bingen.Options{Props: "300"}); err == nil {
+		t.Error("Props without valid IDs should fail")
+	}
+}
+
+func TestGenerateZoneTable(t *testing.T) {
+	var p bingen.Project
+	if err := json.Unmarshal([]byte(stemProject), &p); err != nil {
+		t.Fatal(err)
+	}
+	plain, err := bingen.Generate(&p)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p.Settings.Profiles = []bingen.HardwareProfile{
+		{ID: "a", Name: "Near", AssignedIds: "1-20", LedCount: 30},
+		{ID: "b", Name: "Far", AssignedIds: "21-40", LedCount: 30, Zone: 2},
+		{ID: "c", Name: "Broken", AssignedIds: "41", LedCount: 30, Zone: 99},
+	}
+	ms := 500
+	p.Cues = []bingen.Cue{{ID: "A", TimeMs: &ms, Enabled: true}}
+	result, err := bingen.Generate(&p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Warnings) != 1 {
+		t.Errorf("warnings = %v, want one for the out-of-range zone", result.Warnings)
+	}
+
+	// The zone block goes after the events; the cue block stays last.
+	data := result.Bytes
+	block := len(plain.Bytes)
+	if got := string(data[block : block+4]); got != bingen.ZoneBlockMagic {
+		t.Fatalf("block magic = %q, want %q", got, bingen.ZoneBlockMagic)
+	}
+	zones := data[block+bingen.BlockHeaderSize : block+bingen.ZoneBlockSize]
+	if zones[0] != 0 || zones[20] != 2 || zones[39] != 2 || zones[40] != 0 {
+		t.Errorf("zones = %v, want props 21-40 in zone 2", zones[:41])
+	}
+	if got := string(data[len(data)-bingen.CueBlockSize:][:4]); got != "CUE1" {
+		t.Errorf("last block = %q, want CUE1", got)
+	}
+	if name, _, _ := bintest.Field(data, block+bingen.BlockHeaderSize+20); name != "zone[prop 21]" {
+		t.Errorf("Field() = %q, want zone[prop 21]", name)
+	}
+}
+
+func TestGenerateBanks(t *testing.T) {
+	var p bingen.Project
+	if err := json.Unmarshal([]byte(stemProject), &p); err != nil {
+		t.Fatal(err)
+	}
+	p.PropGroups[2].Bank = 1 // "right" is now props 21-40 of bank 1
+
+	result, err := bingen.Generate(&p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	events, err := bintest.Events(result.Bytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	banks := map[uint8]int{}
+	for _, e := range events {
+		banks[e.Bank]++
+		if e.Bank == 1 && e.Mask != [bingen.MaskArraySize]uint32{0xFFF00000, 0x000000FF} {
+			t.Errorf("bank 1 event at %d ms: mask = %08x", e.StartTime, e.Mask)
+		}
+	}
+	// "All": clip and final gap; "right": gap, clip (no final gap).
+	if banks[0] != 2 || banks[1] != 2 {
+		t.Errorf("events per bank = %v, want 2 in bank 0 and 2 in bank 1", banks)
+	}
+
+	stem, err := bingen.GenerateContext(context.Background(), &p, bingen.Options{Props: "21-40", Bank: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if events, _ := bintest.Events(stem.Bytes); len(events) != 2 || events[0].Bank != 1 {
+		t.Errorf("bank 1 stem events = %+v, want the right track only", events)
+	}
+
+	if _, err := bingen.GenerateContext(context.Background(), &p, bingen.Options{FormatVersion: bingen.FormatV2}); err == nil {
+		t.Error("V2 should reject banked events")
+	}
+	p.PropGroups[2].Bank = 300
+	if result, err := bingen.Generate(&p); err != nil || len(result.Warnings) != 1 {
+		t.Errorf("bank 300: warnings = %v, err = %v; want one warning", result.Warnings, err)
+	}
+}
+
+func TestGenerateThermalDerating(t *testing.T) {
+	var p bingen.Project
+	if err := json.Unmarshal([]byte(stemProject), &p); err != nil {
+		t.Fatal(err)
+	}
+	p.Settings.Profiles = []bingen.HardwareProfile{
+		{ID: "a", Name: "Enclosed", AssignedIds: "1-2", LedCount: 30, BrightnessCap: 255, ThermalCap: 128, ThermalAfter: 300},
+		{ID: "b", Name: "Broken", AssignedIds: "3", LedCount: 30, BrightnessCap: 255, ThermalCap: 100},
+	}
+	result, err := bingen.Generate(&p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Warnings) != 1 {
+		t.Errorf("warnings = %v, want one for the missing thermalAfter", result.Warnings)
+	}
+	lut := result.Bytes[bingen.HeaderSize:]
+	if got := lut[bingen.PropConfigSize+5 : 2*bingen.PropConfigSize]; string(got) != "\x80\x2c\x01" {
+		t.Errorf("prop 2 derating = % x, want 80 2c 01", got)
+	}
+	if got := lut[2*bingen.PropConfigSize+5 : 3*bingen.PropConfigSize]; string(got) != "\x00\x00\x00" {
+		t.Errorf("prop 3 derating = % x, want none", got)
+	}
+	if name, _, _ := bintest.Field(result.Bytes, bingen.HeaderSize+6); name != "lut[prop 1].derateAfter" {
+		t.Errorf("Field() = %q", name)
+	}
+}
+
+func TestGenerateBlackoutCue(t *testing.T) {
+	var p bingen.Project
+	if err := json.Unmarshal([]byte(stemProject), &p); err != nil {
+		t.Fatal(err)
+	}
+	plain, err := bingen.Generate(&p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := bingen.GenerateContext(context.Background(), &p, bingen.Options{BlackoutCue: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Written without any project cues.
+	cue := result.Bytes[len(plain.Bytes):]
+	if len(cue) != bingen.CueBlockSize || string(cue[:4]) != "CUE1" {
+		t.Fatalf("trailer = % x, want a cue block", cue)
+	}
+	if cue[4] != bingen.CueBlockV2 || cue[24] != 'Z' {
+		t.Errorf("cue block version %d, blackout %q; want 2, Z", cue[4], cue[24])
+	}
+	for i := 8; i < 24; i++ {
+		if cue[i] != 0xFF {
+			t.Fatalf("cue times = % x, want all unused", cue[8:24])
+		}
+	}
+}
+
+func TestGenerateChecksum(t *testing.T) {
+	var p bingen.Project
+	if err := json.Unmarshal([]byte(stemProject), &p); err != nil {
+		t.Fatal(err)
+	}
+	opts := bingen.Options{BlackoutCue: true}
+	plain, err := bingen.GenerateContext(context.Background(), &p, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts.Checksum = true
+	result, err := bingen.GenerateContext(context.Background(), &p, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data := result.Bytes
+	if len(data) != len(plain.Bytes)+bingen.ChecksumFooterSize || !bingen.HasChecksum(data) {
+		t.Fatalf("footer = % x, want CRC1 after the cue block", data[len(plain.Bytes):])
+	}
+	body, err := bingen.VerifyChecksum(data)
+	if err != nil || string(body) != string(plain.Bytes) {
+		t.Errorf("VerifyChecksum() = %d bytes, %v; want the file without its footer", len(body), err)
+	}
+	if name, _, _ := bintest.Field(data, len(data)-1); name != "crc.value" {
+		t.Errorf("Field() = %q, want crc.value", name)
+	}
+
+	corrupt := append([]byte{}, data...)
+	corrupt[bingen.HeaderSize] ^= 1
+	if _, err := bingen.VerifyChecksum(corrupt); !errors.Is(err, bingen.ErrChecksum) {
+		t.Errorf("VerifyChecksum(corrupt) = %v, want ErrChecksum", err)
+	}
+	truncated := append(data[:len(plain.Bytes)-10:len(plain.Bytes)-10], data[len(plain.Bytes):]...)
+	if _, err := bingen.VerifyChecksum(truncated); !errors.Is(err, bingen.ErrChecksum) {
+		t.Errorf("VerifyChecksum(truncated) = %v, want ErrChecksum", err)
+	}
+	if _, err := bingen.VerifyChecksum(plain.Bytes); !errors.Is(err, bingen.ErrNoChecksum) {
+		t.Errorf("VerifyChecksum(no footer) = %v, want ErrNoChecksum", err)
+	}
+}
+
+func TestGenerateFades(t *testing.T) {
+	var p bingen.Project
+	if err := json.Unmarshal([]byte(stemProject), &p); err != nil {
+		t.Fatal(err)
+	}
+	plain, err := bingen.Generate(&p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.Tracks[0].Clips[0].Props.FadeIn = 250
+	p.Tracks[0].Clips[0].Props.FadeOut = 1250 // together longer than the clip
+	p.Tracks[0].Clips[0].Props.Easing = "easeInOut"
+	p.Tracks[1].Clips[0].Props.FadeIn = -5
+	p.Tracks[1].Clips[0].Props.Easing = "bounce"
+
+	v3, err := bingen.Generate(&p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(v3.Bytes) != string(plain.Bytes) {
+		t.Error("fades changed a V3 show.bin")
+	}
+	if len(v3.Warnings) != 2 {
+		t.Errorf("warnings = %v, want the negative fade and unknown easing", v3.Warnings)
+	}
+
+	result, err := bingen.GenerateContext(context.Background(), &p, bingen.Options{FormatVersion: bingen.FormatV5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	events, err := bintest.Events(result.Bytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fading, flash *bingen.Event
+	for i := range events {
+		switch events[i].Effect {
+		case 1:
+			fading = &events[i]
+		case 2:
+			flash = &events[i]
+		}
+	}
+	if fading == nil || fading.FadeIn != 166 || fading.FadeOut != 833 || fading.Easing != bingen.EaseInOut {
+		t.Errorf("solid event = %+v, want fades scaled to 166/833ms easing in and out", fading)
+	}
+	if flash == nil || flash.FadeIn != 0 || flash.Easing != bingen.EaseLinear {
+		t.Errorf("flash event = %+v, want no fade, linear", flash)
+	}
+	off := bingen.HeaderSize + bingen.TotalProps*bingen.PropConfigSize + 21
+	if name, _, _ := bintest.Field(result.Bytes, off); name != "event[0].fadeIn" {
+		t.Errorf("Field() = %q, want event[0].fadeIn", name)
+	}
+
+	// A clip split by a later one fades in on its first piece and out on
+	// its last.
+	p.Tracks[0].Clips = append(p.Tracks[0].Clips, bingen.Clip{StartTime: 400, Duration: 200, Type: "flash"})
+	result, err = bingen.GenerateContext(context.Background(), &p, bingen.Options{FormatVersion: bingen.FormatV5, Overlap: bingen.OverlapPriority})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if events, err = bintest.Events(result.Bytes); err != nil {
+		t.Fatal(err)
+	}
+	var pieces []bingen.Event
+	for _, e := range events {
+		if e.Effect == 1 {
+			pieces = append(pieces, e)
+		}
+	}
+	if len(pieces) != 2 || pieces[0].FadeOut != 0 || pieces[0].FadeIn == 0 || pieces[1].FadeIn != 0 || pieces[1].FadeOut == 0 {
+		t.Errorf("split solid events = %+v", pieces)
+	}
+}
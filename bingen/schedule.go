@@ -0,0 +1,120 @@
+package bingen
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Schedule block: when an unattended receiver starts the show on its own,
+// by its real-time clock.
+const (
+	ScheduleBlockMagic   = "SCH1"
+	ScheduleBlockVersion = 1
+	ScheduleBlockSize    = BlockHeaderSize + 12
+)
+
+// Schedule repeat modes, as stored in the schedule block.
+const (
+	RepeatOnce   = 0
+	RepeatDaily  = 1
+	RepeatWeekly = 2
+)
+
+// ScheduleTimeLayout is the format of Schedule.Start: local wall-clock time
+// as the receivers' RTC keeps it, without a zone.
+const ScheduleTimeLayout = "2006-01-02T15:04:05"
+
+// Schedule starts the show at a set time, once or repeating, so
+// installations run without a serial trigger.
+type Schedule struct {
+	Start  string `json:"start"`          // ScheduleTimeLayout; seconds may be left out
+	Repeat string `json:"repeat"`         // "", "daily" or "weekly"
+	Days   []int  `json:"days,omitempty"` // weekly: 0=Sunday..6; default the start's weekday
+}
+
+// ParseStart returns the first start time, in UTC to stand for the zoneless
+// RTC time.
+func (s *Schedule) ParseStart() (time.Time, error) {
+	t, err := time.Parse(ScheduleTimeLayout, s.Start)
+	if err != nil {
+		t, err = time.Parse("2006-01-02T15:04", s.Start)
+	}
+	if err != nil {
+		return time.Time{}, fmt.Errorf("start %q is not a date and time like 2026-12-01T18:00", s.Start)
+	}
+	return t, nil
+}
+
+// encode validates s and returns its schedule block payload.
+func (s *Schedule) encode() ([]byte, error) {
+	start, err := s.ParseStart()
+	if err != nil {
+		return nil, err
+	}
+	if start.Year() < 2000 || start.Year() > 2099 {
+		return nil, errors.New("start year must be between 2000 and 2099")
+	}
+
+	var repeat, days byte
+	switch s.Repeat {
+	case "":
+		repeat = RepeatOnce
+	case "daily":
+		repeat = RepeatDaily
+	case "weekly":
+		repeat = RepeatWeekly
+		for _, d := range s.Days {
+			if d < 0 || d > 6 {
+				return nil, fmt.Errorf("weekday %d outside 0 (Sunday) to 6", d)
+			}
+			days |= 1 << d
+		}
+		if days == 0 {
+			days = 1 << start.Weekday()
+		}
+	default:
+		return nil, fmt.Errorf("unknown repeat %q (use daily or weekly)", s.Repeat)
+	}
+
+	payload := make([]byte, ScheduleBlockSize-BlockHeaderSize)
+	binary.LittleEndian.PutUint16(payload, uint16(start.Year()))
+	payload[2], payload[3] = byte(start.Month()), byte(start.Day())
+	payload[4], payload[5], payload[6] = byte(start.Hour()), byte(start.Minute()), byte(start.Second())
+	payload[7], payload[8] = repeat, days
+	return payload, nil
+}
+
+// Next returns up to n start times at or after from, as a receiver with
+// its clock at from would play them. Both are zoneless RTC times in UTC.
+func (s *Schedule) Next(from time.Time, n int) ([]time.Time, error) {
+	payload, err := s.encode()
+	if err != nil {
+		return nil, err
+	}
+	start, _ := s.ParseStart()
+	var out []time.Time
+	switch payload[7] {
+	case RepeatOnce:
+		if !start.Before(from) && n > 0 {
+			out = append(out, start)
+		}
+	default:
+		t := start
+		if t.Before(from) {
+			// Same time of day, on from's date or the day after.
+			days := int(from.Sub(start).Hours() / 24)
+			t = start.AddDate(0, 0, days)
+			for t.Before(from) {
+				t = t.AddDate(0, 0, 1)
+			}
+		}
+		for ; len(out) < n; t = t.AddDate(0, 0, 1) {
+			if payload[7] == RepeatDaily || payload[8]&(1<<t.Weekday()) != 0 {
+				out = append(out, t)
+			}
+		}
+	}
+	return out, nil
+}
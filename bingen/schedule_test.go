@@ -0,0 +1,78 @@
+package bingen_test
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"PicoLume/bingen"
+)
+
+func TestScheduleNext(t *testing.T) {
+	from := time.Date(2026, 12, 2, 12, 0, 0, 0, time.UTC) // a Wednesday
+	tests := []struct {
+		s    bingen.Schedule
+		want []string
+	}{
+		{bingen.Schedule{Start: "2026-12-05T18:00"}, []string{"2026-12-05T18:00:00"}},
+		{bingen.Schedule{Start: "2026-11-01T18:00"}, nil},
+		{bingen.Schedule{Start: "2026-11-01T18:00", Repeat: "daily"}, []string{"2026-12-02T18:00:00", "2026-12-03T18:00:00", "2026-12-04T18:00:00"}},
+		// Weekly on Saturday and Sunday.
+		{bingen.Schedule{Start: "2026-11-01T09:30:15", Repeat: "weekly", Days: []int{6, 0}}, []string{"2026-12-05T09:30:15", "2026-12-06T09:30:15", "2026-12-12T09:30:15"}},
+		// Weekly without days repeats on the start's weekday (a Sunday).
+		{bingen.Schedule{Start: "2026-11-01T09:00", Repeat: "weekly"}, []string{"2026-12-06T09:00:00", "2026-12-13T09:00:00", "2026-12-20T09:00:00"}},
+	}
+	for _, tt := range tests {
+		next, err := tt.s.Next(from, 3)
+		if err != nil {
+			t.Errorf("%+v: error = %v", tt.s, err)
+			continue
+		}
+		var got []string
+		for _, n := range next {
+			got = append(got, n.Format(bingen.ScheduleTimeLayout))
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%+v: Next() = %v, want %v", tt.s, got, tt.want)
+		}
+	}
+
+	for _, s := range []bingen.Schedule{
+		{Start: "tomorrow"},
+		{Start: "2026-12-05T18:00", Repeat: "hourly"},
+		{Start: "2026-12-05T18:00", Repeat: "weekly", Days: []int{7}},
+	} {
+		if _, err := s.Next(from, 1); err == nil {
+			t.Errorf("%+v: want error", s)
+		}
+	}
+}
+
+func TestGenerateScheduleBlock(t *testing.T) {
+	var p bingen.Project
+	if err := json.Unmarshal([]byte(stemProject), &p); err != nil {
+		t.Fatal(err)
+	}
+	plain, err := bingen.Generate(&p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.Settings.Schedule = &bingen.Schedule{Start: "2026-12-24T17:30", Repeat: "weekly", Days: []int{3, 4}}
+	result, err := bingen.Generate(&p)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	block := result.Bytes[len(plain.Bytes):]
+	want := []byte("SCH1\x01\x00\x0c\x00" + "\xea\x07\x0c\x18\x11\x1e\x00" + "\x02\x18\x00\x00\x00")
+	if !reflect.DeepEqual(block, want) {
+		t.Errorf("schedule block = % x, want % x", block, want)
+	}
+
+	p.Settings.Schedule.Start = "Christmas Eve"
+	if _, err := bingen.GenerateContext(context.Background(), &p, bingen.Options{Strict: true}); err == nil {
+		t.Error("strict generation should reject an invalid schedule")
+	}
+}
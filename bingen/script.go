@@ -0,0 +1,538 @@
+package bingen
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// ScriptEffect is the clip type of scripted clips. Their Props.Script is
+// evaluated every Props.Step milliseconds and each result is baked into an
+// event playing Props.Effect (solid if empty), so receivers need no
+// interpreter.
+//
+// A script is a list of assignments separated by newlines or semicolons:
+//
+//	h = p * 360
+//	color = hsv(h, 1, 0.5 + 0.5 * sin(t * pi))
+//	width = clamp(p, 0.1, 1)
+//
+// Assigning color, color2, speed or width sets that event parameter; other
+// names are locals. Expressions use numbers, 0xRRGGBB and #RRGGBB colors,
+// + - * / % ^, parentheses and these variables and functions:
+//
+//	t      seconds since the clip started
+//	p      progress through the clip, 0 to 1
+//	i      keyframe index
+//	dur    clip duration in seconds
+//	pi
+//	sin cos abs floor sqrt min max clamp(x, lo, hi)
+//	rand(x)            repeatable pseudo-random 0 to 1 for x
+//	rgb(r, g, b)       color from 0-255 channels
+//	hsv(h, s, v)       color from hue 0-360, saturation and value 0-1
+//	mix(c1, c2, f)     blend of two colors, f from 0 to 1
+const ScriptEffect = "script"
+
+// Script limits. Steps are clamped into MinScriptStep..MaxTimeMs and
+// lengthened so no clip bakes more than MaxScriptKeyframes events.
+const (
+	DefaultScriptStep  = 100 // ms
+	MinScriptStep      = 20
+	MaxScriptKeyframes = 1000
+	MaxScriptLength    = 4096
+)
+
+// ErrScript is wrapped by script compile errors.
+var ErrScript = errors.New("invalid script")
+
+// scriptOutputs are the names a script assigns to set event parameters.
+var scriptOutputs = []string{"color", "color2", "speed", "width"}
+
+// Script is a compiled clip script.
+type Script struct {
+	stmts []scriptStmt
+}
+
+type scriptStmt struct {
+	name string
+	expr scriptExpr
+}
+
+// scriptExpr evaluates against the variables of one keyframe.
+type scriptExpr func(vars map[string]float64) float64
+
+// CompileScript parses src.
+func CompileScript(src string) (*Script, error) {
+	if len(src) > MaxScriptLength {
+		return nil, fmt.Errorf("%w: longer than %d characters", ErrScript, MaxScriptLength)
+	}
+	toks, err := scanScript(src)
+	if err != nil {
+		return nil, err
+	}
+	ps := &scriptParser{toks: toks, defined: map[string]bool{"t": true, "p": true, "i": true, "dur": true, "pi": true}}
+	s := &Script{}
+	for {
+		for ps.peek().kind == tokEnd {
+			ps.next()
+		}
+		if ps.peek().kind == tokEOF {
+			break
+		}
+		name := ps.next()
+		if name.kind != tokIdent {
+			return nil, ps.errorf(name, "expected a name to assign")
+		}
+		if _, builtin := scriptFuncs[name.text]; builtin || name.text == "pi" {
+			return nil, ps.errorf(name, "cannot assign to %s", name.text)
+		}
+		if eq := ps.next(); eq.text != "=" {
+			return nil, ps.errorf(eq, "expected = after %s", name.text)
+		}
+		expr, err := ps.expr()
+		if err != nil {
+			return nil, err
+		}
+		if t := ps.next(); t.kind != tokEnd && t.kind != tokEOF {
+			return nil, ps.errorf(t, "unexpected %q", t.text)
+		}
+		ps.defined[name.text] = true
+		s.stmts = append(s.stmts, scriptStmt{name.text, expr})
+	}
+	if len(s.stmts) == 0 {
+		return nil, fmt.Errorf("%w: empty", ErrScript)
+	}
+	return s, nil
+}
+
+// eval runs the script for one keyframe, storing assignments in vars.
+func (s *Script) eval(vars map[string]float64) {
+	for _, st := range s.stmts {
+		vars[st.name] = st.expr(vars)
+	}
+}
+
+func init() { RegisterClipType(ScriptEffect, scriptEncoder{}) }
+
+// scriptEncoder bakes script clips into keyframes.
+type scriptEncoder struct{}
+
+func (scriptEncoder) Validate(clip Clip) []FieldError {
+	if _, err := CompileScript(clip.Props.Script); err != nil {
+		return []FieldError{{Field: "script", Value: clip.Props.Script, Err: err}}
+	}
+	return nil
+}
+
+// CountEvents returns how many keyframes Encode evaluates for clip; merged
+// identical neighbours make the real count lower.
+func (scriptEncoder) CountEvents(clip Clip) int {
+	if _, err := CompileScript(clip.Props.Script); err != nil || checkTime(clip.StartTime) != nil || checkTime(clip.Duration) != nil || clip.Duration == 0 {
+		return 1
+	}
+	start, end := uint32(clip.StartTime), uint32(clip.StartTime+clip.Duration)
+	step := uint32(scriptStep(clip))
+	return int((end - start + step - 1) / step)
+}
+
+// scriptStep returns the keyframe interval of clip in whole ms.
+func scriptStep(clip Clip) float64 {
+	step := clip.Props.Step
+	if step <= 0 || math.IsNaN(step) {
+		step = DefaultScriptStep
+	}
+	return math.Ceil(math.Min(max(step, MinScriptStep, clip.Duration/MaxScriptKeyframes), MaxTimeMs))
+}
+
+// Encode returns the keyframes of clip. A script that does not compile
+// plays as a plain Props.Effect clip, as does a clip with unusable timing
+// (AnalyzePower sees raw clips).
+func (scriptEncoder) Encode(clip Clip) []Event {
+	base := clip
+	base.Type = clip.Props.Effect
+	if base.Type == "" {
+		base.Type = "solid"
+	}
+	static := clipEvent(base)
+	static.StartTime, static.Duration = uint32(clip.StartTime), uint32(clip.Duration)
+
+	s, err := CompileScript(clip.Props.Script)
+	if err != nil || checkTime(clip.StartTime) != nil || checkTime(clip.Duration) != nil || clip.Duration == 0 {
+		return []Event{static}
+	}
+
+	step := scriptStep(clip)
+
+	start, end := uint32(clip.StartTime), uint32(clip.StartTime+clip.Duration)
+	var out []Event
+	vars := make(map[string]float64)
+	for i := 0; ; i++ {
+		at := start + uint32(i)*uint32(step)
+		if at >= end {
+			break
+		}
+		clear(vars)
+		vars["t"] = float64(at-start) / 1000
+		vars["p"] = float64(at-start) / clip.Duration
+		vars["i"] = float64(i)
+		vars["dur"] = clip.Duration / 1000
+		vars["pi"] = math.Pi
+		s.eval(vars)
+
+		e := static
+		e.StartTime, e.Duration = at, uint32(min(int(step), int(end-at)))
+		for _, name := range scriptOutputs {
+			v, ok := vars[name]
+			if !ok {
+				continue
+			}
+			switch name {
+			case "color":
+				e.Color = scriptColor(v)
+			case "color2":
+				e.Color2 = scriptColor(v)
+			case "speed":
+				e.Speed = uint8(math.Max(0, math.Min(255, finite(v)*50)))
+			case "width":
+				e.Width = uint8(math.Max(0, math.Min(1, finite(v))) * 255)
+			}
+		}
+
+		// Hold unchanged keyframes instead of repeating them.
+		if n := len(out); n > 0 {
+			prev := out[n-1]
+			prev.Duration = e.Duration
+			prev.StartTime = e.StartTime
+			if prev == e {
+				out[n-1].Duration += e.Duration
+				continue
+			}
+		}
+		out = append(out, e)
+	}
+	return out
+}
+
+// finite maps NaN and infinities, such as from a division by zero, to 0.
+func finite(v float64) float64 {
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return 0
+	}
+	return v
+}
+
+func scriptColor(v float64) uint32 {
+	return uint32(math.Max(0, math.Min(0xFFFFFF, math.Round(finite(v)))))
+}
+
+func scriptChannels(c float64) (r, g, b float64) {
+	n := scriptColor(c)
+	return float64(n >> 16 & 0xFF), float64(n >> 8 & 0xFF), float64(n & 0xFF)
+}
+
+func packRGB(r, g, b float64) float64 {
+	ch := func(v float64) float64 { return math.Round(math.Max(0, math.Min(255, finite(v)))) }
+	return ch(r)*65536 + ch(g)*256 + ch(b)
+}
+
+// scriptFuncs maps function names to their arity and implementation.
+var scriptFuncs = map[string]struct {
+	args int
+	fn   func(a []float64) float64
+}{
+	"sin":   {1, func(a []float64) float64 { return math.Sin(a[0]) }},
+	"cos":   {1, func(a []float64) float64 { return math.Cos(a[0]) }},
+	"abs":   {1, func(a []float64) float64 { return math.Abs(a[0]) }},
+	"floor": {1, func(a []float64) float64 { return math.Floor(a[0]) }},
+	"sqrt":  {1, func(a []float64) float64 { return math.Sqrt(a[0]) }},
+	"min":   {2, func(a []float64) float64 { return math.Min(a[0], a[1]) }},
+	"max":   {2, func(a []float64) float64 { return math.Max(a[0], a[1]) }},
+	"clamp": {3, func(a []float64) float64 { return math.Max(a[1], math.Min(a[2], a[0])) }},
+	"rand": {1, func(a []float64) float64 {
+		// splitmix64 of the value's bits
+		x := math.Float64bits(a[0]) + 0x9E3779B97F4A7C15
+		x = (x ^ x>>30) * 0xBF58476D1CE4E5B9
+		x = (x ^ x>>27) * 0x94D049BB133111EB
+		x ^= x >> 31
+		return float64(x>>11) / (1 << 53)
+	}},
+	"rgb": {3, func(a []float64) float64 { return packRGB(a[0], a[1], a[2]) }},
+	"hsv": {3, func(a []float64) float64 {
+		h := math.Mod(finite(a[0]), 360)
+		if h < 0 {
+			h += 360
+		}
+		s, v := math.Max(0, math.Min(1, a[1])), math.Max(0, math.Min(1, a[2]))
+		c := v * s
+		x := c * (1 - math.Abs(math.Mod(h/60, 2)-1))
+		var r, g, b float64
+		switch {
+		case h < 60:
+			r, g = c, x
+		case h < 120:
+			r, g = x, c
+		case h < 180:
+			g, b = c, x
+		case h < 240:
+			g, b = x, c
+		case h < 300:
+			r, b = x, c
+		default:
+			r, b = c, x
+		}
+		m := v - c
+		return packRGB((r+m)*255, (g+m)*255, (b+m)*255)
+	}},
+	"mix": {3, func(a []float64) float64 {
+		r1, g1, b1 := scriptChannels(a[0])
+		r2, g2, b2 := scriptChannels(a[1])
+		f := math.Max(0, math.Min(1, finite(a[2])))
+		return packRGB(r1+(r2-r1)*f, g1+(g2-g1)*f, b1+(b2-b1)*f)
+	}},
+}
+
+// Script tokens.
+const (
+	tokEOF = iota
+	tokEnd // newline or semicolon
+	tokNumber
+	tokIdent
+	tokOp
+)
+
+type scriptToken struct {
+	kind int
+	text string
+	num  float64
+	line int
+}
+
+func scanScript(src string) ([]scriptToken, error) {
+	var toks []scriptToken
+	line := 1
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case c == '\n' || c == ';':
+			toks = append(toks, scriptToken{kind: tokEnd, text: string(c), line: line})
+			if c == '\n' {
+				line++
+			}
+			i++
+		case c == ' ' || c == '\t' || c == '\r':
+			i++
+		case c == '/' && i+1 < len(src) && src[i+1] == '/':
+			for i < len(src) && src[i] != '\n' {
+				i++
+			}
+		case c == '#':
+			j := i + 1
+			for j < len(src) && isHexDigit(src[j]) {
+				j++
+			}
+			v, err := strconv.ParseUint(src[i+1:j], 16, 32)
+			if err != nil || j-i != 7 {
+				return nil, fmt.Errorf("%w: line %d: bad color %q", ErrScript, line, src[i:j])
+			}
+			toks = append(toks, scriptToken{kind: tokNumber, text: src[i:j], num: float64(v), line: line})
+			i = j
+		case c >= '0' && c <= '9' || c == '.':
+			j := i + 1
+			if c == '0' && j < len(src) && (src[j] == 'x' || src[j] == 'X') {
+				j++
+				for j < len(src) && isHexDigit(src[j]) {
+					j++
+				}
+			} else {
+				for j < len(src) && (src[j] >= '0' && src[j] <= '9' || src[j] == '.') {
+					j++
+				}
+			}
+			v, err := strconv.ParseFloat(src[i:j], 64)
+			if strings.HasPrefix(src[i:j], "0x") || strings.HasPrefix(src[i:j], "0X") {
+				var u uint64
+				u, err = strconv.ParseUint(src[i+2:j], 16, 32)
+				v = float64(u)
+			}
+			if err != nil {
+				return nil, fmt.Errorf("%w: line %d: bad number %q", ErrScript, line, src[i:j])
+			}
+			toks = append(toks, scriptToken{kind: tokNumber, text: src[i:j], num: v, line: line})
+			i = j
+		case isNameByte(c):
+			j := i + 1
+			for j < len(src) && (isNameByte(src[j]) || src[j] >= '0' && src[j] <= '9') {
+				j++
+			}
+			toks = append(toks, scriptToken{kind: tokIdent, text: src[i:j], line: line})
+			i = j
+		case strings.IndexByte("+-*/%^()=,", c) >= 0:
+			toks = append(toks, scriptToken{kind: tokOp, text: string(c), line: line})
+			i++
+		default:
+			return nil, fmt.Errorf("%w: line %d: unexpected %q", ErrScript, line, c)
+		}
+	}
+	return append(toks, scriptToken{kind: tokEOF, text: "end of script", line: line}), nil
+}
+
+func isNameByte(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+func isHexDigit(c byte) bool {
+	return c >= '0' && c <= '9' || c >= 'a' && c <= 'f' || c >= 'A' && c <= 'F'
+}
+
+// scriptParser is a recursive descent parser over tokens. defined holds
+// the variables assigned so far, so misspelled names fail to compile
+// instead of reading as 0.
+type scriptParser struct {
+	toks    []scriptToken
+	pos     int
+	defined map[string]bool
+}
+
+func (ps *scriptParser) peek() scriptToken { return ps.toks[ps.pos] }
+
+func (ps *scriptParser) next() scriptToken {
+	t := ps.toks[ps.pos]
+	if t.kind != tokEOF {
+		ps.pos++
+	}
+	return t
+}
+
+func (ps *scriptParser) errorf(t scriptToken, format string, args ...any) error {
+	return fmt.Errorf("%w: line %d: %s", ErrScript, t.line, fmt.Sprintf(format, args...))
+}
+
+// expr := term { ("+" | "-") term }
+func (ps *scriptParser) expr() (scriptExpr, error) {
+	return ps.binary(ps.term, "+-")
+}
+
+// term := power { ("*" | "/" | "%") power }
+func (ps *scriptParser) term() (scriptExpr, error) {
+	return ps.binary(ps.power, "*/%")
+}
+
+func (ps *scriptParser) binary(operand func() (scriptExpr, error), ops string) (scriptExpr, error) {
+	left, err := operand()
+	if err != nil {
+		return nil, err
+	}
+	for t := ps.peek(); t.kind == tokOp && strings.Contains(ops, t.text); t = ps.peek() {
+		ps.next()
+		right, err := operand()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		switch t.text {
+		case "+":
+			left = func(v map[string]float64) float64 { return l(v) + right(v) }
+		case "-":
+			left = func(v map[string]float64) float64 { return l(v) - right(v) }
+		case "*":
+			left = func(v map[string]float64) float64 { return l(v) * right(v) }
+		case "/":
+			left = func(v map[string]float64) float64 { return l(v) / right(v) }
+		case "%":
+			left = func(v map[string]float64) float64 { return math.Mod(l(v), right(v)) }
+		}
+	}
+	return left, nil
+}
+
+// power := unary [ "^" power ]
+func (ps *scriptParser) power() (scriptExpr, error) {
+	base, err := ps.unary()
+	if err != nil {
+		return nil, err
+	}
+	if t := ps.peek(); t.kind == tokOp && t.text == "^" {
+		ps.next()
+		exp, err := ps.power()
+		if err != nil {
+			return nil, err
+		}
+		return func(v map[string]float64) float64 { return math.Pow(base(v), exp(v)) }, nil
+	}
+	return base, nil
+}
+
+// unary := "-" unary | primary
+func (ps *scriptParser) unary() (scriptExpr, error) {
+	if t := ps.peek(); t.kind == tokOp && t.text == "-" {
+		ps.next()
+		x, err := ps.unary()
+		if err != nil {
+			return nil, err
+		}
+		return func(v map[string]float64) float64 { return -x(v) }, nil
+	}
+	return ps.primary()
+}
+
+// primary := number | name | name "(" args ")" | "(" expr ")"
+func (ps *scriptParser) primary() (scriptExpr, error) {
+	t := ps.next()
+	switch {
+	case t.kind == tokNumber:
+		n := t.num
+		return func(map[string]float64) float64 { return n }, nil
+	case t.kind == tokOp && t.text == "(":
+		x, err := ps.expr()
+		if err != nil {
+			return nil, err
+		}
+		if c := ps.next(); c.text != ")" {
+			return nil, ps.errorf(c, "expected )")
+		}
+		return x, nil
+	case t.kind == tokIdent && ps.peek().text == "(":
+		return ps.call(t)
+	case t.kind == tokIdent:
+		if !ps.defined[t.text] {
+			return nil, ps.errorf(t, "unknown name %s", t.text)
+		}
+		name := t.text
+		return func(v map[string]float64) float64 { return v[name] }, nil
+	}
+	return nil, ps.errorf(t, "unexpected %q", t.text)
+}
+
+func (ps *scriptParser) call(name scriptToken) (scriptExpr, error) {
+	f, ok := scriptFuncs[name.text]
+	if !ok {
+		return nil, ps.errorf(name, "unknown function %s", name.text)
+	}
+	ps.next() // (
+	var args []scriptExpr
+	for ps.peek().text != ")" {
+		if len(args) > 0 {
+			if c := ps.next(); c.text != "," {
+				return nil, ps.errorf(c, "expected , or ) in %s()", name.text)
+			}
+		}
+		a, err := ps.expr()
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, a)
+	}
+	ps.next() // )
+	if len(args) != f.args {
+		return nil, ps.errorf(name, "%s() takes %d arguments, not %d", name.text, f.args, len(args))
+	}
+	return func(v map[string]float64) float64 {
+		vals := make([]float64, len(args))
+		for i, a := range args {
+			vals[i] = a(v)
+		}
+		return f.fn(vals)
+	}, nil
+}
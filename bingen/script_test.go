@@ -0,0 +1,72 @@
+package bingen_test
+
+import (
+	"errors"
+	"testing"
+
+	"PicoLume/bingen"
+	"PicoLume/bingen/bintest"
+)
+
+func TestCompileScript(t *testing.T) {
+	for _, src := range []string{
+		"color = hsv(p * 360, 1, 1)",
+		"h = t * 90; color = mix(#ff0000, 0x0000FF, p) // fade\nwidth = clamp(h, 0.1, 1)",
+		"speed = 2 ^ -1 + rand(i) * (1 - abs(sin(t * pi)))",
+	} {
+		if _, err := bingen.CompileScript(src); err != nil {
+			t.Errorf("CompileScript(%q) error = %v", src, err)
+		}
+	}
+	for _, src := range []string{"", "color =", "color = hue", "color = hsv(1, 2)", "sin = 1", "color = 1 2", "color = #ff00"} {
+		if _, err := bingen.CompileScript(src); !errors.Is(err, bingen.ErrScript) {
+			t.Errorf("CompileScript(%q) error = %v, want ErrScript", src, err)
+		}
+	}
+}
+
+func TestGenerateScriptClip(t *testing.T) {
+	p := &bingen.Project{
+		Settings:   bingen.Settings{ShowDuration: 1000},
+		PropGroups: []bingen.PropGroup{{ID: "g", IDs: "1"}},
+		Tracks: []bingen.Track{{Type: "led", GroupId: "g", Clips: []bingen.Clip{{
+			StartTime: 0, Duration: 1000, Type: bingen.ScriptEffect,
+			// Red for the first half, then blue; the keyframes within each
+			// half are identical and merge.
+			Props: bingen.ClipProps{Script: "color = rgb(255 * (1 - floor(p * 2)), 0, 255 * floor(p * 2))", Step: 250},
+		}}}},
+	}
+	result, err := bingen.Generate(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Warnings) != 0 {
+		t.Fatalf("warnings = %v", result.Warnings)
+	}
+	events, err := bintest.Events(result.Bytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []struct {
+		start, dur uint32
+		color      uint32
+	}{{0, 500, 0xFF0000}, {500, 500, 0x0000FF}}
+	if len(events) != len(want) {
+		t.Fatalf("got %d events, want %d: %+v", len(events), len(want), events)
+	}
+	for i, w := range want {
+		e := events[i]
+		if e.StartTime != w.start || e.Duration != w.dur || e.Color != w.color || e.Effect != 1 {
+			t.Errorf("event %d = %d+%d ms effect %d color %06x, want %d+%d ms solid %06x", i, e.StartTime, e.Duration, e.Effect, e.Color, w.start, w.dur, w.color)
+		}
+	}
+
+	p.Tracks[0].Clips[0].Props.Script = "color = hue"
+	result, err = bingen.Generate(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if events, _ := bintest.Events(result.Bytes); len(result.Warnings) != 1 || len(events) != 1 {
+		t.Errorf("bad script: %d events, warnings %v; want one plain event and a warning", len(events), result.Warnings)
+	}
+}
@@ -0,0 +1,64 @@
+package bingen
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+)
+
+// Standby block: the look receivers show before the show starts and after
+// it ends, instead of going dark or holding the last frame.
+const (
+	StandbyBlockMagic   = "IDL1"
+	StandbyBlockVersion = 1
+	StandbyBlockSize    = BlockHeaderSize + 16
+)
+
+// Standby is the idle look, such as a slow breathe at 10% in the venue's
+// color. It plays on every prop within its brightness cap.
+type Standby struct {
+	Type       string    `json:"type"`       // effect, as a clip type; "" for none
+	Props      ClipProps `json:"props"`      // color, color2, speed and width as for clips
+	Brightness float64   `json:"brightness"` // 0-1, scales each prop's brightness cap
+}
+
+// encode returns the standby block payload, with a FieldError for each
+// value it had to fix.
+func (s *Standby) encode() ([]byte, []FieldError) {
+	var errs []FieldError
+	report := func(field string, value any, err error) {
+		errs = append(errs, FieldError{Track: -1, Clip: -1, Field: "standby " + field, Value: value, Err: err})
+	}
+
+	known := false
+	for _, e := range effects {
+		known = known || e.Name == s.Type
+	}
+	if !known {
+		report("type", s.Type, errors.New("unknown effect"))
+	}
+	for _, c := range []struct{ field, value string }{{"color", s.Props.Color}, {"color2", s.Props.Color2}} {
+		if c.value != "" {
+			if _, err := ParseColorValue(c.value); err != nil {
+				report(c.field, c.value, err)
+			}
+		}
+	}
+	brightness := s.Brightness
+	if math.IsNaN(brightness) || brightness < 0 || brightness > 1 {
+		report("brightness", s.Brightness, errors.New("outside 0 to 1"))
+		if brightness > 1 {
+			brightness = 1
+		} else {
+			brightness = 0
+		}
+	}
+
+	e := clipEvent(Clip{Type: s.Type, Props: s.Props})
+	payload := make([]byte, StandbyBlockSize-BlockHeaderSize)
+	payload[0], payload[1], payload[2] = e.Effect, e.Speed, e.Width
+	payload[3] = uint8(math.Round(brightness * 255))
+	binary.LittleEndian.PutUint32(payload[4:], e.Color)
+	binary.LittleEndian.PutUint32(payload[8:], e.Color2)
+	return payload, errs
+}
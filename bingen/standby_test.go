@@ -0,0 +1,45 @@
+package bingen_test
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"PicoLume/bingen"
+)
+
+func TestGenerateStandbyBlock(t *testing.T) {
+	var p bingen.Project
+	if err := json.Unmarshal([]byte(stemProject), &p); err != nil {
+		t.Fatal(err)
+	}
+	plain, err := bingen.Generate(&p)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p.Settings.Standby = &bingen.Standby{Type: "breathe", Props: bingen.ClipProps{Color: "#FF8000", Speed: 0.2}, Brightness: 0.1}
+	result, err := bingen.Generate(&p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	block := result.Bytes[len(plain.Bytes):]
+	want := []byte("IDL1\x01\x00\x10\x00" + "\x11\x0a\x00\x1a" + "\x00\x80\xff\x00" + "\x00\x00\x00\x00" + "\x00\x00\x00\x00")
+	if !reflect.DeepEqual(block, want) {
+		t.Errorf("standby block = % x, want % x", block, want)
+	}
+
+	p.Settings.Standby = &bingen.Standby{Type: "glow", Brightness: 2}
+	result, err = bingen.Generate(&p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Warnings) != 2 {
+		t.Errorf("warnings = %v, want unknown type and brightness", result.Warnings)
+	}
+
+	p.Settings.Standby = &bingen.Standby{}
+	if result, _ := bingen.Generate(&p); len(result.Bytes) != len(plain.Bytes) {
+		t.Error("a standby without a type should not write a block")
+	}
+}
@@ -0,0 +1,99 @@
+package bingen_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"PicoLume/bingen"
+	"PicoLume/bingen/fixtures"
+)
+
+// chunks records each Write, failing once it has taken limit bytes if
+// limit is set.
+type chunks struct {
+	bytes.Buffer
+	sizes []int
+	limit int
+}
+
+func (c *chunks) Write(p []byte) (int, error) {
+	if c.limit > 0 && c.Len()+len(p) > c.limit {
+		return 0, errors.New("disk full")
+	}
+	c.sizes = append(c.sizes, len(p))
+	return c.Buffer.Write(p)
+}
+
+func TestGenerateToMatchesGenerate(t *testing.T) {
+	all, err := fixtures.All()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, fx := range all {
+		want, err := bingen.GenerateFromJSON(string(fx.Project))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var p bingen.Project
+		if err := json.Unmarshal(fx.Project, &p); err != nil {
+			t.Fatal(err)
+		}
+		var w chunks
+		result, err := bingen.GenerateTo(&w, &p)
+		if err != nil {
+			t.Fatalf("%s: GenerateTo() error = %v", fx.Name, err)
+		}
+		if !bytes.Equal(w.Bytes(), want.Bytes) || result.Bytes != nil || result.Size != int64(len(want.Bytes)) || result.EventCount != want.EventCount {
+			t.Errorf("%s: GenerateTo() wrote %d bytes (Size %d), want Generate's %d", fx.Name, w.Len(), result.Size, len(want.Bytes))
+		}
+	}
+}
+
+func TestGenerateToStreams(t *testing.T) {
+	p := &bingen.Project{
+		Settings:   bingen.Settings{ShowDuration: 1000},
+		PropGroups: []bingen.PropGroup{{ID: "g1", IDs: "1-224"}},
+		Tracks:     []bingen.Track{{Type: "led", GroupId: "g1"}},
+	}
+	for i := 0; i < 5000; i++ {
+		p.Tracks[0].Clips = append(p.Tracks[0].Clips, bingen.Clip{StartTime: float64(i * 10), Duration: 5, Type: "solid", Props: bingen.ClipProps{Color: "#FF0000"}})
+	}
+	opts := bingen.Options{FormatVersion: bingen.FormatV4, Checksum: true}
+	want, err := bingen.GenerateContext(context.Background(), p, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var w chunks
+	if _, err := bingen.GenerateToContext(context.Background(), &w, p, opts); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(w.Bytes(), want.Bytes) {
+		t.Fatal("streamed show.bin differs from GenerateContext")
+	}
+	if _, err := bingen.VerifyChecksum(w.Bytes()); err != nil {
+		t.Errorf("VerifyChecksum() = %v", err)
+	}
+	largest := 0
+	for _, n := range w.sizes {
+		largest = max(largest, n)
+	}
+	if len(w.sizes) < 10 || largest > 33<<10 {
+		t.Errorf("%d writes of up to %d bytes, want chunks of about 32 KB", len(w.sizes), largest)
+	}
+
+	full := chunks{limit: 100 << 10}
+	if _, err := bingen.GenerateToContext(context.Background(), &full, p, opts); err == nil {
+		t.Error("GenerateToContext() ignored a failed write")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	var untouched chunks
+	if _, err := bingen.GenerateToContext(ctx, &untouched, p, opts); !errors.Is(err, context.Canceled) || untouched.Len() != 0 {
+		t.Errorf("cancelled GenerateToContext() = %v after writing %d bytes", err, untouched.Len())
+	}
+}
@@ -0,0 +1,101 @@
+package bingen
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// Default step lengths of GenerateTestPattern.
+const (
+	DefaultBlinkMs = 500
+	DefaultColorMs = 2000
+	DefaultSoakMs  = 5 * 60 * 1000
+)
+
+// TestPatternOptions configures GenerateTestPattern.
+type TestPatternOptions struct {
+	Props   string          `json:"props"`   // prop IDs to test, e.g. "1-24"
+	Profile HardwareProfile `json:"profile"` // LED setup of those props; AssignedIds is ignored
+	BlinkMs int             `json:"blinkMs"` // per prop; 0 for DefaultBlinkMs
+	ColorMs int             `json:"colorMs"` // per color; 0 for DefaultColorMs
+	SoakMs  int             `json:"soakMs"`  // 0 for DefaultSoakMs, negative to leave out
+
+	FormatVersion int `json:"formatVersion"` // as Options.FormatVersion
+}
+
+// GenerateTestPattern builds a diagnostic show for validating newly built
+// props without authoring a project:
+//
+//  1. each prop lights white alone, in ID order, to check addressing;
+//  2. all props show red, green, then blue, to check the color order;
+//  3. all props stay full white, as a thermal and power soak.
+func GenerateTestPattern(opts TestPatternOptions) (*Result, error) {
+	p, err := testPatternProject(opts)
+	if err != nil {
+		return nil, err
+	}
+	return GenerateContext(context.Background(), p, Options{FormatVersion: opts.FormatVersion})
+}
+
+func testPatternProject(opts TestPatternOptions) (*Project, error) {
+	ids := ParseIDRange(opts.Props)
+	if len(ids) == 0 {
+		return nil, errors.New("no props to test")
+	}
+	if opts.Profile.LedCount <= 0 {
+		return nil, errors.New("LED count must be set")
+	}
+	blink, color, soak := opts.BlinkMs, opts.ColorMs, opts.SoakMs
+	if blink <= 0 {
+		blink = DefaultBlinkMs
+	}
+	if color <= 0 {
+		color = DefaultColorMs
+	}
+	switch {
+	case soak == 0:
+		soak = DefaultSoakMs
+	case soak < 0:
+		soak = 0
+	}
+
+	prof := opts.Profile
+	prof.ID, prof.AssignedIds = "test", opts.Props
+	if prof.Name == "" {
+		prof.Name = "Test"
+	}
+	if prof.BrightnessCap == 0 {
+		prof.BrightnessCap = 255
+	}
+	p := &Project{
+		Settings:   Settings{Profiles: []HardwareProfile{prof}, Patch: map[string]string{}},
+		PropGroups: []PropGroup{{ID: "all", Name: "All", IDs: opts.Props}},
+	}
+
+	// 1. ID blink: one track per prop.
+	t := 0.0
+	for _, id := range ids {
+		gid := "prop" + strconv.Itoa(id)
+		p.PropGroups = append(p.PropGroups, PropGroup{ID: gid, Name: fmt.Sprintf("Prop %d", id), IDs: strconv.Itoa(id)})
+		p.Tracks = append(p.Tracks, Track{Type: "led", GroupId: gid, Clips: []Clip{
+			{StartTime: t, Duration: float64(blink), Type: "solid", Props: ClipProps{Color: "#FFFFFF"}},
+		}})
+		t += float64(blink)
+	}
+
+	// 2. Color order, then 3. soak, on all props.
+	all := Track{Type: "led", GroupId: "all"}
+	for _, c := range []string{"#FF0000", "#00FF00", "#0000FF"} {
+		all.Clips = append(all.Clips, Clip{StartTime: t, Duration: float64(color), Type: "solid", Props: ClipProps{Color: c}})
+		t += float64(color)
+	}
+	if soak > 0 {
+		all.Clips = append(all.Clips, Clip{StartTime: t, Duration: float64(soak), Type: "solid", Props: ClipProps{Color: "#FFFFFF"}})
+		t += float64(soak)
+	}
+	p.Tracks = append(p.Tracks, all)
+	p.Settings.ShowDuration = t
+	return p, nil
+}
@@ -0,0 +1,65 @@
+package bingen_test
+
+import (
+	"testing"
+
+	"PicoLume/bingen"
+	"PicoLume/bingen/bintest"
+)
+
+func TestGenerateTestPattern(t *testing.T) {
+	result, err := bingen.GenerateTestPattern(bingen.TestPatternOptions{
+		Props:   "1-3",
+		Profile: bingen.HardwareProfile{LedCount: 50, ColorOrder: bingen.ColorOrderRGB},
+		SoakMs:  60000,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	events, err := bintest.Events(result.Bytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Each prop alone, in order.
+	for i := 0; i < 3; i++ {
+		var lit *bingen.Event
+		for j := range events {
+			e := &events[j]
+			if e.Effect != 0 && e.StartTime == uint32(i*bingen.DefaultBlinkMs) {
+				lit = e
+			}
+		}
+		if lit == nil || lit.Mask[0] != 1<<i || lit.Color != 0xFFFFFF {
+			t.Errorf("step %d: event = %+v, want prop %d white", i, lit, i+1)
+		}
+	}
+
+	// Then red, green, blue and the soak on all three.
+	var all []uint32
+	for _, e := range events {
+		if e.Effect != 0 && e.Mask[0] == 0b111 {
+			all = append(all, e.Color)
+		}
+	}
+	want := []uint32{0xFF0000, 0x00FF00, 0x0000FF, 0xFFFFFF}
+	if len(all) != len(want) {
+		t.Fatalf("all-prop colors = %06x, want %06x", all, want)
+	}
+	for i := range want {
+		if all[i] != want[i] {
+			t.Errorf("all-prop colors = %06x, want %06x", all, want)
+			break
+		}
+	}
+
+	// The LUT carries the profile.
+	lut := result.Bytes[bingen.HeaderSize:]
+	if lut[0] != 50 || lut[3] != bingen.ColorOrderRGB || lut[3*bingen.PropConfigSize] != 164 {
+		t.Errorf("LUT = % x, want 50 RGB LEDs on props 1-3 only", lut[:4*bingen.PropConfigSize])
+	}
+
+	if _, err := bingen.GenerateTestPattern(bingen.TestPatternOptions{Props: "", Profile: bingen.HardwareProfile{LedCount: 1}}); err == nil {
+		t.Error("no props should fail")
+	}
+}
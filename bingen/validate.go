@@ -0,0 +1,281 @@
+package bingen
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// MaxTimeMs is the latest time a show.bin can address. Event times are
+// uint32 milliseconds on the wire; anything past a day is a broken project
+// rather than a real show.
+const MaxTimeMs = 24 * 60 * 60 * 1000
+
+// Reasons a time or duration is rejected, for use with errors.Is.
+var (
+	ErrNotFinite   = errors.New("not a finite number")
+	ErrNegative    = errors.New("negative")
+	ErrNotPositive = errors.New("must be greater than zero")
+	ErrTooLarge    = fmt.Errorf("later than %d ms", MaxTimeMs)
+)
+
+// FieldError reports one invalid value. Track and Clip index the project's
+// tracks and that track's clips as stored; both are -1 for fields outside
+// a clip.
+type FieldError struct {
+	Track int
+	Clip  int
+	Field string // e.g. "startTime", "duration", "color", "showDuration"
+	Value any    // the offending number or string
+	Err   error  // one of the Err* sentinels, possibly wrapped
+}
+
+func (e *FieldError) Error() string {
+	where := e.Field
+	if e.Clip >= 0 {
+		where = fmt.Sprintf("track %d clip %d %s", e.Track+1, e.Clip+1, e.Field)
+	}
+	return fmt.Sprintf("%s %v: %v", where, e.Value, e.Err)
+}
+
+func (e *FieldError) Unwrap() error { return e.Err }
+
+// ValidationError collects every FieldError found in strict mode.
+type ValidationError struct {
+	Errors []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	const shown = 5
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "project has %d invalid value(s): ", len(e.Errors))
+	for i := range e.Errors {
+		if i == shown {
+			fmt.Fprintf(&sb, "; and %d more", len(e.Errors)-shown)
+			break
+		}
+		if i > 0 {
+			sb.WriteString("; ")
+		}
+		sb.WriteString(e.Errors[i].Error())
+	}
+	return sb.String()
+}
+
+// Unwrap exposes the individual errors to errors.Is and errors.As.
+func (e *ValidationError) Unwrap() []error {
+	errs := make([]error, len(e.Errors))
+	for i := range e.Errors {
+		errs[i] = &e.Errors[i]
+	}
+	return errs
+}
+
+// checkTime returns why v is not a usable time, or nil.
+func checkTime(v float64) error {
+	switch {
+	case math.IsNaN(v) || math.IsInf(v, 0):
+		return ErrNotFinite
+	case v < 0:
+		return ErrNegative
+	case v > MaxTimeMs:
+		return ErrTooLarge
+	}
+	return nil
+}
+
+// sanitizeClips returns the clips of track ti that can be encoded, clamped
+// into 0..MaxTimeMs, along with a FieldError for every value it had to fix.
+// Clips without a usable start or with no remaining duration are dropped;
+// unrecognized colors are reported and encode as black, and unusable fades
+// as hard cuts.
+func sanitizeClips(ti int, in []Clip) ([]Clip, []FieldError) {
+	var out []Clip
+	var errs []FieldError
+	for ci, clip := range in {
+		report := func(field string, v any, err error) {
+			errs = append(errs, FieldError{Track: ti, Clip: ci, Field: field, Value: v, Err: err})
+		}
+
+		for _, c := range []struct{ field, value string }{
+			{"color", clip.Props.Color},
+			{"color2", clip.Props.Color2},
+			{"colorA", clip.Props.ColorA},
+			{"colorB", clip.Props.ColorB},
+			{"colorStart", clip.Props.ColorStart},
+		} {
+			if c.value == "" {
+				continue
+			}
+			if _, err := ParseColorValue(c.value); err != nil {
+				report(c.field, c.value, ErrBadColor)
+			}
+		}
+
+		for _, f := range []struct {
+			field string
+			value float64
+		}{
+			{"fadeIn", clip.Props.FadeIn},
+			{"fadeOut", clip.Props.FadeOut},
+		} {
+			if math.IsNaN(f.value) || math.IsInf(f.value, 0) {
+				report(f.field, f.value, ErrNotFinite)
+			} else if f.value < 0 {
+				report(f.field, f.value, ErrNegative)
+			}
+		}
+		if e := clip.Props.Easing; e != "" && e != easings[easingCode(e)] {
+			report("easing", e, ErrBadEasing)
+		}
+
+		errs = append(errs, checkColorStops(ti, ci, clip)...)
+		errs = append(errs, validateClip(ti, ci, clip)...)
+
+		startErr := checkTime(clip.StartTime)
+		durErr := checkTime(clip.Duration)
+		if durErr == nil && clip.Duration == 0 {
+			durErr = ErrNotPositive
+		}
+		if startErr != nil {
+			report("startTime", clip.StartTime, startErr)
+		}
+		if durErr != nil {
+			report("duration", clip.Duration, durErr)
+		}
+		if startErr == ErrNotFinite || (durErr != nil && durErr != ErrTooLarge) {
+			continue
+		}
+
+		// A clip starting before zero keeps its end time; one running past
+		// the limit is cut there.
+		end := math.Min(clip.StartTime+clip.Duration, MaxTimeMs)
+		clip.StartTime = math.Max(clip.StartTime, 0)
+		if end <= clip.StartTime {
+			continue
+		}
+		clip.Duration = end - clip.StartTime
+		out = append(out, clip)
+	}
+	return out, errs
+}
+
+// checkProfile returns a FieldError for each hardware profile setting that
+// cannot be encoded; Generate leaves those settings out.
+func checkProfile(prof *HardwareProfile) []FieldError {
+	var errs []FieldError
+	report := func(field string, value int, err error) {
+		errs = append(errs, FieldError{Track: -1, Clip: -1, Field: "profile " + prof.Name + " " + field, Value: value, Err: err})
+	}
+	if prof.Zone < 0 || prof.Zone > MaxZone {
+		report("zone", prof.Zone, fmt.Errorf("outside 0 to %d", MaxZone))
+	}
+	if prof.ThermalCap < 0 || prof.ThermalCap > 254 {
+		report("thermalCap", prof.ThermalCap, errors.New("outside 0 to 254"))
+	}
+	if prof.ThermalAfter < 0 || prof.ThermalAfter > math.MaxUint16 {
+		report("thermalAfter", prof.ThermalAfter, fmt.Errorf("outside 0 to %d seconds", math.MaxUint16))
+	}
+	if prof.ThermalCap > 0 && prof.ThermalAfter == 0 {
+		report("thermalAfter", prof.ThermalAfter, ErrNotPositive)
+	}
+	return errs
+}
+
+// Problem severities.
+const (
+	// SeverityError marks a value Generate cannot use as written: it is
+	// clamped, replaced or left out, and strict mode refuses the project.
+	SeverityError = "error"
+	// SeverityWarning marks something Generate accepts that is probably
+	// not what the author meant, such as a track whose group is missing.
+	SeverityWarning = "warning"
+)
+
+// Problem is one finding of Validate. Track and Clip index the project's
+// tracks and that track's clips as stored; both are -1 for problems outside
+// a clip, and Clip is -1 for a whole track.
+type Problem struct {
+	Track    int    `json:"track"`
+	Clip     int    `json:"clip"`
+	Field    string `json:"field"`
+	Message  string `json:"message"`
+	Severity string `json:"severity"`
+}
+
+// Validate checks p for everything Generate would fix on the fly in any
+// format, and for tracks and clips that would play differently than they
+// look in the editor. Problems are ordered by track and clip, project-wide
+// ones first.
+func Validate(p *Project) []Problem {
+	problems := []Problem{}
+	add := func(ti, ci int, field, msg, severity string) {
+		problems = append(problems, Problem{Track: ti, Clip: ci, Field: field, Message: msg, Severity: severity})
+	}
+
+	// The newest format runs every check.
+	result, err := GenerateContext(context.Background(), p, Options{FormatVersion: FormatV5})
+	if err != nil {
+		add(-1, -1, "", err.Error(), SeverityError)
+		return problems
+	}
+	for _, e := range result.Warnings {
+		add(e.Track, e.Clip, e.Field, fmt.Sprintf("%v: %v", e.Value, e.Err), SeverityError)
+	}
+	for _, c := range result.Conflicts {
+		add(c.Other, -1, "groupId", fmt.Sprintf("plays props %s at the same time as track %d, first at %dms-%dms (%d overlaps)", c.Props, c.Track+1, c.StartMs, c.EndMs, c.Count), SeverityWarning)
+	}
+
+	for gi, g := range p.PropGroups {
+		if isMaskEmpty(calculateMask(g.IDs)) {
+			add(-1, -1, fmt.Sprintf("propGroups[%d].ids", gi), fmt.Sprintf("group %q has no valid prop IDs (1-%d)", g.Name, TotalProps), SeverityWarning)
+		}
+	}
+	for ti, track := range p.Tracks {
+		if track.Type != "led" && track.Type != "automation" {
+			continue
+		}
+		if p.FindGroup(track.GroupId) == nil {
+			add(ti, -1, "groupId", fmt.Sprintf("group %q not found; the track is left out", track.GroupId), SeverityWarning)
+		}
+		if track.Type != "led" {
+			continue
+		}
+		for ci, clip := range track.Clips {
+			if getEffectCode(clip.Type) == 1 && clip.Type != "solid" && clipEncoder(clip.Type) == nil {
+				add(ti, ci, "type", fmt.Sprintf("unknown clip type %q plays as solid", clip.Type), SeverityWarning)
+			}
+		}
+
+		// Each clip starting inside an earlier one, against the one
+		// reaching furthest.
+		order := make([]int, len(track.Clips))
+		for i := range order {
+			order[i] = i
+		}
+		clips := track.Clips
+		sort.SliceStable(order, func(i, j int) bool { return clips[order[i]].StartTime < clips[order[j]].StartTime })
+		last := -1
+		for _, ci := range order {
+			clip := clips[ci]
+			if last >= 0 && clip.StartTime < clips[last].StartTime+clips[last].Duration {
+				add(ti, ci, "startTime", fmt.Sprintf("overlaps clip %d", last+1), SeverityWarning)
+			}
+			if last < 0 || clip.StartTime+clip.Duration > clips[last].StartTime+clips[last].Duration {
+				last = ci
+			}
+		}
+	}
+
+	sort.SliceStable(problems, func(i, j int) bool {
+		a, b := problems[i], problems[j]
+		if a.Track != b.Track {
+			return a.Track < b.Track
+		}
+		return a.Clip < b.Clip
+	})
+	return problems
+}
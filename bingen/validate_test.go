@@ -0,0 +1,117 @@
+package bingen_test
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+
+	"PicoLume/bingen"
+)
+
+func invalidTimesProject() *bingen.Project {
+	cue := -5
+	return &bingen.Project{
+		Settings:   bingen.Settings{ShowDuration: 4000},
+		PropGroups: []bingen.PropGroup{{ID: "g1", IDs: "1"}},
+		Tracks: []bingen.Track{{Type: "led", GroupId: "g1", Clips: []bingen.Clip{
+			{StartTime: -500, Duration: 1000, Type: "solid"},
+			{StartTime: math.NaN(), Duration: 1000, Type: "solid"},
+			{StartTime: 1000, Duration: -10, Type: "solid"},
+			{StartTime: 2000, Duration: 1e12, Type: "solid"},
+		}}},
+		Cues: []bingen.Cue{{ID: "A", TimeMs: &cue, Enabled: true}},
+	}
+}
+
+func TestInvalidTimesClamped(t *testing.T) {
+	result, err := bingen.GenerateContext(context.Background(), invalidTimesProject(), bingen.Options{FormatVersion: bingen.FormatV2})
+	if err != nil {
+		t.Fatalf("GenerateContext() error = %v", err)
+	}
+
+	want := []struct {
+		clip  int
+		field string
+		err   error
+	}{
+		{0, "startTime", bingen.ErrNegative},
+		{1, "startTime", bingen.ErrNotFinite},
+		{2, "duration", bingen.ErrNegative},
+		{3, "duration", bingen.ErrTooLarge},
+		{-1, "cue A timeMs", bingen.ErrNegative},
+	}
+	if len(result.Warnings) != len(want) {
+		t.Fatalf("got %d warnings, want %d: %v", len(result.Warnings), len(want), result.Warnings)
+	}
+	for i, w := range want {
+		got := result.Warnings[i]
+		if got.Clip != w.clip || got.Field != w.field || !errors.Is(&got, w.err) {
+			t.Errorf("warning %d = %v, want clip %d %s %v", i, &got, w.clip, w.field, w.err)
+		}
+	}
+
+	// 0-500 solid, 500-2000 off, 2000-MaxTimeMs solid; showDuration is
+	// already covered, and the invalid cue leaves no cue block.
+	if result.EventCount != 3 {
+		t.Errorf("EventCount = %d, want 3", result.EventCount)
+	}
+	if size := bingen.HeaderSize + 3*bingen.EventSize; len(result.Bytes) != size {
+		t.Errorf("len(Bytes) = %d, want %d", len(result.Bytes), size)
+	}
+}
+
+func TestInvalidTimesStrict(t *testing.T) {
+	_, err := bingen.GenerateContext(context.Background(), invalidTimesProject(), bingen.Options{Strict: true})
+	var verr *bingen.ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("GenerateContext(Strict) error = %v, want *ValidationError", err)
+	}
+	if len(verr.Errors) != 5 {
+		t.Errorf("got %d errors, want 5: %v", len(verr.Errors), err)
+	}
+	if !errors.Is(err, bingen.ErrNotFinite) {
+		t.Error("ValidationError should unwrap to ErrNotFinite")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	p := &bingen.Project{
+		Settings:   bingen.Settings{ShowDuration: 4000},
+		PropGroups: []bingen.PropGroup{{ID: "g1", Name: "Front", IDs: "1-4"}, {ID: "g2", Name: "Broken", IDs: "300"}},
+		Tracks: []bingen.Track{
+			{Type: "audio", GroupId: "nope"},
+			{Type: "led", GroupId: "g1", Clips: []bingen.Clip{
+				{StartTime: 0, Duration: 1000, Type: "solid", Props: bingen.ClipProps{Color: "#zz0000"}},
+				{StartTime: 500, Duration: 1000, Type: "sparkles"},
+				{StartTime: 2000, Duration: -1, Type: "solid"},
+			}},
+			{Type: "led", GroupId: "missing"},
+		},
+	}
+	want := []bingen.Problem{
+		{Track: -1, Clip: -1, Field: "propGroups[1].ids", Severity: bingen.SeverityWarning},
+		{Track: 1, Clip: 0, Field: "color", Severity: bingen.SeverityError},
+		{Track: 1, Clip: 1, Field: "type", Severity: bingen.SeverityWarning},
+		{Track: 1, Clip: 1, Field: "startTime", Severity: bingen.SeverityWarning},
+		{Track: 1, Clip: 2, Field: "duration", Severity: bingen.SeverityError},
+		{Track: 2, Clip: -1, Field: "groupId", Severity: bingen.SeverityWarning},
+	}
+	got := bingen.Validate(p)
+	if len(got) != len(want) {
+		t.Fatalf("Validate() = %+v, want %d problems", got, len(want))
+	}
+	for i, w := range want {
+		g := got[i]
+		if g.Track != w.Track || g.Clip != w.Clip || g.Field != w.Field || g.Severity != w.Severity || g.Message == "" {
+			t.Errorf("problem %d = %+v, want %+v with a message", i, g, w)
+		}
+	}
+	if msg := got[3].Message; msg != "overlaps clip 1" {
+		t.Errorf("overlap message = %q", msg)
+	}
+
+	if got := bingen.Validate(&bingen.Project{}); got == nil || len(got) != 0 {
+		t.Errorf("Validate(empty) = %v, want an empty list", got)
+	}
+}
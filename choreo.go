@@ -0,0 +1,49 @@
+package main
+
+import (
+	"PicoLume/choreo"
+	"PicoLume/logger"
+)
+
+// ==========================================================
+// CHOREOGRAPHY TRANSFORMS
+// ==========================================================
+
+// TransformResponse is returned by the timeline transform bindings.
+type TransformResponse struct {
+	Project  string `json:"project"` // the transformed project JSON
+	Copied   int    `json:"copied"`
+	Replaced int    `json:"replaced"`
+	Moved    int    `json:"moved"`
+	Error    string `json:"error"`
+}
+
+func transformResponse(name string, r *choreo.Result, err error) TransformResponse {
+	if err != nil {
+		return TransformResponse{Error: err.Error()}
+	}
+	logger.Info("%s: Copied %d, replaced %d, moved %d clips", name, r.Copied, r.Replaced, r.Moved)
+	return TransformResponse{Project: string(r.Project), Copied: r.Copied, Replaced: r.Replaced, Moved: r.Moved}
+}
+
+// CanonTrack repeats a track's clips on other groups, each a fixed offset
+// later than the last, for canon and wave effects.
+func (a *App) CanonTrack(projectJson string, opts choreo.CanonOptions) TransformResponse {
+	defer a.recoverBinding("CanonTrack")
+	r, err := choreo.Canon([]byte(projectJson), opts)
+	return transformResponse("CanonTrack", r, err)
+}
+
+// MirrorGroups copies one group's clips onto another, or swaps them.
+func (a *App) MirrorGroups(projectJson string, opts choreo.MirrorOptions) TransformResponse {
+	defer a.recoverBinding("MirrorGroups")
+	r, err := choreo.Mirror([]byte(projectJson), opts)
+	return transformResponse("MirrorGroups", r, err)
+}
+
+// StaggerClips spreads the starts of the selected clips by a fixed step.
+func (a *App) StaggerClips(projectJson string, opts choreo.StaggerOptions) TransformResponse {
+	defer a.recoverBinding("StaggerClips")
+	r, err := choreo.Stagger([]byte(projectJson), opts)
+	return transformResponse("StaggerClips", r, err)
+}
@@ -0,0 +1,389 @@
+// Package choreo applies bulk timeline transforms to a project: canons
+// (a track's clips repeated on other groups, each a little later), mirrors
+// between two groups, and staggered starts. Projects are edited as generic
+// JSON so editor-only fields survive.
+//
+// Copied clips replace whatever they overlap on the destination track, as
+// a paste does in the editor. Tracks and single-prop groups that a
+// transform needs are created.
+package choreo
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"time"
+
+	"PicoLume/bingen"
+)
+
+// MaxOffset bounds per-step offsets.
+const MaxOffset = 60_000 // ms
+
+// Result is a transformed project.
+type Result struct {
+	Project  json.RawMessage `json:"project"`
+	Copied   int             `json:"copied"`   // clips added
+	Replaced int             `json:"replaced"` // clips removed because copies overlapped them
+	Moved    int             `json:"moved"`    // clips whose start changed
+}
+
+// CanonOptions repeats a track on other groups.
+type CanonOptions struct {
+	Track  string   `json:"track"`  // source track ID
+	Groups []string `json:"groups"` // destinations, in order
+	Offset float64  `json:"offset"` // ms each destination starts after the previous one
+
+	// PerProp treats every prop of the destination groups as its own
+	// step, for waves that roll prop by prop. Missing single-prop groups
+	// are created.
+	PerProp bool `json:"perProp"`
+}
+
+// MirrorOptions copies one group's clips onto another.
+type MirrorOptions struct {
+	From string `json:"from"` // group ID
+	To   string `json:"to"`
+	Swap bool   `json:"swap"` // exchange the two groups' clips instead
+}
+
+// StaggerOptions delays each selected clip a step more than the last.
+type StaggerOptions struct {
+	ClipIDs []string `json:"clipIds"`
+	Step    float64  `json:"step"` // ms; negative staggers backwards in time
+}
+
+// Canon copies the clips of opts.Track onto each destination, the n-th one
+// (counting from 1) delayed by n*Offset.
+func Canon(project []byte, opts CanonOptions) (*Result, error) {
+	if err := checkOffset(opts.Offset); err != nil {
+		return nil, err
+	}
+	if len(opts.Groups) == 0 {
+		return nil, errors.New("no destination groups")
+	}
+	d, err := parse(project)
+	if err != nil {
+		return nil, err
+	}
+	src := d.trackByID(opts.Track)
+	if src == nil {
+		return nil, fmt.Errorf("track %q not found", opts.Track)
+	}
+
+	var dests []string
+	for _, g := range opts.Groups {
+		group := d.group(g)
+		if group == nil {
+			return nil, fmt.Errorf("group %q not found", g)
+		}
+		if !opts.PerProp {
+			dests = append(dests, g)
+			continue
+		}
+		ids, _ := group["ids"].(string)
+		for _, id := range bingen.ParseIDRange(ids) {
+			dests = append(dests, d.propGroup(group, id))
+		}
+	}
+
+	res := &Result{}
+	clips := clipsOf(src)
+	for n, g := range dests {
+		delay := float64(n+1) * opts.Offset
+		dst := d.ledTrack(g)
+		if sameMap(dst, src) {
+			continue
+		}
+		for _, c := range clips {
+			cp := d.copyClip(c)
+			cp["startTime"] = math.Max(0, num(c, "startTime")+delay)
+			res.Replaced += paste(dst, cp)
+			res.Copied++
+		}
+	}
+	return d.finish(res)
+}
+
+// Mirror makes opts.To play what opts.From plays, or exchanges the two.
+// Only each group's first LED track is used.
+func Mirror(project []byte, opts MirrorOptions) (*Result, error) {
+	if opts.From == opts.To {
+		return nil, errors.New("mirror needs two different groups")
+	}
+	d, err := parse(project)
+	if err != nil {
+		return nil, err
+	}
+	for _, g := range []string{opts.From, opts.To} {
+		if d.group(g) == nil {
+			return nil, fmt.Errorf("group %q not found", g)
+		}
+	}
+	from, to := d.ledTrack(opts.From), d.ledTrack(opts.To)
+	res := &Result{}
+	if opts.Swap {
+		a, b := clipsOf(from), clipsOf(to)
+		setClips(from, b)
+		setClips(to, a)
+		res.Moved = len(a) + len(b)
+		return d.finish(res)
+	}
+	res.Replaced = len(clipsOf(to))
+	var copies []map[string]any
+	for _, c := range clipsOf(from) {
+		copies = append(copies, d.copyClip(c))
+	}
+	setClips(to, copies)
+	res.Copied = len(copies)
+	return d.finish(res)
+}
+
+// Stagger delays the k-th selected clip, in start order, by k*Step. A clip
+// that would overlap a neighbour or start before zero is left in place.
+func Stagger(project []byte, opts StaggerOptions) (*Result, error) {
+	if err := checkOffset(opts.Step); err != nil {
+		return nil, err
+	}
+	d, err := parse(project)
+	if err != nil {
+		return nil, err
+	}
+	want := make(map[string]bool, len(opts.ClipIDs))
+	for _, id := range opts.ClipIDs {
+		want[id] = true
+	}
+	type sel struct {
+		track map[string]any
+		clip  map[string]any
+	}
+	var picked []sel
+	for _, t := range d.tracks {
+		for _, c := range clipsOf(t) {
+			if id, _ := c["id"].(string); want[id] {
+				picked = append(picked, sel{t, c})
+			}
+		}
+	}
+	if len(picked) == 0 {
+		return nil, errors.New("none of the selected clips are in the project")
+	}
+	sort.SliceStable(picked, func(i, j int) bool { return num(picked[i].clip, "startTime") < num(picked[j].clip, "startTime") })
+
+	// Move the clip travelling furthest first, so the clip ahead of each
+	// one has already made room when its new spot is checked.
+	res := &Result{}
+	order := make([]int, len(picked))
+	for i := range order {
+		order[i] = i
+	}
+	if opts.Step > 0 {
+		for i, j := 0, len(order)-1; i < j; i, j = i+1, j-1 {
+			order[i], order[j] = order[j], order[i]
+		}
+	}
+	for _, k := range order {
+		s := picked[k]
+		start := num(s.clip, "startTime") + float64(k)*opts.Step
+		if k == 0 || start < 0 || !fits(s.track, s.clip, start) {
+			continue
+		}
+		s.clip["startTime"] = start
+		res.Moved++
+	}
+	return d.finish(res)
+}
+
+func checkOffset(ms float64) error {
+	if math.IsNaN(ms) || math.Abs(ms) > MaxOffset {
+		return fmt.Errorf("offset must be within %d ms", MaxOffset)
+	}
+	return nil
+}
+
+// doc is a project decoded as generic JSON.
+type doc struct {
+	root   map[string]any
+	tracks []map[string]any
+	groups []map[string]any
+	ids    int // for new clip, track and group IDs
+	stamp  string
+}
+
+func parse(project []byte) (*doc, error) {
+	var root map[string]any
+	if err := json.Unmarshal(project, &root); err != nil {
+		return nil, fmt.Errorf("invalid project JSON: %w", err)
+	}
+	d := &doc{root: root, stamp: strconv.FormatInt(time.Now().UnixMilli(), 10)}
+	for _, key := range []string{"tracks", "propGroups"} {
+		raw, _ := root[key].([]any)
+		for i, v := range raw {
+			m, ok := v.(map[string]any)
+			if !ok {
+				return nil, fmt.Errorf("%s entry %d is not an object", key, i+1)
+			}
+			if key == "tracks" {
+				d.tracks = append(d.tracks, m)
+			} else {
+				d.groups = append(d.groups, m)
+			}
+		}
+	}
+	return d, nil
+}
+
+func (d *doc) finish(res *Result) (*Result, error) {
+	out, err := json.Marshal(d.root)
+	if err != nil {
+		return nil, err
+	}
+	res.Project = out
+	return res, nil
+}
+
+// newID returns an ID in the editor's style: prefix, a timestamp and a
+// counter.
+func (d *doc) newID(prefix string) string {
+	d.ids++
+	return fmt.Sprintf("%s%s_%d", prefix, d.stamp, d.ids)
+}
+
+func (d *doc) trackByID(id string) map[string]any {
+	for _, t := range d.tracks {
+		if t["id"] == id {
+			return t
+		}
+	}
+	return nil
+}
+
+func (d *doc) group(id string) map[string]any {
+	for _, g := range d.groups {
+		if g["id"] == id {
+			return g
+		}
+	}
+	return nil
+}
+
+// ledTrack returns the first LED track of group, adding one if there is
+// none.
+func (d *doc) ledTrack(group string) map[string]any {
+	for _, t := range d.tracks {
+		if t["type"] == "led" && t["groupId"] == group {
+			return t
+		}
+	}
+	label := group
+	if g := d.group(group); g != nil {
+		if name, ok := g["name"].(string); ok && name != "" {
+			label = name
+		}
+	}
+	t := map[string]any{"id": d.newID("t"), "type": "led", "label": label, "groupId": group, "clips": []any{}}
+	d.tracks = append(d.tracks, t)
+	d.root["tracks"] = appendAny(d.root["tracks"], t)
+	return t
+}
+
+// propGroup returns the ID of a group holding only prop id, adding one
+// named after parent if there is none.
+func (d *doc) propGroup(parent map[string]any, id int) string {
+	want := strconv.Itoa(id)
+	for _, g := range d.groups {
+		if g["ids"] == want {
+			gid, _ := g["id"].(string)
+			return gid
+		}
+	}
+	name, _ := parent["name"].(string)
+	g := map[string]any{"id": d.newID("g"), "name": fmt.Sprintf("%s #%d", name, id), "ids": want}
+	d.groups = append(d.groups, g)
+	d.root["propGroups"] = appendAny(d.root["propGroups"], g)
+	return g["id"].(string)
+}
+
+// copyClip deep-copies c with a new ID.
+func (d *doc) copyClip(c map[string]any) map[string]any {
+	var cp map[string]any
+	data, _ := json.Marshal(c)
+	json.Unmarshal(data, &cp)
+	cp["id"] = d.newID("c")
+	return cp
+}
+
+func appendAny(list any, v any) []any {
+	l, _ := list.([]any)
+	return append(l, v)
+}
+
+func clipsOf(track map[string]any) []map[string]any {
+	raw, _ := track["clips"].([]any)
+	clips := make([]map[string]any, 0, len(raw))
+	for _, c := range raw {
+		if m, ok := c.(map[string]any); ok {
+			clips = append(clips, m)
+		}
+	}
+	return clips
+}
+
+func setClips(track map[string]any, clips []map[string]any) {
+	raw := make([]any, len(clips))
+	for i, c := range clips {
+		raw[i] = c
+	}
+	track["clips"] = raw
+}
+
+// paste adds clip to track, removing the clips it overlaps, and returns
+// how many were removed.
+func paste(track map[string]any, clip map[string]any) int {
+	start := num(clip, "startTime")
+	end := start + num(clip, "duration")
+	var kept []map[string]any
+	for _, c := range clipsOf(track) {
+		cs := num(c, "startTime")
+		if start < cs+num(c, "duration") && cs < end {
+			continue
+		}
+		kept = append(kept, c)
+	}
+	removed := len(clipsOf(track)) - len(kept)
+	setClips(track, append(kept, clip))
+	return removed
+}
+
+// fits reports whether clip could start at start on track without
+// overlapping any other clip there.
+func fits(track map[string]any, clip map[string]any, start float64) bool {
+	end := start + num(clip, "duration")
+	for _, c := range clipsOf(track) {
+		if sameMap(c, clip) {
+			continue
+		}
+		cs := num(c, "startTime")
+		if start < cs+num(c, "duration") && cs < end {
+			return false
+		}
+	}
+	return true
+}
+
+// sameMap reports whether a and b are the same object.
+func sameMap(a, b map[string]any) bool {
+	if a == nil || b == nil {
+		return false
+	}
+	return reflect.ValueOf(a).Pointer() == reflect.ValueOf(b).Pointer()
+}
+
+func num(m map[string]any, key string) float64 {
+	v, _ := m[key].(float64)
+	return v
+}
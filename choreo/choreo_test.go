@@ -0,0 +1,118 @@
+package choreo
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+const project = `{
+  "propGroups": [
+    {"id": "left", "name": "Left", "ids": "1-2"},
+    {"id": "right", "name": "Right", "ids": "3-4"},
+    {"id": "p3", "name": "Prop 3", "ids": "3"}
+  ],
+  "tracks": [
+    {"id": "t1", "type": "led", "groupId": "left", "clips": [
+      {"id": "a", "startTime": 0, "duration": 500, "type": "solid", "props": {"color": "#ff0000"}},
+      {"id": "b", "startTime": 1000, "duration": 500, "type": "flash", "props": {}}
+    ]},
+    {"id": "t2", "type": "led", "groupId": "right", "clips": [
+      {"id": "x", "startTime": 200, "duration": 200, "type": "solid", "props": {}},
+      {"id": "y", "startTime": 3000, "duration": 500, "type": "solid", "props": {}}
+    ]}
+  ]
+}`
+
+type testProject struct {
+	PropGroups []struct {
+		ID, Name, IDs string
+	} `json:"propGroups"`
+	Tracks []struct {
+		ID      string `json:"id"`
+		GroupID string `json:"groupId"`
+		Clips   []struct {
+			ID        string  `json:"id"`
+			StartTime float64 `json:"startTime"`
+			Type      string  `json:"type"`
+		} `json:"clips"`
+	} `json:"tracks"`
+}
+
+func decode(t *testing.T, r *Result) testProject {
+	t.Helper()
+	var p testProject
+	if err := json.Unmarshal(r.Project, &p); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestCanon(t *testing.T) {
+	r, err := Canon([]byte(project), CanonOptions{Track: "t1", Groups: []string{"right"}, Offset: 250})
+	if err != nil {
+		t.Fatalf("Canon() error = %v", err)
+	}
+	p := decode(t, r)
+	right := p.Tracks[1].Clips
+	// x overlapped the first copy (250-750) and was replaced; y survives.
+	if r.Copied != 2 || r.Replaced != 1 || len(right) != 3 {
+		t.Fatalf("copied %d, replaced %d, right track = %+v", r.Copied, r.Replaced, right)
+	}
+	if right[1].StartTime != 250 || right[2].StartTime != 1250 || right[2].Type != "flash" || right[1].ID == "a" {
+		t.Errorf("copies = %+v, want a and b 250 ms later with new IDs", right[1:])
+	}
+
+	r, err = Canon([]byte(project), CanonOptions{Track: "t1", Groups: []string{"right"}, Offset: 100, PerProp: true})
+	if err != nil {
+		t.Fatalf("Canon(PerProp) error = %v", err)
+	}
+	p = decode(t, r)
+	// Prop 3 reuses its group; prop 4 gets a new group and track.
+	if len(p.PropGroups) != 4 || p.PropGroups[3].IDs != "4" || p.PropGroups[3].Name != "Right #4" {
+		t.Fatalf("groups = %+v, want a new Right #4", p.PropGroups)
+	}
+	if len(p.Tracks) != 4 || p.Tracks[2].GroupID != "p3" || p.Tracks[3].Clips[0].StartTime != 200 {
+		t.Errorf("tracks = %+v, want prop 3 at +100 ms and prop 4 at +200 ms", p.Tracks)
+	}
+
+	if _, err := Canon([]byte(project), CanonOptions{Track: "t9", Groups: []string{"right"}}); err == nil {
+		t.Error("unknown track should fail")
+	}
+}
+
+func TestMirror(t *testing.T) {
+	r, err := Mirror([]byte(project), MirrorOptions{From: "left", To: "right", Swap: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p := decode(t, r); p.Tracks[0].Clips[0].ID != "x" || p.Tracks[1].Clips[0].ID != "a" {
+		t.Errorf("swap: tracks = %+v", p.Tracks)
+	}
+
+	r, err = Mirror([]byte(project), MirrorOptions{From: "left", To: "right"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := decode(t, r)
+	if len(p.Tracks[1].Clips) != 2 || p.Tracks[1].Clips[1].Type != "flash" || r.Replaced != 2 {
+		t.Errorf("copy: right = %+v, replaced %d", p.Tracks[1].Clips, r.Replaced)
+	}
+}
+
+func TestStagger(t *testing.T) {
+	r, err := Stagger([]byte(project), StaggerOptions{ClipIDs: []string{"a", "x", "b"}, Step: 300})
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := decode(t, r)
+	// a stays, x (second) moves to 500, b (third) to 1600.
+	if got := p.Tracks[1].Clips[0].StartTime; got != 500 {
+		t.Errorf("x at %v, want 500", got)
+	}
+	if got := p.Tracks[0].Clips[1].StartTime; got != 1600 {
+		t.Errorf("b at %v, want 1600", got)
+	}
+	if r.Moved != 2 {
+		t.Errorf("Moved = %d, want 2", r.Moved)
+	}
+}
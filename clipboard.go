@@ -0,0 +1,72 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+
+	"PicoLume/i18n"
+	"PicoLume/logger"
+	"PicoLume/snippet"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// ==========================================================
+// CLIPBOARD SNIPPETS
+// ==========================================================
+
+// SnippetSelection is what the frontend copies: whole tracks and/or loose
+// clips, in the project's JSON shape.
+type SnippetSelection struct {
+	Tracks []json.RawMessage `json:"tracks"`
+	Clips  []json.RawMessage `json:"clips"`
+}
+
+type SnippetResponse struct {
+	Snippet *snippet.Snippet `json:"snippet"` // nil if nothing to paste
+	Error   string           `json:"error"`
+}
+
+// CopySnippet validates the selection and places it on the system clipboard
+// as a PicoLume snippet.
+func (a *App) CopySnippet(selectionJson string) string {
+	defer a.recoverBinding("CopySnippet")
+
+	var sel SnippetSelection
+	if err := json.Unmarshal([]byte(selectionJson), &sel); err != nil {
+		return i18n.T("Error: Invalid selection - %s", err.Error())
+	}
+	s, err := snippet.New(sel.Tracks, sel.Clips, AppVersion)
+	if err != nil {
+		return "Error: " + err.Error()
+	}
+	data, err := s.Encode()
+	if err != nil {
+		return "Error: " + err.Error()
+	}
+	if err := runtime.ClipboardSetText(a.ctx, string(data)); err != nil {
+		return i18n.T("Error: Could not write to clipboard - %s", err.Error())
+	}
+	logger.Info("CopySnippet: Copied %d tracks, %d clips", len(sel.Tracks), len(sel.Clips))
+	return "OK"
+}
+
+// PasteSnippet reads a snippet from the system clipboard. Clipboard text that
+// isn't a snippet yields a nil Snippet and no error, so the frontend can
+// fall back to its own paste handling.
+func (a *App) PasteSnippet() SnippetResponse {
+	defer a.recoverBinding("PasteSnippet")
+
+	text, err := runtime.ClipboardGetText(a.ctx)
+	if err != nil {
+		return SnippetResponse{Error: "Could not read clipboard: " + err.Error()}
+	}
+	s, err := snippet.Decode([]byte(text))
+	if errors.Is(err, snippet.ErrNotSnippet) {
+		return SnippetResponse{}
+	}
+	if err != nil {
+		return SnippetResponse{Error: "Invalid snippet: " + err.Error()}
+	}
+	return SnippetResponse{Snippet: s}
+}
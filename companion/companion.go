@@ -0,0 +1,202 @@
+// Package companion implements a plain-text command listener compatible with
+// Bitfocus Companion's generic TCP/UDP modules, so Stream Deck operators can
+// fire PicoLume actions ("CUE A", "GO", "BLACKOUT") next to video and audio.
+package companion
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Actions understood by the listener.
+const (
+	ActionGo       = "GO"
+	ActionStop     = "STOP"
+	ActionPause    = "PAUSE"
+	ActionCue      = "CUE"
+	ActionBlackout = "BLACKOUT"
+	ActionPing     = "PING"
+)
+
+// Cues are the cue letters a show.bin defines.
+const Cues = "ABCD"
+
+// MaxLineLength bounds a single command so a misbehaving client can't grow
+// the read buffer without limit.
+const MaxLineLength = 256
+
+// Command is a parsed request.
+type Command struct {
+	Action string `json:"action"`
+	Arg    string `json:"arg,omitempty"`
+	Source string `json:"source"` // remote address
+}
+
+// Handler executes a command. A returned error is reported to TCP clients.
+type Handler func(Command) error
+
+// ParseCommand validates one command line. Matching is case-insensitive.
+func ParseCommand(line string) (Command, error) {
+	fields := strings.Fields(strings.ToUpper(strings.TrimSpace(line)))
+	if len(fields) == 0 {
+		return Command{}, errors.New("empty command")
+	}
+
+	cmd := Command{Action: fields[0]}
+	switch cmd.Action {
+	case ActionGo, ActionStop, ActionPause, ActionBlackout, ActionPing:
+		if len(fields) != 1 {
+			return Command{}, fmt.Errorf("%s takes no arguments", cmd.Action)
+		}
+	case ActionCue:
+		if len(fields) != 2 || len(fields[1]) != 1 || !strings.Contains(Cues, fields[1]) {
+			return Command{}, errors.New("usage: CUE <A-D>")
+		}
+		cmd.Arg = fields[1]
+	default:
+		return Command{}, fmt.Errorf("unknown command %q", fields[0])
+	}
+	return cmd, nil
+}
+
+// Server listens for commands on the same port over TCP and UDP.
+type Server struct {
+	handler Handler
+
+	mu      sync.Mutex
+	tcp     net.Listener
+	udp     net.PacketConn
+	conns   map[net.Conn]struct{}
+	wg      sync.WaitGroup
+	stopped bool
+}
+
+// Listen starts TCP and UDP listeners on addr (e.g. "127.0.0.1:16759").
+func Listen(addr string, handler Handler) (*Server, error) {
+	if handler == nil {
+		return nil, errors.New("handler cannot be nil")
+	}
+
+	tcp, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on tcp %s: %w", addr, err)
+	}
+	udp, err := net.ListenPacket("udp", tcp.Addr().String())
+	if err != nil {
+		tcp.Close()
+		return nil, fmt.Errorf("failed to listen on udp %s: %w", addr, err)
+	}
+
+	s := &Server{
+		handler: handler,
+		tcp:     tcp,
+		udp:     udp,
+		conns:   make(map[net.Conn]struct{}),
+	}
+	s.wg.Add(2)
+	go s.acceptLoop()
+	go s.udpLoop()
+	return s, nil
+}
+
+// Addr reports the bound TCP address.
+func (s *Server) Addr() string {
+	return s.tcp.Addr().String()
+}
+
+// Close stops both listeners and disconnects TCP clients.
+func (s *Server) Close() error {
+	s.mu.Lock()
+	if s.stopped {
+		s.mu.Unlock()
+		return nil
+	}
+	s.stopped = true
+	s.tcp.Close()
+	s.udp.Close()
+	for c := range s.conns {
+		c.Close()
+	}
+	s.mu.Unlock()
+
+	s.wg.Wait()
+	return nil
+}
+
+func (s *Server) acceptLoop() {
+	defer s.wg.Done()
+	for {
+		conn, err := s.tcp.Accept()
+		if err != nil {
+			return
+		}
+		s.mu.Lock()
+		if s.stopped {
+			s.mu.Unlock()
+			conn.Close()
+			return
+		}
+		s.conns[conn] = struct{}{}
+		s.wg.Add(1)
+		s.mu.Unlock()
+		go s.serveConn(conn)
+	}
+}
+
+func (s *Server) serveConn(conn net.Conn) {
+	defer s.wg.Done()
+	defer func() {
+		s.mu.Lock()
+		delete(s.conns, conn)
+		s.mu.Unlock()
+		conn.Close()
+	}()
+
+	scanner := bufio.NewScanner(conn)
+	scanner.Buffer(make([]byte, MaxLineLength), MaxLineLength)
+	for scanner.Scan() {
+		reply := s.dispatch(scanner.Text(), conn.RemoteAddr().String())
+		conn.SetWriteDeadline(time.Now().Add(2 * time.Second))
+		if _, err := conn.Write([]byte(reply + "\n")); err != nil {
+			return
+		}
+	}
+}
+
+func (s *Server) udpLoop() {
+	defer s.wg.Done()
+	buf := make([]byte, MaxLineLength)
+	for {
+		n, from, err := s.udp.ReadFrom(buf)
+		if err != nil {
+			return
+		}
+		// Companion's UDP module may pack several lines into one datagram.
+		for _, line := range strings.Split(string(buf[:n]), "\n") {
+			if strings.TrimSpace(line) == "" {
+				continue
+			}
+			s.dispatch(line, from.String())
+		}
+	}
+}
+
+func (s *Server) dispatch(line, source string) string {
+	cmd, err := ParseCommand(line)
+	if err != nil {
+		return "ERR " + err.Error()
+	}
+	cmd.Source = source
+	if cmd.Action == ActionPing {
+		return "PONG"
+	}
+	if err := s.handler(cmd); err != nil {
+		return "ERR " + err.Error()
+	}
+	return "OK"
+}
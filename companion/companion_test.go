@@ -0,0 +1,126 @@
+package companion
+
+import (
+	"bufio"
+	"errors"
+	"net"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestParseCommand(t *testing.T) {
+	tests := []struct {
+		line    string
+		want    Command
+		wantErr bool
+	}{
+		{"GO", Command{Action: ActionGo}, false},
+		{"  stop \r", Command{Action: ActionStop}, false},
+		{"pause", Command{Action: ActionPause}, false},
+		{"Blackout", Command{Action: ActionBlackout}, false},
+		{"PING", Command{Action: ActionPing}, false},
+		{"CUE A", Command{Action: ActionCue, Arg: "A"}, false},
+		{"cue d", Command{Action: ActionCue, Arg: "D"}, false},
+		{"CUE E", Command{}, true},
+		{"CUE Z", Command{}, true},
+		{"CUE 1", Command{}, true},
+		{"CUE AB", Command{}, true},
+		{"CUE", Command{}, true},
+		{"CUE A B", Command{}, true},
+		{"GO now", Command{}, true},
+		{"", Command{}, true},
+		{"LAUNCH", Command{}, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseCommand(tt.line)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseCommand(%q) error = %v, wantErr %v", tt.line, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseCommand(%q) = %+v, want %+v", tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestServer(t *testing.T) {
+	var mu sync.Mutex
+	var got []Command
+	udpSeen := make(chan struct{}, 4)
+	srv, err := Listen("127.0.0.1:0", func(c Command) error {
+		mu.Lock()
+		got = append(got, c)
+		mu.Unlock()
+		if c.Action == ActionStop {
+			udpSeen <- struct{}{}
+		}
+		if c.Action == ActionPause {
+			return errors.New("nothing playing")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer srv.Close()
+
+	conn, err := net.Dial("tcp", srv.Addr())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	replies := bufio.NewScanner(conn)
+	for _, tt := range []struct{ line, reply string }{
+		{"GO", "OK"},
+		{"PING", "PONG"},
+		{"CUE Q", "ERR usage: CUE <A-D>"},
+		{"PAUSE", "ERR nothing playing"},
+		{"cue b", "OK"},
+	} {
+		conn.SetDeadline(time.Now().Add(2 * time.Second))
+		if _, err := conn.Write([]byte(tt.line + "\n")); err != nil {
+			t.Fatal(err)
+		}
+		if !replies.Scan() {
+			t.Fatalf("no reply to %q: %v", tt.line, replies.Err())
+		}
+		if replies.Text() != tt.reply {
+			t.Errorf("%q: reply %q, want %q", tt.line, replies.Text(), tt.reply)
+		}
+	}
+
+	udp, err := net.Dial("udp", srv.Addr())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer udp.Close()
+	if _, err := udp.Write([]byte("CUE C\n\nSTOP\n")); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-udpSeen:
+	case <-time.After(2 * time.Second):
+		t.Fatal("UDP commands not handled")
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	var actions []string
+	for _, c := range got {
+		actions = append(actions, c.Action+c.Arg)
+		if c.Source == "" {
+			t.Errorf("%s has no source", c.Action)
+		}
+	}
+	want := []string{"GO", "PAUSE", "CUEB", "CUEC", "STOP"}
+	if len(actions) != len(want) {
+		t.Fatalf("handled %v, want %v", actions, want)
+	}
+	for i := range want {
+		if actions[i] != want[i] {
+			t.Errorf("handled %v, want %v", actions, want)
+			break
+		}
+	}
+}
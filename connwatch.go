@@ -0,0 +1,129 @@
+package main
+
+import (
+	"time"
+
+	"PicoLume/logger"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// ==========================================================
+// CONNECTION WATCHER
+// ==========================================================
+
+// deviceDebounce is how long a receiver must stay plugged in, unplugged or
+// in a new mode before it is reported, so the drive dropping and coming back
+// during a reset does not flicker the status bar.
+const deviceDebounce = time.Second
+
+// DeviceChange is the payload of "device:connected", "device:disconnected"
+// and "device:mode-changed" events.
+type DeviceChange struct {
+	Status       PicoConnectionStatus `json:"status"`
+	PreviousMode string               `json:"previousMode"` // "NONE" for device:connected
+}
+
+// watchConnection rescans for receivers every settings.StatusPollMs so the
+// status binding can answer from the cache, and emits "device:status" when
+// the result changes. Receivers appearing, disappearing and changing mode
+// are also emitted as device events once they held for deviceDebounce; a
+// change waiting for that is rechecked sooner than the next poll.
+func (a *App) watchConnection() {
+	a.goSafe("connection watcher", func() {
+		for {
+			a.refreshConnectionStatus()
+
+			interval := time.Duration(a.currentSettings().StatusPollMs) * time.Millisecond
+			a.mu.Lock()
+			if a.presence.settling() {
+				interval = min(interval, deviceDebounce)
+			}
+			a.mu.Unlock()
+			select {
+			case <-a.ctx.Done():
+				return
+			case <-time.After(interval):
+			}
+		}
+	})
+}
+
+// refreshConnectionStatus scans now and updates the cached status.
+func (a *App) refreshConnectionStatus() PicoConnectionStatus {
+	a.mu.Lock()
+	previous := a.lastConnStatus
+	a.mu.Unlock()
+
+	status := scanConnectionStatus(previous, a.currentSettings().Serial.BaudRate, a.serialPorts())
+
+	now := time.Now()
+	a.mu.Lock()
+	changed := status != a.lastConnStatus || a.lastConnAt.IsZero()
+	a.lastConnStatus = status
+	a.lastConnAt = now
+	event, change := a.presence.observe(status, now)
+	a.mu.Unlock()
+
+	if a.ctx == nil {
+		return status
+	}
+	if changed {
+		runtime.EventsEmit(a.ctx, "device:status", status)
+	}
+	if event != "" {
+		logger.Info("Connection watcher: %s (%s -> %s)", event, change.PreviousMode, deviceMode(status))
+		runtime.EventsEmit(a.ctx, event, change)
+	}
+	return status
+}
+
+// presenceDebouncer turns scan results into device events. Only whether a
+// receiver is connected and its mode count; lock state and ports are
+// reported by "device:status".
+type presenceDebouncer struct {
+	reported PicoConnectionStatus // last status an event was sent for
+	pending  *PicoConnectionStatus
+	since    time.Time // when pending was first seen
+}
+
+// observe records status, scanned at now, and returns the event due and
+// its payload, or an empty event.
+func (d *presenceDebouncer) observe(status PicoConnectionStatus, now time.Time) (string, DeviceChange) {
+	mode := deviceMode(status)
+	if mode == deviceMode(d.reported) {
+		d.pending = nil
+		return "", DeviceChange{}
+	}
+	if d.pending == nil || mode != deviceMode(*d.pending) {
+		d.pending, d.since = &status, now
+		return "", DeviceChange{}
+	}
+	if now.Sub(d.since) < deviceDebounce {
+		return "", DeviceChange{}
+	}
+
+	change := DeviceChange{Status: status, PreviousMode: deviceMode(d.reported)}
+	d.reported, d.pending = status, nil
+	switch {
+	case change.PreviousMode == "NONE":
+		return "device:connected", change
+	case mode == "NONE":
+		return "device:disconnected", change
+	default:
+		return "device:mode-changed", change
+	}
+}
+
+// settling reports whether a change waits for deviceDebounce.
+func (d *presenceDebouncer) settling() bool {
+	return d.pending != nil
+}
+
+// deviceMode is status's mode, "NONE" when no receiver is connected.
+func deviceMode(status PicoConnectionStatus) string {
+	if !status.Connected {
+		return "NONE"
+	}
+	return status.Mode
+}
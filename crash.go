@@ -0,0 +1,304 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+	goruntime "runtime"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"PicoLume/i18n"
+	"PicoLume/logger"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// ==========================================================
+// PANIC CAPTURE
+// ==========================================================
+
+// CrashReport is emitted to the frontend as "app:crash" when a panic is
+// captured, so the UI can tell the user something went wrong instead of the
+// operation silently vanishing.
+type CrashReport struct {
+	Where    string `json:"where"`
+	Message  string `json:"message"`
+	DumpPath string `json:"dumpPath"` // empty if the dump could not be written
+}
+
+// crashDir is where crash dump files are written.
+func crashDir() string {
+	return filepath.Join(appDataDir(), "crashes")
+}
+
+// recoverBinding records a panic in a bound method, then re-panics so Wails
+// still rejects the frontend promise with the error.
+// Use as: defer a.recoverBinding("UploadToPico")
+func (a *App) recoverBinding(where string) {
+	if r := recover(); r != nil {
+		a.reportPanic(where, r, debug.Stack())
+		panic(r)
+	}
+}
+
+// recoverGoroutine records a panic in a background goroutine and swallows it
+// so the process survives.
+// Use as: defer a.recoverGoroutine("drive watcher")
+func (a *App) recoverGoroutine(where string) {
+	if r := recover(); r != nil {
+		a.reportPanic(where, r, debug.Stack())
+	}
+}
+
+// goSafe runs fn in a new goroutine with panic capture.
+func (a *App) goSafe(where string, fn func()) {
+	go func() {
+		defer a.recoverGoroutine(where)
+		fn()
+	}()
+}
+
+func (a *App) reportPanic(where string, value interface{}, stack []byte) {
+	message := fmt.Sprint(value)
+	logger.Error("PANIC in %s: %s\n%s", where, message, stack)
+
+	dumpPath, err := writeCrashDump(crashDir(), where, message, stack)
+	if err != nil {
+		logger.Warn("Failed to write crash dump: %v", err)
+	}
+
+	if a != nil && a.ctx != nil {
+		runtime.EventsEmit(a.ctx, "app:crash", CrashReport{
+			Where:    where,
+			Message:  message,
+			DumpPath: dumpPath,
+		})
+	}
+}
+
+// writeCrashDump writes a plain-text crash file and returns its path.
+func writeCrashDump(dir, where, message string, stack []byte) (string, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+
+	content := fmt.Sprintf(
+		"PicoLume Studio crash report\n"+
+			"Time:     %s\n"+
+			"Where:    %s\n"+
+			"Panic:    %s\n"+
+			"Platform: %s/%s (%s)\n\n"+
+			"%s",
+		now.Format(time.RFC3339), where, message,
+		goruntime.GOOS, goruntime.GOARCH, goruntime.Version(),
+		stack)
+
+	// Panics in two goroutines can land in the same millisecond; the
+	// second gets a numbered name instead of overwriting the first.
+	stamp := now.Format("2006-01-02_15-04-05.000")
+	for n := 1; ; n++ {
+		name := fmt.Sprintf("crash_%s.txt", stamp)
+		if n > 1 {
+			name = fmt.Sprintf("crash_%s_%d.txt", stamp, n)
+		}
+		path := filepath.Join(dir, name)
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if errors.Is(err, fs.ErrExist) {
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+		_, err = f.WriteString(content)
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			return "", err
+		}
+		return path, nil
+	}
+}
+
+// ==========================================================
+// CRASH REPORT SUBMISSION
+// ==========================================================
+
+// MaxCrashUploadBytes caps the size of one submitted dump.
+const MaxCrashUploadBytes = 1024 * 1024
+
+// CrashDumpInfo describes a crash dump waiting for the user's decision.
+type CrashDumpInfo struct {
+	Name string `json:"name"`
+	Time string `json:"time"`
+	Size int64  `json:"size"`
+}
+
+type PendingCrashResponse struct {
+	Reports   []CrashDumpInfo `json:"reports"`
+	CanSubmit bool            `json:"canSubmit"` // an endpoint is configured
+	Error     string          `json:"error"`
+}
+
+// archivedCrashDir holds dumps that were submitted or dismissed, so they are
+// not offered again but stay available for diagnostics bundles.
+func archivedCrashDir() string {
+	return filepath.Join(crashDir(), "archived")
+}
+
+// pendingCrashDumps lists crash files not yet submitted or dismissed, newest
+// first.
+func pendingCrashDumps() ([]CrashDumpInfo, error) {
+	entries, err := os.ReadDir(crashDir())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []CrashDumpInfo{}, nil
+		}
+		return nil, err
+	}
+	reports := []CrashDumpInfo{}
+	for i := len(entries) - 1; i >= 0; i-- {
+		e := entries[i]
+		if e.IsDir() || !isCrashDumpName(e.Name()) {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		reports = append(reports, CrashDumpInfo{
+			Name: e.Name(),
+			Time: info.ModTime().Format(time.RFC3339),
+			Size: info.Size(),
+		})
+	}
+	return reports, nil
+}
+
+// isCrashDumpName accepts only bare crash file names, never paths.
+func isCrashDumpName(name string) bool {
+	return strings.HasPrefix(name, "crash_") && strings.HasSuffix(name, ".txt") &&
+		filepath.Base(name) == name && !strings.ContainsAny(name, `/\`)
+}
+
+// GetPendingCrashReports lists crash dumps from earlier sessions that the
+// user has not yet submitted or dismissed.
+func (a *App) GetPendingCrashReports() PendingCrashResponse {
+	reports, err := pendingCrashDumps()
+	if err != nil {
+		return PendingCrashResponse{Error: err.Error()}
+	}
+	return PendingCrashResponse{
+		Reports:   reports,
+		CanSubmit: a.currentSettings().CrashReportURL != "",
+	}
+}
+
+// notifyPendingCrashes emits "crash:pending" at launch if earlier sessions
+// left crash dumps, so the frontend can offer to submit them.
+func (a *App) notifyPendingCrashes() {
+	resp := a.GetPendingCrashReports()
+	if len(resp.Reports) > 0 && a.ctx != nil {
+		runtime.EventsEmit(a.ctx, "crash:pending", resp)
+	}
+}
+
+// SubmitCrashReports uploads the named dumps plus an optional user comment to
+// the configured endpoint as multipart/form-data, then archives them.
+func (a *App) SubmitCrashReports(names []string, comment string) string {
+	defer a.recoverBinding("SubmitCrashReports")
+
+	endpoint := a.currentSettings().CrashReportURL
+	if endpoint == "" {
+		return i18n.T("Error: No crash report endpoint configured")
+	}
+	if len(names) == 0 {
+		return i18n.T("Error: No reports selected")
+	}
+
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	mw.WriteField("appVersion", AppVersion)
+	mw.WriteField("gitCommit", GitCommit)
+	mw.WriteField("os", goruntime.GOOS+"/"+goruntime.GOARCH)
+	mw.WriteField("comment", comment)
+	for _, name := range names {
+		if !isCrashDumpName(name) {
+			return i18n.T("Error: Invalid report name %s", name)
+		}
+		data, err := readLimited(filepath.Join(crashDir(), name), MaxCrashUploadBytes)
+		if err != nil {
+			return i18n.T("Error reading %s: %s", name, err.Error())
+		}
+		fw, err := mw.CreateFormFile("dump", name)
+		if err != nil {
+			return "Error: " + err.Error()
+		}
+		fw.Write(data)
+	}
+	mw.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, &body)
+	if err != nil {
+		return "Error: " + err.Error()
+	}
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return i18n.T("Error: Crash report server not reachable: %s", err.Error())
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<16))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return i18n.T("Error: Crash report rejected: %s", resp.Status)
+	}
+
+	logger.Info("SubmitCrashReports: Submitted %d report(s) to %s", len(names), endpoint)
+	archiveCrashDumps(names)
+	return "OK"
+}
+
+// DismissCrashReports archives the named dumps without submitting them.
+func (a *App) DismissCrashReports(names []string) string {
+	for _, name := range names {
+		if !isCrashDumpName(name) {
+			return i18n.T("Error: Invalid report name %s", name)
+		}
+	}
+	archiveCrashDumps(names)
+	return "OK"
+}
+
+func archiveCrashDumps(names []string) {
+	if err := os.MkdirAll(archivedCrashDir(), 0755); err != nil {
+		logger.Warn("Failed to create crash archive: %v", err)
+		return
+	}
+	for _, name := range names {
+		if err := os.Rename(filepath.Join(crashDir(), name), filepath.Join(archivedCrashDir(), name)); err != nil {
+			logger.Warn("Failed to archive crash dump %s: %v", name, err)
+		}
+	}
+}
+
+func readLimited(path string, limit int64) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return io.ReadAll(io.LimitReader(f, limit))
+}
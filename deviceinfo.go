@@ -0,0 +1,200 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"PicoLume/bingen"
+	"PicoLume/serialmanager"
+	"PicoLume/serialsession"
+)
+
+// ==========================================================
+// DEVICE INFO
+// ==========================================================
+
+// infoCommand asks a receiver to describe itself. It answers on one line
+// with space-separated key=value fields, e.g.
+//
+//	OK fw=1.4.0 format=3 flash=1572864 leds=150 ledtype=ws2812 order=GRB battery=3.92
+//
+// Unknown keys are ignored so firmware can add fields; missing ones are
+// left zero. Older firmware answers ERR.
+const infoCommand = "info"
+
+// Timeouts for asking a receiver: opening its port when no session holds
+// it, and waiting for the reply, which keeps an upload from stalling on
+// firmware that never answers.
+const (
+	infoOpenTimeout  = 2 * time.Second
+	infoReplyTimeout = time.Second
+)
+
+// DeviceInfo is returned by GetDeviceInfo.
+type DeviceInfo struct {
+	Port          string  `json:"port"`
+	Firmware      string  `json:"firmware"`
+	FormatVersion int     `json:"formatVersion"` // newest show.bin version it reads; 0 if unknown
+	FlashBytes    int64   `json:"flashBytes"`    // flash for show.bin; 0 if unknown
+	LedCount      int     `json:"ledCount"`
+	LedType       string  `json:"ledType"`
+	ColorOrder    string  `json:"colorOrder"`
+	BatteryVolts  float64 `json:"batteryVolts"` // 0 without a battery monitor
+	Error         string  `json:"error"`
+}
+
+// commander sends a command line to a receiver and returns the text after
+// its OK reply. *serialsession.Session implements it.
+type commander interface {
+	Command(cmd string, timeout time.Duration) (string, error)
+}
+
+// GetDeviceInfo asks the receiver on the serial port for its firmware,
+// supported show.bin format, flash size, LED setup and battery voltage.
+// It uses the open serial session, or opens the first receiver port for
+// the question.
+func (a *App) GetDeviceInfo() DeviceInfo {
+	defer a.recoverBinding("GetDeviceInfo")
+
+	var info DeviceInfo
+	err := a.withDeviceSession(func(s *serialsession.Session) error {
+		var err error
+		info, err = queryDeviceInfo(s)
+		info.Port = s.Name()
+		if err != nil {
+			serialLog.Debug("GetDeviceInfo: %s: %v", s.Name(), err)
+			return err
+		}
+		serialLog.Info("GetDeviceInfo: %s runs firmware %s (format %d)", s.Name(), info.Firmware, info.FormatVersion)
+		return nil
+	})
+	if err != nil {
+		info.Error = err.Error()
+	}
+	return info
+}
+
+// receiverInfo asks the receiver about itself before an upload, so the
+// show is written in a format it plays and refused if it cannot fit.
+// Firmware that answers ERR or nothing at all predates infoCommand and
+// reads show format V2 at most, so that is what it is sent. A receiver that
+// cannot be reached yields a zero DeviceInfo: the upload then uses the
+// configured format, and the drive's free space decides what fits.
+func (a *App) receiverInfo() DeviceInfo {
+	var info DeviceInfo
+	err := a.withDeviceSession(func(s *serialsession.Session) error {
+		var err error
+		info, err = queryDeviceInfo(s)
+		info.Port = s.Name()
+		if predatesInfo(err) {
+			serialLog.Info("receiverInfo: %s does not describe itself (%v), assuming show format V%d", s.Name(), err, bingen.FormatV2)
+			info.FormatVersion = bingen.FormatV2
+			return nil
+		}
+		return err
+	})
+	if err != nil {
+		serialLog.Debug("receiverInfo: %v", err)
+		return DeviceInfo{}
+	}
+	return info
+}
+
+// predatesInfo reports whether err, from queryDeviceInfo, means the
+// firmware does not know infoCommand.
+func predatesInfo(err error) bool {
+	var devErr *serialsession.DeviceError
+	return errors.As(err, &devErr) || errors.Is(err, serialsession.ErrTimeout)
+}
+
+// queryDeviceInfo sends infoCommand to dev and parses the reply.
+func queryDeviceInfo(dev commander) (DeviceInfo, error) {
+	reply, err := dev.Command(infoCommand, infoReplyTimeout)
+	if err != nil {
+		return DeviceInfo{}, err
+	}
+	return parseDeviceInfo(reply)
+}
+
+// parseDeviceInfo reads the fields of an infoCommand reply. The firmware
+// version is required; a malformed number is an error rather than a zero,
+// so a garbled reply is not taken for a receiver without that feature.
+func parseDeviceInfo(reply string) (DeviceInfo, error) {
+	var info DeviceInfo
+	for _, field := range strings.Fields(reply) {
+		key, value, ok := strings.Cut(field, "=")
+		if !ok {
+			continue
+		}
+		var err error
+		switch key {
+		case "fw":
+			info.Firmware = value
+		case "format":
+			info.FormatVersion, err = strconv.Atoi(value)
+		case "flash":
+			info.FlashBytes, err = strconv.ParseInt(value, 10, 64)
+		case "leds":
+			info.LedCount, err = strconv.Atoi(value)
+		case "ledtype":
+			info.LedType = value
+		case "order":
+			info.ColorOrder = value
+		case "battery":
+			info.BatteryVolts, err = strconv.ParseFloat(value, 64)
+		}
+		if err != nil {
+			return DeviceInfo{}, fmt.Errorf("bad %s in device info: %q", key, value)
+		}
+	}
+	if info.Firmware == "" {
+		return DeviceInfo{}, fmt.Errorf("no firmware version in device info: %q", reply)
+	}
+	return info, nil
+}
+
+// withDeviceSession calls fn with a connected session to ask the receiver
+// something: the open serial session, or a session on the first receiver
+// port, opened for fn as a serial manager operation and closed after.
+func (a *App) withDeviceSession(fn func(s *serialsession.Session) error) error {
+	if s := a.currentSerialSession(); s != nil {
+		if s.State() != serialsession.StateConnected {
+			return fmt.Errorf("serial session on %s is %s", s.Name(), s.State())
+		}
+		return fn(s)
+	}
+
+	candidates, err := findSerialCandidates()
+	if err != nil || len(candidates) == 0 {
+		return errors.New("no receiver serial port found")
+	}
+	port := candidates[0].Name
+	return a.serialPorts().Do(context.Background(), serialmanager.OpInfo, port, func() error {
+		opened := make(chan error, 1)
+		s := serialsession.Open(port, a.openSessionPort, serialsession.Options{
+			OnState: func(state string, err error) {
+				switch state {
+				case serialsession.StateConnected, serialsession.StateDisconnected:
+					select {
+					case opened <- err:
+					default:
+					}
+				}
+			},
+		})
+		defer s.Close()
+		select {
+		case err = <-opened:
+		case <-time.After(infoOpenTimeout):
+			err = serialsession.ErrNotConnected
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w", port, err)
+		}
+		return fn(s)
+	})
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"path/filepath"
+
+	"PicoLume/bingen"
+	"PicoLume/devices"
+	"PicoLume/i18n"
+	"PicoLume/logger"
+	"PicoLume/showaudio"
+)
+
+// ==========================================================
+// DEVICE REGISTRY
+// ==========================================================
+
+type DevicesResponse struct {
+	Devices []devices.Device `json:"devices"`
+	Error   string           `json:"error"`
+}
+
+// loadDevices opens the device registry in the app data directory, starting
+// empty (and logging why) if the file is unusable.
+func (a *App) loadDevices() {
+	registry, err := devices.Open(filepath.Join(appDataDir(), devices.FileName))
+	if err != nil {
+		logger.Warn("Devices: %v; starting with an empty registry", err)
+	}
+	a.mu.Lock()
+	a.registry = registry
+	a.mu.Unlock()
+}
+
+func (a *App) deviceRegistry() *devices.Registry {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.registry
+}
+
+// ListDevices returns the registered receivers.
+func (a *App) ListDevices() DevicesResponse {
+	r := a.deviceRegistry()
+	if r == nil {
+		return DevicesResponse{Devices: []devices.Device{}}
+	}
+	return DevicesResponse{Devices: r.List()}
+}
+
+// SaveDevice adds or replaces a registered receiver.
+func (a *App) SaveDevice(d devices.Device) DevicesResponse {
+	defer a.recoverBinding("SaveDevice")
+	r := a.deviceRegistry()
+	if r == nil {
+		return DevicesResponse{Devices: []devices.Device{}, Error: "Device registry is not loaded yet"}
+	}
+	if err := r.Put(d); err != nil {
+		return DevicesResponse{Devices: r.List(), Error: err.Error()}
+	}
+	logger.Info("SaveDevice: Saved %s (prop %d)", d.ID, d.PropID)
+	return DevicesResponse{Devices: r.List()}
+}
+
+// RemoveDevice deletes a registered receiver.
+func (a *App) RemoveDevice(id string) DevicesResponse {
+	defer a.recoverBinding("RemoveDevice")
+	r := a.deviceRegistry()
+	if r == nil {
+		return DevicesResponse{Devices: []devices.Device{}, Error: "Device registry is not loaded yet"}
+	}
+	if err := r.Remove(id); err != nil {
+		return DevicesResponse{Devices: r.List(), Error: err.Error()}
+	}
+	return DevicesResponse{Devices: r.List()}
+}
+
+// registeredDevice looks up id in the registry.
+func (a *App) registeredDevice(id string) (devices.Device, error) {
+	r := a.deviceRegistry()
+	if r == nil {
+		return devices.Device{}, fmt.Errorf("device registry is not loaded")
+	}
+	d, ok := r.Get(id)
+	if !ok {
+		return devices.Device{}, fmt.Errorf("device %q is not registered", id)
+	}
+	return d, nil
+}
+
+// deviceAudio renders the show audio for d if its prop's hardware profile
+// plays audio locally. It returns nil for other receivers and for projects
+// without audio clips.
+func (a *App) deviceAudio(projectJson string, d devices.Device) ([]byte, error) {
+	var p bingen.Project
+	if err := json.Unmarshal([]byte(projectJson), &p); err != nil {
+		return nil, fmt.Errorf("invalid project: %w", err)
+	}
+	if prof := p.PropProfiles()[d.PropID]; prof == nil || !prof.Audio {
+		return nil, nil
+	}
+
+	a.mu.Lock()
+	files := maps.Clone(a.audioFiles)
+	a.mu.Unlock()
+	clips, err := showaudio.Clips(&p, files)
+	if err != nil || len(clips) == 0 {
+		return nil, err
+	}
+
+	a.emitUploadStatus(i18n.T("Converting show audio..."))
+	wav, err := showaudio.RenderWAV(context.Background(), clips)
+	if err != nil {
+		return nil, err
+	}
+	logger.Info("deviceAudio: Rendered %d clips (%d KB) for %s", len(clips), len(wav)/1024, d.ID)
+	return wav, nil
+}
+
+// DriveInfoResponse describes the receiver on a mounted USB drive.
+type DriveInfoResponse struct {
+	Drive string `json:"drive"`
+	devices.VolumeInfo
+	Device          *devices.Device `json:"device"`          // registry entry with this serial
+	MatchesLastShow bool            `json:"matchesLastShow"` // holds the last show generated here
+	Error           string          `json:"error"`
+}
+
+// GetDriveInfo reads the firmware version, serial number and show hash from
+// a receiver drive, or from the connected one if drive is empty.
+func (a *App) GetDriveInfo(drive string) DriveInfoResponse {
+	defer a.recoverBinding("GetDriveInfo")
+	if drive == "" {
+		a.mu.Lock()
+		drive = a.lastConnStatus.USBDrive
+		a.mu.Unlock()
+	}
+	if drive == "" {
+		return DriveInfoResponse{Error: "No receiver drive connected"}
+	}
+
+	info, err := devices.ReadVolume(drive)
+	resp := DriveInfoResponse{Drive: drive, VolumeInfo: info}
+	if err != nil {
+		resp.Error = err.Error()
+	}
+	if r := a.deviceRegistry(); r != nil && info.Serial != "" {
+		if d, ok := r.Get(info.Serial); ok {
+			resp.Device = &d
+		}
+	}
+	if info.ShowHash != "" {
+		a.gen.mu.Lock()
+		if a.gen.result != nil {
+			sum := sha256.Sum256(a.gen.result.Bytes)
+			resp.MatchesLastShow = hex.EncodeToString(sum[:]) == info.ShowHash
+		}
+		a.gen.mu.Unlock()
+	}
+	return resp
+}
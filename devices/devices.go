@@ -0,0 +1,200 @@
+// Package devices keeps a registry of known receivers (which prop each one
+// plays, its radio channel and default brightness) so that configuration
+// can be written to a receiver together with its show.
+package devices
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"PicoLume/bingen"
+)
+
+// FileName is the registry file inside the app data directory.
+const FileName = "devices.json"
+
+// ConfigFileName is the per-device file written next to show.bin.
+const ConfigFileName = "config.json"
+
+// ConfigVersion is written into config.json so firmware can reject layouts
+// it does not understand.
+const ConfigVersion = 1
+
+// MaxRadioChannel is the highest channel the RFM69 firmware accepts.
+const MaxRadioChannel = 255
+
+// Device is one registered receiver.
+type Device struct {
+	ID           string `json:"id"` // serial number or user-chosen label
+	Name         string `json:"name"`
+	PropID       int    `json:"propId"`       // 1..bingen.TotalProps
+	RadioChannel int    `json:"radioChannel"` // 0..MaxRadioChannel
+	Brightness   int    `json:"brightness"`   // 1..255
+}
+
+// Validate checks d's fields.
+func (d Device) Validate() error {
+	if strings.TrimSpace(d.ID) == "" {
+		return errors.New("device ID cannot be empty")
+	}
+	if d.PropID < 1 || d.PropID > bingen.TotalProps {
+		return fmt.Errorf("prop ID must be 1-%d", bingen.TotalProps)
+	}
+	if d.RadioChannel < 0 || d.RadioChannel > MaxRadioChannel {
+		return fmt.Errorf("radio channel must be 0-%d", MaxRadioChannel)
+	}
+	if d.Brightness < 1 || d.Brightness > 255 {
+		return errors.New("brightness must be 1-255")
+	}
+	return nil
+}
+
+// Config is the config.json document a receiver reads at boot.
+type Config struct {
+	Version      int    `json:"version"`
+	DeviceID     string `json:"deviceId"`
+	PropID       int    `json:"propId"`
+	RadioChannel int    `json:"radioChannel"`
+	Brightness   int    `json:"brightness"`
+}
+
+// ConfigJSON returns d's config.json contents.
+func (d Device) ConfigJSON() ([]byte, error) {
+	if err := d.Validate(); err != nil {
+		return nil, err
+	}
+	return json.MarshalIndent(Config{
+		Version:      ConfigVersion,
+		DeviceID:     d.ID,
+		PropID:       d.PropID,
+		RadioChannel: d.RadioChannel,
+		Brightness:   d.Brightness,
+	}, "", "  ")
+}
+
+// Registry loads and saves devices at a fixed path. It is safe for
+// concurrent use.
+type Registry struct {
+	mu      sync.Mutex
+	path    string
+	devices map[string]Device
+}
+
+// Open loads the registry at path. A missing file yields an empty
+// registry; an unreadable or invalid one yields an empty registry plus the
+// error.
+func Open(path string) (*Registry, error) {
+	r := &Registry{path: path, devices: make(map[string]Device)}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return r, nil
+	}
+	if err != nil {
+		return r, fmt.Errorf("failed to read device registry: %w", err)
+	}
+	var list []Device
+	if err := json.Unmarshal(data, &list); err != nil {
+		return r, fmt.Errorf("invalid device registry: %w", err)
+	}
+	for _, d := range list {
+		if err := d.Validate(); err != nil {
+			return r, fmt.Errorf("invalid device registry entry %q: %w", d.ID, err)
+		}
+		r.devices[d.ID] = d
+	}
+	return r, nil
+}
+
+// List returns all devices sorted by prop ID, then ID.
+func (r *Registry) List() []Device {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.listLocked()
+}
+
+func (r *Registry) listLocked() []Device {
+	list := make([]Device, 0, len(r.devices))
+	for _, d := range r.devices {
+		list = append(list, d)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].PropID != list[j].PropID {
+			return list[i].PropID < list[j].PropID
+		}
+		return list[i].ID < list[j].ID
+	})
+	return list
+}
+
+// Get returns the device with the given ID.
+func (r *Registry) Get(id string) (Device, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	d, ok := r.devices[id]
+	return d, ok
+}
+
+// Put validates and saves d, replacing any device with the same ID.
+func (r *Registry) Put(d Device) error {
+	d.ID = strings.TrimSpace(d.ID)
+	if err := d.Validate(); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	previous, existed := r.devices[d.ID]
+	r.devices[d.ID] = d
+	if err := r.saveLocked(); err != nil {
+		if existed {
+			r.devices[d.ID] = previous
+		} else {
+			delete(r.devices, d.ID)
+		}
+		return err
+	}
+	return nil
+}
+
+// Remove deletes the device with the given ID, if present.
+func (r *Registry) Remove(id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	previous, ok := r.devices[id]
+	if !ok {
+		return nil
+	}
+	delete(r.devices, id)
+	if err := r.saveLocked(); err != nil {
+		r.devices[id] = previous
+		return err
+	}
+	return nil
+}
+
+// saveLocked writes via a temp file and rename so a crash mid-write never
+// leaves a truncated registry.
+func (r *Registry) saveLocked() error {
+	data, err := json.MarshalIndent(r.listLocked(), "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(r.path), 0755); err != nil {
+		return fmt.Errorf("failed to create registry directory: %w", err)
+	}
+	tmp := r.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to write device registry: %w", err)
+	}
+	if err := os.Rename(tmp, r.path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write device registry: %w", err)
+	}
+	return nil
+}
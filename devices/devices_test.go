@@ -0,0 +1,109 @@
+package devices
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPutPersists(t *testing.T) {
+	path := filepath.Join(t.TempDir(), FileName)
+	r, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	d := Device{ID: " PL-0042 ", Name: "Drum 1", PropID: 12, RadioChannel: 3, Brightness: 180}
+	if err := r.Put(d); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+
+	reopened, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open() after Put error = %v", err)
+	}
+	got, ok := reopened.Get("PL-0042")
+	if !ok || got.PropID != 12 || got.Name != "Drum 1" {
+		t.Errorf("Get() = %+v, %v; want the saved device", got, ok)
+	}
+
+	if err := reopened.Remove("PL-0042"); err != nil {
+		t.Fatalf("Remove() error = %v", err)
+	}
+	if list := reopened.List(); len(list) != 0 {
+		t.Errorf("List() after Remove = %v, want empty", list)
+	}
+}
+
+func TestPutRejectsInvalid(t *testing.T) {
+	r, _ := Open(filepath.Join(t.TempDir(), FileName))
+	for _, d := range []Device{
+		{ID: "", PropID: 1, Brightness: 255},
+		{ID: "a", PropID: 0, Brightness: 255},
+		{ID: "a", PropID: 225, Brightness: 255},
+		{ID: "a", PropID: 1, RadioChannel: -1, Brightness: 255},
+		{ID: "a", PropID: 1, Brightness: 0},
+	} {
+		if err := r.Put(d); err == nil {
+			t.Errorf("Put(%+v) should fail", d)
+		}
+	}
+	if len(r.List()) != 0 {
+		t.Error("rejected devices should not be stored")
+	}
+}
+
+func TestConfigJSON(t *testing.T) {
+	data, err := Device{ID: "PL-7", PropID: 7, RadioChannel: 2, Brightness: 128}.ConfigJSON()
+	if err != nil {
+		t.Fatalf("ConfigJSON() error = %v", err)
+	}
+	var c Config
+	if err := json.Unmarshal(data, &c); err != nil {
+		t.Fatal(err)
+	}
+	want := Config{Version: ConfigVersion, DeviceID: "PL-7", PropID: 7, RadioChannel: 2, Brightness: 128}
+	if c != want {
+		t.Errorf("config = %+v, want %+v", c, want)
+	}
+}
+
+func TestParseIndex(t *testing.T) {
+	tests := []struct {
+		page, firmware, serial string
+	}{
+		{`<html><head><meta name="picolume-firmware" content="1.4.2"><meta name="serial" content="E6614C31"></head></html>`, "1.4.2", "E6614C31"},
+		{"<html><body><h1>PicoLume</h1>Firmware: 1.3.0<br>Serial Number: ABC123<br></body></html>", "1.3.0", "ABC123"},
+		{"<html><body>Nothing here</body></html>", "", ""},
+	}
+	for _, tt := range tests {
+		fw, serial := ParseIndex(tt.page)
+		if fw != tt.firmware || serial != tt.serial {
+			t.Errorf("ParseIndex(%q) = %q, %q; want %q, %q", tt.page, fw, serial, tt.firmware, tt.serial)
+		}
+	}
+}
+
+func TestReadVolumePrefersInfoJSON(t *testing.T) {
+	root := t.TempDir()
+	write := func(name, data string) {
+		if err := os.WriteFile(filepath.Join(root, name), []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(IndexFileName, "Firmware: 0.9<br>Serial: OLD")
+	write(InfoFileName, `{"firmware": "1.5.0", "serial": "E661"}`)
+	write(ShowFileName, "PICO")
+
+	info, err := ReadVolume(root)
+	if err != nil {
+		t.Fatalf("ReadVolume() error = %v", err)
+	}
+	sum := sha256.Sum256([]byte("PICO"))
+	want := VolumeInfo{Firmware: "1.5.0", Serial: "E661", ShowHash: hex.EncodeToString(sum[:]), ShowSize: 4, Source: InfoFileName}
+	if info != want {
+		t.Errorf("ReadVolume() = %+v, want %+v", info, want)
+	}
+}
@@ -0,0 +1,118 @@
+package devices
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"html"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Files a receiver exposes on its USB volume.
+const (
+	InfoFileName  = "info.json" // machine-readable, preferred
+	IndexFileName = "INDEX.HTM" // human-readable status page
+	ShowFileName  = "show.bin"
+	maxInfoSize   = 64 * 1024
+	maxShowSize   = 64 * 1024 * 1024
+)
+
+// VolumeInfo is what a mounted receiver volume says about the device.
+// Fields the device does not report are empty.
+type VolumeInfo struct {
+	Firmware string `json:"firmware"`
+	Serial   string `json:"serial"`
+	ShowHash string `json:"showHash"` // SHA-256 of show.bin, hex
+	ShowSize int64  `json:"showSize"`
+	Source   string `json:"source"` // file the metadata came from, if any
+}
+
+// info.json keys, also accepted as "Key: value" lines or meta tags in
+// INDEX.HTM.
+type infoFile struct {
+	Firmware string `json:"firmware"`
+	Serial   string `json:"serial"`
+}
+
+// ReadVolume reads device metadata from the volume mounted at root and
+// hashes its show.bin. info.json wins over INDEX.HTM; a volume with
+// neither still reports the show.
+func ReadVolume(root string) (VolumeInfo, error) {
+	var info VolumeInfo
+	if data, err := readSmall(filepath.Join(root, InfoFileName)); err == nil {
+		var f infoFile
+		if err := json.Unmarshal(data, &f); err != nil {
+			return info, errors.New("invalid " + InfoFileName + ": " + err.Error())
+		}
+		info.Firmware, info.Serial, info.Source = f.Firmware, f.Serial, InfoFileName
+	} else if data, err := readSmall(filepath.Join(root, IndexFileName)); err == nil {
+		info.Firmware, info.Serial = ParseIndex(string(data))
+		info.Source = IndexFileName
+	}
+
+	f, err := os.Open(filepath.Join(root, ShowFileName))
+	if errors.Is(err, os.ErrNotExist) {
+		return info, nil
+	}
+	if err != nil {
+		return info, err
+	}
+	defer f.Close()
+	h := sha256.New()
+	n, err := io.Copy(h, io.LimitReader(f, maxShowSize))
+	if err != nil {
+		return info, err
+	}
+	info.ShowHash, info.ShowSize = hex.EncodeToString(h.Sum(nil)), n
+	return info, nil
+}
+
+func readSmall(path string) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return io.ReadAll(io.LimitReader(f, maxInfoSize))
+}
+
+var (
+	metaTag = regexp.MustCompile(`(?i)<meta\s+name="(?:picolume-)?(firmware|serial)"\s+content="([^"]*)"`)
+	htmlTag = regexp.MustCompile(`<[^>]*>`)
+	keyLine = regexp.MustCompile(`(?im)^\s*(firmware(?:\s+version)?|version|serial(?:\s+number)?)\s*:\s*(\S.*?)\s*$`)
+)
+
+// ParseIndex extracts the firmware version and serial number from an
+// INDEX.HTM status page, using <meta name="firmware" content="..."> tags
+// if present and otherwise "Firmware: ..." / "Serial: ..." lines in the
+// page text.
+func ParseIndex(page string) (firmware, serial string) {
+	for _, m := range metaTag.FindAllStringSubmatch(page, -1) {
+		value := html.UnescapeString(m[2])
+		if strings.EqualFold(m[1], "firmware") {
+			firmware = value
+		} else {
+			serial = value
+		}
+	}
+	if firmware != "" || serial != "" {
+		return firmware, serial
+	}
+
+	text := htmlTag.ReplaceAllString(strings.NewReplacer("<br>", "\n", "<br/>", "\n", "<br />", "\n").Replace(page), "\n")
+	for _, m := range keyLine.FindAllStringSubmatch(html.UnescapeString(text), -1) {
+		key := strings.ToLower(m[1])
+		switch {
+		case strings.HasPrefix(key, "serial") && serial == "":
+			serial = m[2]
+		case !strings.HasPrefix(key, "serial") && firmware == "":
+			firmware = m[2]
+		}
+	}
+	return firmware, serial
+}
@@ -0,0 +1,510 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"PicoLume/logger"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// ==========================================================
+// DEVICE WRITES
+// ==========================================================
+
+// errDeviceFull means the files do not fit on the receiver's USB volume.
+var errDeviceFull = errors.New("device full")
+
+// errShowTooLarge means the files would not fit on the receiver even with
+// its drive empty, so deleting old files cannot help.
+var errShowTooLarge = errors.New("show too large for the receiver")
+
+// backupSuffix marks the previous version of a file while an upload is in
+// progress, so it can be restored if a later file fails.
+const backupSuffix = ".bak"
+
+// volumeSpace is the free and total space on a volume and its allocation
+// unit. Total is 0 if unknown.
+type volumeSpace struct {
+	Free    uint64
+	Total   uint64
+	Cluster uint64
+}
+
+// allocated rounds n up to whole clusters. FAT volumes allocate whole
+// clusters (up to 64 KB on large cards), so a file can need noticeably more
+// than its size.
+func (v volumeSpace) allocated(n int64) uint64 {
+	c := max(v.Cluster, 1)
+	return (uint64(n) + c - 1) / c * c
+}
+
+// deviceFile is one entry of an upload manifest: in-memory Data, or a local
+// Source file that is streamed.
+type deviceFile struct {
+	Name   string // relative to the volume root, slash-separated
+	Data   []byte
+	Source string
+}
+
+func (f deviceFile) size() (int64, error) {
+	if f.Source == "" {
+		return int64(len(f.Data)), nil
+	}
+	fi, err := os.Stat(f.Source)
+	if err != nil {
+		return 0, err
+	}
+	return fi.Size(), nil
+}
+
+func (f deviceFile) open() (io.ReadCloser, error) {
+	if f.Source == "" {
+		return io.NopCloser(bytes.NewReader(f.Data)), nil
+	}
+	return os.Open(f.Source)
+}
+
+// Upload file states in UploadFileProgress.
+const (
+	fileWriting    = "writing"
+	fileVerifying  = "verifying"
+	fileDone       = "done"
+	fileFailed     = "failed"
+	fileRolledBack = "rolledBack"
+)
+
+// UploadFileProgress is the payload of "upload:file" events.
+type UploadFileProgress struct {
+	Name    string `json:"name"`
+	Index   int    `json:"index"`
+	Total   int    `json:"total"`
+	Written int64  `json:"written"`
+	Size    int64  `json:"size"`
+	State   string `json:"state"`
+}
+
+func (a *App) emitUploadFile(p UploadFileProgress) {
+	if a.ctx != nil {
+		runtime.EventsEmit(a.ctx, "upload:file", p)
+	}
+}
+
+// Upload phases in UploadProgress. Sync covers flushing each file to the
+// device and reading it back to verify it.
+const (
+	phaseGenerate = "generate"
+	phaseCopy     = "copy"
+	phaseSync     = "sync"
+	phaseReset    = "reset"
+)
+
+// UploadProgress is the payload of "upload:progress" events: the whole
+// upload in bytes, over every file, for a progress bar.
+type UploadProgress struct {
+	Phase   string `json:"phase"`
+	Written int64  `json:"written"`
+	Total   int64  `json:"total"` // 0 until the files are generated
+
+	// RemainingMs estimates the copy time left from the rate so far; -1
+	// until there is a rate to go on, and during reset.
+	RemainingMs int64 `json:"remainingMs"`
+}
+
+// progressInterval throttles copy progress; phase changes are sent at once.
+const progressInterval = 100 * time.Millisecond
+
+// uploadMeter turns chunk reports from every file of an upload into
+// "upload:progress" events. A nil meter reports nothing.
+type uploadMeter struct {
+	emit func(UploadProgress)
+	now  func() time.Time
+
+	total   int64
+	base    int64 // bytes of the files already written
+	written int64
+	phase   string
+	started time.Time // first copied byte
+	sent    time.Time
+}
+
+func (a *App) newUploadMeter() *uploadMeter {
+	return &uploadMeter{
+		emit: func(p UploadProgress) {
+			if a.ctx != nil {
+				runtime.EventsEmit(a.ctx, "upload:progress", p)
+			}
+		},
+		now: time.Now,
+	}
+}
+
+// enter switches to phase and reports it.
+func (m *uploadMeter) enter(phase string) {
+	if m == nil {
+		return
+	}
+	m.phase = phase
+	m.send()
+}
+
+// start sets the upload size before the first file is copied.
+func (m *uploadMeter) start(total int64) {
+	if m == nil {
+		return
+	}
+	m.total = total
+	m.enter(phaseCopy)
+}
+
+// copied records n bytes of the current file written.
+func (m *uploadMeter) copied(n int64) {
+	if m == nil {
+		return
+	}
+	if m.started.IsZero() {
+		m.started = m.now()
+	}
+	m.written = m.base + n
+	if m.phase != phaseCopy {
+		m.enter(phaseCopy)
+	} else if m.now().Sub(m.sent) >= progressInterval || m.written == m.total {
+		m.send()
+	}
+}
+
+// fileDone moves past a file of size bytes.
+func (m *uploadMeter) fileDone(size int64) {
+	if m == nil {
+		return
+	}
+	m.base += size
+	m.written = m.base
+}
+
+func (m *uploadMeter) send() {
+	m.sent = m.now()
+	m.emit(UploadProgress{Phase: m.phase, Written: m.written, Total: m.total, RemainingMs: m.remaining()})
+}
+
+func (m *uploadMeter) remaining() int64 {
+	elapsed := m.now().Sub(m.started)
+	if m.phase == phaseGenerate || m.phase == phaseReset || m.started.IsZero() || m.written <= 0 || elapsed <= 0 {
+		return -1
+	}
+	left := float64(m.total-m.written) * float64(elapsed.Milliseconds()) / float64(m.written)
+	return int64(left)
+}
+
+// meteredSync reports the sync phase before each Sync of the file it wraps.
+type meteredSync struct {
+	syncWriter
+	meter *uploadMeter
+}
+
+func (w meteredSync) Sync() error {
+	w.meter.enter(phaseSync)
+	return w.syncWriter.Sync()
+}
+
+// checkDeviceSpace reports errShowTooLarge if the files are larger than the
+// volume at root and errDeviceFull if they cannot be written to it now. It
+// returns whether there is also room to keep the files they
+// replace until the upload completes; if not, those are overwritten in
+// place and a failed upload cannot restore them. If the free space cannot
+// be read the upload is attempted with backups.
+func checkDeviceSpace(root string, files []deviceFile, sizes []int64) (keepBackups bool, err error) {
+	space, err := volumeFreeSpace(root)
+	if err != nil {
+		logger.Debug("checkDeviceSpace: Cannot read free space for %s: %v", root, err)
+		return true, nil
+	}
+	return fitDeviceSpace(space, root, files, sizes)
+}
+
+// fitDeviceSpace is checkDeviceSpace for a volume with the given space.
+func fitDeviceSpace(space volumeSpace, root string, files []deviceFile, sizes []int64) (keepBackups bool, err error) {
+	var need, replaced uint64
+	for i, f := range files {
+		need += space.allocated(sizes[i])
+		if fi, err := os.Stat(filepath.Join(root, filepath.FromSlash(f.Name))); err == nil {
+			replaced += space.allocated(fi.Size())
+		}
+	}
+	switch {
+	case need <= space.Free:
+		return true, nil
+	case need <= space.Free+replaced:
+		return false, nil
+	case space.Total > 0 && need > space.Total:
+		return false, fmt.Errorf("%w: upload needs %d KB, the drive holds %d KB", errShowTooLarge, need/1024, space.Total/1024)
+	}
+	return false, fmt.Errorf("%w: upload needs %d KB, %d KB free", errDeviceFull, need/1024, (space.Free+replaced)/1024)
+}
+
+// checkShowCapacity reports errShowTooLarge if a show.bin of size bytes is
+// larger than the flash device reports. If it cannot tell, the upload goes
+// ahead and the drive's free space decides.
+func checkShowCapacity(device DeviceInfo, size int64) error {
+	if device.FlashBytes > 0 && size > device.FlashBytes {
+		return fmt.Errorf("%w: show.bin is %d KB, the receiver holds %d KB", errShowTooLarge, (size+1023)/1024, device.FlashBytes/1024)
+	}
+	return nil
+}
+
+// writeDeviceFiles writes an upload manifest to the volume at root, emitting
+// "upload:file" progress and, if meter is set, "upload:progress". Each file is read back and compared after
+// writing. If any file fails, the files already written are removed and the
+// previous versions restored, so the receiver never holds a mix of old and
+// new files or a truncated show.bin. Cancelling ctx abandons the write in
+// progress and rolls back the same way.
+func (a *App) writeDeviceFiles(ctx context.Context, root string, files []deviceFile, meter *uploadMeter) error {
+	sizes := make([]int64, len(files))
+	for i, f := range files {
+		n, err := f.size()
+		if err != nil {
+			return err
+		}
+		sizes[i] = n
+	}
+	keepBackups, err := checkDeviceSpace(root, files, sizes)
+	if err != nil {
+		return err
+	}
+	if !keepBackups {
+		logger.Warn("writeDeviceFiles: Not enough space on %s to keep previous files; overwriting in place", root)
+	}
+	var total int64
+	for _, n := range sizes {
+		total += n
+	}
+	meter.start(total)
+
+	type written struct{ path, backup string }
+	var done []written
+	rollback := func() {
+		for i := len(done) - 1; i >= 0; i-- {
+			w := done[i]
+			if err := os.Remove(w.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+				logger.Warn("writeDeviceFiles: Could not remove %s: %v", w.path, err)
+			}
+			if w.backup != "" {
+				if err := os.Rename(w.backup, w.path); err != nil {
+					logger.Warn("writeDeviceFiles: Could not restore %s: %v", w.path, err)
+				}
+			}
+			a.emitUploadFile(UploadFileProgress{Name: files[i].Name, Index: i, Total: len(files), Size: sizes[i], State: fileRolledBack})
+		}
+	}
+
+	for i, f := range files {
+		progress := UploadFileProgress{Name: f.Name, Index: i, Total: len(files), Size: sizes[i], State: fileWriting}
+		path := filepath.Join(root, filepath.FromSlash(f.Name))
+		w := written{path: path}
+
+		err := os.MkdirAll(filepath.Dir(path), 0755)
+		if err == nil && keepBackups {
+			if _, serr := os.Stat(path); serr == nil {
+				w.backup = path + backupSuffix
+				os.Remove(w.backup) // left over from an interrupted upload
+				err = os.Rename(path, w.backup)
+			}
+		}
+		if err == nil {
+			done = append(done, w)
+			err = a.writeDeviceFile(ctx, path, f, progress, meter)
+		}
+		if err != nil {
+			progress.State = fileFailed
+			a.emitUploadFile(progress)
+			rollback()
+			if isDiskFull(err) {
+				return fmt.Errorf("%w: %v", errDeviceFull, err)
+			}
+			return fmt.Errorf("%s: %w", f.Name, err)
+		}
+		meter.fileDone(sizes[i])
+	}
+
+	for _, w := range done {
+		if w.backup != "" {
+			if err := os.Remove(w.backup); err != nil {
+				logger.Warn("writeDeviceFiles: Could not remove backup %s: %v", w.backup, err)
+			}
+		}
+	}
+	return nil
+}
+
+// Upload writes go out in chunks so progress keeps moving on slow USB
+// mass-storage volumes, with a Sync every syncInterval so the OS cache
+// never holds more than that. A chunk that takes longer than
+// writeStallTimeout means the receiver has stopped responding.
+const (
+	writeChunkSize    = 256 * 1024
+	syncInterval      = 4 * 1024 * 1024
+	writeStallTimeout = 30 * time.Second
+)
+
+// errWriteStalled means a write made no progress within writeStallTimeout.
+var errWriteStalled = errors.New("device stopped responding")
+
+// syncWriter is the part of *os.File chunkedCopy uses.
+type syncWriter interface {
+	io.Writer
+	Sync() error
+}
+
+// chunkedCopy copies src to dst in writeChunkSize chunks, syncing every
+// syncInterval bytes and at the end, and calls report after each chunk. A
+// write or sync that blocks for longer than stall fails with
+// errWriteStalled, and one still running when ctx is cancelled fails with
+// ctx's error; the blocked call is abandoned.
+func chunkedCopy(ctx context.Context, dst syncWriter, src io.Reader, stall time.Duration, report func(int64)) (int64, error) {
+	buf := make([]byte, writeChunkSize)
+	var written, unsynced int64
+	for {
+		n, rerr := io.ReadFull(src, buf)
+		if n > 0 {
+			chunk := buf[:n]
+			if err := withStallTimeout(ctx, stall, func() error {
+				_, err := dst.Write(chunk)
+				return err
+			}); err != nil {
+				return written, err
+			}
+			written += int64(n)
+			unsynced += int64(n)
+			if unsynced >= syncInterval {
+				if err := syncDevice(ctx, dst, stall); err != nil {
+					return written, err
+				}
+				unsynced = 0
+			}
+			report(written)
+		}
+		if rerr == io.EOF || rerr == io.ErrUnexpectedEOF {
+			break
+		}
+		if rerr != nil {
+			return written, rerr
+		}
+	}
+	if unsynced > 0 {
+		if err := syncDevice(ctx, dst, stall); err != nil {
+			return written, err
+		}
+	}
+	return written, nil
+}
+
+// syncDevice flushes dst to disk. Only a full disk, a stall or
+// cancellation is fatal; some drivers report spurious sync errors for
+// removable media.
+func syncDevice(ctx context.Context, dst syncWriter, stall time.Duration) error {
+	err := withStallTimeout(ctx, stall, dst.Sync)
+	if err != nil && !errors.Is(err, errWriteStalled) && ctx.Err() == nil && !isDiskFull(err) {
+		logger.Warn("syncDevice: Sync to disk failed: %v", err)
+		return nil
+	}
+	return err
+}
+
+// withStallTimeout runs fn, giving up with errWriteStalled after timeout or
+// with ctx's error when it is cancelled.
+func withStallTimeout(ctx context.Context, timeout time.Duration, fn func() error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	done := make(chan error, 1)
+	go func() { done <- fn() }()
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case err := <-done:
+		return err
+	case <-timer.C:
+		return fmt.Errorf("%w: no progress for %s", errWriteStalled, timeout)
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// deviceFileWriter is the part of *os.File writeDeviceFile uses.
+type deviceFileWriter interface {
+	syncWriter
+	io.Closer
+}
+
+// createDeviceFile creates or truncates path on the receiver's volume.
+// Tests replace it to simulate a drive filling up.
+var createDeviceFile = func(path string) (deviceFileWriter, error) {
+	return os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0666)
+}
+
+// writeDeviceFile writes one manifest entry to path and verifies it.
+func (a *App) writeDeviceFile(ctx context.Context, path string, f deviceFile, progress UploadFileProgress, meter *uploadMeter) error {
+	src, err := f.open()
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	out, err := createDeviceFile(path)
+	if err != nil {
+		return err
+	}
+	a.emitUploadFile(progress)
+	h := sha256.New()
+	var dst syncWriter = out
+	if meter != nil {
+		dst = meteredSync{out, meter}
+	}
+	_, err = chunkedCopy(ctx, dst, io.TeeReader(src, h), writeStallTimeout, func(n int64) {
+		progress.Written = n
+		a.emitUploadFile(progress)
+		meter.copied(n)
+	})
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+
+	progress.State = fileVerifying
+	a.emitUploadFile(progress)
+	meter.enter(phaseSync)
+	if err := verifyFile(path, progress.Size, h.Sum(nil)); err != nil {
+		return err
+	}
+	progress.State = fileDone
+	a.emitUploadFile(progress)
+	return nil
+}
+
+// verifyFile reads path back and checks its size and SHA-256.
+func verifyFile(path string, size int64, sum []byte) error {
+	in, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	h := sha256.New()
+	n, err := io.Copy(h, in)
+	if err != nil {
+		return err
+	}
+	if n != size || !bytes.Equal(h.Sum(nil), sum) {
+		return fmt.Errorf("verification failed: read back %d of %d bytes with a different checksum", n, size)
+	}
+	return nil
+}
@@ -0,0 +1,24 @@
+//go:build !windows
+
+package main
+
+import (
+	"errors"
+	"syscall"
+)
+
+func volumeFreeSpace(dir string) (volumeSpace, error) {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(dir, &st); err != nil {
+		return volumeSpace{}, err
+	}
+	return volumeSpace{
+		Free:    uint64(st.Bavail) * uint64(st.Bsize),
+		Total:   uint64(st.Blocks) * uint64(st.Bsize),
+		Cluster: uint64(st.Bsize),
+	}, nil
+}
+
+func isDiskFull(err error) bool {
+	return errors.Is(err, syscall.ENOSPC)
+}
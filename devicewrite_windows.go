@@ -0,0 +1,44 @@
+//go:build windows
+
+package main
+
+import (
+	"errors"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+var (
+	kernel32              = windows.NewLazySystemDLL("kernel32.dll")
+	procGetDiskFreeSpaceW = kernel32.NewProc("GetDiskFreeSpaceW")
+)
+
+func volumeFreeSpace(dir string) (volumeSpace, error) {
+	path, err := windows.UTF16PtrFromString(dir)
+	if err != nil {
+		return volumeSpace{}, err
+	}
+	var free, total, totalFree uint64
+	if err := windows.GetDiskFreeSpaceEx(path, &free, &total, &totalFree); err != nil {
+		return volumeSpace{}, err
+	}
+
+	space := volumeSpace{Free: free, Total: total}
+	var sectorsPerCluster, bytesPerSector, freeClusters, totalClusters uint32
+	r, _, _ := procGetDiskFreeSpaceW.Call(
+		uintptr(unsafe.Pointer(path)),
+		uintptr(unsafe.Pointer(&sectorsPerCluster)),
+		uintptr(unsafe.Pointer(&bytesPerSector)),
+		uintptr(unsafe.Pointer(&freeClusters)),
+		uintptr(unsafe.Pointer(&totalClusters)),
+	)
+	if r != 0 {
+		space.Cluster = uint64(sectorsPerCluster) * uint64(bytesPerSector)
+	}
+	return space, nil
+}
+
+func isDiskFull(err error) bool {
+	return errors.Is(err, windows.ERROR_DISK_FULL) || errors.Is(err, windows.ERROR_HANDLE_DISK_FULL)
+}
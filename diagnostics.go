@@ -0,0 +1,246 @@
+package main
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"PicoLume/bingen"
+	"PicoLume/i18n"
+	"PicoLume/logger"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// ==========================================================
+// DIAGNOSTICS
+// ==========================================================
+
+// MaxRecentLogs caps how many log records GetRecentLogs returns in one call.
+const MaxRecentLogs = 1000
+
+// Diagnostic bundle limits
+const (
+	MaxDiagnosticLogBytes = 5 * 1024 * 1024 // tail of the current log file
+	MaxDiagnosticCrashes  = 5               // newest crash dumps included
+)
+
+type RecentLogsResponse struct {
+	Records []logger.Record `json:"records"`
+	LogFile string          `json:"logFile"` // path of the on-disk log, if any
+	Error   string          `json:"error"`
+}
+
+// GetRecentLogs returns the most recent in-memory log records at or above
+// level ("DEBUG", "INFO", "WARN", "ERROR") for the diagnostics panel.
+func (a *App) GetRecentLogs(level string, limit int) RecentLogsResponse {
+	minLevel, err := logger.ParseLevel(level)
+	if err != nil {
+		return RecentLogsResponse{Error: err.Error()}
+	}
+	if limit <= 0 || limit > MaxRecentLogs {
+		limit = MaxRecentLogs
+	}
+
+	records := logger.Recent(minLevel, limit)
+	if records == nil {
+		records = []logger.Record{}
+	}
+	return RecentLogsResponse{
+		Records: records,
+		LogFile: logger.FilePath(),
+	}
+}
+
+// diagnosticConnection is written as connection.json in the bundle
+type diagnosticConnection struct {
+	CheckedAt string               `json:"checkedAt,omitempty"` // empty if never scanned
+	Status    PicoConnectionStatus `json:"status"`
+}
+
+// projectSummary describes a project's shape without names, colors or audio.
+type projectSummary struct {
+	ShowDurationMs float64        `json:"showDurationMs"`
+	LedCount       uint16         `json:"ledCount"`
+	Brightness     uint8          `json:"brightness"`
+	Profiles       int            `json:"profiles"`
+	PropGroups     int            `json:"propGroups"`
+	Tracks         int            `json:"tracks"`
+	Clips          int            `json:"clips"`
+	ClipTypes      map[string]int `json:"clipTypes"`
+	EnabledCues    int            `json:"enabledCues"`
+	Error          string         `json:"error,omitempty"` // parse or generation failure
+	EventCount     int            `json:"eventCount,omitempty"`
+}
+
+// ExportDiagnostics writes a zip with recent logs, GetSystemInfo output, the
+// last device scan and, if projectJson is non-empty, a sanitized project
+// summary. An empty path prompts with a save dialog.
+func (a *App) ExportDiagnostics(path string, projectJson string) string {
+	defer a.recoverBinding("ExportDiagnostics")
+
+	if path == "" {
+		filename, err := runtime.SaveFileDialog(a.ctx, runtime.SaveDialogOptions{
+			DefaultFilename: fmt.Sprintf("picolume-diagnostics-%s.zip", time.Now().Format("2006-01-02")),
+			Title:           "Export Diagnostics",
+			Filters: []runtime.FileFilter{
+				{DisplayName: "Zip Archive (*.zip)", Pattern: "*.zip"},
+			},
+		})
+		if err != nil || filename == "" {
+			return "Cancelled"
+		}
+		path = filename
+	}
+
+	safePath, err := validateSavePath(path, []string{".zip"})
+	if err != nil {
+		return i18n.T("Error: Invalid path - %s", err.Error())
+	}
+
+	outFile, err := os.Create(safePath)
+	if err != nil {
+		return i18n.T("Error creating file: %s", err.Error())
+	}
+	defer outFile.Close()
+
+	zw := zip.NewWriter(outFile)
+
+	if err := a.writeDiagnostics(zw, projectJson); err != nil {
+		zw.Close()
+		return "Error: " + err.Error()
+	}
+	if err := zw.Close(); err != nil {
+		return i18n.T("Error finalizing zip: %s", err.Error())
+	}
+
+	logger.Info("ExportDiagnostics: Wrote diagnostics bundle to %s", safePath)
+	return "OK"
+}
+
+func (a *App) writeDiagnostics(zw *zip.Writer, projectJson string) error {
+	writeJSON := func(name string, v interface{}) error {
+		w, err := zw.Create(name)
+		if err != nil {
+			return err
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	}
+
+	if err := writeJSON("system.json", a.GetSystemInfo()); err != nil {
+		return err
+	}
+
+	a.mu.Lock()
+	conn := diagnosticConnection{Status: a.lastConnStatus}
+	if !a.lastConnAt.IsZero() {
+		conn.CheckedAt = a.lastConnAt.Format(time.RFC3339)
+	}
+	a.mu.Unlock()
+	if err := writeJSON("connection.json", conn); err != nil {
+		return err
+	}
+
+	// In-memory records include DEBUG lines that may not be in the file.
+	w, err := zw.Create("logs/recent.log")
+	if err != nil {
+		return err
+	}
+	for _, rec := range logger.Recent(logger.DEBUG, 0) {
+		name := ""
+		if rec.Logger != "" {
+			name = "[" + rec.Logger + "] "
+		}
+		fmt.Fprintf(w, "[%s] [%s] [%s] %s%s\n", rec.Time.Format("2006-01-02 15:04:05.000"), rec.Level, rec.Caller, name, rec.Message)
+	}
+
+	if logPath := logger.FilePath(); logPath != "" {
+		if err := addFileTail(zw, "logs/"+filepath.Base(logPath), logPath, MaxDiagnosticLogBytes); err != nil {
+			logger.Warn("ExportDiagnostics: Skipping log file: %v", err)
+		}
+	}
+
+	crashes, _ := filepath.Glob(filepath.Join(crashDir(), "crash_*.txt"))
+	sort.Sort(sort.Reverse(sort.StringSlice(crashes))) // timestamped names: newest first
+	if len(crashes) > MaxDiagnosticCrashes {
+		crashes = crashes[:MaxDiagnosticCrashes]
+	}
+	for _, c := range crashes {
+		if err := addFileTail(zw, "crashes/"+filepath.Base(c), c, MaxDiagnosticLogBytes); err != nil {
+			logger.Warn("ExportDiagnostics: Skipping crash dump %s: %v", c, err)
+		}
+	}
+
+	if strings.TrimSpace(projectJson) != "" {
+		if err := writeJSON("project-summary.json", summarizeProject(projectJson)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// addFileTail copies at most the last limit bytes of src into the zip.
+func addFileTail(zw *zip.Writer, name, src string, limit int64) error {
+	f, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if info, err := f.Stat(); err == nil && info.Size() > limit {
+		if _, err := f.Seek(-limit, io.SeekEnd); err != nil {
+			return err
+		}
+	}
+
+	w, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(w, io.LimitReader(f, limit))
+	return err
+}
+
+func summarizeProject(projectJson string) projectSummary {
+	p, err := parseProject(projectJson)
+	if err != nil {
+		return projectSummary{Error: err.Error()}
+	}
+
+	summary := projectSummary{
+		ShowDurationMs: p.Settings.ShowDuration,
+		LedCount:       p.Settings.LedCount,
+		Brightness:     p.Settings.Brightness,
+		Profiles:       len(p.Settings.Profiles),
+		PropGroups:     len(p.PropGroups),
+		Tracks:         len(p.Tracks),
+		ClipTypes:      make(map[string]int),
+	}
+	for _, t := range p.Tracks {
+		summary.Clips += len(t.Clips)
+		for _, c := range t.Clips {
+			summary.ClipTypes[c.Type]++
+		}
+	}
+	for _, c := range p.Cues {
+		if c.Enabled {
+			summary.EnabledCues++
+		}
+	}
+
+	// Whether the show generates at all is usually the first question.
+	if result, err := bingen.Generate(p); err != nil {
+		summary.Error = err.Error()
+	} else {
+		summary.EventCount = result.EventCount
+	}
+	return summary
+}
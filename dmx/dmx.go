@@ -0,0 +1,160 @@
+// Package dmx drives ENTTEC DMX USB Pro compatible interfaces over the same
+// serial stack used for PicoLume receivers, so small venues with a USB dongle
+// (and no network node) can mirror the show onto DMX fixtures.
+package dmx
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"go.bug.st/serial"
+	"go.bug.st/serial/enumerator"
+)
+
+const (
+	// UniverseSize is the number of channels in one DMX universe.
+	UniverseSize = 512
+
+	// ENTTEC Pro framing.
+	startOfMessage = 0x7E
+	endOfMessage   = 0xE7
+
+	// LabelSendDMX is the "Output Only Send DMX Packet Request" label.
+	LabelSendDMX = 6
+
+	// FTDI VID used by ENTTEC and most clones.
+	ftdiVID = "0403"
+)
+
+// Frame holds channel values 1..512 at indexes 0..511.
+type Frame [UniverseSize]byte
+
+// SetRGB writes an 0xRRGGBB color to three consecutive channels starting at
+// the 1-based channel addr. Channels past the end of the universe are dropped.
+func (f *Frame) SetRGB(addr int, color uint32) {
+	vals := [3]byte{byte(color >> 16), byte(color >> 8), byte(color)}
+	for i, v := range vals {
+		ch := addr + i
+		if ch >= 1 && ch <= UniverseSize {
+			f[ch-1] = v
+		}
+	}
+}
+
+// PatchEntry maps a prop group onto an RGB fixture (or bank of fixtures)
+// starting at a 1-based DMX channel.
+type PatchEntry struct {
+	GroupID      string `json:"groupId"`
+	StartChannel int    `json:"startChannel"`
+}
+
+// Patch is an ordered list of group-to-channel assignments.
+type Patch []PatchEntry
+
+// Validate checks that every entry lands fully inside the universe.
+func (p Patch) Validate() error {
+	for _, e := range p {
+		if e.GroupID == "" {
+			return errors.New("patch entry has no group")
+		}
+		if e.StartChannel < 1 || e.StartChannel+2 > UniverseSize {
+			return fmt.Errorf("group %s: start channel %d out of range (1-%d)", e.GroupID, e.StartChannel, UniverseSize-2)
+		}
+	}
+	return nil
+}
+
+// Render builds a frame from per-group colors. Groups absent from colors are
+// left at zero (blackout).
+func (p Patch) Render(colors map[string]uint32) Frame {
+	var f Frame
+	for _, e := range p {
+		if c, ok := colors[e.GroupID]; ok {
+			f.SetRGB(e.StartChannel, c)
+		}
+	}
+	return f
+}
+
+// EncodePacket wraps a payload in ENTTEC Pro framing.
+func EncodePacket(label byte, payload []byte) []byte {
+	n := len(payload)
+	pkt := make([]byte, 0, n+5)
+	pkt = append(pkt, startOfMessage, label, byte(n&0xFF), byte(n>>8))
+	pkt = append(pkt, payload...)
+	return append(pkt, endOfMessage)
+}
+
+// EncodeDMX builds a Send DMX packet (start code 0 followed by 512 channels).
+func EncodeDMX(f *Frame) []byte {
+	payload := make([]byte, 0, UniverseSize+1)
+	payload = append(payload, 0x00)
+	payload = append(payload, f[:]...)
+	return EncodePacket(LabelSendDMX, payload)
+}
+
+// ListInterfaces returns serial ports that look like ENTTEC-compatible
+// (FTDI based) DMX dongles.
+func ListInterfaces() ([]string, error) {
+	ports, err := enumerator.GetDetailedPortsList()
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, p := range ports {
+		if p.IsUSB && strings.Contains(strings.ToUpper(p.VID), ftdiVID) {
+			names = append(names, p.Name)
+		}
+	}
+	return names, nil
+}
+
+// Output is an open ENTTEC DMX USB Pro interface.
+type Output struct {
+	mu   sync.Mutex
+	port serial.Port
+	name string
+}
+
+// Open connects to the interface on portName.
+func Open(portName string) (*Output, error) {
+	// The Pro is a virtual COM port; the baud rate is ignored by the device
+	// but must be something the driver accepts.
+	port, err := serial.Open(portName, &serial.Mode{BaudRate: 57600})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open DMX interface %s: %w", portName, err)
+	}
+	return &Output{port: port, name: portName}, nil
+}
+
+// Name is the serial port the interface is attached to.
+func (o *Output) Name() string {
+	return o.name
+}
+
+// Send transmits one frame.
+func (o *Output) Send(f *Frame) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.port == nil {
+		return errors.New("DMX interface is closed")
+	}
+	_, err := o.port.Write(EncodeDMX(f))
+	return err
+}
+
+// Close blacks out the universe and releases the port.
+func (o *Output) Close() error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.port == nil {
+		return nil
+	}
+	var blank Frame
+	_, _ = o.port.Write(EncodeDMX(&blank))
+	err := o.port.Close()
+	o.port = nil
+	return err
+}
@@ -0,0 +1,92 @@
+package dmx
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestSetRGB(t *testing.T) {
+	tests := []struct {
+		addr int
+		want map[int]byte // 1-based channel -> value; others stay zero
+	}{
+		{1, map[int]byte{1: 0x12, 2: 0x34, 3: 0x56}},
+		{100, map[int]byte{100: 0x12, 101: 0x34, 102: 0x56}},
+		{511, map[int]byte{511: 0x12, 512: 0x34}},
+		{0, map[int]byte{1: 0x34, 2: 0x56}},
+		{-5, nil},
+		{513, nil},
+	}
+	for _, tt := range tests {
+		var f Frame
+		f.SetRGB(tt.addr, 0x123456)
+		for ch := 1; ch <= UniverseSize; ch++ {
+			if f[ch-1] != tt.want[ch] {
+				t.Errorf("SetRGB(%d): channel %d = %#x, want %#x", tt.addr, ch, f[ch-1], tt.want[ch])
+			}
+		}
+	}
+}
+
+func TestPatchValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		patch   Patch
+		wantErr bool
+	}{
+		{"empty", nil, false},
+		{"first and last fixture", Patch{{"g1", 1}, {"g2", 510}}, false},
+		{"no group", Patch{{"", 1}}, true},
+		{"channel 0", Patch{{"g1", 0}}, true},
+		{"past the end", Patch{{"g1", 511}}, true},
+	}
+	for _, tt := range tests {
+		if err := tt.patch.Validate(); (err != nil) != tt.wantErr {
+			t.Errorf("%s: Validate() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestPatchRender(t *testing.T) {
+	p := Patch{{"g1", 1}, {"g2", 4}, {"g1", 10}}
+	f := p.Render(map[string]uint32{"g1": 0xFF0000, "other": 0xFFFFFF})
+	want := map[int]byte{1: 0xFF, 10: 0xFF}
+	for ch := 1; ch <= UniverseSize; ch++ {
+		if f[ch-1] != want[ch] {
+			t.Errorf("channel %d = %#x, want %#x", ch, f[ch-1], want[ch])
+		}
+	}
+}
+
+func TestEncodePacket(t *testing.T) {
+	tests := []struct {
+		label   byte
+		payload []byte
+		want    []byte
+	}{
+		{LabelSendDMX, nil, []byte{0x7E, 6, 0, 0, 0xE7}},
+		{3, []byte{1, 2, 3}, []byte{0x7E, 3, 3, 0, 1, 2, 3, 0xE7}},
+		{LabelSendDMX, make([]byte, 300), append(append([]byte{0x7E, 6, 0x2C, 0x01}, make([]byte, 300)...), 0xE7)},
+	}
+	for _, tt := range tests {
+		if got := EncodePacket(tt.label, tt.payload); !bytes.Equal(got, tt.want) {
+			t.Errorf("EncodePacket(%d, %d bytes) = % x, want % x", tt.label, len(tt.payload), got, tt.want)
+		}
+	}
+}
+
+func TestEncodeDMX(t *testing.T) {
+	var f Frame
+	f[0], f[511] = 0xAA, 0xBB
+	pkt := EncodeDMX(&f)
+	if len(pkt) != UniverseSize+6 {
+		t.Fatalf("packet is %d bytes, want %d", len(pkt), UniverseSize+6)
+	}
+	// 513 bytes of payload: the start code and 512 channels.
+	if !bytes.Equal(pkt[:5], []byte{0x7E, LabelSendDMX, 0x01, 0x02, 0x00}) {
+		t.Errorf("header = % x", pkt[:5])
+	}
+	if pkt[5] != 0xAA || pkt[len(pkt)-2] != 0xBB || pkt[len(pkt)-1] != 0xE7 {
+		t.Errorf("channels or end marker wrong: first %#x, last %#x, end %#x", pkt[5], pkt[len(pkt)-2], pkt[len(pkt)-1])
+	}
+}
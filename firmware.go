@@ -0,0 +1,307 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"time"
+
+	"PicoLume/firmware"
+	"PicoLume/i18n"
+	"PicoLume/logger"
+	"PicoLume/serialmanager"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+	"go.bug.st/serial"
+)
+
+// ==========================================================
+// RECEIVER FIRMWARE
+// ==========================================================
+
+// FirmwareListResponse lists the receiver firmware releases.
+type FirmwareListResponse struct {
+	Releases []firmware.Release `json:"releases"`
+	Error    string             `json:"error"`
+}
+
+// FirmwareProgress is the payload of "firmware:progress" events.
+type FirmwareProgress struct {
+	Stage   string `json:"stage"` // download, bootloader, flash, restart, done
+	Message string `json:"message"`
+	Written int64  `json:"written"` // bytes of the image flashed so far
+	Total   int64  `json:"total"`   // image size; 0 outside the flash stage
+}
+
+func (a *App) emitFirmwareProgress(stage, message string) {
+	logger.Info("Firmware: %s", message)
+	if a.ctx != nil {
+		runtime.EventsEmit(a.ctx, "firmware:progress", FirmwareProgress{Stage: stage, Message: message})
+	}
+}
+
+func (a *App) firmwareClient() *firmware.Client {
+	return &firmware.Client{IndexURL: a.currentSettings().FirmwareIndexURL}
+}
+
+// ListFirmware returns the receiver firmware releases, newest first.
+func (a *App) ListFirmware() FirmwareListResponse {
+	defer a.recoverBinding("ListFirmware")
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+	releases, err := a.firmwareClient().Releases(ctx)
+	if err != nil {
+		logger.Warn("ListFirmware: %v", err)
+		return FirmwareListResponse{Releases: []firmware.Release{}, Error: err.Error()}
+	}
+	return FirmwareListResponse{Releases: releases}
+}
+
+// FlashFirmware downloads a firmware release (the newest if version is
+// empty), verifies its checksum, reboots the connected receiver into its
+// bootloader and flashes it. Progress is reported with "firmware:progress"
+// events.
+func (a *App) FlashFirmware(version string) string {
+	defer a.recoverBinding("FlashFirmware")
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Minute)
+	defer cancel()
+
+	client := a.firmwareClient()
+	releases, err := client.Releases(ctx)
+	if err != nil {
+		return "Error: " + err.Error()
+	}
+	var rel *firmware.Release
+	for i := range releases {
+		if version == "" || releases[i].Version == version {
+			rel = &releases[i]
+			break
+		}
+	}
+	if rel == nil {
+		return "Error: " + i18n.T("Firmware %s is not available", version)
+	}
+
+	a.emitFirmwareProgress("download", i18n.T("Downloading firmware %s...", rel.Version))
+	image, err := client.Download(ctx, *rel, filepath.Join(appDataDir(), "firmware"))
+	if errors.Is(err, firmware.ErrChecksum) {
+		return "Error: " + i18n.T("The downloaded firmware is corrupt (checksum mismatch). Try again.")
+	}
+	if err != nil {
+		return "Error: " + err.Error()
+	}
+
+	return a.installFirmware(ctx, image, rel.Version)
+}
+
+// FlashFirmwareFile flashes a UF2 image from disk, such as a test build,
+// the way FlashFirmware flashes a release. An empty path asks for the
+// file.
+func (a *App) FlashFirmwareFile(uf2Path string) string {
+	defer a.recoverBinding("FlashFirmwareFile")
+
+	if uf2Path == "" {
+		var err error
+		uf2Path, err = runtime.OpenFileDialog(a.ctx, runtime.OpenDialogOptions{
+			Title: i18n.T("Choose a firmware image"),
+			Filters: []runtime.FileFilter{
+				{DisplayName: "UF2 Firmware (*.uf2)", Pattern: "*.uf2"},
+			},
+		})
+		if err != nil || uf2Path == "" {
+			return "Cancelled"
+		}
+	}
+	// A bad file must not cost the receiver a trip into the bootloader.
+	if err := firmware.CheckFile(uf2Path); err != nil {
+		return "Error: " + err.Error()
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Minute)
+	defer cancel()
+	return a.installFirmware(ctx, uf2Path, filepath.Base(uf2Path))
+}
+
+// installFirmware flashes a checked UF2 image, labelled name in progress
+// messages: it finds the bootloader volume, rebooting the receiver into
+// it if needed, copies the image with "firmware:progress" events and
+// waits for the receiver to come back with the new firmware.
+func (a *App) installFirmware(ctx context.Context, image, name string) string {
+	root := findBootloaderVolume()
+	if root == "" {
+		a.emitFirmwareProgress("bootloader", i18n.T("Rebooting receiver into bootloader..."))
+		var err error
+		if root, err = a.rebootToBootloader(ctx); err != nil {
+			return "Error: " + err.Error()
+		}
+	}
+	if board, err := firmware.BoardID(root); err == nil && board != "" {
+		logger.Info("FlashFirmware: Bootloader %s at %s", board, root)
+	}
+
+	message := i18n.T("Flashing firmware %s to %s...", name, root)
+	a.emitFirmwareProgress("flash", message)
+	var sent time.Time
+	err := firmware.Install(image, root, func(written, total int64) {
+		if a.ctx != nil && (time.Since(sent) >= progressInterval || written == total) {
+			sent = time.Now()
+			runtime.EventsEmit(a.ctx, "firmware:progress", FirmwareProgress{Stage: "flash", Message: message, Written: written, Total: total})
+		}
+	})
+	if err != nil {
+		return "Error: " + err.Error()
+	}
+	if waitForVolume(ctx, 15*time.Second, false) != "" {
+		logger.Warn("FlashFirmware: Bootloader drive %s still present after flashing", root)
+	}
+
+	a.emitFirmwareProgress("restart", i18n.T("Waiting for the receiver to restart..."))
+	if !waitForReceiver(ctx, 20*time.Second) {
+		a.emitFirmwareProgress("done", i18n.T("Firmware %s installed. The receiver is restarting.", name))
+		return "OK"
+	}
+	if info := a.receiverInfo(); info.Firmware != "" {
+		a.emitFirmwareProgress("done", i18n.T("Firmware %s installed. The receiver is running firmware %s.", name, info.Firmware))
+		return "OK"
+	}
+	a.emitFirmwareProgress("done", i18n.T("Firmware %s installed. The receiver is back.", name))
+	return "OK"
+}
+
+// listVolumes lists the mounted volume roots. Tests replace it.
+var listVolumes = volumeRoots
+
+// findBootloaderVolume returns the root of a mounted RP2040 bootloader
+// volume, or "".
+func findBootloaderVolume() string {
+	for _, root := range listVolumes() {
+		if _, err := os.Stat(root + firmware.BootloaderInfoFile); err == nil {
+			return root
+		}
+	}
+	return ""
+}
+
+// waitForVolume polls until a bootloader volume appears (or, if present
+// is false, is gone) and returns the last one seen.
+func waitForVolume(ctx context.Context, timeout time.Duration, present bool) string {
+	deadline := time.Now().Add(timeout)
+	for {
+		root := findBootloaderVolume()
+		if (root != "") == present || time.Now().After(deadline) || ctx.Err() != nil {
+			return root
+		}
+		time.Sleep(250 * time.Millisecond)
+	}
+}
+
+// waitForReceiver polls until a receiver shows up again after flashing,
+// by its serial port or its drive, and reports whether one did.
+func waitForReceiver(ctx context.Context, timeout time.Duration) bool {
+	deadline := time.Now().Add(timeout)
+	for {
+		if ports, err := findSerialCandidates(); err == nil && len(ports) > 0 {
+			return true
+		}
+		for _, root := range volumeRoots() {
+			if _, err := os.Stat(root + "INDEX.HTM"); err == nil {
+				return true
+			}
+		}
+		if time.Now().After(deadline) || ctx.Err() != nil {
+			return false
+		}
+		time.Sleep(250 * time.Millisecond)
+	}
+}
+
+// bootloaderCommand asks running receiver firmware to reboot into its
+// bootloader, the way "r" asks it to reset.
+const bootloaderCommand = "b"
+
+// bootloaderCommandWait is how long the bootloader volume gets to appear
+// after bootloaderCommand before the 1200 baud touch is tried instead.
+const bootloaderCommandWait = 5 * time.Second
+
+// RebootToBootloader moves the connected receiver into its UF2 bootloader,
+// as FlashFirmware does before flashing, for installing firmware by hand
+// without holding BOOTSEL while plugging it in.
+func (a *App) RebootToBootloader() string {
+	defer a.recoverBinding("RebootToBootloader")
+	if root := findBootloaderVolume(); root != "" {
+		return "OK"
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	if _, err := a.rebootToBootloader(ctx); err != nil {
+		return "Error: " + err.Error()
+	}
+	return "OK"
+}
+
+// rebootToBootloader reboots the receiver into its bootloader and returns
+// the bootloader volume. It sends bootloaderCommand, through the serial
+// session if one holds the port, and falls back to the 1200 baud touch for
+// firmware that predates the command; that needs the port to itself, so
+// the session is closed first.
+func (a *App) rebootToBootloader(ctx context.Context) (string, error) {
+	candidates, err := findSerialCandidates()
+	if err != nil || len(candidates) == 0 {
+		return "", errors.New(i18n.T("No receiver serial port found. Hold BOOTSEL while plugging in the receiver, then try again."))
+	}
+	port := candidates[0].Name
+
+	if err := a.sendBootloaderCommand(ctx, port); err != nil {
+		serialLog.Debug("rebootToBootloader: Command on %s failed: %v", port, err)
+	} else if root := waitForVolume(ctx, bootloaderCommandWait, true); root != "" {
+		serialLog.Info("rebootToBootloader: %s rebooted on command", port)
+		return root, nil
+	}
+
+	if s := a.currentSerialSession(); s != nil && s.Name() == port {
+		a.CloseSerialSession()
+	}
+	err = a.serialPorts().Do(ctx, serialmanager.OpBootloader, port, func() error {
+		return touchBootloaderBaud(port)
+	})
+	if err != nil {
+		return "", err
+	}
+	root := waitForVolume(ctx, 20*time.Second, true)
+	if root == "" {
+		return "", errors.New(i18n.T("The bootloader drive did not appear. Hold BOOTSEL while plugging in the receiver, then try again."))
+	}
+	serialLog.Info("rebootToBootloader: %s rebooted on the 1200 baud touch", port)
+	return root, nil
+}
+
+// sendBootloaderCommand writes bootloaderCommand to port, through the
+// serial session if it holds the port.
+func (a *App) sendBootloaderCommand(ctx context.Context, port string) error {
+	if s := a.currentSerialSession(); s != nil && s.Name() == port {
+		return s.Write([]byte(bootloaderCommand + "\n"))
+	}
+	return a.serialPorts().Do(ctx, serialmanager.OpBootloader, port, func() error {
+		p, err := openSerial(port, &serial.Mode{BaudRate: a.currentSettings().Serial.BaudRate})
+		if err != nil {
+			return err
+		}
+		defer p.Close()
+		// Some USB CDC implementations only deliver data after DTR is asserted.
+		_ = p.SetDTR(true)
+		time.Sleep(100 * time.Millisecond)
+		_, err = p.Write([]byte(bootloaderCommand + "\n"))
+		return err
+	})
+}
+
+// touchBootloaderBaud does the 1200 baud touch on port.
+func touchBootloaderBaud(port string) error {
+	p, err := openSerial(port, &serial.Mode{BaudRate: firmware.BootloaderBaud})
+	if err != nil {
+		return err
+	}
+	_ = p.SetDTR(false)
+	time.Sleep(100 * time.Millisecond)
+	return p.Close()
+}
@@ -0,0 +1,244 @@
+// Package firmware lists receiver firmware releases, downloads and verifies
+// UF2 images, and copies them to an RP2040 in bootloader mode, so updating
+// a fleet is one guided step instead of four manual ones.
+//
+// Releases come from a JSON index at a configurable URL:
+//
+//	{"releases": [{"version": "1.4.0", "url": "https://.../picolume-rx-1.4.0.uf2",
+//	  "sha256": "<hex>", "size": 123456, "notes": "...", "published": "2026-01-31"}]}
+//
+// Every release must carry a SHA-256; images that do not match are
+// rejected before the device is touched.
+package firmware
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"PicoLume/updater"
+)
+
+// DefaultIndexURL is the index published with receiver firmware releases.
+const DefaultIndexURL = "https://github.com/picolume/firmware/releases/latest/download/index.json"
+
+// MaxImageSize caps UF2 downloads; the RP2040's largest common flash is
+// 16 MB, which is 32 MB as UF2.
+const MaxImageSize = 32 * 1024 * 1024
+
+const maxIndexSize = 1 << 20
+
+// ErrChecksum means a downloaded image does not match its release.
+var ErrChecksum = errors.New("firmware checksum mismatch")
+
+// Release is one firmware build in the index.
+type Release struct {
+	Version   string `json:"version"`
+	Name      string `json:"name,omitempty"`
+	Notes     string `json:"notes,omitempty"`
+	Published string `json:"published,omitempty"`
+	URL       string `json:"url"`
+	SHA256    string `json:"sha256"`
+	Size      int64  `json:"size,omitempty"`
+}
+
+// FileName is the local file name for r's image.
+func (r Release) FileName() string {
+	name := path.Base(r.URL)
+	if u, err := url.Parse(r.URL); err == nil {
+		name = path.Base(u.Path)
+	}
+	if !strings.HasSuffix(strings.ToLower(name), ".uf2") {
+		name = "picolume-" + r.Version + ".uf2"
+	}
+	return name
+}
+
+func (r Release) validate() error {
+	if r.Version == "" {
+		return errors.New("release without version")
+	}
+	u, err := url.Parse(r.URL)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		return fmt.Errorf("release %s: invalid url", r.Version)
+	}
+	if sum, err := hex.DecodeString(r.SHA256); err != nil || len(sum) != sha256.Size {
+		return fmt.Errorf("release %s: invalid sha256", r.Version)
+	}
+	if r.Size < 0 || r.Size > MaxImageSize {
+		return fmt.Errorf("release %s: invalid size", r.Version)
+	}
+	return nil
+}
+
+// Client fetches the index and images.
+type Client struct {
+	IndexURL string // DefaultIndexURL if empty
+	HTTP     *http.Client
+}
+
+func (c *Client) httpClient(timeout time.Duration) *http.Client {
+	if c.HTTP != nil {
+		return c.HTTP
+	}
+	return &http.Client{Timeout: timeout}
+}
+
+// Releases returns the releases in the index, newest first.
+func (c *Client) Releases(ctx context.Context) ([]Release, error) {
+	indexURL := c.IndexURL
+	if indexURL == "" {
+		indexURL = DefaultIndexURL
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, indexURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.httpClient(15 * time.Second).Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("firmware server not reachable: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("firmware index not available: %s", resp.Status)
+	}
+
+	var index struct {
+		Releases []Release `json:"releases"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxIndexSize)).Decode(&index); err != nil {
+		return nil, fmt.Errorf("invalid firmware index: %w", err)
+	}
+	for _, r := range index.Releases {
+		if err := r.validate(); err != nil {
+			return nil, fmt.Errorf("invalid firmware index: %w", err)
+		}
+	}
+	sort.SliceStable(index.Releases, func(i, j int) bool {
+		return updater.IsNewer(index.Releases[i].Version, index.Releases[j].Version)
+	})
+	return index.Releases, nil
+}
+
+// Download saves r's image into dir and returns its path. An image already
+// there with the right checksum is reused. The checksum and UF2 structure
+// are verified before the file is kept.
+func (c *Client) Download(ctx context.Context, r Release, dir string) (string, error) {
+	if err := r.validate(); err != nil {
+		return "", err
+	}
+	dest := filepath.Join(dir, r.FileName())
+	if err := VerifyFile(dest, r.SHA256); err == nil {
+		return dest, nil
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, r.URL, nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := c.httpClient(10 * time.Minute).Do(req)
+	if err != nil {
+		return "", fmt.Errorf("download failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("download failed: %s", resp.Status)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, MaxImageSize+1))
+	if err != nil {
+		return "", fmt.Errorf("download failed: %w", err)
+	}
+	if len(data) > MaxImageSize {
+		return "", errors.New("firmware image too large")
+	}
+	if r.Size > 0 && int64(len(data)) != r.Size {
+		return "", fmt.Errorf("incomplete download (%d of %d bytes)", len(data), r.Size)
+	}
+	if err := verify(data, r.SHA256); err != nil {
+		return "", err
+	}
+
+	tmp := dest + ".part"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return "", err
+	}
+	if err := os.Rename(tmp, dest); err != nil {
+		os.Remove(tmp)
+		return "", err
+	}
+	return dest, nil
+}
+
+// VerifyFile checks a downloaded image against its expected SHA-256.
+func VerifyFile(path, sha string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	return verify(data, sha)
+}
+
+func verify(data []byte, sha string) error {
+	sum := sha256.Sum256(data)
+	if !strings.EqualFold(hex.EncodeToString(sum[:]), strings.TrimSpace(sha)) {
+		return ErrChecksum
+	}
+	return CheckUF2(data)
+}
+
+// UF2 block layout, see https://github.com/microsoft/uf2.
+const (
+	uf2BlockSize  = 512
+	uf2Magic0     = 0x0A324655
+	uf2Magic1     = 0x9E5D5157
+	uf2MagicEnd   = 0x0AB16F30
+	uf2FamilyFlag = 0x00002000
+)
+
+// RP2040FamilyID is the UF2 family ID of RP2040 images.
+const RP2040FamilyID = 0xE48BFF56
+
+// CheckUF2 checks that data is a well-formed UF2 image for the RP2040:
+// every block has the UF2 magics and, if it names a family, the RP2040's,
+// and the image holds all the blocks it numbers, so a truncated copy is
+// caught before it is flashed.
+func CheckUF2(data []byte) error {
+	if len(data) == 0 || len(data)%uf2BlockSize != 0 {
+		return errors.New("not a UF2 image")
+	}
+	le := binary.LittleEndian
+	blocks := le.Uint32(data[24:])
+	for off := 0; off < len(data); off += uf2BlockSize {
+		b := data[off : off+uf2BlockSize]
+		if le.Uint32(b[0:]) != uf2Magic0 || le.Uint32(b[4:]) != uf2Magic1 || le.Uint32(b[508:]) != uf2MagicEnd {
+			return fmt.Errorf("not a UF2 image (bad block %d)", off/uf2BlockSize)
+		}
+		if le.Uint32(b[8:])&uf2FamilyFlag != 0 && le.Uint32(b[28:]) != RP2040FamilyID {
+			return fmt.Errorf("UF2 image is not for the RP2040 (family %#x)", le.Uint32(b[28:]))
+		}
+		if le.Uint32(b[24:]) != blocks || le.Uint32(b[20:]) >= blocks {
+			return fmt.Errorf("UF2 image is damaged (block %d is numbered %d of %d)", off/uf2BlockSize, le.Uint32(b[20:]), le.Uint32(b[24:]))
+		}
+	}
+	if n := uint32(len(data) / uf2BlockSize); n != blocks {
+		return fmt.Errorf("UF2 image is incomplete (%d of %d blocks)", n, blocks)
+	}
+	return nil
+}
@@ -0,0 +1,150 @@
+package firmware
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// uf2Image builds an RP2040 UF2 image of n blocks.
+func uf2Image(n int, family uint32) []byte {
+	data := make([]byte, n*uf2BlockSize)
+	for i := 0; i < n; i++ {
+		b := data[i*uf2BlockSize:]
+		binary.LittleEndian.PutUint32(b[0:], uf2Magic0)
+		binary.LittleEndian.PutUint32(b[4:], uf2Magic1)
+		binary.LittleEndian.PutUint32(b[8:], uf2FamilyFlag)
+		binary.LittleEndian.PutUint32(b[20:], uint32(i))
+		binary.LittleEndian.PutUint32(b[24:], uint32(n))
+		binary.LittleEndian.PutUint32(b[28:], family)
+		binary.LittleEndian.PutUint32(b[508:], uf2MagicEnd)
+	}
+	return data
+}
+
+func sha(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// serve publishes an index with the given image under two versions; the
+// older one claims a wrong checksum.
+func serve(t *testing.T, image []byte) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	mux.HandleFunc("/index.json", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"releases": [
+			{"version": "1.2.0", "url": "%[1]s/rx-1.2.0.uf2", "sha256": "%[2]s"},
+			{"version": "1.10.0", "url": "%[1]s/rx-1.10.0.uf2", "sha256": "%[3]s", "size": %[4]d}
+		]}`, srv.URL, sha([]byte("something else")), sha(image), len(image))
+	})
+	mux.HandleFunc("/rx-1.2.0.uf2", func(w http.ResponseWriter, r *http.Request) { w.Write(image) })
+	mux.HandleFunc("/rx-1.10.0.uf2", func(w http.ResponseWriter, r *http.Request) { w.Write(image) })
+	return srv
+}
+
+func TestReleasesNewestFirst(t *testing.T) {
+	srv := serve(t, uf2Image(2, RP2040FamilyID))
+	releases, err := (&Client{IndexURL: srv.URL + "/index.json"}).Releases(context.Background())
+	if err != nil {
+		t.Fatalf("Releases() error = %v", err)
+	}
+	if len(releases) != 2 || releases[0].Version != "1.10.0" {
+		t.Errorf("Releases() = %+v, want 1.10.0 first", releases)
+	}
+}
+
+func TestDownloadVerifiesChecksum(t *testing.T) {
+	image := uf2Image(2, RP2040FamilyID)
+	srv := serve(t, image)
+	c := &Client{IndexURL: srv.URL + "/index.json"}
+	releases, err := c.Releases(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+
+	path, err := c.Download(context.Background(), releases[0], dir)
+	if err != nil {
+		t.Fatalf("Download(1.10.0) error = %v", err)
+	}
+	if filepath.Base(path) != "rx-1.10.0.uf2" {
+		t.Errorf("path = %s, want rx-1.10.0.uf2", path)
+	}
+
+	if _, err := c.Download(context.Background(), releases[1], dir); !errors.Is(err, ErrChecksum) {
+		t.Errorf("Download(1.2.0) error = %v, want ErrChecksum", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "rx-1.2.0.uf2")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("image with a bad checksum was kept: %v", err)
+	}
+}
+
+func TestCheckUF2(t *testing.T) {
+	if err := CheckUF2(uf2Image(3, RP2040FamilyID)); err != nil {
+		t.Errorf("CheckUF2(rp2040) error = %v", err)
+	}
+	if err := CheckUF2(uf2Image(1, 0x68ed2b88)); err == nil { // SAMD21
+		t.Error("CheckUF2(other family) should fail")
+	}
+	if err := CheckUF2([]byte("not firmware")); err == nil {
+		t.Error("CheckUF2(text) should fail")
+	}
+	if err := CheckUF2(uf2Image(3, RP2040FamilyID)[:2*uf2BlockSize]); err == nil {
+		t.Error("CheckUF2(truncated) should fail")
+	}
+}
+
+func TestCheckFile(t *testing.T) {
+	dir := t.TempDir()
+	good, bad := filepath.Join(dir, "rx.uf2"), filepath.Join(dir, "notes.uf2")
+	os.WriteFile(good, uf2Image(2, RP2040FamilyID), 0644)
+	os.WriteFile(bad, []byte("release notes"), 0644)
+	if err := CheckFile(good); err != nil {
+		t.Errorf("CheckFile(image) error = %v", err)
+	}
+	for _, path := range []string{bad, filepath.Join(dir, "missing.uf2")} {
+		if err := CheckFile(path); err == nil {
+			t.Errorf("CheckFile(%s) should fail", filepath.Base(path))
+		}
+	}
+}
+
+func TestInstall(t *testing.T) {
+	image := filepath.Join(t.TempDir(), "rx.uf2")
+	data := uf2Image(2, RP2040FamilyID)
+	if err := os.WriteFile(image, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	boot := t.TempDir()
+	os.WriteFile(filepath.Join(boot, BootloaderInfoFile), []byte("UF2 Bootloader v3.0\nModel: Raspberry Pi RP2\nBoard-ID: RPI-RP2\n"), 0644)
+
+	if id, err := BoardID(boot); err != nil || id != "RPI-RP2" {
+		t.Errorf("BoardID() = %q, %v; want RPI-RP2", id, err)
+	}
+	var reports []int64
+	if err := Install(image, boot, func(written, total int64) {
+		if total != int64(len(data)) {
+			t.Errorf("progress total = %d, want %d", total, len(data))
+		}
+		reports = append(reports, written)
+	}); err != nil {
+		t.Fatalf("Install() error = %v", err)
+	}
+	if len(reports) == 0 || reports[len(reports)-1] != int64(len(data)) {
+		t.Errorf("progress reports = %v, want the last at %d", reports, len(data))
+	}
+	if got, _ := os.ReadFile(filepath.Join(boot, "rx.uf2")); len(got) != len(data) {
+		t.Errorf("flashed %d bytes, want %d", len(got), len(data))
+	}
+}
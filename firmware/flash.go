@@ -0,0 +1,91 @@
+package firmware
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// BootloaderInfoFile is present on the volume an RP2040 exposes in BOOTSEL
+// (bootloader) mode.
+const BootloaderInfoFile = "INFO_UF2.TXT"
+
+// BootloaderBaud is the "1200 baud touch": opening the receiver's USB
+// serial port at this rate and closing it reboots pico-sdk and Arduino-core
+// firmware into the bootloader.
+const BootloaderBaud = 1200
+
+// BoardID reads the Board-ID line of a bootloader volume's INFO_UF2.TXT,
+// e.g. "RPI-RP2".
+func BoardID(root string) (string, error) {
+	data, err := os.ReadFile(filepath.Join(root, BootloaderInfoFile))
+	if err != nil {
+		return "", err
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if id, ok := strings.CutPrefix(strings.TrimSpace(line), "Board-ID:"); ok {
+			return strings.TrimSpace(id), nil
+		}
+	}
+	return "", nil
+}
+
+// installChunk is how much of an image Install writes between progress
+// reports: 128 UF2 blocks, or 32 KB of flash.
+const installChunk = 128 * uf2BlockSize
+
+// CheckFile checks that the file at path is a UF2 image for the RP2040,
+// before a receiver is rebooted into its bootloader for it.
+func CheckFile(path string) error {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if fi.Size() > MaxImageSize {
+		return fmt.Errorf("%s is %d MB, too large for a firmware image", filepath.Base(path), fi.Size()>>20)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	return CheckUF2(data)
+}
+
+// Install copies the UF2 image to the bootloader volume at root, calling
+// progress, if set, with the bytes written after each chunk. Each chunk is
+// synced so progress follows what the bootloader has received. The device
+// reboots as soon as the last block arrives and the volume vanishes, so
+// once every byte is written, errors from syncing or closing are expected
+// and ignored.
+func Install(image, root string, progress func(written, total int64)) error {
+	data, err := os.ReadFile(image)
+	if err != nil {
+		return err
+	}
+	if err := CheckUF2(data); err != nil {
+		return err
+	}
+	out, err := os.Create(filepath.Join(root, filepath.Base(image)))
+	if err != nil {
+		return fmt.Errorf("cannot write to bootloader drive: %w", err)
+	}
+	n := 0
+	for n < len(data) {
+		m, werr := out.Write(data[n:min(n+installChunk, len(data))])
+		n += m
+		if werr != nil {
+			err = werr
+			break
+		}
+		out.Sync()
+		if progress != nil {
+			progress(int64(n), int64(len(data)))
+		}
+	}
+	out.Close()
+	if n < len(data) {
+		return fmt.Errorf("flashing stopped after %d of %d bytes: %v", n, len(data), err)
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+// Package fpp pushes sequences and audio to a Falcon Player (FPP) instance
+// over its HTTP API, so fixed installations driven by FPP can be updated from
+// the same place as the wearable props.
+package fpp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Upload directories understood by the FPP file API.
+const (
+	DirSequences = "sequences"
+	DirMusic     = "music"
+)
+
+// Client talks to a single FPP host.
+type Client struct {
+	BaseURL    string
+	HTTPClient *http.Client
+}
+
+// Status is the subset of /api/fppd/status used to confirm a host is alive.
+type Status struct {
+	Mode       string `json:"mode_name"`
+	Status     string `json:"status_name"`
+	Version    string `json:"version"`
+	CurrentSeq string `json:"current_sequence"`
+}
+
+// NewClient builds a client for host, which may be a bare hostname/IP
+// ("192.168.1.50", "fpp.local") or a full URL.
+func NewClient(host string) (*Client, error) {
+	host = strings.TrimSpace(host)
+	if host == "" {
+		return nil, fmt.Errorf("FPP host cannot be empty")
+	}
+	if !strings.Contains(host, "://") {
+		host = "http://" + host
+	}
+	u, err := url.Parse(host)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("invalid FPP host %q", host)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("unsupported FPP URL scheme %q", u.Scheme)
+	}
+	u.Path = strings.TrimRight(u.Path, "/")
+
+	return &Client{
+		BaseURL:    u.String(),
+		HTTPClient: &http.Client{Timeout: 10 * time.Minute},
+	}, nil
+}
+
+// Status queries the player state; it doubles as a reachability check.
+func (c *Client) Status(ctx context.Context) (*Status, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.BaseURL+"/api/fppd/status", nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("FPP not reachable: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("FPP status request failed: %s", resp.Status)
+	}
+
+	var st Status
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&st); err != nil {
+		return nil, fmt.Errorf("invalid FPP status response: %w", err)
+	}
+	return &st, nil
+}
+
+// UploadFile streams a local file into one of FPP's media directories.
+func (c *Client) UploadFile(ctx context.Context, dir, localPath string) error {
+	f, err := os.Open(localPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	name := filepath.Base(localPath)
+	endpoint := fmt.Sprintf("%s/api/file/%s/%s", c.BaseURL, url.PathEscape(dir), url.PathEscape(name))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, f)
+	if err != nil {
+		return err
+	}
+	req.ContentLength = info.Size()
+	req.Header.Set("Content-Type", "application/octet-stream")
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("upload of %s failed: %w", name, err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<16))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("upload of %s rejected: %s", name, resp.Status)
+	}
+	return nil
+}
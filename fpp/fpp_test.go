@@ -0,0 +1,131 @@
+package fpp
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestNewClient(t *testing.T) {
+	tests := []struct {
+		host    string
+		want    string
+		wantErr bool
+	}{
+		{"192.168.1.50", "http://192.168.1.50", false},
+		{"  fpp.local  ", "http://fpp.local", false},
+		{"https://fpp.local:8443/", "https://fpp.local:8443", false},
+		{"http://fpp.local/sub/", "http://fpp.local/sub", false},
+		{"", "", true},
+		{"ftp://fpp.local", "", true},
+		{"http://", "", true},
+	}
+	for _, tt := range tests {
+		c, err := NewClient(tt.host)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("NewClient(%q) = %q, want an error", tt.host, c.BaseURL)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("NewClient(%q) error = %v", tt.host, err)
+			continue
+		}
+		if c.BaseURL != tt.want {
+			t.Errorf("NewClient(%q).BaseURL = %q, want %q", tt.host, c.BaseURL, tt.want)
+		}
+	}
+}
+
+func TestStatus(t *testing.T) {
+	tests := []struct {
+		name    string
+		code    int
+		body    string
+		want    Status
+		wantErr bool
+	}{
+		{"playing", 200, `{"mode_name": "player", "status_name": "playing", "version": "7.5", "current_sequence": "show.fseq", "extra": 1}`,
+			Status{Mode: "player", Status: "playing", Version: "7.5", CurrentSeq: "show.fseq"}, false},
+		{"server error", 500, `{}`, Status{}, true},
+		{"not json", 200, `<html>`, Status{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/api/fppd/status" {
+					t.Errorf("request to %s", r.URL.Path)
+				}
+				w.WriteHeader(tt.code)
+				io.WriteString(w, tt.body)
+			}))
+			defer srv.Close()
+
+			c, err := NewClient(srv.URL)
+			if err != nil {
+				t.Fatal(err)
+			}
+			st, err := c.Status(context.Background())
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("Status() = %+v, want an error", st)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if *st != tt.want {
+				t.Errorf("Status() = %+v, want %+v", *st, tt.want)
+			}
+		})
+	}
+}
+
+func TestUploadFile(t *testing.T) {
+	local := filepath.Join(t.TempDir(), "my show.fseq")
+	if err := os.WriteFile(local, []byte("FSEQ data"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		code    int
+		wantErr bool
+	}{
+		{"accepted", 200, false},
+		{"rejected", 403, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var path, body, ctype string
+			var length int64
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				path, ctype, length = r.URL.EscapedPath(), r.Header.Get("Content-Type"), r.ContentLength
+				b, _ := io.ReadAll(r.Body)
+				body = string(b)
+				w.WriteHeader(tt.code)
+			}))
+			defer srv.Close()
+
+			c, _ := NewClient(srv.URL)
+			err := c.UploadFile(context.Background(), DirSequences, local)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("UploadFile() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if path != "/api/file/sequences/my%20show.fseq" || body != "FSEQ data" || length != 9 || ctype != "application/octet-stream" {
+				t.Errorf("request %s (%s, %d bytes): %q", path, ctype, length, body)
+			}
+		})
+	}
+
+	c, _ := NewClient("127.0.0.1:1")
+	if err := c.UploadFile(context.Background(), DirMusic, filepath.Join(t.TempDir(), "missing.mp3")); err == nil {
+		t.Error("UploadFile() of a missing file succeeded")
+	}
+}
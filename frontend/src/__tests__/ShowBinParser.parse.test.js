@@ -50,5 +50,24 @@ describe('ShowBinParser.parseShowBin', () => {
     expect(parsed.cueBlock.times).toEqual({ A: 1234, B: null, C: 5678, D: null });
     expect(parsed.trailingBytes).toBe(cueSize);
   });
-});
 
+  it('finds the cue block before a CRC1 footer', () => {
+    const cueSize = 32;
+    const footerSize = 8;
+    const totalSize = 16 + 224 * 8 + cueSize + footerSize;
+    const bytes = new Uint8Array(totalSize);
+    writeU32LE(bytes, 0, 0x5049434f);
+    writeU16LE(bytes, 4, 3);
+
+    const cueBase = totalSize - footerSize - cueSize;
+    writeU32LE(bytes, cueBase, 0x31455543); // "CUE1"
+    writeU16LE(bytes, cueBase + 4, 1);
+    writeU16LE(bytes, cueBase + 6, 4);
+    writeU32LE(bytes, totalSize - footerSize, 0x31435243); // "CRC1"
+    writeU32LE(bytes, totalSize - 4, 0xdeadbeef);
+
+    const parsed = parseShowBin(bytes);
+    expect(parsed.cueBlock?.base).toBe(cueBase);
+    expect(parsed.checksum).toEqual({ base: totalSize - footerSize, value: 0xdeadbeef });
+  });
+});
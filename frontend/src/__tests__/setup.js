@@ -7,6 +7,7 @@ global.window.go = {
       SaveProjectToPath: vi.fn(),
       RequestSavePath: vi.fn(),
       LoadProject: vi.fn(),
+      ImportAudio: vi.fn(),
       SaveBinary: vi.fn(),
       UploadToPico: vi.fn(),
     }
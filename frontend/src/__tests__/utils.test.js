@@ -297,6 +297,30 @@ describe('formatPicoStatus', () => {
             expect(result.title).toContain('Warning: Serial port is in use');
         });
 
+        it('should name the process holding a locked port', () => {
+            const result = formatPicoStatus({
+                connected: true,
+                mode: 'SERIAL',
+                serialPort: 'COM5',
+                serialPortLocked: true,
+                serialPortHolder: 'arduino-ide.exe (PID 4242)'
+            });
+            expect(result.text).toBe('Pico: Connected (COM5) [PORT BUSY]');
+            expect(result.title).toContain('in use by arduino-ide.exe (PID 4242)');
+        });
+
+        it('should show NO PERMISSION warning with the backend hint', () => {
+            const result = formatPicoStatus({
+                connected: true,
+                mode: 'SERIAL',
+                serialPort: '/dev/ttyACM0',
+                serialPortDenied: true,
+                serialHint: 'Add your user to the dialout group.'
+            });
+            expect(result.text).toBe('Pico: Connected (/dev/ttyACM0) [NO PERMISSION]');
+            expect(result.title).toContain('Add your user to the dialout group.');
+        });
+
         it('should not show warning when serialPortLocked is false', () => {
             const result = formatPicoStatus({
                 connected: true,
@@ -30,6 +30,9 @@ function createWailsBackend(app) {
         async loadProject() {
             return await app.LoadProject();
         },
+        async importAudio(bufferId, dataURL) {
+            return await app.ImportAudio(bufferId, dataURL);
+        },
         async saveBinary(projectJson) {
             // Use WASM binary generator (Go→WASM), then save via Go's native file dialog.
             // If WASM isn't available (missing assets / bad hosting), fall back to Go-side generation.
@@ -267,6 +270,10 @@ function createOnlineBackend() {
                 return `Error: ${err?.message || err} (WASM binary generator unavailable; rebuild and deploy /src/wasm/bingen.wasm + wasm_exec.js)`;
             }
         },
+        async importAudio() {
+            // Audio stays in the page; there is no backend to upload from.
+            return 'OK';
+        },
         async uploadToPico() {
             return 'Not available in online version';
         }
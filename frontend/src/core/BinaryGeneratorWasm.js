@@ -190,3 +190,30 @@ export async function generateBinaryBase64Async(project) {
     return generateBinaryBase64(project);
 }
 
+
+/**
+ * Stream show.bin from WASM chunk by chunk, without holding the whole file
+ * (for example into a WritableStream for a very large show). Needs a
+ * bingen.wasm built with generateBinaryStream.
+ *
+ * @param {Object} project - The project data
+ * @param {(chunk: Uint8Array) => void} onChunk - Called with each chunk, in file order
+ * @returns {{ size: number, eventCount: number }}
+ */
+export function generateBinaryStream(project, onChunk) {
+    if (!wasmReady || !window.picolume?.generateBinaryStream) {
+        throw new Error('WASM binary generator not initialized');
+    }
+
+    const projectJson = JSON.stringify(project);
+    const result = window.picolume.generateBinaryStream(projectJson, onChunk);
+
+    if (result.error) {
+        throw new Error(`WASM binary generation failed: ${result.error}`);
+    }
+
+    return {
+        size: result.size,
+        eventCount: result.eventCount
+    };
+}
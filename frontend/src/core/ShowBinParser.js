@@ -20,6 +20,11 @@ export const CUE_BLOCK_SIZE = 32;
 export const CUE_MAGIC = 0x31455543; // "CUE1" when read as u32 little-endian
 export const CUE_UNUSED = 0xffffffff;
 
+// Optional checksum footer after the cue block: "CRC1" + CRC-32 of every
+// preceding byte (8 bytes at end of file)
+export const CHECKSUM_FOOTER_SIZE = 8;
+export const CHECKSUM_MAGIC = 0x31435243; // "CRC1" when read as u32 little-endian
+
 export const COLOR_ORDER = Object.freeze({
     0: "GRB", 1: "RGB", 2: "BRG", 3: "RBG", 4: "GBR", 5: "BGR"
 });
@@ -160,7 +165,9 @@ export function parseShowBin(bytes) {
             const ledType = readU8(dv, offset + 2);
             const colorOrder = readU8(dv, offset + 3);
             const brightnessCap = readU8(dv, offset + 4);
-            propConfigs.push({ propId: i + 1, ledCount, ledType, colorOrder, brightnessCap });
+            const sustainedCap = readU8(dv, offset + 5);
+            const derateAfter = readU16LE(dv, offset + 6);
+            propConfigs.push({ propId: i + 1, ledCount, ledType, colorOrder, brightnessCap, sustainedCap, derateAfter });
         }
     }
 
@@ -204,10 +211,21 @@ export function parseShowBin(bytes) {
         });
     }
 
-    // Parse optional CUE block at the end of the file (last 32 bytes)
+    // Parse optional checksum footer (last 8 bytes)
+    let checksum = null;
+    let trailerEnd = dv.byteLength;
+    if (dv.byteLength >= HEADER_SIZE + CHECKSUM_FOOTER_SIZE) {
+        const base = dv.byteLength - CHECKSUM_FOOTER_SIZE;
+        if (readU32LE(dv, base) === CHECKSUM_MAGIC) {
+            checksum = { base, value: readU32LE(dv, base + 4) };
+            trailerEnd = base;
+        }
+    }
+
+    // Parse optional CUE block at the end of the file (last 32 bytes, before any checksum footer)
     let cueBlock = null;
-    if (dv.byteLength >= CUE_BLOCK_SIZE) {
-        const base = dv.byteLength - CUE_BLOCK_SIZE;
+    if (trailerEnd >= CUE_BLOCK_SIZE) {
+        const base = trailerEnd - CUE_BLOCK_SIZE;
         const cueMagic = readU32LE(dv, base + 0);
         if (cueMagic === CUE_MAGIC) {
             const cueVersion = readU16LE(dv, base + 4);
@@ -218,11 +236,13 @@ export function parseShowBin(bytes) {
                 const t = readU32LE(dv, base + 8 + i * 4);
                 times[cueIds[i]] = t === CUE_UNUSED ? null : t;
             }
-            cueBlock = { base, version: cueVersion, count: cueCount, times };
+            const blackout = cueVersion >= 2 ? readU8(dv, base + 24) : 0;
+            const blackoutCue = blackout ? String.fromCharCode(blackout) : null;
+            cueBlock = { base, version: cueVersion, count: cueCount, times, blackoutCue };
         }
     }
 
-    // Trailing bytes after the expected events payload (includes cue block if present)
+    // Trailing bytes after the expected events payload (includes cue block and checksum if present)
     const expectedEnd = eventsOffset + eventCount * EVENT_SIZE;
     const trailingBytes = dv.byteLength > expectedEnd ? (dv.byteLength - expectedEnd) : 0;
 
@@ -241,6 +261,7 @@ export function parseShowBin(bytes) {
         events,
         eventsOffset,
         cueBlock,
+        checksum,
         trailingBytes,
         stats: {
             totalEvents: events.length,
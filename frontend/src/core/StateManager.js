@@ -114,9 +114,18 @@ export function createDefaultProfile(id, name, ledCount, assignedIds) {
         ledType: LED_TYPES.WS2812B,
         colorOrder: COLOR_ORDERS.RGB,
         brightnessCap: 255,
+        zone: 0,                 // radio zone (transmitter channel), 0-15
+        thermalCap: 0,           // sustained brightness for enclosed props (0 = no derating)
+        thermalAfter: 0,         // seconds above thermalCap before derating
 
-        // Informational fields (for documentation/UI only)
+        // Receiver capabilities (used by uploads, not written to show.bin)
+        audio: false,            // plays the show audio from SD/flash
+
+        // Power budget (used by the limitPower setting)
         voltage: 5,              // 5V or 12V or 24V
+        powerBudget: 0,          // watts per prop; 0 = no limit
+
+        // Informational fields (for documentation/UI only)
         physicalLength: null,    // Length in cm (null = not specified)
         pixelsPerMeter: 60,      // LED density
         notes: ''                // User notes
@@ -141,9 +150,18 @@ export function migrateProfile(profile) {
         ledType: profile.ledType ?? LED_TYPES.WS2812B,
         colorOrder: profile.colorOrder ?? COLOR_ORDERS.RGB,
         brightnessCap: profile.brightnessCap ?? 255,
+        zone: profile.zone ?? 0,
+        thermalCap: profile.thermalCap ?? 0,
+        thermalAfter: profile.thermalAfter ?? 0,
 
-        // Add informational fields with defaults if missing
+        // Add capabilities with defaults if missing
+        audio: profile.audio ?? false,
+
+        // Add power fields with defaults if missing
         voltage: profile.voltage ?? 5,
+        powerBudget: profile.powerBudget ?? 0,
+
+        // Add informational fields with defaults if missing
         physicalLength: profile.physicalLength ?? null,
         pixelsPerMeter: profile.pixelsPerMeter ?? 60,
         notes: profile.notes ?? ''
@@ -220,11 +220,21 @@ window.addEventListener('DOMContentLoaded', async () => {
                 let explanation = '';
 
                 if (reason.startsWith('PORT_LOCKED:')) {
-                    const port = reason.split(':')[1] || 'serial port';
+                    const [portName, ...holderParts] = reason.slice('PORT_LOCKED:'.length).split(':');
+                    const port = portName || 'serial port';
+                    const holder = holderParts.join(':');
                     title = 'Serial Port In Use';
-                    explanation = `Another application is using ${port}.\n\nClose any other application that utilizes the serial port (i.e. Arduino IDE, PuTTY, Serial Monitor...) then try uploading again.`;
+                    explanation = holder
+                        ? `${port} is in use by ${holder}.\n\nClose that application then try uploading again.`
+                        : `Another application is using ${port}.\n\nClose any other application that utilizes the serial port (i.e. Arduino IDE, PuTTY, Serial Monitor...) then try uploading again.`;
+                } else if (reason.startsWith('PORT_DENIED:')) {
+                    const [port, ...hint] = reason.slice('PORT_DENIED:'.length).split('\n');
+                    title = 'Serial Port Permission Denied';
+                    explanation = hint.join('\n') || `You do not have permission to open ${port || 'the serial port'}.`;
                 } else if (reason === 'RESET_FAILED') {
                     explanation = 'The device did not respond to the reset command.';
+                } else if (reason === 'RESET_CANCELLED') {
+                    explanation = 'The upload was cancelled before the device was reset.';
                 } else if (reason) {
                     explanation = reason;
                 }
@@ -1210,6 +1220,13 @@ window.addEventListener('DOMContentLoaded', async () => {
             const bufferId = `audio_${Date.now()}`;
             const buffer = await audioService.loadAudioFile(file, bufferId);
 
+            // The desktop backend keeps its own copy so uploads can use the
+            // audio before the project is saved.
+            const imported = await projectService.backend.importAudio?.(bufferId, audioService.getAudioDataURL(bufferId));
+            if (imported && imported !== 'OK') {
+                errorHandler.warning(`Audio is not available for uploads: ${imported}`);
+            }
+
             const clip = {
                 id: `c${Date.now()}`,
                 type: 'audio',
@@ -1657,7 +1674,8 @@ window.addEventListener('DOMContentLoaded', async () => {
             breathe: { color: '#00ffff', speed: 1 },
             heartbeat: { color: '#ff0000', speed: 1 },
             alternate: { colorA: '#ff0000', colorB: '#0000ff' },
-            energy: { color: '#ff00ff', color2: '#00ffff', speed: 1 }
+            energy: { color: '#ff00ff', color2: '#00ffff', speed: 1 },
+            script: { effect: 'solid', script: 'color = hsv(p * 360, 1, 1)', step: 100 }
         };
 
         return {
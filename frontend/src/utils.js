@@ -305,11 +305,20 @@ export function formatPicoStatus(status) {
     const mode = String(status.mode || '').toUpperCase();
     const portLocked = status.serialPortLocked === true;
 
-    // Warning suffix for locked port
-    const lockWarning = portLocked ? ' [PORT BUSY]' : '';
-    const lockTooltip = portLocked
-        ? '\n\nWarning: Serial port is in use by another application (Arduino IDE, PuTTY, etc.). Auto-reset after upload will fail.'
-        : '';
+    const portDenied = status.serialPortDenied === true;
+
+    // Warning suffix for locked or inaccessible port
+    let lockWarning = '';
+    let lockTooltip = '';
+    if (portLocked) {
+        lockWarning = ' [PORT BUSY]';
+        lockTooltip = status.serialPortHolder
+            ? `\n\nWarning: Serial port is in use by ${status.serialPortHolder}. Auto-reset after upload will fail.`
+            : '\n\nWarning: Serial port is in use by another application (Arduino IDE, PuTTY, etc.). Auto-reset after upload will fail.';
+    } else if (portDenied) {
+        lockWarning = ' [NO PERMISSION]';
+        lockTooltip = `\n\nWarning: ${status.serialHint || 'No permission to open the serial port.'} Auto-reset after upload will fail.`;
+    }
 
     if (mode === 'BOOTLOADER') {
         return {
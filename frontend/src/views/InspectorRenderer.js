@@ -19,7 +19,19 @@ const DEFAULT_COLLAPSED = {
     cuePoints: false,         // expanded
     hardwareProfiles: false,  // expanded
     colorPalettes: false,     // expanded
-    propGroups: false         // expanded
+    propGroups: false,        // expanded
+    standby: true             // collapsed
+};
+
+// Effects offered for the standby look (written to show.bin's IDL1 block)
+const STANDBY_EFFECTS = {
+    '': 'None (dark)',
+    solid: 'Solid',
+    breathe: 'Breathe',
+    rainbow: 'Rainbow',
+    sparkle: 'Sparkle',
+    fire: 'Fire',
+    heartbeat: 'Heartbeat'
 };
 
 // Cue marker colors (must match TimelineRenderer)
@@ -363,10 +375,45 @@ export class InspectorRenderer {
         // Groups
         this._renderPropGroups(container, project);
 
+        // Standby Look
+        this._renderStandbySection(container, project);
+
         // Cue Points
         this._renderCueSection(container, project);
     }
 
+    _renderStandbySection(container, project) {
+        const { content } = this._createCollapsibleSection(container, 'standby', 'Standby Look');
+        const div = document.createElement('div');
+        div.className = "bg-[var(--ui-toolbar-bg)] p-2 rounded border border-[var(--ui-border)] space-y-3";
+        div.insertAdjacentHTML('beforeend', `<div class="text-xs text-[var(--ui-text-subtle)]">Shown before the show starts and after it ends.</div>`);
+
+        const standby = project.settings?.standby || {};
+        const update = (fn) => {
+            this.stateManager?.update(draft => {
+                if (!draft.project.settings.standby) {
+                    draft.project.settings.standby = { type: '', props: { color: '#ffffff', speed: 0.2 }, brightness: 0.1 };
+                }
+                fn(draft.project.settings.standby);
+                draft.isDirty = true;
+            });
+        };
+
+        this._addModalSelect(div, "Effect", STANDBY_EFFECTS, standby.type || '', (val) => {
+            update(sb => { sb.type = val; });
+            this.render(null);
+        });
+        if (standby.type) {
+            this._addInput(div, "Color", standby.props?.color || '#ffffff', (e) => {
+                update(sb => { sb.props = { ...sb.props, color: e.target.value }; });
+            }, 'color');
+            this._addModalSlider(div, "Brightness", 0, 100, Math.round((standby.brightness ?? 0.1) * 100), (val) => {
+                update(sb => { sb.brightness = val / 100; });
+            }, (v) => `${v}%`);
+        }
+        content.appendChild(div);
+    }
+
     _renderCueSection(container, project) {
         const cues = project?.cues || [];
 
@@ -1421,12 +1468,28 @@ export class InspectorRenderer {
             this._updateProfile(profile.id, { brightnessCap: parseInt(val) });
         }, (v) => `${Math.round((v / 255) * 100)}%`);
 
-        // Separator for info fields
-        body.insertAdjacentHTML('beforeend', `
-            <div class="border-t border-[var(--ui-border)] pt-4 mt-4">
-                <div class="text-xs text-[var(--ui-text-subtle)] uppercase mb-3">Documentation (Optional)</div>
-            </div>
-        `);
+        // Thermal derating (enclosed props with poor airflow)
+        this._addModalSlider(body, "Sustained Brightness (thermal)", 0, 254, profile.thermalCap ?? 0, (val) => {
+            this._updateProfile(profile.id, { thermalCap: parseInt(val) });
+        }, (v) => v === 0 ? 'Off' : `${Math.round((v / 255) * 100)}%`);
+        this._addModalField(body, "Derate After (seconds)", "number", profile.thermalAfter ?? 0, (val) => {
+            this._updateProfile(profile.id, { thermalAfter: Math.max(0, Math.min(65535, parseInt(val) || 0)) });
+        });
+
+        // Radio zone (multi-transmitter rigs)
+        const zones = {};
+        for (let z = 0; z <= 15; z++) zones[z] = z === 0 ? 'Zone 0 (default transmitter)' : `Zone ${z}`;
+        this._addModalSelect(body, "Radio Zone", zones, profile.zone ?? 0, (val) => {
+            this._updateProfile(profile.id, { zone: parseInt(val) });
+        });
+
+        // Audio playback (uploads copy the show audio to these receivers)
+        this._addModalSelect(body, "Audio Playback", {
+            0: 'None',
+            1: 'Plays show audio (SD/flash)'
+        }, profile.audio ? 1 : 0, (val) => {
+            this._updateProfile(profile.id, { audio: val === '1' });
+        });
 
         // Voltage
         this._addModalSelect(body, "Voltage", {
@@ -1437,6 +1500,18 @@ export class InspectorRenderer {
             this._updateProfile(profile.id, { voltage: parseInt(val) });
         });
 
+        // Power budget (battery/PSU watts per prop; clips above it are dimmed when limiting is on)
+        this._addModalField(body, "Power Budget (W per prop, 0 = none)", "number", profile.powerBudget ?? 0, (val) => {
+            this._updateProfile(profile.id, { powerBudget: Math.max(0, parseFloat(val) || 0) });
+        });
+
+        // Separator for info fields
+        body.insertAdjacentHTML('beforeend', `
+            <div class="border-t border-[var(--ui-border)] pt-4 mt-4">
+                <div class="text-xs text-[var(--ui-text-subtle)] uppercase mb-3">Documentation (Optional)</div>
+            </div>
+        `);
+
         // Physical Length
         // Notes
         this._addModalTextarea(body, "Notes", profile.notes || '', (val) => {
@@ -1763,6 +1838,24 @@ export class InspectorRenderer {
             };
             ids.onkeydown = (e) => { if (e.key === 'Enter') { e.preventDefault(); ids.blur(); } };
             row2.appendChild(ids);
+
+            // Prop bank: IDs repeat in every bank of 224 receivers
+            const bank = document.createElement('input');
+            bank.type = 'number';
+            bank.min = 0; bank.max = 255;
+            bank.title = "Bank (receivers bank × 224 + ID)";
+            bank.className = "bg-[var(--ui-select-bg)] text-xs text-[var(--ui-text)] rounded px-1 py-0.5 w-12 ml-1 outline-none border border-[var(--ui-border)]";
+            bank.value = grp.bank || 0;
+            bank.onchange = e => {
+                const val = Math.max(0, Math.min(255, parseInt(e.target.value) || 0));
+                bank.value = val;
+                this.stateManager?.update(draft => {
+                    const g = (draft.project.propGroups || []).find(x => x.id === grp.id);
+                    if (g) { g.bank = val; draft.isDirty = true; }
+                });
+            };
+            row2.insertAdjacentHTML('beforeend', `<span class="text-xs text-[var(--ui-text-subtle)] ml-2">Bank:</span>`);
+            row2.appendChild(bank);
             idsContainer.appendChild(row2);
             idsContainer.appendChild(idsError);
             card.appendChild(idsContainer);
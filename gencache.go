@@ -0,0 +1,304 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	"PicoLume/bingen"
+	"PicoLume/logger"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// ==========================================================
+// GENERATION CACHE
+// ==========================================================
+
+// genCache remembers the last generated show.bin so repeated uploads and
+// exports of an unchanged project skip regeneration, and tracks the
+// generations in progress.
+type genCache struct {
+	mu     sync.Mutex
+	key    [sha256.Size]byte
+	result *bingen.Result
+
+	// Running generations by purpose; see beginGeneration.
+	running map[genPurpose]genRun
+	lastID  uint64
+}
+
+// genPurpose says what a show.bin is generated for. A new generation only
+// replaces a running one of the same purpose, so a live preview starting
+// does not cancel an upload waiting on its show.bin.
+type genPurpose string
+
+const (
+	genUpload    genPurpose = "upload"
+	genExport    genPurpose = "export"
+	genPlaylist  genPurpose = "playlist"
+	genPreview   genPurpose = "live preview"
+	genHotReload genPurpose = "hot reload"
+	genHash      genPurpose = "hash"
+)
+
+type genRun struct {
+	id     uint64
+	cancel context.CancelFunc
+}
+
+// genCacheKey hashes the project together with the generation options and
+// current format versions, so a Studio update that changes the output never
+// reuses bytes.
+func genCacheKey(projectJSON string, opts bingen.Options) [sha256.Size]byte {
+	h := sha256.New()
+	binary.Write(h, binary.LittleEndian, [5]uint16{uint16(opts.FormatVersion), uint16(opts.TargetVersion), bingen.FormatVersion, bingen.CueBlockVersion, bingen.CueBlockV2})
+	h.Write([]byte(opts.Overlap + "\x00"))
+	h.Write([]byte(opts.Conflicts + "\x00"))
+	h.Write([]byte(opts.Props + "\x00"))
+	binary.Write(h, binary.LittleEndian, uint16(opts.Bank))
+	if opts.Strict {
+		h.Write([]byte{1})
+	}
+	if opts.LimitPower {
+		h.Write([]byte{2})
+	}
+	if opts.BlackoutCue {
+		h.Write([]byte{3})
+	}
+	if opts.Checksum {
+		h.Write([]byte{4})
+	}
+	if opts.Optimize {
+		h.Write([]byte{5})
+	}
+	h.Write([]byte(projectJSON))
+	var key [sha256.Size]byte
+	h.Sum(key[:0])
+	return key
+}
+
+// GenerateProgress is the payload of "generate:progress" events.
+type GenerateProgress struct {
+	Done  int `json:"done"`  // tracks encoded
+	Total int `json:"total"` // tracks in the project
+}
+
+type genOutcome struct {
+	result *bingen.Result
+	err    error
+}
+
+var errGenerationPanicked = errors.New("show generation crashed; see the crash report")
+
+// showOptions returns the generation options from settings, with the
+// format version overridden if non-zero.
+func (a *App) showOptions(formatVersion int) bingen.Options {
+	s := a.currentSettings()
+	if formatVersion == 0 {
+		formatVersion = s.ShowFormatVersion
+	}
+	return bingen.Options{
+		FormatVersion: formatVersion,
+		Overlap:       bingen.OverlapPolicy(s.Overlap),
+		Conflicts:     bingen.ConflictPolicy(s.TrackConflicts),
+		Strict:        s.StrictValidation,
+		LimitPower:    s.LimitPower,
+		BlackoutCue:   s.BlackoutCue,
+		Checksum:      s.ShowChecksum,
+		Optimize:      s.OptimizeShow,
+	}
+}
+
+// generateShow returns show.bin for projectJSON, from the cache when the
+// project and options are unchanged. Generation runs in a background goroutine that
+// emits "generate:progress" and can be stopped with CancelGeneration, in
+// which case context.Canceled is returned. A new generation cancels one
+// still running for the same purpose. The returned bytes are shared and
+// must not be modified.
+func (a *App) generateShow(purpose genPurpose, projectJSON string, opts bingen.Options) (*bingen.Result, error) {
+	key := genCacheKey(projectJSON, opts)
+	if result := a.cachedShow(key); result != nil {
+		return result, nil
+	}
+	result, err := a.runGeneration(purpose, projectJSON, opts, nil)
+	if err != nil {
+		return nil, err
+	}
+	a.gen.mu.Lock()
+	a.gen.key, a.gen.result = key, result
+	a.gen.mu.Unlock()
+	return result, nil
+}
+
+// exportShow writes show.bin for projectJSON to w like generateShow, but
+// streams it as it is encoded instead of holding the whole file, for
+// exports of shows too large to buffer. A cached show is copied out; a
+// new one is not cached.
+func (a *App) exportShow(w io.Writer, projectJSON string, opts bingen.Options) (*bingen.Result, error) {
+	if result := a.cachedShow(genCacheKey(projectJSON, opts)); result != nil {
+		if _, err := w.Write(result.Bytes); err != nil {
+			return nil, err
+		}
+		return result, nil
+	}
+	return a.runGeneration(genExport, projectJSON, opts, w)
+}
+
+func (a *App) cachedShow(key [sha256.Size]byte) *bingen.Result {
+	a.gen.mu.Lock()
+	defer a.gen.mu.Unlock()
+	if a.gen.result != nil && a.gen.key == key {
+		logger.Debug("generateShow: Using cached show.bin (%d bytes)", len(a.gen.result.Bytes))
+		return a.gen.result
+	}
+	return nil
+}
+
+// beginGeneration registers a generation for purpose, cancelling one
+// already running for it, and returns its context and the function to call
+// once it is done.
+func (a *App) beginGeneration(purpose genPurpose) (context.Context, func()) {
+	ctx, cancel := context.WithCancel(context.Background())
+	a.gen.mu.Lock()
+	if run, ok := a.gen.running[purpose]; ok {
+		run.cancel()
+	}
+	if a.gen.running == nil {
+		a.gen.running = make(map[genPurpose]genRun)
+	}
+	a.gen.lastID++
+	id := a.gen.lastID
+	a.gen.running[purpose] = genRun{id: id, cancel: cancel}
+	a.gen.mu.Unlock()
+
+	return ctx, func() {
+		cancel()
+		a.gen.mu.Lock()
+		if a.gen.running[purpose].id == id {
+			delete(a.gen.running, purpose)
+		}
+		a.gen.mu.Unlock()
+	}
+}
+
+// cancelGeneration stops the running generation for purpose, if any, and
+// reports whether there was one.
+func (a *App) cancelGeneration(purpose genPurpose) bool {
+	a.gen.mu.Lock()
+	run, ok := a.gen.running[purpose]
+	a.gen.mu.Unlock()
+	if ok {
+		run.cancel()
+	}
+	return ok
+}
+
+// runGeneration generates show.bin in a background goroutine, into
+// Result.Bytes or, if w is set, streamed to w.
+func (a *App) runGeneration(purpose genPurpose, projectJSON string, opts bingen.Options, w io.Writer) (*bingen.Result, error) {
+	ctx, end := a.beginGeneration(purpose)
+	defer end()
+
+	done := make(chan genOutcome, 1)
+	a.goSafe("show generation", func() {
+		outcome := genOutcome{err: errGenerationPanicked}
+		defer func() { done <- outcome }()
+
+		// Decoding needs a byte copy of the JSON plus the parsed structs.
+		releaseParse, err := a.reserveMemory("Reading the project", 3*int64(len(projectJSON)))
+		if err != nil {
+			outcome.err = err
+			return
+		}
+		var p bingen.Project
+		err = json.Unmarshal([]byte(projectJSON), &p)
+		releaseParse()
+		if err != nil {
+			outcome.err = fmt.Errorf("failed to parse project JSON: %w", err)
+			return
+		}
+
+		// Events are buffered, and show.bin too unless it is streamed.
+		need := bingen.MaxSize(&p)
+		if w == nil {
+			need *= 2
+		}
+		release, err := a.reserveMemory("Generating show.bin", need)
+		if err != nil {
+			outcome.err = err
+			return
+		}
+		defer release()
+		// One event per percent; projects can have thousands of tracks.
+		lastPct := -1
+		progress := func(done, total int) {
+			if pct := done * 100 / max(total, 1); pct != lastPct {
+				lastPct = pct
+				a.emitGenerateProgress(done, total)
+			}
+		}
+		opts.Progress = progress
+		if w != nil {
+			outcome.result, outcome.err = bingen.GenerateToContext(ctx, w, &p, opts)
+		} else {
+			outcome.result, outcome.err = bingen.GenerateContext(ctx, &p, opts)
+		}
+	})
+
+	var outcome genOutcome
+	select {
+	case outcome = <-done:
+	case <-ctx.Done():
+		// The worker notices at its next check and exits on its own; one
+		// streaming to w is waited for, so the caller can close it.
+		if w != nil {
+			<-done
+		}
+		outcome.err = ctx.Err()
+	}
+	if outcome.err != nil {
+		if errors.Is(outcome.err, context.Canceled) {
+			logger.Info("generateShow: Cancelled %s generation", purpose)
+		}
+		return nil, outcome.err
+	}
+
+	if warnings := outcome.result.Warnings; len(warnings) > 0 {
+		logger.Warn("generateShow: Fixed %d invalid value(s), first: %v", len(warnings), &warnings[0])
+	}
+	if limits := outcome.result.PowerLimits; len(limits) > 0 {
+		logger.Warn("generateShow: %d clip(s) over their power budget, first: track %d clip %d at %.2f of full brightness", len(limits), limits[0].Track+1, limits[0].Clip+1, limits[0].Scale)
+	}
+	if o := outcome.result.Optimized; o != nil {
+		logger.Info("generateShow: Optimized %d events to %d (%d to %d bytes)", o.EventsBefore, o.EventsAfter, o.BytesBefore, o.BytesAfter)
+	}
+	return outcome.result, nil
+}
+
+func (a *App) emitGenerateProgress(done, total int) {
+	if a.ctx != nil {
+		runtime.EventsEmit(a.ctx, "generate:progress", GenerateProgress{Done: done, Total: total})
+	}
+}
+
+// CancelGeneration stops the show.bin generations the user waits on, for
+// an upload, export or playlist; the operation returns "Cancelled".
+// Background generations, such as for the live preview, keep running.
+func (a *App) CancelGeneration() string {
+	cancelled := false
+	for _, purpose := range []genPurpose{genUpload, genExport, genPlaylist} {
+		if a.cancelGeneration(purpose) {
+			cancelled = true
+		}
+	}
+	if !cancelled {
+		return "OK"
+	}
+	return "Cancelled"
+}
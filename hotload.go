@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"PicoLume/hotload"
+	"PicoLume/i18n"
+	"PicoLume/serialsession"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// ==========================================================
+// HOT RELOAD
+// ==========================================================
+
+// HotReloadProgress is the payload of "hotload:progress" events.
+type HotReloadProgress struct {
+	Sent  int `json:"sent"`  // bytes acknowledged by the receiver
+	Total int `json:"total"` // size of show.bin
+}
+
+// HotReloadShow generates show.bin and streams it to the receiver on the
+// open serial session, which swaps it in without a reboot. The old show
+// keeps playing if the transfer fails.
+func (a *App) HotReloadShow(projectJson string) string {
+	defer a.recoverBinding("HotReloadShow")
+	s := a.currentSerialSession()
+	if s == nil {
+		return i18n.T("Error: No serial session open")
+	}
+	if s.State() != serialsession.StateConnected {
+		return i18n.T("Error: Serial session on %s is %s", s.Name(), s.State())
+	}
+
+	result, err := a.generateShow(genHotReload, projectJson, a.showOptions(0))
+	if errors.Is(err, context.Canceled) {
+		return "Cancelled"
+	}
+	if err != nil {
+		return "Error: " + err.Error()
+	}
+
+	start := time.Now()
+	err = hotload.Send(context.Background(), s, result.Bytes, hotload.Options{
+		Progress: func(sent, total int) {
+			if a.ctx != nil {
+				runtime.EventsEmit(a.ctx, "hotload:progress", HotReloadProgress{Sent: sent, Total: total})
+			}
+		},
+	})
+	if err != nil {
+		serialLog.Warn("HotReloadShow: Transfer to %s failed: %v", s.Name(), err)
+		return "Error: " + err.Error()
+	}
+	serialLog.Info("HotReloadShow: Sent %d bytes to %s in %s", len(result.Bytes), s.Name(), time.Since(start).Round(time.Millisecond))
+	return "OK"
+}
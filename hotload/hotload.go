@@ -0,0 +1,141 @@
+// Package hotload pushes an updated show.bin to a receiver over its serial
+// session and swaps it in without a reboot, so a change made in rehearsal
+// reaches a prop in seconds instead of a USB drive and reset cycle.
+//
+// The protocol runs on the session's command lines and OK/ERR replies:
+//
+//	load begin <size> <crc>                 OK
+//	load chunk <offset> <crc> <base64>      OK <offset>
+//	load apply                              OK
+//	load abort                              OK
+//
+// CRCs are CRC-32 (IEEE) as 8 lowercase hex digits. The device acknowledges
+// each chunk after checking its CRC and answers "ERR crc" to have it sent
+// again. On apply it checks the whole file against the CRC from begin,
+// stores it as show.bin and restarts playback from the new file; until
+// then the old show keeps running.
+package hotload
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"strconv"
+	"time"
+
+	"PicoLume/serialsession"
+)
+
+// Defaults and limits for Options.
+const (
+	DefaultChunkSize = 192 // 256 base64 characters per line
+	MaxChunkSize     = 2048
+	DefaultRetries   = 3
+	DefaultTimeout   = 2 * time.Second
+
+	// ApplyTimeout allows for the device writing the file to flash.
+	ApplyTimeout = 10 * time.Second
+)
+
+// Device sends a command line and returns the text after OK, a
+// *serialsession.DeviceError for ERR replies, or serialsession.ErrTimeout.
+// *serialsession.Session implements it.
+type Device interface {
+	Command(cmd string, timeout time.Duration) (string, error)
+}
+
+// Options controls Send.
+type Options struct {
+	ChunkSize int           // raw bytes per chunk; DefaultChunkSize if 0
+	Retries   int           // resends per chunk; DefaultRetries if 0
+	Timeout   time.Duration // per command; DefaultTimeout if 0
+
+	// Progress, if set, is called after each acknowledged chunk.
+	Progress func(sent, total int)
+}
+
+// ErrRejected means the device refused the update.
+var ErrRejected = errors.New("receiver rejected the update")
+
+// Send transfers data to dev and applies it. On failure it aborts the
+// transfer so the device discards what it received.
+func Send(ctx context.Context, dev Device, data []byte, opts Options) (err error) {
+	chunk := opts.ChunkSize
+	if chunk <= 0 {
+		chunk = DefaultChunkSize
+	}
+	if chunk > MaxChunkSize {
+		return fmt.Errorf("chunk size %d above %d", chunk, MaxChunkSize)
+	}
+	retries := opts.Retries
+	if retries <= 0 {
+		retries = DefaultRetries
+	}
+	timeout := opts.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	if len(data) == 0 {
+		return errors.New("nothing to send")
+	}
+
+	if _, err := dev.Command(fmt.Sprintf("load begin %d %08x", len(data), crc32.ChecksumIEEE(data)), timeout); err != nil {
+		return fmt.Errorf("%w: %v", ErrRejected, err)
+	}
+	defer func() {
+		if err != nil {
+			dev.Command("load abort", timeout)
+		}
+	}()
+
+	for off := 0; off < len(data); off += chunk {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		part := data[off:min(off+chunk, len(data))]
+		cmd := fmt.Sprintf("load chunk %d %08x %s", off, crc32.ChecksumIEEE(part), base64.StdEncoding.EncodeToString(part))
+		if err := sendChunk(dev, cmd, off, retries, timeout); err != nil {
+			return fmt.Errorf("chunk at byte %d: %w", off, err)
+		}
+		if opts.Progress != nil {
+			opts.Progress(off+len(part), len(data))
+		}
+	}
+
+	if _, err := dev.Command("load apply", ApplyTimeout); err != nil {
+		return fmt.Errorf("%w: %v", ErrRejected, err)
+	}
+	return nil
+}
+
+// sendChunk sends one chunk until the device acknowledges its offset,
+// resending after CRC errors, lost replies and timeouts.
+func sendChunk(dev Device, cmd string, off, retries int, timeout time.Duration) error {
+	var lastErr error
+	for attempt := 0; attempt <= retries; attempt++ {
+		reply, err := dev.Command(cmd, timeout)
+		switch {
+		case err == nil && reply == strconv.Itoa(off):
+			return nil
+		case err == nil:
+			lastErr = fmt.Errorf("unexpected reply %q", reply)
+		case isRetryable(err):
+			lastErr = err
+		default:
+			return fmt.Errorf("%w: %v", ErrRejected, err)
+		}
+	}
+	return lastErr
+}
+
+// isRetryable reports whether a failed chunk may be sent again: CRC
+// errors and lost replies, but not refusals such as "ERR no transfer".
+func isRetryable(err error) bool {
+	var de *serialsession.DeviceError
+	if errors.As(err, &de) {
+		return de.Message == "crc"
+	}
+	return errors.Is(err, serialsession.ErrTimeout)
+}
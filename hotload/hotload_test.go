@@ -0,0 +1,108 @@
+package hotload
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"PicoLume/serialsession"
+)
+
+// fakeDevice implements the receiver side of the protocol.
+type fakeDevice struct {
+	buf      []byte
+	size     int
+	crc      string
+	applied  []byte
+	aborted  bool
+	corrupt  int // chunk offset whose first copy arrives damaged
+	refuse   bool
+	commands int
+}
+
+func (d *fakeDevice) Command(cmd string, _ time.Duration) (string, error) {
+	d.commands++
+	f := strings.Fields(cmd)
+	switch strings.Join(f[:2], " ") {
+	case "load begin":
+		if d.refuse {
+			return "", &serialsession.DeviceError{Message: "busy"}
+		}
+		d.size, _ = strconv.Atoi(f[2])
+		d.crc, d.buf = f[3], nil
+	case "load chunk":
+		data, _ := base64.StdEncoding.DecodeString(f[4])
+		if f[2] == strconv.Itoa(d.corrupt) && d.corrupt >= 0 {
+			d.corrupt = -1
+			data[0] ^= 0xFF
+		}
+		if fmt.Sprintf("%08x", crc32.ChecksumIEEE(data)) != f[3] {
+			return "", &serialsession.DeviceError{Message: "crc"}
+		}
+		if off, _ := strconv.Atoi(f[2]); off == len(d.buf) {
+			d.buf = append(d.buf, data...)
+		}
+		return f[2], nil
+	case "load apply":
+		if len(d.buf) != d.size || fmt.Sprintf("%08x", crc32.ChecksumIEEE(d.buf)) != d.crc {
+			return "", &serialsession.DeviceError{Message: "file crc"}
+		}
+		d.applied = d.buf
+	case "load abort":
+		d.aborted = true
+	}
+	return "", nil
+}
+
+func TestSendResendsCorruptChunk(t *testing.T) {
+	data := bytes.Repeat([]byte("show.bin "), 100) // 900 bytes, 5 chunks
+	dev := &fakeDevice{corrupt: 2 * DefaultChunkSize}
+	var progress []int
+	err := Send(context.Background(), dev, data, Options{Progress: func(sent, total int) { progress = append(progress, sent) }})
+	if err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	if !bytes.Equal(dev.applied, data) {
+		t.Errorf("applied %d bytes, want the %d sent", len(dev.applied), len(data))
+	}
+	if dev.commands != 1+5+1+1 { // begin, chunks, one resend, apply
+		t.Errorf("sent %d commands, want 8", dev.commands)
+	}
+	if len(progress) != 5 || progress[4] != len(data) {
+		t.Errorf("progress = %v", progress)
+	}
+}
+
+func TestSendAbortsWhenRefused(t *testing.T) {
+	if err := Send(context.Background(), &fakeDevice{refuse: true}, []byte("x"), Options{}); !errors.Is(err, ErrRejected) {
+		t.Errorf("Send() error = %v, want ErrRejected", err)
+	}
+
+	// A chunk that keeps failing its CRC aborts the transfer.
+	dev := &fakeDevice{corrupt: -1}
+	broken := &alwaysCorrupt{dev}
+	if err := Send(context.Background(), broken, []byte("hello"), Options{Retries: 2}); err == nil || !dev.aborted {
+		t.Errorf("Send() error = %v, aborted = %v; want error and abort", err, dev.aborted)
+	}
+	if dev.applied != nil {
+		t.Error("a failed transfer was applied")
+	}
+}
+
+// alwaysCorrupt damages every chunk.
+type alwaysCorrupt struct{ *fakeDevice }
+
+func (d *alwaysCorrupt) Command(cmd string, timeout time.Duration) (string, error) {
+	if strings.HasPrefix(cmd, "load chunk") {
+		d.commands++
+		return "", &serialsession.DeviceError{Message: "crc"}
+	}
+	return d.fakeDevice.Command(cmd, timeout)
+}
@@ -0,0 +1,184 @@
+// Package i18n translates backend-produced user-facing strings.
+//
+// Catalogs are keyed by the English format string, so untranslated messages
+// fall back to English unchanged:
+//
+//	i18n.T("Uploading show.bin to %s...", drive)
+//
+// Built-in catalogs are embedded from locales/*.json; users can add or
+// override languages by dropping <lang>.json files into a directory passed
+// to LoadDir.
+package i18n
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// SourceLanguage is the language the keys are written in.
+const SourceLanguage = "en"
+
+// MaxCatalogSize bounds user-provided catalog files.
+const MaxCatalogSize = 2 * 1024 * 1024
+
+//go:embed locales/*.json
+var embedded embed.FS
+
+// Catalog maps English format strings to their translation.
+type Catalog map[string]string
+
+// Bundle holds all known catalogs and the active language. It is safe for
+// concurrent use.
+type Bundle struct {
+	mu       sync.RWMutex
+	catalogs map[string]Catalog
+	lang     string
+}
+
+// Default is the bundle used by T.
+var Default = New()
+
+// New returns a bundle with the embedded catalogs loaded.
+func New() *Bundle {
+	b := &Bundle{catalogs: make(map[string]Catalog), lang: SourceLanguage}
+	if err := b.loadFS(embedded, "locales"); err != nil {
+		// Embedded catalogs are validated by tests; this is unreachable in
+		// a correct build.
+		panic(err)
+	}
+	return b
+}
+
+// LoadDir merges <lang>.json catalogs from dir over the built-in ones. A
+// missing dir is not an error.
+func (b *Bundle) LoadDir(dir string) error {
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		return nil
+	}
+	return b.loadFS(os.DirFS(dir), ".")
+}
+
+func (b *Bundle) loadFS(fsys fs.FS, dir string) error {
+	paths, err := fs.Glob(fsys, filepath.ToSlash(filepath.Join(dir, "*.json")))
+	if err != nil {
+		return err
+	}
+	for _, p := range paths {
+		info, err := fs.Stat(fsys, p)
+		if err != nil {
+			return err
+		}
+		if info.Size() > MaxCatalogSize {
+			return fmt.Errorf("catalog %s too large", p)
+		}
+		data, err := fs.ReadFile(fsys, p)
+		if err != nil {
+			return err
+		}
+		var cat Catalog
+		if err := json.Unmarshal(data, &cat); err != nil {
+			return fmt.Errorf("invalid catalog %s: %w", p, err)
+		}
+
+		lang := normalize(strings.TrimSuffix(filepath.Base(p), ".json"))
+		b.mu.Lock()
+		if b.catalogs[lang] == nil {
+			b.catalogs[lang] = make(Catalog)
+		}
+		for k, v := range cat {
+			if v != "" {
+				b.catalogs[lang][k] = v
+			}
+		}
+		b.mu.Unlock()
+	}
+	return nil
+}
+
+// SetLanguage selects the active language ("de", "de-AT", ...). Regional
+// variants fall back to the base language; unknown languages and "" select
+// English.
+func (b *Bundle) SetLanguage(lang string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.lang = b.resolve(lang)
+}
+
+// Language returns the active language.
+func (b *Bundle) Language() string {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.lang
+}
+
+// Languages lists languages with a catalog, plus the source language.
+func (b *Bundle) Languages() []string {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	langs := []string{SourceLanguage}
+	for lang := range b.catalogs {
+		if lang != SourceLanguage {
+			langs = append(langs, lang)
+		}
+	}
+	sort.Strings(langs[1:])
+	return langs
+}
+
+// Catalog returns a copy of the catalog for lang (after fallback), for the
+// frontend to use with the same keys.
+func (b *Bundle) Catalog(lang string) (string, Catalog) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	lang = b.resolve(lang)
+	out := make(Catalog, len(b.catalogs[lang]))
+	for k, v := range b.catalogs[lang] {
+		out[k] = v
+	}
+	return lang, out
+}
+
+// T translates format into the active language and applies args with
+// fmt.Sprintf.
+func (b *Bundle) T(format string, args ...interface{}) string {
+	b.mu.RLock()
+	if translated, ok := b.catalogs[b.lang][format]; ok {
+		format = translated
+	}
+	b.mu.RUnlock()
+	if len(args) == 0 {
+		return format
+	}
+	return fmt.Sprintf(format, args...)
+}
+
+// resolve maps a requested language to one with a catalog. Caller must hold
+// b.mu.
+func (b *Bundle) resolve(lang string) string {
+	lang = normalize(lang)
+	if _, ok := b.catalogs[lang]; ok {
+		return lang
+	}
+	if base, _, ok := strings.Cut(lang, "-"); ok {
+		if _, ok := b.catalogs[base]; ok {
+			return base
+		}
+	}
+	return SourceLanguage
+}
+
+func normalize(lang string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(lang), "_", "-"))
+}
+
+// T translates using the Default bundle.
+func T(format string, args ...interface{}) string {
+	return Default.T(format, args...)
+}
@@ -0,0 +1,72 @@
+package i18n
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"testing"
+)
+
+var verbPattern = regexp.MustCompile(`%[-+# 0-9.]*[a-zA-Z%]`)
+
+// TestEmbeddedCatalogsKeepVerbs guards against translations that drop or
+// reorder format verbs, which would garble messages at runtime.
+func TestEmbeddedCatalogsKeepVerbs(t *testing.T) {
+	b := New()
+	for _, lang := range b.Languages() {
+		_, cat := b.Catalog(lang)
+		for key, value := range cat {
+			want := verbPattern.FindAllString(key, -1)
+			got := verbPattern.FindAllString(value, -1)
+			if len(got) != len(want) {
+				t.Errorf("%s: %q has verbs %v, want %v", lang, value, got, want)
+				continue
+			}
+			for i := range want {
+				if got[i] != want[i] {
+					t.Errorf("%s: %q has verbs %v, want %v", lang, value, got, want)
+					break
+				}
+			}
+		}
+	}
+}
+
+func TestTranslateWithFallback(t *testing.T) {
+	b := New()
+	b.SetLanguage("de-AT")
+	if b.Language() != "de" {
+		t.Fatalf("Language() = %q, want de", b.Language())
+	}
+	if got := b.T("Uploaded %d events. Device is reloading.", 3); got != "3 Ereignisse hochgeladen. Das Gerät lädt neu." {
+		t.Errorf("T() = %q", got)
+	}
+	if got := b.T("Untranslated %s", "x"); got != "Untranslated x" {
+		t.Errorf("T() untranslated = %q", got)
+	}
+
+	b.SetLanguage("xx")
+	if b.Language() != SourceLanguage {
+		t.Errorf("unknown language selected %q", b.Language())
+	}
+}
+
+func TestLoadDirOverrides(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "fr.json"), []byte(`{"Export cancelled":"Export annulé"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	b := New()
+	if err := b.LoadDir(dir); err != nil {
+		t.Fatalf("LoadDir() error = %v", err)
+	}
+	langs := b.Languages()
+	if !sort.StringsAreSorted(langs[1:]) || langs[0] != SourceLanguage {
+		t.Errorf("Languages() = %v", langs)
+	}
+	b.SetLanguage("fr")
+	if got := b.T("Export cancelled"); got != "Export annulé" {
+		t.Errorf("T() = %q", got)
+	}
+}
@@ -0,0 +1,212 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"PicoLume/bingen"
+	"PicoLume/i18n"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// ==========================================================
+// BINARY INSPECTOR
+// ==========================================================
+
+// Inspection limits: a full V3 show.bin is about 3 MB, and a report lists
+// at most maxInspectEvents events.
+const (
+	maxShowBinSize   = 16 * megabyte
+	maxInspectEvents = 10000
+)
+
+// BinaryReport is returned by InspectBinary.
+type BinaryReport struct {
+	Path       string `json:"path"`
+	Size       int    `json:"size"`
+	Version    int    `json:"version"`
+	EventCount int    `json:"eventCount"`
+	DurationMs uint32 `json:"durationMs"` // end of the last event
+
+	Props       []PropReport      `json:"props"`  // props whose LUT entry is set
+	Blocks      []string          `json:"blocks"` // extension block magics, in file order
+	Cues        map[string]uint32 `json:"cues"`   // cue ID -> ms
+	BlackoutCue bool              `json:"blackoutCue"`
+	Checksum    bool              `json:"checksum"` // CRC1 footer present and valid
+	Hash        string            `json:"hash"`     // as ShowHash reports it
+
+	Events    []EventReport `json:"events"`
+	Truncated bool          `json:"truncated"` // more events than listed
+
+	Error string `json:"error"`
+}
+
+// PropReport is one PropConfig LUT entry.
+type PropReport struct {
+	ID            int    `json:"id"`
+	LedCount      int    `json:"ledCount"`
+	LedType       int    `json:"ledType"`
+	ColorOrder    string `json:"colorOrder"`
+	BrightnessCap int    `json:"brightnessCap"`
+	SustainedCap  int    `json:"sustainedCap"`
+	DerateAfter   int    `json:"derateAfter"` // seconds
+	Zone          int    `json:"zone"`
+}
+
+// EventReport is one event, decoded for display.
+type EventReport struct {
+	StartTime uint32 `json:"startTime"`
+	Duration  uint32 `json:"duration"`
+	Effect    string `json:"effect"` // clip type, "off", or the code if unknown
+	Speed     int    `json:"speed"`
+	Width     int    `json:"width"`
+	Color     string `json:"color"`
+	Color2    string `json:"color2"`
+	Props     string `json:"props"` // ID list, e.g. "1-18,20"
+	Bank      int    `json:"bank"`
+}
+
+// InspectBinary decodes a show.bin, such as the one on a receiver's drive,
+// for comparing what a device holds with what the project says. An empty
+// path asks for a file.
+func (a *App) InspectBinary(path string) BinaryReport {
+	defer a.recoverBinding("InspectBinary")
+
+	if path == "" {
+		var err error
+		path, err = runtime.OpenFileDialog(a.ctx, runtime.OpenDialogOptions{
+			Title: i18n.T("Inspect show.bin"),
+			Filters: []runtime.FileFilter{
+				{DisplayName: "Binary Files (*.bin)", Pattern: "*.bin"},
+			},
+		})
+		if err != nil || path == "" {
+			return BinaryReport{Error: "Cancelled"}
+		}
+	}
+	report, err := inspectBinary(path)
+	if err != nil {
+		return BinaryReport{Path: path, Error: err.Error()}
+	}
+	return *report
+}
+
+// HashResponse is returned by ShowHash.
+type HashResponse struct {
+	Hash  string `json:"hash"`
+	Error string `json:"error"`
+}
+
+// ShowHash identifies the show.bin an upload of the project would write
+// with the current settings. It equals the hash InspectBinary reports for a
+// receiver's show.bin exactly when the receiver holds that show.
+func (a *App) ShowHash(projectJson string) HashResponse {
+	defer a.recoverBinding("ShowHash")
+
+	result, err := a.generateShow(genHash, projectJson, a.showOptions(0))
+	if err != nil {
+		return HashResponse{Error: err.Error()}
+	}
+	return HashResponse{Hash: bingen.HashBytes(result.Bytes)}
+}
+
+// SizeResponse is returned by EstimateShowSize.
+type SizeResponse struct {
+	Bytes      int64  `json:"bytes"`
+	Events     int    `json:"events"`
+	LimitBytes int64  `json:"limitBytes"` // the showFlashKb setting
+	Fits       bool   `json:"fits"`
+	Error      string `json:"error"`
+}
+
+// EstimateShowSize forecasts the show.bin an upload of the project would
+// write with the current settings, without generating it, so the editor
+// can warn while the show grows past the receivers' flash.
+func (a *App) EstimateShowSize(projectJson string) SizeResponse {
+	defer a.recoverBinding("EstimateShowSize")
+
+	p, err := parseProject(projectJson)
+	if err != nil {
+		return SizeResponse{Error: "Invalid project - " + err.Error()}
+	}
+	est := bingen.EstimateSize(p, a.showOptions(0))
+	limit := int64(a.currentSettings().ShowFlashKB) * 1024
+	return SizeResponse{
+		Bytes:      est.Bytes,
+		Events:     est.Events,
+		LimitBytes: limit,
+		Fits:       est.Bytes <= limit,
+	}
+}
+
+func inspectBinary(path string) (*BinaryReport, error) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if fi.Size() > maxShowBinSize {
+		return nil, fmt.Errorf("%s is %d MB, too large for a show.bin", path, fi.Size()/megabyte)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	_, info, err := bingen.Parse(data)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &BinaryReport{
+		Path:        path,
+		Size:        len(data),
+		Version:     info.Version,
+		EventCount:  len(info.Events),
+		Props:       []PropReport{},
+		Blocks:      []string{},
+		Cues:        info.Cues,
+		BlackoutCue: info.BlackoutCue,
+		Checksum:    info.Checksum,
+		Hash:        bingen.HashBytes(data),
+		Events:      []EventReport{},
+	}
+	if info.Version >= bingen.FormatV3 {
+		for i, c := range info.Props {
+			order := fmt.Sprint(c.ColorOrder)
+			if int(c.ColorOrder) < len(bingen.ColorOrderNames) {
+				order = bingen.ColorOrderNames[c.ColorOrder]
+			}
+			r.Props = append(r.Props, PropReport{
+				ID: i + 1, LedCount: int(c.LedCount), LedType: int(c.LedType), ColorOrder: order,
+				BrightnessCap: int(c.BrightnessCap), SustainedCap: int(c.SustainedCap),
+				DerateAfter: int(c.DerateAfter), Zone: int(info.Zones[i]),
+			})
+		}
+	}
+	for _, b := range info.Blocks {
+		r.Blocks = append(r.Blocks, b.Magic)
+	}
+
+	names := map[uint8]string{0: "off"}
+	for _, e := range bingen.EffectSchemas() {
+		names[e.Code] = e.Name
+	}
+	for i, e := range info.Events {
+		r.DurationMs = max(r.DurationMs, e.StartTime+e.Duration)
+		if i >= maxInspectEvents {
+			r.Truncated = true
+			continue
+		}
+		effect, ok := names[e.Effect]
+		if !ok {
+			effect = fmt.Sprint(e.Effect)
+		}
+		r.Events = append(r.Events, EventReport{
+			StartTime: e.StartTime, Duration: e.Duration, Effect: effect,
+			Speed: int(e.Speed), Width: int(e.Width),
+			Color: fmt.Sprintf("#%06x", e.Color), Color2: fmt.Sprintf("#%06x", e.Color2),
+			Props: bingen.FormatIDRange(e.PropIDs()), Bank: int(e.Bank),
+		})
+	}
+	return r, nil
+}
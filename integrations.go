@@ -0,0 +1,634 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"time"
+
+	"PicoLume/bingen"
+	"PicoLume/companion"
+	"PicoLume/dmx"
+	"PicoLume/fpp"
+	"PicoLume/i18n"
+	"PicoLume/logger"
+	"PicoLume/ltc"
+	"PicoLume/markers"
+	"PicoLume/ofl"
+	"PicoLume/qlcplus"
+	"PicoLume/showsync"
+	"PicoLume/wled"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// ==========================================================
+// THIRD-PARTY EXPORTS
+// ==========================================================
+
+// parseProject decodes project JSON into the shared bingen model.
+func parseProject(projectJson string) (*bingen.Project, error) {
+	var p bingen.Project
+	if err := json.Unmarshal([]byte(projectJson), &p); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// ExportQLCWorkspace writes a QLC+ workspace (.qxw) with a fixture patch and
+// chasers derived from the project, as a DMX console backup of the show.
+func (a *App) ExportQLCWorkspace(projectJson string) string {
+	defer a.recoverBinding("ExportQLCWorkspace")
+
+	p, err := parseProject(projectJson)
+	if err != nil {
+		return i18n.T("Error: Invalid project - %s", err.Error())
+	}
+
+	data, err := qlcplus.Export(p, qlcplus.Options{Author: "PicoLume Studio"})
+	if err != nil {
+		return "Error: " + err.Error()
+	}
+
+	filename, err := runtime.SaveFileDialog(a.ctx, runtime.SaveDialogOptions{
+		DefaultFilename: "show.qxw",
+		Title:           "Export QLC+ Workspace",
+		Filters: []runtime.FileFilter{
+			{DisplayName: "QLC+ Workspace (*.qxw)", Pattern: "*.qxw"},
+		},
+	})
+	if err != nil || filename == "" {
+		return "Cancelled"
+	}
+
+	safePath, err := validateSavePath(filename, []string{".qxw"})
+	if err != nil {
+		return i18n.T("Error: Invalid path - %s", err.Error())
+	}
+
+	if err := os.WriteFile(safePath, data, 0644); err != nil {
+		return i18n.T("Error saving file: %s", err.Error())
+	}
+
+	return "OK"
+}
+
+// ==========================================================
+// FALCON PLAYER (FPP)
+// ==========================================================
+
+// UploadToFPP pushes an FSEQ sequence and (optionally) its audio to a
+// Falcon Player instance, so the fixed pixel elements of a mixed
+// installation are updated along with the receivers. Studio does not render
+// FSEQ itself: fseqPath is the sequence for the fixed elements, as exported
+// by the sequencer that drives them (such as xLights), and audioPath is
+// usually the show audio. The sequence is uploaded before the audio, and
+// nothing is uploaded if FPP does not answer its status request.
+func (a *App) UploadToFPP(host string, fseqPath string, audioPath string) string {
+	defer a.recoverBinding("UploadToFPP")
+
+	client, err := fpp.NewClient(host)
+	if err != nil {
+		return "Error: " + err.Error()
+	}
+
+	seqPath, err := validateSavePath(fseqPath, []string{".fseq"})
+	if err != nil {
+		return i18n.T("Error: Invalid sequence path - %s", err.Error())
+	}
+	var musicPath string
+	if audioPath != "" {
+		musicPath, err = validateSavePath(audioPath, []string{".mp3", ".wav", ".ogg"})
+		if err != nil {
+			return i18n.T("Error: Invalid audio path - %s", err.Error())
+		}
+	}
+
+	ctx := a.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	a.emitUploadStatus(i18n.T("Contacting FPP at %s...", client.BaseURL))
+	statusCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	st, err := client.Status(statusCtx)
+	cancel()
+	if err != nil {
+		return "Error: " + err.Error()
+	}
+	logger.Info("UploadToFPP: Connected to FPP %s (mode=%s, status=%s)", st.Version, st.Mode, st.Status)
+
+	a.emitUploadStatus(i18n.T("Uploading sequence to FPP..."))
+	if err := client.UploadFile(ctx, fpp.DirSequences, seqPath); err != nil {
+		return "Error: " + err.Error()
+	}
+
+	if musicPath != "" {
+		a.emitUploadStatus(i18n.T("Uploading audio to FPP..."))
+		if err := client.UploadFile(ctx, fpp.DirMusic, musicPath); err != nil {
+			return "Error: " + err.Error()
+		}
+	}
+
+	logger.Info("UploadToFPP: Uploaded %s to %s", seqPath, client.BaseURL)
+	return "OK"
+}
+
+// ==========================================================
+// DAW MARKER IMPORT
+// ==========================================================
+
+// MaxMarkerFileSize caps marker exports read by ImportMarkers (5MB).
+const MaxMarkerFileSize = 5 * 1024 * 1024
+
+type MarkerImportResponse struct {
+	Format  string           `json:"format"`
+	Markers []markers.Marker `json:"markers"`
+	Cues    []bingen.Cue     `json:"cues"`
+	Error   string           `json:"error"`
+}
+
+// ImportMarkers reads a DAW marker export (Reaper CSV, Pro Tools session
+// text, or generic CSV) and maps the first markers onto cues A-D.
+func (a *App) ImportMarkers() MarkerImportResponse {
+	defer a.recoverBinding("ImportMarkers")
+
+	filename, err := runtime.OpenFileDialog(a.ctx, runtime.OpenDialogOptions{
+		Title: "Import DAW Markers",
+		Filters: []runtime.FileFilter{
+			{DisplayName: "Marker Exports (*.csv, *.txt)", Pattern: "*.csv;*.txt"},
+		},
+	})
+	if err != nil || filename == "" {
+		return MarkerImportResponse{Error: "Cancelled"}
+	}
+
+	f, err := os.Open(filename)
+	if err != nil {
+		return MarkerImportResponse{Error: "Failed to open file: " + err.Error()}
+	}
+	defer f.Close()
+
+	data, err := io.ReadAll(io.LimitReader(f, MaxMarkerFileSize+1))
+	if err != nil {
+		return MarkerImportResponse{Error: "Failed to read file: " + err.Error()}
+	}
+	if len(data) > MaxMarkerFileSize {
+		return MarkerImportResponse{Error: fmt.Sprintf("Marker file too large (max %dMB)", MaxMarkerFileSize/(1024*1024))}
+	}
+
+	list, format, err := markers.Parse(data)
+	if err != nil {
+		return MarkerImportResponse{Format: string(format), Error: "Failed to parse markers: " + err.Error()}
+	}
+
+	if len(list) > len(markers.CueIDs) {
+		logger.Info("ImportMarkers: %d markers found, only the first %d map to cues", len(list), len(markers.CueIDs))
+	}
+	logger.Info("ImportMarkers: Imported %d %s markers from %s", len(list), format, filename)
+
+	return MarkerImportResponse{
+		Format:  string(format),
+		Markers: list,
+		Cues:    markers.ToCues(list),
+	}
+}
+
+// ==========================================================
+// COMPANION / STREAM DECK COMMAND LISTENER
+// ==========================================================
+
+// DefaultCommandPort is the TCP/UDP port used when none is configured.
+const DefaultCommandPort = 16759
+
+// StartCommandListener opens a TCP+UDP command listener compatible with
+// Companion's generic modules. Commands are forwarded to the frontend as
+// "remote:command" events. Unless allowRemote is set, only connections from
+// this machine are accepted.
+func (a *App) StartCommandListener(port int, allowRemote bool) string {
+	if port == 0 {
+		port = DefaultCommandPort
+	}
+	if port < 1 || port > 65535 {
+		return i18n.T("Error: Invalid port")
+	}
+
+	host := "127.0.0.1"
+	if allowRemote {
+		host = "0.0.0.0"
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.commandServer != nil {
+		a.commandServer.Close()
+		a.commandServer = nil
+	}
+
+	srv, err := companion.Listen(net.JoinHostPort(host, strconv.Itoa(port)), a.handleRemoteCommand)
+	if err != nil {
+		return "Error: " + err.Error()
+	}
+	a.commandServer = srv
+	logger.Info("Command listener started on %s", srv.Addr())
+	return "OK"
+}
+
+// StopCommandListener closes the command listener if it is running.
+func (a *App) StopCommandListener() string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.commandServer == nil {
+		return "OK"
+	}
+	a.commandServer.Close()
+	a.commandServer = nil
+	logger.Info("Command listener stopped")
+	return "OK"
+}
+
+func (a *App) handleRemoteCommand(cmd companion.Command) error {
+	defer a.recoverGoroutine("remote command")
+
+	if a.ctx == nil {
+		return fmt.Errorf("application not ready")
+	}
+	logger.Info("Remote command from %s: %s %s", cmd.Source, cmd.Action, cmd.Arg)
+	if cmd.Action == companion.ActionBlackout && a.currentSerialSession() != nil {
+		// Don't wait for the UI: blackout is the emergency path.
+		a.Blackout()
+	}
+	runtime.EventsEmit(a.ctx, "remote:command", cmd)
+	return nil
+}
+
+// ==========================================================
+// ENTTEC DMX USB PRO OUTPUT
+// ==========================================================
+
+// ListDMXInterfaces returns serial ports that look like ENTTEC-compatible
+// DMX dongles.
+func (a *App) ListDMXInterfaces() []string {
+	names, err := dmx.ListInterfaces()
+	if err != nil {
+		logger.Warn("ListDMXInterfaces: Port enumeration failed: %v", err)
+		return []string{}
+	}
+	return names
+}
+
+// StartDMXOutput opens the DMX interface on portName and installs the
+// group-to-channel patch used by SendDMXFrame.
+func (a *App) StartDMXOutput(portName string, patch dmx.Patch) string {
+	defer a.recoverBinding("StartDMXOutput")
+
+	if err := patch.Validate(); err != nil {
+		return i18n.T("Error: Invalid patch - %s", err.Error())
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.dmxOutput != nil {
+		a.dmxOutput.Close()
+		a.dmxOutput = nil
+	}
+
+	out, err := dmx.Open(portName)
+	if err != nil {
+		if isPortLockedError(err) {
+			return fmt.Sprintf("PORT_LOCKED:%s", portName)
+		}
+		return "Error: " + err.Error()
+	}
+	a.dmxOutput = out
+	a.dmxPatch = patch
+	logger.Info("DMX output started on %s with %d patched groups", portName, len(patch))
+	return "OK"
+}
+
+// SendDMXFrame renders the current per-group colors (group ID -> hex color,
+// as computed by the playback engine) through the patch and transmits them.
+func (a *App) SendDMXFrame(groupColors map[string]string) string {
+	defer a.recoverBinding("SendDMXFrame")
+
+	colors := make(map[string]uint32, len(groupColors))
+	for id, hex := range groupColors {
+		colors[id] = bingen.ParseColor(hex)
+	}
+
+	a.mu.Lock()
+	out, patch := a.dmxOutput, a.dmxPatch
+	a.mu.Unlock()
+
+	if out == nil {
+		return i18n.T("Error: DMX output not started")
+	}
+	frame := patch.Render(colors)
+	if err := out.Send(&frame); err != nil {
+		return "Error: " + err.Error()
+	}
+	return "OK"
+}
+
+// StopDMXOutput blacks out and closes the DMX interface.
+func (a *App) StopDMXOutput() string {
+	defer a.recoverBinding("StopDMXOutput")
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.dmxOutput == nil {
+		return "OK"
+	}
+	if err := a.dmxOutput.Close(); err != nil {
+		logger.Warn("StopDMXOutput: Close failed: %v", err)
+	}
+	a.dmxOutput = nil
+	logger.Info("DMX output stopped")
+	return "OK"
+}
+
+// ==========================================================
+// WLED PRESETS
+// ==========================================================
+
+// ExportWLEDPresets saves the project's LED tracks as a WLED presets.json
+// (one playlist per track) for import on a WLED controller.
+func (a *App) ExportWLEDPresets(projectJson string) string {
+	defer a.recoverBinding("ExportWLEDPresets")
+
+	p, err := parseProject(projectJson)
+	if err != nil {
+		return i18n.T("Error: Invalid project - %s", err.Error())
+	}
+	presets, err := wled.Export(p, wled.Options{})
+	if err != nil {
+		return "Error: " + err.Error()
+	}
+	data, err := json.Marshal(presets)
+	if err != nil {
+		return "Error: " + err.Error()
+	}
+
+	filename, err := runtime.SaveFileDialog(a.ctx, runtime.SaveDialogOptions{
+		DefaultFilename: "presets.json",
+		Title:           "Export WLED Presets",
+		Filters: []runtime.FileFilter{
+			{DisplayName: "WLED Presets (*.json)", Pattern: "*.json"},
+		},
+	})
+	if err != nil || filename == "" {
+		return "Cancelled"
+	}
+
+	safePath, err := validateSavePath(filename, []string{".json"})
+	if err != nil {
+		return i18n.T("Error: Invalid path - %s", err.Error())
+	}
+	if err := os.WriteFile(safePath, data, 0644); err != nil {
+		return i18n.T("Error saving file: %s", err.Error())
+	}
+	return "OK"
+}
+
+// PushWLEDPresets uploads the generated presets directly to a WLED
+// controller, replacing its presets.json.
+func (a *App) PushWLEDPresets(projectJson string, host string) string {
+	defer a.recoverBinding("PushWLEDPresets")
+
+	p, err := parseProject(projectJson)
+	if err != nil {
+		return i18n.T("Error: Invalid project - %s", err.Error())
+	}
+	presets, err := wled.Export(p, wled.Options{})
+	if err != nil {
+		return "Error: " + err.Error()
+	}
+
+	ctx := a.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if err := wled.Upload(ctx, host, presets); err != nil {
+		return "Error: " + err.Error()
+	}
+	logger.Info("PushWLEDPresets: Uploaded %d presets to %s", len(presets), host)
+	return "OK"
+}
+
+// ==========================================================
+// OPEN FIXTURE LIBRARY IMPORT
+// ==========================================================
+
+// MaxFixtureFileSize caps OFL fixture files read by ImportOFLProfiles (2MB).
+const MaxFixtureFileSize = 2 * 1024 * 1024
+
+type ProfileImportResponse struct {
+	Profiles []bingen.HardwareProfile `json:"profiles"`
+	Error    string                   `json:"error"`
+}
+
+// ImportOFLProfiles reads an Open Fixture Library fixture definition and
+// returns one HardwareProfile per RGB mode. AssignedIds is left empty for the
+// user to fill in.
+func (a *App) ImportOFLProfiles() ProfileImportResponse {
+	defer a.recoverBinding("ImportOFLProfiles")
+
+	filename, err := runtime.OpenFileDialog(a.ctx, runtime.OpenDialogOptions{
+		Title: "Import Open Fixture Library Fixture",
+		Filters: []runtime.FileFilter{
+			{DisplayName: "OFL Fixture (*.json)", Pattern: "*.json"},
+		},
+	})
+	if err != nil || filename == "" {
+		return ProfileImportResponse{Error: "Cancelled"}
+	}
+
+	f, err := os.Open(filename)
+	if err != nil {
+		return ProfileImportResponse{Error: "Failed to open file: " + err.Error()}
+	}
+	defer f.Close()
+
+	data, err := io.ReadAll(io.LimitReader(f, MaxFixtureFileSize+1))
+	if err != nil {
+		return ProfileImportResponse{Error: "Failed to read file: " + err.Error()}
+	}
+	if len(data) > MaxFixtureFileSize {
+		return ProfileImportResponse{Error: fmt.Sprintf("Fixture file too large (max %dMB)", MaxFixtureFileSize/(1024*1024))}
+	}
+
+	fixture, err := ofl.Parse(data)
+	if err != nil {
+		return ProfileImportResponse{Error: err.Error()}
+	}
+	profiles, err := fixture.Profiles()
+	if err != nil {
+		return ProfileImportResponse{Error: err.Error()}
+	}
+
+	logger.Info("ImportOFLProfiles: Created %d profiles from %s", len(profiles), filename)
+	return ProfileImportResponse{Profiles: profiles}
+}
+
+// ==========================================================
+// LTC TIMECODE OUTPUT
+// ==========================================================
+
+const (
+	// LTCSampleRate is the sample rate of generated timecode audio.
+	LTCSampleRate = 48000
+
+	// MaxLTCChunkMs bounds a single GenerateLTC call; the frontend requests
+	// consecutive chunks while the transport runs.
+	MaxLTCChunkMs = 5 * 60 * 1000
+)
+
+type LTCResponse struct {
+	DataURL  string `json:"dataUrl"`  // audio/wav data URL
+	Timecode string `json:"timecode"` // timecode at startMs
+	Error    string `json:"error"`
+}
+
+// GenerateLTC renders SMPTE LTC for a span of the show timeline. The frontend
+// plays it on the output device selected for timecode, started in lockstep
+// with the playback engine, so external gear can chase PicoLume.
+func (a *App) GenerateLTC(startMs int, durationMs int, fps int) LTCResponse {
+	if durationMs <= 0 || durationMs > MaxLTCChunkMs {
+		return LTCResponse{Error: fmt.Sprintf("Duration must be between 1 and %d ms", MaxLTCChunkMs)}
+	}
+
+	gen := ltc.Generator{FPS: fps, SampleRate: LTCSampleRate, Amplitude: 0.5}
+	samples, err := gen.Render(int64(startMs), int64(durationMs))
+	if err != nil {
+		return LTCResponse{Error: err.Error()}
+	}
+
+	wav := ltc.WAV(samples, LTCSampleRate)
+	return LTCResponse{
+		DataURL:  "data:audio/wav;base64," + base64.StdEncoding.EncodeToString(wav),
+		Timecode: ltc.FromMillis(int64(startMs), fps).String(),
+	}
+}
+
+// ==========================================================
+// NETWORK SHOW-SYNC
+// ==========================================================
+
+// SyncEvent is emitted to the frontend as "sync:state" while following.
+type SyncEvent struct {
+	Master     string `json:"master"`
+	Transport  string `json:"state"`
+	PositionMs int64  `json:"positionMs"` // extrapolated to "now"
+	Cue        string `json:"cue,omitempty"`
+	Show       string `json:"show,omitempty"`
+}
+
+// StartSyncMaster broadcasts this instance's transport state on the LAN.
+// The frontend feeds it with UpdateSyncState on every transport change.
+func (a *App) StartSyncMaster(port int) string {
+	if port == 0 {
+		port = showsync.DefaultPort
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.stopSyncLocked()
+	m, err := showsync.StartMaster(port)
+	if err != nil {
+		return "Error: " + err.Error()
+	}
+	a.syncMaster = m
+	logger.Info("Show-sync master %s broadcasting on UDP %d", m.Instance(), port)
+	return "OK"
+}
+
+// UpdateSyncState publishes the current transport state when acting as master
+// and to the detached preview window and the live preview, if open.
+func (a *App) UpdateSyncState(state showsync.State) string {
+	a.mu.Lock()
+	m := a.syncMaster
+	pm := a.previewMaster
+	live := a.livePreview != nil
+	a.mu.Unlock()
+
+	if m == nil && pm == nil && !live {
+		return i18n.T("Error: Sync master not running")
+	}
+	switch state.Transport {
+	case showsync.StatePlaying, showsync.StatePaused, showsync.StateStopped:
+	default:
+		return i18n.T("Error: Invalid transport state")
+	}
+	if m != nil {
+		m.Update(state)
+	}
+	if pm != nil {
+		pm.Update(state)
+	}
+	if live {
+		a.seekLivePreview(state)
+	}
+	return "OK"
+}
+
+// StartSyncFollower listens for a sync master and forwards its state to the
+// frontend as "sync:state" events.
+func (a *App) StartSyncFollower(port int) string {
+	if port == 0 {
+		port = showsync.DefaultPort
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.stopSyncLocked()
+	f, err := showsync.StartFollower(port, "", func(pkt showsync.Packet) {
+		defer a.recoverGoroutine("sync follower")
+
+		if a.ctx == nil {
+			return
+		}
+		runtime.EventsEmit(a.ctx, "sync:state", SyncEvent{
+			Master:     pkt.Instance,
+			Transport:  pkt.Transport,
+			PositionMs: pkt.Extrapolate(time.Now()),
+			Cue:        pkt.Cue,
+			Show:       pkt.Show,
+		})
+	})
+	if err != nil {
+		return "Error: " + err.Error()
+	}
+	a.syncFollower = f
+	logger.Info("Show-sync follower listening on UDP %d", port)
+	return "OK"
+}
+
+// StopSync leaves master or follower mode.
+func (a *App) StopSync() string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.stopSyncLocked()
+	return "OK"
+}
+
+func (a *App) stopSyncLocked() {
+	if a.syncMaster != nil {
+		a.syncMaster.Close()
+		a.syncMaster = nil
+	}
+	if a.syncFollower != nil {
+		a.syncFollower.Close()
+		a.syncFollower = nil
+	}
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"strings"
+
+	"PicoLume/companion"
+	"PicoLume/logger"
+
+	"github.com/wailsapp/wails/v2/pkg/options"
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// ==========================================================
+// LAUNCH ARGUMENTS AND picolume:// LINKS
+// ==========================================================
+
+// URLScheme is the custom protocol registered by the installer (see the
+// "protocols" entry in wails.json).
+const URLScheme = "picolume"
+
+// singleInstanceID identifies the running Studio so a second launch (file
+// double-click, picolume:// link) is forwarded to it instead of opening a
+// new window.
+const singleInstanceID = "com.picolume.studio"
+
+// projectPathFromArgs returns the first .lum file in args (as passed by
+// "PicoLume.exe show.lum" or an OS file association), or "".
+func projectPathFromArgs(args []string) string {
+	for _, arg := range args {
+		if strings.HasPrefix(arg, "-") {
+			continue
+		}
+		if strings.EqualFold(filepath.Ext(arg), ".lum") {
+			if abs, err := filepath.Abs(arg); err == nil {
+				return abs
+			}
+			return arg
+		}
+	}
+	return ""
+}
+
+// deepLinkFromArgs returns the first picolume:// URL in args, or "".
+func deepLinkFromArgs(args []string) string {
+	for _, arg := range args {
+		if strings.HasPrefix(strings.ToLower(arg), URLScheme+"://") {
+			return arg
+		}
+	}
+	return ""
+}
+
+// DeepLink is a parsed picolume:// URL.
+type DeepLink struct {
+	Path    string             // set for picolume://open?path=...
+	Command *companion.Command // set for action links
+}
+
+// parseDeepLink accepts:
+//
+//	picolume://open?path=C:\shows\foo.lum
+//	picolume://cue/A
+//	picolume://go, picolume://stop, picolume://pause, picolume://blackout
+func parseDeepLink(raw string) (DeepLink, error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return DeepLink{}, fmt.Errorf("invalid link: %w", err)
+	}
+	if !strings.EqualFold(u.Scheme, URLScheme) {
+		return DeepLink{}, fmt.Errorf("not a %s:// link", URLScheme)
+	}
+
+	// "picolume://cue/A" parses with Host "cue" and Path "/A".
+	action := strings.ToLower(u.Host)
+	arg := strings.Trim(u.Path, "/")
+
+	if action == "open" {
+		path := u.Query().Get("path")
+		if path == "" {
+			return DeepLink{}, fmt.Errorf("open link needs a path")
+		}
+		return DeepLink{Path: path}, nil
+	}
+
+	cmd, err := companion.ParseCommand(strings.TrimSpace(action + " " + arg))
+	if err != nil || cmd.Action == companion.ActionPing {
+		return DeepLink{}, fmt.Errorf("unsupported link action %q", action)
+	}
+	cmd.Source = URLScheme + "://"
+	return DeepLink{Command: &cmd}, nil
+}
+
+// handleLaunchArgs opens a project or runs a link action from command-line
+// arguments. Projects are delivered as "project:opened" with a LoadResponse.
+func (a *App) handleLaunchArgs(ctx context.Context, args []string) {
+	if link := deepLinkFromArgs(args); link != "" {
+		a.openDeepLink(ctx, link)
+		return
+	}
+	if path := projectPathFromArgs(args); path != "" {
+		a.openProjectAndNotify(ctx, path)
+	}
+}
+
+func (a *App) openDeepLink(ctx context.Context, raw string) {
+	link, err := parseDeepLink(raw)
+	if err != nil {
+		logger.Warn("Ignoring link %s: %v", raw, err)
+		return
+	}
+	if link.Path != "" {
+		a.openProjectAndNotify(ctx, link.Path)
+		return
+	}
+	if err := a.handleRemoteCommand(*link.Command); err != nil {
+		logger.Warn("Link %s failed: %v", raw, err)
+	}
+}
+
+func (a *App) openProjectAndNotify(ctx context.Context, path string) {
+	logger.Info("Opening project from launch request: %s", path)
+	resp := a.LoadProjectFromPath(path)
+	if resp.Error != "" {
+		logger.Warn("Failed to open %s: %s", path, resp.Error)
+	}
+	runtime.EventsEmit(ctx, "project:opened", resp)
+}
+
+// domReady runs once the frontend can receive events. It moves the window to
+// its configured monitor, reports crash dumps left by earlier sessions and
+// handles the arguments this instance was started with.
+func (a *App) domReady(ctx context.Context) {
+	if !a.preview.Enabled {
+		a.placeWindow(a.currentSettings().Window)
+	}
+	a.notifyPendingCrashes()
+
+	a.mu.Lock()
+	args := a.launchArgs
+	a.launchArgs, a.launchReady = nil, true
+	a.mu.Unlock()
+	a.handleLaunchArgs(ctx, args)
+}
+
+// secondInstance handles a launch forwarded from another process.
+func (a *App) secondInstance(data options.SecondInstanceData) {
+	defer a.recoverGoroutine("second instance")
+
+	if a.ctx == nil {
+		return
+	}
+	runtime.WindowUnminimise(a.ctx)
+	runtime.Show(a.ctx)
+	a.handleLaunchArgs(a.ctx, data.Args)
+}
+
+// openURL is the macOS handler for picolume:// links. Links arriving
+// before the DOM is ready are queued for domReady.
+func (a *App) openURL(raw string) {
+	defer a.recoverGoroutine("open url")
+
+	a.mu.Lock()
+	if !a.launchReady {
+		a.launchArgs = append(a.launchArgs, raw)
+		a.mu.Unlock()
+		return
+	}
+	a.mu.Unlock()
+	a.openDeepLink(a.ctx, raw)
+}
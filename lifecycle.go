@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+
+	"PicoLume/logger"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// ==========================================================
+// WINDOW CLOSE / UNSAVED CHANGES
+// ==========================================================
+
+// SetDirty records whether the frontend has unsaved changes. While dirty,
+// closing the window is held back and "app:close-requested" is emitted so
+// the frontend can prompt.
+func (a *App) SetDirty(dirty bool) {
+	a.mu.Lock()
+	a.dirty = dirty
+	a.mu.Unlock()
+}
+
+// ConfirmClose quits even if changes are unsaved; the frontend calls it after
+// the user chose to save or discard.
+func (a *App) ConfirmClose() {
+	a.mu.Lock()
+	a.closeConfirmed = true
+	a.mu.Unlock()
+	if a.ctx != nil {
+		runtime.Quit(a.ctx)
+	}
+}
+
+// beforeClose is the OnBeforeClose hook. Returning true keeps the window open.
+func (a *App) beforeClose(ctx context.Context) (prevent bool) {
+	a.mu.Lock()
+	dirty, confirmed := a.dirty, a.closeConfirmed
+	a.mu.Unlock()
+
+	if !dirty || confirmed {
+		return false
+	}
+	logger.Info("Close requested with unsaved changes; asking frontend")
+	runtime.EventsEmit(ctx, "app:close-requested")
+	return true
+}
+
+// shutdown is the OnShutdown hook: it releases OS resources (tray icon,
+// preview process, network listeners) before the process exits.
+func (a *App) shutdown(ctx context.Context) {
+	a.tray.Close()
+	a.ClosePreviewWindow()
+	a.StopCommandListener()
+	a.StopDMXOutput()
+	a.StopSync()
+	a.StopLivePreview()
+	a.CloseSerialSession()
+	a.serialPorts().Close()
+	if !a.preview.Enabled {
+		a.clearProjectAudio()
+	}
+}
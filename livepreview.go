@@ -0,0 +1,155 @@
+package main
+
+import (
+	"context"
+	"errors"
+
+	"PicoLume/bingen"
+	"PicoLume/i18n"
+	"PicoLume/livepreview"
+	"PicoLume/serialsession"
+	"PicoLume/showsync"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// ==========================================================
+// LIVE PREVIEW
+// ==========================================================
+
+// LivePreviewStatus is the payload of "livepreview:state" events.
+type LivePreviewStatus struct {
+	Running bool   `json:"running"`
+	Port    string `json:"port"`
+	Error   string `json:"error"` // why the preview stopped, if it did by itself
+}
+
+func (a *App) emitLivePreview(status LivePreviewStatus) {
+	if a.ctx != nil {
+		runtime.EventsEmit(a.ctx, "livepreview:state", status)
+	}
+}
+
+// StartLivePreview streams the project at the editor's playhead to the
+// receiver on the open serial session, which pauses its own show and plays
+// along, so colors can be judged on the props while editing. The playhead
+// follows UpdateSyncState. Calling it again while the preview runs swaps in
+// the edited project.
+func (a *App) StartLivePreview(projectJson string) string {
+	defer a.recoverBinding("StartLivePreview")
+	s := a.currentSerialSession()
+	if s == nil {
+		return i18n.T("Error: No serial session open")
+	}
+	if s.State() != serialsession.StateConnected {
+		return i18n.T("Error: Serial session on %s is %s", s.Name(), s.State())
+	}
+
+	events, err := a.previewEvents(projectJson)
+	if errors.Is(err, context.Canceled) {
+		return "Cancelled"
+	}
+	if err != nil {
+		return "Error: " + err.Error()
+	}
+
+	a.mu.Lock()
+	running := a.livePreview
+	a.mu.Unlock()
+	if running != nil {
+		running.SetEvents(events)
+		return "OK"
+	}
+
+	// The streamer is stored before it starts, so OnStop always finds it.
+	var st *livepreview.Streamer
+	st = livepreview.New(s, events, livepreview.Options{
+		OnStop: func(err error) {
+			serialLog.Warn("StartLivePreview: Stream to %s stopped: %v", s.Name(), err)
+			a.mu.Lock()
+			stopped := a.livePreview == st
+			if stopped {
+				a.livePreview = nil
+			}
+			a.mu.Unlock()
+			if stopped {
+				a.serialPorts().SetStreaming(false)
+			}
+			a.emitLivePreview(LivePreviewStatus{Port: s.Name(), Error: err.Error()})
+		},
+	})
+	a.mu.Lock()
+	old := a.livePreview
+	a.livePreview = st
+	a.mu.Unlock()
+	if old != nil {
+		old.Stop()
+	}
+	if err := st.Start(); err != nil {
+		a.mu.Lock()
+		if a.livePreview == st {
+			a.livePreview = nil
+		}
+		a.mu.Unlock()
+		if errors.Is(err, livepreview.ErrStopped) {
+			return "Cancelled"
+		}
+		return "Error: " + err.Error()
+	}
+	a.serialPorts().SetStreaming(true)
+	// The stream may have failed or been stopped before streaming was
+	// recorded; OnStop and StopLivePreview cleared it first then.
+	a.mu.Lock()
+	stopped := a.livePreview != st
+	a.mu.Unlock()
+	if stopped {
+		a.serialPorts().SetStreaming(false)
+		return "Cancelled"
+	}
+	serialLog.Info("StartLivePreview: Streaming to %s", s.Name())
+	a.emitLivePreview(LivePreviewStatus{Running: true, Port: s.Name()})
+	return "OK"
+}
+
+// StopLivePreview ends the live preview; the receiver resumes its show.
+func (a *App) StopLivePreview() string {
+	defer a.recoverBinding("StopLivePreview")
+	a.mu.Lock()
+	st := a.livePreview
+	a.livePreview = nil
+	a.mu.Unlock()
+	if st == nil {
+		return "OK"
+	}
+	a.serialPorts().SetStreaming(false)
+	a.emitLivePreview(LivePreviewStatus{})
+	if err := st.Stop(); err != nil {
+		serialLog.Debug("StopLivePreview: %v", err)
+		return "Error: " + err.Error()
+	}
+	return "OK"
+}
+
+// previewEvents returns the show.bin events of the project, as the
+// receiver would play them after an upload.
+func (a *App) previewEvents(projectJson string) ([]bingen.Event, error) {
+	result, err := a.generateShow(genPreview, projectJson, a.showOptions(0))
+	if err != nil {
+		return nil, err
+	}
+	_, info, err := bingen.Parse(result.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	return info.Events, nil
+}
+
+// seekLivePreview moves the live preview's playhead to the editor's.
+func (a *App) seekLivePreview(state showsync.State) {
+	a.mu.Lock()
+	st := a.livePreview
+	a.mu.Unlock()
+	if st != nil {
+		st.SetPosition(state.PositionMs, state.Transport == showsync.StatePlaying)
+	}
+}
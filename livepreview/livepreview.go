@@ -0,0 +1,265 @@
+// Package livepreview streams the show at the editor's playhead to a
+// receiver over its serial session, so designers can judge colors on real
+// props while editing instead of only after a full upload.
+//
+// The protocol runs on the session's lines. Preview begin and end are
+// commands with OK/ERR replies; frames are sent without waiting for one,
+// so a slow reply never holds up the next frame:
+//
+//	preview begin        OK    the receiver pauses its show and plays frames
+//	pf <posMs> <count>         a frame: the count pe lines that follow
+//	pe <bank> <mask> <effect> <speed> <width> <color> <color2> <elapsedMs>
+//	preview end          OK    the receiver resumes its show
+//
+// Each pe line is a show.bin event playing at posMs: mask is the event's
+// prop mask as comma-separated hex words with trailing zero words left
+// out, colors are 6 hex digits, and elapsedMs is how far into the event
+// the playhead is, so animated effects run in phase with the editor. The
+// events of a frame replace everything the previous frame played; later
+// events win where they address the same props. Fades and gradients are
+// not previewed.
+package livepreview
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"PicoLume/bingen"
+)
+
+// Defaults and limits.
+const (
+	// DefaultInterval is the frame rate while playing, 25 frames a second.
+	DefaultInterval = 40 * time.Millisecond
+	DefaultTimeout  = 2 * time.Second
+
+	// MaxFrameEvents caps the events in one frame; a receiver renders at
+	// most this many layers.
+	MaxFrameEvents = 64
+)
+
+// Device sends raw lines and commands to a receiver.
+// *serialsession.Session implements it.
+type Device interface {
+	Command(cmd string, timeout time.Duration) (string, error)
+	Write(p []byte) error
+}
+
+// Options controls Start.
+type Options struct {
+	Interval time.Duration // between frames while playing; DefaultInterval if 0
+	Timeout  time.Duration // for begin and end; DefaultTimeout if 0
+
+	// OnStop, if set, is called once the stream ends by itself because a
+	// frame could not be sent, such as when the receiver was unplugged.
+	OnStop func(err error)
+
+	now func() time.Time // for tests
+}
+
+var (
+	// ErrRejected means the receiver refused to enter preview.
+	ErrRejected = errors.New("receiver rejected the preview")
+	// ErrStopped is returned by Streamer.Start after Stop.
+	ErrStopped = errors.New("preview stopped")
+)
+
+// Streamer sends frames to one receiver until Stop.
+type Streamer struct {
+	dev  Device
+	opts Options
+
+	mu      sync.Mutex
+	events  []bingen.Event
+	posMs   int64     // playhead at since
+	since   time.Time // when posMs was set
+	playing bool
+	changed bool // frame must be sent even if paused
+	started bool // the device is in preview
+
+	wake chan struct{}
+	done chan struct{}
+	wg   sync.WaitGroup
+}
+
+// Start puts dev into preview and streams frames of events, which are in
+// show.bin order, from position 0, paused, until Stop. It is New followed by
+// Streamer.Start.
+func Start(dev Device, events []bingen.Event, opts Options) (*Streamer, error) {
+	s := New(dev, events, opts)
+	if err := s.Start(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// New returns a streamer of events to dev that sends nothing until Start.
+// It lets a caller store the streamer before OnStop can be called for it.
+func New(dev Device, events []bingen.Event, opts Options) *Streamer {
+	if opts.Interval <= 0 {
+		opts.Interval = DefaultInterval
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = DefaultTimeout
+	}
+	if opts.now == nil {
+		opts.now = time.Now
+	}
+	return &Streamer{
+		dev:     dev,
+		opts:    opts,
+		events:  events,
+		since:   opts.now(),
+		changed: true,
+		wake:    make(chan struct{}, 1),
+		done:    make(chan struct{}),
+	}
+}
+
+// Start puts the device into preview and starts streaming. It returns
+// ErrStopped, after ending the preview again, if Stop was called first.
+func (s *Streamer) Start() error {
+	if _, err := s.dev.Command("preview begin", s.opts.Timeout); err != nil {
+		return fmt.Errorf("%w: %v", ErrRejected, err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	select {
+	case <-s.done:
+		s.dev.Command("preview end", s.opts.Timeout)
+		return ErrStopped
+	default:
+	}
+	s.started = true
+	s.since = s.opts.now()
+	s.wg.Add(1)
+	go s.loop()
+	return nil
+}
+
+// SetEvents replaces the show being previewed, after an edit.
+func (s *Streamer) SetEvents(events []bingen.Event) {
+	s.mu.Lock()
+	s.events, s.changed = events, true
+	s.mu.Unlock()
+	s.poke()
+}
+
+// SetPosition moves the playhead to posMs, playing on from there or
+// holding it.
+func (s *Streamer) SetPosition(posMs int64, playing bool) {
+	s.mu.Lock()
+	s.posMs, s.since, s.playing, s.changed = max(posMs, 0), s.opts.now(), playing, true
+	s.mu.Unlock()
+	s.poke()
+}
+
+// Stop ends the stream and tells the receiver to resume its show.
+func (s *Streamer) Stop() error {
+	s.mu.Lock()
+	select {
+	case <-s.done:
+	default:
+		close(s.done)
+	}
+	started := s.started
+	s.mu.Unlock()
+	s.wg.Wait()
+	if !started {
+		return nil
+	}
+	_, err := s.dev.Command("preview end", s.opts.Timeout)
+	return err
+}
+
+func (s *Streamer) poke() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (s *Streamer) loop() {
+	defer s.wg.Done()
+	ticker := time.NewTicker(s.opts.Interval)
+	defer ticker.Stop()
+	for {
+		if frame := s.nextFrame(); frame != nil {
+			if err := s.dev.Write(frame); err != nil {
+				if s.opts.OnStop != nil {
+					s.opts.OnStop(err)
+				}
+				return
+			}
+		}
+		select {
+		case <-s.done:
+			return
+		case <-s.wake:
+		case <-ticker.C:
+		}
+	}
+}
+
+// nextFrame returns the frame to send now, or nil while the playhead holds
+// still and nothing changed.
+func (s *Streamer) nextFrame() []byte {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.playing && !s.changed {
+		return nil
+	}
+	s.changed = false
+	pos := s.posMs
+	if s.playing {
+		pos += s.opts.now().Sub(s.since).Milliseconds()
+	}
+	return EncodeFrame(uint32(min(pos, int64(bingen.MaxTimeMs))), s.events)
+}
+
+// Active returns the events of a show playing at posMs, in show order.
+func Active(events []bingen.Event, posMs uint32) []bingen.Event {
+	var out []bingen.Event
+	for _, e := range events {
+		if e.StartTime > posMs {
+			break // events are sorted by start time
+		}
+		if posMs-e.StartTime < e.Duration {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+// EncodeFrame returns the frame lines for the show playing at posMs. Past
+// MaxFrameEvents the earliest events are left out, since later ones win.
+func EncodeFrame(posMs uint32, events []bingen.Event) []byte {
+	active := Active(events, posMs)
+	if len(active) > MaxFrameEvents {
+		active = active[len(active)-MaxFrameEvents:]
+	}
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "pf %d %d\n", posMs, len(active))
+	for _, e := range active {
+		fmt.Fprintf(&b, "pe %d %s %d %d %d %06x %06x %d\n",
+			e.Bank, maskWords(e.Mask), e.Effect, e.Speed, e.Width, e.Color, e.Color2, posMs-e.StartTime)
+	}
+	return b.Bytes()
+}
+
+// maskWords formats mask as hex words without the trailing zero words.
+func maskWords(mask [bingen.MaskArraySize]uint32) string {
+	n := len(mask)
+	for n > 1 && mask[n-1] == 0 {
+		n--
+	}
+	words := make([]string, n)
+	for i := range words {
+		words[i] = fmt.Sprintf("%x", mask[i])
+	}
+	return strings.Join(words, ",")
+}
@@ -0,0 +1,161 @@
+package livepreview
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"PicoLume/bingen"
+	"PicoLume/serialsession"
+)
+
+// fakeDevice records what a receiver is sent.
+type fakeDevice struct {
+	mu       sync.Mutex
+	commands []string
+	frames   []string
+	refuse   bool
+	unplug   bool
+	written  chan struct{}
+}
+
+func newFakeDevice() *fakeDevice {
+	return &fakeDevice{written: make(chan struct{}, 100)}
+}
+
+func (d *fakeDevice) Command(cmd string, _ time.Duration) (string, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.commands = append(d.commands, cmd)
+	if d.refuse {
+		return "", &serialsession.DeviceError{Message: "unknown command"}
+	}
+	return "", nil
+}
+
+func (d *fakeDevice) Write(p []byte) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.unplug {
+		return serialsession.ErrNotConnected
+	}
+	d.frames = append(d.frames, string(p))
+	d.written <- struct{}{}
+	return nil
+}
+
+func (d *fakeDevice) lastFrame() string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.frames[len(d.frames)-1]
+}
+
+func (d *fakeDevice) wait(t *testing.T) {
+	t.Helper()
+	select {
+	case <-d.written:
+	case <-time.After(time.Second):
+		t.Fatal("no frame sent")
+	}
+}
+
+var show = []bingen.Event{
+	{StartTime: 0, Duration: 1000, Effect: 1, Color: 0xFF0000, Mask: [bingen.MaskArraySize]uint32{0xF}},
+	{StartTime: 500, Duration: 1000, Effect: 2, Speed: 50, Width: 10, Color: 0x0000FF, Mask: [bingen.MaskArraySize]uint32{0, 1}, Bank: 1},
+	{StartTime: 1500, Duration: 500, Mask: [bingen.MaskArraySize]uint32{0xF}},
+}
+
+func TestEncodeFrame(t *testing.T) {
+	got := string(EncodeFrame(700, show))
+	want := "pf 700 2\n" +
+		"pe 0 f 1 0 0 ff0000 000000 700\n" +
+		"pe 1 0,1 2 50 10 0000ff 000000 200\n"
+	if got != want {
+		t.Errorf("EncodeFrame(700) =\n%s\nwant\n%s", got, want)
+	}
+	if got := string(EncodeFrame(2000, show)); got != "pf 2000 0\n" {
+		t.Errorf("EncodeFrame(2000) = %q, want an empty frame after the show", got)
+	}
+	if got := Active(show, 1500); len(got) != 1 || got[0].StartTime != 1500 {
+		t.Errorf("Active(1500) = %+v, want the off event only", got)
+	}
+}
+
+func TestStreamer(t *testing.T) {
+	dev := newFakeDevice()
+	now := time.Unix(0, 0)
+	var clock sync.Mutex
+	s, err := Start(dev, show, Options{Interval: time.Hour, now: func() time.Time {
+		clock.Lock()
+		defer clock.Unlock()
+		return now
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	dev.wait(t)
+	if got := dev.lastFrame(); got != "pf 0 1\npe 0 f 1 0 0 ff0000 000000 0\n" {
+		t.Errorf("first frame = %q", got)
+	}
+
+	s.SetPosition(400, true)
+	dev.wait(t)
+	clock.Lock()
+	now = now.Add(300 * time.Millisecond)
+	clock.Unlock()
+	s.SetEvents(show)
+	dev.wait(t)
+	if got := dev.lastFrame(); !strings.HasPrefix(got, "pf 700 2\n") {
+		t.Errorf("frame after playing 300 ms from 400 = %q, want position 700", got)
+	}
+
+	if err := s.Stop(); err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(dev.commands, "; "); got != "preview begin; preview end" {
+		t.Errorf("commands = %s", got)
+	}
+}
+
+func TestStreamerStopsWhenUnplugged(t *testing.T) {
+	dev := newFakeDevice()
+	dev.unplug = true
+	stopped := make(chan error, 1)
+	s, err := Start(dev, show, Options{OnStop: func(err error) { stopped <- err }})
+	if err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case err := <-stopped:
+		if !errors.Is(err, serialsession.ErrNotConnected) {
+			t.Errorf("OnStop error = %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("OnStop not called")
+	}
+	s.Stop()
+
+	refusing := newFakeDevice()
+	refusing.refuse = true
+	if _, err := Start(refusing, show, Options{}); !errors.Is(err, ErrRejected) {
+		t.Errorf("Start() on firmware without preview = %v, want ErrRejected", err)
+	}
+}
+
+func TestStreamerStoppedBeforeStart(t *testing.T) {
+	dev := newFakeDevice()
+	s := New(dev, show, Options{})
+	if err := s.Stop(); err != nil {
+		t.Errorf("Stop() before Start = %v", err)
+	}
+	if err := s.Start(); !errors.Is(err, ErrStopped) {
+		t.Errorf("Start() after Stop = %v, want ErrStopped", err)
+	}
+	dev.mu.Lock()
+	defer dev.mu.Unlock()
+	if got := strings.Join(dev.commands, "; "); got != "preview begin; preview end" || len(dev.frames) != 0 {
+		t.Errorf("commands = %s, %d frames; want the preview ended and no frames", got, len(dev.frames))
+	}
+}
@@ -1,11 +1,14 @@
 package logger
 
 import (
+	"encoding/json"
 	"fmt"
-	"log"
+	"io"
 	"os"
 	"path/filepath"
 	"runtime"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 )
@@ -35,13 +38,145 @@ func (l Level) String() string {
 	}
 }
 
+// ParseLevel maps a level name (case-insensitive) to a Level
+func ParseLevel(s string) (Level, error) {
+	switch strings.ToUpper(strings.TrimSpace(s)) {
+	case "DEBUG":
+		return DEBUG, nil
+	case "", "INFO":
+		return INFO, nil
+	case "WARN", "WARNING":
+		return WARN, nil
+	case "ERROR":
+		return ERROR, nil
+	default:
+		return INFO, fmt.Errorf("unknown log level %q", s)
+	}
+}
+
+// Format selects how log lines are rendered
+type Format int
+
+const (
+	// FormatText renders "[timestamp] [LEVEL] [caller] message key=value"
+	FormatText Format = iota
+	// FormatJSON renders one JSON object per line for ingestion by tooling
+	FormatJSON
+)
+
+// ParseFormat maps "text"/"json" (case-insensitive) to a Format
+func ParseFormat(s string) (Format, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "text":
+		return FormatText, nil
+	case "json":
+		return FormatJSON, nil
+	default:
+		return FormatText, fmt.Errorf("unknown log format %q", s)
+	}
+}
+
+// Fields are key/value pairs attached to a log line
+type Fields map[string]interface{}
+
+// RecentCapacity is the number of records kept in memory for Recent
+const RecentCapacity = 2000
+
+// Record is a log line as kept in the in-memory ring buffer
+type Record struct {
+	Time    time.Time `json:"time"`
+	Level   string    `json:"level"`
+	Logger  string    `json:"logger,omitempty"`
+	Caller  string    `json:"caller"`
+	Message string    `json:"message"`
+	Fields  Fields    `json:"fields,omitempty"`
+
+	level Level
+}
+
+// ring is a fixed-size circular buffer of recent records
+type ring struct {
+	records []Record
+	next    int
+	full    bool
+}
+
+// Write implements Sink
+func (r *ring) Write(rec Record, _ string) error {
+	r.add(rec)
+	return nil
+}
+
+func (r *ring) add(rec Record) {
+	if r.records == nil {
+		r.records = make([]Record, RecentCapacity)
+	}
+	r.records[r.next] = rec
+	r.next = (r.next + 1) % len(r.records)
+	if r.next == 0 {
+		r.full = true
+	}
+}
+
+// Sink receives every log line that passes the level filter. Write is called
+// with the logger's lock held, so lines arrive in order; a sink must not log.
+type Sink interface {
+	Write(rec Record, line string) error
+}
+
+// writerSink writes rendered lines to an io.Writer
+type writerSink struct {
+	w io.Writer
+}
+
+// WriterSink returns a Sink that writes each rendered line plus a newline to w
+func WriterSink(w io.Writer) Sink {
+	return writerSink{w: w}
+}
+
+func (s writerSink) Write(_ Record, line string) error {
+	_, err := io.WriteString(s.w, line+"\n")
+	return err
+}
+
+// fileSink is the log file Init opens. Unlike a writerSink, which writes to
+// a writer it does not own (such as os.Stdout), it is closed by Close.
+type fileSink struct {
+	writerSink
+	f *os.File
+}
+
+func (s *fileSink) Close() error {
+	return s.f.Close()
+}
+
 // Logger provides structured logging with levels
 type Logger struct {
 	mu       sync.Mutex
 	level    Level
-	logger   *log.Logger
-	file     *os.File
+	format   Format
+	sinks    []Sink
 	filePath string
+	file     *fileSink // the log file, owned by the logger
+	recent   ring
+	named    map[string]Level // per-name level overrides, see SetNamedLevel
+}
+
+// Entry is a logger bound to a set of fields and/or a name, created by With
+// or Named
+type Entry struct {
+	name   string
+	fields Fields
+}
+
+// jsonLine is the JSON rendering of a log line
+type jsonLine struct {
+	Timestamp string `json:"timestamp"`
+	Level     string `json:"level"`
+	Logger    string `json:"logger,omitempty"`
+	Caller    string `json:"caller"`
+	Message   string `json:"message"`
+	Fields    Fields `json:"fields,omitempty"`
 }
 
 var (
@@ -50,13 +185,14 @@ var (
 )
 
 // Init initializes the default logger with optional file output
-func Init(logDir string, minLevel Level) error {
+func Init(logDir string, minLevel Level, format Format) error {
 	var initErr error
 	once.Do(func() {
 		defaultLogger = &Logger{
 			level:  minLevel,
-			logger: log.New(os.Stdout, "", 0),
+			format: format,
 		}
+		defaultLogger.sinks = []Sink{WriterSink(os.Stdout), &defaultLogger.recent}
 
 		if logDir != "" {
 			if err := os.MkdirAll(logDir, 0755); err != nil {
@@ -73,19 +209,62 @@ func Init(logDir string, minLevel Level) error {
 				return
 			}
 
-			defaultLogger.file = f
 			defaultLogger.filePath = logPath
-			defaultLogger.logger = log.New(f, "", 0)
+			defaultLogger.file = &fileSink{writerSink: writerSink{w: f}, f: f}
+			defaultLogger.sinks = append(defaultLogger.sinks, defaultLogger.file)
 		}
 	})
 	return initErr
 }
 
-// Close closes the log file if one is open
+// Close closes the log file opened by Init and stops writing to it. Stdout
+// and sinks attached with AddSink belong to the caller and stay open.
 func Close() {
-	if defaultLogger != nil && defaultLogger.file != nil {
-		defaultLogger.file.Close()
+	if defaultLogger == nil {
+		return
+	}
+	defaultLogger.mu.Lock()
+	defer defaultLogger.mu.Unlock()
+	file := defaultLogger.file
+	if file == nil {
+		return
+	}
+	for i, s := range defaultLogger.sinks {
+		if s == Sink(file) {
+			defaultLogger.sinks = append(defaultLogger.sinks[:i:i], defaultLogger.sinks[i+1:]...)
+			break
+		}
 	}
+	defaultLogger.file = nil
+	file.Close()
+}
+
+// AddSink attaches an additional sink (e.g. a remote collector) to the
+// default logger. It returns a function that detaches it again.
+func AddSink(sink Sink) (remove func()) {
+	l := getDefaultLogger()
+	added := &addedSink{Sink: sink}
+	l.mu.Lock()
+	l.sinks = append(l.sinks, added)
+	l.mu.Unlock()
+
+	return func() {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		for i, s := range l.sinks {
+			if s == Sink(added) {
+				l.sinks = append(l.sinks[:i:i], l.sinks[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// addedSink wraps a sink attached with AddSink so its registration can be
+// found again by pointer: the sink itself may not be comparable, and equal
+// sinks (two WriterSinks on one writer) must not remove each other.
+type addedSink struct {
+	Sink
 }
 
 // SetLevel sets the minimum log level
@@ -100,58 +279,206 @@ func SetLevel(level Level) {
 func getDefaultLogger() *Logger {
 	if defaultLogger == nil {
 		defaultLogger = &Logger{
-			level:  INFO,
-			logger: log.New(os.Stdout, "", 0),
+			level: INFO,
 		}
+		defaultLogger.sinks = []Sink{WriterSink(os.Stdout), &defaultLogger.recent}
 	}
 	return defaultLogger
 }
 
-func (l *Logger) log(level Level, format string, args ...interface{}) {
-	if level < l.level {
-		return
+// SetNamedLevel overrides the minimum level for a named logger and its
+// children (e.g. "serial" also covers "serial.reset")
+func SetNamedLevel(name string, level Level) {
+	l := getDefaultLogger()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.named == nil {
+		l.named = make(map[string]Level)
+	}
+	l.named[name] = level
+}
+
+// ClearNamedLevel removes an override so the name follows the global level
+func ClearNamedLevel(name string) {
+	l := getDefaultLogger()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.named, name)
+}
+
+// ParseNamedLevels parses "serial=debug,bingen=warn" into per-name levels
+func ParseNamedLevels(s string) (map[string]Level, error) {
+	levels := make(map[string]Level)
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		name, levelName, ok := strings.Cut(part, "=")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			return nil, fmt.Errorf("invalid named level %q (want name=level)", part)
+		}
+		level, err := ParseLevel(levelName)
+		if err != nil {
+			return nil, err
+		}
+		levels[name] = level
+	}
+	return levels, nil
+}
+
+// levelFor returns the effective minimum level for a logger name, using the
+// longest matching dotted prefix. Caller must hold l.mu.
+func (l *Logger) levelFor(name string) Level {
+	for name != "" {
+		if level, ok := l.named[name]; ok {
+			return level
+		}
+		i := strings.LastIndex(name, ".")
+		if i < 0 {
+			break
+		}
+		name = name[:i]
 	}
+	return l.level
+}
 
+func (l *Logger) log(level Level, name string, fields Fields, format string, args ...interface{}) {
 	l.mu.Lock()
 	defer l.mu.Unlock()
 
-	timestamp := time.Now().Format("2006-01-02 15:04:05.000")
+	if level < l.levelFor(name) {
+		return
+	}
+
+	now := time.Now()
 	message := fmt.Sprintf(format, args...)
 
-	// Get caller info (skip 3 frames: log, public func, caller)
-	_, file, line, ok := runtime.Caller(3)
+	// Get caller info (skip 2 frames: log, public func)
+	_, file, line, ok := runtime.Caller(2)
 	caller := "unknown"
 	if ok {
 		caller = fmt.Sprintf("%s:%d", filepath.Base(file), line)
 	}
 
-	logLine := fmt.Sprintf("[%s] [%s] [%s] %s", timestamp, level, caller, message)
-	l.logger.Println(logLine)
+	var logLine string
+	if l.format == FormatJSON {
+		b, err := json.Marshal(jsonLine{
+			Timestamp: now.Format(time.RFC3339Nano),
+			Level:     level.String(),
+			Logger:    name,
+			Caller:    caller,
+			Message:   message,
+			Fields:    fields,
+		})
+		if err != nil {
+			b, _ = json.Marshal(jsonLine{
+				Timestamp: now.Format(time.RFC3339Nano),
+				Level:     level.String(),
+				Logger:    name,
+				Caller:    caller,
+				Message:   message + " (unserializable fields: " + err.Error() + ")",
+			})
+		}
+		logLine = string(b)
+	} else {
+		timestamp := now.Format("2006-01-02 15:04:05.000")
+		prefix := ""
+		if name != "" {
+			prefix = "[" + name + "] "
+		}
+		logLine = fmt.Sprintf("[%s] [%s] [%s] %s%s%s", timestamp, level, caller, prefix, message, formatFields(fields))
+	}
+	rec := Record{Time: now, Level: level.String(), Logger: name, Caller: caller, Message: message, Fields: fields, level: level}
+	for _, sink := range l.sinks {
+		// A failing sink (full disk, closed pipe) must not take logging down
+		// with it; the other sinks still get the line.
+		_ = sink.Write(rec, logLine)
+	}
+}
 
-	// Also print to stdout if logging to file
-	if l.file != nil {
-		fmt.Println(logLine)
+// Recent returns up to limit of the most recent records at or above minLevel,
+// oldest first. A limit <= 0 returns everything retained.
+func Recent(minLevel Level, limit int) []Record {
+	l := getDefaultLogger()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	r := &l.recent
+	count := r.next
+	if r.full {
+		count = len(r.records)
+	}
+
+	// Walk backwards from the newest record so the limit keeps the latest.
+	var out []Record
+	for i := 0; i < count; i++ {
+		idx := (r.next - 1 - i + len(r.records)) % len(r.records)
+		rec := r.records[idx]
+		if rec.level < minLevel {
+			continue
+		}
+		out = append(out, rec)
+		if limit > 0 && len(out) >= limit {
+			break
+		}
+	}
+	for i, j := 0, len(out)-1; i < j; i, j = i+1, j-1 {
+		out[i], out[j] = out[j], out[i]
 	}
+	return out
+}
+
+// FilePath returns the path of the current log file, or "" if logging to
+// stdout only
+func FilePath() string {
+	l := getDefaultLogger()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.filePath
+}
+
+// formatFields renders fields as " key=value" pairs in key order
+func formatFields(fields Fields) string {
+	if len(fields) == 0 {
+		return ""
+	}
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	for _, k := range keys {
+		v := fmt.Sprint(fields[k])
+		if strings.ContainsAny(v, " \t\"=") {
+			v = fmt.Sprintf("%q", v)
+		}
+		fmt.Fprintf(&b, " %s=%s", k, v)
+	}
+	return b.String()
 }
 
 // Debug logs a debug message
 func Debug(format string, args ...interface{}) {
-	getDefaultLogger().log(DEBUG, format, args...)
+	getDefaultLogger().log(DEBUG, "", nil, format, args...)
 }
 
 // Info logs an info message
 func Info(format string, args ...interface{}) {
-	getDefaultLogger().log(INFO, format, args...)
+	getDefaultLogger().log(INFO, "", nil, format, args...)
 }
 
 // Warn logs a warning message
 func Warn(format string, args ...interface{}) {
-	getDefaultLogger().log(WARN, format, args...)
+	getDefaultLogger().log(WARN, "", nil, format, args...)
 }
 
 // Error logs an error message
 func Error(format string, args ...interface{}) {
-	getDefaultLogger().log(ERROR, format, args...)
+	getDefaultLogger().log(ERROR, "", nil, format, args...)
 }
 
 // WithError logs an error with the error object
@@ -160,7 +487,7 @@ func WithError(err error, format string, args ...interface{}) {
 		return
 	}
 	message := fmt.Sprintf(format, args...)
-	getDefaultLogger().log(ERROR, "%s: %v", message, err)
+	getDefaultLogger().log(ERROR, "", nil, "%s: %v", message, err)
 }
 
 // WarnWithError logs a warning with the error object
@@ -169,5 +496,57 @@ func WarnWithError(err error, format string, args ...interface{}) {
 		return
 	}
 	message := fmt.Sprintf(format, args...)
-	getDefaultLogger().log(WARN, "%s: %v", message, err)
+	getDefaultLogger().log(WARN, "", nil, "%s: %v", message, err)
+}
+
+// With returns an Entry that attaches fields to every line it logs
+func With(fields Fields) *Entry {
+	return &Entry{fields: fields}
+}
+
+// With returns a new Entry with additional fields (overriding duplicates)
+func (e *Entry) With(fields Fields) *Entry {
+	merged := make(Fields, len(e.fields)+len(fields))
+	for k, v := range e.fields {
+		merged[k] = v
+	}
+	for k, v := range fields {
+		merged[k] = v
+	}
+	return &Entry{name: e.name, fields: merged}
+}
+
+// Named returns an Entry whose lines are prefixed with name and whose level
+// can be controlled independently with SetNamedLevel
+func Named(name string) *Entry {
+	return &Entry{name: name}
+}
+
+// Named returns a child logger called "parent.name" that keeps the entry's
+// fields
+func (e *Entry) Named(name string) *Entry {
+	if e.name != "" {
+		name = e.name + "." + name
+	}
+	return &Entry{name: name, fields: e.fields}
+}
+
+// Debug logs a debug message with the entry's fields
+func (e *Entry) Debug(format string, args ...interface{}) {
+	getDefaultLogger().log(DEBUG, e.name, e.fields, format, args...)
+}
+
+// Info logs an info message with the entry's fields
+func (e *Entry) Info(format string, args ...interface{}) {
+	getDefaultLogger().log(INFO, e.name, e.fields, format, args...)
+}
+
+// Warn logs a warning message with the entry's fields
+func (e *Entry) Warn(format string, args ...interface{}) {
+	getDefaultLogger().log(WARN, e.name, e.fields, format, args...)
+}
+
+// Error logs an error message with the entry's fields
+func (e *Entry) Error(format string, args ...interface{}) {
+	getDefaultLogger().log(ERROR, e.name, e.fields, format, args...)
 }
@@ -0,0 +1,288 @@
+package logger
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// closeRecorder is a writer that records whether it was closed.
+type closeRecorder struct {
+	strings.Builder
+	closed bool
+}
+
+func (c *closeRecorder) Close() error {
+	c.closed = true
+	return nil
+}
+
+func TestCloseOnlyClosesLogFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.log")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout, added := &closeRecorder{}, &closeRecorder{}
+	file := &fileSink{writerSink: writerSink{w: f}, f: f}
+	saved := defaultLogger
+	defer func() { defaultLogger = saved }()
+	defaultLogger = &Logger{level: INFO, file: file}
+	defaultLogger.sinks = []Sink{WriterSink(stdout), file}
+	remove := AddSink(WriterSink(added))
+	defer remove()
+
+	Info("before close")
+	Close()
+	Info("after close")
+	Close()
+
+	if stdout.closed || added.closed {
+		t.Error("Close() closed a writer the logger does not own")
+	}
+	if !strings.Contains(stdout.String(), "after close") || !strings.Contains(added.String(), "after close") {
+		t.Error("sinks stopped receiving lines after Close()")
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "before close") || strings.Contains(string(data), "after close") {
+		t.Errorf("log file = %q, want only the line before Close()", data)
+	}
+	if _, err := f.Write([]byte("x")); err == nil {
+		t.Error("Close() left the log file open")
+	}
+}
+
+// batchSink collects records; its slice makes it not comparable.
+type batchSink struct {
+	records *[]Record
+	pending []Record
+}
+
+func (s batchSink) Write(rec Record, _ string) error {
+	*s.records = append(*s.records, rec)
+	return nil
+}
+
+func TestAddSinkRemove(t *testing.T) {
+	saved := defaultLogger
+	defer func() { defaultLogger = saved }()
+	defaultLogger = &Logger{level: INFO}
+
+	var batched []Record
+	removeBatch := AddSink(batchSink{records: &batched})
+	var out strings.Builder
+	removeFirst := AddSink(WriterSink(&out))
+	AddSink(WriterSink(&out))
+
+	removeBatch()
+	removeFirst()
+	Info("after remove")
+	if len(batched) != 0 {
+		t.Errorf("removed sink got %d records", len(batched))
+	}
+	if got := strings.Count(out.String(), "after remove"); got != 1 {
+		t.Errorf("the writer got the line %d times, want once from the sink still attached", got)
+	}
+	removeFirst()
+	if len(defaultLogger.sinks) != 1 {
+		t.Errorf("%d sinks attached, want 1", len(defaultLogger.sinks))
+	}
+}
+
+// useLogger makes l the default logger for the rest of the test.
+func useLogger(t *testing.T, l *Logger) {
+	saved := defaultLogger
+	t.Cleanup(func() { defaultLogger = saved })
+	defaultLogger = l
+}
+
+func TestJSONFormat(t *testing.T) {
+	var out strings.Builder
+	useLogger(t, &Logger{level: DEBUG, format: FormatJSON, sinks: []Sink{WriterSink(&out)}})
+
+	With(Fields{"port": "COM3", "attempt": 2}).Named("serial").Warn("reset %s", "failed")
+
+	var line jsonLine
+	if err := json.Unmarshal([]byte(out.String()), &line); err != nil {
+		t.Fatalf("line %q is not JSON: %v", out.String(), err)
+	}
+	if line.Level != "WARN" || line.Logger != "serial" || line.Message != "reset failed" {
+		t.Errorf("line = %+v", line)
+	}
+	if !strings.HasPrefix(line.Caller, "logger_test.go:") {
+		t.Errorf("caller = %q, want the line that logged", line.Caller)
+	}
+	if line.Fields["port"] != "COM3" || line.Fields["attempt"] != 2.0 || len(line.Fields) != 2 {
+		t.Errorf("fields = %v", line.Fields)
+	}
+	if _, err := time.Parse(time.RFC3339Nano, line.Timestamp); err != nil {
+		t.Errorf("timestamp %q: %v", line.Timestamp, err)
+	}
+
+	// Fields JSON cannot encode are reported in the message instead.
+	out.Reset()
+	line = jsonLine{}
+	With(Fields{"done": make(chan int)}).Info("sent")
+	if err := json.Unmarshal([]byte(out.String()), &line); err != nil {
+		t.Fatalf("line %q is not JSON: %v", out.String(), err)
+	}
+	if !strings.HasPrefix(line.Message, "sent (unserializable fields: ") || line.Fields != nil {
+		t.Errorf("line with a channel field = %+v", line)
+	}
+}
+
+func TestEntryWithMergesFields(t *testing.T) {
+	var out strings.Builder
+	useLogger(t, &Logger{level: DEBUG, sinks: []Sink{WriterSink(&out)}})
+
+	base := With(Fields{"port": "COM3", "try": 1})
+	base.With(Fields{"try": 2, "baud": 1200}).Info("touch")
+	base.Info("probe")
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines: %q", len(lines), out.String())
+	}
+	if !strings.HasSuffix(lines[0], "touch baud=1200 port=COM3 try=2") {
+		t.Errorf("merged line = %q", lines[0])
+	}
+	if !strings.HasSuffix(lines[1], "probe port=COM3 try=1") {
+		t.Errorf("With() changed the parent entry: %q", lines[1])
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	for in, want := range map[string]Format{"": FormatText, "text": FormatText, " JSON ": FormatJSON} {
+		if got, err := ParseFormat(in); err != nil || got != want {
+			t.Errorf("ParseFormat(%q) = %v, %v; want %v", in, got, err, want)
+		}
+	}
+	for _, in := range []string{"xml", "json5", "t"} {
+		if got, err := ParseFormat(in); err == nil || got != FormatText {
+			t.Errorf("ParseFormat(%q) = %v, %v; want an error and FormatText", in, got, err)
+		}
+	}
+}
+
+func TestRecent(t *testing.T) {
+	l := &Logger{level: DEBUG}
+	l.sinks = []Sink{&l.recent}
+	useLogger(t, l)
+
+	// Overfill the ring by 10; every third line is a warning.
+	for i := 0; i < RecentCapacity+10; i++ {
+		if i%3 == 0 {
+			Warn("%d", i)
+		} else {
+			Info("%d", i)
+		}
+	}
+
+	tests := []struct {
+		name        string
+		minLevel    Level
+		limit       int
+		count       int
+		first, last string
+	}{
+		{"all retained", DEBUG, 0, RecentCapacity, "10", "2009"},
+		{"warnings", WARN, 0, 666, "12", "2007"},
+		{"latest three", INFO, 3, 3, "2007", "2009"},
+		{"latest two warnings", WARN, 2, 2, "2004", "2007"},
+		{"limit above what is kept", DEBUG, RecentCapacity * 2, RecentCapacity, "10", "2009"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Recent(tt.minLevel, tt.limit)
+			if len(got) != tt.count {
+				t.Fatalf("Recent() returned %d records, want %d", len(got), tt.count)
+			}
+			if got[0].Message != tt.first || got[len(got)-1].Message != tt.last {
+				t.Errorf("Recent() = %s ... %s, want %s ... %s", got[0].Message, got[len(got)-1].Message, tt.first, tt.last)
+			}
+			for i, rec := range got {
+				if rec.level < tt.minLevel {
+					t.Errorf("Recent() includes %s record %s", rec.Level, rec.Message)
+				}
+				if i > 0 && rec.Time.Before(got[i-1].Time) {
+					t.Errorf("record %s is older than %s before it", rec.Message, got[i-1].Message)
+				}
+			}
+		})
+	}
+	if got := Recent(ERROR, 0); len(got) != 0 {
+		t.Errorf("Recent(ERROR) = %d records, want none", len(got))
+	}
+
+	// Before the ring wraps it holds just what was logged.
+	l = &Logger{level: DEBUG}
+	l.sinks = []Sink{&l.recent}
+	useLogger(t, l)
+	Debug("a")
+	Info("b")
+	var msgs []string
+	for _, rec := range Recent(DEBUG, 0) {
+		msgs = append(msgs, rec.Message)
+	}
+	if fmt.Sprint(msgs) != "[a b]" {
+		t.Errorf("Recent() before wrapping = %v, want [a b]", msgs)
+	}
+}
+
+func TestNamedLevels(t *testing.T) {
+	var out strings.Builder
+	useLogger(t, &Logger{level: INFO, sinks: []Sink{WriterSink(&out)}})
+
+	SetNamedLevel("serial", DEBUG)
+	SetNamedLevel("serial.reset", ERROR)
+	serial := Named("serial")
+	serial.Debug("serial debug")
+	serial.Named("probe").Debug("child follows parent")
+	serial.Named("reset").Warn("child override wins")
+	serial.Named("reset").Named("touch").Error("grandchild follows child")
+	Named("serialx").Debug("no dotted prefix")
+	Debug("global level")
+
+	got := out.String()
+	for _, want := range []string{"serial debug", "child follows parent", "grandchild follows child"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("%q not logged", want)
+		}
+	}
+	for _, unwanted := range []string{"child override wins", "no dotted prefix", "global level"} {
+		if strings.Contains(got, unwanted) {
+			t.Errorf("%q logged", unwanted)
+		}
+	}
+	if !strings.Contains(got, "[serial.reset.touch] grandchild") {
+		t.Errorf("child name missing from %q", got)
+	}
+
+	out.Reset()
+	ClearNamedLevel("serial")
+	serial.Named("probe").Debug("cleared")
+	if out.Len() != 0 {
+		t.Errorf("after ClearNamedLevel got %q", out.String())
+	}
+}
+
+func TestParseNamedLevels(t *testing.T) {
+	got, err := ParseNamedLevels(" serial=debug, bingen = warn ,,")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got["serial"] != DEBUG || got["bingen"] != WARN {
+		t.Errorf("ParseNamedLevels() = %v", got)
+	}
+	for _, spec := range []string{"serial", "=debug", "serial=loud", "serial=debug,bingen"} {
+		if levels, err := ParseNamedLevels(spec); err == nil {
+			t.Errorf("ParseNamedLevels(%q) = %v, want an error", spec, levels)
+		}
+	}
+}
@@ -0,0 +1,176 @@
+// Package ltc generates SMPTE linear timecode (LTC) audio so external systems
+// (cameras, video servers, lighting desks) can chase PicoLume playback.
+//
+// The generator produces 16-bit mono PCM; playback on a chosen output device
+// is left to the caller (the frontend plays it through Web Audio, which can
+// route to any output and is already locked to the transport).
+package ltc
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+)
+
+// BitsPerFrame is the fixed length of an LTC frame.
+const BitsPerFrame = 80
+
+// syncWord occupies bits 64-79 of every frame.
+var syncWord = [16]byte{0, 0, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 0, 1}
+
+// Timecode is an hours:minutes:seconds:frames position.
+type Timecode struct {
+	Hours, Minutes, Seconds, Frames int
+}
+
+func (t Timecode) String() string {
+	return fmt.Sprintf("%02d:%02d:%02d:%02d", t.Hours, t.Minutes, t.Seconds, t.Frames)
+}
+
+// FromMillis converts a show position to timecode at the given frame rate.
+// Hours wrap at 24 as on real timecode.
+func FromMillis(ms int64, fps int) Timecode {
+	totalFrames := ms * int64(fps) / 1000
+	return FromFrames(totalFrames, fps)
+}
+
+// FromFrames converts an absolute frame count to timecode.
+func FromFrames(totalFrames int64, fps int) Timecode {
+	f := totalFrames % int64(fps)
+	s := totalFrames / int64(fps)
+	return Timecode{
+		Hours:   int(s/3600) % 24,
+		Minutes: int(s/60) % 60,
+		Seconds: int(s % 60),
+		Frames:  int(f),
+	}
+}
+
+// FrameBits returns the 80 LTC bits for one frame (non-drop-frame, user
+// bits zero), including the polarity correction bit.
+func FrameBits(tc Timecode, fps int) [BitsPerFrame]byte {
+	var bits [BitsPerFrame]byte
+	put := func(start, width, value int) {
+		for i := 0; i < width; i++ {
+			bits[start+i] = byte((value >> i) & 1)
+		}
+	}
+
+	put(0, 4, tc.Frames%10)
+	put(8, 2, tc.Frames/10)
+	put(16, 4, tc.Seconds%10)
+	put(24, 3, tc.Seconds/10)
+	put(32, 4, tc.Minutes%10)
+	put(40, 3, tc.Minutes/10)
+	put(48, 4, tc.Hours%10)
+	put(56, 2, tc.Hours/10)
+	copy(bits[64:], syncWord[:])
+
+	// Polarity correction keeps the number of zeros in the frame even, so
+	// every frame starts with the same signal polarity. The bit lives at 59
+	// for 25fps and 27 otherwise.
+	pcBit := 27
+	if fps == 25 {
+		pcBit = 59
+	}
+	zeros := 0
+	for _, b := range bits {
+		if b == 0 {
+			zeros++
+		}
+	}
+	if zeros%2 == 1 {
+		bits[pcBit] = 1
+	}
+	return bits
+}
+
+// Generator renders LTC as PCM samples.
+type Generator struct {
+	FPS        int     // 24, 25 or 30 (non-drop)
+	SampleRate int     // e.g. 48000
+	Amplitude  float64 // 0..1 of full scale
+}
+
+// Validate checks the generator settings.
+func (g Generator) Validate() error {
+	switch g.FPS {
+	case 24, 25, 30:
+	default:
+		return fmt.Errorf("unsupported frame rate %d (use 24, 25 or 30)", g.FPS)
+	}
+	if g.SampleRate < 8000 || g.SampleRate > 192000 {
+		return fmt.Errorf("unsupported sample rate %d", g.SampleRate)
+	}
+	if g.Amplitude <= 0 || g.Amplitude > 1 {
+		return fmt.Errorf("amplitude must be in (0, 1]")
+	}
+	return nil
+}
+
+// Render returns PCM for [startMs, startMs+durationMs) using biphase mark
+// coding: every bit starts with a transition, and a 1 has a second
+// transition mid-bit.
+func (g Generator) Render(startMs, durationMs int64) ([]int16, error) {
+	if err := g.Validate(); err != nil {
+		return nil, err
+	}
+	if startMs < 0 || durationMs <= 0 {
+		return nil, fmt.Errorf("invalid range")
+	}
+
+	samplesPerBit := float64(g.SampleRate) / float64(g.FPS*BitsPerFrame)
+	halfBit := samplesPerBit / 2
+	level := int16(g.Amplitude * math.MaxInt16)
+
+	firstFrame := startMs * int64(g.FPS) / 1000
+	frameStartMs := firstFrame * 1000 / int64(g.FPS)
+	skip := int((startMs - frameStartMs) * int64(g.SampleRate) / 1000)
+	want := int(durationMs * int64(g.SampleRate) / 1000)
+
+	out := make([]int16, 0, want+skip)
+	polarity := int16(1)
+	var clock float64 // fractional sample position
+	emit := func(until float64) {
+		for float64(len(out)) < until {
+			out = append(out, polarity*level)
+		}
+	}
+
+	for frame := firstFrame; len(out) < want+skip; frame++ {
+		bits := FrameBits(FromFrames(frame, g.FPS), g.FPS)
+		for _, bit := range bits {
+			polarity = -polarity
+			if bit == 1 {
+				emit(clock + halfBit)
+				polarity = -polarity
+			}
+			clock += samplesPerBit
+			emit(clock)
+		}
+	}
+
+	return out[skip : skip+want], nil
+}
+
+// WAV wraps mono 16-bit PCM samples in a RIFF/WAVE container.
+func WAV(samples []int16, sampleRate int) []byte {
+	var buf bytes.Buffer
+	dataLen := uint32(len(samples) * 2)
+	buf.WriteString("RIFF")
+	binary.Write(&buf, binary.LittleEndian, 36+dataLen)
+	buf.WriteString("WAVE")
+	buf.WriteString("fmt ")
+	binary.Write(&buf, binary.LittleEndian, uint32(16))
+	binary.Write(&buf, binary.LittleEndian, uint16(1)) // PCM
+	binary.Write(&buf, binary.LittleEndian, uint16(1)) // mono
+	binary.Write(&buf, binary.LittleEndian, uint32(sampleRate))
+	binary.Write(&buf, binary.LittleEndian, uint32(sampleRate*2))
+	binary.Write(&buf, binary.LittleEndian, uint16(2))
+	binary.Write(&buf, binary.LittleEndian, uint16(16))
+	buf.WriteString("data")
+	binary.Write(&buf, binary.LittleEndian, dataLen)
+	binary.Write(&buf, binary.LittleEndian, samples)
+	return buf.Bytes()
+}
@@ -0,0 +1,81 @@
+package ltc
+
+import (
+	"testing"
+)
+
+// decodeFrame recovers bits from biphase mark PCM by checking for a
+// mid-bit transition.
+func decodeFrame(samples []int16, samplesPerBit float64) [BitsPerFrame]byte {
+	var bits [BitsPerFrame]byte
+	for i := 0; i < BitsPerFrame; i++ {
+		start := float64(i) * samplesPerBit
+		early := samples[int(start+samplesPerBit*0.25)]
+		late := samples[int(start+samplesPerBit*0.75)]
+		if (early > 0) != (late > 0) {
+			bits[i] = 1
+		}
+	}
+	return bits
+}
+
+func TestFrameBitsRoundTrip(t *testing.T) {
+	tc := Timecode{Hours: 1, Minutes: 23, Seconds: 45, Frames: 12}
+	bits := FrameBits(tc, 30)
+
+	for i, want := range syncWord {
+		if bits[64+i] != want {
+			t.Fatalf("sync word bit %d = %d, want %d", 64+i, bits[64+i], want)
+		}
+	}
+
+	zeros := 0
+	for _, b := range bits {
+		if b == 0 {
+			zeros++
+		}
+	}
+	if zeros%2 != 0 {
+		t.Errorf("frame has %d zeros, want even (polarity correction)", zeros)
+	}
+
+	field := func(start, width int) int {
+		v := 0
+		for i := 0; i < width; i++ {
+			v |= int(bits[start+i]) << i
+		}
+		return v
+	}
+	got := Timecode{
+		Hours:   field(56, 2)*10 + field(48, 4),
+		Minutes: field(40, 3)*10 + field(32, 4),
+		Seconds: field(24, 3)*10 + field(16, 4),
+		Frames:  field(8, 2)*10 + field(0, 4),
+	}
+	if got != tc {
+		t.Errorf("decoded %v, want %v", got, tc)
+	}
+}
+
+func TestRenderEncodesBiphaseMark(t *testing.T) {
+	g := Generator{FPS: 25, SampleRate: 48000, Amplitude: 0.5}
+	samples, err := g.Render(2000, 40)
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	if len(samples) != 1920 {
+		t.Fatalf("Render() returned %d samples, want 1920", len(samples))
+	}
+
+	got := decodeFrame(samples, 24)
+	want := FrameBits(Timecode{Seconds: 2}, 25)
+	if got != want {
+		t.Errorf("decoded frame bits\n got %v\nwant %v", got, want)
+	}
+}
+
+func TestGeneratorValidate(t *testing.T) {
+	if _, err := (Generator{FPS: 29, SampleRate: 48000, Amplitude: 0.5}).Render(0, 100); err == nil {
+		t.Error("Render() with 29fps should fail")
+	}
+}
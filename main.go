@@ -32,6 +32,7 @@ import (
 	"github.com/wailsapp/wails/v2"
 	"github.com/wailsapp/wails/v2/pkg/options"
 	"github.com/wailsapp/wails/v2/pkg/options/assetserver"
+	"github.com/wailsapp/wails/v2/pkg/options/mac"
 	"github.com/wailsapp/wails/v2/pkg/options/windows"
 )
 
@@ -46,46 +47,109 @@ func getAssets() fs.FS {
 	return sub
 }
 
-func main() {
-	// Initialize logging
-	// Use user's config directory for logs
+// appDataDir returns the directory holding logs, settings, crash dumps and
+// other application state: next to the executable in portable mode,
+// otherwise per-user.
+func appDataDir() string {
+	if portableDataDir != "" {
+		return portableDataDir
+	}
 	configDir, err := os.UserConfigDir()
 	if err != nil {
 		configDir = "."
 	}
-	logDir := filepath.Join(configDir, "PicoLume", "logs")
+	return filepath.Join(configDir, "PicoLume")
+}
+
+func main() {
+	initPortableMode(os.Args[1:])
 
-	if err := logger.Init(logDir, logger.INFO); err != nil {
+	// Initialize logging
+	// Use the app data directory (user config dir, or next to the exe when portable)
+	logDir := filepath.Join(appDataDir(), "logs")
+
+	// PICOLUME_LOG_FORMAT=json switches to machine-readable logs for support tooling
+	logFormat, formatErr := logger.ParseFormat(os.Getenv("PICOLUME_LOG_FORMAT"))
+
+	if err := logger.Init(logDir, logger.INFO, logFormat); err != nil {
 		// Fall back to stdout-only logging if file logging fails
 		logger.Warn("Failed to initialize file logging: %v", err)
 	}
 	defer logger.Close()
 
+	if formatErr != nil {
+		logger.Warn("%v; using text logs", formatErr)
+	}
+
+	// PICOLUME_LOG_LEVELS=serial=debug,drive=warn tunes noisy subsystems
+	if levels, err := logger.ParseNamedLevels(os.Getenv("PICOLUME_LOG_LEVELS")); err != nil {
+		logger.Warn("Ignoring PICOLUME_LOG_LEVELS: %v", err)
+	} else {
+		for name, level := range levels {
+			logger.SetNamedLevel(name, level)
+		}
+	}
+
 	logger.Info("PicoLume Studio starting...")
+	if portableDataDir != "" {
+		logger.Info("Portable mode: data in %s", portableDataDir)
+	}
 
 	// Create an instance of the app structure
 	app := NewApp()
+	app.launchArgs = os.Args[1:]
+	app.preview = parsePreviewArgs(os.Args[1:])
+	app.loadSettings()
+	app.loadDevices()
 
-	// Create application with options
-	err = wails.Run(&options.App{
+	appOptions := &options.App{
 		Title:     "PicoLume Studio",
 		Frameless: true,
 		Windows: &windows.Options{
 			DisableWindowIcon: true,
+			// Empty uses the WebView2 default under %AppData%.
+			WebviewUserDataPath: portableWebviewDir(),
+		},
+		Mac: &mac.Options{
+			OnUrlOpen: app.openURL,
+		},
+		SingleInstanceLock: &options.SingleInstanceLock{
+			UniqueId:               singleInstanceID,
+			OnSecondInstanceLaunch: app.secondInstance,
 		},
 		Width:  1280,
 		Height: 800,
 		AssetServer: &assetserver.Options{
-			Assets: getAssets(),
+			Assets:     getAssets(),
+			Middleware: app.projectAudioMiddleware,
 		},
-		BackgroundColour: &options.RGBA{R: 27, G: 38, B: 54, A: 1},
+		BackgroundColour: startupBackground(app.currentSettings()),
 		OnStartup:        app.startup,
+		OnDomReady:       app.domReady,
+		OnBeforeClose:    app.beforeClose,
+		OnShutdown:       app.shutdown,
 		Bind: []interface{}{
 			app,
 		},
 
-		WindowStartState: options.Maximised,
-	})
+		WindowStartState: windowStartState(app.currentSettings().Window),
+		AlwaysOnTop:      app.currentSettings().Window.AlwaysOnTop,
+	}
+
+	if app.preview.Enabled {
+		// The detached preview is a second process: a smaller always-on-top
+		// window that must not be forwarded to the editor instance.
+		appOptions.Title = "PicoLume Preview"
+		appOptions.Width, appOptions.Height = 800, 600
+		appOptions.WindowStartState = options.Normal
+		appOptions.AlwaysOnTop = true
+		appOptions.SingleInstanceLock = nil
+		appOptions.OnBeforeClose = nil
+		app.launchArgs = nil
+	}
+
+	// Create application with options
+	err := wails.Run(appOptions)
 
 	if err != nil {
 		logger.Error("Application failed to start: %v", err)
@@ -0,0 +1,489 @@
+// Package markers imports song-section markers exported from DAWs (Reaper
+// marker/region CSV, Pro Tools session text, generic CSV) so music editors'
+// markers can become project cues without re-timing them by ear.
+package markers
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"PicoLume/bingen"
+)
+
+// Format identifies the layout of a marker export.
+type Format string
+
+const (
+	FormatReaper   Format = "reaper"
+	FormatProTools Format = "protools"
+	FormatCSV      Format = "csv"
+)
+
+// CueIDs are the cue slots markers are mapped onto, in order.
+var CueIDs = []string{"A", "B", "C", "D"}
+
+// Marker is a named position on the song timeline.
+type Marker struct {
+	Name   string `json:"name"`
+	TimeMs int    `json:"timeMs"`
+}
+
+// Parse detects the export format and returns the markers sorted by time.
+func Parse(data []byte) ([]Marker, Format, error) {
+	format := Detect(data)
+	var (
+		markers []Marker
+		err     error
+	)
+	switch format {
+	case FormatProTools:
+		markers, err = parseProTools(data)
+	case FormatReaper:
+		markers, err = parseReaper(data)
+	default:
+		markers, err = parseGenericCSV(data)
+	}
+	if err != nil {
+		return nil, format, err
+	}
+	if len(markers) == 0 {
+		return nil, format, fmt.Errorf("no markers found")
+	}
+	sort.SliceStable(markers, func(i, j int) bool { return markers[i].TimeMs < markers[j].TimeMs })
+	return markers, format, nil
+}
+
+// Detect guesses the export format from its content.
+func Detect(data []byte) Format {
+	text := strings.ToUpper(string(data))
+	if strings.Contains(text, "M A R K E R S  L I S T I N G") || strings.Contains(text, "SESSION NAME:") {
+		return FormatProTools
+	}
+	firstLine, _, _ := strings.Cut(strings.TrimSpace(text), "\n")
+	if strings.HasPrefix(firstLine, "#,NAME,START") {
+		return FormatReaper
+	}
+	return FormatCSV
+}
+
+// ToCues maps the first markers onto cue slots A-D. Unused slots are
+// returned disabled so the result can replace a project's cue list.
+func ToCues(markers []Marker) []bingen.Cue {
+	cues := make([]bingen.Cue, len(CueIDs))
+	for i, id := range CueIDs {
+		cues[i] = bingen.Cue{ID: id}
+		if i < len(markers) {
+			t := markers[i].TimeMs
+			cues[i].TimeMs = &t
+			cues[i].Enabled = true
+		}
+	}
+	return cues
+}
+
+// parseReaper reads Reaper's marker/region manager export:
+// "#,Name,Start,End,Length" with IDs like "M1" (marker) or "R1" (region).
+func parseReaper(data []byte) ([]Marker, error) {
+	records, err := readCSV(data)
+	if err != nil {
+		return nil, err
+	}
+	var markers []Marker
+	for i, rec := range records {
+		if i == 0 || len(rec) < 3 {
+			continue
+		}
+		ms, err := ParseTime(rec[2])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", i+1, err)
+		}
+		name := strings.TrimSpace(rec[1])
+		if name == "" {
+			name = strings.TrimSpace(rec[0])
+		}
+		markers = append(markers, Marker{Name: name, TimeMs: ms})
+	}
+	return markers, nil
+}
+
+// parseProTools reads the MARKERS LISTING section of a Pro Tools
+// "Export Session Info as Text" file. Sample-based locations are converted
+// using the session sample rate when available; timecode locations use the
+// session's timecode format and count from its start timecode.
+func parseProTools(data []byte) ([]Marker, error) {
+	var (
+		markers    []Marker
+		sampleRate float64
+		rate       = defaultTimecodeRate
+		start      string
+		inMarkers  bool
+		sawHeader  bool
+	)
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		upper := strings.ToUpper(strings.TrimSpace(line))
+
+		if strings.HasPrefix(upper, "SAMPLE RATE:") {
+			v := strings.TrimSpace(line[strings.Index(line, ":")+1:])
+			sampleRate, _ = strconv.ParseFloat(v, 64)
+			continue
+		}
+		if strings.HasPrefix(upper, "TIMECODE FORMAT:") {
+			if r, err := parseTimecodeRate(line[strings.Index(line, ":")+1:]); err == nil {
+				rate = r
+			}
+			continue
+		}
+		if strings.HasPrefix(upper, "SESSION START TIMECODE:") {
+			start = strings.TrimSpace(line[strings.Index(line, ":")+1:])
+			continue
+		}
+		if strings.HasPrefix(upper, "M A R K E R S") {
+			inMarkers = true
+			continue
+		}
+		if !inMarkers {
+			continue
+		}
+		if upper == "" {
+			if sawHeader {
+				break
+			}
+			continue
+		}
+		if strings.HasPrefix(upper, "#") {
+			sawHeader = true
+			continue
+		}
+
+		cols := strings.Split(line, "\t")
+		for i := range cols {
+			cols[i] = strings.TrimSpace(cols[i])
+		}
+		// #, LOCATION, TIME REFERENCE, UNITS, NAME, COMMENTS
+		if len(cols) < 5 {
+			continue
+		}
+
+		var ms int
+		var err error
+		if strings.EqualFold(cols[3], "Samples") && sampleRate > 0 {
+			samples, perr := strconv.ParseFloat(cols[2], 64)
+			if perr != nil {
+				return nil, fmt.Errorf("marker %s: invalid sample position %q", cols[0], cols[2])
+			}
+			ms = int(math.Round(samples / sampleRate * 1000))
+		} else if isTimecode(cols[1]) {
+			ms, err = timecodeFromStart(cols[1], start, rate)
+			if err != nil {
+				return nil, fmt.Errorf("marker %s: %w", cols[0], err)
+			}
+		} else {
+			ms, err = ParseTime(cols[1])
+			if err != nil {
+				return nil, fmt.Errorf("marker %s: %w", cols[0], err)
+			}
+		}
+		markers = append(markers, Marker{Name: cols[4], TimeMs: ms})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return markers, nil
+}
+
+// parseGenericCSV accepts rows with a time column and usually a name,
+// such as "name,time", "time,name" or "index,name,time", with an optional
+// header line. The columns are chosen once for the whole file: from the
+// header's labels, or else the time column is the one that parses as a
+// time in every row, preferring one that is not just whole numbers, which
+// is more likely an index.
+func parseGenericCSV(data []byte) ([]Marker, error) {
+	records, err := readCSV(data)
+	if err != nil {
+		return nil, err
+	}
+	first := 0
+	for first < len(records) && blankRecord(records[first]) {
+		first++
+	}
+	if first == len(records) {
+		return nil, nil
+	}
+
+	timeCol, nameCol := headerColumns(records[first])
+	body := records[first:]
+	if timeCol >= 0 || nameCol >= 0 || !anyTime(records[first]) {
+		first++
+		body = records[first:]
+	}
+	if timeCol < 0 {
+		timeCol = timeColumn(body)
+	}
+	if timeCol < 0 {
+		return nil, fmt.Errorf("no column holds a time in every row")
+	}
+	if nameCol < 0 {
+		nameCol = nameColumn(body, timeCol)
+	}
+
+	var markers []Marker
+	for i, rec := range body {
+		if blankRecord(rec) {
+			continue
+		}
+		line := first + i + 1
+		if timeCol >= len(rec) {
+			return nil, fmt.Errorf("line %d: no time column", line)
+		}
+		ms, err := ParseTime(rec[timeCol])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		name := ""
+		if nameCol >= 0 && nameCol < len(rec) {
+			name = strings.TrimSpace(rec[nameCol])
+		}
+		if name == "" {
+			name = fmt.Sprintf("Marker %d", len(markers)+1)
+		}
+		markers = append(markers, Marker{Name: name, TimeMs: ms})
+	}
+	return markers, nil
+}
+
+// Header labels that name the time and name columns of a generic CSV.
+var (
+	timeLabels = map[string]bool{"time": true, "start": true, "position": true, "start time": true}
+	nameLabels = map[string]bool{"name": true, "label": true, "marker": true, "title": true}
+)
+
+// headerColumns returns the columns rec labels as the time and the name,
+// or -1 for each it does not label.
+func headerColumns(rec []string) (timeCol, nameCol int) {
+	timeCol, nameCol = -1, -1
+	for c, field := range rec {
+		label := strings.ToLower(strings.TrimSpace(field))
+		if timeLabels[label] && timeCol < 0 {
+			timeCol = c
+		}
+		if nameLabels[label] && nameCol < 0 {
+			nameCol = c
+		}
+	}
+	return timeCol, nameCol
+}
+
+// timeColumn returns the column of records that parses as a time in every
+// row, or -1. Of several, the first that is not all whole numbers wins,
+// else the last.
+func timeColumn(records [][]string) int {
+	best := -1
+	for c := 0; ; c++ {
+		inRange, parses, counts := false, true, true
+		for _, rec := range records {
+			if blankRecord(rec) {
+				continue
+			}
+			if c >= len(rec) {
+				parses = false
+				continue
+			}
+			inRange = true
+			if _, err := ParseTime(rec[c]); err != nil {
+				parses = false
+			}
+			if _, err := strconv.Atoi(strings.TrimSpace(rec[c])); err == nil {
+				continue
+			}
+			counts = false
+		}
+		if !inRange {
+			return best
+		}
+		if !parses {
+			continue
+		}
+		if !counts {
+			return c
+		}
+		best = c
+	}
+}
+
+// nameColumn returns the first column of records besides timeCol holding
+// text that is not a time, else the first besides timeCol, or -1.
+func nameColumn(records [][]string, timeCol int) int {
+	fallback := -1
+	for c := 0; ; c++ {
+		inRange := false
+		for _, rec := range records {
+			if c >= len(rec) {
+				continue
+			}
+			inRange = true
+			if c == timeCol {
+				continue
+			}
+			if fallback < 0 {
+				fallback = c
+			}
+			if field := strings.TrimSpace(rec[c]); field != "" {
+				if _, err := ParseTime(field); err != nil {
+					return c
+				}
+			}
+		}
+		if !inRange {
+			return fallback
+		}
+	}
+}
+
+// anyTime reports whether a field of rec parses as a time.
+func anyTime(rec []string) bool {
+	for _, field := range rec {
+		if _, err := ParseTime(field); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+func blankRecord(rec []string) bool {
+	for _, field := range rec {
+		if strings.TrimSpace(field) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+func readCSV(data []byte) ([][]string, error) {
+	r := csv.NewReader(bytes.NewReader(data))
+	r.FieldsPerRecord = -1
+	r.TrimLeadingSpace = true
+	r.Comment = 0
+	var records [][]string
+	for {
+		rec, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid CSV: %w", err)
+		}
+		records = append(records, rec)
+	}
+	return records, nil
+}
+
+// ParseTime converts "h:mm:ss.sss", "m:ss.sss" or plain seconds to
+// milliseconds.
+func ParseTime(s string) (int, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, fmt.Errorf("empty time")
+	}
+	parts := strings.Split(s, ":")
+	if len(parts) > 3 {
+		return 0, fmt.Errorf("invalid time %q", s)
+	}
+	var seconds float64
+	for _, part := range parts {
+		v, err := strconv.ParseFloat(part, 64)
+		if err != nil || v < 0 || math.IsInf(v, 0) || math.IsNaN(v) {
+			return 0, fmt.Errorf("invalid time %q", s)
+		}
+		seconds = seconds*60 + v
+	}
+	return int(math.Round(seconds * 1000)), nil
+}
+
+// TimecodeRate is a SMPTE frame rate.
+type TimecodeRate struct {
+	FPS  float64 // frames per second of real time, e.g. 29.97
+	Drop bool    // drop-frame numbering
+}
+
+// defaultTimecodeRate is used when a session does not state its format.
+var defaultTimecodeRate = TimecodeRate{FPS: 30}
+
+// parseTimecodeRate reads a Pro Tools timecode format such as "25 Frame"
+// or "29.97 Drop Frame".
+func parseTimecodeRate(s string) (TimecodeRate, error) {
+	fields := strings.Fields(s)
+	if len(fields) == 0 {
+		return TimecodeRate{}, fmt.Errorf("empty timecode format")
+	}
+	fps, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil || fps <= 0 || fps > 120 {
+		return TimecodeRate{}, fmt.Errorf("invalid timecode format %q", strings.TrimSpace(s))
+	}
+	return TimecodeRate{FPS: fps, Drop: strings.Contains(strings.ToUpper(s), "DROP")}, nil
+}
+
+// isTimecode reports whether s looks like "hh:mm:ss:ff" (or "hh:mm:ss;ff"
+// for drop-frame) rather than a clock time.
+func isTimecode(s string) bool {
+	return strings.Count(s, ":")+strings.Count(s, ";") == 3
+}
+
+// ParseTimecode converts SMPTE timecode "hh:mm:ss:ff" at rate to
+// milliseconds. Drop-frame timecode may separate the frames with ";".
+func ParseTimecode(s string, rate TimecodeRate) (int, error) {
+	s = strings.TrimSpace(s)
+	parts := strings.FieldsFunc(s, func(r rune) bool { return r == ':' || r == ';' })
+	if len(parts) != 4 || !isTimecode(s) {
+		return 0, fmt.Errorf("invalid timecode %q", s)
+	}
+	var v [4]int
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid timecode %q", s)
+		}
+		v[i] = n
+	}
+	h, m, sec, ff := v[0], v[1], v[2], v[3]
+	nominal := int(math.Round(rate.FPS))
+	if m > 59 || sec > 59 || ff >= nominal {
+		return 0, fmt.Errorf("invalid timecode %q", s)
+	}
+
+	frames := (h*3600+m*60+sec)*nominal + ff
+	if rate.Drop {
+		// Drop-frame skips the first frame numbers of every minute except
+		// each tenth, 2 per minute at 30 fps and 4 at 60 fps.
+		dropped := nominal / 15
+		minutes := h*60 + m
+		frames -= dropped * (minutes - minutes/10)
+	}
+	return int(math.Round(float64(frames) / rate.FPS * 1000)), nil
+}
+
+// timecodeFromStart converts tc to milliseconds after the session start
+// timecode start, or after zero when start is empty.
+func timecodeFromStart(tc, start string, rate TimecodeRate) (int, error) {
+	ms, err := ParseTimecode(tc, rate)
+	if err != nil || start == "" {
+		return ms, err
+	}
+	startMs, err := ParseTimecode(start, rate)
+	if err != nil {
+		return 0, fmt.Errorf("session start: %w", err)
+	}
+	if ms < startMs {
+		return 0, fmt.Errorf("timecode %s is before the session start %s", strings.TrimSpace(tc), start)
+	}
+	return ms - startMs, nil
+}
@@ -0,0 +1,167 @@
+package markers
+
+import (
+	"testing"
+)
+
+func TestParseFormats(t *testing.T) {
+	tests := []struct {
+		name       string
+		input      string
+		wantFormat Format
+		want       []Marker
+	}{
+		{
+			name: "reaper marker manager export",
+			input: "#,Name,Start,End,Length\n" +
+				"M1,Intro,0:00.000,,\n" +
+				"R2,Verse,0:12.500,0:40.000,0:27.500\n" +
+				"M3,,1:05.250,,\n",
+			wantFormat: FormatReaper,
+			want: []Marker{
+				{Name: "Intro", TimeMs: 0},
+				{Name: "Verse", TimeMs: 12500},
+				{Name: "M3", TimeMs: 65250},
+			},
+		},
+		{
+			name: "pro tools session text with samples",
+			input: "SESSION NAME:\tOpener\n" +
+				"SAMPLE RATE:\t48000.000000\n\n" +
+				"M A R K E R S  L I S T I N G\n" +
+				"#   \tLOCATION     \tTIME REFERENCE    \tUNITS    \tNAME                             \tCOMMENTS\n" +
+				"1   \t0:02.000     \t96000            \tSamples  \tHit 1                            \t\n" +
+				"2   \t0:10.500     \t504000           \tSamples  \tBallad                           \t\n",
+			wantFormat: FormatProTools,
+			want: []Marker{
+				{Name: "Hit 1", TimeMs: 2000},
+				{Name: "Ballad", TimeMs: 10500},
+			},
+		},
+		{
+			name: "pro tools session text with timecode",
+			input: "SESSION NAME:\tOpener\n" +
+				"TIMECODE FORMAT:\t25 Frame\n" +
+				"SESSION START TIMECODE:\t01:00:00:00\n\n" +
+				"M A R K E R S  L I S T I N G\n" +
+				"#   \tLOCATION     \tTIME REFERENCE    \tUNITS    \tNAME                             \tCOMMENTS\n" +
+				"1   \t01:00:02:00  \t96000            \tSamples  \tHit 1                            \t\n" +
+				"2   \t01:01:10:12  \t3379200          \tSamples  \tBallad                           \t\n",
+			wantFormat: FormatProTools,
+			want: []Marker{
+				{Name: "Hit 1", TimeMs: 2000},
+				{Name: "Ballad", TimeMs: 70480},
+			},
+		},
+		{
+			name:       "generic csv with header, unsorted",
+			input:      "time,name\n30.5,Chorus\n4,Intro\n",
+			wantFormat: FormatCSV,
+			want: []Marker{
+				{Name: "Intro", TimeMs: 4000},
+				{Name: "Chorus", TimeMs: 30500},
+			},
+		},
+		{
+			name:       "generic csv with a leading index column",
+			input:      "1,Verse,0:30\n2,Chorus,1:05\n",
+			wantFormat: FormatCSV,
+			want: []Marker{
+				{Name: "Verse", TimeMs: 30000},
+				{Name: "Chorus", TimeMs: 65000},
+			},
+		},
+		{
+			name:       "generic csv with an index column and header",
+			input:      "Index,Name,Time\n1,Intro,0\n2,Verse,30\n",
+			wantFormat: FormatCSV,
+			want: []Marker{
+				{Name: "Intro", TimeMs: 0},
+				{Name: "Verse", TimeMs: 30000},
+			},
+		},
+		{
+			name:       "generic csv with a name that parses as a time",
+			input:      "Intro,0:05\n2024,0:10\n",
+			wantFormat: FormatCSV,
+			want: []Marker{
+				{Name: "Intro", TimeMs: 5000},
+				{Name: "2024", TimeMs: 10000},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, format, err := Parse([]byte(tt.input))
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if format != tt.wantFormat {
+				t.Errorf("Parse() format = %v, want %v", format, tt.wantFormat)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("Parse() = %+v, want %+v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("marker %d = %+v, want %+v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestToCues(t *testing.T) {
+	cues := ToCues([]Marker{{Name: "Intro", TimeMs: 0}, {Name: "Verse", TimeMs: 12500}})
+	if len(cues) != 4 {
+		t.Fatalf("ToCues() returned %d cues, want 4", len(cues))
+	}
+	if !cues[1].Enabled || cues[1].TimeMs == nil || *cues[1].TimeMs != 12500 {
+		t.Errorf("cue B = %+v, want enabled at 12500", cues[1])
+	}
+	if cues[2].Enabled || cues[2].TimeMs != nil {
+		t.Errorf("cue C = %+v, want disabled", cues[2])
+	}
+}
+
+func TestParseTimecode(t *testing.T) {
+	tests := []struct {
+		tc      string
+		rate    TimecodeRate
+		want    int
+		wantErr bool
+	}{
+		{"00:00:01:00", TimecodeRate{FPS: 30}, 1000, false},
+		{"01:00:00:00", TimecodeRate{FPS: 25}, 3_600_000, false},
+		{"00:00:02:12", TimecodeRate{FPS: 24}, 2500, false},
+		{"00:00:02:12", TimecodeRate{FPS: 23.976}, 2503, false},
+		{"00:01:00;02", TimecodeRate{FPS: 29.97, Drop: true}, 60_060, false},
+		{"00:10:00;00", TimecodeRate{FPS: 29.97, Drop: true}, 600_000, false},
+		{"00:00:00:25", TimecodeRate{FPS: 25}, 0, true},
+		{"00:60:00:00", TimecodeRate{FPS: 25}, 0, true},
+		{"00:00:01", TimecodeRate{FPS: 25}, 0, true},
+		{"00:00:aa:00", TimecodeRate{FPS: 25}, 0, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseTimecode(tt.tc, tt.rate)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseTimecode(%q, %v) error = %v, wantErr %v", tt.tc, tt.rate, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseTimecode(%q, %v) = %d, want %d", tt.tc, tt.rate, got, tt.want)
+		}
+	}
+}
+
+func TestParseGenericCSVErrors(t *testing.T) {
+	for _, input := range []string{
+		"Intro,soon\nVerse,later\n",
+		"time,name\n0:10,Intro\nlater,Verse\n",
+	} {
+		if markers, _, err := Parse([]byte(input)); err == nil {
+			t.Errorf("Parse(%q) = %+v, want an error", input, markers)
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"fmt"
+	"sync/atomic"
+
+	"PicoLume/logger"
+)
+
+// ==========================================================
+// MEMORY BUDGET
+// ==========================================================
+
+// memoryBudget accounts for the large allocations Studio makes on behalf of
+// a project (project JSON, show.bin buffers, decoded audio) against
+// settings.Limits.MemoryMB. Estimates are deliberately generous; the point
+// is to refuse a job up front instead of being OOM-killed halfway through.
+type memoryBudget struct {
+	used atomic.Int64
+}
+
+// reserveMemory claims n bytes for what, returning a release func, or an
+// error if the budget would be exceeded.
+func (a *App) reserveMemory(what string, n int64) (func(), error) {
+	limit := int64(a.currentSettings().Limits.MemoryMB) * megabyte
+	if n > 0 {
+		used := a.memory.used.Add(n)
+		if used > limit {
+			a.memory.used.Add(-n)
+			logger.Warn("Memory budget: refused %s (%d MB, %d of %d MB in use)", what, n/megabyte, (used-n)/megabyte, limit/megabyte)
+			return nil, fmt.Errorf("%s needs about %d MB, which exceeds the memory budget (%d of %d MB in use); raise it in Settings or simplify the project",
+				what, max(1, n/megabyte), (used-n)/megabyte, limit/megabyte)
+		}
+	}
+	var once atomic.Bool
+	return func() {
+		if once.CompareAndSwap(false, true) && n > 0 {
+			a.memory.used.Add(-n)
+		}
+	}, nil
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	goruntime "runtime"
+
+	"github.com/wailsapp/wails/v2/pkg/menu"
+	"github.com/wailsapp/wails/v2/pkg/menu/keys"
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// ==========================================================
+// APPLICATION MENU
+// ==========================================================
+
+// Menu actions emitted to the frontend as "menu:action" when the action
+// needs frontend state (the current project, undo history, panels).
+const (
+	MenuNew           = "new"
+	MenuSave          = "save"
+	MenuSaveAs        = "save-as"
+	MenuExportBinary  = "export-binary"
+	MenuUpload        = "upload"
+	MenuToggleConsole = "toggle-console"
+	MenuPreferences   = "preferences"
+	MenuAbout         = "about"
+)
+
+// emitMenuAction returns a menu callback that forwards action to the frontend.
+func (a *App) emitMenuAction(action string) menu.Callback {
+	return func(*menu.CallbackData) {
+		if a.ctx != nil {
+			runtime.EventsEmit(a.ctx, "menu:action", action)
+		}
+	}
+}
+
+// buildMenu creates the File/Edit/Device/Help menu. Actions the backend can
+// complete on its own (open, diagnostics, update check) run here directly.
+func (a *App) buildMenu() *menu.Menu {
+	appMenu := menu.NewMenu()
+	if goruntime.GOOS == "darwin" {
+		appMenu.Append(menu.AppMenu())
+	}
+
+	file := appMenu.AddSubmenu("File")
+	file.AddText("New Project", keys.CmdOrCtrl("n"), a.emitMenuAction(MenuNew))
+	file.AddText("Open Project...", keys.CmdOrCtrl("o"), func(*menu.CallbackData) {
+		a.goSafe("menu open", func() {
+			resp := a.LoadProject()
+			if resp.Error == "Cancelled" {
+				return
+			}
+			runtime.EventsEmit(a.ctx, "project:opened", resp)
+		})
+	})
+	file.AddSeparator()
+	file.AddText("Save", keys.CmdOrCtrl("s"), a.emitMenuAction(MenuSave))
+	file.AddText("Save As...", keys.Combo("s", keys.CmdOrCtrlKey, keys.ShiftKey), a.emitMenuAction(MenuSaveAs))
+	file.AddText("Export show.bin...", keys.CmdOrCtrl("e"), a.emitMenuAction(MenuExportBinary))
+	file.AddSeparator()
+	file.AddText("Preferences...", keys.CmdOrCtrl(","), a.emitMenuAction(MenuPreferences))
+	if goruntime.GOOS != "darwin" {
+		file.AddSeparator()
+		file.AddText("Quit", keys.CmdOrCtrl("q"), func(*menu.CallbackData) {
+			// Goes through OnBeforeClose, so unsaved changes still prompt.
+			runtime.Quit(a.ctx)
+		})
+	}
+
+	if goruntime.GOOS == "darwin" {
+		// macOS only routes Cmd+C/V/X to the webview through the Edit role.
+		appMenu.Append(menu.EditMenu())
+	}
+
+	device := appMenu.AddSubmenu("Device")
+	device.AddText("Upload to Receiver", keys.CmdOrCtrl("u"), a.emitMenuAction(MenuUpload))
+	device.AddCheckbox("Log Serial Traffic", serialTraceEnabled.Load(), nil, func(cd *menu.CallbackData) {
+		a.SetSerialTrace(cd.MenuItem.Checked)
+	})
+
+	help := appMenu.AddSubmenu("Help")
+	help.AddText("Toggle Console", keys.Key("f12"), a.emitMenuAction(MenuToggleConsole))
+	help.AddText("Export Diagnostics...", nil, func(*menu.CallbackData) {
+		a.goSafe("menu diagnostics", func() {
+			a.ExportDiagnostics("", "")
+		})
+	})
+	help.AddText("Check for Updates...", nil, func(*menu.CallbackData) {
+		a.goSafe("menu update check", func() {
+			info := a.CheckForUpdates()
+			runtime.EventsEmit(a.ctx, "update:checked", info)
+		})
+	})
+	help.AddSeparator()
+	help.AddText("About PicoLume Studio", nil, a.emitMenuAction(MenuAbout))
+
+	return appMenu
+}
@@ -0,0 +1,237 @@
+// Package ofl imports Open Fixture Library (OFL) fixture definitions and turns
+// their pixel modes into PicoLume HardwareProfiles (LED count, color order,
+// chipset), so commercially available pixel props don't need hand entry.
+package ofl
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"PicoLume/bingen"
+)
+
+// Fixture is the subset of the OFL fixture schema used for profile import.
+type Fixture struct {
+	Name              string             `json:"name"`
+	ShortName         string             `json:"shortName"`
+	Categories        []string           `json:"categories"`
+	Matrix            *Matrix            `json:"matrix"`
+	AvailableChannels map[string]Channel `json:"availableChannels"`
+	TemplateChannels  map[string]Channel `json:"templateChannels"`
+	Modes             []Mode             `json:"modes"`
+}
+
+// Matrix describes the pixel layout of a multi-pixel fixture.
+type Matrix struct {
+	PixelCount []int         `json:"pixelCount"`
+	PixelKeys  [][][]*string `json:"pixelKeys"`
+}
+
+// Channel is an OFL channel with either one capability or a list.
+type Channel struct {
+	Capability   *Capability  `json:"capability"`
+	Capabilities []Capability `json:"capabilities"`
+}
+
+// Capability is the part of an OFL capability that identifies color.
+type Capability struct {
+	Type  string `json:"type"`
+	Color string `json:"color"`
+}
+
+// Mode is a DMX personality. Channels holds either channel names or
+// matrix insert blocks, so it is decoded lazily.
+type Mode struct {
+	Name      string            `json:"name"`
+	ShortName string            `json:"shortName"`
+	Channels  []json.RawMessage `json:"channels"`
+}
+
+type matrixInsert struct {
+	Insert           string   `json:"insert"`
+	TemplateChannels []string `json:"templateChannels"`
+}
+
+// Parse decodes an OFL fixture JSON document.
+func Parse(data []byte) (*Fixture, error) {
+	var f Fixture
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("invalid OFL fixture: %w", err)
+	}
+	if f.Name == "" || len(f.Modes) == 0 {
+		return nil, fmt.Errorf("invalid OFL fixture: missing name or modes")
+	}
+	return &f, nil
+}
+
+// PixelCount returns the number of addressable pixels (1 for single-cell
+// fixtures).
+func (f *Fixture) PixelCount() int {
+	if f.Matrix == nil {
+		return 1
+	}
+	if len(f.Matrix.PixelCount) > 0 {
+		n := 1
+		for _, d := range f.Matrix.PixelCount {
+			if d > 0 {
+				n *= d
+			}
+		}
+		return n
+	}
+	n := 0
+	for _, plane := range f.Matrix.PixelKeys {
+		for _, row := range plane {
+			for _, key := range row {
+				if key != nil {
+					n++
+				}
+			}
+		}
+	}
+	if n == 0 {
+		return 1
+	}
+	return n
+}
+
+// Profiles builds one HardwareProfile per mode that exposes RGB channels.
+// Modes without color channels (e.g. dimmer-only) are skipped.
+func (f *Fixture) Profiles() ([]bingen.HardwareProfile, error) {
+	var profiles []bingen.HardwareProfile
+	for _, mode := range f.Modes {
+		colors := f.modeColors(mode)
+		order, ok := colorOrder(colors)
+		if !ok {
+			continue
+		}
+
+		ledType := bingen.LedTypeWS2812B
+		for _, c := range colors {
+			if c == "W" {
+				ledType = bingen.LedTypeSK6812RGBW
+			}
+		}
+
+		name := f.Name
+		if mode.Name != "" {
+			name = fmt.Sprintf("%s (%s)", f.Name, mode.Name)
+		}
+		profiles = append(profiles, bingen.HardwareProfile{
+			ID:            "ofl-" + slug(name),
+			Name:          name,
+			LedCount:      f.PixelCount(),
+			LedType:       ledType,
+			ColorOrder:    order,
+			BrightnessCap: 255,
+		})
+	}
+	if len(profiles) == 0 {
+		return nil, fmt.Errorf("fixture %q has no RGB modes", f.Name)
+	}
+	return profiles, nil
+}
+
+// modeColors lists the color letters (R, G, B, W) of the first pixel in the
+// order they appear on the wire.
+func (f *Fixture) modeColors(mode Mode) []string {
+	var colors []string
+	for _, raw := range mode.Channels {
+		var name string
+		if err := json.Unmarshal(raw, &name); err == nil {
+			if c := colorLetter(f.lookupChannel(name)); c != "" {
+				colors = append(colors, c)
+			}
+			continue
+		}
+
+		var ins matrixInsert
+		if err := json.Unmarshal(raw, &ins); err == nil && ins.Insert == "matrixChannels" {
+			// All pixels repeat the template order; one pixel is enough.
+			for _, tmpl := range ins.TemplateChannels {
+				if c := colorLetter(f.TemplateChannels[tmpl]); c != "" {
+					colors = append(colors, c)
+				}
+			}
+			if len(colors) > 0 {
+				return colors
+			}
+		}
+	}
+	return colors
+}
+
+// lookupChannel finds a mode channel by name. Resolved template channels
+// look like "Red 1" and match template "Red $pixelKey"; if several
+// templates match, the longest prefix wins, so "Red Fine 1" resolves to
+// "Red Fine $pixelKey" rather than "Red $pixelKey" whatever the map order.
+func (f *Fixture) lookupChannel(name string) Channel {
+	if ch, ok := f.AvailableChannels[name]; ok {
+		return ch
+	}
+	tmplNames := make([]string, 0, len(f.TemplateChannels))
+	for tmplName := range f.TemplateChannels {
+		tmplNames = append(tmplNames, tmplName)
+	}
+	sort.Strings(tmplNames)
+
+	best, bestLen := "", 0
+	for _, tmplName := range tmplNames {
+		prefix := strings.TrimSpace(strings.Replace(tmplName, "$pixelKey", "", 1))
+		if prefix != "" && strings.HasPrefix(name, prefix) && len(prefix) > bestLen {
+			best, bestLen = tmplName, len(prefix)
+		}
+	}
+	if best == "" {
+		return Channel{}
+	}
+	return f.TemplateChannels[best]
+}
+
+func colorLetter(ch Channel) string {
+	caps := ch.Capabilities
+	if ch.Capability != nil {
+		caps = []Capability{*ch.Capability}
+	}
+	for _, c := range caps {
+		if c.Type != "ColorIntensity" {
+			continue
+		}
+		switch c.Color {
+		case "Red":
+			return "R"
+		case "Green":
+			return "G"
+		case "Blue":
+			return "B"
+		case "White", "Warm White", "Cold White":
+			return "W"
+		}
+	}
+	return ""
+}
+
+// colorOrder maps the R/G/B sequence to the firmware enum, ignoring white.
+func colorOrder(colors []string) (int, bool) {
+	var rgb strings.Builder
+	for _, c := range colors {
+		if c != "W" && !strings.Contains(rgb.String(), c) {
+			rgb.WriteString(c)
+		}
+	}
+	for i, name := range bingen.ColorOrderNames {
+		if name == rgb.String() {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+var nonSlug = regexp.MustCompile(`[^a-z0-9]+`)
+
+func slug(s string) string {
+	return strings.Trim(nonSlug.ReplaceAllString(strings.ToLower(s), "-"), "-")
+}
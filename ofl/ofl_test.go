@@ -0,0 +1,129 @@
+package ofl
+
+import (
+	"testing"
+
+	"PicoLume/bingen"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		wantErr bool
+	}{
+		{"valid", `{"name": "Bar", "modes": [{"name": "3ch", "channels": ["Red"]}]}`, false},
+		{"not json", `{`, true},
+		{"no name", `{"modes": [{"name": "3ch"}]}`, true},
+		{"no modes", `{"name": "Bar"}`, true},
+	}
+	for _, tt := range tests {
+		if _, err := Parse([]byte(tt.data)); (err != nil) != tt.wantErr {
+			t.Errorf("%s: Parse() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestPixelCount(t *testing.T) {
+	a, b := "A", "B"
+	tests := []struct {
+		name   string
+		matrix *Matrix
+		want   int
+	}{
+		{"single cell", nil, 1},
+		{"pixel count", &Matrix{PixelCount: []int{8, 2, 1}}, 16},
+		{"pixel keys", &Matrix{PixelKeys: [][][]*string{{{&a, nil, &b}}}}, 2},
+		{"empty keys", &Matrix{PixelKeys: [][][]*string{{{nil}}}}, 1},
+	}
+	for _, tt := range tests {
+		f := Fixture{Matrix: tt.matrix}
+		if got := f.PixelCount(); got != tt.want {
+			t.Errorf("%s: PixelCount() = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+const pixelBar = `{
+	"name": "Pixel Bar",
+	"matrix": {"pixelCount": [4, 1, 1]},
+	"availableChannels": {
+		"Dimmer": {"capability": {"type": "Intensity"}},
+		"Red": {"capability": {"type": "ColorIntensity", "color": "Red"}},
+		"Green": {"capability": {"type": "ColorIntensity", "color": "Green"}},
+		"Blue": {"capability": {"type": "ColorIntensity", "color": "Blue"}},
+		"White": {"capabilities": [{"type": "Generic"}, {"type": "ColorIntensity", "color": "Warm White"}]}
+	},
+	"templateChannels": {
+		"Red $pixelKey": {"capability": {"type": "ColorIntensity", "color": "Red"}},
+		"Red Fine $pixelKey": {"capability": {"type": "Generic"}},
+		"Green $pixelKey": {"capability": {"type": "ColorIntensity", "color": "Green"}},
+		"Blue $pixelKey": {"capability": {"type": "ColorIntensity", "color": "Blue"}}
+	},
+	"modes": [
+		{"name": "Dimmer", "channels": ["Dimmer"]},
+		{"name": "RGB", "channels": ["Dimmer", "Red", "Green", "Blue"]},
+		{"name": "GRBW", "channels": ["Green", "Red", "Blue", "White"]},
+		{"name": "Pixels", "channels": [{"insert": "matrixChannels", "repeatFor": "eachPixel", "templateChannels": ["Green $pixelKey", "Blue $pixelKey", "Red $pixelKey"]}]},
+		{"name": "Resolved", "channels": ["Red Fine 1", "Blue 1", "Red 1", "Green 1"]}
+	]
+}`
+
+func TestProfiles(t *testing.T) {
+	f, err := Parse([]byte(pixelBar))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := f.Profiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	order := func(name string) int {
+		for i, n := range bingen.ColorOrderNames {
+			if n == name {
+				return i
+			}
+		}
+		t.Fatalf("no color order %s", name)
+		return 0
+	}
+	want := []bingen.HardwareProfile{
+		{ID: "ofl-pixel-bar-rgb", Name: "Pixel Bar (RGB)", LedCount: 4, LedType: bingen.LedTypeWS2812B, ColorOrder: order("RGB"), BrightnessCap: 255},
+		{ID: "ofl-pixel-bar-grbw", Name: "Pixel Bar (GRBW)", LedCount: 4, LedType: bingen.LedTypeSK6812RGBW, ColorOrder: order("GRB"), BrightnessCap: 255},
+		{ID: "ofl-pixel-bar-pixels", Name: "Pixel Bar (Pixels)", LedCount: 4, LedType: bingen.LedTypeWS2812B, ColorOrder: order("GBR"), BrightnessCap: 255},
+		{ID: "ofl-pixel-bar-resolved", Name: "Pixel Bar (Resolved)", LedCount: 4, LedType: bingen.LedTypeWS2812B, ColorOrder: order("BRG"), BrightnessCap: 255},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("Profiles() = %+v, want %d profiles", got, len(want))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("profile %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+
+	dimmer, _ := Parse([]byte(`{"name": "Par", "availableChannels": {"Dimmer": {}}, "modes": [{"channels": ["Dimmer"]}]}`))
+	if _, err := dimmer.Profiles(); err == nil {
+		t.Error("Profiles() of a fixture without RGB modes succeeded")
+	}
+}
+
+// TestLookupChannelStable checks that the longest matching template wins
+// on every run, not whichever the map yields first.
+func TestLookupChannelStable(t *testing.T) {
+	f, err := Parse([]byte(pixelBar))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 50; i++ {
+		if c := colorLetter(f.lookupChannel("Red Fine 1")); c != "" {
+			t.Fatalf("run %d: Red Fine 1 resolved to color %s, want the fine channel", i, c)
+		}
+		if c := colorLetter(f.lookupChannel("Red 1")); c != "R" {
+			t.Fatalf("run %d: Red 1 resolved to %q", i, c)
+		}
+	}
+	if ch := f.lookupChannel("Amber 1"); ch.Capability != nil || ch.Capabilities != nil {
+		t.Errorf("unknown channel resolved to %+v", ch)
+	}
+}
@@ -0,0 +1,312 @@
+// Package palette suggests stage palettes for a base color and flags color
+// pairs an audience cannot tell apart, including under the three common
+// colour vision deficiencies.
+//
+// Distance is CIE76 ΔE in CIELAB. Deficiencies are simulated on linear RGB
+// with the full-severity matrices of Machado, Oliveira and Fernandes (2009).
+package palette
+
+import (
+	"fmt"
+	"math"
+
+	"PicoLume/bingen"
+)
+
+// MinDeltaE is the smallest color distance counted as clearly different
+// across a stage; two LEDs closer than this read as the same color.
+const MinDeltaE = 20
+
+// Visions a pair is checked under.
+const (
+	Normal = "normal"
+	Protan = "protan" // red-blind
+	Deutan = "deutan" // green-blind, the most common
+	Tritan = "tritan" // blue-blind
+)
+
+// Visions lists every vision in check order.
+var Visions = []string{Normal, Protan, Deutan, Tritan}
+
+var cvdMatrices = map[string][3][3]float64{
+	Protan: {{0.152286, 1.052583, -0.204868}, {0.114503, 0.786281, 0.099216}, {-0.003882, -0.048116, 1.051998}},
+	Deutan: {{0.367322, 0.860646, -0.227968}, {0.280085, 0.672501, 0.047413}, {-0.011820, 0.042940, 0.968881}},
+	Tritan: {{1.255528, -0.076749, -0.178779}, {-0.078411, 0.930809, 0.147602}, {0.004733, 0.691367, 0.303900}},
+}
+
+// Palette is a suggested set of colors, base first.
+type Palette struct {
+	Name   string   `json:"name"`
+	Colors []string `json:"colors"` // "#RRGGBB"
+	// CVDSafe means every pair stays at least MinDeltaE apart under every
+	// simulated vision.
+	CVDSafe bool `json:"cvdSafe"`
+}
+
+// Suggest returns harmony palettes for base (complementary, split
+// complementary, triadic, analogous) and a colour-vision-safe set. Hues
+// are rotated at the base's saturation and brightness so the set keeps
+// its feel; LEDs render saturated colors best, so a washed-out base is
+// lifted to at least 70% saturation.
+func Suggest(base string) ([]Palette, error) {
+	c, err := bingen.ParseColorValue(base)
+	if err != nil {
+		return nil, err
+	}
+	h, s, v := toHSV(c)
+	if s < 0.05 {
+		h = 0 // grey: start the wheel at red
+	}
+	s = math.Max(s, 0.7)
+	v = math.Max(v, 0.5)
+	at := func(offsets ...float64) []uint32 {
+		out := []uint32{c}
+		for _, o := range offsets {
+			out = append(out, fromHSV(h+o, s, v))
+		}
+		return out
+	}
+
+	out := []Palette{
+		palette("Complementary", at(180)),
+		palette("Split Complementary", at(150, 210)),
+		palette("Triadic", at(120, 240)),
+		palette("Analogous", at(-30, 30)),
+		palette("Colour-Vision Safe", safeSet(c, s, v, 4)),
+	}
+	return out, nil
+}
+
+func palette(name string, colors []uint32) Palette {
+	p := Palette{Name: name, CVDSafe: true}
+	for i, a := range colors {
+		p.Colors = append(p.Colors, Hex(a))
+		for _, b := range colors[i+1:] {
+			if d, _ := MinDistance(a, b); d < MinDeltaE {
+				p.CVDSafe = false
+			}
+		}
+	}
+	return p
+}
+
+// safeSet greedily picks n colors, starting from base, from 24 hues and
+// white, each time taking the candidate whose worst-case distance to the
+// colors chosen so far is largest.
+func safeSet(base uint32, s, v float64, n int) []uint32 {
+	candidates := []uint32{0xFFFFFF}
+	for hue := 0.0; hue < 360; hue += 15 {
+		candidates = append(candidates, fromHSV(hue, s, v))
+	}
+	set := []uint32{base}
+	for len(set) < n {
+		best, bestD := uint32(0), -1.0
+		for _, c := range candidates {
+			worst := math.Inf(1)
+			for _, chosen := range set {
+				d, _ := MinDistance(c, chosen)
+				worst = math.Min(worst, d)
+			}
+			if worst > bestD {
+				best, bestD = c, worst
+			}
+		}
+		set = append(set, best)
+	}
+	return set
+}
+
+// Issue is a pair of colors too close to tell apart.
+type Issue struct {
+	Where  string  `json:"where"` // e.g. `palette "Venue"`, "track 2 clip 5"
+	A      string  `json:"a"`
+	B      string  `json:"b"`
+	DeltaE float64 `json:"deltaE"`
+	Vision string  `json:"vision"` // the vision the pair is closest under
+}
+
+func (i Issue) String() string {
+	return fmt.Sprintf("%s: %s and %s differ by ΔE %.1f (%s vision)", i.Where, i.A, i.B, i.DeltaE, i.Vision)
+}
+
+// CheckPair returns an Issue if a and b are less than MinDeltaE apart
+// under any vision. Unparseable colors are not checked.
+func CheckPair(where, a, b string) (Issue, bool) {
+	ca, errA := bingen.ParseColorValue(a)
+	cb, errB := bingen.ParseColorValue(b)
+	if errA != nil || errB != nil {
+		return Issue{}, false
+	}
+	d, vision := MinDistance(ca, cb)
+	if d >= MinDeltaE {
+		return Issue{}, false
+	}
+	return Issue{Where: where, A: a, B: b, DeltaE: math.Round(d*10) / 10, Vision: vision}, true
+}
+
+// CheckColors checks every pair in colors.
+func CheckColors(where string, colors []string) []Issue {
+	var issues []Issue
+	for i, a := range colors {
+		for _, b := range colors[i+1:] {
+			if issue, found := CheckPair(where, a, b); found {
+				issues = append(issues, issue)
+			}
+		}
+	}
+	return issues
+}
+
+// MinDistance returns the smallest ΔE between a and b over every vision,
+// and that vision.
+func MinDistance(a, b uint32) (float64, string) {
+	best, vision := math.Inf(1), Normal
+	for _, v := range Visions {
+		if d := deltaE(toLab(simulate(a, v)), toLab(simulate(b, v))); d < best {
+			best, vision = d, v
+		}
+	}
+	return best, vision
+}
+
+// Hex formats 0xRRGGBB as "#RRGGBB".
+func Hex(c uint32) string {
+	return fmt.Sprintf("#%06X", c&0xFFFFFF)
+}
+
+// simulate returns c as seen with vision, in linear RGB.
+func simulate(c uint32, vision string) [3]float64 {
+	lin := [3]float64{linear(c >> 16 & 0xFF), linear(c >> 8 & 0xFF), linear(c & 0xFF)}
+	m, ok := cvdMatrices[vision]
+	if !ok {
+		return lin
+	}
+	var out [3]float64
+	for i := range out {
+		out[i] = math.Max(0, math.Min(1, m[i][0]*lin[0]+m[i][1]*lin[1]+m[i][2]*lin[2]))
+	}
+	return out
+}
+
+func linear(ch uint32) float64 {
+	v := float64(ch) / 255
+	if v <= 0.04045 {
+		return v / 12.92
+	}
+	return math.Pow((v+0.055)/1.055, 2.4)
+}
+
+// toLab converts linear sRGB to CIELAB (D65).
+func toLab(rgb [3]float64) [3]float64 {
+	r, g, b := rgb[0], rgb[1], rgb[2]
+	x := (0.4124*r + 0.3576*g + 0.1805*b) / 0.95047
+	y := 0.2126*r + 0.7152*g + 0.0722*b
+	z := (0.0193*r + 0.1192*g + 0.9505*b) / 1.08883
+	f := func(t float64) float64 {
+		if t > 216.0/24389 {
+			return math.Cbrt(t)
+		}
+		return (24389.0/27*t + 16) / 116
+	}
+	fx, fy, fz := f(x), f(y), f(z)
+	return [3]float64{116*fy - 16, 500 * (fx - fy), 200 * (fy - fz)}
+}
+
+func deltaE(a, b [3]float64) float64 {
+	return math.Sqrt((a[0]-b[0])*(a[0]-b[0]) + (a[1]-b[1])*(a[1]-b[1]) + (a[2]-b[2])*(a[2]-b[2]))
+}
+
+func toHSV(c uint32) (h, s, v float64) {
+	r, g, b := float64(c>>16&0xFF)/255, float64(c>>8&0xFF)/255, float64(c&0xFF)/255
+	hi, lo := math.Max(r, math.Max(g, b)), math.Min(r, math.Min(g, b))
+	d := hi - lo
+	switch {
+	case d == 0:
+		h = 0
+	case hi == r:
+		h = 60 * math.Mod((g-b)/d+6, 6)
+	case hi == g:
+		h = 60 * ((b-r)/d + 2)
+	default:
+		h = 60 * ((r-g)/d + 4)
+	}
+	if hi > 0 {
+		s = d / hi
+	}
+	return h, s, hi
+}
+
+func fromHSV(h, s, v float64) uint32 {
+	h = math.Mod(math.Mod(h, 360)+360, 360)
+	c := v * s
+	x := c * (1 - math.Abs(math.Mod(h/60, 2)-1))
+	var r, g, b float64
+	switch {
+	case h < 60:
+		r, g = c, x
+	case h < 120:
+		r, g = x, c
+	case h < 180:
+		g, b = c, x
+	case h < 240:
+		g, b = x, c
+	case h < 300:
+		r, b = x, c
+	default:
+		r, b = c, x
+	}
+	m := v - c
+	ch := func(f float64) uint32 { return uint32(math.Round((f + m) * 255)) }
+	return ch(r)<<16 | ch(g)<<8 | ch(b)
+}
+
+// Named is a palette as stored in project settings.
+type Named struct {
+	Name    string   `json:"name"`
+	Builtin bool     `json:"builtin"`
+	Colors  []string `json:"colors"`
+}
+
+// CheckShow returns the low-contrast pairs in the custom palettes and in
+// the clips of p that show two colors at once (glitch, energy, alternate).
+// Built-in palettes are skipped: they are ranges to pick from, not sets
+// meant to be seen together.
+func CheckShow(p *bingen.Project, palettes []Named) []Issue {
+	var issues []Issue
+	for _, pal := range palettes {
+		if !pal.Builtin {
+			issues = append(issues, CheckColors(fmt.Sprintf("palette %q", pal.Name), pal.Colors)...)
+		}
+	}
+
+	twoColor := make(map[string]bool)
+	for _, e := range bingen.EffectSchemas() {
+		n := 0
+		for _, param := range e.Params {
+			if param.Kind == bingen.ParamColor {
+				n++
+			}
+		}
+		twoColor[e.Name] = n >= 2
+	}
+	for ti, track := range p.Tracks {
+		if track.Type != "led" {
+			continue
+		}
+		for ci, clip := range track.Clips {
+			if !twoColor[clip.Type] {
+				continue
+			}
+			a, b := clip.ColorHex()
+			if issue, found := CheckPair(fmt.Sprintf("track %d clip %d", ti+1, ci+1), a, b); found {
+				issues = append(issues, issue)
+			}
+		}
+	}
+	return issues
+}
+
+// Distance is the ΔE between a and b under normal vision.
+func Distance(a, b uint32) float64 {
+	return deltaE(toLab(simulate(a, Normal)), toLab(simulate(b, Normal)))
+}
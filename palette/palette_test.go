@@ -0,0 +1,60 @@
+package palette
+
+import (
+	"testing"
+
+	"PicoLume/bingen"
+)
+
+func TestSuggest(t *testing.T) {
+	pals, err := Suggest("#FF0000")
+	if err != nil {
+		t.Fatalf("Suggest() error = %v", err)
+	}
+	byName := make(map[string]Palette)
+	for _, p := range pals {
+		byName[p.Name] = p
+		if p.Colors[0] != "#FF0000" {
+			t.Errorf("%s starts with %s, want the base color", p.Name, p.Colors[0])
+		}
+	}
+	if got := byName["Complementary"].Colors; len(got) != 2 || got[1] != "#00FFFF" {
+		t.Errorf("complementary = %v, want red and cyan", got)
+	}
+	if safe := byName["Colour-Vision Safe"]; !safe.CVDSafe || len(safe.Colors) != 4 {
+		t.Errorf("safe set = %+v, want 4 colors marked safe", safe)
+	}
+	if _, err := Suggest("not a color"); err == nil {
+		t.Error("Suggest(bad) should fail")
+	}
+}
+
+func TestMinDistance(t *testing.T) {
+	// Red and green look alike to a deuteranope but not to normal vision.
+	red, green := uint32(0xFF0000), uint32(0x00B000)
+	if d := deltaE(toLab(simulate(red, Normal)), toLab(simulate(green, Normal))); d < MinDeltaE {
+		t.Errorf("normal ΔE = %.1f, want clearly different", d)
+	}
+	if d, vision := MinDistance(red, green); d >= MinDeltaE || (vision != Deutan && vision != Protan) {
+		t.Errorf("MinDistance(red, green) = %.1f %s, want a red-green deficiency below %d", d, vision, MinDeltaE)
+	}
+	if d, _ := MinDistance(0x0000FF, 0xFFFF00); d < MinDeltaE {
+		t.Errorf("blue and yellow ΔE = %.1f, want safe under every vision", d)
+	}
+}
+
+func TestCheckShow(t *testing.T) {
+	p := &bingen.Project{Tracks: []bingen.Track{{Type: "led", Clips: []bingen.Clip{
+		{Type: "alternate", Props: bingen.ClipProps{ColorA: "#FF0000", ColorB: "#00B000"}},
+		{Type: "solid", Props: bingen.ClipProps{Color: "#FF0000", Color2: "#FF0000"}},
+		{Type: "energy", Props: bingen.ClipProps{Color: "#0000FF", Color2: "#FFFF00"}},
+	}}}}
+	palettes := []Named{
+		{Name: "Warm", Builtin: true, Colors: []string{"#FF4500", "#FF8C00"}},
+		{Name: "Venue", Colors: []string{"#FFFFFF", "#FAFAFA", "#0000FF"}},
+	}
+	issues := CheckShow(p, palettes)
+	if len(issues) != 2 || issues[0].Where != `palette "Venue"` || issues[1].Where != "track 1 clip 1" {
+		t.Errorf("issues = %v, want the Venue whites and the red/green alternate", issues)
+	}
+}
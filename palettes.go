@@ -0,0 +1,59 @@
+package main
+
+import (
+	"encoding/json"
+
+	"PicoLume/palette"
+)
+
+// ==========================================================
+// PALETTE SUGGESTIONS & CONTRAST CHECKS
+// ==========================================================
+
+// PaletteSuggestionResponse is returned by SuggestPalettes.
+type PaletteSuggestionResponse struct {
+	Palettes []palette.Palette `json:"palettes"`
+	Error    string            `json:"error"`
+}
+
+// SuggestPalettes returns harmony palettes and a colour-vision-safe set
+// built around base, for the color picker to offer.
+func (a *App) SuggestPalettes(base string) PaletteSuggestionResponse {
+	defer a.recoverBinding("SuggestPalettes")
+
+	pals, err := palette.Suggest(base)
+	if err != nil {
+		return PaletteSuggestionResponse{Error: err.Error()}
+	}
+	return PaletteSuggestionResponse{Palettes: pals}
+}
+
+// PaletteReportResponse is returned by CheckPaletteContrast.
+type PaletteReportResponse struct {
+	Issues []palette.Issue `json:"issues"`
+	Error  string          `json:"error"`
+}
+
+// CheckPaletteContrast flags color pairs in the project's custom palettes
+// and two-color clips that the audience, or part of it, cannot tell apart.
+func (a *App) CheckPaletteContrast(projectJson string) PaletteReportResponse {
+	defer a.recoverBinding("CheckPaletteContrast")
+
+	p, err := parseProject(projectJson)
+	if err != nil {
+		return PaletteReportResponse{Error: "Invalid project - " + err.Error()}
+	}
+	var extra struct {
+		Settings struct {
+			Palettes []palette.Named `json:"palettes"`
+		} `json:"settings"`
+	}
+	if err := json.Unmarshal([]byte(projectJson), &extra); err != nil {
+		return PaletteReportResponse{Error: "Invalid project - " + err.Error()}
+	}
+	issues := palette.CheckShow(p, extra.Settings.Palettes)
+	if issues == nil {
+		issues = []palette.Issue{}
+	}
+	return PaletteReportResponse{Issues: issues}
+}
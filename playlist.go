@@ -0,0 +1,119 @@
+package main
+
+import (
+	"archive/zip"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"PicoLume/bingen"
+	"PicoLume/i18n"
+	"PicoLume/settings"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// ==========================================================
+// PLAYLISTS
+// ==========================================================
+
+// ExportPlaylist compiles several .lum projects, in order, into one
+// playlist.bin, so a whole set can go onto the props at once and be
+// switched from the remote. With no paths it asks for the projects. Each
+// show is named after its file.
+func (a *App) ExportPlaylist(projectPaths []string) string {
+	defer a.recoverBinding("ExportPlaylist")
+
+	if len(projectPaths) == 0 {
+		paths, err := runtime.OpenMultipleFilesDialog(a.ctx, runtime.OpenDialogOptions{
+			DefaultDirectory: a.currentSettings().ProjectDir,
+			Title:            i18n.T("Choose the projects for the playlist"),
+			Filters: []runtime.FileFilter{
+				{DisplayName: "PicoLume Project (*.lum)", Pattern: "*.lum"},
+			},
+		})
+		if err != nil || len(paths) == 0 {
+			return "Cancelled"
+		}
+		projectPaths = paths
+	}
+
+	data, entries, err := a.buildPlaylist(projectPaths)
+	if errors.Is(err, context.Canceled) {
+		return "Cancelled"
+	}
+	if err != nil {
+		return "Error: " + err.Error()
+	}
+
+	filename, err := runtime.SaveFileDialog(a.ctx, runtime.SaveDialogOptions{
+		DefaultDirectory: a.currentSettings().ExportDir,
+		DefaultFilename:  "playlist.bin",
+		Title:            i18n.T("Export Playlist"),
+		Filters: []runtime.FileFilter{
+			{DisplayName: "Binary Files (*.bin)", Pattern: "*.bin"},
+		},
+	})
+	if err != nil || filename == "" {
+		return i18n.T("Export cancelled")
+	}
+	if err := os.WriteFile(filename, data, 0644); err != nil {
+		return i18n.T("Error saving file: %s", err.Error())
+	}
+	return "Success! " + i18n.T("Exported %d shows to %s", len(entries), filename)
+}
+
+// buildPlaylist generates show.bin for each project with the current
+// settings and packs them into a playlist.
+func (a *App) buildPlaylist(projectPaths []string) ([]byte, []bingen.PlaylistEntry, error) {
+	limits := a.currentSettings().Limits
+	shows := make([]bingen.PlaylistShow, 0, len(projectPaths))
+	for _, path := range projectPaths {
+		name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+		projectJson, err := readProjectJSON(path, limits)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s: %w", name, err)
+		}
+		result, err := a.generateShow(genPlaylist, projectJson, a.showOptions(0))
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s: %w", name, err)
+		}
+		shows = append(shows, bingen.PlaylistShow{Name: name, Data: result.Bytes})
+	}
+	return bingen.BuildPlaylist(shows)
+}
+
+// readProjectJSON returns the project.json of a .lum file, within the same
+// limits LoadProjectFromPath applies, without extracting its audio.
+func readProjectJSON(filename string, limits settings.Limits) (string, error) {
+	if !strings.EqualFold(filepath.Ext(filename), ".lum") {
+		return "", errors.New(i18n.T("Not a PicoLume project (.lum): %s", filename))
+	}
+	fileInfo, err := os.Stat(filename)
+	if err != nil {
+		return "", err
+	}
+	if fileInfo.Size() > int64(limits.ProjectFileMB)*megabyte {
+		return "", errors.New(i18n.T("Project file too large (max %dMB)", limits.ProjectFileMB))
+	}
+
+	r, err := zip.OpenReader(filename)
+	if err != nil {
+		return "", err
+	}
+	defer r.Close()
+	for _, f := range r.File {
+		if f.Name != "project.json" {
+			continue
+		}
+		content, err := readZipEntry(f, MaxProjectJsonSize)
+		if errors.Is(err, errEntryTooLarge) {
+			return "", errors.New(i18n.T("project.json too large (max %dMB)", MaxProjectJsonSize/(1024*1024)))
+		}
+		return string(content), err
+	}
+	return "", errors.New("no project.json in the project file")
+}
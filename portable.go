@@ -0,0 +1,64 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// ==========================================================
+// PORTABLE MODE
+// ==========================================================
+
+// portableMarker, placed next to the executable, turns on portable mode
+// without a command-line flag (handy for file associations on venue PCs).
+const portableMarker = "portable.txt"
+
+// portableDataDirName is created next to the executable in portable mode.
+const portableDataDirName = "PicoLumeData"
+
+// portableDataDir is non-empty in portable mode; appDataDir returns it.
+var portableDataDir string
+
+// initPortableMode enables portable mode for --portable or a marker file
+// next to the executable. It must run before anything calls appDataDir.
+func initPortableMode(args []string) {
+	exe, err := os.Executable()
+	if err != nil {
+		return
+	}
+	if resolved, err := filepath.EvalSymlinks(exe); err == nil {
+		exe = resolved
+	}
+	exeDir := filepath.Dir(exe)
+
+	enabled := false
+	for _, arg := range args {
+		if arg == "--portable" {
+			enabled = true
+		}
+	}
+	if _, err := os.Stat(filepath.Join(exeDir, portableMarker)); err == nil {
+		enabled = true
+	}
+	if enabled {
+		portableDataDir = filepath.Join(exeDir, portableDataDirName)
+	}
+}
+
+// portableWebviewDir keeps WebView2 storage (including the frontend's
+// localStorage) with the portable data, or returns "" for the default.
+func portableWebviewDir() string {
+	if portableDataDir == "" {
+		return ""
+	}
+	return filepath.Join(portableDataDir, "webview")
+}
+
+// childProcessArgs returns flags a spawned Studio process (e.g. the preview
+// window) needs to share this instance's data directory.
+func childProcessArgs() []string {
+	if portableDataDir == "" {
+		return nil
+	}
+	return []string{"--portable"}
+}
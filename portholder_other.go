@@ -0,0 +1,42 @@
+//go:build !windows
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// portHolder names the process holding a serial device open, e.g.
+// "minicom (PID 1234)", by scanning /proc/*/fd. It returns "" where /proc
+// is unavailable (macOS) or the holder belongs to another user.
+func portHolder(port string) string {
+	target, err := filepath.EvalSymlinks(port)
+	if err != nil {
+		return ""
+	}
+	procs, err := os.ReadDir("/proc")
+	if err != nil {
+		return ""
+	}
+	self := os.Getpid()
+	for _, p := range procs {
+		pid, err := strconv.Atoi(p.Name())
+		if err != nil || pid == self {
+			continue
+		}
+		fds, err := os.ReadDir(filepath.Join("/proc", p.Name(), "fd"))
+		if err != nil {
+			continue
+		}
+		for _, fd := range fds {
+			if link, err := os.Readlink(filepath.Join("/proc", p.Name(), "fd", fd.Name())); err == nil && link == target {
+				comm, _ := os.ReadFile(filepath.Join("/proc", p.Name(), "comm"))
+				return formatPortHolder(strings.TrimSpace(string(comm)), pid)
+			}
+		}
+	}
+	return ""
+}
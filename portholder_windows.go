@@ -0,0 +1,176 @@
+//go:build windows
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+var (
+	ntdll             = windows.NewLazySystemDLL("ntdll.dll")
+	procNtQueryObject = ntdll.NewProc("NtQueryObject")
+)
+
+const (
+	objectNameInformation = 1
+	portHolderBudget      = 2 * time.Second
+	maxHandleTableSize    = 256 * 1024 * 1024
+)
+
+// systemHandleEntry is SYSTEM_HANDLE_TABLE_ENTRY_INFO_EX.
+type systemHandleEntry struct {
+	Object                uintptr
+	UniqueProcessID       uintptr
+	HandleValue           uintptr
+	GrantedAccess         uint32
+	CreatorBackTraceIndex uint16
+	ObjectTypeIndex       uint16
+	HandleAttributes      uint32
+	Reserved              uint32
+}
+
+// portHolder names the process holding a COM port open, e.g.
+// "arduino-ide.exe (PID 1234)", or returns "" if it cannot be found. It
+// walks the system handle table for file handles, duplicating each
+// character-device handle to compare its kernel object name with the
+// port's device (\Device\USBSER000 for COM5, say). Processes this user may
+// not open are skipped.
+func portHolder(port string) string {
+	target, err := dosDeviceTarget(port)
+	if err != nil {
+		serialLog.Debug("portHolder: QueryDosDevice(%s) failed: %v", port, err)
+		return ""
+	}
+	handles, err := systemHandles()
+	if err != nil {
+		serialLog.Debug("portHolder: Handle enumeration failed: %v", err)
+		return ""
+	}
+	fileType, ok := fileObjectType(handles)
+	if !ok {
+		return ""
+	}
+
+	self := windows.CurrentProcess()
+	selfPID := uintptr(os.Getpid())
+	procs := make(map[uintptr]windows.Handle) // 0 if it cannot be opened
+	defer func() {
+		for _, h := range procs {
+			if h != 0 {
+				windows.CloseHandle(h)
+			}
+		}
+	}()
+
+	deadline := time.Now().Add(portHolderBudget)
+	for _, e := range handles {
+		if e.ObjectTypeIndex != fileType || e.UniqueProcessID == selfPID || e.UniqueProcessID <= 4 {
+			continue
+		}
+		if time.Now().After(deadline) {
+			serialLog.Debug("portHolder: Gave up after %s", portHolderBudget)
+			return ""
+		}
+		proc, seen := procs[e.UniqueProcessID]
+		if !seen {
+			proc, _ = windows.OpenProcess(windows.PROCESS_DUP_HANDLE|windows.PROCESS_QUERY_LIMITED_INFORMATION, false, uint32(e.UniqueProcessID))
+			procs[e.UniqueProcessID] = proc
+		}
+		if proc == 0 {
+			continue
+		}
+		var dup windows.Handle
+		if err := windows.DuplicateHandle(proc, windows.Handle(e.HandleValue), self, &dup, 0, false, windows.DUPLICATE_SAME_ACCESS); err != nil {
+			continue
+		}
+		// Only character devices: querying the name of a pipe handle can
+		// block indefinitely.
+		var name string
+		if t, _ := windows.GetFileType(dup); t == windows.FILE_TYPE_CHAR {
+			name = objectName(dup)
+		}
+		windows.CloseHandle(dup)
+		if strings.EqualFold(name, target) {
+			return processLabel(proc, uint32(e.UniqueProcessID))
+		}
+	}
+	return ""
+}
+
+// dosDeviceTarget resolves "COM5" to its NT device path.
+func dosDeviceTarget(port string) (string, error) {
+	name, err := windows.UTF16PtrFromString(port)
+	if err != nil {
+		return "", err
+	}
+	buf := make([]uint16, 1024)
+	if _, err := windows.QueryDosDevice(name, &buf[0], uint32(len(buf))); err != nil {
+		return "", err
+	}
+	return windows.UTF16ToString(buf), nil
+}
+
+// systemHandles returns every open handle in the system.
+func systemHandles() ([]systemHandleEntry, error) {
+	size := uint32(1024 * 1024)
+	for {
+		buf := make([]byte, size)
+		var needed uint32
+		err := windows.NtQuerySystemInformation(windows.SystemExtendedHandleInformation, unsafe.Pointer(&buf[0]), size, &needed)
+		if err == windows.STATUS_INFO_LENGTH_MISMATCH && size < maxHandleTableSize {
+			// The table grows between calls; leave headroom.
+			size = max(size*2, needed+needed/4)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		// SYSTEM_HANDLE_INFORMATION_EX: count, reserved, entries.
+		count := *(*uintptr)(unsafe.Pointer(&buf[0]))
+		first := unsafe.Pointer(&buf[2*unsafe.Sizeof(uintptr(0))])
+		return append([]systemHandleEntry(nil), unsafe.Slice((*systemHandleEntry)(first), count)...), nil
+	}
+}
+
+// fileObjectType finds the object type index of file handles (it differs
+// between Windows versions) from a handle this process opened.
+func fileObjectType(handles []systemHandleEntry) (uint16, bool) {
+	f, err := os.Open(os.DevNull)
+	if err != nil {
+		return 0, false
+	}
+	defer f.Close()
+	selfPID, h := uintptr(os.Getpid()), f.Fd()
+	for _, e := range handles {
+		if e.UniqueProcessID == selfPID && e.HandleValue == h {
+			return e.ObjectTypeIndex, true
+		}
+	}
+	return 0, false
+}
+
+// objectName returns the kernel object name of h.
+func objectName(h windows.Handle) string {
+	buf := make([]byte, 2048)
+	var needed uint32
+	r, _, _ := procNtQueryObject.Call(uintptr(h), objectNameInformation, uintptr(unsafe.Pointer(&buf[0])), uintptr(len(buf)), uintptr(unsafe.Pointer(&needed)))
+	if r != 0 {
+		return ""
+	}
+	return (*windows.NTUnicodeString)(unsafe.Pointer(&buf[0])).String()
+}
+
+func processLabel(proc windows.Handle, pid uint32) string {
+	buf := make([]uint16, windows.MAX_LONG_PATH)
+	size := uint32(len(buf))
+	if err := windows.QueryFullProcessImageName(proc, 0, &buf[0], &size); err != nil {
+		return formatPortHolder("", int(pid))
+	}
+	return formatPortHolder(filepath.Base(windows.UTF16ToString(buf[:size])), int(pid))
+}
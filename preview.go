@@ -0,0 +1,203 @@
+package main
+
+import (
+	"context"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"PicoLume/i18n"
+	"PicoLume/logger"
+	"PicoLume/showsync"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// ==========================================================
+// DETACHED PREVIEW WINDOW
+// ==========================================================
+//
+// Wails v2 supports a single window per process, so the detached preview is
+// a second Studio process started with --preview. It loads the project from
+// a file the editor keeps up to date and follows the editor's transport over
+// a loopback show-sync channel.
+
+// PreviewSyncPort is the loopback UDP port used between editor and preview.
+const PreviewSyncPort = showsync.DefaultPort + 1
+
+// previewPollInterval is how often the preview re-reads the project file.
+const previewPollInterval = 500 * time.Millisecond
+
+// previewOptions are parsed from the preview process's command line.
+type previewOptions struct {
+	Enabled     bool
+	ProjectPath string
+	Port        int
+}
+
+// parsePreviewArgs recognises --preview, --preview-project=PATH and
+// --preview-port=N.
+func parsePreviewArgs(args []string) previewOptions {
+	opts := previewOptions{Port: PreviewSyncPort}
+	for _, arg := range args {
+		switch {
+		case arg == "--preview":
+			opts.Enabled = true
+		case strings.HasPrefix(arg, "--preview-project="):
+			opts.ProjectPath = strings.TrimPrefix(arg, "--preview-project=")
+		case strings.HasPrefix(arg, "--preview-port="):
+			if p, err := strconv.Atoi(strings.TrimPrefix(arg, "--preview-port=")); err == nil {
+				opts.Port = p
+			}
+		}
+	}
+	return opts
+}
+
+func previewProjectPath() string {
+	return filepath.Join(appDataDir(), "preview", "project.json")
+}
+
+type PreviewInfo struct {
+	Preview     bool   `json:"preview"`     // this window is the detached preview
+	ProjectJson string `json:"projectJson"` // current project (preview only)
+	Error       string `json:"error"`
+}
+
+// GetPreviewMode tells the frontend whether to render the preview-only
+// layout, and supplies the project in that case.
+func (a *App) GetPreviewMode() PreviewInfo {
+	if !a.preview.Enabled {
+		return PreviewInfo{}
+	}
+	data, err := os.ReadFile(a.preview.ProjectPath)
+	if err != nil {
+		return PreviewInfo{Preview: true, Error: err.Error()}
+	}
+	return PreviewInfo{Preview: true, ProjectJson: string(data)}
+}
+
+// OpenPreviewWindow starts the detached preview process for projectJson. The
+// frontend keeps it current with UpdatePreviewProject and UpdateSyncState.
+func (a *App) OpenPreviewWindow(projectJson string) string {
+	defer a.recoverBinding("OpenPreviewWindow")
+
+	a.mu.Lock()
+	running := a.previewCmd != nil
+	a.mu.Unlock()
+	if running {
+		return a.UpdatePreviewProject(projectJson)
+	}
+
+	if res := a.UpdatePreviewProject(projectJson); res != "OK" {
+		return res
+	}
+
+	exe, err := os.Executable()
+	if err != nil {
+		return "Error: " + err.Error()
+	}
+	master, err := showsync.StartMasterTo(&net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: PreviewSyncPort})
+	if err != nil {
+		return "Error: " + err.Error()
+	}
+
+	args := append(childProcessArgs(),
+		"--preview",
+		"--preview-project="+previewProjectPath(),
+		"--preview-port="+strconv.Itoa(PreviewSyncPort))
+	cmd := exec.Command(exe, args...)
+	if err := cmd.Start(); err != nil {
+		master.Close()
+		return i18n.T("Error starting preview: %s", err.Error())
+	}
+
+	a.mu.Lock()
+	a.previewCmd = cmd
+	a.previewMaster = master
+	a.mu.Unlock()
+	logger.Info("OpenPreviewWindow: Started preview process %d", cmd.Process.Pid)
+
+	a.goSafe("preview process", func() {
+		cmd.Wait()
+		a.mu.Lock()
+		if a.previewCmd == cmd {
+			a.previewCmd = nil
+			a.previewMaster.Close()
+			a.previewMaster = nil
+		}
+		a.mu.Unlock()
+		logger.Info("Preview process exited")
+		if a.ctx != nil {
+			runtime.EventsEmit(a.ctx, "preview:closed")
+		}
+	})
+	return "OK"
+}
+
+// UpdatePreviewProject writes the project the preview window displays.
+func (a *App) UpdatePreviewProject(projectJson string) string {
+	path := previewProjectPath()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "Error: " + err.Error()
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(projectJson), 0644); err != nil {
+		return "Error: " + err.Error()
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return "Error: " + err.Error()
+	}
+	return "OK"
+}
+
+// ClosePreviewWindow stops the preview process.
+func (a *App) ClosePreviewWindow() string {
+	a.mu.Lock()
+	cmd := a.previewCmd
+	a.mu.Unlock()
+	if cmd == nil {
+		return "OK"
+	}
+	if err := cmd.Process.Kill(); err != nil {
+		return "Error: " + err.Error()
+	}
+	return "OK"
+}
+
+// startPreview runs in the preview process: it follows the editor's
+// transport and emits "preview:project" when the project file changes.
+func (a *App) startPreview(ctx context.Context) {
+	if res := a.StartSyncFollower(a.preview.Port); res != "OK" {
+		logger.Warn("Preview: %s", res)
+	}
+
+	a.goSafe("preview project watcher", func() {
+		var lastMod time.Time
+		ticker := time.NewTicker(previewPollInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			info, err := os.Stat(a.preview.ProjectPath)
+			if err != nil || !info.ModTime().After(lastMod) {
+				continue
+			}
+			first := lastMod.IsZero()
+			lastMod = info.ModTime()
+			if first {
+				continue // initial content comes from GetPreviewMode
+			}
+			if data, err := os.ReadFile(a.preview.ProjectPath); err == nil {
+				runtime.EventsEmit(ctx, "preview:project", string(data))
+			}
+		}
+	})
+}
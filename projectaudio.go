@@ -0,0 +1,310 @@
+package main
+
+import (
+	"archive/zip"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	goruntime "runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"PicoLume/i18n"
+	"PicoLume/logger"
+	"PicoLume/settings"
+)
+
+// ==========================================================
+// PROJECT AUDIO
+// ==========================================================
+
+// Audio from a loaded project is streamed into a per-session temp directory
+// and served to the frontend by an asset server middleware, instead of being
+// read into memory and sent as base64 data URLs. Plain HTTP lets the webview
+// use range requests, so <audio> elements can seek without loading the
+// whole file.
+
+// projectAudioRoute is the URL prefix for /project-audio/{id}.
+const projectAudioRoute = "/project-audio/"
+
+// maxExtractWorkers bounds concurrent zip entry extraction.
+const maxExtractWorkers = 4
+
+var errEntryTooLarge = errors.New("zip entry exceeds size limit")
+
+// readZipEntry reads a whole entry, failing with errEntryTooLarge past max.
+func readZipEntry(f *zip.File, max int64) ([]byte, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	// Security: Use LimitReader to enforce size limit during read
+	content, err := io.ReadAll(io.LimitReader(rc, max+1)) // +1 to detect overflow
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(content)) > max {
+		return nil, errEntryTooLarge
+	}
+	return content, nil
+}
+
+// extractAudio streams audio entries into a fresh session directory in
+// parallel, makes it the served set and returns buffer ID -> URL. Entries
+// that can't be read or have malformed names are skipped with a warning, as
+// before; size violations abort.
+func (a *App) extractAudio(entries []*zip.File, limits settings.Limits) (map[string]string, error) {
+	session, err := os.MkdirTemp("", "picolume-audio-")
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		mu        sync.Mutex
+		files     = make(map[string]string)
+		firstErr  error
+		extracted atomic.Int64
+		wg        sync.WaitGroup
+		jobs      = make(chan *zip.File)
+	)
+	workers := min(maxExtractWorkers, goruntime.NumCPU(), len(entries))
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer a.recoverGoroutine("audio extraction")
+			for f := range jobs {
+				id, file, err := extractAudioEntry(f, session, limits, &extracted)
+				mu.Lock()
+				switch {
+				case errors.Is(err, errEntryTooLarge):
+					if firstErr == nil {
+						firstErr = err
+					}
+				case err != nil:
+					logger.Warn("LoadProject: Skipping audio entry %s: %v", f.Name, err)
+				default:
+					files[id] = file
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	for _, f := range entries {
+		jobs <- f
+	}
+	close(jobs)
+	wg.Wait()
+
+	if firstErr != nil {
+		os.RemoveAll(session)
+		return nil, firstErr
+	}
+
+	a.mu.Lock()
+	previous := a.audioDir
+	a.audioDir, a.audioFiles = session, files
+	a.mu.Unlock()
+	if previous != "" {
+		os.RemoveAll(previous)
+	}
+
+	urls := make(map[string]string, len(files))
+	for id := range files {
+		urls[id] = projectAudioRoute + url.PathEscape(id)
+	}
+	return urls, nil
+}
+
+// projectAudioFile returns the extracted file a /project-audio/{id} URL
+// serves, so saving can copy it back into the project.
+func (a *App) projectAudioFile(src string) (string, bool) {
+	escaped, ok := strings.CutPrefix(src, projectAudioRoute)
+	if !ok {
+		return "", false
+	}
+	id, err := url.PathUnescape(escaped)
+	if err != nil {
+		return "", false
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	file, ok := a.audioFiles[id]
+	return file, ok
+}
+
+// parseDataURL splits a base64 data URL into its MIME type and payload.
+func parseDataURL(src string) (mime, data string, err error) {
+	header, data, ok := strings.Cut(src, ",")
+	if !ok || strings.Contains(data, ",") {
+		return "", "", fmt.Errorf("not a data URL or project audio URL")
+	}
+
+	// Parse MIME type safely
+	_, mime, ok = strings.Cut(header, ":")
+	if !ok || mime == "" {
+		return "", "", fmt.Errorf("invalid MIME format %q", header)
+	}
+	mime, _, _ = strings.Cut(mime, ";")
+	return mime, data, nil
+}
+
+// audioExt is the file extension for an audio MIME type, or "" if it is
+// not one Studio stores.
+func audioExt(mime string) string {
+	switch {
+	case strings.Contains(mime, "mpeg") || strings.Contains(mime, "mp3"):
+		return "mp3"
+	case strings.Contains(mime, "wav"):
+		return "wav"
+	case strings.Contains(mime, "ogg"):
+		return "ogg"
+	}
+	return ""
+}
+
+// ImportAudio stores audio imported into the open project, given as a
+// base64 data URL, with the audio extracted from the loaded project. Uploads
+// can then copy it and render it for audio receivers before the project is
+// saved and reopened.
+func (a *App) ImportAudio(id string, dataURL string) string {
+	defer a.recoverBinding("ImportAudio")
+
+	// Security: the ID becomes a file name
+	if id == "" || strings.ContainsAny(id, `/\.:`) {
+		return i18n.T("Error: Invalid audio ID %q", id)
+	}
+	mime, data, err := parseDataURL(dataURL)
+	if err != nil {
+		return "Error: " + err.Error()
+	}
+	ext := audioExt(mime)
+	if ext == "" {
+		ext = "mp3" // matches extractAudioEntry
+	}
+
+	a.mu.Lock()
+	dir := a.audioDir
+	if dir == "" {
+		dir, err = os.MkdirTemp("", "picolume-audio-")
+		if err == nil {
+			a.audioDir = dir
+		}
+	}
+	a.mu.Unlock()
+	if err != nil {
+		return "Error: " + err.Error()
+	}
+
+	file := filepath.Join(dir, id+"."+ext)
+	out, err := os.Create(file)
+	if err != nil {
+		return "Error: " + err.Error()
+	}
+	maxSize := int64(a.currentSettings().Limits.AudioFileMB) * megabyte
+	dec := base64.NewDecoder(base64.StdEncoding, strings.NewReader(data))
+	n, err := io.Copy(out, io.LimitReader(dec, maxSize+1))
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil && n > maxSize {
+		err = errEntryTooLarge
+	}
+	if err != nil {
+		os.Remove(file)
+		return "Error: " + err.Error()
+	}
+
+	a.mu.Lock()
+	if a.audioFiles == nil {
+		a.audioFiles = make(map[string]string)
+	}
+	a.audioFiles[id] = file
+	a.mu.Unlock()
+	logger.Debug("ImportAudio: Stored %s (%d bytes)", id, n)
+	return "OK"
+}
+
+// clearProjectAudio deletes the current session's extracted audio.
+func (a *App) clearProjectAudio() {
+	a.mu.Lock()
+	dir := a.audioDir
+	a.audioDir, a.audioFiles = "", nil
+	a.mu.Unlock()
+	if dir != "" {
+		os.RemoveAll(dir)
+	}
+}
+
+// extractAudioEntry copies one audio/<id>.<ext> entry into dir and returns
+// the written file.
+func extractAudioEntry(f *zip.File, dir string, limits settings.Limits, total *atomic.Int64) (id, file string, err error) {
+	fileName := path.Base(f.Name)
+	fileParts := strings.Split(fileName, ".")
+	if len(fileParts) < 2 || fileParts[0] == "" {
+		return "", "", fmt.Errorf("malformed audio filename")
+	}
+	id = fileParts[0]
+	ext := strings.ToLower(fileParts[len(fileParts)-1])
+	switch ext {
+	case "mp3", "wav", "ogg":
+	default:
+		ext = "mp3" // matches the old audio/mpeg fallback
+	}
+
+	rc, err := f.Open()
+	if err != nil {
+		return "", "", err
+	}
+	defer rc.Close()
+
+	file = filepath.Join(dir, id+"."+ext)
+	out, err := os.Create(file)
+	if err != nil {
+		return "", "", err
+	}
+	maxSize := int64(limits.AudioFileMB) * megabyte
+	n, err := io.Copy(out, io.LimitReader(rc, maxSize+1))
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return "", "", err
+	}
+	if n > maxSize || total.Add(n) > int64(limits.TotalExtractedMB)*megabyte {
+		return "", "", errEntryTooLarge
+	}
+	logger.Debug("LoadProject: Extracted audio file %s (%d bytes)", id, n)
+	return id, file, nil
+}
+
+// projectAudioMiddleware serves /project-audio/{id} from the current
+// session and passes every other request on to the embedded frontend.
+// http.ServeFile handles Range requests and sets the audio content type.
+func (a *App) projectAudioMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, ok := strings.CutPrefix(r.URL.Path, projectAudioRoute)
+		if !ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+		// Security: only IDs from the loaded project map to files
+		a.mu.Lock()
+		file := a.audioFiles[id]
+		a.mu.Unlock()
+		if file == "" {
+			http.NotFound(w, r)
+			return
+		}
+		http.ServeFile(w, r, file)
+	})
+}
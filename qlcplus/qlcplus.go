@@ -0,0 +1,329 @@
+// Package qlcplus exports PicoLume projects as QLC+ workspaces (.qxw).
+// The export gives venues a DMX console backup of the wearable show: every
+// prop is patched as a generic RGB fixture, every prop group becomes a
+// fixture group, and every LED track becomes a chaser of static scenes.
+package qlcplus
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"math"
+	"sort"
+
+	"PicoLume/bingen"
+)
+
+const (
+	// ChannelsPerProp is the DMX footprint of each exported prop (R, G, B).
+	ChannelsPerProp = 3
+
+	// PropsPerUniverse is how many props fit in one 512-channel universe.
+	PropsPerUniverse = 512 / ChannelsPerProp
+
+	qlcVersion = "4.12.7"
+)
+
+// Options controls how the workspace is laid out.
+type Options struct {
+	// Author is written into the workspace creator block.
+	Author string
+	// StartAddress is the 0-based DMX address of prop 1 in the first
+	// universe, at most MaxStartAddress. Props that no longer fit in the
+	// first universe continue at address 0 of the next.
+	StartAddress int
+}
+
+// MaxStartAddress is the last address a prop's channels fit after.
+const MaxStartAddress = 512 - ChannelsPerProp
+
+type workspace struct {
+	XMLName       xml.Name `xml:"Workspace"`
+	Xmlns         string   `xml:"xmlns,attr"`
+	CurrentWindow string   `xml:"CurrentWindow,attr"`
+	Creator       creator  `xml:"Creator"`
+	Engine        engine   `xml:"Engine"`
+}
+
+type creator struct {
+	Name    string `xml:"Name"`
+	Version string `xml:"Version"`
+	Author  string `xml:"Author"`
+}
+
+type engine struct {
+	Universes     []universe     `xml:"InputOutputMap>Universe"`
+	Fixtures      []fixture      `xml:"Fixture"`
+	FixtureGroups []fixtureGroup `xml:"FixtureGroup"`
+	Functions     []function     `xml:"Function"`
+}
+
+type universe struct {
+	Name string `xml:"Name,attr"`
+	ID   int    `xml:"ID,attr"`
+}
+
+type fixture struct {
+	Manufacturer string `xml:"Manufacturer"`
+	Model        string `xml:"Model"`
+	Mode         string `xml:"Mode"`
+	ID           int    `xml:"ID"`
+	Name         string `xml:"Name"`
+	Universe     int    `xml:"Universe"`
+	Address      int    `xml:"Address"`
+	Channels     int    `xml:"Channels"`
+}
+
+type fixtureGroup struct {
+	ID    int         `xml:"ID,attr"`
+	Name  string      `xml:"Name"`
+	Size  groupSize   `xml:"Size"`
+	Heads []groupHead `xml:"Head"`
+}
+
+type groupSize struct {
+	X int `xml:"X,attr"`
+	Y int `xml:"Y,attr"`
+}
+
+type groupHead struct {
+	X       int `xml:"X,attr"`
+	Y       int `xml:"Y,attr"`
+	Fixture int `xml:"Fixture,attr"`
+	Head    int `xml:",chardata"`
+}
+
+type function struct {
+	ID         int          `xml:"ID,attr"`
+	Type       string       `xml:"Type,attr"`
+	Name       string       `xml:"Name,attr"`
+	Path       string       `xml:"Path,attr,omitempty"`
+	Speed      speed        `xml:"Speed"`
+	Direction  string       `xml:"Direction,omitempty"`
+	RunOrder   string       `xml:"RunOrder,omitempty"`
+	SpeedModes *speedModes  `xml:"SpeedModes,omitempty"`
+	FixtureVal []fixtureVal `xml:"FixtureVal,omitempty"`
+	Steps      []step       `xml:"Step,omitempty"`
+}
+
+type speed struct {
+	FadeIn   int `xml:"FadeIn,attr"`
+	FadeOut  int `xml:"FadeOut,attr"`
+	Duration int `xml:"Duration,attr"`
+}
+
+type speedModes struct {
+	FadeIn   string `xml:"FadeIn,attr"`
+	FadeOut  string `xml:"FadeOut,attr"`
+	Duration string `xml:"Duration,attr"`
+}
+
+type fixtureVal struct {
+	ID     int    `xml:"ID,attr"`
+	Values string `xml:",chardata"`
+}
+
+type step struct {
+	Number   int `xml:"Number,attr"`
+	FadeIn   int `xml:"FadeIn,attr"`
+	Hold     int `xml:"Hold,attr"`
+	FadeOut  int `xml:"FadeOut,attr"`
+	Function int `xml:",chardata"`
+}
+
+// Export renders the project as a QLC+ workspace document.
+//
+// Only props that belong to at least one prop group are patched. Effects are
+// approximated by their primary color: QLC+ has no equivalent of the
+// firmware's effect engine, so the backup reproduces looks, not animation.
+func Export(p *bingen.Project, opts Options) ([]byte, error) {
+	if p == nil {
+		return nil, fmt.Errorf("project is nil")
+	}
+	if opts.StartAddress < 0 || opts.StartAddress > MaxStartAddress {
+		return nil, fmt.Errorf("start address %d out of range 0-%d", opts.StartAddress, MaxStartAddress)
+	}
+
+	ws := workspace{
+		Xmlns:         "http://www.qlcplus.org/Workspace",
+		CurrentWindow: "FunctionManager",
+		Creator: creator{
+			Name:    "Q Light Controller Plus",
+			Version: qlcVersion,
+			Author:  opts.Author,
+		},
+	}
+
+	// --- 1. PATCH FIXTURES ---
+	propSet := make(map[int]bool)
+	for _, g := range p.PropGroups {
+		for _, id := range bingen.ParseIDRange(g.IDs) {
+			propSet[id] = true
+		}
+	}
+	props := make([]int, 0, len(propSet))
+	for id := range propSet {
+		props = append(props, id)
+	}
+	sort.Ints(props)
+
+	fixtureByProp := make(map[int]int, len(props))
+	maxUniverse := 0
+	firstUniverseProps := (512 - opts.StartAddress) / ChannelsPerProp
+	for i, propID := range props {
+		uni, addr := 0, opts.StartAddress+(propID-1)*ChannelsPerProp
+		if slot := propID - 1 - firstUniverseProps; slot >= 0 {
+			uni = 1 + slot/PropsPerUniverse
+			addr = (slot % PropsPerUniverse) * ChannelsPerProp
+		}
+		if uni > maxUniverse {
+			maxUniverse = uni
+		}
+		fixtureByProp[propID] = i
+		ws.Engine.Fixtures = append(ws.Engine.Fixtures, fixture{
+			Manufacturer: "Generic",
+			Model:        "Generic RGB",
+			Mode:         "RGB",
+			ID:           i,
+			Name:         fmt.Sprintf("Prop %d", propID),
+			Universe:     uni,
+			Address:      addr,
+			Channels:     ChannelsPerProp,
+		})
+	}
+	for u := 0; u <= maxUniverse; u++ {
+		ws.Engine.Universes = append(ws.Engine.Universes, universe{Name: fmt.Sprintf("Universe %d", u+1), ID: u})
+	}
+
+	// --- 2. FIXTURE GROUPS ---
+	for gi, g := range p.PropGroups {
+		ids := bingen.ParseIDRange(g.IDs)
+		fg := fixtureGroup{ID: gi, Name: g.Name, Size: groupSize{X: len(ids), Y: 1}}
+		for x, id := range ids {
+			fg.Heads = append(fg.Heads, groupHead{X: x, Y: 0, Fixture: fixtureByProp[id], Head: 0})
+		}
+		ws.Engine.FixtureGroups = append(ws.Engine.FixtureGroups, fg)
+	}
+
+	// --- 3. SCENES AND CHASERS ---
+	nextID := 0
+	sceneFor := func(name string, ids []int, color uint32) int {
+		r, g, b := (color>>16)&0xFF, (color>>8)&0xFF, color&0xFF
+		values := fmt.Sprintf("0,%d,1,%d,2,%d", r, g, b)
+		fn := function{ID: nextID, Type: "Scene", Name: name, Path: "PicoLume"}
+		for _, id := range ids {
+			fn.FixtureVal = append(fn.FixtureVal, fixtureVal{ID: fixtureByProp[id], Values: values})
+		}
+		ws.Engine.Functions = append(ws.Engine.Functions, fn)
+		nextID++
+		return fn.ID
+	}
+
+	for ti, track := range p.Tracks {
+		if track.Type != "led" {
+			continue
+		}
+		group := p.FindGroup(track.GroupId)
+		if group == nil {
+			continue
+		}
+		ids := bingen.ParseIDRange(group.IDs)
+		if len(ids) == 0 {
+			continue
+		}
+
+		trackName := fmt.Sprintf("Track %d - %s", ti+1, group.Name)
+		clips := make([]bingen.Clip, len(track.Clips))
+		copy(clips, track.Clips)
+		sort.SliceStable(clips, func(i, j int) bool { return clips[i].StartTime < clips[j].StartTime })
+
+		var steps []step
+		blackout := -1
+		scenes := make(map[int]int) // clip index -> scene
+		for _, seg := range timeline(clips) {
+			fn := blackout
+			switch {
+			case seg.clip < 0 && blackout < 0:
+				blackout = sceneFor(trackName+" - Off", ids, 0)
+				fn = blackout
+			case seg.clip >= 0:
+				var ok bool
+				if fn, ok = scenes[seg.clip]; !ok {
+					clip := clips[seg.clip]
+					colorHex, _ := clip.ColorHex()
+					fn = sceneFor(fmt.Sprintf("%s - %d %s", trackName, seg.clip+1, clip.Type), ids, bingen.ParseColor(colorHex))
+					scenes[seg.clip] = fn
+				}
+			}
+			hold := int(math.Round(seg.end)) - int(math.Round(seg.start))
+			steps = append(steps, step{Number: len(steps), Hold: hold, Function: fn})
+		}
+		if len(steps) == 0 {
+			continue
+		}
+
+		ws.Engine.Functions = append(ws.Engine.Functions, function{
+			ID:         nextID,
+			Type:       "Chaser",
+			Name:       trackName,
+			Path:       "PicoLume",
+			Direction:  "Forward",
+			RunOrder:   "SingleShot",
+			SpeedModes: &speedModes{FadeIn: "PerStep", FadeOut: "PerStep", Duration: "PerStep"},
+			Steps:      steps,
+		})
+		nextID++
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	buf.WriteString("<!DOCTYPE Workspace>\n")
+	enc := xml.NewEncoder(&buf)
+	enc.Indent("", " ")
+	if err := enc.Encode(ws); err != nil {
+		return nil, fmt.Errorf("failed to encode workspace: %w", err)
+	}
+	buf.WriteString("\n")
+	return buf.Bytes(), nil
+}
+
+// segment is a stretch of a track's chaser: clip plays from start to end,
+// in milliseconds, or nothing does if clip is -1.
+type segment struct {
+	clip       int
+	start, end float64
+}
+
+// timeline splits clips, sorted by start time, into the stretches a chaser
+// steps through. Where clips overlap, the one that started last plays, as
+// on the receiver; an earlier clip still running resumes once it ends.
+// Gaps, including one before the first clip, play nothing.
+func timeline(clips []bingen.Clip) []segment {
+	cuts := []float64{0}
+	for _, c := range clips {
+		if c.Duration > 0 {
+			cuts = append(cuts, c.StartTime, c.StartTime+c.Duration)
+		}
+	}
+	sort.Float64s(cuts)
+
+	var segs []segment
+	for i := 0; i+1 < len(cuts); i++ {
+		start, end := cuts[i], cuts[i+1]
+		if end <= start || start < 0 {
+			continue
+		}
+		active := -1
+		for ci, c := range clips {
+			if c.Duration > 0 && c.StartTime <= start && c.StartTime+c.Duration >= end {
+				active = ci
+			}
+		}
+		if n := len(segs); n > 0 && segs[n-1].clip == active && segs[n-1].end == start {
+			segs[n-1].end = end
+			continue
+		}
+		segs = append(segs, segment{clip: active, start: start, end: end})
+	}
+	return segs
+}
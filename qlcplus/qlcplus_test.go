@@ -0,0 +1,121 @@
+package qlcplus
+
+import (
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"PicoLume/bingen"
+)
+
+var update = flag.Bool("update", false, "rewrite golden .qxw files in testdata")
+
+func project(t *testing.T, js string) *bingen.Project {
+	t.Helper()
+	var p bingen.Project
+	if err := json.Unmarshal([]byte(js), &p); err != nil {
+		t.Fatal(err)
+	}
+	return &p
+}
+
+func TestExportGolden(t *testing.T) {
+	tests := []struct {
+		name    string
+		project string
+		opts    Options
+	}{
+		{
+			name: "basic",
+			project: `{"propGroups": [{"id": "g1", "name": "Left", "ids": "1-2"}, {"id": "g2", "name": "Right", "ids": "3"}],
+				"tracks": [
+					{"type": "led", "groupId": "g1", "clips": [
+						{"startTime": 500, "duration": 1000, "type": "solid", "props": {"color": "#FF0000"}},
+						{"startTime": 2000, "duration": 500, "type": "strobe", "props": {"color": "#00FF00"}}]},
+					{"type": "led", "groupId": "g2", "clips": [
+						{"startTime": 0, "duration": 250, "type": "solid", "props": {"color": "#0000FF"}}]},
+					{"type": "audio", "clips": [{"startTime": 0, "duration": 5000}]}]}`,
+			opts: Options{Author: "Test"},
+		},
+		{
+			// The later clip plays over the earlier one, which resumes.
+			name: "overlap",
+			project: `{"propGroups": [{"id": "g1", "name": "All", "ids": "1-2"}],
+				"tracks": [{"type": "led", "groupId": "g1", "clips": [
+					{"startTime": 0, "duration": 1000, "type": "solid", "props": {"color": "#FF0000"}},
+					{"startTime": 200, "duration": 200, "type": "solid", "props": {"color": "#0000FF"}},
+					{"startTime": 900, "duration": 600, "type": "solid", "props": {"color": "#FFFFFF"}}]}]}`,
+		},
+		{
+			// Props past the end of the first universe continue in the next.
+			name: "address-overflow",
+			project: `{"propGroups": [{"id": "g1", "name": "All", "ids": "1-6"}],
+				"tracks": [{"type": "led", "groupId": "g1", "clips": [
+					{"startTime": 0, "duration": 1000, "type": "solid", "props": {"color": "#123456"}}]}]}`,
+			opts: Options{StartAddress: 500},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Export(project(t, tt.project), tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			golden := filepath.Join("testdata", tt.name+".qxw")
+			if *update {
+				if err := os.WriteFile(golden, got, 0644); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatalf("%v; run go test ./qlcplus -update", err)
+			}
+			if string(got) != string(want) {
+				t.Errorf("workspace differs from %s:\n%s", golden, got)
+			}
+		})
+	}
+}
+
+func TestExportStartAddress(t *testing.T) {
+	p := project(t, `{"propGroups": [{"id": "g1", "name": "All", "ids": "1"}]}`)
+	for _, addr := range []int{-1, MaxStartAddress + 1, 512} {
+		if _, err := Export(p, Options{StartAddress: addr}); err == nil {
+			t.Errorf("Export(StartAddress %d) succeeded, want an error", addr)
+		}
+	}
+	if _, err := Export(p, Options{StartAddress: MaxStartAddress}); err != nil {
+		t.Errorf("Export(StartAddress %d) = %v", MaxStartAddress, err)
+	}
+}
+
+func TestTimeline(t *testing.T) {
+	clip := func(start, dur float64) bingen.Clip { return bingen.Clip{StartTime: start, Duration: dur} }
+	tests := []struct {
+		name  string
+		clips []bingen.Clip
+		want  []segment
+	}{
+		{"empty", nil, nil},
+		{"gaps", []bingen.Clip{clip(100, 100), clip(300, 100)},
+			[]segment{{-1, 0, 100}, {0, 100, 200}, {-1, 200, 300}, {1, 300, 400}}},
+		{"nested", []bingen.Clip{clip(0, 1000), clip(200, 200)},
+			[]segment{{0, 0, 200}, {1, 200, 400}, {0, 400, 1000}}},
+		{"tail overlap", []bingen.Clip{clip(0, 1000), clip(900, 600)},
+			[]segment{{0, 0, 900}, {1, 900, 1500}}},
+		{"same start", []bingen.Clip{clip(0, 500), clip(0, 300)},
+			[]segment{{1, 0, 300}, {0, 300, 500}}},
+		{"zero length", []bingen.Clip{clip(0, 0), clip(0, 100)},
+			[]segment{{1, 0, 100}}},
+	}
+	for _, tt := range tests {
+		if got := timeline(tt.clips); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: timeline() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"time"
+
+	"PicoLume/bingen"
+)
+
+// ==========================================================
+// SCHEDULED SHOW START
+// ==========================================================
+
+// ScheduleResponse is returned by CheckSchedule.
+type ScheduleResponse struct {
+	Schedule bingen.Schedule `json:"schedule"` // normalized, to store in project settings
+	Next     []string        `json:"next"`     // upcoming starts, ScheduleTimeLayout
+	Error    string          `json:"error"`
+}
+
+// scheduleLookahead is how many upcoming starts CheckSchedule lists.
+const scheduleLookahead = 5
+
+// CheckSchedule validates a show schedule edited in the project settings
+// and lists its next starts from now, by this computer's local time (the
+// receivers' clocks are expected to keep the same). The returned schedule
+// has the start time in full and the weekdays spelled out, and is what gets
+// stored as settings.schedule and written to show.bin.
+func (a *App) CheckSchedule(s bingen.Schedule) ScheduleResponse {
+	defer a.recoverBinding("CheckSchedule")
+
+	start, err := s.ParseStart()
+	if err != nil {
+		return ScheduleResponse{Schedule: s, Error: err.Error()}
+	}
+	s.Start = start.Format(bingen.ScheduleTimeLayout)
+	if s.Repeat == "weekly" {
+		seen := [7]bool{}
+		for _, d := range s.Days {
+			if d >= 0 && d <= 6 {
+				seen[d] = true
+			}
+		}
+		s.Days = s.Days[:0]
+		for d, ok := range seen {
+			if ok {
+				s.Days = append(s.Days, d)
+			}
+		}
+		if len(s.Days) == 0 {
+			s.Days = []int{int(start.Weekday())}
+		}
+	} else {
+		s.Days = nil
+	}
+
+	// The schedule is zoneless; compare against local wall-clock time.
+	now := time.Now()
+	from := time.Date(now.Year(), now.Month(), now.Day(), now.Hour(), now.Minute(), now.Second(), 0, time.UTC)
+	next, err := s.Next(from, scheduleLookahead)
+	if err != nil {
+		return ScheduleResponse{Schedule: s, Error: err.Error()}
+	}
+	resp := ScheduleResponse{Schedule: s, Next: []string{}}
+	for _, t := range next {
+		resp.Next = append(resp.Next, t.Format(bingen.ScheduleTimeLayout))
+	}
+	return resp
+}
@@ -0,0 +1,27 @@
+package main
+
+import "PicoLume/search"
+
+// ==========================================================
+// PROJECT SEARCH
+// ==========================================================
+
+// SearchResponse is returned by SearchProject.
+type SearchResponse struct {
+	Matches   []search.Match `json:"matches"`
+	Truncated bool           `json:"truncated"`
+	Error     string         `json:"error"`
+}
+
+// SearchProject finds the clips matching a query such as "red strobes
+// after 3:00", for find-all in large projects. See package search for the
+// query terms.
+func (a *App) SearchProject(projectJson string, query string) SearchResponse {
+	defer a.recoverBinding("SearchProject")
+
+	res, err := search.Search([]byte(projectJson), query)
+	if err != nil {
+		return SearchResponse{Error: err.Error()}
+	}
+	return SearchResponse{Matches: res.Matches, Truncated: res.Truncated}
+}
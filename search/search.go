@@ -0,0 +1,273 @@
+// Package search finds clips in a project by effect, color, group and time,
+// from a short free-text query such as "red strobes after 3:00" or
+// "chase group:left between 1:00 and 2:30".
+//
+// A query is a list of terms, all of which must match:
+//
+//	strobe, strobes          effect type
+//	red, #ff8800             color; clips with a similar color match
+//	group:left, left         prop group, by ID or name
+//	after 3:00               clips starting at or after a time
+//	before 90s               clips starting before a time
+//	between 1:00 and 1:30    both of the above
+//
+// Times are m:ss, m:ss.mmm, or numbers with an s or ms suffix. Filler
+// words such as "all", "clips", "on" and "with" are ignored.
+package search
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"PicoLume/bingen"
+	"PicoLume/palette"
+)
+
+// ColorTolerance is the largest ΔE at which a clip color matches the
+// query color.
+const ColorTolerance = 30
+
+// MaxResults caps a search; Result.Truncated reports more.
+const MaxResults = 1000
+
+var fillers = map[string]bool{
+	"find": true, "all": true, "the": true, "a": true, "clip": true, "clips": true,
+	"on": true, "in": true, "with": true, "of": true, "and": true, "show": true,
+}
+
+// Query is a parsed search.
+type Query struct {
+	Effect string   // clip type; "" for any
+	Colors []uint32 // each must match one of the clip's colors
+	Group  string   // group ID; "" for any
+	After  float64  // ms; starts at or after
+	Before float64  // ms; starts before; 0 for no limit
+}
+
+// Match is one clip found.
+type Match struct {
+	Track     int     `json:"track"` // index into the project's tracks
+	TrackID   string  `json:"trackId"`
+	Clip      int     `json:"clip"` // index into that track's clips
+	ClipID    string  `json:"clipId"`
+	Group     string  `json:"group"` // group name
+	Type      string  `json:"type"`
+	StartTime float64 `json:"startTime"`
+	Duration  float64 `json:"duration"`
+}
+
+// Result is what Search found.
+type Result struct {
+	Matches   []Match `json:"matches"`
+	Truncated bool    `json:"truncated"`
+}
+
+// project holds the parts of a project search reads.
+type project struct {
+	PropGroups []bingen.PropGroup `json:"propGroups"`
+	Tracks     []struct {
+		ID      string `json:"id"`
+		Type    string `json:"type"`
+		GroupID string `json:"groupId"`
+		Clips   []struct {
+			ID string `json:"id"`
+			bingen.Clip
+		} `json:"clips"`
+	} `json:"tracks"`
+}
+
+// Search runs query over projectJSON.
+func Search(projectJSON []byte, query string) (*Result, error) {
+	var p project
+	if err := json.Unmarshal(projectJSON, &p); err != nil {
+		return nil, fmt.Errorf("invalid project JSON: %w", err)
+	}
+	q, err := Parse(query, p.PropGroups)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make(map[string]string)
+	for _, g := range p.PropGroups {
+		names[g.ID] = g.Name
+	}
+	res := &Result{Matches: []Match{}}
+	for ti, t := range p.Tracks {
+		if t.Type != "led" || (q.Group != "" && t.GroupID != q.Group) {
+			continue
+		}
+		for ci, c := range t.Clips {
+			if !q.matches(c.Clip) {
+				continue
+			}
+			if len(res.Matches) == MaxResults {
+				res.Truncated = true
+				return res, nil
+			}
+			res.Matches = append(res.Matches, Match{
+				Track: ti, TrackID: t.ID, Clip: ci, ClipID: c.ID,
+				Group: names[t.GroupID], Type: c.Type,
+				StartTime: c.StartTime, Duration: c.Duration,
+			})
+		}
+	}
+	return res, nil
+}
+
+func (q *Query) matches(c bingen.Clip) bool {
+	if q.Effect != "" && c.Type != q.Effect {
+		return false
+	}
+	if c.StartTime < q.After || (q.Before > 0 && c.StartTime >= q.Before) {
+		return false
+	}
+	colors := []string{c.Props.Color, c.Props.Color2, c.Props.ColorA, c.Props.ColorB, c.Props.ColorStart}
+	for _, stop := range c.Props.ColorStops {
+		colors = append(colors, stop.Color)
+	}
+	for _, want := range q.Colors {
+		found := false
+		for _, s := range colors {
+			if got, err := bingen.ParseColorValue(s); err == nil && palette.Distance(got, want) <= ColorTolerance {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// Parse reads a query; groups resolve group names.
+func Parse(query string, groups []bingen.PropGroup) (*Query, error) {
+	q := &Query{}
+	words := strings.Fields(strings.ToLower(query))
+	if len(words) == 0 {
+		return nil, errors.New("empty search")
+	}
+	var unknown []string
+	for i := 0; i < len(words); i++ {
+		w := words[i]
+		switch {
+		case fillers[w]:
+		case w == "after" || w == "before" || w == "between":
+			if i+1 >= len(words) {
+				return nil, fmt.Errorf("%q needs a time", w)
+			}
+			t, err := parseTime(words[i+1])
+			if err != nil {
+				return nil, err
+			}
+			i++
+			switch w {
+			case "after":
+				q.After = t
+			case "before":
+				q.Before = t
+			case "between":
+				if i+2 >= len(words) || words[i+1] != "and" {
+					return nil, errors.New(`"between" needs two times, as in "between 1:00 and 2:00"`)
+				}
+				end, err := parseTime(words[i+2])
+				if err != nil {
+					return nil, err
+				}
+				q.After, q.Before = t, end
+				i += 2
+			}
+		case strings.HasPrefix(w, "group:"):
+			g := findGroup(groups, strings.TrimPrefix(w, "group:"))
+			if g == "" {
+				return nil, fmt.Errorf("no group %q", strings.TrimPrefix(w, "group:"))
+			}
+			q.Group = g
+		default:
+			if effect := effectName(w); effect != "" {
+				q.Effect = effect
+			} else if c, err := bingen.ParseColorValue(w); err == nil {
+				q.Colors = append(q.Colors, c)
+			} else if g := findGroup(groups, w); g != "" {
+				q.Group = g
+			} else {
+				unknown = append(unknown, w)
+			}
+		}
+	}
+	if len(unknown) > 0 {
+		return nil, fmt.Errorf("unrecognized search terms: %s", strings.Join(unknown, ", "))
+	}
+	if q.Before > 0 && q.Before <= q.After {
+		return nil, errors.New("the end time is not after the start time")
+	}
+	return q, nil
+}
+
+// effectName resolves a clip type, singular or plural, ignoring case.
+func effectName(w string) string {
+	var names []string
+	for _, e := range bingen.EffectSchemas() {
+		names = append(names, e.Name)
+	}
+	names = append(names, bingen.ClipTypes()...)
+	for _, n := range names {
+		l := strings.ToLower(n)
+		if w == l || w == l+"s" || w == l+"es" {
+			return n
+		}
+	}
+	return ""
+}
+
+func findGroup(groups []bingen.PropGroup, w string) string {
+	for _, g := range groups {
+		if strings.EqualFold(g.ID, w) || strings.EqualFold(g.Name, w) {
+			return g.ID
+		}
+	}
+	return ""
+}
+
+// parseTime reads m:ss(.mmm), h:mm:ss, Ns or Nms into milliseconds.
+func parseTime(s string) (float64, error) {
+	bad := fmt.Errorf("unrecognized time %q (use m:ss, 90s or 1500ms)", s)
+	var ms float64
+	switch {
+	case strings.Contains(s, ":"):
+		parts := strings.Split(s, ":")
+		if len(parts) > 3 {
+			return 0, bad
+		}
+		for _, part := range parts {
+			v, err := strconv.ParseFloat(part, 64)
+			if err != nil || v < 0 {
+				return 0, bad
+			}
+			ms = ms*60 + v
+		}
+		ms *= 1000
+	case strings.HasSuffix(s, "ms"):
+		v, err := strconv.ParseFloat(strings.TrimSuffix(s, "ms"), 64)
+		if err != nil {
+			return 0, bad
+		}
+		ms = v
+	case strings.HasSuffix(s, "s"):
+		v, err := strconv.ParseFloat(strings.TrimSuffix(s, "s"), 64)
+		if err != nil {
+			return 0, bad
+		}
+		ms = v * 1000
+	default:
+		return 0, bad
+	}
+	if math.IsNaN(ms) || math.IsInf(ms, 0) || ms < 0 || ms > bingen.MaxTimeMs {
+		return 0, bad
+	}
+	return ms, nil
+}
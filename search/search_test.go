@@ -0,0 +1,105 @@
+package search
+
+import (
+	"strings"
+	"testing"
+)
+
+const testProject = `{
+	"propGroups": [{"id": "g1", "name": "Left", "ids": "1-5"}, {"id": "g2", "name": "Right", "ids": "6-10"}],
+	"tracks": [
+		{"id": "t1", "type": "led", "groupId": "g1", "clips": [
+			{"id": "c1", "type": "strobe", "startTime": 1000, "duration": 500, "props": {"color": "#ff0000"}},
+			{"id": "c2", "type": "strobe", "startTime": 200000, "duration": 500, "props": {"color": "#f01010"}},
+			{"id": "c3", "type": "solid", "startTime": 190000, "duration": 500, "props": {"color": "#ff0000"}}
+		]},
+		{"id": "t2", "type": "led", "groupId": "g2", "clips": [
+			{"id": "c4", "type": "strobe", "startTime": 185000, "duration": 500, "props": {"color": "#0000ff"}},
+			{"id": "c5", "type": "alternate", "startTime": 240000, "duration": 500, "props": {"colorA": "#0000ff", "colorB": "red"}},
+			{"id": "c6", "type": "chase", "startTime": 250000, "duration": 500, "props": {"colorStops": [{"position": 0, "color": "#ffffff"}, {"position": 0.5, "color": "#00ff00"}, {"position": 1, "color": "#ffffff"}]}}
+		]},
+		{"id": "t3", "type": "audio", "clips": [{"id": "a1", "type": "audio", "startTime": 0, "duration": 9000}]}
+	]
+}`
+
+func TestSearch(t *testing.T) {
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"find all red strobes after 3:00", []string{"c2"}},
+		{"strobes", []string{"c1", "c2", "c4"}},
+		{"red", []string{"c1", "c2", "c3", "c5"}},
+		{"red blue", []string{"c5"}},
+		{"clips on right", []string{"c4", "c5", "c6"}},
+		{"group:g1 between 3:00 and 3:15", []string{"c3"}},
+		{"before 2s", []string{"c1"}},
+		{"after 200000ms", []string{"c2", "c5", "c6"}},
+		{"#00ff00", []string{"c6"}},
+	}
+	for _, tt := range tests {
+		res, err := Search([]byte(testProject), tt.query)
+		if err != nil {
+			t.Errorf("Search(%q) error = %v", tt.query, err)
+			continue
+		}
+		var got []string
+		for _, m := range res.Matches {
+			got = append(got, m.ClipID)
+		}
+		if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("Search(%q) = %v, want %v", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestSearchLocation(t *testing.T) {
+	res, err := Search([]byte(testProject), "blue strobe")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Match{Track: 1, TrackID: "t2", Clip: 0, ClipID: "c4", Group: "Right", Type: "strobe", StartTime: 185000, Duration: 500}
+	if len(res.Matches) != 1 || res.Matches[0] != want {
+		t.Errorf("matches = %+v, want %+v", res.Matches, want)
+	}
+}
+
+func TestSearchErrors(t *testing.T) {
+	for _, query := range []string{
+		"",
+		"sparkly things",
+		"after",
+		"after noon",
+		"between 1:00",
+		"between 2:00 and 1:00",
+		"group:nowhere",
+	} {
+		if _, err := Search([]byte(testProject), query); err == nil {
+			t.Errorf("Search(%q) should fail", query)
+		}
+	}
+	if _, err := Search([]byte("{"), "red"); err == nil {
+		t.Error("Search(bad JSON) should fail")
+	}
+}
+
+func TestParseTime(t *testing.T) {
+	tests := map[string]float64{
+		"3:00":    180000,
+		"1:02.5":  62500,
+		"1:00:00": 3600000,
+		"90s":     90000,
+		"1.5s":    1500,
+		"1500ms":  1500,
+	}
+	for in, want := range tests {
+		if got, err := parseTime(in); err != nil || got != want {
+			t.Errorf("parseTime(%q) = %v, %v; want %v", in, got, err, want)
+		}
+	}
+	for _, in := range []string{"3", "-1s", "1:2:3:4", "nans", "99999999s"} {
+		if _, err := parseTime(in); err == nil {
+			t.Errorf("parseTime(%q) should fail", in)
+		}
+	}
+}
@@ -0,0 +1,267 @@
+// Package serialmanager is the one owner of the receiver serial ports. It
+// holds the persistent session and runs every operation that needs a port
+// to itself, such as a connection probe, a reset or the 1200 baud touch,
+// one at a time on its own goroutine. A status check can then no longer
+// open the port in the middle of a reset, and a reset no longer fails
+// because a probe briefly held the port.
+//
+// Operations on the session's port should go through the session instead:
+// commands and streaming share it, and the session serializes them.
+package serialmanager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"sync"
+
+	"PicoLume/logger"
+	"PicoLume/serialsession"
+)
+
+// Operations reported in State.Op.
+const (
+	OpProbe      = "probe"      // brief open to see whether another program holds the port
+	OpReset      = "reset"      // reset over a port no session holds
+	OpInfo       = "info"       // question over a temporary session
+	OpBootloader = "bootloader" // reboot into the UF2 bootloader
+)
+
+var (
+	// ErrBusy is returned by TryDo while another operation runs.
+	ErrBusy = errors.New("serial port busy")
+	// ErrClosed is returned after Close.
+	ErrClosed = errors.New("serial manager closed")
+	// ErrPanicked is wrapped in the error of an operation that panicked.
+	ErrPanicked = errors.New("serial operation panicked")
+)
+
+// State is what the manager is doing with the ports.
+type State struct {
+	Port      string `json:"port"`      // the session's port; empty without one
+	Session   string `json:"session"`   // the session's state, see serialsession
+	Op        string `json:"op"`        // operation running; empty when idle
+	OpPort    string `json:"opPort"`    // port of Op
+	Streaming bool   `json:"streaming"` // a live preview streams over the session
+}
+
+// Options configures a Manager.
+type Options struct {
+	// OnState, if set, is called with the new state whenever it changes.
+	// Calls are serialized and must not block.
+	OnState func(State)
+
+	// OnPanic, if set, is called with the panic value and stack when an
+	// operation panics, instead of logging it. The manager keeps running
+	// and the operation returns an error wrapping ErrPanicked.
+	OnPanic func(op string, value interface{}, stack []byte)
+}
+
+// Manager owns the serial ports. The zero value is not usable; call New.
+type Manager struct {
+	opts Options
+	jobs chan *job
+	done chan struct{}
+	wg   sync.WaitGroup
+
+	notifyMu sync.Mutex // serializes OnState
+	notified State      // last state passed to OnState
+
+	mu        sync.Mutex
+	session   *serialsession.Session
+	op        string
+	opPort    string
+	streaming bool
+	closed    bool
+}
+
+type job struct {
+	ctx    context.Context
+	op     string
+	port   string
+	fn     func() error
+	result chan error
+}
+
+// New starts a manager.
+func New(opts Options) *Manager {
+	m := &Manager{opts: opts, jobs: make(chan *job), done: make(chan struct{})}
+	m.wg.Add(1)
+	go m.loop()
+	return m
+}
+
+// Close waits for the running operation, closes the session and stops the
+// manager.
+func (m *Manager) Close() {
+	m.mu.Lock()
+	if m.closed {
+		m.mu.Unlock()
+		return
+	}
+	m.closed = true
+	close(m.done)
+	m.mu.Unlock()
+	m.wg.Wait()
+	m.CloseSession()
+}
+
+// Do runs fn as op on port once no other operation runs, and returns its
+// error. Operations run in the order they were asked for. If ctx ends
+// before fn starts, fn is skipped and ctx's error returned; fn itself
+// should honor ctx if it may take long.
+func (m *Manager) Do(ctx context.Context, op, port string, fn func() error) error {
+	j := &job{ctx: ctx, op: op, port: port, fn: fn, result: make(chan error, 1)}
+	select {
+	case m.jobs <- j:
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-m.done:
+		return ErrClosed
+	}
+	return <-j.result
+}
+
+// TryDo runs fn like Do if no other operation runs or waits, and returns
+// ErrBusy otherwise. It suits checks that can be skipped, like a probe.
+func (m *Manager) TryDo(op, port string, fn func() error) error {
+	j := &job{ctx: context.Background(), op: op, port: port, fn: fn, result: make(chan error, 1)}
+	select {
+	case m.jobs <- j:
+	case <-m.done:
+		return ErrClosed
+	default:
+		return ErrBusy
+	}
+	return <-j.result
+}
+
+func (m *Manager) loop() {
+	defer m.wg.Done()
+	for {
+		var j *job
+		select {
+		case j = <-m.jobs:
+		case <-m.done:
+			return
+		}
+		if err := j.ctx.Err(); err != nil {
+			j.result <- err
+			continue
+		}
+		m.setOp(j.op, j.port)
+		err := m.run(j)
+		m.setOp("", "")
+		j.result <- err
+	}
+}
+
+// run runs j's operation, turning a panic into its error so one bad
+// operation does not take the manager, and every later one, down with it.
+func (m *Manager) run(j *job) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			stack := debug.Stack()
+			if m.opts.OnPanic != nil {
+				m.opts.OnPanic(j.op, r, stack)
+			} else {
+				logger.Error("PANIC in serial %s on %s: %v\n%s", j.op, j.port, r, stack)
+			}
+			err = fmt.Errorf("%w: %s on %s: %v", ErrPanicked, j.op, j.port, r)
+		}
+	}()
+	return j.fn()
+}
+
+func (m *Manager) setOp(op, port string) {
+	m.mu.Lock()
+	m.op, m.opPort = op, port
+	m.mu.Unlock()
+	m.changed()
+}
+
+// OpenSession opens the persistent session on port, replacing the open
+// one. opts.OnState is called as before; the manager's state follows the
+// session's.
+func (m *Manager) OpenSession(port string, open serialsession.Opener, opts serialsession.Options) *serialsession.Session {
+	m.CloseSession()
+	onState := opts.OnState
+	opts.OnState = func(state string, err error) {
+		if onState != nil {
+			onState(state, err)
+		}
+		m.changed()
+	}
+	s := serialsession.Open(port, open, opts)
+	m.mu.Lock()
+	old := m.session
+	m.session = s
+	m.mu.Unlock()
+	if old != nil {
+		// Another OpenSession raced this one.
+		old.Close()
+	}
+	m.changed()
+	return s
+}
+
+// CloseSession closes the persistent session and returns it, or nil if
+// none was open.
+func (m *Manager) CloseSession() *serialsession.Session {
+	m.mu.Lock()
+	s := m.session
+	m.session, m.streaming = nil, false
+	m.mu.Unlock()
+	if s == nil {
+		return nil
+	}
+	s.Close()
+	m.changed()
+	return s
+}
+
+// Session returns the persistent session, or nil if none is open.
+func (m *Manager) Session() *serialsession.Session {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.session
+}
+
+// SetStreaming records whether a live preview streams over the session.
+func (m *Manager) SetStreaming(streaming bool) {
+	m.mu.Lock()
+	if m.streaming == streaming {
+		m.mu.Unlock()
+		return
+	}
+	m.streaming = streaming
+	m.mu.Unlock()
+	m.changed()
+}
+
+// State returns what the manager is doing now.
+func (m *Manager) State() State {
+	m.mu.Lock()
+	st := State{Op: m.op, OpPort: m.opPort, Streaming: m.streaming}
+	s := m.session
+	m.mu.Unlock()
+	if s == nil {
+		st.Session = serialsession.StateClosed
+		return st
+	}
+	st.Port, st.Session = s.Name(), s.State()
+	return st
+}
+
+func (m *Manager) changed() {
+	if m.opts.OnState == nil {
+		return
+	}
+	m.notifyMu.Lock()
+	defer m.notifyMu.Unlock()
+	if st := m.State(); st != m.notified {
+		m.notified = st
+		m.opts.OnState(st)
+	}
+}
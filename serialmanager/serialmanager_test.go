@@ -0,0 +1,165 @@
+package serialmanager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"PicoLume/serialsession"
+)
+
+func TestDoRunsOneAtATime(t *testing.T) {
+	m := New(Options{})
+	defer m.Close()
+
+	var running, overlaps atomic.Int32
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := m.Do(context.Background(), OpReset, "COM3", func() error {
+				if running.Add(1) > 1 {
+					overlaps.Add(1)
+				}
+				time.Sleep(5 * time.Millisecond)
+				running.Add(-1)
+				return nil
+			})
+			if err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	if n := overlaps.Load(); n != 0 {
+		t.Errorf("%d operations overlapped", n)
+	}
+
+	want := errors.New("port gone")
+	if err := m.Do(context.Background(), OpReset, "COM3", func() error { return want }); err != want {
+		t.Errorf("Do() = %v, want the operation's error", err)
+	}
+}
+
+func TestTryDoWhileBusy(t *testing.T) {
+	var mu sync.Mutex
+	var states []State
+	m := New(Options{OnState: func(st State) {
+		mu.Lock()
+		states = append(states, st)
+		mu.Unlock()
+	}})
+	defer m.Close()
+
+	started, release := make(chan struct{}), make(chan struct{})
+	go m.Do(context.Background(), OpBootloader, "COM3", func() error {
+		close(started)
+		<-release
+		return nil
+	})
+	<-started
+	if st := m.State(); st.Op != OpBootloader || st.OpPort != "COM3" {
+		t.Errorf("State() during the operation = %+v", st)
+	}
+
+	probed := false
+	if err := m.TryDo(OpProbe, "COM3", func() error { probed = true; return nil }); !errors.Is(err, ErrBusy) || probed {
+		t.Errorf("TryDo() while busy = %v (probed %v), want ErrBusy", err, probed)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := m.Do(ctx, OpReset, "COM3", func() error { t.Error("cancelled operation ran"); return nil }); !errors.Is(err, context.Canceled) {
+		t.Errorf("Do() with a cancelled context = %v", err)
+	}
+
+	close(release)
+	deadline := time.Now().Add(time.Second)
+	for m.State().Op != "" && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if err := m.TryDo(OpProbe, "COM3", func() error { probed = true; return nil }); err != nil || !probed {
+		t.Errorf("TryDo() when idle = %v (probed %v)", err, probed)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(states) < 2 || states[0].Op != OpBootloader || states[1].Op != "" {
+		t.Errorf("OnState got %+v, want the operation starting and ending", states)
+	}
+}
+
+// pipePort is a serial port whose device never says anything.
+type pipePort struct {
+	r *io.PipeReader
+	w *io.PipeWriter
+}
+
+func openPipe(string) (serialsession.Port, error) {
+	r, w := io.Pipe()
+	return &pipePort{r, w}, nil
+}
+
+func (p *pipePort) Read(b []byte) (int, error)  { return p.r.Read(b) }
+func (p *pipePort) Write(b []byte) (int, error) { return len(b), nil }
+func (p *pipePort) Close() error                { return p.r.Close() }
+
+func TestSession(t *testing.T) {
+	m := New(Options{})
+
+	connected := make(chan struct{}, 1)
+	s := m.OpenSession("COM3", openPipe, serialsession.Options{OnState: func(state string, _ error) {
+		if state == serialsession.StateConnected {
+			connected <- struct{}{}
+		}
+	}})
+	select {
+	case <-connected:
+	case <-time.After(2 * time.Second):
+		t.Fatal("session did not connect")
+	}
+	if m.Session() != s {
+		t.Error("Session() is not the opened session")
+	}
+	m.SetStreaming(true)
+	if st := m.State(); st != (State{Port: "COM3", Session: serialsession.StateConnected, Streaming: true}) {
+		t.Errorf("State() = %+v", st)
+	}
+
+	m.Close()
+	if s.State() != serialsession.StateClosed || m.Session() != nil {
+		t.Error("Close() left the session open")
+	}
+	if st := m.State(); st.Streaming || st.Session != serialsession.StateClosed {
+		t.Errorf("State() after Close = %+v", st)
+	}
+	if err := m.Do(context.Background(), OpReset, "COM3", func() error { return nil }); !errors.Is(err, ErrClosed) {
+		t.Errorf("Do() after Close = %v, want ErrClosed", err)
+	}
+}
+
+func TestDoSurvivesPanic(t *testing.T) {
+	var panicked atomic.Value
+	m := New(Options{OnPanic: func(op string, value interface{}, _ []byte) { panicked.Store(fmt.Sprint(op, ": ", value)) }})
+	defer m.Close()
+
+	err := m.Do(context.Background(), OpReset, "COM3", func() error { panic("nil port") })
+	if !errors.Is(err, ErrPanicked) {
+		t.Errorf("Do() of a panicking operation = %v, want ErrPanicked", err)
+	}
+	if got := panicked.Load(); got != "reset: nil port" {
+		t.Errorf("OnPanic got %v", got)
+	}
+	if st := m.State(); st.Op != "" {
+		t.Errorf("State() after the panic = %+v, want idle", st)
+	}
+	if err := m.TryDo(OpProbe, "COM3", func() error { return nil }); err != nil {
+		t.Errorf("TryDo() after a panic = %v, want the manager still running", err)
+	}
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"go.bug.st/serial"
+	"go.bug.st/serial/enumerator"
+)
+
+// ==========================================================
+// RECEIVER PORT & DRIVE DISCOVERY
+// ==========================================================
+
+// cdcDevicePatterns are the device names USB CDC serial ports get on Linux
+// and macOS. macOS also creates /dev/tty.usbmodem*, but opening that waits
+// for carrier detect, so only the cu.* callout device is used.
+var cdcDevicePatterns = []string{"/dev/ttyACM*", "/dev/cu.usbmodem*"}
+
+func isCDCDeviceName(name string) bool {
+	for _, pattern := range cdcDevicePatterns {
+		if ok, _ := filepath.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// isSerialCandidate reports whether p may be a receiver. Ports with USB
+// details are matched by VID/product; without them (no udev in a container,
+// or a bare device node) a CDC device name is enough.
+func isSerialCandidate(p *enumerator.PortDetails) bool {
+	if p == nil || strings.HasPrefix(p.Name, "/dev/tty.") {
+		return false
+	}
+	if hasUSBDetails(p) {
+		return isPicoLikeUSBSerialPort(p)
+	}
+	return isCDCDeviceName(p.Name)
+}
+
+func hasUSBDetails(p *enumerator.PortDetails) bool {
+	return p.IsUSB && (p.VID != "" || p.Product != "")
+}
+
+// listSerialPorts enumerates the serial ports. Tests replace it.
+var listSerialPorts = enumerator.GetDetailedPortsList
+
+// findSerialCandidates lists the serial ports that may be receivers, sorted
+// by name. If the enumerator fails or finds nothing, the CDC device nodes
+// are listed directly.
+func findSerialCandidates() ([]*enumerator.PortDetails, error) {
+	ports, err := listSerialPorts()
+	var nodes []string
+	for _, pattern := range cdcDevicePatterns {
+		matches, _ := filepath.Glob(pattern)
+		nodes = append(nodes, matches...)
+	}
+	candidates := serialCandidates(ports, nodes)
+	if err != nil && len(candidates) > 0 {
+		err = nil
+	}
+	return candidates, err
+}
+
+// serialCandidates picks the receiver candidates from the enumerated ports
+// and, if none match, from the CDC device nodes found on disk. A node the
+// enumerator described with USB details is left out: those details said it
+// is not a receiver, and probing it would send commands and the 1200 baud
+// touch to someone else's device.
+func serialCandidates(ports []*enumerator.PortDetails, nodes []string) []*enumerator.PortDetails {
+	var candidates []*enumerator.PortDetails
+	described := make(map[string]bool)
+	for _, p := range ports {
+		if p == nil {
+			continue
+		}
+		if isSerialCandidate(p) {
+			candidates = append(candidates, p)
+		}
+		if hasUSBDetails(p) {
+			described[p.Name] = true
+		}
+	}
+	if len(candidates) == 0 {
+		for _, name := range nodes {
+			if !described[name] {
+				candidates = append(candidates, &enumerator.PortDetails{Name: name})
+			}
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i].Name < candidates[j].Name })
+	return candidates
+}
+
+// isPortPermissionError reports whether opening a port failed because the
+// user may not access the device node (Linux/macOS; Windows reports a port
+// held by another program as access denied, which isPortLockedError covers).
+func isPortPermissionError(err error) bool {
+	var pe *serial.PortError
+	if errors.As(err, &pe) {
+		return pe.Code() == serial.PermissionDenied
+	}
+	return errors.Is(err, fs.ErrPermission)
+}
+
+// formatPortHolder labels the process found by portHolder.
+func formatPortHolder(name string, pid int) string {
+	if name == "" {
+		return fmt.Sprintf("PID %d", pid)
+	}
+	return fmt.Sprintf("%s (PID %d)", name, pid)
+}
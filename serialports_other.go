@@ -0,0 +1,65 @@
+//go:build !windows
+
+package main
+
+import (
+	"os"
+	"os/user"
+	"path/filepath"
+	goruntime "runtime"
+	"slices"
+	"strconv"
+	"syscall"
+
+	"PicoLume/i18n"
+)
+
+// volumeRoots lists the mounted volumes a receiver drive may appear under,
+// each with a trailing slash.
+func volumeRoots() []string {
+	var patterns []string
+	if goruntime.GOOS == "darwin" {
+		patterns = []string{"/Volumes/*"}
+	} else {
+		name := os.Getenv("USER")
+		if u, err := user.Current(); err == nil {
+			name = u.Username
+		}
+		// udisks mounts under /run/media/$USER (Fedora, Arch) or
+		// /media/$USER (Debian, Ubuntu); manual mounts use /media or /mnt.
+		patterns = []string{"/run/media/" + name + "/*", "/media/" + name + "/*", "/media/*", "/mnt/*"}
+	}
+	var roots []string
+	for _, pattern := range patterns {
+		matches, _ := filepath.Glob(pattern)
+		for _, m := range matches {
+			if fi, err := os.Stat(m); err == nil && fi.IsDir() {
+				roots = append(roots, m+"/")
+			}
+		}
+	}
+	return roots
+}
+
+// serialPermissionHint explains how to get access to a serial device node,
+// naming the group that owns it (usually dialout or uucp on Linux).
+func serialPermissionHint(port string) string {
+	if goruntime.GOOS == "darwin" {
+		return i18n.T("Permission denied for %s. Check that no security software is blocking USB serial access, then reconnect the receiver.", port)
+	}
+	group := "dialout"
+	var gid int
+	if fi, err := os.Stat(port); err == nil {
+		if st, ok := fi.Sys().(*syscall.Stat_t); ok {
+			gid = int(st.Gid)
+			if g, err := user.LookupGroupId(strconv.Itoa(gid)); err == nil {
+				group = g.Name
+			}
+		}
+	}
+	if groups, err := os.Getgroups(); err == nil && gid != 0 && slices.Contains(groups, gid) {
+		// The group was added after this session started.
+		return i18n.T("Permission denied for %s. You are in the %s group, but only new logins get it: log out and back in, then try again.", port, group)
+	}
+	return i18n.T("Permission denied for %s. Add your user to the %s group (sudo usermod -aG %s $USER), then log out and back in.", port, group, group)
+}
@@ -0,0 +1,27 @@
+//go:build windows
+
+package main
+
+import (
+	"os"
+
+	"PicoLume/i18n"
+)
+
+// volumeRoots lists the drive letters a receiver drive may appear under.
+func volumeRoots() []string {
+	var roots []string
+	for _, drive := range "CDEFGHIJKLMNOPQRSTUVWXYZ" {
+		root := string(drive) + ":/"
+		if _, err := os.Stat(root); err == nil {
+			roots = append(roots, root)
+		}
+	}
+	return roots
+}
+
+// serialPermissionHint explains an access error. Windows reports a port
+// held by another program as access denied, so this is rarely reached.
+func serialPermissionHint(port string) string {
+	return i18n.T("Permission denied for %s. Close other programs using the port, then reconnect the receiver.", port)
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"PicoLume/bingen"
+	"PicoLume/i18n"
+	"PicoLume/logger"
+	"PicoLume/serialmanager"
+	"PicoLume/serialsession"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+	"go.bug.st/serial"
+)
+
+// ==========================================================
+// SERIAL SESSION
+// ==========================================================
+
+// SerialSessionStatus is the payload of "serial:state" events.
+type SerialSessionStatus struct {
+	Port  string `json:"port"` // empty when no session is open
+	State string `json:"state"`
+	Error string `json:"error"`
+}
+
+// SerialCommandResponse is the result of SerialCommand.
+type SerialCommandResponse struct {
+	Reply string `json:"reply"`
+	Error string `json:"error"`
+}
+
+// OpenSerialSession keeps port open for console, telemetry and commands,
+// reconnecting after device resets. Uploads reset the receiver through the
+// session instead of opening the port themselves. An empty port picks the
+// first receiver found.
+func (a *App) OpenSerialSession(port string) string {
+	defer a.recoverBinding("OpenSerialSession")
+	if port == "" {
+		candidates, err := findSerialCandidates()
+		if err != nil || len(candidates) == 0 {
+			return i18n.T("Error: No receiver serial port found")
+		}
+		port = candidates[0].Name
+	}
+
+	a.serialPorts().OpenSession(port, a.openSessionPort, serialsession.Options{
+		OnLine: func(l serialsession.Line) {
+			if a.ctx != nil {
+				runtime.EventsEmit(a.ctx, "serial:line", l)
+			}
+		},
+		OnState: func(state string, err error) {
+			status := SerialSessionStatus{Port: port, State: state}
+			if err != nil {
+				status.Error = err.Error()
+				serialLog.Debug("Serial session %s: %s (%v)", port, state, err)
+			} else {
+				serialLog.Info("Serial session %s: %s", port, state)
+			}
+			if a.ctx != nil {
+				runtime.EventsEmit(a.ctx, "serial:state", status)
+			}
+		},
+	})
+	return "OK"
+}
+
+// openSessionPort opens a receiver port with the configured baud rate.
+func (a *App) openSessionPort(name string) (serialsession.Port, error) {
+	p, err := openSerial(name, &serial.Mode{BaudRate: a.currentSettings().Serial.BaudRate})
+	if err != nil {
+		return nil, err
+	}
+	// Some USB CDC implementations only deliver data after DTR is asserted.
+	_ = p.SetDTR(true)
+	return p, nil
+}
+
+// CloseSerialSession closes the session's port, if one is open.
+func (a *App) CloseSerialSession() string {
+	if s := a.serialPorts().CloseSession(); s != nil {
+		logger.Info("Serial session %s closed", s.Name())
+	}
+	return "OK"
+}
+
+// GetSerialSession reports the session's port and state.
+func (a *App) GetSerialSession() SerialSessionStatus {
+	s := a.currentSerialSession()
+	if s == nil {
+		return SerialSessionStatus{State: serialsession.StateClosed}
+	}
+	return SerialSessionStatus{Port: s.Name(), State: s.State()}
+}
+
+// SerialCommand sends a command line to the receiver and waits for its
+// OK/ERR reply.
+func (a *App) SerialCommand(command string) SerialCommandResponse {
+	defer a.recoverBinding("SerialCommand")
+	s := a.currentSerialSession()
+	if s == nil {
+		return SerialCommandResponse{Error: "No serial session open"}
+	}
+	command = strings.TrimSpace(command)
+	if command == "" || strings.ContainsAny(command, "\r\n") {
+		return SerialCommandResponse{Error: "Invalid command"}
+	}
+	reply, err := s.Command(command, 0)
+	if err != nil {
+		return SerialCommandResponse{Error: err.Error()}
+	}
+	return SerialCommandResponse{Reply: reply}
+}
+
+// blackoutCommand triggers the blackout cue that show.bin reserves when the
+// blackoutCue setting is on.
+const blackoutCommand = "cue " + bingen.BlackoutCueID
+
+// Blackout sends the blackout cue to the receiver on the open serial
+// session, turning every prop off at once.
+func (a *App) Blackout() string {
+	defer a.recoverBinding("Blackout")
+	s := a.currentSerialSession()
+	if s == nil {
+		return i18n.T("Error: No serial session open")
+	}
+	if err := s.Write([]byte(blackoutCommand + "\n")); err != nil {
+		serialLog.Warn("Blackout: Write to %s failed: %v", s.Name(), err)
+		return "Error: " + err.Error()
+	}
+	serialLog.Info("Blackout: Sent to %s", s.Name())
+	return "OK"
+}
+
+func (a *App) currentSerialSession() *serialsession.Session {
+	return a.serialPorts().Session()
+}
+
+// serialPorts returns the manager that owns the receiver serial ports,
+// starting it on first use. Anything that opens a port goes through it, so
+// probes, resets, questions and the session never race for a port.
+// Changes are emitted as "serial:manager" events.
+func (a *App) serialPorts() *serialmanager.Manager {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.serial == nil {
+		a.serial = serialmanager.New(serialmanager.Options{
+			OnState: func(state serialmanager.State) {
+				if a.ctx != nil {
+					runtime.EventsEmit(a.ctx, "serial:manager", state)
+				}
+			},
+			OnPanic: func(op string, value interface{}, stack []byte) {
+				a.reportPanic("serial "+op, value, stack)
+			},
+		})
+	}
+	return a.serial
+}
+
+// resetViaSession sends the reset command through the open session if it
+// holds port. The session reconnects on its own once the device reboots.
+func (a *App) resetViaSession(port string) (bool, error) {
+	s := a.currentSerialSession()
+	if s == nil || s.Name() != port {
+		return false, nil
+	}
+	if s.State() != serialsession.StateConnected {
+		return true, fmt.Errorf("serial session on %s is %s", port, s.State())
+	}
+	if err := s.Write([]byte("r\n")); err != nil {
+		return true, err
+	}
+	// Give the device time to act before the drive is checked.
+	time.Sleep(250 * time.Millisecond)
+	return true, nil
+}
@@ -0,0 +1,307 @@
+// Package serialsession keeps one receiver serial port open across
+// operations and shares it between features. Lines from the device are
+// routed to console, telemetry or command-reply channels, commands are
+// serialized, and the port is reopened automatically when the device resets
+// or is replugged, so features no longer open and close the port and race
+// each other for it.
+package serialsession
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Session states.
+const (
+	StateConnecting   = "connecting"
+	StateConnected    = "connected"
+	StateDisconnected = "disconnected" // waiting to reconnect
+	StateClosed       = "closed"
+)
+
+// Channels a received line is routed to.
+const (
+	ChannelConsole   = "console"
+	ChannelTelemetry = "telemetry"
+	ChannelReply     = "reply"
+)
+
+// Defaults for Options.
+const (
+	DefaultReconnectDelay = time.Second
+	DefaultCommandTimeout = 2 * time.Second
+
+	// MaxLineLength bounds one line so a device streaming garbage can't
+	// grow the read buffer without limit.
+	MaxLineLength = 4096
+)
+
+var (
+	// ErrNotConnected is returned when the port is not open.
+	ErrNotConnected = errors.New("serial port not connected")
+	// ErrTimeout is returned when a command gets no reply in time.
+	ErrTimeout = errors.New("no reply from device")
+	// ErrClosed is returned after Close.
+	ErrClosed = errors.New("serial session closed")
+)
+
+// DeviceError is an ERR reply to a command.
+type DeviceError struct {
+	Message string // the text after ERR
+}
+
+func (e *DeviceError) Error() string { return "device error: " + e.Message }
+
+// Port is the part of a serial port a session uses.
+type Port interface {
+	io.ReadWriteCloser
+}
+
+// Opener opens the named port.
+type Opener func(name string) (Port, error)
+
+// Line is one line received from the device.
+type Line struct {
+	Channel string    `json:"channel"`
+	Text    string    `json:"text"` // without the channel prefix
+	At      time.Time `json:"at"`
+}
+
+// Classify routes a line: "T:" lines are telemetry, "OK" and "ERR" lines
+// answer the pending command, anything else is console output. It returns
+// the channel and the text without the routing prefix.
+func Classify(line string) (channel, text string) {
+	if rest, ok := strings.CutPrefix(line, "T:"); ok {
+		return ChannelTelemetry, strings.TrimSpace(rest)
+	}
+	if line == "OK" || line == "ERR" || strings.HasPrefix(line, "OK ") || strings.HasPrefix(line, "ERR ") {
+		return ChannelReply, line
+	}
+	return ChannelConsole, line
+}
+
+// Options configures a Session. Callbacks run on the session's reader
+// goroutine and must not block.
+type Options struct {
+	ReconnectDelay time.Duration // 0 for DefaultReconnectDelay
+	OnLine         func(Line)
+	OnState        func(state string, err error)
+}
+
+// Session owns one serial port.
+type Session struct {
+	name string
+	open Opener
+	opts Options
+
+	cmdMu sync.Mutex // serializes Command
+
+	mu      sync.Mutex
+	port    Port
+	state   string
+	pending chan string // reply to the running Command
+	done    chan struct{}
+	wg      sync.WaitGroup
+}
+
+// Open starts a session on the named port. The port is opened in the
+// background and reopened whenever it drops; OnState reports progress.
+func Open(name string, open Opener, opts Options) *Session {
+	if opts.ReconnectDelay <= 0 {
+		opts.ReconnectDelay = DefaultReconnectDelay
+	}
+	s := &Session{name: name, open: open, opts: opts, state: StateConnecting, done: make(chan struct{})}
+	s.wg.Add(1)
+	go s.loop()
+	return s
+}
+
+// Name is the port the session is bound to.
+func (s *Session) Name() string {
+	return s.name
+}
+
+// State returns the current state.
+func (s *Session) State() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.state
+}
+
+// Close closes the port and stops reconnecting.
+func (s *Session) Close() error {
+	s.mu.Lock()
+	select {
+	case <-s.done:
+		s.mu.Unlock()
+		return nil
+	default:
+	}
+	close(s.done)
+	port := s.port
+	s.mu.Unlock()
+	if port != nil {
+		port.Close() // unblocks the reader
+	}
+	s.wg.Wait()
+	return nil
+}
+
+// Write sends raw bytes, e.g. the single-byte reset command.
+func (s *Session) Write(p []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.writeLocked(p)
+}
+
+func (s *Session) writeLocked(p []byte) error {
+	select {
+	case <-s.done:
+		return ErrClosed
+	default:
+	}
+	if s.port == nil {
+		return ErrNotConnected
+	}
+	_, err := s.port.Write(p)
+	return err
+}
+
+// Command sends cmd as a line and waits for the device's OK/ERR reply.
+// Commands are sent one at a time; console and telemetry lines received
+// meanwhile are delivered as usual.
+func (s *Session) Command(cmd string, timeout time.Duration) (string, error) {
+	if timeout <= 0 {
+		timeout = DefaultCommandTimeout
+	}
+	s.cmdMu.Lock()
+	defer s.cmdMu.Unlock()
+
+	reply := make(chan string, 1)
+	s.mu.Lock()
+	s.pending = reply
+	err := s.writeLocked([]byte(cmd + "\n"))
+	if err != nil {
+		s.pending = nil
+	}
+	s.mu.Unlock()
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		s.mu.Lock()
+		s.pending = nil
+		s.mu.Unlock()
+	}()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case r, ok := <-reply:
+		if !ok {
+			return "", ErrNotConnected
+		}
+		if rest, isErr := strings.CutPrefix(r, "ERR"); isErr {
+			return "", &DeviceError{Message: strings.TrimSpace(rest)}
+		}
+		return strings.TrimSpace(strings.TrimPrefix(r, "OK")), nil
+	case <-timer.C:
+		return "", ErrTimeout
+	case <-s.done:
+		return "", ErrClosed
+	}
+}
+
+func (s *Session) setState(state string, err error) {
+	s.mu.Lock()
+	if s.state == StateClosed || s.state == state && err == nil {
+		s.mu.Unlock()
+		return
+	}
+	s.state = state
+	s.mu.Unlock()
+	if s.opts.OnState != nil {
+		s.opts.OnState(state, err)
+	}
+}
+
+func (s *Session) loop() {
+	defer s.wg.Done()
+	defer s.setState(StateClosed, nil)
+	for {
+		port, err := s.open(s.name)
+		if err == nil {
+			s.mu.Lock()
+			select {
+			case <-s.done:
+				s.mu.Unlock()
+				port.Close()
+				return
+			default:
+			}
+			s.port = port
+			s.mu.Unlock()
+			s.setState(StateConnected, nil)
+			err = s.read(port)
+
+			s.mu.Lock()
+			s.port = nil
+			if s.pending != nil {
+				close(s.pending)
+				s.pending = nil
+			}
+			s.mu.Unlock()
+			port.Close()
+		}
+
+		select {
+		case <-s.done:
+			return
+		default:
+		}
+		// A reset or replug drops the port; it comes back under the
+		// same name once the device has rebooted.
+		s.setState(StateDisconnected, err)
+		select {
+		case <-s.done:
+			return
+		case <-time.After(s.opts.ReconnectDelay):
+		}
+	}
+}
+
+// read delivers lines until the port fails.
+func (s *Session) read(port Port) error {
+	sc := bufio.NewScanner(port)
+	sc.Buffer(make([]byte, 0, 256), MaxLineLength)
+	for sc.Scan() {
+		text := strings.TrimRight(sc.Text(), "\r")
+		if text == "" {
+			continue
+		}
+		channel, text := Classify(text)
+		if channel == ChannelReply {
+			s.mu.Lock()
+			pending := s.pending
+			s.pending = nil
+			s.mu.Unlock()
+			if pending != nil {
+				pending <- text
+				continue
+			}
+			// Nobody asked; show it on the console.
+			channel = ChannelConsole
+		}
+		if s.opts.OnLine != nil {
+			s.opts.OnLine(Line{Channel: channel, Text: text, At: time.Now()})
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return err
+	}
+	return io.EOF
+}
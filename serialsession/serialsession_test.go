@@ -0,0 +1,155 @@
+package serialsession
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakePort is one connection to a fake device: the test writes device
+// output to out and reads what the session sent from in.
+type fakePort struct {
+	r    *io.PipeReader
+	out  *io.PipeWriter
+	in   chan string
+	once sync.Once
+}
+
+func newFakePort() *fakePort {
+	r, w := io.Pipe()
+	return &fakePort{r: r, out: w, in: make(chan string, 16)}
+}
+
+func (p *fakePort) Read(b []byte) (int, error) { return p.r.Read(b) }
+
+func (p *fakePort) Write(b []byte) (int, error) {
+	p.in <- string(b)
+	return len(b), nil
+}
+
+func (p *fakePort) Close() error {
+	p.once.Do(func() { p.r.Close() })
+	return nil
+}
+
+// unplug makes the session's next read fail, like a device reset.
+func (p *fakePort) unplug() { p.out.CloseWithError(errors.New("device disconnected")) }
+
+// device hands out a new fakePort on every open.
+type device struct {
+	ports chan *fakePort
+}
+
+func newDevice() *device { return &device{ports: make(chan *fakePort, 4)} }
+
+func (d *device) open(string) (Port, error) {
+	p := newFakePort()
+	d.ports <- p
+	return p, nil
+}
+
+func (d *device) next(t *testing.T) *fakePort {
+	t.Helper()
+	select {
+	case p := <-d.ports:
+		return p
+	case <-time.After(2 * time.Second):
+		t.Fatal("session did not open the port")
+		return nil
+	}
+}
+
+func TestClassify(t *testing.T) {
+	tests := []struct{ line, channel, text string }{
+		{"T: fps=60 rssi=-40", ChannelTelemetry, "fps=60 rssi=-40"},
+		{"OK", ChannelReply, "OK"},
+		{"ERR unknown command", ChannelReply, "ERR unknown command"},
+		{"OKAY then", ChannelConsole, "OKAY then"},
+		{"Booting PicoLume", ChannelConsole, "Booting PicoLume"},
+	}
+	for _, tt := range tests {
+		if c, text := Classify(tt.line); c != tt.channel || text != tt.text {
+			t.Errorf("Classify(%q) = %q, %q; want %q, %q", tt.line, c, text, tt.channel, tt.text)
+		}
+	}
+}
+
+func TestCommandAndRouting(t *testing.T) {
+	d := newDevice()
+	lines := make(chan Line, 16)
+	s := Open("COM5", d.open, Options{OnLine: func(l Line) { lines <- l }})
+	defer s.Close()
+	p := d.next(t)
+
+	go func() {
+		sc := bufio.NewScanner(readerOf(p.in))
+		for sc.Scan() {
+			if sc.Text() == "version" {
+				io.WriteString(p.out, "T: fps=60\r\nOK 1.4.0\r\n")
+			}
+		}
+	}()
+
+	var reply string
+	var err error
+	for i := 0; i < 50; i++ { // the port opens in the background
+		if reply, err = s.Command("version", time.Second); !errors.Is(err, ErrNotConnected) {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if err != nil || reply != "1.4.0" {
+		t.Fatalf("Command() = %q, %v; want 1.4.0", reply, err)
+	}
+	l := <-lines
+	if l.Channel != ChannelTelemetry || l.Text != "fps=60" {
+		t.Errorf("line = %+v, want telemetry fps=60", l)
+	}
+}
+
+func TestReconnectsAfterReset(t *testing.T) {
+	d := newDevice()
+	states := make(chan string, 16)
+	s := Open("COM5", d.open, Options{
+		ReconnectDelay: 10 * time.Millisecond,
+		OnState:        func(state string, _ error) { states <- state },
+	})
+	defer s.Close()
+
+	want := func(state string) {
+		t.Helper()
+		select {
+		case got := <-states:
+			if got != state {
+				t.Fatalf("state = %q, want %q", got, state)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("no state change, want %q", state)
+		}
+	}
+	want(StateConnected)
+	d.next(t).unplug()
+	want(StateDisconnected)
+	d.next(t)
+	want(StateConnected)
+
+	s.Close()
+	want(StateClosed)
+	if err := s.Write([]byte("r")); !errors.Is(err, ErrClosed) {
+		t.Errorf("Write() after Close = %v, want ErrClosed", err)
+	}
+}
+
+// readerOf turns written chunks into a stream.
+func readerOf(in chan string) io.Reader {
+	r, w := io.Pipe()
+	go func() {
+		for s := range in {
+			io.WriteString(w, s)
+		}
+	}()
+	return r
+}
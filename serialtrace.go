@@ -0,0 +1,172 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"PicoLume/logger"
+	"PicoLume/settings"
+
+	"go.bug.st/serial"
+)
+
+// ==========================================================
+// SERIAL TRAFFIC TRACE
+// ==========================================================
+
+// maxTraceBytes caps how many bytes of a single read/write are dumped.
+const maxTraceBytes = 64
+
+// serialTraceEnabled turns per-byte serial logging on or off at runtime.
+var serialTraceEnabled atomic.Bool
+
+// serialTraceForced is set by PICOLUME_SERIAL_TRACE=1 and keeps the trace on
+// regardless of settings: the environment takes precedence over the saved
+// choice, which SetSerialTrace still records for later runs.
+var serialTraceForced bool
+
+var serialIOLog = serialLog.Named("io")
+
+func init() {
+	if v := os.Getenv("PICOLUME_SERIAL_TRACE"); v == "1" || strings.EqualFold(v, "true") {
+		serialTraceForced = true
+		serialTraceEnabled.Store(true)
+	}
+}
+
+// SetSerialTrace enables or disables logging of serial bytes sent/received
+// and remembers the choice in settings. Trace lines go to the "serial.io"
+// logger and show up in GetRecentLogs. While PICOLUME_SERIAL_TRACE is set
+// the trace stays on; disabling only takes effect in runs without it.
+func (a *App) SetSerialTrace(enabled bool) {
+	a.mu.Lock()
+	store := a.prefs
+	a.mu.Unlock()
+	if store != nil {
+		if _, err := store.Update(func(s *settings.Settings) { s.Serial.Trace = enabled }); err != nil {
+			logger.Warn("SetSerialTrace: Failed to save setting: %v", err)
+		}
+	}
+	serialTraceEnabled.Store(enabled || serialTraceForced)
+
+	switch {
+	case enabled:
+		logger.Info("SetSerialTrace: Serial traffic trace enabled")
+	case serialTraceForced:
+		logger.Info("SetSerialTrace: Serial traffic trace stays enabled by PICOLUME_SERIAL_TRACE")
+	default:
+		logger.Info("SetSerialTrace: Serial traffic trace disabled")
+	}
+}
+
+// IsSerialTraceEnabled reports whether serial traffic is being logged.
+func (a *App) IsSerialTraceEnabled() bool {
+	return serialTraceEnabled.Load()
+}
+
+// openSerialPort opens a port without tracing. Tests replace it with a fake
+// receiver.
+var openSerialPort = serial.Open
+
+// openSerial opens a port, wrapping it so traffic is logged while the trace
+// is enabled. Use instead of serial.Open for device communication.
+func openSerial(name string, mode *serial.Mode) (serial.Port, error) {
+	start := time.Now()
+	p, err := openSerialPort(name, mode)
+	if serialTraceEnabled.Load() {
+		if err != nil {
+			serialIOLog.Info("%s OPEN failed after %s: %v", name, time.Since(start).Round(time.Millisecond), err)
+		} else {
+			serialIOLog.Info("%s OPEN %d baud", name, mode.BaudRate)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &tracedPort{Port: p, name: name}, nil
+}
+
+// tracedPort logs reads, writes and modem line changes of the wrapped port.
+type tracedPort struct {
+	serial.Port
+	name string
+}
+
+func (t *tracedPort) Write(p []byte) (int, error) {
+	n, err := t.Port.Write(p)
+	if serialTraceEnabled.Load() {
+		t.trace("TX", p[:max(n, 0)], err)
+	}
+	return n, err
+}
+
+func (t *tracedPort) Read(p []byte) (int, error) {
+	n, err := t.Port.Read(p)
+	if serialTraceEnabled.Load() {
+		t.trace("RX", p[:max(n, 0)], err)
+	}
+	return n, err
+}
+
+func (t *tracedPort) SetDTR(dtr bool) error {
+	err := t.Port.SetDTR(dtr)
+	if serialTraceEnabled.Load() {
+		serialIOLog.Info("%s DTR=%v%s", t.name, dtr, traceErr(err))
+	}
+	return err
+}
+
+func (t *tracedPort) SetRTS(rts bool) error {
+	err := t.Port.SetRTS(rts)
+	if serialTraceEnabled.Load() {
+		serialIOLog.Info("%s RTS=%v%s", t.name, rts, traceErr(err))
+	}
+	return err
+}
+
+func (t *tracedPort) Close() error {
+	err := t.Port.Close()
+	if serialTraceEnabled.Load() {
+		serialIOLog.Info("%s CLOSE%s", t.name, traceErr(err))
+	}
+	return err
+}
+
+func (t *tracedPort) trace(dir string, data []byte, err error) {
+	serialIOLog.Info("%s %s %d bytes: %s%s", t.name, dir, len(data), hexDump(data), traceErr(err))
+}
+
+// hexDump renders bytes as "72 0a |r.|", truncated to maxTraceBytes.
+func hexDump(data []byte) string {
+	shown := data
+	if len(shown) > maxTraceBytes {
+		shown = shown[:maxTraceBytes]
+	}
+	var hexPart, asciiPart strings.Builder
+	for i, b := range shown {
+		if i > 0 {
+			hexPart.WriteByte(' ')
+		}
+		fmt.Fprintf(&hexPart, "%02x", b)
+		if b >= 0x20 && b < 0x7f {
+			asciiPart.WriteByte(b)
+		} else {
+			asciiPart.WriteByte('.')
+		}
+	}
+	out := hexPart.String() + " |" + asciiPart.String() + "|"
+	if len(data) > len(shown) {
+		out += fmt.Sprintf(" (+%d more)", len(data)-len(shown))
+	}
+	return out
+}
+
+func traceErr(err error) string {
+	if err == nil {
+		return ""
+	}
+	return " error: " + err.Error()
+}
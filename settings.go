@@ -0,0 +1,114 @@
+package main
+
+import (
+	"path/filepath"
+
+	"PicoLume/i18n"
+	"PicoLume/logger"
+	"PicoLume/settings"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// ==========================================================
+// SETTINGS
+// ==========================================================
+
+type SettingsResponse struct {
+	Settings settings.Settings `json:"settings"`
+	Path     string            `json:"path"`
+	Error    string            `json:"error"`
+}
+
+type TranslationsResponse struct {
+	Language  string       `json:"language"`  // resolved language
+	Languages []string     `json:"languages"` // all available
+	Messages  i18n.Catalog `json:"messages"`  // English source -> translation
+}
+
+// GetTranslations returns the message catalog for lang ("" for the language
+// chosen in settings) so the frontend can share the backend's translations.
+func (a *App) GetTranslations(lang string) TranslationsResponse {
+	if lang == "" {
+		lang = a.currentSettings().Language
+	}
+	resolved, messages := i18n.Default.Catalog(lang)
+	return TranslationsResponse{
+		Language:  resolved,
+		Languages: i18n.Default.Languages(),
+		Messages:  messages,
+	}
+}
+
+// loadSettings opens the settings store in the app data directory, falling
+// back to defaults (and logging why) if the file is unusable.
+func (a *App) loadSettings() {
+	if err := i18n.Default.LoadDir(filepath.Join(appDataDir(), "locales")); err != nil {
+		logger.Warn("Translations: %v", err)
+	}
+
+	store, err := settings.Open(filepath.Join(appDataDir(), settings.FileName))
+	if err != nil {
+		logger.Warn("Settings: %v; using defaults", err)
+	}
+	a.mu.Lock()
+	a.prefs = store
+	a.mu.Unlock()
+	a.applySettings(store.Get())
+}
+
+// currentSettings returns the active settings, or defaults before startup.
+func (a *App) currentSettings() settings.Settings {
+	a.mu.Lock()
+	store := a.prefs
+	a.mu.Unlock()
+	if store == nil {
+		return settings.Defaults()
+	}
+	return store.Get()
+}
+
+// applySettings pushes settings that change backend behavior into effect.
+func (a *App) applySettings(s settings.Settings) {
+	serialTraceEnabled.Store(s.Serial.Trace || serialTraceForced)
+	i18n.Default.SetLanguage(s.Language)
+}
+
+// GetSettings returns the persisted preferences.
+func (a *App) GetSettings() SettingsResponse {
+	a.mu.Lock()
+	store := a.prefs
+	a.mu.Unlock()
+	if store == nil {
+		return SettingsResponse{Settings: settings.Defaults()}
+	}
+	return SettingsResponse{Settings: store.Get(), Path: store.Path()}
+}
+
+// UpdateSettings validates, saves and applies a full settings object, then
+// emits "settings:changed" so other views can refresh.
+func (a *App) UpdateSettings(s settings.Settings) SettingsResponse {
+	defer a.recoverBinding("UpdateSettings")
+
+	a.mu.Lock()
+	store := a.prefs
+	a.mu.Unlock()
+	if store == nil {
+		return SettingsResponse{Settings: settings.Defaults(), Error: "Settings are not loaded yet"}
+	}
+
+	previous := store.Get()
+	if err := store.Set(s); err != nil {
+		return SettingsResponse{Settings: store.Get(), Path: store.Path(), Error: err.Error()}
+	}
+	a.applySettings(s)
+	if s.Window != previous.Window && a.ctx != nil && !a.preview.Enabled {
+		a.placeWindow(s.Window)
+	}
+	logger.Info("UpdateSettings: Saved settings to %s", store.Path())
+
+	if a.ctx != nil {
+		runtime.EventsEmit(a.ctx, "settings:changed", s)
+	}
+	return SettingsResponse{Settings: s, Path: store.Path()}
+}
@@ -0,0 +1,337 @@
+// Package settings persists application preferences as a JSON file in the
+// user's config directory, so choices that affect backend behavior (serial
+// reset tuning, default folders, polling) survive restarts and aren't
+// confined to frontend localStorage.
+package settings
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// FileName is the settings file inside the app data directory.
+const FileName = "settings.json"
+
+// Themes accepted by Settings.Theme.
+const (
+	ThemeSystem = "system"
+	ThemeLight  = "light"
+	ThemeDark   = "dark"
+)
+
+// Settings holds all persisted preferences.
+type Settings struct {
+	// Default folders for file dialogs; empty lets the OS decide.
+	ProjectDir string `json:"projectDir"`
+	ExportDir  string `json:"exportDir"`
+
+	// AutoResetAfterUpload sends the serial reset command after writing
+	// show.bin so the receiver reloads without a manual eject.
+	AutoResetAfterUpload bool `json:"autoResetAfterUpload"`
+
+	// ShowFormatVersion is the show.bin layout for uploads and exports: 0
+	// for the default (3), 2 for receivers on firmware that predates the V3
+	// PropConfig table, 4 for compact prop masks, 5 to add clip fades and
+	// gradients.
+	ShowFormatVersion int `json:"showFormatVersion"`
+
+	// Overlap resolves clips overlapping on one track: "trim" the earlier
+	// clip, "priority" to let later clips cut into earlier ones, "error" to
+	// refuse, or "" (the default) to write both as older versions did.
+	Overlap string `json:"overlap"`
+
+	// TrackConflicts resolves LED tracks playing on the same props at the
+	// same time: "last" lets the later track win, "priority" the track
+	// with the higher priority, "error" refuses, and "" writes both.
+	TrackConflicts string `json:"trackConflicts"`
+
+	// StrictValidation refuses to generate show.bin when clip times or
+	// durations are invalid, instead of clamping them.
+	StrictValidation bool `json:"strictValidation"`
+
+	// BlackoutCue reserves cue Z in every show.bin as an instant blackout,
+	// so there is always an emergency-off path. Off by default: it changes
+	// the cue block, which older receiver firmware may not read.
+	BlackoutCue bool `json:"blackoutCue"`
+
+	// LimitPower dims clips that would draw more than a hardware profile's
+	// power budget when generating show.bin.
+	LimitPower bool `json:"limitPower"`
+
+	// OptimizeShow merges redundant events when generating show.bin, so
+	// long shows take less flash on the receivers.
+	OptimizeShow bool `json:"optimizeShow"`
+
+	// ShowChecksum appends a CRC-32 footer to show.bin so receivers can
+	// reject truncated or corrupt files. Needs firmware that reads it.
+	ShowChecksum bool `json:"showChecksum"`
+
+	// ShowFlashKB is the flash receivers have for show.bin, in KB; the
+	// editor warns while a show is estimated to need more.
+	ShowFlashKB int `json:"showFlashKb"`
+
+	// StatusPollMs is how often the device status is refreshed.
+	StatusPollMs int `json:"statusPollMs"`
+
+	Theme string `json:"theme"`
+
+	// Language selects backend message translations ("de", "fr", ...).
+	// Empty means English.
+	Language string `json:"language"`
+
+	// CheckForUpdates looks for a newer release on startup.
+	CheckForUpdates bool `json:"checkForUpdates"`
+
+	// CrashReportURL receives crash dumps the user chooses to submit.
+	// Empty disables submission.
+	CrashReportURL string `json:"crashReportUrl"`
+
+	// FirmwareIndexURL lists receiver firmware releases. Empty uses the
+	// official index.
+	FirmwareIndexURL string `json:"firmwareIndexUrl"`
+
+	Serial Serial `json:"serial"`
+
+	Window Window `json:"window"`
+
+	Limits Limits `json:"limits"`
+}
+
+// MaxShowFlashKB bounds ShowFlashKB (16 MB, the largest RP2040 flash).
+const MaxShowFlashKB = 16 * 1024
+
+// MaxMonitor bounds Window.Monitor.
+const MaxMonitor = 16
+
+// Window controls where and how the main window opens, for fixed
+// installations that run Studio as a show-control kiosk.
+type Window struct {
+	Monitor     int  `json:"monitor"`     // 1-based display index; 0 lets the OS decide
+	Kiosk       bool `json:"kiosk"`       // fullscreen without window chrome
+	AlwaysOnTop bool `json:"alwaysOnTop"` // stay above other windows
+}
+
+// Serial tunes how Studio talks to receivers over USB serial.
+type Serial struct {
+	BaudRate      int  `json:"baudRate"`
+	ResetAttempts int  `json:"resetAttempts"` // per candidate port
+	ResetDelayMs  int  `json:"resetDelayMs"`  // between attempts
+	Trace         bool `json:"trace"`         // log serial traffic
+}
+
+// Limits caps what loading a .lum project may extract, as protection
+// against zip bombs. Sizes are in MB; crews with long multitrack audio can
+// raise them.
+type Limits struct {
+	ProjectFileMB    int `json:"projectFileMb"`    // the .lum file itself
+	AudioFileMB      int `json:"audioFileMb"`      // each audio entry
+	TotalExtractedMB int `json:"totalExtractedMb"` // all entries together
+	FilesInArchive   int `json:"filesInArchive"`
+
+	// MemoryMB is the budget for large in-memory work (project load,
+	// show.bin generation, audio analysis); jobs that would exceed it fail
+	// with a clear error instead of exhausting the machine.
+	MemoryMB int `json:"memoryMb"`
+}
+
+// MaxLimitMB is the largest accepted size limit (64 GB).
+const MaxLimitMB = 64 * 1024
+
+// Defaults returns the settings used when no file exists.
+func Defaults() Settings {
+	return Settings{
+		AutoResetAfterUpload: true,
+		StatusPollMs:         2000,
+		ShowFlashKB:          1536,
+		Theme:                ThemeSystem,
+		CheckForUpdates:      true,
+		Serial: Serial{
+			BaudRate:      115200,
+			ResetAttempts: 3,
+			ResetDelayMs:  350,
+		},
+		Limits: Limits{
+			ProjectFileMB:    500,
+			AudioFileMB:      200,
+			TotalExtractedMB: 1024,
+			FilesInArchive:   100,
+			MemoryMB:         2048,
+		},
+	}
+}
+
+// Validate checks that values are in range.
+func (s Settings) Validate() error {
+	if s.StatusPollMs < 250 || s.StatusPollMs > 60000 {
+		return fmt.Errorf("statusPollMs must be between 250 and 60000")
+	}
+	switch s.Theme {
+	case ThemeSystem, ThemeLight, ThemeDark:
+	default:
+		return fmt.Errorf("unknown theme %q", s.Theme)
+	}
+	if s.Serial.BaudRate < 300 || s.Serial.BaudRate > 4000000 {
+		return fmt.Errorf("serial baudRate out of range")
+	}
+	if s.Serial.ResetAttempts < 1 || s.Serial.ResetAttempts > 10 {
+		return fmt.Errorf("serial resetAttempts must be between 1 and 10")
+	}
+	if s.Serial.ResetDelayMs < 0 || s.Serial.ResetDelayMs > 5000 {
+		return fmt.Errorf("serial resetDelayMs must be between 0 and 5000")
+	}
+	for _, mb := range []int{s.Limits.ProjectFileMB, s.Limits.AudioFileMB, s.Limits.TotalExtractedMB} {
+		if mb < 1 || mb > MaxLimitMB {
+			return fmt.Errorf("size limits must be between 1 and %d MB", MaxLimitMB)
+		}
+	}
+	switch s.ShowFormatVersion {
+	case 0, 2, 3, 4, 5:
+	default:
+		return fmt.Errorf("showFormatVersion must be 0, 2, 3, 4 or 5")
+	}
+	switch s.Overlap {
+	case "", "trim", "priority", "error":
+	default:
+		return fmt.Errorf("unknown overlap policy %q", s.Overlap)
+	}
+	switch s.TrackConflicts {
+	case "", "last", "priority", "error":
+	default:
+		return fmt.Errorf("unknown track conflict policy %q", s.TrackConflicts)
+	}
+	if s.ShowFlashKB < 64 || s.ShowFlashKB > MaxShowFlashKB {
+		return fmt.Errorf("showFlashKb must be between 64 and %d", MaxShowFlashKB)
+	}
+	if s.Limits.MemoryMB < 64 || s.Limits.MemoryMB > MaxLimitMB {
+		return fmt.Errorf("memoryMb must be between 64 and %d", MaxLimitMB)
+	}
+	if s.Limits.FilesInArchive < 1 || s.Limits.FilesInArchive > 10000 {
+		return fmt.Errorf("filesInArchive must be between 1 and 10000")
+	}
+	if s.Window.Monitor < 0 || s.Window.Monitor > MaxMonitor {
+		return fmt.Errorf("window monitor must be between 0 and %d", MaxMonitor)
+	}
+	if s.CrashReportURL != "" && !isHTTPURL(s.CrashReportURL) {
+		return fmt.Errorf("crashReportUrl must be an http(s) URL")
+	}
+	if s.FirmwareIndexURL != "" && !isHTTPURL(s.FirmwareIndexURL) {
+		return fmt.Errorf("firmwareIndexUrl must be an http(s) URL")
+	}
+	for _, dir := range []string{s.ProjectDir, s.ExportDir} {
+		if dir != "" && !filepath.IsAbs(dir) {
+			return fmt.Errorf("folder %q must be an absolute path", dir)
+		}
+	}
+	return nil
+}
+
+func isHTTPURL(s string) bool {
+	u, err := url.Parse(s)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// Store loads and saves Settings at a fixed path. It is safe for concurrent
+// use.
+type Store struct {
+	mu      sync.Mutex
+	path    string
+	current Settings
+}
+
+// Open loads the settings file at path. A missing file yields defaults; an
+// unreadable or invalid one yields defaults plus the error, so the app can
+// still start.
+func Open(path string) (*Store, error) {
+	st := &Store{path: path, current: Defaults()}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return st, nil
+	}
+	if err != nil {
+		return st, fmt.Errorf("failed to read settings: %w", err)
+	}
+
+	// Decode over the defaults so fields added in newer versions keep
+	// their default values.
+	loaded := Defaults()
+	if err := json.Unmarshal(data, &loaded); err != nil {
+		return st, fmt.Errorf("invalid settings file: %w", err)
+	}
+	if err := loaded.Validate(); err != nil {
+		return st, fmt.Errorf("invalid settings file: %w", err)
+	}
+	st.current = loaded
+	return st, nil
+}
+
+// Path returns the settings file location.
+func (st *Store) Path() string {
+	return st.path
+}
+
+// Get returns a copy of the current settings.
+func (st *Store) Get() Settings {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	return st.current
+}
+
+// Set validates and persists s, replacing the current settings.
+func (st *Store) Set(s Settings) error {
+	if err := s.Validate(); err != nil {
+		return err
+	}
+
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	if err := writeFile(st.path, s); err != nil {
+		return err
+	}
+	st.current = s
+	return nil
+}
+
+// Update applies fn to a copy of the current settings, then validates and
+// persists the result like Set. The read, change and write happen under one
+// lock, so concurrent updates of different fields do not undo each other.
+func (st *Store) Update(fn func(*Settings)) (Settings, error) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	s := st.current
+	fn(&s)
+	if err := s.Validate(); err != nil {
+		return st.current, err
+	}
+	if err := writeFile(st.path, s); err != nil {
+		return st.current, err
+	}
+	st.current = s
+	return s, nil
+}
+
+// writeFile writes via a temp file and rename so a crash mid-write never
+// leaves a truncated settings file.
+func writeFile(path string, s Settings) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create settings directory: %w", err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to write settings: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write settings: %w", err)
+	}
+	return nil
+}
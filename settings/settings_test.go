@@ -0,0 +1,104 @@
+package settings
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+func TestOpenMissingFileUsesDefaults(t *testing.T) {
+	st, err := Open(filepath.Join(t.TempDir(), FileName))
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	if st.Get() != Defaults() {
+		t.Errorf("Get() = %+v, want defaults", st.Get())
+	}
+}
+
+func TestSetPersists(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", FileName)
+	st, _ := Open(path)
+
+	s := Defaults()
+	s.Theme = ThemeDark
+	s.Serial.ResetAttempts = 5
+	if err := st.Set(s); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+
+	reopened, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	if reopened.Get() != s {
+		t.Errorf("reopened settings = %+v, want %+v", reopened.Get(), s)
+	}
+}
+
+func TestSetRejectsInvalid(t *testing.T) {
+	st, _ := Open(filepath.Join(t.TempDir(), FileName))
+	s := Defaults()
+	s.StatusPollMs = 1
+	if err := st.Set(s); err == nil {
+		t.Error("Set() with statusPollMs=1 should fail")
+	}
+	if st.Get() != Defaults() {
+		t.Error("rejected Set() changed the current settings")
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), FileName)
+	st, _ := Open(path)
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		if _, err := st.Update(func(s *Settings) { s.Serial.Trace = true }); err != nil {
+			t.Error(err)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		if _, err := st.Update(func(s *Settings) { s.Theme = ThemeDark }); err != nil {
+			t.Error(err)
+		}
+	}()
+	wg.Wait()
+	if s := st.Get(); !s.Serial.Trace || s.Theme != ThemeDark {
+		t.Errorf("concurrent updates lost a change: %+v", s)
+	}
+
+	got, err := st.Update(func(s *Settings) { s.StatusPollMs = 1 })
+	if err == nil {
+		t.Error("Update() to statusPollMs=1 should fail")
+	}
+	if got != st.Get() || got.StatusPollMs == 1 {
+		t.Errorf("rejected Update() returned %+v", got)
+	}
+	reopened, _ := Open(path)
+	if reopened.Get() != st.Get() {
+		t.Errorf("reopened settings = %+v, want %+v", reopened.Get(), st.Get())
+	}
+}
+
+func TestOpenKeepsDefaultsForMissingFields(t *testing.T) {
+	path := filepath.Join(t.TempDir(), FileName)
+	if err := os.WriteFile(path, []byte(`{"theme":"light"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	st, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	got := st.Get()
+	if got.Theme != ThemeLight {
+		t.Errorf("Theme = %q, want %q", got.Theme, ThemeLight)
+	}
+	if got.Serial != Defaults().Serial {
+		t.Errorf("Serial = %+v, want defaults", got.Serial)
+	}
+}
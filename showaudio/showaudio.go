@@ -0,0 +1,171 @@
+// Package showaudio renders a project's audio tracks into one mono WAV for
+// receivers that play the soundtrack themselves from SD card or flash, so
+// they stay in step with the show without a cable from the sound desk.
+//
+// WAV sources are decoded natively. Other formats (MP3, OGG, ...) are
+// transcoded with ffmpeg when it is on the PATH.
+package showaudio
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"os/exec"
+	"strconv"
+
+	"PicoLume/analysis"
+	"PicoLume/bingen"
+	"PicoLume/ltc"
+)
+
+// SampleRate is the rate receivers play at: enough for speech and music on
+// a small speaker, at a quarter of the card space of CD audio.
+const SampleRate = 22050
+
+// DeviceFile is where the rendered audio goes on a receiver volume.
+const DeviceFile = "audio/show.wav"
+
+// ErrNeedsFFmpeg is returned for compressed sources when ffmpeg is not
+// installed.
+var ErrNeedsFFmpeg = errors.New("ffmpeg is required to convert compressed audio; install it or use WAV files")
+
+// Clip is one audio clip placed on the show timeline.
+type Clip struct {
+	Source     string  // local audio file
+	StartMs    float64 // show time the clip starts
+	DurationMs float64
+	Volume     float64 // 0..1
+}
+
+// Clips lists the audio clips in p. files maps buffer IDs to local files;
+// a clip whose audio is not loaded is an error.
+func Clips(p *bingen.Project, files map[string]string) ([]Clip, error) {
+	var out []Clip
+	for _, track := range p.Tracks {
+		if track.Type != "audio" {
+			continue
+		}
+		for _, c := range track.Clips {
+			if c.BufferId == "" || c.Duration <= 0 {
+				continue
+			}
+			file := files[c.BufferId]
+			if file == "" {
+				return nil, fmt.Errorf("audio %q is not part of the loaded project", c.BufferId)
+			}
+			volume := 1.0
+			if c.Props.Volume != nil {
+				volume = math.Max(0, math.Min(1, *c.Props.Volume))
+			}
+			out = append(out, Clip{Source: file, StartMs: c.StartTime, DurationMs: c.Duration, Volume: volume})
+		}
+	}
+	return out, nil
+}
+
+// Decode reads path as mono audio at its own sample rate.
+func Decode(ctx context.Context, path string) (*analysis.Audio, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	audio, err := analysis.DecodeWAV(bufio.NewReader(f))
+	f.Close()
+	if !errors.Is(err, analysis.ErrUnsupported) {
+		return audio, err
+	}
+	return transcode(ctx, path)
+}
+
+// transcode has ffmpeg convert path to mono 16-bit WAV at SampleRate.
+func transcode(ctx context.Context, path string) (*analysis.Audio, error) {
+	ffmpeg, err := exec.LookPath("ffmpeg")
+	if err != nil {
+		return nil, ErrNeedsFFmpeg
+	}
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, ffmpeg, "-v", "error", "-i", path,
+		"-ac", "1", "-ar", strconv.Itoa(SampleRate), "-f", "wav", "-")
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ffmpeg could not convert %s: %s", path, bytes.TrimSpace(stderr.Bytes()))
+	}
+	return analysis.DecodeWAV(bytes.NewReader(out))
+}
+
+// Render mixes clips into mono 16-bit samples at SampleRate starting at
+// show time 0. Overlapping clips are summed and limited to full scale.
+func Render(ctx context.Context, clips []Clip) ([]int16, error) {
+	var endMs float64
+	for _, c := range clips {
+		endMs = math.Max(endMs, c.StartMs+c.DurationMs)
+	}
+	mix := make([]float32, int(endMs*SampleRate/1000))
+
+	decoded := make(map[string][]float32)
+	for _, c := range clips {
+		src, ok := decoded[c.Source]
+		if !ok {
+			audio, err := Decode(ctx, c.Source)
+			if err != nil {
+				return nil, err
+			}
+			src = Resample(audio.Mono, audio.SampleRate, SampleRate)
+			decoded[c.Source] = src
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		start := int(math.Max(0, c.StartMs) * SampleRate / 1000)
+		n := min(int(c.DurationMs*SampleRate/1000), len(src), len(mix)-start)
+		vol := float32(c.Volume)
+		for i := 0; i < n; i++ {
+			mix[start+i] += src[i] * vol
+		}
+	}
+
+	out := make([]int16, len(mix))
+	for i, v := range mix {
+		out[i] = int16(math.Round(float64(max(-1, min(1, v))) * math.MaxInt16))
+	}
+	return out, nil
+}
+
+// RenderWAV renders clips and wraps them in a WAV file.
+func RenderWAV(ctx context.Context, clips []Clip) ([]byte, error) {
+	samples, err := Render(ctx, clips)
+	if err != nil {
+		return nil, err
+	}
+	return ltc.WAV(samples, SampleRate), nil
+}
+
+// Resample converts mono samples between rates by linear interpolation,
+// which is transparent enough for the receivers' speakers.
+func Resample(in []float32, from, to int) []float32 {
+	if from == to || from <= 0 || len(in) == 0 {
+		return in
+	}
+	out := make([]float32, int(int64(len(in))*int64(to)/int64(from)))
+	step := float64(from) / float64(to)
+	for i := range out {
+		pos := float64(i) * step
+		j := int(pos)
+		if j+1 >= len(in) {
+			out[i] = in[len(in)-1]
+			continue
+		}
+		frac := float32(pos - float64(j))
+		out[i] = in[j] + (in[j+1]-in[j])*frac
+	}
+	return out
+}
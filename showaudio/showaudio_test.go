@@ -0,0 +1,100 @@
+package showaudio
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"PicoLume/bingen"
+	"PicoLume/ltc"
+)
+
+// writeWAV writes a constant-level mono WAV of the given length.
+func writeWAV(t *testing.T, level int16, rate, n int) string {
+	t.Helper()
+	samples := make([]int16, n)
+	for i := range samples {
+		samples[i] = level
+	}
+	path := filepath.Join(t.TempDir(), "a.wav")
+	if err := os.WriteFile(path, ltc.WAV(samples, rate), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestResample(t *testing.T) {
+	out := Resample([]float32{0, 1, 0, -1}, 44100, 22050)
+	if len(out) != 2 || out[0] != 0 || out[1] != 0 {
+		t.Errorf("Resample(44100->22050) = %v, want [0 0]", out)
+	}
+	out = Resample([]float32{0, 1}, 11025, 22050)
+	if len(out) != 4 || out[1] != 0.5 || out[2] != 1 {
+		t.Errorf("Resample(11025->22050) = %v, want [0 0.5 1 1]", out)
+	}
+}
+
+func TestRenderPlacesAndMixesClips(t *testing.T) {
+	// One second of quarter-scale audio at 44.1 kHz.
+	src := writeWAV(t, 8192, 44100, 44100)
+	clips := []Clip{
+		{Source: src, StartMs: 0, DurationMs: 500, Volume: 1},
+		{Source: src, StartMs: 250, DurationMs: 1500, Volume: 0.5},
+	}
+	out, err := Render(context.Background(), clips)
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	if want := SampleRate * 1750 / 1000; len(out) != want {
+		t.Fatalf("len = %d, want %d", len(out), want)
+	}
+	at := func(ms int) int16 { return out[ms*SampleRate/1000] }
+	if got := at(100); got < 8180 || got > 8200 {
+		t.Errorf("sample at 100ms = %d, want ~8192", got)
+	}
+	if got := at(400); got < 12270 || got > 12300 {
+		t.Errorf("sample at 400ms = %d, want ~12288 (both clips)", got)
+	}
+	if got := at(1000); got < 4090 || got > 4100 {
+		t.Errorf("sample at 1000ms = %d, want ~4096 (second clip only)", got)
+	}
+	// The source is one second long, so the second clip ends early.
+	if got := at(1500); got != 0 {
+		t.Errorf("sample at 1500ms = %d, want 0 past the source's end", got)
+	}
+}
+
+func TestClips(t *testing.T) {
+	half := 0.5
+	p := &bingen.Project{Tracks: []bingen.Track{
+		{Type: "led", Clips: []bingen.Clip{{StartTime: 0, Duration: 1000, Type: "solid"}}},
+		{Type: "audio", Clips: []bingen.Clip{
+			{StartTime: 100, Duration: 2000, BufferId: "song", Props: bingen.ClipProps{Volume: &half}},
+			{StartTime: 0, Duration: 0, BufferId: "song"},
+		}},
+	}}
+	clips, err := Clips(p, map[string]string{"song": "/tmp/song.wav"})
+	if err != nil {
+		t.Fatalf("Clips() error = %v", err)
+	}
+	want := Clip{Source: "/tmp/song.wav", StartMs: 100, DurationMs: 2000, Volume: 0.5}
+	if len(clips) != 1 || clips[0] != want {
+		t.Errorf("Clips() = %+v, want [%+v]", clips, want)
+	}
+	if _, err := Clips(p, nil); err == nil {
+		t.Error("Clips() with missing audio: expected error")
+	}
+}
+
+func TestDecodeCompressedNeedsFFmpeg(t *testing.T) {
+	t.Setenv("PATH", t.TempDir())
+	path := filepath.Join(t.TempDir(), "a.mp3")
+	if err := os.WriteFile(path, []byte("ID3\x03\x00"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Decode(context.Background(), path); !errors.Is(err, ErrNeedsFFmpeg) {
+		t.Errorf("Decode(mp3) error = %v, want ErrNeedsFFmpeg", err)
+	}
+}
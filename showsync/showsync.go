@@ -0,0 +1,303 @@
+// Package showsync shares playback position over the LAN. A master
+// broadcasts its transport state as small UDP datagrams; followers (other
+// Studio instances, or future network receivers) apply it so shows can be
+// rehearsed across rooms in lockstep.
+package showsync
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+)
+
+const (
+	// ProtocolVersion is carried in every packet; followers drop others.
+	ProtocolVersion = 1
+
+	// DefaultPort is the UDP port used when none is configured.
+	DefaultPort = 16760
+
+	// BroadcastInterval is how often the master repeats its state even
+	// when nothing changed, so late joiners and lost packets recover.
+	BroadcastInterval = 100 * time.Millisecond
+
+	maxPacketSize = 1024
+
+	// Read errors other than the socket closing are retried after a delay
+	// that doubles from minReadBackoff up to maxReadBackoff, so a broken
+	// socket does not spin the follower.
+	minReadBackoff = 10 * time.Millisecond
+	maxReadBackoff = time.Second
+)
+
+// Transport states.
+const (
+	StateStopped = "stopped"
+	StatePaused  = "paused"
+	StatePlaying = "playing"
+)
+
+// State is the master's transport state.
+type State struct {
+	Transport  string `json:"state"`
+	PositionMs int64  `json:"posMs"`
+	Cue        string `json:"cue,omitempty"`
+	Show       string `json:"show,omitempty"` // identifies the loaded show
+}
+
+// Packet is the on-the-wire format.
+type Packet struct {
+	Version  int    `json:"v"`
+	Instance string `json:"id"`
+	Seq      uint64 `json:"seq"`
+	SentAt   int64  `json:"sentAt"` // master wall clock, unix ms
+	State
+}
+
+// Extrapolate returns where a playing master is now, accounting for the time
+// since the packet was sent (assuming reasonably synced clocks on the LAN).
+func (p Packet) Extrapolate(now time.Time) int64 {
+	if p.Transport != StatePlaying {
+		return p.PositionMs
+	}
+	elapsed := now.UnixMilli() - p.SentAt
+	if elapsed < 0 || elapsed > 2000 {
+		elapsed = 0
+	}
+	return p.PositionMs + elapsed
+}
+
+func newInstanceID() string {
+	b := make([]byte, 6)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%x", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b)
+}
+
+// Master periodically broadcasts the current State.
+type Master struct {
+	conn     *net.UDPConn
+	target   *net.UDPAddr
+	instance string
+
+	mu        sync.Mutex
+	state     State
+	updatedAt time.Time
+	seq       uint64
+	changed   chan struct{}
+	done      chan struct{}
+	wg        sync.WaitGroup
+}
+
+// StartMaster begins broadcasting to the given port on the IPv4 broadcast
+// address.
+func StartMaster(port int) (*Master, error) {
+	return StartMasterTo(&net.UDPAddr{IP: net.IPv4bcast, Port: port})
+}
+
+// StartMasterTo sends to a single address instead of broadcasting, e.g. a
+// follower on the loopback interface.
+func StartMasterTo(target *net.UDPAddr) (*Master, error) {
+	if target == nil || target.Port < 1 || target.Port > 65535 {
+		return nil, errors.New("invalid port")
+	}
+	conn, err := net.ListenUDP("udp4", &net.UDPAddr{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open sync socket: %w", err)
+	}
+	m := &Master{
+		conn:     conn,
+		target:   target,
+		instance: newInstanceID(),
+		state:    State{Transport: StateStopped},
+		changed:  make(chan struct{}, 1),
+		done:     make(chan struct{}),
+	}
+	m.wg.Add(1)
+	go m.loop()
+	return m, nil
+}
+
+// Instance is this master's random identifier.
+func (m *Master) Instance() string {
+	return m.instance
+}
+
+// Update replaces the broadcast state and sends it immediately. While
+// playing, later broadcasts advance the position from this update, so the
+// caller only needs to report transport changes and seeks.
+func (m *Master) Update(s State) {
+	m.mu.Lock()
+	m.state = s
+	m.updatedAt = time.Now()
+	m.mu.Unlock()
+	select {
+	case m.changed <- struct{}{}:
+	default:
+	}
+}
+
+// Close stops broadcasting.
+func (m *Master) Close() error {
+	select {
+	case <-m.done:
+		return nil
+	default:
+	}
+	close(m.done)
+	m.wg.Wait()
+	return m.conn.Close()
+}
+
+func (m *Master) loop() {
+	defer m.wg.Done()
+	ticker := time.NewTicker(BroadcastInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-m.done:
+			return
+		case <-ticker.C:
+		case <-m.changed:
+		}
+		m.send()
+	}
+}
+
+func (m *Master) send() {
+	now := time.Now()
+	m.mu.Lock()
+	m.seq++
+	pkt := Packet{
+		Version:  ProtocolVersion,
+		Instance: m.instance,
+		Seq:      m.seq,
+		SentAt:   now.UnixMilli(),
+		State:    m.state,
+	}
+	if pkt.Transport == StatePlaying && !m.updatedAt.IsZero() {
+		pkt.PositionMs += now.Sub(m.updatedAt).Milliseconds()
+	}
+	m.mu.Unlock()
+
+	data, err := json.Marshal(pkt)
+	if err != nil {
+		return
+	}
+	// Broadcast failures (no network, firewall) are transient; the next
+	// tick retries.
+	_, _ = m.conn.WriteToUDP(data, m.target)
+}
+
+// Follower listens for master packets and hands each newer one to a
+// callback.
+type Follower struct {
+	conn   *net.UDPConn
+	ignore string
+	onPkt  func(Packet)
+	done   chan struct{}
+	wg     sync.WaitGroup
+
+	mu       sync.Mutex
+	lastSeq  map[string]uint64
+	lastSeen time.Time
+}
+
+// StartFollower listens on port. Packets from the ignore instance (normally
+// a master in this same process) are dropped.
+func StartFollower(port int, ignore string, onPacket func(Packet)) (*Follower, error) {
+	if onPacket == nil {
+		return nil, errors.New("callback cannot be nil")
+	}
+	conn, err := net.ListenUDP("udp4", &net.UDPAddr{Port: port})
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen for sync on port %d: %w", port, err)
+	}
+	f := &Follower{
+		conn:    conn,
+		ignore:  ignore,
+		onPkt:   onPacket,
+		done:    make(chan struct{}),
+		lastSeq: make(map[string]uint64),
+	}
+	f.wg.Add(1)
+	go f.loop()
+	return f, nil
+}
+
+// LastSeen reports when the last valid packet arrived.
+func (f *Follower) LastSeen() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.lastSeen
+}
+
+// Close stops listening.
+func (f *Follower) Close() error {
+	select {
+	case <-f.done:
+		return nil
+	default:
+	}
+	close(f.done)
+	err := f.conn.Close()
+	f.wg.Wait()
+	return err
+}
+
+func (f *Follower) loop() {
+	defer f.wg.Done()
+	buf := make([]byte, maxPacketSize)
+	var backoff time.Duration
+	for {
+		n, _, err := f.conn.ReadFromUDP(buf)
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return
+			}
+			backoff = nextBackoff(backoff)
+			select {
+			case <-f.done:
+				return
+			case <-time.After(backoff):
+			}
+			continue
+		}
+		backoff = 0
+
+		var pkt Packet
+		if json.Unmarshal(buf[:n], &pkt) != nil || pkt.Version != ProtocolVersion || pkt.Instance == "" {
+			continue
+		}
+		if pkt.Instance == f.ignore {
+			continue
+		}
+
+		f.mu.Lock()
+		// Drop reordered/duplicate datagrams; a restarted master gets a new
+		// instance ID, so its sequence starting over is not a problem.
+		if pkt.Seq <= f.lastSeq[pkt.Instance] {
+			f.mu.Unlock()
+			continue
+		}
+		f.lastSeq[pkt.Instance] = pkt.Seq
+		f.lastSeen = time.Now()
+		f.mu.Unlock()
+
+		f.onPkt(pkt)
+	}
+}
+
+// nextBackoff is the delay after a read error that followed one of d.
+func nextBackoff(d time.Duration) time.Duration {
+	if d < minReadBackoff {
+		return minReadBackoff
+	}
+	return min(2*d, maxReadBackoff)
+}
@@ -0,0 +1,144 @@
+package showsync
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+func TestExtrapolate(t *testing.T) {
+	now := time.UnixMilli(10_000)
+	tests := []struct {
+		name      string
+		transport string
+		sentAt    int64
+		want      int64
+	}{
+		{"playing", StatePlaying, 9_750, 5_250},
+		{"paused", StatePaused, 9_750, 5_000},
+		{"stopped", StateStopped, 9_750, 5_000},
+		{"clock ahead", StatePlaying, 10_500, 5_000},
+		{"stale", StatePlaying, 7_000, 5_000},
+	}
+	for _, tt := range tests {
+		p := Packet{SentAt: tt.sentAt, State: State{Transport: tt.transport, PositionMs: 5_000}}
+		if got := p.Extrapolate(now); got != tt.want {
+			t.Errorf("%s: Extrapolate() = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestNextBackoff(t *testing.T) {
+	tests := []struct {
+		d, want time.Duration
+	}{
+		{0, minReadBackoff},
+		{minReadBackoff, 2 * minReadBackoff},
+		{400 * time.Millisecond, 800 * time.Millisecond},
+		{800 * time.Millisecond, maxReadBackoff},
+		{maxReadBackoff, maxReadBackoff},
+	}
+	for _, tt := range tests {
+		if got := nextBackoff(tt.d); got != tt.want {
+			t.Errorf("nextBackoff(%v) = %v, want %v", tt.d, got, tt.want)
+		}
+	}
+}
+
+func TestStartErrors(t *testing.T) {
+	for _, addr := range []*net.UDPAddr{nil, {Port: 0}, {Port: 70000}} {
+		if m, err := StartMasterTo(addr); err == nil {
+			m.Close()
+			t.Errorf("StartMasterTo(%v) succeeded", addr)
+		}
+	}
+	if _, err := StartFollower(0, "", nil); err == nil {
+		t.Error("StartFollower() without a callback succeeded")
+	}
+}
+
+func TestMasterToFollower(t *testing.T) {
+	pkts := make(chan Packet, 16)
+	f, err := StartFollower(0, "", func(p Packet) { pkts <- p })
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	port := f.conn.LocalAddr().(*net.UDPAddr).Port
+
+	m, err := StartMasterTo(&net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: port})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+	m.Update(State{Transport: StatePaused, PositionMs: 1234, Cue: "B"})
+
+	deadline := time.After(2 * time.Second)
+	var last uint64
+	for {
+		select {
+		case p := <-pkts:
+			if p.Instance != m.Instance() || p.Version != ProtocolVersion {
+				t.Fatalf("packet %+v from an unknown master", p)
+			}
+			if p.Seq <= last {
+				t.Fatalf("sequence %d after %d", p.Seq, last)
+			}
+			last = p.Seq
+			if p.State != (State{Transport: StatePaused, PositionMs: 1234, Cue: "B"}) {
+				continue
+			}
+			if f.LastSeen().IsZero() {
+				t.Error("LastSeen() is zero after a packet")
+			}
+			return
+		case <-deadline:
+			t.Fatal("follower did not see the update")
+		}
+	}
+}
+
+func TestFollowerDrops(t *testing.T) {
+	pkts := make(chan Packet, 16)
+	f, err := StartFollower(0, "self", func(p Packet) { pkts <- p })
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	conn, err := net.DialUDP("udp4", nil, f.conn.LocalAddr().(*net.UDPAddr))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	for _, datagram := range []string{
+		`not json`,
+		`{"v": 2, "id": "other", "seq": 1}`,
+		`{"v": 1, "id": "", "seq": 1}`,
+		`{"v": 1, "id": "self", "seq": 1}`,
+		`{"v": 1, "id": "other", "seq": 5, "posMs": 1}`,
+		`{"v": 1, "id": "other", "seq": 5, "posMs": 2}`,
+		`{"v": 1, "id": "other", "seq": 4, "posMs": 3}`,
+		`{"v": 1, "id": "other", "seq": 6, "posMs": 4}`,
+	} {
+		if _, err := conn.Write([]byte(datagram)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, want := range []int64{1, 4} {
+		select {
+		case p := <-pkts:
+			if p.PositionMs != want {
+				t.Errorf("got packet at %d ms, want %d", p.PositionMs, want)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("no packet at %d ms", want)
+		}
+	}
+	select {
+	case p := <-pkts:
+		t.Errorf("unexpected packet %+v", p)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
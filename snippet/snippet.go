@@ -0,0 +1,140 @@
+// Package snippet serializes selected clips and tracks to a namespaced JSON
+// document for the system clipboard, so sequences can be copied between two
+// running Studio instances or between projects. Entries are kept as raw JSON
+// so editor-only fields survive the round trip; only the fields the show
+// format depends on are validated.
+package snippet
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+
+	"PicoLume/bingen"
+)
+
+const (
+	// Format marks clipboard text as a PicoLume snippet.
+	Format = "picolume/snippet"
+
+	// Version is bumped when the layout changes incompatibly.
+	Version = 1
+
+	// MaxSize bounds clipboard text accepted by Decode.
+	MaxSize = 8 << 20
+)
+
+// ErrNotSnippet means the clipboard holds something other than a snippet
+// (plain text, another app's JSON), which callers usually ignore quietly.
+var ErrNotSnippet = errors.New("clipboard does not contain a PicoLume snippet")
+
+// Snippet is the clipboard document.
+type Snippet struct {
+	Format  string `json:"format"`
+	Version int    `json:"version"`
+	App     string `json:"app,omitempty"` // Studio version that copied it
+
+	// OriginMs is the earliest clip start, so a paste can be offset to the
+	// playhead.
+	OriginMs float64 `json:"originMs"`
+
+	Tracks []json.RawMessage `json:"tracks,omitempty"`
+	Clips  []json.RawMessage `json:"clips,omitempty"`
+}
+
+// New validates a selection and wraps it in a Snippet.
+func New(tracks, clips []json.RawMessage, app string) (*Snippet, error) {
+	s := &Snippet{
+		Format:  Format,
+		Version: Version,
+		App:     app,
+		Tracks:  tracks,
+		Clips:   clips,
+	}
+	if err := s.validate(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Encode returns the clipboard text for s.
+func (s *Snippet) Encode() ([]byte, error) {
+	return json.Marshal(s)
+}
+
+// Decode parses clipboard text, returning ErrNotSnippet for foreign content.
+func Decode(data []byte) (*Snippet, error) {
+	if len(data) > MaxSize {
+		return nil, fmt.Errorf("snippet is larger than %d MB", MaxSize>>20)
+	}
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 || data[0] != '{' {
+		return nil, ErrNotSnippet
+	}
+	var s Snippet
+	if err := json.Unmarshal(data, &s); err != nil || s.Format != Format {
+		return nil, ErrNotSnippet
+	}
+	if s.Version != Version {
+		return nil, fmt.Errorf("snippet version %d is not supported (expected %d); update Studio", s.Version, Version)
+	}
+	if err := s.validate(); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// validate checks every entry and recomputes OriginMs.
+func (s *Snippet) validate() error {
+	if len(s.Tracks) == 0 && len(s.Clips) == 0 {
+		return errors.New("snippet is empty")
+	}
+
+	origin := math.Inf(1)
+	for i, raw := range s.Clips {
+		var c bingen.Clip
+		if err := json.Unmarshal(raw, &c); err != nil {
+			return fmt.Errorf("clip %d: %w", i+1, err)
+		}
+		if err := validateClip(c); err != nil {
+			return fmt.Errorf("clip %d: %w", i+1, err)
+		}
+		origin = math.Min(origin, c.StartTime)
+	}
+	for i, raw := range s.Tracks {
+		var t bingen.Track
+		if err := json.Unmarshal(raw, &t); err != nil {
+			return fmt.Errorf("track %d: %w", i+1, err)
+		}
+		if t.Type == "" {
+			return fmt.Errorf("track %d: missing type", i+1)
+		}
+		for j, c := range t.Clips {
+			if err := validateClip(c); err != nil {
+				return fmt.Errorf("track %d clip %d: %w", i+1, j+1, err)
+			}
+			origin = math.Min(origin, c.StartTime)
+		}
+	}
+
+	if math.IsInf(origin, 1) {
+		origin = 0
+	}
+	s.OriginMs = origin
+	return nil
+}
+
+func validateClip(c bingen.Clip) error {
+	if c.Type == "" {
+		return errors.New("missing type")
+	}
+	if c.StartTime < 0 || math.IsNaN(c.StartTime) {
+		return fmt.Errorf("invalid start time %v", c.StartTime)
+	}
+	if c.Duration <= 0 || math.IsNaN(c.Duration) {
+		return fmt.Errorf("invalid duration %v", c.Duration)
+	}
+	return nil
+}
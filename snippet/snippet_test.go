@@ -0,0 +1,53 @@
+package snippet
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func raw(s string) json.RawMessage { return json.RawMessage(s) }
+
+func TestRoundTripKeepsEditorFields(t *testing.T) {
+	clips := []json.RawMessage{
+		raw(`{"id":"c2","startTime":3000,"duration":500,"type":"solid","props":{"color":"#ff0000"}}`),
+		raw(`{"id":"c1","startTime":1500,"duration":500,"type":"flash","props":{}}`),
+	}
+	s, err := New(nil, clips, "0.2.4")
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	data, err := s.Encode()
+	if err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+
+	got, err := Decode(data)
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	if got.OriginMs != 1500 {
+		t.Errorf("OriginMs = %v, want 1500", got.OriginMs)
+	}
+	var first map[string]any
+	json.Unmarshal(got.Clips[0], &first)
+	if first["id"] != "c2" {
+		t.Errorf("clip id = %v, want c2 (editor fields must survive)", first["id"])
+	}
+}
+
+func TestDecodeRejects(t *testing.T) {
+	if _, err := Decode([]byte("hello")); !errors.Is(err, ErrNotSnippet) {
+		t.Errorf("plain text: err = %v, want ErrNotSnippet", err)
+	}
+	if _, err := Decode([]byte(`{"format":"other"}`)); !errors.Is(err, ErrNotSnippet) {
+		t.Errorf("foreign JSON: err = %v, want ErrNotSnippet", err)
+	}
+	if _, err := Decode([]byte(`{"format":"picolume/snippet","version":99,"clips":[]}`)); err == nil || errors.Is(err, ErrNotSnippet) {
+		t.Errorf("future version: err = %v, want version error", err)
+	}
+	bad := `{"format":"picolume/snippet","version":1,"tracks":[{"type":"led","clips":[{"startTime":0,"duration":0,"type":"solid"}]}]}`
+	if _, err := Decode([]byte(bad)); err == nil {
+		t.Error("zero-duration clip should be rejected")
+	}
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"path/filepath"
+	goruntime "runtime"
+	"time"
+
+	"go.bug.st/serial/enumerator"
+)
+
+// ==========================================================
+// SYSTEM INFO
+// ==========================================================
+
+// USBSerialDevice is a USB serial port as reported by the OS.
+type USBSerialDevice struct {
+	Port         string `json:"port"`
+	VID          string `json:"vid"`
+	PID          string `json:"pid"`
+	SerialNumber string `json:"serialNumber"`
+	Product      string `json:"product"`
+	PicoLike     bool   `json:"picoLike"` // would be used for upload reset
+}
+
+// SystemInfo is returned by GetSystemInfo and written to diagnostics bundles.
+type SystemInfo struct {
+	AppVersion  string            `json:"appVersion"`
+	GitCommit   string            `json:"gitCommit"`
+	BuildDate   string            `json:"buildDate"`
+	GoVersion   string            `json:"goVersion"`
+	OS          string            `json:"os"`
+	OSVersion   string            `json:"osVersion"`
+	Arch        string            `json:"arch"`
+	CPUs        int               `json:"cpus"`
+	DataDir     string            `json:"dataDir"`
+	DiskFree    uint64            `json:"diskFree"`  // bytes free on the data dir volume
+	DiskTotal   uint64            `json:"diskTotal"` // 0 if unknown
+	USBDevices  []USBSerialDevice `json:"usbDevices"`
+	GeneratedAt string            `json:"generatedAt"`
+	Error       string            `json:"error"` // non-fatal lookup failures
+}
+
+// GetSystemInfo reports OS, disk and USB details for the support panel.
+func (a *App) GetSystemInfo() SystemInfo {
+	defer a.recoverBinding("GetSystemInfo")
+
+	info := SystemInfo{
+		AppVersion:  AppVersion,
+		GitCommit:   GitCommit,
+		BuildDate:   BuildDate,
+		GoVersion:   goruntime.Version(),
+		OS:          goruntime.GOOS,
+		OSVersion:   osVersion(),
+		Arch:        goruntime.GOARCH,
+		CPUs:        goruntime.NumCPU(),
+		DataDir:     appDataDir(),
+		USBDevices:  []USBSerialDevice{},
+		GeneratedAt: time.Now().Format(time.RFC3339),
+	}
+
+	var problems []string
+	free, total, err := diskSpace(info.DataDir)
+	if err != nil {
+		problems = append(problems, "disk: "+err.Error())
+	}
+	info.DiskFree, info.DiskTotal = free, total
+
+	ports, err := enumerator.GetDetailedPortsList()
+	if err != nil {
+		problems = append(problems, "usb: "+err.Error())
+	}
+	for _, p := range ports {
+		if !p.IsUSB {
+			continue
+		}
+		info.USBDevices = append(info.USBDevices, USBSerialDevice{
+			Port:         p.Name,
+			VID:          p.VID,
+			PID:          p.PID,
+			SerialNumber: p.SerialNumber,
+			Product:      p.Product,
+			PicoLike:     isPicoLikeUSBSerialPort(p),
+		})
+	}
+
+	for i, p := range problems {
+		if i > 0 {
+			info.Error += "; "
+		}
+		info.Error += p
+	}
+	return info
+}
+
+// parentDir returns the parent of dir, or "" at the filesystem root.
+func parentDir(dir string) string {
+	parent := filepath.Dir(dir)
+	if parent == dir {
+		return ""
+	}
+	return parent
+}
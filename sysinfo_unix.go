@@ -0,0 +1,47 @@
+//go:build !windows
+
+package main
+
+import (
+	"bufio"
+	"os"
+	"os/exec"
+	goruntime "runtime"
+	"strings"
+	"syscall"
+)
+
+func osVersion() string {
+	if goruntime.GOOS == "darwin" {
+		if out, err := exec.Command("sw_vers", "-productVersion").Output(); err == nil {
+			return "macOS " + strings.TrimSpace(string(out))
+		}
+		return "macOS"
+	}
+	if f, err := os.Open("/etc/os-release"); err == nil {
+		defer f.Close()
+		sc := bufio.NewScanner(f)
+		for sc.Scan() {
+			if v, ok := strings.CutPrefix(sc.Text(), "PRETTY_NAME="); ok {
+				return strings.Trim(v, `"`)
+			}
+		}
+	}
+	return goruntime.GOOS
+}
+
+// diskSpace reports free/total bytes on the volume holding dir (or the
+// nearest existing parent).
+func diskSpace(dir string) (free, total uint64, err error) {
+	for dir != "" {
+		if _, statErr := os.Stat(dir); statErr == nil {
+			break
+		}
+		dir = parentDir(dir)
+	}
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(dir, &st); err != nil {
+		return 0, 0, err
+	}
+	return st.Bavail * uint64(st.Bsize), st.Blocks * uint64(st.Bsize), nil
+}
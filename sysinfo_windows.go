@@ -0,0 +1,43 @@
+//go:build windows
+
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"golang.org/x/sys/windows"
+	"golang.org/x/sys/windows/registry"
+)
+
+func osVersion() string {
+	k, err := registry.OpenKey(registry.LOCAL_MACHINE, `SOFTWARE\Microsoft\Windows NT\CurrentVersion`, registry.QUERY_VALUE)
+	if err != nil {
+		return "unknown"
+	}
+	defer k.Close()
+	name, _, _ := k.GetStringValue("ProductName")
+	display, _, _ := k.GetStringValue("DisplayVersion")
+	build, _, _ := k.GetStringValue("CurrentBuild")
+	return fmt.Sprintf("%s %s (build %s)", name, display, build)
+}
+
+// diskSpace reports free/total bytes on the volume holding dir (or the
+// nearest existing parent).
+func diskSpace(dir string) (free, total uint64, err error) {
+	for dir != "" {
+		if _, statErr := os.Stat(dir); statErr == nil {
+			break
+		}
+		dir = parentDir(dir)
+	}
+	p, err := windows.UTF16PtrFromString(dir)
+	if err != nil {
+		return 0, 0, err
+	}
+	var avail, tot, totalFree uint64
+	if err := windows.GetDiskFreeSpaceEx(p, &avail, &tot, &totalFree); err != nil {
+		return 0, 0, err
+	}
+	return avail, tot, nil
+}
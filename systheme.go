@@ -0,0 +1,73 @@
+package main
+
+import (
+	"time"
+
+	"PicoLume/settings"
+
+	"github.com/wailsapp/wails/v2/pkg/options"
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// ==========================================================
+// SYSTEM THEME
+// ==========================================================
+
+// themePollInterval is how often the OS theme is re-read to detect changes.
+const themePollInterval = 5 * time.Second
+
+// SystemTheme is the OS appearance. It is also the payload of the
+// "theme:changed" event.
+type SystemTheme struct {
+	Dark   bool   `json:"dark"`
+	Accent string `json:"accent"` // "#RRGGBB", empty if unknown
+}
+
+// Window background colours matching the frontend's dark and light themes,
+// shown before the frontend has painted.
+var (
+	darkBackground  = options.RGBA{R: 27, G: 38, B: 54, A: 1}
+	lightBackground = options.RGBA{R: 241, G: 245, B: 249, A: 1}
+)
+
+// GetSystemTheme reports the OS dark/light mode and accent colour.
+func (a *App) GetSystemTheme() SystemTheme {
+	return detectSystemTheme()
+}
+
+// startupBackground picks the window background from the theme setting,
+// following the OS when set to "system".
+func startupBackground(s settings.Settings) *options.RGBA {
+	dark := true
+	switch s.Theme {
+	case settings.ThemeLight:
+		dark = false
+	case settings.ThemeSystem:
+		dark = detectSystemTheme().Dark
+	}
+	if dark {
+		return &darkBackground
+	}
+	return &lightBackground
+}
+
+// watchSystemTheme emits "theme:changed" whenever the OS appearance changes.
+func (a *App) watchSystemTheme() {
+	a.goSafe("theme watcher", func() {
+		last := detectSystemTheme()
+		ticker := time.NewTicker(themePollInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-a.ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			current := detectSystemTheme()
+			if current != last {
+				last = current
+				runtime.EventsEmit(a.ctx, "theme:changed", current)
+			}
+		}
+	})
+}
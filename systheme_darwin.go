@@ -0,0 +1,36 @@
+//go:build darwin
+
+package main
+
+import (
+	"os/exec"
+	"strings"
+)
+
+// macAccents maps AppleAccentColor values to the system colours.
+var macAccents = map[string]string{
+	"-1": "#8C8C8C", // graphite
+	"0":  "#FF5257", // red
+	"1":  "#F7821B", // orange
+	"2":  "#FFC600", // yellow
+	"3":  "#62BA46", // green
+	"4":  "#007AFF", // blue
+	"5":  "#A550A7", // purple
+	"6":  "#F74F9E", // pink
+}
+
+func detectSystemTheme() SystemTheme {
+	theme := SystemTheme{Accent: macAccents["4"]}
+
+	// The key only exists in dark mode.
+	if out, err := exec.Command("defaults", "read", "-g", "AppleInterfaceStyle").Output(); err == nil {
+		theme.Dark = strings.EqualFold(strings.TrimSpace(string(out)), "Dark")
+	}
+	// The key is absent when the default (blue) accent is selected.
+	if out, err := exec.Command("defaults", "read", "-g", "AppleAccentColor").Output(); err == nil {
+		if accent, ok := macAccents[strings.TrimSpace(string(out))]; ok {
+			theme.Accent = accent
+		}
+	}
+	return theme
+}
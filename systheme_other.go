@@ -0,0 +1,20 @@
+//go:build !windows && !darwin
+
+package main
+
+import (
+	"os/exec"
+	"strings"
+)
+
+// detectSystemTheme asks GNOME-compatible desktops via gsettings; elsewhere
+// it reports light mode with no accent.
+func detectSystemTheme() SystemTheme {
+	theme := SystemTheme{}
+	if out, err := exec.Command("gsettings", "get", "org.gnome.desktop.interface", "color-scheme").Output(); err == nil {
+		theme.Dark = strings.Contains(string(out), "dark")
+	} else if out, err := exec.Command("gsettings", "get", "org.gnome.desktop.interface", "gtk-theme").Output(); err == nil {
+		theme.Dark = strings.Contains(strings.ToLower(string(out)), "dark")
+	}
+	return theme
+}
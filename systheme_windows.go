@@ -0,0 +1,29 @@
+//go:build windows
+
+package main
+
+import (
+	"fmt"
+
+	"golang.org/x/sys/windows/registry"
+)
+
+func detectSystemTheme() SystemTheme {
+	theme := SystemTheme{}
+
+	if k, err := registry.OpenKey(registry.CURRENT_USER, `Software\Microsoft\Windows\CurrentVersion\Themes\Personalize`, registry.QUERY_VALUE); err == nil {
+		if light, _, err := k.GetIntegerValue("AppsUseLightTheme"); err == nil {
+			theme.Dark = light == 0
+		}
+		k.Close()
+	}
+
+	// AccentColor is stored as 0xAABBGGRR.
+	if k, err := registry.OpenKey(registry.CURRENT_USER, `Software\Microsoft\Windows\DWM`, registry.QUERY_VALUE); err == nil {
+		if abgr, _, err := k.GetIntegerValue("AccentColor"); err == nil {
+			theme.Accent = fmt.Sprintf("#%02X%02X%02X", abgr&0xFF, (abgr>>8)&0xFF, (abgr>>16)&0xFF)
+		}
+		k.Close()
+	}
+	return theme
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"fmt"
+
+	"PicoLume/companion"
+	"PicoLume/logger"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// ==========================================================
+// SYSTEM TRAY
+// ==========================================================
+//
+// Wails v2 has no tray API, so the icon is implemented natively per platform
+// (tray_windows.go). Elsewhere startTray reports that the tray is
+// unavailable and the app runs without it.
+
+// Tray menu actions.
+const (
+	trayShow = iota + 1
+	trayUpload
+	trayBlackout
+	trayQuit
+)
+
+// trayStatusText summarises the last device scan for the tray menu.
+func (a *App) trayStatusText() string {
+	a.mu.Lock()
+	status := a.lastConnStatus
+	scanned := !a.lastConnAt.IsZero()
+	a.mu.Unlock()
+
+	switch {
+	case !scanned:
+		return "Device: not checked yet"
+	case !status.Connected:
+		return "Device: not connected"
+	case status.USBDrive != "" && status.SerialPort != "":
+		return fmt.Sprintf("Device: %s (%s, %s)", status.Mode, status.USBDrive, status.SerialPort)
+	case status.USBDrive != "":
+		return fmt.Sprintf("Device: %s (%s)", status.Mode, status.USBDrive)
+	default:
+		return fmt.Sprintf("Device: %s (%s)", status.Mode, status.SerialPort)
+	}
+}
+
+// handleTrayAction runs a tray menu choice. Upload needs the project from the
+// frontend, so it is forwarded as "tray:action".
+func (a *App) handleTrayAction(action int) {
+	defer a.recoverGoroutine("tray action")
+
+	if a.ctx == nil {
+		return
+	}
+	switch action {
+	case trayShow:
+		runtime.WindowUnminimise(a.ctx)
+		runtime.Show(a.ctx)
+	case trayUpload:
+		logger.Info("Tray: upload requested")
+		runtime.EventsEmit(a.ctx, "tray:action", "upload")
+	case trayBlackout:
+		a.handleRemoteCommand(companion.Command{Action: companion.ActionBlackout, Source: "tray"})
+	case trayQuit:
+		runtime.Quit(a.ctx)
+	}
+}
@@ -0,0 +1,13 @@
+//go:build !windows
+
+package main
+
+import "errors"
+
+type trayIcon struct{}
+
+func (a *App) startTray() (*trayIcon, error) {
+	return nil, errors.New("system tray is only supported on Windows")
+}
+
+func (t *trayIcon) Close() {}
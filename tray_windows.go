@@ -0,0 +1,286 @@
+//go:build windows
+
+package main
+
+import (
+	"errors"
+	"os"
+	goruntime "runtime"
+	"sync"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+var (
+	user32  = windows.NewLazySystemDLL("user32.dll")
+	shell32 = windows.NewLazySystemDLL("shell32.dll")
+
+	procRegisterClassExW = user32.NewProc("RegisterClassExW")
+	procCreateWindowExW  = user32.NewProc("CreateWindowExW")
+	procDefWindowProcW   = user32.NewProc("DefWindowProcW")
+	procDestroyWindow    = user32.NewProc("DestroyWindow")
+	procGetMessageW      = user32.NewProc("GetMessageW")
+	procTranslateMessage = user32.NewProc("TranslateMessage")
+	procDispatchMessageW = user32.NewProc("DispatchMessageW")
+	procPostMessageW     = user32.NewProc("PostMessageW")
+	procPostQuitMessage  = user32.NewProc("PostQuitMessage")
+	procCreatePopupMenu  = user32.NewProc("CreatePopupMenu")
+	procAppendMenuW      = user32.NewProc("AppendMenuW")
+	procTrackPopupMenu   = user32.NewProc("TrackPopupMenu")
+	procDestroyMenu      = user32.NewProc("DestroyMenu")
+	procGetCursorPos     = user32.NewProc("GetCursorPos")
+	procSetForegroundWnd = user32.NewProc("SetForegroundWindow")
+	procLoadIconW        = user32.NewProc("LoadIconW")
+	procShellNotifyIconW = shell32.NewProc("Shell_NotifyIconW")
+	procExtractIconW     = shell32.NewProc("ExtractIconW")
+)
+
+const (
+	wmDestroy     = 0x0002
+	wmClose       = 0x0010
+	wmLButtonUp   = 0x0202
+	wmRButtonUp   = 0x0205
+	wmLButtonDbl  = 0x0203
+	wmTrayMessage = 0x8000 + 1 // WM_APP + 1
+
+	nimAdd    = 0x0
+	nimDelete = 0x2
+
+	nifMessage = 0x1
+	nifIcon    = 0x2
+	nifTip     = 0x4
+
+	mfString    = 0x0
+	mfGrayed    = 0x1
+	mfSeparator = 0x800
+
+	tpmReturnCmd   = 0x100
+	tpmRightButton = 0x2
+
+	idiApplication = 32512
+)
+
+var hwndMessage = ^uintptr(2) // HWND_MESSAGE (-3)
+
+type wndClassEx struct {
+	Size       uint32
+	Style      uint32
+	WndProc    uintptr
+	ClsExtra   int32
+	WndExtra   int32
+	Instance   windows.Handle
+	Icon       windows.Handle
+	Cursor     windows.Handle
+	Background windows.Handle
+	MenuName   *uint16
+	ClassName  *uint16
+	IconSm     windows.Handle
+}
+
+type notifyIconData struct {
+	Size            uint32
+	Wnd             windows.HWND
+	ID              uint32
+	Flags           uint32
+	CallbackMessage uint32
+	Icon            windows.Handle
+	Tip             [128]uint16
+	State           uint32
+	StateMask       uint32
+	Info            [256]uint16
+	Version         uint32
+	InfoTitle       [64]uint16
+	InfoFlags       uint32
+	GUIDItem        windows.GUID
+	BalloonIcon     windows.Handle
+}
+
+type point struct{ X, Y int32 }
+
+type msg struct {
+	Wnd     windows.HWND
+	Message uint32
+	WParam  uintptr
+	LParam  uintptr
+	Time    uint32
+	Pt      point
+}
+
+// trayIcon owns a hidden message-only window and the notification icon.
+type trayIcon struct {
+	app  *App
+	hwnd uintptr
+	done chan struct{}
+	once sync.Once
+}
+
+// The window procedure is a process-wide callback; only one tray exists.
+var activeTray *trayIcon
+
+var trayWndProc = windows.NewCallback(func(hwnd, message, wParam, lParam uintptr) uintptr {
+	t := activeTray
+	switch message {
+	case wmTrayMessage:
+		switch lParam {
+		case wmRButtonUp, wmLButtonUp:
+			if t != nil {
+				t.showMenu(hwnd)
+			}
+		case wmLButtonDbl:
+			if t != nil {
+				go t.app.handleTrayAction(trayShow)
+			}
+		}
+		return 0
+	case wmClose:
+		procDestroyWindow.Call(hwnd)
+		return 0
+	case wmDestroy:
+		procPostQuitMessage.Call(0)
+		return 0
+	}
+	r, _, _ := procDefWindowProcW.Call(hwnd, message, wParam, lParam)
+	return r
+})
+
+// startTray adds the tray icon. The Win32 message loop runs on its own
+// locked OS thread, independent of the Wails window.
+func (a *App) startTray() (*trayIcon, error) {
+	t := &trayIcon{app: a, done: make(chan struct{})}
+	ready := make(chan error, 1)
+
+	go func() {
+		defer a.recoverGoroutine("tray loop")
+		goruntime.LockOSThread()
+		defer goruntime.UnlockOSThread()
+		defer close(t.done)
+
+		if err := t.create(); err != nil {
+			ready <- err
+			return
+		}
+		activeTray = t
+		ready <- nil
+
+		var m msg
+		for {
+			r, _, _ := procGetMessageW.Call(uintptr(unsafe.Pointer(&m)), 0, 0, 0)
+			if int32(r) <= 0 {
+				break
+			}
+			procTranslateMessage.Call(uintptr(unsafe.Pointer(&m)))
+			procDispatchMessageW.Call(uintptr(unsafe.Pointer(&m)))
+		}
+		t.removeIcon()
+		activeTray = nil
+	}()
+
+	if err := <-ready; err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+func (t *trayIcon) create() error {
+	className, _ := windows.UTF16PtrFromString("PicoLumeTray")
+	var instance windows.Handle
+	if err := windows.GetModuleHandleEx(0, nil, &instance); err != nil {
+		return err
+	}
+
+	wc := wndClassEx{
+		WndProc:   trayWndProc,
+		Instance:  instance,
+		ClassName: className,
+	}
+	wc.Size = uint32(unsafe.Sizeof(wc))
+	if r, _, err := procRegisterClassExW.Call(uintptr(unsafe.Pointer(&wc))); r == 0 {
+		return err
+	}
+
+	hwnd, _, err := procCreateWindowExW.Call(0,
+		uintptr(unsafe.Pointer(className)), 0, 0,
+		0, 0, 0, 0,
+		hwndMessage, 0, uintptr(instance), 0)
+	if hwnd == 0 {
+		return err
+	}
+	t.hwnd = hwnd
+
+	nid := t.iconData()
+	nid.Flags = nifMessage | nifIcon | nifTip
+	nid.CallbackMessage = wmTrayMessage
+	nid.Icon = appIcon(instance)
+	copy(nid.Tip[:len(nid.Tip)-1], windows.StringToUTF16("PicoLume Studio"))
+	if r, _, _ := procShellNotifyIconW.Call(nimAdd, uintptr(unsafe.Pointer(nid))); r == 0 {
+		procDestroyWindow.Call(hwnd)
+		return errors.New("Shell_NotifyIcon failed")
+	}
+	return nil
+}
+
+func (t *trayIcon) iconData() *notifyIconData {
+	nid := &notifyIconData{Wnd: windows.HWND(t.hwnd), ID: 1}
+	nid.Size = uint32(unsafe.Sizeof(*nid))
+	return nid
+}
+
+func (t *trayIcon) removeIcon() {
+	procShellNotifyIconW.Call(nimDelete, uintptr(unsafe.Pointer(t.iconData())))
+}
+
+// appIcon uses the executable's own icon, falling back to the stock one.
+func appIcon(instance windows.Handle) windows.Handle {
+	if exe, err := os.Executable(); err == nil {
+		if p, err := windows.UTF16PtrFromString(exe); err == nil {
+			if h, _, _ := procExtractIconW.Call(uintptr(instance), uintptr(unsafe.Pointer(p)), 0); h > 1 {
+				return windows.Handle(h)
+			}
+		}
+	}
+	h, _, _ := procLoadIconW.Call(0, idiApplication)
+	return windows.Handle(h)
+}
+
+// showMenu displays the popup menu at the cursor and runs the chosen action.
+func (t *trayIcon) showMenu(hwnd uintptr) {
+	menu, _, _ := procCreatePopupMenu.Call()
+	if menu == 0 {
+		return
+	}
+	defer procDestroyMenu.Call(menu)
+
+	add := func(flags uintptr, id int, label string) {
+		p, _ := windows.UTF16PtrFromString(label)
+		procAppendMenuW.Call(menu, flags, uintptr(id), uintptr(unsafe.Pointer(p)))
+	}
+	add(mfString|mfGrayed, 0, t.app.trayStatusText())
+	procAppendMenuW.Call(menu, mfSeparator, 0, 0)
+	add(mfString, trayShow, "Show PicoLume Studio")
+	add(mfString, trayUpload, "Upload current show")
+	add(mfString, trayBlackout, "Blackout")
+	procAppendMenuW.Call(menu, mfSeparator, 0, 0)
+	add(mfString, trayQuit, "Quit")
+
+	var pt point
+	procGetCursorPos.Call(uintptr(unsafe.Pointer(&pt)))
+	// Required so the menu closes when the user clicks elsewhere.
+	procSetForegroundWnd.Call(hwnd)
+	cmd, _, _ := procTrackPopupMenu.Call(menu, tpmReturnCmd|tpmRightButton,
+		uintptr(pt.X), uintptr(pt.Y), 0, hwnd, 0)
+	if cmd != 0 {
+		go t.app.handleTrayAction(int(cmd))
+	}
+}
+
+// Close removes the icon and stops the message loop.
+func (t *trayIcon) Close() {
+	if t == nil {
+		return
+	}
+	t.once.Do(func() {
+		procPostMessageW.Call(t.hwnd, wmClose, 0, 0)
+		<-t.done
+	})
+}
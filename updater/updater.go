@@ -0,0 +1,294 @@
+// Package updater checks GitHub releases for a newer Studio build and, on
+// Windows, fetches the NSIS installer so crews can update before show day.
+// Installers are only accepted when they match a SHA-256 checksum published
+// with the release.
+package updater
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultRepo is the GitHub "owner/name" that publishes Studio releases.
+const DefaultRepo = "picolume/studio"
+
+// MaxInstallerSize caps installer downloads.
+const MaxInstallerSize = 200 * 1024 * 1024
+
+// maxChecksumSize caps checksum file downloads.
+const maxChecksumSize = 64 * 1024
+
+// checksumFiles are release assets listing checksums for several files, as
+// written by sha256sum. A "<installer>.sha256" asset is preferred.
+var checksumFiles = []string{"sha256sums", "sha256sums.txt", "checksums.txt"}
+
+// Asset is a downloadable file attached to a release.
+type Asset struct {
+	Name string `json:"name"`
+	Size int64  `json:"size"`
+	URL  string `json:"browser_download_url"`
+}
+
+// Release is the subset of the GitHub release API used here.
+type Release struct {
+	Tag         string    `json:"tag_name"`
+	Name        string    `json:"name"`
+	Notes       string    `json:"body"`
+	URL         string    `json:"html_url"`
+	PublishedAt time.Time `json:"published_at"`
+	Prerelease  bool      `json:"prerelease"`
+	Draft       bool      `json:"draft"`
+	Assets      []Asset   `json:"assets"`
+}
+
+// Version returns the release tag without a leading "v".
+func (r Release) Version() string {
+	return strings.TrimPrefix(strings.TrimSpace(r.Tag), "v")
+}
+
+// InstallerFor returns the NSIS installer asset for an architecture
+// ("amd64", "arm64"), as named by build/windows/installer/project.nsi.
+func (r Release) InstallerFor(arch string) (Asset, bool) {
+	suffix := "-" + arch + "-installer.exe"
+	for _, a := range r.Assets {
+		if strings.HasSuffix(strings.ToLower(a.Name), suffix) {
+			return a, true
+		}
+	}
+	return Asset{}, false
+}
+
+// ChecksumFor returns the release asset holding the SHA-256 checksum of
+// asset: "<name>.sha256" if published, otherwise a SHA256SUMS or
+// checksums.txt list.
+func (r Release) ChecksumFor(asset Asset) (Asset, bool) {
+	for _, a := range r.Assets {
+		if strings.EqualFold(a.Name, asset.Name+".sha256") {
+			return a, true
+		}
+	}
+	for _, name := range checksumFiles {
+		for _, a := range r.Assets {
+			if strings.EqualFold(a.Name, name) {
+				return a, true
+			}
+		}
+	}
+	return Asset{}, false
+}
+
+// ParseChecksum returns the hex SHA-256 of the file name from a checksum
+// file: either a line "<hex>  <name>" as written by sha256sum, or a file
+// holding only the checksum.
+func ParseChecksum(data []byte, name string) (string, error) {
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	for _, line := range lines {
+		fields := strings.Fields(line)
+		switch {
+		case len(fields) == 1 && len(lines) == 1:
+		case len(fields) >= 2 && strings.TrimPrefix(fields[1], "*") == name:
+		default:
+			continue
+		}
+		sum := strings.ToLower(fields[0])
+		if b, err := hex.DecodeString(sum); err != nil || len(b) != sha256.Size {
+			return "", fmt.Errorf("invalid checksum for %s", name)
+		}
+		return sum, nil
+	}
+	return "", fmt.Errorf("no checksum for %s", name)
+}
+
+// Client queries the GitHub API.
+type Client struct {
+	Repo    string // "owner/name"; DefaultRepo if empty
+	BaseURL string // API root; https://api.github.com if empty
+	HTTP    *http.Client
+}
+
+// Latest returns the newest published, non-prerelease release.
+func (c *Client) Latest(ctx context.Context) (Release, error) {
+	repo := c.Repo
+	if repo == "" {
+		repo = DefaultRepo
+	}
+	base := strings.TrimRight(c.BaseURL, "/")
+	if base == "" {
+		base = "https://api.github.com"
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, base+"/repos/"+repo+"/releases/latest", nil)
+	if err != nil {
+		return Release{}, err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+
+	resp, err := c.httpClient(15 * time.Second).Do(req)
+	if err != nil {
+		return Release{}, fmt.Errorf("update server not reachable: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return Release{}, fmt.Errorf("no releases published for %s", repo)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return Release{}, fmt.Errorf("update check failed: %s", resp.Status)
+	}
+
+	var rel Release
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&rel); err != nil {
+		return Release{}, fmt.Errorf("invalid release data: %w", err)
+	}
+	if rel.Tag == "" {
+		return Release{}, fmt.Errorf("release has no tag")
+	}
+	return rel, nil
+}
+
+// Checksum fetches the SHA-256 published for asset in rel, as hex.
+func (c *Client) Checksum(ctx context.Context, rel Release, asset Asset) (string, error) {
+	sumAsset, ok := rel.ChecksumFor(asset)
+	if !ok {
+		return "", fmt.Errorf("release %s publishes no checksum for %s", rel.Version(), asset.Name)
+	}
+	resp, err := c.get(ctx, sumAsset.URL, 30*time.Second)
+	if err != nil {
+		return "", fmt.Errorf("checksum download failed: %w", err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxChecksumSize))
+	if err != nil {
+		return "", fmt.Errorf("checksum download failed: %w", err)
+	}
+	return ParseChecksum(data, asset.Name)
+}
+
+// Download saves asset into dir and returns the file path. The file is
+// written under a temporary name and renamed only once complete and
+// matching sum, the asset's hex SHA-256 (see Checksum).
+func (c *Client) Download(ctx context.Context, asset Asset, sum, dir string) (string, error) {
+	if asset.URL == "" || asset.Name == "" {
+		return "", fmt.Errorf("invalid asset")
+	}
+	want, err := hex.DecodeString(sum)
+	if err != nil || len(want) != sha256.Size {
+		return "", fmt.Errorf("invalid checksum for %s", asset.Name)
+	}
+	if asset.Size > MaxInstallerSize {
+		return "", fmt.Errorf("installer too large (%d bytes)", asset.Size)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+
+	resp, err := c.get(ctx, asset.URL, 10*time.Minute)
+	if err != nil {
+		return "", fmt.Errorf("download failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	dest := filepath.Join(dir, filepath.Base(asset.Name))
+	tmp := dest + ".part"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return "", err
+	}
+	h := sha256.New()
+	n, err := io.Copy(io.MultiWriter(f, h), io.LimitReader(resp.Body, MaxInstallerSize+1))
+	f.Close()
+	if err == nil && n > MaxInstallerSize {
+		err = fmt.Errorf("installer too large")
+	}
+	if err == nil && asset.Size > 0 && n != asset.Size {
+		err = fmt.Errorf("incomplete download (%d of %d bytes)", n, asset.Size)
+	}
+	if err == nil && !bytes.Equal(h.Sum(nil), want) {
+		err = fmt.Errorf("%s does not match the published checksum", asset.Name)
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return "", err
+	}
+	if err := os.Rename(tmp, dest); err != nil {
+		os.Remove(tmp)
+		return "", err
+	}
+	return dest, nil
+}
+
+// get fetches url and fails unless the response is 200 OK.
+func (c *Client) get(ctx context.Context, url string, timeout time.Duration) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.httpClient(timeout).Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("%s", resp.Status)
+	}
+	return resp, nil
+}
+
+func (c *Client) httpClient(timeout time.Duration) *http.Client {
+	if c.HTTP != nil {
+		return c.HTTP
+	}
+	return &http.Client{Timeout: timeout}
+}
+
+// IsNewer reports whether version a is newer than b. Versions are dotted
+// numbers with an optional "v" prefix and "-suffix"; a pre-release suffix
+// sorts before the plain version. Unparseable versions are never newer.
+func IsNewer(a, b string) bool {
+	pa, sa, okA := parseVersion(a)
+	pb, sb, okB := parseVersion(b)
+	if !okA || !okB {
+		return false
+	}
+	for i := 0; i < len(pa) || i < len(pb); i++ {
+		var x, y int
+		if i < len(pa) {
+			x = pa[i]
+		}
+		if i < len(pb) {
+			y = pb[i]
+		}
+		if x != y {
+			return x > y
+		}
+	}
+	// Same numbers: 1.2.0 is newer than 1.2.0-beta.
+	return sa == "" && sb != ""
+}
+
+func parseVersion(v string) ([]int, string, bool) {
+	v = strings.TrimPrefix(strings.TrimSpace(v), "v")
+	v, suffix, _ := strings.Cut(v, "-")
+	if v == "" {
+		return nil, "", false
+	}
+	var parts []int
+	for _, s := range strings.Split(v, ".") {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 0 {
+			return nil, "", false
+		}
+		parts = append(parts, n)
+	}
+	return parts, suffix, true
+}
@@ -0,0 +1,203 @@
+package updater
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestIsNewer(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"1.2.1", "1.2.0", true},
+		{"v1.10.0", "1.9.9", true},
+		{"2.0", "1.99.99", true},
+		{"1.2.0", "1.2.0", false},
+		{"v1.2.0", "1.2", false},
+		{"1.2.0", "1.2.1", false},
+		{"1.2.0", "1.2.0-beta", true},
+		{"1.2.0-beta", "1.2.0", false},
+		{"1.2.0-rc.2", "1.2.0-rc.1", false},
+		{"dev", "1.0.0", false},
+		{"1.0.0", "dev", false},
+		{"", "1.0.0", false},
+	}
+	for _, tt := range tests {
+		if got := IsNewer(tt.a, tt.b); got != tt.want {
+			t.Errorf("IsNewer(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+var testRelease = Release{
+	Tag: "v1.4.0",
+	Assets: []Asset{
+		{Name: "PicoLume-amd64-installer.exe", URL: "/amd64"},
+		{Name: "PicoLume-arm64-Installer.exe", URL: "/arm64"},
+		{Name: "PicoLume-amd64-installer.exe.sha256", URL: "/amd64.sha256"},
+		{Name: "SHA256SUMS", URL: "/sums"},
+		{Name: "PicoLume-darwin.zip", URL: "/darwin"},
+	},
+}
+
+func TestInstallerFor(t *testing.T) {
+	tests := []struct {
+		arch     string
+		want     string
+		wantSums string
+	}{
+		{"amd64", "PicoLume-amd64-installer.exe", "PicoLume-amd64-installer.exe.sha256"},
+		{"arm64", "PicoLume-arm64-Installer.exe", "SHA256SUMS"},
+		{"386", "", ""},
+	}
+	for _, tt := range tests {
+		asset, ok := testRelease.InstallerFor(tt.arch)
+		if ok != (tt.want != "") || asset.Name != tt.want {
+			t.Errorf("InstallerFor(%q) = %q, %v, want %q", tt.arch, asset.Name, ok, tt.want)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		if sums, _ := testRelease.ChecksumFor(asset); sums.Name != tt.wantSums {
+			t.Errorf("ChecksumFor(%q) = %q, want %q", asset.Name, sums.Name, tt.wantSums)
+		}
+	}
+	if _, ok := (Release{Assets: testRelease.Assets[:2]}).ChecksumFor(testRelease.Assets[1]); ok {
+		t.Error("ChecksumFor() found a checksum in a release without one")
+	}
+}
+
+func TestParseChecksum(t *testing.T) {
+	sum := strings.Repeat("ab", 32)
+	tests := []struct {
+		name    string
+		data    string
+		want    string
+		wantErr bool
+	}{
+		{"bare", sum + "\n", sum, false},
+		{"sha256sum line", strings.ToUpper(sum) + "  app.exe\n", sum, false},
+		{"binary mode", "00  other.exe\n" + sum + " *app.exe\n", sum, false},
+		{"other file only", sum + "  other.exe\n", "", true},
+		{"short", "abcd", "", true},
+		{"not hex", strings.Repeat("zz", 32), "", true},
+		{"empty", "", "", true},
+	}
+	for _, tt := range tests {
+		got, err := ParseChecksum([]byte(tt.data), "app.exe")
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("%s: ParseChecksum() = %q, %v, want %q (error %v)", tt.name, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestLatest(t *testing.T) {
+	tests := []struct {
+		name        string
+		code        int
+		body        string
+		wantVersion string
+		wantErr     bool
+	}{
+		{"newer", 200, `{"tag_name": "v1.4.0", "assets": [{"name": "a"}]}`, "1.4.0", false},
+		{"same version", 200, `{"tag_name": "1.3.0"}`, "1.3.0", false},
+		{"no releases", 404, `{}`, "", true},
+		{"server error", 502, ``, "", true},
+		{"no tag", 200, `{"name": "x"}`, "", true},
+		{"not json", 200, `<html>`, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/repos/picolume/studio/releases/latest" {
+					t.Errorf("request to %s", r.URL.Path)
+				}
+				w.WriteHeader(tt.code)
+				io.WriteString(w, tt.body)
+			}))
+			defer srv.Close()
+
+			rel, err := (&Client{BaseURL: srv.URL + "/"}).Latest(context.Background())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Latest() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if rel.Version() != tt.wantVersion {
+				t.Errorf("Latest() version = %q, want %q", rel.Version(), tt.wantVersion)
+			}
+		})
+	}
+
+	// The "already up to date" path: the latest release is not newer than
+	// the running build.
+	if IsNewer("1.3.0", "v1.3.0") {
+		t.Error("the running version counts as an update")
+	}
+}
+
+func TestDownload(t *testing.T) {
+	installer := []byte("MZ installer bytes")
+	h := sha256.Sum256(installer)
+	sum := hex.EncodeToString(h[:])
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/app.exe":
+			w.Write(installer)
+		case "/app.exe.sha256":
+			io.WriteString(w, sum+"  app.exe\n")
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	asset := Asset{Name: "app.exe", Size: int64(len(installer)), URL: srv.URL + "/app.exe"}
+	rel := Release{Tag: "v2.0.0", Assets: []Asset{asset, {Name: "app.exe.sha256", URL: srv.URL + "/app.exe.sha256"}}}
+	c := &Client{}
+
+	got, err := c.Checksum(context.Background(), rel, asset)
+	if err != nil || got != sum {
+		t.Fatalf("Checksum() = %q, %v, want %q", got, err, sum)
+	}
+	if _, err := c.Checksum(context.Background(), Release{Assets: []Asset{asset}}, asset); err == nil {
+		t.Error("Checksum() without a published checksum succeeded")
+	}
+
+	dir := t.TempDir()
+	path, err := c.Download(context.Background(), asset, sum, dir)
+	if err != nil {
+		t.Fatalf("Download() error = %v", err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != string(installer) {
+		t.Errorf("downloaded %q", data)
+	}
+
+	tests := []struct {
+		name  string
+		asset Asset
+		sum   string
+	}{
+		{"checksum mismatch", asset, strings.Repeat("00", 32)},
+		{"no checksum", asset, ""},
+		{"truncated", Asset{Name: "app.exe", Size: 100, URL: asset.URL}, sum},
+		{"missing", Asset{Name: "gone.exe", URL: srv.URL + "/gone.exe"}, sum},
+	}
+	for _, tt := range tests {
+		dir := t.TempDir()
+		if _, err := c.Download(context.Background(), tt.asset, tt.sum, dir); err == nil {
+			t.Errorf("%s: Download() succeeded", tt.name)
+		}
+		if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+			t.Errorf("%s: Download() left %s behind", tt.name, filepath.Join(dir, entries[0].Name()))
+		}
+	}
+}
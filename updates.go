@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"os/exec"
+	"path/filepath"
+	goruntime "runtime"
+	"time"
+
+	"PicoLume/i18n"
+	"PicoLume/logger"
+	"PicoLume/updater"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// ==========================================================
+// UPDATES
+// ==========================================================
+
+// UpdateInfo describes the result of an update check. It is also the payload
+// of the "update:available" event.
+type UpdateInfo struct {
+	CurrentVersion string `json:"currentVersion"`
+	LatestVersion  string `json:"latestVersion"`
+	Available      bool   `json:"available"`
+	ReleaseName    string `json:"releaseName"`
+	Notes          string `json:"notes"`
+	URL            string `json:"url"`        // release page
+	CanInstall     bool   `json:"canInstall"` // a checksummed installer exists for this platform
+	Error          string `json:"error"`
+}
+
+func checkLatestRelease(ctx context.Context) (UpdateInfo, updater.Release, error) {
+	info := UpdateInfo{CurrentVersion: AppVersion}
+	rel, err := (&updater.Client{}).Latest(ctx)
+	if err != nil {
+		return info, rel, err
+	}
+	info.LatestVersion = rel.Version()
+	info.Available = updater.IsNewer(rel.Version(), AppVersion)
+	info.ReleaseName = rel.Name
+	info.Notes = rel.Notes
+	info.URL = rel.URL
+	if goruntime.GOOS == "windows" {
+		if asset, ok := rel.InstallerFor(goruntime.GOARCH); ok {
+			_, info.CanInstall = rel.ChecksumFor(asset)
+		}
+	}
+	return info, rel, nil
+}
+
+// CheckForUpdates queries GitHub releases for a version newer than this
+// build and emits "update:available" when one exists.
+func (a *App) CheckForUpdates() UpdateInfo {
+	defer a.recoverBinding("CheckForUpdates")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+
+	info, _, err := checkLatestRelease(ctx)
+	if err != nil {
+		logger.Warn("CheckForUpdates: %v", err)
+		info.Error = err.Error()
+		return info
+	}
+	if info.Available {
+		logger.Info("CheckForUpdates: Version %s available (running %s)", info.LatestVersion, AppVersion)
+		if a.ctx != nil {
+			runtime.EventsEmit(a.ctx, "update:available", info)
+		}
+	}
+	return info
+}
+
+// InstallUpdate downloads the latest Windows installer, checks it against
+// the SHA-256 published with the release, launches it and quits so the
+// installer can replace the running executable.
+func (a *App) InstallUpdate() string {
+	defer a.recoverBinding("InstallUpdate")
+
+	if goruntime.GOOS != "windows" {
+		return i18n.T("Error: Automatic install is only supported on Windows")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Minute)
+	defer cancel()
+
+	info, rel, err := checkLatestRelease(ctx)
+	if err != nil {
+		return "Error: " + err.Error()
+	}
+	if !info.Available {
+		return "Already up to date"
+	}
+	asset, ok := rel.InstallerFor(goruntime.GOARCH)
+	if !ok {
+		return i18n.T("Error: Release %s has no %s installer", info.LatestVersion, goruntime.GOARCH)
+	}
+
+	client := &updater.Client{}
+	sum, err := client.Checksum(ctx, rel, asset)
+	if err != nil {
+		return "Error: " + err.Error()
+	}
+
+	logger.Info("InstallUpdate: Downloading %s (sha256 %s)", asset.Name, sum)
+	path, err := client.Download(ctx, asset, sum, filepath.Join(appDataDir(), "updates"))
+	if err != nil {
+		return "Error: " + err.Error()
+	}
+
+	if err := exec.Command(path).Start(); err != nil {
+		return i18n.T("Error launching installer: %s", err.Error())
+	}
+	logger.Info("InstallUpdate: Launched installer %s; quitting", path)
+	if a.ctx != nil {
+		runtime.Quit(a.ctx)
+	}
+	return "OK"
+}
+
+// checkForUpdatesOnStartup runs a quiet background check if enabled.
+func (a *App) checkForUpdatesOnStartup() {
+	if !a.currentSettings().CheckForUpdates {
+		return
+	}
+	a.goSafe("update check", func() {
+		a.CheckForUpdates()
+	})
+}
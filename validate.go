@@ -0,0 +1,26 @@
+package main
+
+import "PicoLume/bingen"
+
+// ==========================================================
+// PROJECT VALIDATION
+// ==========================================================
+
+// ValidateResponse is returned by ValidateProject.
+type ValidateResponse struct {
+	Problems []bingen.Problem `json:"problems"`
+	Error    string           `json:"error"`
+}
+
+// ValidateProject lists the values in a project that show.bin generation
+// would clamp, replace or drop, and tracks and clips that would not play as
+// they look, so the editor can point at them before an export or upload.
+func (a *App) ValidateProject(projectJson string) ValidateResponse {
+	defer a.recoverBinding("ValidateProject")
+
+	p, err := parseProject(projectJson)
+	if err != nil {
+		return ValidateResponse{Error: "Invalid project - " + err.Error()}
+	}
+	return ValidateResponse{Problems: bingen.Validate(p)}
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"PicoLume/logger"
+	"PicoLume/variation"
+)
+
+// ==========================================================
+// CLIP VARIATIONS
+// ==========================================================
+
+// VariationResponse is returned by VaryClips.
+type VariationResponse struct {
+	Project string `json:"project"` // the varied project JSON
+	Varied  int    `json:"varied"`
+	Skipped int    `json:"skipped"`
+	Error   string `json:"error"`
+}
+
+// VaryClips applies seeded random variations (hue shifts, timing jitter,
+// alternating groups) to the selected clips and returns the modified
+// project. The frontend replaces its project with it as one undo step.
+func (a *App) VaryClips(projectJson string, opts variation.Options) VariationResponse {
+	defer a.recoverBinding("VaryClips")
+
+	r, err := variation.Apply([]byte(projectJson), opts)
+	if err != nil {
+		return VariationResponse{Error: err.Error()}
+	}
+	logger.Info("VaryClips: Varied %d of %d clips (seed %d, %d kept in place)", r.Varied, len(opts.ClipIDs), opts.Seed, r.Skipped)
+	return VariationResponse{Project: string(r.Project), Varied: r.Varied, Skipped: r.Skipped}
+}
@@ -0,0 +1,266 @@
+// Package variation makes seeded random variations of selected clips: hue
+// shifts, start-time jitter and alternation between prop groups, which are
+// tedious to do by hand for background textures. The project is edited as
+// generic JSON so editor-only fields survive, and the same seed always
+// gives the same result.
+package variation
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"reflect"
+	"sort"
+
+	"PicoLume/bingen"
+)
+
+// Limits for Options.
+const (
+	MaxHueShift = 180    // degrees either way
+	MaxJitter   = 10_000 // ms either way
+)
+
+// colorProps are the clip properties a hue shift changes.
+var colorProps = []string{"color", "color2", "colorA", "colorB", "colorStart"}
+
+// Options selects the clips and the variations to apply.
+type Options struct {
+	ClipIDs  []string `json:"clipIds"`
+	Seed     uint64   `json:"seed"`
+	HueShift float64  `json:"hueShift"` // max degrees each clip's colors rotate, either way
+	Jitter   float64  `json:"jitter"`   // max ms each clip's start moves, either way
+
+	// Groups, if set, spreads the clips in start order over the first LED
+	// track of each group in turn: A, B, A, B...
+	Groups []string `json:"groups"`
+}
+
+// Result is the varied project.
+type Result struct {
+	Project json.RawMessage `json:"project"`
+	Varied  int             `json:"varied"`  // clips changed
+	Skipped int             `json:"skipped"` // clips kept on their track because they would overlap another
+}
+
+func (o Options) validate() error {
+	switch {
+	case len(o.ClipIDs) == 0:
+		return errors.New("no clips selected")
+	case math.IsNaN(o.HueShift) || o.HueShift < 0 || o.HueShift > MaxHueShift:
+		return fmt.Errorf("hue shift must be 0 to %d degrees", MaxHueShift)
+	case math.IsNaN(o.Jitter) || o.Jitter < 0 || o.Jitter > MaxJitter:
+		return fmt.Errorf("jitter must be 0 to %d ms", MaxJitter)
+	}
+	return nil
+}
+
+// selected is one chosen clip and the track holding it.
+type selected struct {
+	track int
+	clip  map[string]any
+}
+
+// Apply returns project with the variations in opts applied to the chosen
+// clips. Jitter never moves a clip onto a neighbour or before zero.
+func Apply(project []byte, opts Options) (*Result, error) {
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+	var doc map[string]any
+	if err := json.Unmarshal(project, &doc); err != nil {
+		return nil, fmt.Errorf("invalid project JSON: %w", err)
+	}
+	rawTracks, _ := doc["tracks"].([]any)
+	tracks := make([]map[string]any, len(rawTracks))
+	for i, t := range rawTracks {
+		if tracks[i], _ = t.(map[string]any); tracks[i] == nil {
+			return nil, fmt.Errorf("track %d is not an object", i+1)
+		}
+	}
+
+	want := make(map[string]bool, len(opts.ClipIDs))
+	for _, id := range opts.ClipIDs {
+		want[id] = true
+	}
+	var sel []selected
+	for ti, t := range tracks {
+		for _, c := range clipsOf(t) {
+			if id, _ := c["id"].(string); want[id] {
+				sel = append(sel, selected{ti, c})
+			}
+		}
+	}
+	if len(sel) == 0 {
+		return nil, errors.New("none of the selected clips are in the project")
+	}
+	sort.SliceStable(sel, func(i, j int) bool { return num(sel[i].clip, "startTime") < num(sel[j].clip, "startTime") })
+
+	var targets []int
+	for _, g := range opts.Groups {
+		ti := groupTrack(tracks, g)
+		if ti < 0 {
+			return nil, fmt.Errorf("no LED track for group %q", g)
+		}
+		targets = append(targets, ti)
+	}
+
+	rng := rand.New(rand.NewPCG(opts.Seed, 0x5069636f4c756d65))
+	res := &Result{}
+	for k, s := range sel {
+		changed := false
+		if len(targets) > 0 {
+			if to := targets[k%len(targets)]; to != s.track {
+				if fits(tracks[to], s.clip, num(s.clip, "startTime")) {
+					setClips(tracks[s.track], without(clipsOf(tracks[s.track]), s.clip))
+					setClips(tracks[to], append(clipsOf(tracks[to]), s.clip))
+					s.track, changed = to, true
+				} else {
+					res.Skipped++
+				}
+			}
+		}
+
+		// Draw every random value even when unused, so each clip's
+		// variation does not depend on the other options.
+		hue := (rng.Float64()*2 - 1) * opts.HueShift
+		jitter := (rng.Float64()*2 - 1) * opts.Jitter
+
+		if opts.HueShift > 0 {
+			if props, _ := s.clip["props"].(map[string]any); props != nil {
+				for _, key := range colorProps {
+					if v, ok := props[key].(string); ok && v != "" {
+						if c, err := bingen.ParseColorValue(v); err == nil {
+							props[key] = fmt.Sprintf("#%06x", rotateHue(c, hue))
+							changed = true
+						}
+					}
+				}
+			}
+		}
+		if opts.Jitter > 0 {
+			start := math.Round(math.Max(0, num(s.clip, "startTime")+jitter))
+			if start != num(s.clip, "startTime") && fits(tracks[s.track], s.clip, start) {
+				s.clip["startTime"] = start
+				changed = true
+			}
+		}
+		if changed {
+			res.Varied++
+		}
+	}
+
+	out, err := json.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+	res.Project = out
+	return res, nil
+}
+
+func clipsOf(track map[string]any) []map[string]any {
+	raw, _ := track["clips"].([]any)
+	clips := make([]map[string]any, 0, len(raw))
+	for _, c := range raw {
+		if m, ok := c.(map[string]any); ok {
+			clips = append(clips, m)
+		}
+	}
+	return clips
+}
+
+func setClips(track map[string]any, clips []map[string]any) {
+	raw := make([]any, len(clips))
+	for i, c := range clips {
+		raw[i] = c
+	}
+	track["clips"] = raw
+}
+
+func without(clips []map[string]any, clip map[string]any) []map[string]any {
+	out := clips[:0]
+	for _, c := range clips {
+		if !same(c, clip) {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// same reports whether a and b are the same clip object.
+func same(a, b map[string]any) bool {
+	return reflect.ValueOf(a).Pointer() == reflect.ValueOf(b).Pointer()
+}
+
+func num(m map[string]any, key string) float64 {
+	v, _ := m[key].(float64)
+	return v
+}
+
+// groupTrack is the index of the first LED track playing group, or -1.
+func groupTrack(tracks []map[string]any, group string) int {
+	for i, t := range tracks {
+		if t["type"] == "led" && t["groupId"] == group {
+			return i
+		}
+	}
+	return -1
+}
+
+// fits reports whether clip could start at start on track without
+// overlapping any other clip there.
+func fits(track map[string]any, clip map[string]any, start float64) bool {
+	end := start + num(clip, "duration")
+	for _, c := range clipsOf(track) {
+		if same(c, clip) {
+			continue
+		}
+		cs := num(c, "startTime")
+		if start < cs+num(c, "duration") && cs < end {
+			return false
+		}
+	}
+	return true
+}
+
+// rotateHue turns the hue of 0xRRGGBB c by deg degrees, keeping its
+// saturation and value.
+func rotateHue(c uint32, deg float64) uint32 {
+	r, g, b := float64(c>>16&0xFF)/255, float64(c>>8&0xFF)/255, float64(c&0xFF)/255
+	hi, lo := math.Max(r, math.Max(g, b)), math.Min(r, math.Min(g, b))
+	if hi == lo {
+		return c // grey has no hue
+	}
+	d := hi - lo
+	var h float64
+	switch hi {
+	case r:
+		h = math.Mod((g-b)/d, 6)
+	case g:
+		h = (b-r)/d + 2
+	default:
+		h = (r-g)/d + 4
+	}
+	h = math.Mod(h*60+deg+720, 360)
+
+	x := d * (1 - math.Abs(math.Mod(h/60, 2)-1))
+	var rr, gg, bb float64
+	switch {
+	case h < 60:
+		rr, gg = d, x
+	case h < 120:
+		rr, gg = x, d
+	case h < 180:
+		gg, bb = d, x
+	case h < 240:
+		gg, bb = x, d
+	case h < 300:
+		rr, bb = x, d
+	default:
+		rr, bb = d, x
+	}
+	ch := func(v float64) uint32 { return uint32(math.Round((v + lo) * 255)) }
+	return ch(rr)<<16 | ch(gg)<<8 | ch(bb)
+}
@@ -0,0 +1,117 @@
+package variation
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+const project = `{
+  "settings": {"showDuration": 10000},
+  "propGroups": [{"id": "left", "ids": "1-4"}, {"id": "right", "ids": "5-8"}],
+  "tracks": [
+    {"id": "t1", "type": "led", "groupId": "left", "label": "Left", "clips": [
+      {"id": "a", "startTime": 0, "duration": 1000, "type": "solid", "props": {"color": "#ff0000", "colorPaletteIdx": 2}},
+      {"id": "b", "startTime": 1000, "duration": 1000, "type": "solid", "props": {"color": "#ff0000"}},
+      {"id": "c", "startTime": 2000, "duration": 1000, "type": "solid", "props": {"color": "#808080"}},
+      {"id": "d", "startTime": 3000, "duration": 1000, "type": "solid", "props": {"color": "#ff0000"}}
+    ]},
+    {"id": "t2", "type": "led", "groupId": "right", "clips": []}
+  ]
+}`
+
+type clip struct {
+	ID        string         `json:"id"`
+	StartTime float64        `json:"startTime"`
+	Props     map[string]any `json:"props"`
+}
+
+func tracksOf(t *testing.T, r *Result) [][]clip {
+	t.Helper()
+	var p struct {
+		Tracks []struct {
+			Label string `json:"label"`
+			Clips []clip `json:"clips"`
+		} `json:"tracks"`
+	}
+	if err := json.Unmarshal(r.Project, &p); err != nil {
+		t.Fatal(err)
+	}
+	if p.Tracks[0].Label != "Left" {
+		t.Error("editor fields of tracks were lost")
+	}
+	var out [][]clip
+	for _, tr := range p.Tracks {
+		out = append(out, tr.Clips)
+	}
+	return out
+}
+
+func TestApplyIsSeeded(t *testing.T) {
+	opts := Options{ClipIDs: []string{"a", "b", "c", "d"}, Seed: 7, HueShift: 60, Jitter: 400}
+	first, err := Apply([]byte(project), opts)
+	if err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+	again, _ := Apply([]byte(project), opts)
+	if string(first.Project) != string(again.Project) {
+		t.Error("the same seed gave different results")
+	}
+	opts.Seed = 8
+	if other, _ := Apply([]byte(project), opts); string(other.Project) == string(first.Project) {
+		t.Error("a different seed gave the same result")
+	}
+
+	clips := tracksOf(t, first)[0]
+	for i, c := range clips {
+		if i > 0 && c.StartTime < clips[i-1].StartTime+1000 {
+			t.Errorf("clip %s at %v overlaps %s at %v", c.ID, c.StartTime, clips[i-1].ID, clips[i-1].StartTime)
+		}
+	}
+	if clips[0].Props["colorPaletteIdx"] != 2.0 {
+		t.Error("editor fields of clips were lost")
+	}
+	if clips[2].Props["color"] != "#808080" {
+		t.Errorf("grey became %v; it has no hue to shift", clips[2].Props["color"])
+	}
+	if clips[0].Props["color"] == "#ff0000" || clips[0].Props["color"] == clips[1].Props["color"] {
+		t.Errorf("colors = %v, %v; want distinct hue shifts", clips[0].Props["color"], clips[1].Props["color"])
+	}
+}
+
+func TestApplyAlternatesGroups(t *testing.T) {
+	r, err := Apply([]byte(project), Options{ClipIDs: []string{"a", "b", "c", "d"}, Groups: []string{"left", "right"}})
+	if err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+	tracks := tracksOf(t, r)
+	if len(tracks[0]) != 2 || tracks[0][0].ID != "a" || tracks[0][1].ID != "c" || len(tracks[1]) != 2 || tracks[1][0].ID != "b" {
+		t.Errorf("tracks = %+v, want a, c on left and b, d on right", tracks)
+	}
+	if r.Varied != 2 {
+		t.Errorf("Varied = %d, want 2", r.Varied)
+	}
+
+	if _, err := Apply([]byte(project), Options{ClipIDs: []string{"a"}, Groups: []string{"stage"}}); err == nil {
+		t.Error("a group without a track should fail")
+	}
+	if _, err := Apply([]byte(project), Options{ClipIDs: []string{"zz"}}); err == nil {
+		t.Error("unknown clip IDs should fail")
+	}
+}
+
+func TestRotateHue(t *testing.T) {
+	tests := []struct {
+		in, want uint32
+		deg      float64
+	}{
+		{0xFF0000, 0x00FF00, 120},
+		{0xFF0000, 0x0000FF, -120},
+		{0x800000, 0x008080, 180},
+		{0x808080, 0x808080, 90},
+	}
+	for _, tt := range tests {
+		if got := rotateHue(tt.in, tt.deg); got != tt.want {
+			t.Errorf("rotateHue(%06x, %v) = %06x, want %06x", tt.in, tt.deg, got, tt.want)
+		}
+	}
+}
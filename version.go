@@ -0,0 +1,45 @@
+package main
+
+import (
+	_ "embed"
+
+	"PicoLume/bingen"
+)
+
+// Build information. These are overridden at build time, e.g.
+//
+//	wails build -ldflags "-X main.AppVersion=0.3.0 -X main.GitCommit=$(git rev-parse --short HEAD) -X main.BuildDate=$(date -u +%Y-%m-%dT%H:%M:%SZ)"
+var (
+	AppVersion = "0.2.4"
+	GitCommit  = "dev"
+	BuildDate  = ""
+)
+
+//go:embed LICENSE.txt
+var licenseText string
+
+// AppInfo is returned by GetAppInfo for the About dialog.
+type AppInfo struct {
+	Name            string `json:"name"`
+	Version         string `json:"version"`
+	GitCommit       string `json:"gitCommit"`
+	BuildDate       string `json:"buildDate"`
+	ShowFormat      int    `json:"showFormat"`      // show.bin header version written
+	CueBlockVersion int    `json:"cueBlockVersion"` // CUE1 trailer version written
+	License         string `json:"license"`         // short name
+	LicenseText     string `json:"licenseText"`     // full text
+}
+
+// GetAppInfo reports the version and build details compiled into the binary.
+func (a *App) GetAppInfo() AppInfo {
+	return AppInfo{
+		Name:            "PicoLume Studio",
+		Version:         AppVersion,
+		GitCommit:       GitCommit,
+		BuildDate:       BuildDate,
+		ShowFormat:      bingen.FormatVersion,
+		CueBlockVersion: bingen.CueBlockVersion,
+		License:         "GPL-3.0",
+		LicenseText:     licenseText,
+	}
+}
@@ -6,6 +6,7 @@ package main
 
 import (
 	"encoding/base64"
+	"encoding/json"
 	"syscall/js"
 
 	"PicoLume/bingen"
@@ -61,11 +62,67 @@ func generateBinaryBase64(this js.Value, args []js.Value) interface{} {
 	}
 }
 
+// chunkWriter hands each chunk written to it to a JavaScript callback as
+// a Uint8Array.
+type chunkWriter struct {
+	onChunk js.Value
+}
+
+func (w chunkWriter) Write(p []byte) (int, error) {
+	chunk := js.Global().Get("Uint8Array").New(len(p))
+	js.CopyBytesToJS(chunk, p)
+	w.onChunk.Invoke(chunk)
+	return len(p), nil
+}
+
+// generateBinaryStream streams show.bin to a callback instead of returning
+// it whole, so large shows never need the file in Go and JavaScript memory
+// at once. Takes project JSON and onChunk(Uint8Array), called in file
+// order; returns { size: number, eventCount: number } or { error: string }.
+func generateBinaryStream(this js.Value, args []js.Value) interface{} {
+	if len(args) < 2 || args[1].Type() != js.TypeFunction {
+		return map[string]interface{}{
+			"error": "expected project JSON and a chunk callback",
+		}
+	}
+
+	var p bingen.Project
+	if err := json.Unmarshal([]byte(args[0].String()), &p); err != nil {
+		return map[string]interface{}{
+			"error": "failed to parse project JSON: " + err.Error(),
+		}
+	}
+	result, err := bingen.GenerateTo(chunkWriter{onChunk: args[1]}, &p)
+	if err != nil {
+		return map[string]interface{}{
+			"error": err.Error(),
+		}
+	}
+
+	return map[string]interface{}{
+		"size":       result.Size,
+		"eventCount": result.EventCount,
+	}
+}
+
+// getEffectSchemas returns bingen.EffectSchemas() as a JSON string.
+func getEffectSchemas(this js.Value, args []js.Value) interface{} {
+	data, err := json.Marshal(bingen.EffectSchemas())
+	if err != nil {
+		return map[string]interface{}{
+			"error": err.Error(),
+		}
+	}
+	return string(data)
+}
+
 func main() {
 	// Register functions on the global picolume namespace
 	picolume := js.Global().Get("Object").New()
 	picolume.Set("generateBinaryBytes", js.FuncOf(generateBinaryBytes))
 	picolume.Set("generateBinaryBase64", js.FuncOf(generateBinaryBase64))
+	picolume.Set("generateBinaryStream", js.FuncOf(generateBinaryStream))
+	picolume.Set("getEffectSchemas", js.FuncOf(getEffectSchemas))
 	js.Global().Set("picolume", picolume)
 
 	// Keep the Go runtime alive
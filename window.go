@@ -0,0 +1,122 @@
+package main
+
+import (
+	"PicoLume/logger"
+	"PicoLume/settings"
+
+	"github.com/wailsapp/wails/v2/pkg/options"
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// ==========================================================
+// WINDOW PLACEMENT
+// ==========================================================
+
+// workArea is a monitor's usable area in virtual-screen coordinates.
+type workArea struct {
+	Left, Top, Right, Bottom int
+	Primary                  bool
+}
+
+func (w workArea) contains(x, y int) bool {
+	return x >= w.Left && x < w.Right && y >= w.Top && y < w.Bottom
+}
+
+// MonitorInfo describes a display for the window placement setting.
+type MonitorInfo struct {
+	Index   int  `json:"index"` // value for settings.Window.Monitor
+	Width   int  `json:"width"` // work area, excluding the taskbar
+	Height  int  `json:"height"`
+	Primary bool `json:"primary"`
+	Current bool `json:"current"` // holds the Studio window
+}
+
+// GetMonitors lists the connected displays in the order used by the monitor
+// setting (primary first, then left to right). It is empty where choosing a
+// monitor is not supported.
+func (a *App) GetMonitors() []MonitorInfo {
+	areas, err := monitorWorkAreas()
+	if err != nil {
+		logger.Warn("GetMonitors: %v", err)
+		return []MonitorInfo{}
+	}
+	x, y := runtime.WindowGetPosition(a.ctx)
+	monitors := make([]MonitorInfo, len(areas))
+	for i, area := range areas {
+		monitors[i] = MonitorInfo{
+			Index:   i + 1,
+			Width:   area.Right - area.Left,
+			Height:  area.Bottom - area.Top,
+			Primary: area.Primary,
+			Current: area.contains(x, y),
+		}
+	}
+	return monitors
+}
+
+// windowStartState picks the initial state before the frontend loads. Kiosk
+// mode on a specific monitor goes fullscreen only after placeWindow has moved
+// the window, otherwise it would fill the wrong display.
+func windowStartState(w settings.Window) options.WindowStartState {
+	if w.Kiosk && w.Monitor == 0 {
+		return options.Fullscreen
+	}
+	return options.Maximised
+}
+
+// placeWindow applies the window settings to the running main window.
+func (a *App) placeWindow(w settings.Window) {
+	ctx := a.ctx
+	if ctx == nil {
+		return
+	}
+
+	if w.Monitor > 0 {
+		if err := moveToMonitor(a, w.Monitor); err != nil {
+			logger.Warn("Window placement: %v", err)
+		}
+	}
+
+	if w.Kiosk {
+		runtime.WindowFullscreen(ctx)
+	} else if runtime.WindowIsFullscreen(ctx) {
+		runtime.WindowUnfullscreen(ctx)
+		runtime.WindowMaximise(ctx)
+	}
+	runtime.WindowSetAlwaysOnTop(ctx, w.AlwaysOnTop)
+}
+
+// moveToMonitor maximises the window on the index'th monitor (1-based,
+// primary first).
+func moveToMonitor(a *App, index int) error {
+	areas, err := monitorWorkAreas()
+	if err != nil {
+		return err
+	}
+	if index > len(areas) {
+		logger.Warn("Window placement: monitor %d not connected (%d found); using the primary display", index, len(areas))
+		index = 1
+	}
+	target := areas[index-1]
+
+	ctx := a.ctx
+	// Leave fullscreen/maximised first; otherwise the OS keeps the window
+	// pinned to its current display.
+	runtime.WindowUnfullscreen(ctx)
+	runtime.WindowUnmaximise(ctx)
+
+	// WindowSetPosition is relative to the work area of the monitor the
+	// window is currently on, so translate from absolute coordinates.
+	x, y := runtime.WindowGetPosition(ctx)
+	current := areas[0]
+	for _, area := range areas {
+		if area.contains(x, y) {
+			current = area
+			break
+		}
+	}
+	runtime.WindowSetPosition(ctx, target.Left-current.Left, target.Top-current.Top)
+	runtime.WindowMaximise(ctx)
+	logger.Info("Window placement: moved to monitor %d", index)
+	return nil
+}
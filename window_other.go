@@ -0,0 +1,9 @@
+//go:build !windows
+
+package main
+
+import "errors"
+
+func monitorWorkAreas() ([]workArea, error) {
+	return nil, errors.New("choosing a monitor is only supported on Windows")
+}
@@ -0,0 +1,78 @@
+//go:build windows
+
+package main
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+var (
+	procEnumDisplayMonitors = user32.NewProc("EnumDisplayMonitors")
+	procGetMonitorInfoW     = user32.NewProc("GetMonitorInfoW")
+)
+
+const monitorInfoFPrimary = 0x1
+
+type rect struct{ Left, Top, Right, Bottom int32 }
+
+type monitorInfo struct {
+	Size    uint32
+	Monitor rect
+	Work    rect
+	Flags   uint32
+}
+
+// enumAreas collects work areas for monitorEnumProc during one
+// EnumDisplayMonitors call; enumMu serializes those calls.
+var (
+	enumMu    sync.Mutex
+	enumAreas []workArea
+)
+
+// monitorEnumProc is created once: Windows callbacks are never freed and
+// only about 2000 can exist, so one per call would eventually panic.
+var monitorEnumProc = windows.NewCallback(func(hMonitor, hdc, clip, data uintptr) uintptr {
+	mi := monitorInfo{Size: uint32(unsafe.Sizeof(monitorInfo{}))}
+	if r, _, _ := procGetMonitorInfoW.Call(hMonitor, uintptr(unsafe.Pointer(&mi))); r != 0 {
+		enumAreas = append(enumAreas, workArea{
+			Left:    int(mi.Work.Left),
+			Top:     int(mi.Work.Top),
+			Right:   int(mi.Work.Right),
+			Bottom:  int(mi.Work.Bottom),
+			Primary: mi.Flags&monitorInfoFPrimary != 0,
+		})
+	}
+	return 1
+})
+
+// monitorWorkAreas lists every display's work area, primary first and the
+// rest left to right.
+func monitorWorkAreas() ([]workArea, error) {
+	enumMu.Lock()
+	enumAreas = nil
+	r, _, err := procEnumDisplayMonitors.Call(0, 0, monitorEnumProc, 0)
+	areas := enumAreas
+	enumAreas = nil
+	enumMu.Unlock()
+	if r == 0 {
+		return nil, err
+	}
+	if len(areas) == 0 {
+		return nil, errors.New("no monitors found")
+	}
+	sort.SliceStable(areas, func(i, j int) bool {
+		if areas[i].Primary != areas[j].Primary {
+			return areas[i].Primary
+		}
+		if areas[i].Left != areas[j].Left {
+			return areas[i].Left < areas[j].Left
+		}
+		return areas[i].Top < areas[j].Top
+	})
+	return areas, nil
+}
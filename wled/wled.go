@@ -0,0 +1,299 @@
+// Package wled converts simple PicoLume tracks into WLED presets and
+// playlists, so WLED-driven set pieces can follow the same looks as the
+// wearable props. The result is a presets.json document that can be saved
+// to disk or pushed to a controller over HTTP.
+package wled
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"PicoLume/bingen"
+)
+
+// MaxPresetID is the highest preset slot WLED accepts.
+const MaxPresetID = 250
+
+// effectIDs maps PicoLume clip types to the closest built-in WLED effect.
+// Unknown types fall back to Solid.
+var effectIDs = map[string]int{
+	"solid":       0,
+	"flash":       1,  // Blink
+	"breathe":     2,  // Breathe
+	"wipe":        3,  // Wipe
+	"rainbow":     9,  // Rainbow
+	"rainbowHold": 8,  // Colorloop
+	"sparkle":     20, // Sparkle
+	"strobe":      23, // Strobe
+	"chase":       28, // Chase
+	"scanner":     40, // Scanner
+	"fire":        66, // Fire 2012
+	"meteor":      76, // Meteor
+	"heartbeat":   100,
+	"alternate":   7, // Dynamic
+}
+
+// Options controls the export.
+type Options struct {
+	// LEDCount is the segment length on the controller. Defaults to the
+	// project's ledCount setting.
+	LEDCount int
+	// FirstPresetID is the first slot to write into (default 1).
+	FirstPresetID int
+	// Brightness is the master brightness 1-255 (default project brightness).
+	Brightness int
+}
+
+// Segment is a WLED segment state.
+type Segment struct {
+	ID    int      `json:"id"`
+	Start int      `json:"start"`
+	Stop  int      `json:"stop"`
+	On    bool     `json:"on"`
+	Bri   int      `json:"bri"`
+	Fx    int      `json:"fx"`
+	Sx    int      `json:"sx"`
+	Ix    int      `json:"ix"`
+	Pal   int      `json:"pal"`
+	Col   [][3]int `json:"col"`
+}
+
+// Playlist is a WLED playlist definition. Durations are in tenths of a second.
+type Playlist struct {
+	PS         []int `json:"ps"`
+	Dur        []int `json:"dur"`
+	Transition []int `json:"transition"`
+	Repeat     int   `json:"repeat"`
+	End        int   `json:"end"`
+}
+
+// Preset is one entry of presets.json.
+type Preset struct {
+	Name       string    `json:"n"`
+	On         *bool     `json:"on,omitempty"`
+	Bri        int       `json:"bri,omitempty"`
+	Transition *int      `json:"transition,omitempty"`
+	MainSeg    *int      `json:"mainseg,omitempty"`
+	Seg        []Segment `json:"seg,omitempty"`
+	Playlist   *Playlist `json:"playlist,omitempty"`
+}
+
+// Presets is keyed by preset ID, as in WLED's presets.json.
+type Presets map[int]Preset
+
+// MarshalJSON writes presets in the layout WLED expects, including the empty
+// "0" entry and string keys in ascending order.
+func (ps Presets) MarshalJSON() ([]byte, error) {
+	ids := make([]int, 0, len(ps))
+	for id := range ps {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+
+	var buf bytes.Buffer
+	buf.WriteString(`{"0":{}`)
+	for _, id := range ids {
+		b, err := json.Marshal(ps[id])
+		if err != nil {
+			return nil, err
+		}
+		fmt.Fprintf(&buf, ",%q:", strconv.Itoa(id))
+		buf.Write(b)
+	}
+	buf.WriteString("}")
+	return buf.Bytes(), nil
+}
+
+// Export turns each LED track into a sequence of presets (one per clip plus
+// blackout presets for gaps) and a playlist that runs them in show order.
+func Export(p *bingen.Project, opts Options) (Presets, error) {
+	if p == nil {
+		return nil, fmt.Errorf("project is nil")
+	}
+	if opts.LEDCount <= 0 {
+		opts.LEDCount = int(p.Settings.LedCount)
+	}
+	if opts.LEDCount <= 0 {
+		opts.LEDCount = 30
+	}
+	if opts.FirstPresetID <= 0 {
+		opts.FirstPresetID = 1
+	}
+	if opts.Brightness <= 0 {
+		opts.Brightness = int(p.Settings.Brightness)
+	}
+	if opts.Brightness <= 0 || opts.Brightness > 255 {
+		opts.Brightness = 255
+	}
+
+	presets := make(Presets)
+	next := opts.FirstPresetID
+	alloc := func(preset Preset) (int, error) {
+		if next > MaxPresetID {
+			return 0, fmt.Errorf("show needs more than %d presets; simplify the exported tracks", MaxPresetID-opts.FirstPresetID+1)
+		}
+		id := next
+		presets[id] = preset
+		next++
+		return id, nil
+	}
+
+	on, off := true, false
+	zero, mainSeg := 0, 0
+	offID := 0
+
+	for ti, track := range p.Tracks {
+		if track.Type != "led" || len(track.Clips) == 0 {
+			continue
+		}
+		name := fmt.Sprintf("Track %d", ti+1)
+		if g := p.FindGroup(track.GroupId); g != nil && g.Name != "" {
+			name = g.Name
+		}
+
+		clips := make([]bingen.Clip, len(track.Clips))
+		copy(clips, track.Clips)
+		sort.SliceStable(clips, func(i, j int) bool { return clips[i].StartTime < clips[j].StartTime })
+
+		pl := &Playlist{Transition: []int{0}, Repeat: 1}
+		var lastEnd float64
+		for ci, clip := range clips {
+			if gap := clip.StartTime - lastEnd; gap > 0 {
+				if offID == 0 {
+					id, err := alloc(Preset{Name: "PicoLume Off", On: &off, Transition: &zero})
+					if err != nil {
+						return nil, err
+					}
+					offID = id
+				}
+				pl.PS = append(pl.PS, offID)
+				pl.Dur = append(pl.Dur, tenths(gap))
+			}
+
+			id, err := alloc(Preset{
+				Name:       fmt.Sprintf("%s %d %s", name, ci+1, clip.Type),
+				On:         &on,
+				Bri:        opts.Brightness,
+				Transition: &zero,
+				MainSeg:    &mainSeg,
+				Seg:        []Segment{clipSegment(clip, opts.LEDCount)},
+			})
+			if err != nil {
+				return nil, err
+			}
+			pl.PS = append(pl.PS, id)
+			pl.Dur = append(pl.Dur, tenths(clip.Duration))
+
+			if end := clip.StartTime + clip.Duration; end > lastEnd {
+				lastEnd = end
+			}
+		}
+		if offID != 0 {
+			pl.End = offID
+		}
+
+		if _, err := alloc(Preset{Name: name + " (PicoLume)", Playlist: pl}); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(presets) == 0 {
+		return nil, fmt.Errorf("project has no LED clips to export")
+	}
+	return presets, nil
+}
+
+func clipSegment(clip bingen.Clip, ledCount int) Segment {
+	fx, ok := effectIDs[clip.Type]
+	if !ok {
+		fx = 0
+	}
+	c1, c2 := clip.ColorHex()
+
+	speed := clip.Props.Speed
+	if speed <= 0 {
+		speed = 1.0
+	}
+	return Segment{
+		Start: 0,
+		Stop:  ledCount,
+		On:    true,
+		Bri:   255,
+		Fx:    fx,
+		Sx:    min(255, int(speed*128)),
+		Ix:    min(255, max(0, int(clip.Props.Width*255))),
+		Col:   [][3]int{rgb(bingen.ParseColor(c1)), rgb(bingen.ParseColor(c2)), {0, 0, 0}},
+	}
+}
+
+func rgb(c uint32) [3]int {
+	return [3]int{int(c>>16) & 0xFF, int(c>>8) & 0xFF, int(c) & 0xFF}
+}
+
+// tenths converts milliseconds to WLED playlist units, never returning 0
+// (which WLED treats as "stay on this preset forever").
+func tenths(ms float64) int {
+	t := int(ms/100 + 0.5)
+	if t < 1 {
+		t = 1
+	}
+	return t
+}
+
+// Upload replaces presets.json on the controller at host using WLED's file
+// upload endpoint.
+func Upload(ctx context.Context, host string, presets Presets) error {
+	host = strings.TrimSpace(host)
+	if host == "" {
+		return fmt.Errorf("WLED host cannot be empty")
+	}
+	if !strings.Contains(host, "://") {
+		host = "http://" + host
+	}
+	u, err := url.Parse(host)
+	if err != nil || u.Host == "" {
+		return fmt.Errorf("invalid WLED host %q", host)
+	}
+
+	data, err := json.Marshal(presets)
+	if err != nil {
+		return err
+	}
+
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	fw, err := mw.CreateFormFile("data", "/presets.json")
+	if err != nil {
+		return err
+	}
+	fw.Write(data)
+	mw.Close()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u.Scheme+"://"+u.Host+"/upload", &body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("WLED not reachable: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<16))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("WLED rejected presets: %s", resp.Status)
+	}
+	return nil
+}
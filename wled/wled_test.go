@@ -0,0 +1,141 @@
+package wled
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"PicoLume/bingen"
+)
+
+func project(t *testing.T, js string) *bingen.Project {
+	t.Helper()
+	var p bingen.Project
+	if err := json.Unmarshal([]byte(js), &p); err != nil {
+		t.Fatal(err)
+	}
+	return &p
+}
+
+func TestTenths(t *testing.T) {
+	tests := []struct {
+		ms   float64
+		want int
+	}{
+		{0, 1},
+		{20, 1},
+		{149, 1},
+		{150, 2},
+		{1000, 10},
+		{61234, 612},
+	}
+	for _, tt := range tests {
+		if got := tenths(tt.ms); got != tt.want {
+			t.Errorf("tenths(%v) = %d, want %d", tt.ms, got, tt.want)
+		}
+	}
+}
+
+func TestPresetsMarshalJSON(t *testing.T) {
+	ps := Presets{10: {Name: "ten"}, 2: {Name: "two"}}
+	got, err := json.Marshal(ps)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"0":{},"2":{"n":"two"},"10":{"n":"ten"}}`; string(got) != want {
+		t.Errorf("Marshal = %s, want %s", got, want)
+	}
+}
+
+func TestExport(t *testing.T) {
+	p := project(t, `{"settings": {"ledCount": 60, "brightness": 128},
+		"propGroups": [{"id": "g1", "name": "Arch", "ids": "1"}],
+		"tracks": [{"type": "led", "groupId": "g1", "clips": [
+			{"startTime": 1000, "duration": 500, "type": "strobe", "props": {"color": "#FF8000", "speed": 0.5}},
+			{"startTime": 0, "duration": 1000, "type": "unknown", "props": {"color": "#0000FF", "width": 2}}]},
+			{"type": "audio", "clips": [{"startTime": 0, "duration": 9000}]},
+			{"type": "led", "groupId": "g1", "clips": [
+			{"startTime": 2000, "duration": 1000, "type": "solid", "props": {"color": "#FFFFFF"}}]}]}`)
+
+	got, err := Export(p, Options{FirstPresetID: 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := map[int]string{}
+	for id, ps := range got {
+		names[id] = ps.Name
+	}
+	wantNames := map[int]string{5: "Arch 1 unknown", 6: "Arch 2 strobe", 7: "Arch (PicoLume)", 8: "PicoLume Off", 9: "Arch 1 solid", 10: "Arch (PicoLume)"}
+	if !reflect.DeepEqual(names, wantNames) {
+		t.Fatalf("presets %v, want %v", names, wantNames)
+	}
+
+	first := got[5]
+	if first.Bri != 128 || len(first.Seg) != 1 {
+		t.Fatalf("preset 5 = %+v", first)
+	}
+	seg := first.Seg[0]
+	if seg.Stop != 60 || seg.Fx != 0 || seg.Sx != 128 || seg.Ix != 255 || seg.Col[0] != [3]int{0, 0, 255} {
+		t.Errorf("unknown clip segment = %+v", seg)
+	}
+	if seg := got[6].Seg[0]; seg.Fx != 23 || seg.Sx != 64 || seg.Col[0] != [3]int{255, 128, 0} {
+		t.Errorf("strobe segment = %+v", seg)
+	}
+
+	if pl := got[7].Playlist; pl == nil || !reflect.DeepEqual(pl.PS, []int{5, 6}) || !reflect.DeepEqual(pl.Dur, []int{10, 5}) || pl.End != 0 {
+		t.Errorf("first playlist = %+v", pl)
+	}
+	if pl := got[10].Playlist; pl == nil || !reflect.DeepEqual(pl.PS, []int{8, 9}) || !reflect.DeepEqual(pl.Dur, []int{20, 10}) || pl.End != 8 {
+		t.Errorf("second playlist = %+v, want the gap blacked out", pl)
+	}
+}
+
+func TestExportErrors(t *testing.T) {
+	if _, err := Export(project(t, `{"tracks": [{"type": "audio", "clips": [{"duration": 100}]}]}`), Options{}); err == nil {
+		t.Error("Export() without LED clips succeeded")
+	}
+
+	var clips []string
+	for i := 0; i < 10; i++ {
+		clips = append(clips, `{"startTime": `+strings.Repeat("1", i+1)+`, "duration": 1, "type": "solid"}`)
+	}
+	p := project(t, `{"tracks": [{"type": "led", "clips": [`+strings.Join(clips, ",")+`]}]}`)
+	if _, err := Export(p, Options{FirstPresetID: MaxPresetID - 5}); err == nil || !strings.Contains(err.Error(), "more than 6 presets") {
+		t.Errorf("Export() past MaxPresetID error = %v", err)
+	}
+}
+
+func TestUpload(t *testing.T) {
+	var file, path string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		f, hdr, err := r.FormFile("data")
+		if err != nil {
+			t.Errorf("no data file: %v", err)
+			w.WriteHeader(400)
+			return
+		}
+		b, _ := io.ReadAll(f)
+		file = hdr.Header.Get("Content-Disposition") + " " + string(b)
+	}))
+	defer srv.Close()
+
+	host := strings.TrimPrefix(srv.URL, "http://")
+	if err := Upload(context.Background(), host+"/ignored", Presets{1: {Name: "a"}}); err != nil {
+		t.Fatal(err)
+	}
+	if path != "/upload" || file != `form-data; name="data"; filename="/presets.json" {"0":{},"1":{"n":"a"}}` {
+		t.Errorf("upload to %s: %s", path, file)
+	}
+
+	for _, host := range []string{"", "http://"} {
+		if err := Upload(context.Background(), host, Presets{}); err == nil {
+			t.Errorf("Upload(%q) succeeded", host)
+		}
+	}
+}